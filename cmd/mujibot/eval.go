@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/HaohanHe/mujibot/internal/eval"
+	"github.com/HaohanHe/mujibot/internal/gateway"
+)
+
+// runEval 执行 mujibot eval：在配置的提供商和智能体上运行评测套件，全部通过时返回 0，有失败时返回 1，出错时返回 2
+func runEval(args []string) int {
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	configPath := fs.String("config", "./config.json5", "Path to configuration file")
+	suitePath := fs.String("suite", "./eval.yaml", "Path to the eval suite (YAML or JSON)")
+	only := fs.String("targets", "", "Comma-separated target names to run (default: all)")
+	runs := fs.Int("runs", 0, "Runs per case, overrides the suite's runs")
+	reportPath := fs.String("report", "", "Write the full report as JSON to this file")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: mujibot eval [options] [suite]\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		*suitePath = fs.Arg(0)
+	}
+
+	suite, err := eval.LoadSuite(*suitePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load suite: %v\n", err)
+		return 2
+	}
+	if *runs > 0 {
+		suite.Runs = *runs
+	}
+	if *only != "" {
+		specs, err := selectTargets(suite.Targets, strings.Split(*only, ","))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		suite.Targets = specs
+	}
+
+	gw, err := gateway.NewGateway(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create gateway: %v\n", err)
+		return 2
+	}
+	targets, err := gw.EvalTargets(suite.Targets)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create eval targets: %v\n", err)
+		return 2
+	}
+
+	fmt.Printf("Suite: %s (%d cases, %d targets, %d runs each)\n\n", suite.Name, len(suite.Cases), len(targets), suite.Runs)
+	report := eval.Run(suite, targets, func(r eval.Result) {
+		fmt.Println(eval.FormatResult(r))
+	})
+	fmt.Println()
+	report.WriteSummary(os.Stdout)
+
+	if *reportPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err == nil {
+			err = os.WriteFile(*reportPath, data, 0644)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+			return 2
+		}
+	}
+
+	if report.Failed() {
+		return 1
+	}
+	return 0
+}
+
+// selectTargets 按名称筛选套件中的目标
+func selectTargets(specs []eval.TargetSpec, names []string) ([]eval.TargetSpec, error) {
+	byName := make(map[string]eval.TargetSpec, len(specs))
+	for _, s := range specs {
+		byName[s.Name] = s
+	}
+	var result []eval.TargetSpec
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		s, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("target not found in suite: %s", name)
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
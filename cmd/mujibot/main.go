@@ -2,13 +2,17 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/encryption"
 	"github.com/HaohanHe/mujibot/internal/gateway"
 	"github.com/HaohanHe/mujibot/internal/i18n"
 	"github.com/HaohanHe/mujibot/internal/logger"
@@ -20,11 +24,17 @@ const (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "eval" {
+		os.Exit(runEval(os.Args[2:]))
+	}
+
 	var (
 		configPath  = flag.String("config", "./config.json5", "Path to configuration file")
 		showVersion = flag.Bool("version", false, "Show version information")
 		showHelp    = flag.Bool("help", false, "Show help information")
 		skipSetup   = flag.Bool("skip-setup", false, "Skip initial setup wizard")
+		genKey      = flag.Bool("gen-key", false, "Generate an encryption key for encryption at rest")
+		jsonOutput  = flag.Bool("json", false, "Emit startup events as JSON lines on stdout, logs go to stderr")
 	)
 	flag.Parse()
 
@@ -38,13 +48,27 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *genKey {
+		key, err := encryption.GenerateKey()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to generate key: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(key)
+		os.Exit(0)
+	}
+
+	if *jsonOutput {
+		os.Exit(runJSON(*configPath))
+	}
+
 	fmt.Printf("%s v%s\n", appName, version)
 	fmt.Println(strings.Repeat("=", 40))
 
 	if !*skipSetup {
 		if err := checkAndRunSetup(*configPath); err != nil {
 			fmt.Fprintf(os.Stderr, "Setup failed: %v\n", err)
-			os.Exit(1)
+			os.Exit(gateway.ExitSetup)
 		}
 	}
 
@@ -53,13 +77,45 @@ func main() {
 	gw, err := gateway.NewGateway(*configPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to create gateway: %v\n", err)
-		os.Exit(1)
+		os.Exit(gateway.ExitCode(err))
 	}
 
 	if err := gw.Start(); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to start gateway: %v\n", err)
-		os.Exit(1)
+		os.Exit(gateway.ExitCode(err))
+	}
+}
+
+// runJSON 以 --json 模式运行：不显示横幅和初始化向导，标准输出每行一个生命周期事件，
+// 失败时输出 error 事件并按失败的组件返回退出码
+func runJSON(configPath string) int {
+	var mu sync.Mutex
+	enc := json.NewEncoder(os.Stdout)
+	emit := func(e gateway.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		enc.Encode(e)
+	}
+	fail := func(err error) int {
+		code := gateway.ExitCode(err)
+		emit(gateway.Event{
+			Event:     gateway.EventError,
+			Time:      time.Now(),
+			Component: gateway.ErrorComponent(err),
+			Code:      code,
+			Error:     err.Error(),
+		})
+		return code
+	}
+
+	gw, err := gateway.NewGatewayWithOptions(configPath, gateway.Options{OnEvent: emit, LogStderr: true})
+	if err != nil {
+		return fail(err)
 	}
+	if err := gw.Start(); err != nil {
+		return fail(err)
+	}
+	return 0
 }
 
 func checkAndRunSetup(configPath string) error {
@@ -106,7 +162,7 @@ func runSetupWizard(configPath string) error {
 
 	var choice int
 	for {
-		fmt.Print("Enter [1-3]: ")
+		fmt.Printf("Enter [1-%d]: ", len(languages))
 		input, _ := reader.ReadString('\n')
 		input = strings.TrimSpace(input)
 
@@ -157,7 +213,14 @@ func createInitialConfig(configPath, language string) error {
 	configContent := fmt.Sprintf(`{
   "server": {
     "port": 8080,
-    "healthCheck": true
+    "healthCheck": true,
+    "adminToken": "",
+    "debugLog": {
+      "enabled": false,
+      "file": "./debug_messages.jsonl",
+      "maxEntries": 10000
+    },
+    "webRoot": ""
   },
   "channels": {
     "telegram": {
@@ -189,7 +252,8 @@ func createInitialConfig(configPath, language string) error {
   "language": {
     "default": "%s",
     "current": "%s",
-    "supported": ["en-US", "zh-CN", "ja-JP"]
+    "supported": ["en-US", "zh-CN", "ja-JP", "ko-KR", "de-DE", "fr-FR", "es-ES", "ru-RU"],
+    "autoDetect": true
   },
   "agents": {
     "default": {
@@ -200,22 +264,42 @@ func createInitialConfig(configPath, language string) error {
   },
   "tools": {
     "workDir": "/tmp/mujibot",
+    "workspaces": [],
+    "perUserWorkDir": false,
+    "sharedDir": "",
     "timeout": 30,
     "confirmDangerous": true,
     "allowedCommands": [],
     "blockedCommands": ["reboot", "shutdown", "init", "poweroff", "halt"],
+    "quotas": {
+      "maxCallsPerMessage": 20,
+      "maxCommandsPerHour": 60,
+      "maxHTTPRequestsPerDay": 500
+    },
     "enabledTools": {
       "read_file": true,
       "write_file": true,
       "list_directory": true,
+      "move_file": true,
+      "copy_file": true,
+      "delete_file": true,
+      "make_directory": true,
+      "stat": true,
+      "archive": true,
+      "undo_edit": true,
       "execute_command": true,
+      "processes": true,
       "web_search": true,
       "http_request": true,
+      "read_feed": true,
+      "download_file": true,
       "weather": true,
       "ip_info": true,
       "exchange_rate": true,
+      "datetime": true,
       "memory_read": true,
-      "memory_write": true
+      "memory_write": true,
+      "memory_search": true
     },
     "customAPIs": []
   },
@@ -256,12 +340,15 @@ func printHelp() {
 	fmt.Printf(`%s - Lightweight AI Assistant Gateway
 
 Usage: mujibot [options]
+       mujibot eval [--config path] [--targets a,b] [--runs n] [--report file] [suite]
 
 Options:
   --config string    Path to configuration file (default "./config.json5")
   --version          Show version information
   --help             Show this help message
   --skip-setup       Skip initial setup wizard
+  --gen-key          Print a new encryption key (for encryption.keyFile or MUJIBOT_ENCRYPTION_KEY)
+  --json             Print startup events as JSON lines for supervisors (implies --skip-setup)
 
 Environment Variables:
   TELEGRAM_BOT_TOKEN    Telegram Bot API token
@@ -270,11 +357,14 @@ Environment Variables:
   FEISHU_APP_SECRET     Feishu App Secret
   OPENAI_API_KEY        OpenAI API key
   ANTHROPIC_API_KEY     Anthropic API key
+  MUJIBOT_ENCRYPTION_KEY  Encryption key for memory files and shared sessions
 
 Examples:
   mujibot                          # Start with setup wizard
   mujibot --skip-setup             # Skip setup wizard
   mujibot --config /etc/mujibot/config.json5
+  mujibot --json 2>>mujibot.log    # Events on stdout, logs on stderr
+  mujibot eval eval.yaml           # Run an eval suite against the configured models
 
 Documentation: https://github.com/HaohanHe/mujibot
 `, appName)
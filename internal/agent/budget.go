@@ -0,0 +1,133 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// defaultContextWindow llm.contextWindow 未配置时假定的模型上下文窗口（token）
+	defaultContextWindow = 8192
+	// defaultPromptBudgetPercent llm.promptBudgetPercent 未配置时系统提示词可占用的比例
+	defaultPromptBudgetPercent = 30
+)
+
+// PromptBudget 系统提示词预算：最多占用模型上下文窗口的 Percent%
+type PromptBudget struct {
+	ContextWindow int // 模型上下文窗口（token），<=0 时使用默认值
+	Percent       int // 系统提示词最多占用的百分比，<=0 时使用默认值，>=100 时不限制
+}
+
+// MaxTokens 系统提示词的token上限，0 表示不限制
+func (b PromptBudget) MaxTokens() int {
+	window, percent := b.ContextWindow, b.Percent
+	if window <= 0 {
+		window = defaultContextWindow
+	}
+	if percent <= 0 {
+		percent = defaultPromptBudgetPercent
+	}
+	if percent >= 100 {
+		return 0
+	}
+	return window * percent / 100
+}
+
+// SetPromptBudget 设置系统提示词预算的来源，每次构建提示词时读取，配置热更新后立即生效
+func (a *Agent) SetPromptBudget(fn func() PromptBudget) {
+	a.budget = fn
+}
+
+// EstimateTokens 粗略估算文本的token数，与提供商无关：ASCII 约4个字符一个token，
+// 其他字符（中日韩文字等）按每个字符一个token计
+func EstimateTokens(s string) int {
+	ascii, other := 0, 0
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// promptParts 系统提示词的各片段，按拼接顺序排列
+type promptParts struct {
+	base, tools, rules, memory, env, style, profile, recap, pinned string
+}
+
+func (p promptParts) String() string {
+	return p.base + p.tools + p.rules + p.memory + p.env + p.style + p.profile + p.recap + p.pinned
+}
+
+// fitBudget 系统提示词超出预算时依次去掉记忆上下文、本次不可用工具的说明、上次对话摘要，最后把工具说明缩减为名称列表，
+// 仍然超出时只记录警告。工具定义本身仍随请求发送，不影响调用
+func (a *Agent) fitBudget(parts promptParts, data PromptData) string {
+	prompt := parts.String()
+	if a.budget == nil {
+		return prompt
+	}
+	max := a.budget().MaxTokens()
+	tokens := EstimateTokens(prompt)
+	a.log.Debug("system prompt size", "agent", a.ID, "tokens", tokens, "budget", max)
+	if max <= 0 || tokens <= max {
+		return prompt
+	}
+
+	var dropped []string
+	steps := []struct {
+		name  string
+		apply func()
+	}{
+		{"memory", func() { parts.memory = "" }},
+		{"unavailable_tools", func() { parts.tools = a.buildToolsSection(data.Lang, data.allowed, false) }},
+		{"recap", func() { parts.recap = "" }},
+		{"tool_descriptions", func() { parts.tools = a.buildToolsSection(data.Lang, data.allowed, true) }},
+	}
+	for _, step := range steps {
+		if EstimateTokens(parts.String()) <= max {
+			break
+		}
+		before := parts.String()
+		step.apply()
+		if parts.String() != before {
+			dropped = append(dropped, step.name)
+		}
+	}
+
+	prompt = parts.String()
+	trimmed := EstimateTokens(prompt)
+	if trimmed > max {
+		a.log.Warn("system prompt exceeds budget after trimming", "agent", a.ID, "tokens", tokens, "trimmed", trimmed, "budget", max, "dropped", dropped)
+	} else {
+		a.log.Warn("system prompt trimmed to fit budget", "agent", a.ID, "tokens", tokens, "trimmed", trimmed, "budget", max, "dropped", dropped)
+	}
+	return prompt
+}
+
+// buildToolsSection 构建工具说明，allowed 非nil时只列出其中的工具，compact 为 true 时只列出名称
+func (a *Agent) buildToolsSection(lang string, allowed map[string]bool, compact bool) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("\n\n## %s\n\n", a.tr(lang, "availableTools")))
+	sb.WriteString(a.tr(lang, "toolsIntro") + "\n")
+
+	var names []string
+	for _, tool := range a.ToolManager.GetAll() {
+		if allowed != nil && !allowed[tool.Name()] {
+			continue
+		}
+		if compact {
+			names = append(names, tool.Name())
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("- **%s**: %s\n", tool.Name(), a.toolDescription(lang, tool)))
+	}
+	if compact && len(names) > 0 {
+		sb.WriteString(strings.Join(names, ", ") + "\n")
+	}
+
+	sb.WriteString("\n" + a.tr(lang, "toolUsage") + "\n")
+	return sb.String()
+}
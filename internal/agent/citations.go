@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maxSources 回复后附加的来源数量上限
+const maxSources = 5
+
+var (
+	// 搜索结果格式见 tools.WebSearchTool："1. 标题\n   链接"
+	searchResultPattern = regexp.MustCompile(`(?m)^\d+\. (.+)\n[ \t]+(https?://\S+)[ \t]*$`)
+	httpStatusPattern   = regexp.MustCompile(`(?m)^HTTP (\d{3})\b`)
+	pageTitlePattern    = regexp.MustCompile(`(?m)^Title: (.+)$`)
+)
+
+// source 一条引用来源
+type source struct {
+	Title string
+	URL   string
+}
+
+// citations 收集一次请求中 web_search 和 http_request 结果里的来源，按URL去重。
+// 实际读取的页面排在搜索结果之前
+type citations struct {
+	pages  []source
+	search []source
+	seen   map[string]bool
+}
+
+// newCitations 智能体开启 citations 时返回收集器，否则返回nil，nil收集器的方法什么也不做
+func (a *Agent) newCitations() *citations {
+	if !a.Config.Citations {
+		return nil
+	}
+	return &citations{seen: make(map[string]bool)}
+}
+
+// add 从一次工具调用的参数和结果中提取来源，失败的调用忽略
+func (c *citations) add(tool, arguments, result string, err error) {
+	if c == nil || err != nil {
+		return
+	}
+	switch tool {
+	case "web_search":
+		for _, m := range searchResultPattern.FindAllStringSubmatch(result, -1) {
+			c.search = c.appendSource(c.search, strings.TrimSpace(m[1]), searchTarget(m[2]))
+		}
+	case "http_request":
+		var args struct {
+			URL    string `json:"url"`
+			Method string `json:"method"`
+		}
+		if json.Unmarshal([]byte(arguments), &args) != nil {
+			return
+		}
+		// 只引用成功读取的页面，调用接口写入数据不算来源
+		if args.Method != "" && !strings.EqualFold(args.Method, "GET") {
+			return
+		}
+		if m := httpStatusPattern.FindStringSubmatch(result); m == nil || m[1][0] != '2' {
+			return
+		}
+		title := ""
+		if m := pageTitlePattern.FindStringSubmatch(result); m != nil {
+			title = strings.TrimSpace(m[1])
+		}
+		c.pages = c.appendSource(c.pages, title, args.URL)
+	}
+}
+
+func (c *citations) appendSource(list []source, title, link string) []source {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || c.seen[link] {
+		return list
+	}
+	c.seen[link] = true
+	if title == "" {
+		title = u.Host
+	}
+	return append(list, source{Title: title, URL: link})
+}
+
+// searchTarget DuckDuckGo 的跳转链接还原为目标地址
+func searchTarget(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || !strings.HasSuffix(u.Host, "duckduckgo.com") || u.Path != "/l/" {
+		return link
+	}
+	if target := u.Query().Get("uddg"); target != "" {
+		return target
+	}
+	return link
+}
+
+// format 生成附在回复末尾的来源列表，回复中已出现的链接不再重复，没有来源时返回空字符串
+func (c *citations) format(answer, title string) string {
+	if c == nil || strings.TrimSpace(answer) == "" {
+		return ""
+	}
+	var sb strings.Builder
+	n := 0
+	for _, s := range append(c.pages, c.search...) {
+		if n == maxSources {
+			break
+		}
+		if strings.Contains(answer, s.URL) {
+			continue
+		}
+		n++
+		fmt.Fprintf(&sb, "\n%d. %s - %s", n, s.Title, s.URL)
+	}
+	if n == 0 {
+		return ""
+	}
+	return "\n\n" + title + sb.String()
+}
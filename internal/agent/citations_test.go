@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestCitations(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	if c := CreateAgent("test", config.AgentConfig{}, nil, nil, nil, nil, nil, log).newCitations(); c != nil {
+		t.Fatal("citations collected while disabled")
+	}
+	var disabled *citations
+	disabled.add("http_request", `{"url":"https://example.com"}`, "HTTP 200 OK\n", nil)
+	if s := disabled.format("answer", "Sources:"); s != "" {
+		t.Errorf("nil citations format = %q", s)
+	}
+
+	a := CreateAgent("test", config.AgentConfig{Citations: true}, nil, nil, nil, nil, nil, log)
+	c := a.newCitations()
+	c.add("web_search", "", "Search results for: go\n\n"+
+		"1. The Go Programming Language\n   https://duckduckgo.com/l/?uddg=https%3A%2F%2Fgo.dev%2F&rut=abc\n\n"+
+		"2. Go (programming language) - Wikipedia\n   https://en.wikipedia.org/wiki/Go_(programming_language)\n\n", nil)
+	c.add("http_request", `{"url":"https://go.dev/doc/"}`, "HTTP 200 OK\nContent-Type: text/html\n\nTitle: Documentation\n\nbody", nil)
+	c.add("http_request", `{"url":"https://go.dev/"}`, "HTTP 200 OK\n\nbody", nil)                    // 与搜索结果重复
+	c.add("http_request", `{"url":"https://example.com/missing"}`, "HTTP 404 Not Found\n\nnope", nil) // 请求失败
+	c.add("http_request", `{"url":"https://api.example.com/items","method":"POST"}`, "HTTP 201 Created\n", nil)
+	c.add("http_request", `{"url":"https://example.com/down"}`, "", errors.New("timeout"))
+	c.add("read_file", `{"path":"notes.txt"}`, "https://example.com/in-file", nil)
+
+	got := c.format("Go is at https://en.wikipedia.org/wiki/Go_(programming_language).", "Sources:")
+	want := "\n\nSources:" +
+		"\n1. Documentation - https://go.dev/doc/" +
+		"\n2. The Go Programming Language - https://go.dev/"
+	if got != want {
+		t.Errorf("format() = %q, want %q", got, want)
+	}
+
+	if s := c.format("", "Sources:"); s != "" {
+		t.Errorf("format() of empty answer = %q", s)
+	}
+}
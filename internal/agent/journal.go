@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/memory"
+	"github.com/HaohanHe/mujibot/internal/session"
+)
+
+const (
+	defaultJournalMinMessages = 4
+	defaultJournalMaxChars    = 8000
+	defaultRecapMinMessages   = 2
+)
+
+const journalPrompt = `Summarize the conversation below as an entry for a personal daily journal.
+Write 2-4 short bullet points: what the user wanted, what was done or decided, and any open follow-ups.
+Use the language the user wrote in. Output only the bullet points.`
+
+// Journal 用LLM把结束的会话总结为几条要点，追加到当天的每日笔记。
+// 对话消息（不含工具调用）少于 minMessages 时跳过，发送给LLM的对话只保留最后 maxChars 个字符。
+// ctx 在总结完成前被取消时不写入
+func (a *Agent) Journal(ctx context.Context, c session.Closed, minMessages, maxChars int) error {
+	if a.MemoryMgr == nil || !a.MemoryMgr.IsEnabled() {
+		return nil
+	}
+	if minMessages <= 0 {
+		minMessages = defaultJournalMinMessages
+	}
+	if maxChars <= 0 {
+		maxChars = defaultJournalMaxChars
+	}
+
+	transcript, count := journalTranscript(c.Messages)
+	if count < minMessages {
+		return nil
+	}
+	if r := []rune(transcript); len(r) > maxChars {
+		transcript = "…" + string(r[len(r)-maxChars:])
+	}
+
+	resp, err := a.Provider.Chat([]session.Message{
+		{Role: "system", Content: journalPrompt},
+		{Role: "user", Content: transcript},
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to summarize session: %w", err)
+	}
+	summary := strings.TrimSpace(resp.Content)
+	if summary == "" {
+		return nil
+	}
+
+	// 总结期间用户数据可能已被清除，不再写回
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	entry := memory.JournalEntry(c.Channel+":"+c.UserID, c.Reason, summary)
+	if err := a.MemoryMgr.WriteDailyNote(time.Now().Format("2006-01-02"), entry); err != nil {
+		return err
+	}
+	a.log.Info("session journaled", "session", c.ID, "reason", c.Reason, "messages", count)
+	return nil
+}
+
+// journalTranscript 把用户和助手的文本消息拼成对话记录，返回记录和消息数
+func journalTranscript(messages []session.Message) (string, int) {
+	var sb strings.Builder
+	count := 0
+	for _, msg := range messages {
+		content := strings.TrimSpace(msg.Content)
+		if content == "" {
+			continue
+		}
+		var speaker string
+		switch msg.Role {
+		case "user":
+			speaker = "User"
+		case "assistant":
+			speaker = "Assistant"
+		default:
+			continue
+		}
+		fmt.Fprintf(&sb, "%s: %s\n\n", speaker, content)
+		count++
+	}
+	return sb.String(), count
+}
+
+const recapPrompt = `Summarize the conversation below in one short paragraph (at most 5 sentences) so the assistant can pick up where it left off next time:
+what the user was working on, what was done or decided, and anything still open.
+If an earlier summary is given, keep whatever from it is still relevant.
+Use the language the user wrote in. Output only the paragraph.`
+
+// Recap 用LLM把结束的会话总结为一段话，保存为该用户的上次对话摘要，下次对话时注入系统提示词。
+// 已有摘要时一并发送，使摘要跨多次会话延续；ctx、minMessages、maxChars 的含义同 Journal
+func (a *Agent) Recap(ctx context.Context, c session.Closed, minMessages, maxChars int) error {
+	if a.MemoryMgr == nil || !a.MemoryMgr.IsEnabled() {
+		return nil
+	}
+	if minMessages <= 0 {
+		minMessages = defaultRecapMinMessages
+	}
+	if maxChars <= 0 {
+		maxChars = defaultJournalMaxChars
+	}
+
+	transcript, count := journalTranscript(c.Messages)
+	if count < minMessages {
+		return nil
+	}
+	if r := []rune(transcript); len(r) > maxChars {
+		transcript = "…" + string(r[len(r)-maxChars:])
+	}
+
+	owner := c.Channel + ":" + c.UserID
+	if prev := a.MemoryMgr.Recap(owner); !prev.IsEmpty() {
+		transcript = "Earlier summary:\n" + prev.Summary + "\n\nConversation:\n" + transcript
+	}
+
+	resp, err := a.Provider.Chat([]session.Message{
+		{Role: "system", Content: recapPrompt},
+		{Role: "user", Content: transcript},
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to summarize session: %w", err)
+	}
+	summary := strings.TrimSpace(resp.Content)
+	if summary == "" {
+		return nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := a.MemoryMgr.SetRecap(owner, summary); err != nil {
+		return err
+	}
+	a.log.Info("session recap saved", "session", c.ID, "reason", c.Reason, "messages", count)
+	return nil
+}
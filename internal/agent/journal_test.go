@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/llm"
+	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/memory"
+	"github.com/HaohanHe/mujibot/internal/session"
+)
+
+// summaryProvider 记录收到的对话并返回固定摘要
+type summaryProvider struct {
+	received string
+}
+
+func (p *summaryProvider) Chat(messages []session.Message, tools []llm.Tool) (*llm.Response, error) {
+	p.received = messages[len(messages)-1].Content
+	return &llm.Response{Content: "- fixed the backup script"}, nil
+}
+
+func (p *summaryProvider) ChatStream(messages []session.Message, tools []llm.Tool, callback func(chunk string)) (*llm.Response, error) {
+	return p.Chat(messages, tools)
+}
+
+func (p *summaryProvider) GetModel() string {
+	return "test"
+}
+
+func TestJournal(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	mem, err := memory.NewManager(memory.Config{Enabled: true, MemoryDir: t.TempDir(), MaxFileSize: 1 << 20}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &summaryProvider{}
+	a := CreateAgent("test", config.AgentConfig{Name: "test"}, provider, nil, nil, mem, nil, log)
+
+	closed := session.Closed{
+		ID:      "telegram:42:test",
+		UserID:  "42",
+		Channel: "telegram",
+		Reason:  session.CloseIdle,
+		Messages: []session.Message{
+			{Role: "user", Content: "my backup script fails"},
+			{Role: "assistant", Content: "", ToolCalls: []session.ToolCall{{ID: "1"}}},
+			{Role: "tool", Content: "exit status 1"},
+			{Role: "assistant", Content: "fixed the path"},
+		},
+	}
+
+	if err := a.Journal(context.Background(), closed, 4, 0); err != nil {
+		t.Fatal(err)
+	}
+	if provider.received != "" {
+		t.Fatal("short session should not be summarized")
+	}
+
+	if err := a.Journal(context.Background(), closed, 2, 20); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(provider.received, "exit status") || !strings.HasSuffix(provider.received, "fixed the path\n\n") {
+		t.Errorf("transcript = %q", provider.received)
+	}
+	if n := len([]rune(provider.received)); n != 21 {
+		t.Errorf("transcript length = %d, want truncated to 20 plus ellipsis", n)
+	}
+
+	note, _ := mem.ReadDailyNote(time.Now().Format("2006-01-02"))
+	if !strings.Contains(note, "telegram:42, idle") || !strings.Contains(note, "- fixed the backup script") {
+		t.Errorf("daily note = %q", note)
+	}
+}
+
+func TestRecap(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	mem, err := memory.NewManager(memory.Config{Enabled: true, MemoryDir: t.TempDir(), MaxFileSize: 1 << 20}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &summaryProvider{}
+	a := CreateAgent("test", config.AgentConfig{Name: "test"}, provider, nil, nil, mem, nil, log)
+
+	closed := session.Closed{
+		ID:      "telegram:42:test",
+		UserID:  "42",
+		Channel: "telegram",
+		Reason:  session.CloseIdle,
+		Messages: []session.Message{
+			{Role: "user", Content: "my backup script fails"},
+			{Role: "assistant", Content: "fixed the path"},
+		},
+	}
+	data := PromptData{UserID: "42", Channel: "telegram", Lang: "en-US"}
+
+	if err := a.Recap(context.Background(), closed, 3, 0); err != nil {
+		t.Fatal(err)
+	}
+	if provider.received != "" || a.recapSection(data) != "" {
+		t.Fatal("short session should not be summarized")
+	}
+
+	if err := a.Recap(context.Background(), closed, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if s := a.recapSection(data); !strings.Contains(s, "Previous conversation") || !strings.Contains(s, "- fixed the backup script") {
+		t.Errorf("recapSection() = %q", s)
+	}
+	if s := a.recapSection(PromptData{UserID: "7", Channel: "telegram", Lang: "en-US"}); s != "" {
+		t.Errorf("recapSection() for another user = %q", s)
+	}
+
+	// 再次总结时带上之前的摘要
+	if err := a.Recap(context.Background(), closed, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(provider.received, "Earlier summary:\n- fixed the backup script") {
+		t.Errorf("transcript = %q", provider.received)
+	}
+}
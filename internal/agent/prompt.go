@@ -0,0 +1,278 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/i18n"
+	"github.com/HaohanHe/mujibot/internal/llm"
+	"github.com/HaohanHe/mujibot/internal/memory"
+	"github.com/HaohanHe/mujibot/internal/system"
+	"github.com/HaohanHe/mujibot/internal/tools"
+)
+
+// PromptData 系统提示词模板变量
+type PromptData struct {
+	UserID     string
+	UserName   string
+	Channel    string
+	AgentName  string
+	Date       string
+	Time       string
+	Weekday    string
+	DeviceName string
+	Vars       map[string]string
+	Lang       string // 本次对话使用的语言，决定提示词片段的翻译
+
+	allowed map[string]bool // 本次请求可用的工具，nil 表示全部可用
+}
+
+// newPromptData 构建当前消息的模板变量
+func (a *Agent) newPromptData(userID, username, channel string) PromptData {
+	now := time.Now()
+	if a.MemoryMgr != nil && a.MemoryMgr.IsEnabled() {
+		if p := a.MemoryMgr.Profile(channel + ":" + userID); p.DisplayName != "" {
+			username = p.DisplayName
+		}
+	}
+	if username == "" {
+		username = userID
+	}
+
+	vars := make(map[string]string, len(a.Config.Variables))
+	for k, v := range a.Config.Variables {
+		vars[k] = v
+	}
+
+	deviceName := vars["deviceName"]
+	if deviceName == "" {
+		deviceName = system.GetInfo().Hostname
+	}
+
+	return PromptData{
+		UserID:     userID,
+		UserName:   username,
+		Channel:    channel,
+		AgentName:  a.Name,
+		Date:       now.Format("2006-01-02"),
+		Time:       now.Format("15:04"),
+		Weekday:    now.Weekday().String(),
+		DeviceName: deviceName,
+		Vars:       vars,
+		Lang:       a.lang(),
+	}
+}
+
+// renderSystemPrompt 渲染系统提示词模板，失败时回退为原始文本
+func (a *Agent) renderSystemPrompt(data PromptData) string {
+	if !strings.Contains(a.SystemPrompt, "{{") {
+		return a.SystemPrompt
+	}
+
+	a.tmplOnce.Do(func() {
+		tmpl, err := template.New(a.ID).Option("missingkey=zero").Parse(a.SystemPrompt)
+		if err != nil {
+			a.log.Warn("invalid system prompt template", "agent", a.ID, "error", err)
+			return
+		}
+		a.promptTmpl = tmpl
+	})
+
+	if a.promptTmpl == nil {
+		return a.SystemPrompt
+	}
+
+	var sb strings.Builder
+	if err := a.promptTmpl.Execute(&sb, data); err != nil {
+		a.log.Warn("failed to render system prompt", "agent", a.ID, "error", err)
+		return a.SystemPrompt
+	}
+	return sb.String()
+}
+
+// promptSection 可独立缓存的系统提示词片段
+type promptSection struct {
+	mu      sync.Mutex
+	key     string
+	valid   bool
+	content string
+}
+
+// get 缓存键未变化时返回缓存内容，否则重新构建
+func (s *promptSection) get(key string, build func() string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.valid && s.key == key {
+		return s.content
+	}
+
+	s.content = build()
+	s.key = key
+	s.valid = true
+	return s.content
+}
+
+// toolsSection 工具说明，工具注册表变化时重建
+func (a *Agent) toolsSection(lang string) string {
+	key := fmt.Sprintf("%d:%s", a.ToolManager.Version(), lang)
+	return a.sections.tools.get(key, func() string {
+		return a.buildToolsSection(lang, nil, false)
+	})
+}
+
+// rulesSection 语言与记忆规则，仅随语言变化
+func (a *Agent) rulesSection(lang string) string {
+	return a.sections.rules.get(lang, func() string {
+		var sb strings.Builder
+		sb.WriteString("\n## " + a.tr(lang, "userLanguage") + "\n\n")
+		sb.WriteString(a.tr(lang, "replyInSameLang") + "\n")
+
+		sb.WriteString("\n## " + a.tr(lang, "memoryRulesTitle") + "\n\n")
+		sb.WriteString(a.tr(lang, "memoryRules") + "\n")
+		sb.WriteString("\n" + a.tr(lang, "memoryCategories") + "\n")
+		return sb.String()
+	})
+}
+
+// memorySection 记忆上下文，记忆写入或日期变化时重建
+func (a *Agent) memorySection(lang string) string {
+	if a.MemoryMgr == nil || !a.MemoryMgr.IsEnabled() {
+		return ""
+	}
+
+	key := fmt.Sprintf("%d:%s:%s", a.MemoryMgr.Version(), time.Now().Format("2006-01-02"), lang)
+	return a.sections.memory.get(key, func() string {
+		memoryContext := a.MemoryMgr.GetMemoryContext()
+		if memoryContext == "" {
+			return ""
+		}
+		return fmt.Sprintf("\n## %s\n\n", a.tr(lang, "memoryContext")) + memoryContext + "\n"
+	})
+}
+
+// envSection 环境信息，每分钟刷新一次
+func (a *Agent) envSection(lang string) string {
+	now := time.Now()
+	key := now.Format("2006-01-02 15:04") + ":" + lang
+	return a.sections.env.get(key, func() string {
+		var sb strings.Builder
+		sb.WriteString("\n## 环境信息\n\n")
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", a.tr(lang, "currentTime"), now.Format("2006-01-02 15:04 MST")))
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", a.tr(lang, "timezone"), system.GetTimezone()))
+		sb.WriteString(fmt.Sprintf("- %s: Mujibot AI Assistant\n", a.tr(lang, "systemType")))
+		sb.WriteString(system.GetInfo().Format())
+		return sb.String()
+	})
+}
+
+// profileSection 用户资料，按用户区分
+func (a *Agent) profileSection(data PromptData) string {
+	if a.MemoryMgr == nil || !a.MemoryMgr.IsEnabled() {
+		return ""
+	}
+	p := a.MemoryMgr.Profile(data.Channel + ":" + data.UserID)
+	if p.IsEmpty() {
+		return ""
+	}
+	return fmt.Sprintf("\n## %s\n\n%s\n", a.tr(data.Lang, "userProfile"), a.tr(data.Lang, "profileIntro")) + p.Format()
+}
+
+// recapSection 用户上次对话的摘要，按用户区分
+func (a *Agent) recapSection(data PromptData) string {
+	if a.MemoryMgr == nil || !a.MemoryMgr.IsEnabled() {
+		return ""
+	}
+	r := a.MemoryMgr.Recap(data.Channel + ":" + data.UserID)
+	if r.IsEmpty() {
+		return ""
+	}
+	return fmt.Sprintf("\n## %s (%s)\n\n%s\n", a.tr(data.Lang, "previousConversation"), r.Updated.Format("2006-01-02 15:04"), r.Summary)
+}
+
+// pinnedSection 用户置顶内容，按用户区分且不受会话裁剪影响，放在最后避免破坏共享前缀
+func (a *Agent) pinnedSection(data PromptData) string {
+	if a.MemoryMgr == nil || !a.MemoryMgr.IsEnabled() {
+		return ""
+	}
+	pins := a.MemoryMgr.Pins(data.Channel + ":" + data.UserID)
+	if len(pins) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n## %s\n\n", a.tr(data.Lang, "pinnedContext")) + memory.FormatPins(pins)
+}
+
+// llmTools 转换为LLM工具定义，描述使用对话语言，工具注册表变化时重建
+func (a *Agent) llmTools(lang string) []llm.Tool {
+	a.toolsMu.Lock()
+	defer a.toolsMu.Unlock()
+
+	version := a.ToolManager.Version()
+	if a.toolsVersion != version || a.cachedTools == nil {
+		a.cachedTools = make(map[string][]llm.Tool)
+		a.toolsVersion = version
+	}
+	if cached, ok := a.cachedTools[lang]; ok {
+		return cached
+	}
+
+	all := a.ToolManager.GetAll()
+	result := make([]llm.Tool, 0, len(all))
+	for _, tool := range all {
+		result = append(result, llm.Tool{
+			Type: "function",
+			Function: llm.Function{
+				Name:        tool.Name(),
+				Description: a.toolDescription(lang, tool),
+				Parameters:  tool.Parameters(),
+			},
+		})
+	}
+
+	a.cachedTools[lang] = result
+	return result
+}
+
+// toolDescription 工具描述的翻译，没有翻译时使用工具自带的描述
+func (a *Agent) toolDescription(lang string, tool tools.Tool) string {
+	if lang == "" {
+		lang = a.lang()
+	}
+	return tools.Describe(a.I18n, lang, tool)
+}
+
+func (a *Agent) lang() string {
+	if a.I18n == nil {
+		return ""
+	}
+	return a.I18n.GetLanguage()
+}
+
+// resolveLang 确定本次对话的语言：用户资料中设置的语言优先，其次按消息自动识别，
+// 消息太短无法识别时沿用该用户上次识别的语言，最后使用全局语言
+func (a *Agent) resolveLang(userID, channel, content string) string {
+	if a.I18n == nil {
+		return ""
+	}
+	owner := channel + ":" + userID
+
+	if a.MemoryMgr != nil && a.MemoryMgr.IsEnabled() {
+		if lang := a.I18n.Match(a.MemoryMgr.Profile(owner).Locale); lang != "" {
+			return lang
+		}
+	}
+
+	if a.I18n.AutoDetect() {
+		if lang := a.I18n.Match(i18n.Detect(content)); lang != "" {
+			a.userLangs.Store(owner, lang)
+			return lang
+		}
+		if lang, ok := a.userLangs.Load(owner); ok {
+			return lang.(string)
+		}
+	}
+	return a.lang()
+}
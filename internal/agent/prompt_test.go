@@ -0,0 +1,200 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/i18n"
+	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/memory"
+	"github.com/HaohanHe/mujibot/internal/tools"
+)
+
+func TestRenderSystemPrompt(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	tests := []struct {
+		name     string
+		prompt   string
+		expected string
+	}{
+		{"plain", "You are helpful.", "You are helpful."},
+		{"user and channel", "Hi {{.UserName}} on {{.Channel}}", "Hi alice on telegram"},
+		{"custom var", "Owner: {{.Vars.owner}}", "Owner: bob"},
+		{"missing var", "X{{.Vars.missing}}Y", "XY"},
+		{"invalid template", "Hi {{.UserName", "Hi {{.UserName"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.AgentConfig{
+				Name:         "test",
+				SystemPrompt: tt.prompt,
+				Variables:    map[string]string{"owner": "bob"},
+			}
+			a := CreateAgent("test", cfg, nil, nil, nil, nil, nil, log)
+
+			result := a.renderSystemPrompt(a.newPromptData("42", "alice", "telegram"))
+			if result != tt.expected {
+				t.Errorf("renderSystemPrompt() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPinnedSection(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	mem, err := memory.NewManager(memory.Config{Enabled: true, MemoryDir: t.TempDir(), MaxFileSize: 1 << 20}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := CreateAgent("test", config.AgentConfig{Name: "test"}, nil, nil, nil, mem, nil, log)
+	data := a.newPromptData("42", "alice", "telegram")
+
+	if s := a.pinnedSection(data); s != "" {
+		t.Errorf("pinnedSection() without pins = %q", s)
+	}
+
+	mem.Pin("telegram:42", "Always answer in English")
+	mem.Pin("telegram:42", "My name is Alice")
+	mem.Pin("telegram:7", "other user")
+	if err := mem.Unpin("telegram:42", 3); err == nil {
+		t.Error("Unpin out of range should fail")
+	}
+
+	s := a.pinnedSection(data)
+	if !strings.Contains(s, "1. Always answer in English\n2. My name is Alice\n") || strings.Contains(s, "other user") {
+		t.Errorf("pinnedSection() = %q", s)
+	}
+
+	mem.Unpin("telegram:42", 1)
+	if pins := mem.Pins("telegram:42"); len(pins) != 1 || pins[0] != "My name is Alice" {
+		t.Errorf("Pins after Unpin = %v", pins)
+	}
+	mem.Unpin("telegram:42", 0)
+	if s := a.pinnedSection(data); s != "" {
+		t.Errorf("pinnedSection() after clear = %q", s)
+	}
+}
+
+func TestResolveLang(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	mem, err := memory.NewManager(memory.Config{Enabled: true, MemoryDir: t.TempDir(), MaxFileSize: 1 << 20}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem.UpdateProfile("telegram:1", func(p *memory.Profile) error { return p.Set("locale", "ja") })
+
+	i := i18n.New("en-US")
+	i.SetAutoDetect(true)
+	a := CreateAgent("test", config.AgentConfig{Name: "test"}, nil, nil, nil, mem, i, log)
+
+	steps := []struct {
+		userID  string
+		content string
+		want    string
+	}{
+		{"1", "What's the weather?", "ja-JP"},
+		{"2", "明天北京天气怎么样", "zh-CN"},
+		{"2", "ok", "zh-CN"},
+		{"2", "What about Tokyo tomorrow?", "en-US"},
+		{"3", "明日の天気は？", "ja-JP"},
+		{"4", "👍", "en-US"},
+	}
+	for _, s := range steps {
+		if got := a.resolveLang(s.userID, "telegram", s.content); got != s.want {
+			t.Errorf("resolveLang(%s, %q) = %s, want %s", s.userID, s.content, got, s.want)
+		}
+	}
+
+	i.SetAutoDetect(false)
+	if got := a.resolveLang("2", "telegram", "明天呢"); got != "en-US" {
+		t.Errorf("resolveLang without auto-detect = %s, want en-US", got)
+	}
+	if got := a.tr("zh-CN", "userProfile"); got != "用户资料" {
+		t.Errorf("tr(zh-CN) = %q", got)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"你好世界", 4},
+		{"hi 你好", 3},
+	}
+	for _, tt := range tests {
+		if got := EstimateTokens(tt.text); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestPromptBudget(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	mem, err := memory.NewManager(memory.Config{Enabled: true, MemoryDir: t.TempDir(), MaxFileSize: 1 << 20}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem.WriteLongTermMemory(strings.Repeat("remember this fact. ", 400))
+	toolMgr, err := tools.NewManager(tools.Config{WorkDir: t.TempDir()}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := CreateAgent("test", config.AgentConfig{Name: "test", SystemPrompt: "You are helpful."}, nil, toolMgr, nil, mem, i18n.New("en-US"), log)
+	data := a.newPromptData("42", "alice", "telegram")
+	data.Lang = "en-US"
+
+	full := a.buildSystemPrompt(data)
+	if !strings.Contains(full, "remember this fact") || !strings.Contains(full, "**read_file**") {
+		t.Fatal("prompt without budget should contain memory and tool descriptions")
+	}
+	size := EstimateTokens(full)
+
+	// 去掉记忆后即可满足预算
+	memTokens := EstimateTokens(a.memorySection(data.Lang))
+	window := (size-memTokens+20)*100/99 + 1
+	a.SetPromptBudget(func() PromptBudget { return PromptBudget{ContextWindow: window, Percent: 99} })
+	prompt := a.buildSystemPrompt(data)
+	if strings.Contains(prompt, "remember this fact") {
+		t.Error("memory context should be dropped first")
+	}
+	if !strings.Contains(prompt, "**read_file**") {
+		t.Error("tool descriptions should be kept when memory alone is enough")
+	}
+
+	// 定时任务只保留允许的工具说明
+	data.allowed = map[string]bool{"read_file": true}
+	a.SetPromptBudget(func() PromptBudget { return PromptBudget{ContextWindow: 1000, Percent: 50} })
+	prompt = a.buildSystemPrompt(data)
+	if !strings.Contains(prompt, "**read_file**") || strings.Contains(prompt, "**write_file**") {
+		t.Errorf("only allowed tools should be described:\n%s", prompt)
+	}
+
+	// 最后只保留工具名称
+	data.allowed = nil
+	a.SetPromptBudget(func() PromptBudget { return PromptBudget{ContextWindow: 100, Percent: 50} })
+	prompt = a.buildSystemPrompt(data)
+	if strings.Contains(prompt, "**read_file**") || !strings.Contains(prompt, "read_file") {
+		t.Errorf("tool list should be reduced to names:\n%s", prompt)
+	}
+
+	// 100% 不限制
+	a.SetPromptBudget(func() PromptBudget { return PromptBudget{ContextWindow: 100, Percent: 100} })
+	if a.buildSystemPrompt(data) != full {
+		t.Error("percent 100 should disable trimming")
+	}
+}
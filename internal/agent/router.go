@@ -1,19 +1,21 @@
 package agent
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"runtime/debug"
-	"strings"
 	"sync"
+	"text/template"
 
 	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/crash"
+	"github.com/HaohanHe/mujibot/internal/guardrail"
 	"github.com/HaohanHe/mujibot/internal/i18n"
 	"github.com/HaohanHe/mujibot/internal/llm"
 	"github.com/HaohanHe/mujibot/internal/logger"
 	"github.com/HaohanHe/mujibot/internal/memory"
 	"github.com/HaohanHe/mujibot/internal/session"
-	"github.com/HaohanHe/mujibot/internal/system"
 	"github.com/HaohanHe/mujibot/internal/tools"
 )
 
@@ -29,12 +31,34 @@ type Agent struct {
 	Config       config.AgentConfig
 	I18n         *i18n.I18n
 	log          *logger.Logger
+
+	promptTmpl *template.Template
+	tmplOnce   sync.Once
+
+	// 系统提示词分段缓存
+	sections struct {
+		tools, rules, memory, env promptSection
+	}
+	cachedTools  map[string][]llm.Tool // 按语言缓存
+	toolsVersion uint64
+	toolsMu      sync.Mutex
+
+	// budget 系统提示词预算，为空时不检查
+	budget func() PromptBudget
+
+	// style 按渠道读取回复长度与风格，为空时不限制
+	style func(channel string) config.ResponseStyle
+
+	// userLangs 自动识别出的用户语言（channel:userID -> 语言），短消息无法识别时沿用
+	userLangs sync.Map
 }
 
 // Router 智能体路由器
 type Router struct {
 	agents   map[string]*Agent
 	defaultAgent string
+	guard    *guardrail.Engine
+	crash    *crash.Reporter
 	mu       sync.RWMutex
 	log      *logger.Logger
 }
@@ -60,6 +84,40 @@ func (r *Router) RegisterAgent(id string, agent *Agent) {
 	r.log.Info("agent registered", "id", id, "name", agent.Name)
 }
 
+// SetGuardrail 设置内容安全引擎
+func (r *Router) SetGuardrail(g *guardrail.Engine) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.guard = g
+}
+
+// SetCrashReporter 设置崩溃报告器
+func (r *Router) SetCrashReporter(c *crash.Reporter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.crash = c
+}
+
+// recovered 记录已恢复的panic
+func (r *Router) recovered(ctx context.Context, agent *Agent, rec interface{}) {
+	r.mu.RLock()
+	reporter := r.crash
+	r.mu.RUnlock()
+
+	if reporter != nil {
+		reporter.Report("agent:"+agent.ID, rec, debug.Stack())
+		return
+	}
+	r.log.Ctx(ctx).Error("agent panic recovered", "error", rec, "stack", string(debug.Stack()))
+}
+
+// guardrail 获取内容安全引擎
+func (r *Router) guardrail() *guardrail.Engine {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.guard
+}
+
 // GetAgent 获取智能体
 func (r *Router) GetAgent(id string) (*Agent, bool) {
 	r.mu.RLock()
@@ -109,70 +167,103 @@ func (r *Router) GetAllAgents() map[string]*Agent {
 }
 
 // ProcessMessage 处理消息（带panic恢复）
-func (r *Router) ProcessMessage(agent *Agent, userID, channel, content string) (string, error) {
+func (r *Router) ProcessMessage(ctx context.Context, agent *Agent, userID, username, channel, content string) (string, error) {
 	defer func() {
 		if rec := recover(); rec != nil {
-			r.log.Error("agent panic recovered", "error", rec, "stack", string(debug.Stack()))
+			r.recovered(ctx, agent, rec)
 		}
 	}()
 
-	return agent.ProcessMessage(userID, channel, content)
+	guard := r.guardrail()
+	if guard != nil && guard.CheckInput(ctx, channel, userID, content) != nil {
+		return guard.Refusal(agent.resolveLang(userID, channel, content)), nil
+	}
+
+	response, err := agent.ProcessMessage(ctx, userID, username, channel, content)
+	if err != nil {
+		return "", err
+	}
+
+	if guard != nil && guard.CheckOutput(ctx, channel, userID, response) != nil {
+		return guard.Refusal(agent.resolveLang(userID, channel, content)), nil
+	}
+	return response, nil
+}
+
+// RunTask 执行定时任务（带panic恢复）
+func (r *Router) RunTask(ctx context.Context, agent *Agent, name, prompt string, allowedTools []string) (response string, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.recovered(ctx, agent, rec)
+			err = fmt.Errorf("task panic: %v", rec)
+		}
+	}()
+
+	response, err = agent.RunTask(ctx, name, prompt, allowedTools)
+	if err != nil {
+		return "", err
+	}
+
+	if guard := r.guardrail(); guard != nil && guard.CheckOutput(ctx, "scheduler", name, response) != nil {
+		return guard.Refusal(agent.lang()), nil
+	}
+	return response, nil
 }
 
 // ProcessMessageStream 流式处理消息
-func (r *Router) ProcessMessageStream(agent *Agent, userID, channel, content string, callback func(chunk string)) (string, error) {
+func (r *Router) ProcessMessageStream(ctx context.Context, agent *Agent, userID, username, channel, content string, callback func(chunk string)) (string, error) {
 	defer func() {
 		if rec := recover(); rec != nil {
-			r.log.Error("agent panic recovered", "error", rec, "stack", string(debug.Stack()))
+			r.recovered(ctx, agent, rec)
 		}
 	}()
 
-	return agent.ProcessMessageStream(userID, channel, content, callback)
+	guard := r.guardrail()
+	if guard != nil && guard.CheckInput(ctx, channel, userID, content) != nil {
+		refusal := guard.Refusal(agent.resolveLang(userID, channel, content))
+		if callback != nil {
+			callback(refusal)
+		}
+		return refusal, nil
+	}
+
+	response, err := agent.ProcessMessageStream(ctx, userID, username, channel, content, callback)
+	if err != nil {
+		return "", err
+	}
+
+	// 流式输出已下发，违规时仅替换最终结果
+	if guard != nil && guard.CheckOutput(ctx, channel, userID, response) != nil {
+		return guard.Refusal(agent.resolveLang(userID, channel, content)), nil
+	}
+	return response, nil
 }
 
 // ProcessMessage 处理消息
-func (a *Agent) ProcessMessage(userID, channel, content string) (string, error) {
+func (a *Agent) ProcessMessage(ctx context.Context, userID, username, channel, content string) (string, error) {
 	// 获取或创建会话
 	sess := a.SessionMgr.GetOrCreate(userID, channel, a.ID)
 
 	// 添加用户消息
 	a.SessionMgr.AddMessage(sess, "user", content)
 
-	// 构建消息历史
-	messages := a.buildMessages(sess)
-
-	// 获取工具定义
-	toolDefs := a.ToolManager.GetToolDefinitions()
-	tools := make([]llm.Tool, 0, len(toolDefs))
-	for _, def := range toolDefs {
-		fn, ok := def["function"].(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		name, _ := fn["name"].(string)
-		desc, _ := fn["description"].(string)
-		params, _ := fn["parameters"].(map[string]interface{})
-
-		if name == "" {
-			continue
-		}
+	promptData := a.newPromptData(userID, username, channel)
+	promptData.Lang = a.resolveLang(userID, channel, content)
+	return a.run(ctx, sess, promptData, a.llmTools(promptData.Lang), nil)
+}
 
-		tools = append(tools, llm.Tool{
-			Type: "function",
-			Function: llm.Function{
-				Name:        name,
-				Description: desc,
-				Parameters:  params,
-			},
-		})
-	}
+// run 执行一轮对话（含工具调用），allowed 非nil时仅允许其中的工具
+func (a *Agent) run(ctx context.Context, sess *session.Session, promptData PromptData, tools []llm.Tool, allowed map[string]bool) (string, error) {
+	// 构建消息历史
+	messages := a.buildMessages(sess, promptData)
 
-	// 调用LLM
-	resp, err := a.Provider.Chat(messages, tools)
+	// 调用LLM，渠道设置了 maxResponseTokens 时每次请求都带上 max_tokens
+	opts := a.chatOptions(promptData.Channel)
+	resp, err := llm.ChatWith(a.Provider, messages, tools, opts)
 	if err != nil {
 		return "", fmt.Errorf("llm error: %w", err)
 	}
+	cites := a.newCitations()
 
 	// 处理工具调用
 	if len(resp.ToolCalls) > 0 {
@@ -181,7 +272,14 @@ func (a *Agent) ProcessMessage(userID, channel, content string) (string, error)
 
 		// 执行工具
 		for _, tc := range resp.ToolCalls {
-			result, err := a.executeToolCall(tc)
+			var result string
+			var err error
+			if allowed != nil && !allowed[tc.Function.Name] {
+				err = fmt.Errorf("tool %s is not allowed in unattended mode", tc.Function.Name)
+			} else {
+				result, err = a.executeToolCall(ctx, tc)
+			}
+			cites.add(tc.Function.Name, tc.Function.Arguments, result, err)
 			if err != nil {
 				result = fmt.Sprintf("Error: %v", err)
 			}
@@ -192,55 +290,74 @@ func (a *Agent) ProcessMessage(userID, channel, content string) (string, error)
 		}
 
 		// 再次调用LLM获取最终响应
-		messages = a.buildMessages(sess)
-		resp, err = a.Provider.Chat(messages, nil)
+		messages = a.buildMessages(sess, promptData)
+		resp, err = llm.ChatWith(a.Provider, messages, nil, opts)
 		if err != nil {
 			return "", fmt.Errorf("llm error: %w", err)
 		}
 	}
 
-	// 添加助手响应
+	// 添加助手响应，来源列表只附在本次回复中，不写入会话历史
 	a.SessionMgr.AddMessage(sess, "assistant", resp.Content)
 
-	return resp.Content, nil
+	return resp.Content + cites.format(resp.Content, a.tr(promptData.Lang, "sources")), nil
 }
 
-// ProcessMessageStream 流式处理消息
-func (a *Agent) ProcessMessageStream(userID, channel, content string, callback func(chunk string)) (string, error) {
-	sess := a.SessionMgr.GetOrCreate(userID, channel, a.ID)
-
-	a.SessionMgr.AddMessage(sess, "user", content)
+// RunTask 无人值守执行定时任务，每次运行使用独立的空会话，仅允许白名单内的工具
+func (a *Agent) RunTask(ctx context.Context, name, prompt string, allowedTools []string) (string, error) {
+	sess := a.SessionMgr.GetOrCreate(name, "scheduler", a.ID)
+	a.SessionMgr.Clear(sess)
+	a.SessionMgr.AddMessage(sess, "user", prompt)
 
-	messages := a.buildMessages(sess)
+	allowed := make(map[string]bool, len(allowedTools))
+	for _, t := range allowedTools {
+		allowed[t] = true
+	}
 
-	toolDefs := a.ToolManager.GetToolDefinitions()
-	tools := make([]llm.Tool, 0, len(toolDefs))
-	for _, def := range toolDefs {
-		fn, ok := def["function"].(map[string]interface{})
-		if !ok {
-			continue
+	promptData := a.newPromptData(name, name, "scheduler")
+	promptData.allowed = allowed
+	tools := make([]llm.Tool, 0, len(allowed))
+	for _, t := range a.llmTools(promptData.Lang) {
+		if allowed[t.Function.Name] {
+			tools = append(tools, t)
 		}
+	}
+	if len(tools) == 0 {
+		tools = nil
+	}
 
-		name, _ := fn["name"].(string)
-		desc, _ := fn["description"].(string)
-		params, _ := fn["parameters"].(map[string]interface{})
+	return a.run(ctx, sess, promptData, tools, allowed)
+}
 
-		if name == "" {
-			continue
-		}
+// Probe 以空会话把 prompt 发给模型一次，系统提示词和工具定义与聊天时相同，
+// 返回模型的回复和请求的工具调用，不执行工具也不保存会话，用于 mujibot eval
+func (a *Agent) Probe(prompt string) (*llm.Response, error) {
+	promptData := a.newPromptData("eval", "eval", "eval")
+	promptData.Lang = a.resolveLang("eval", "eval", prompt)
 
-		tools = append(tools, llm.Tool{
-			Type: "function",
-			Function: llm.Function{
-				Name:        name,
-				Description: desc,
-				Parameters:  params,
-			},
-		})
+	var messages []session.Message
+	if a.SystemPrompt != "" {
+		messages = append(messages, session.Message{Role: "system", Content: a.buildSystemPrompt(promptData)})
 	}
+	messages = append(messages, session.Message{Role: "user", Content: prompt})
+	return a.Provider.Chat(messages, a.llmTools(promptData.Lang))
+}
+
+// ProcessMessageStream 流式处理消息
+func (a *Agent) ProcessMessageStream(ctx context.Context, userID, username, channel, content string, callback func(chunk string)) (string, error) {
+	sess := a.SessionMgr.GetOrCreate(userID, channel, a.ID)
+
+	a.SessionMgr.AddMessage(sess, "user", content)
+
+	promptData := a.newPromptData(userID, username, channel)
+	promptData.Lang = a.resolveLang(userID, channel, content)
+	messages := a.buildMessages(sess, promptData)
+
+	tools := a.llmTools(promptData.Lang)
+	opts := a.chatOptions(channel)
 
 	var fullContent string
-	resp, err := a.Provider.ChatStream(messages, tools, func(chunk string) {
+	resp, err := llm.ChatStreamWith(a.Provider, messages, tools, opts, func(chunk string) {
 		fullContent += chunk
 		if callback != nil {
 			callback(chunk)
@@ -250,12 +367,14 @@ func (a *Agent) ProcessMessageStream(userID, channel, content string, callback f
 		return "", fmt.Errorf("llm error: %w", err)
 	}
 
+	cites := a.newCitations()
 	if len(resp.ToolCalls) > 0 {
 		a.SessionMgr.AddToolCallMessage(sess, "assistant", fullContent, resp.ToolCalls)
 
 		// 执行工具
 		for _, tc := range resp.ToolCalls {
-			result, err := a.executeToolCall(tc)
+			result, err := a.executeToolCall(ctx, tc)
+			cites.add(tc.Function.Name, tc.Function.Arguments, result, err)
 			if err != nil {
 				result = fmt.Sprintf("Error: %v", err)
 			}
@@ -266,9 +385,9 @@ func (a *Agent) ProcessMessageStream(userID, channel, content string, callback f
 		}
 
 		// 再次调用LLM获取最终响应
-		messages = a.buildMessages(sess)
+		messages = a.buildMessages(sess, promptData)
 		fullContent = ""
-		resp, err = a.Provider.ChatStream(messages, nil, func(chunk string) {
+		resp, err = llm.ChatStreamWith(a.Provider, messages, nil, opts, func(chunk string) {
 			fullContent += chunk
 			if callback != nil {
 				callback(chunk)
@@ -282,16 +401,22 @@ func (a *Agent) ProcessMessageStream(userID, channel, content string, callback f
 	// 添加助手响应
 	a.SessionMgr.AddMessage(sess, "assistant", fullContent)
 
+	if sources := cites.format(fullContent, a.tr(promptData.Lang, "sources")); sources != "" {
+		if callback != nil {
+			callback(sources)
+		}
+		fullContent += sources
+	}
 	return fullContent, nil
 }
 
 // buildMessages 构建消息列表
-func (a *Agent) buildMessages(sess *session.Session) []session.Message {
+func (a *Agent) buildMessages(sess *session.Session, data PromptData) []session.Message {
 	messages := make([]session.Message, 0)
 
 	// 添加系统提示
 	if a.SystemPrompt != "" {
-		systemContent := a.buildSystemPrompt()
+		systemContent := a.buildSystemPrompt(data)
 
 		messages = append(messages, session.Message{
 			Role:    "system",
@@ -307,56 +432,35 @@ func (a *Agent) buildMessages(sess *session.Session) []session.Message {
 }
 
 // buildSystemPrompt 构建完整的系统提示词
-func (a *Agent) buildSystemPrompt() string {
-	var sb strings.Builder
-
-	sb.WriteString(a.SystemPrompt)
-
-	sb.WriteString("\n\n## 环境信息\n\n")
-	sb.WriteString(fmt.Sprintf("- %s: %s\n", a.t("currentTime"), system.GetCurrentTime()))
-	sb.WriteString(fmt.Sprintf("- %s: %s\n", a.t("timezone"), system.GetTimezone()))
-	sb.WriteString(fmt.Sprintf("- %s: Mujibot AI Assistant\n", a.t("systemType")))
-
-	sysInfo := system.GetInfo()
-	sb.WriteString(sysInfo.Format())
-
-	sb.WriteString(fmt.Sprintf("\n## %s\n\n", a.t("availableTools")))
-	sb.WriteString(a.t("toolsIntro") + "\n")
-
-	toolDefs := a.ToolManager.GetToolDefinitions()
-	for _, tool := range toolDefs {
-		sb.WriteString(fmt.Sprintf("- **%s**: %s\n", tool["name"], tool["description"]))
-	}
-
-	sb.WriteString("\n" + a.t("toolUsage") + "\n")
-
-	if a.MemoryMgr != nil && a.MemoryMgr.IsEnabled() {
-		memoryContext := a.MemoryMgr.GetMemoryContext()
-		if memoryContext != "" {
-			sb.WriteString(fmt.Sprintf("\n## %s\n\n", a.t("memoryContext")))
-			sb.WriteString(memoryContext)
-		}
-	}
-
-	sb.WriteString("\n## " + a.t("userLanguage") + "\n\n")
-	sb.WriteString(a.t("replyInSameLang") + "\n")
-
-	sb.WriteString("\n## " + a.t("memoryRulesTitle") + "\n\n")
-	sb.WriteString(a.t("memoryRules") + "\n")
-	sb.WriteString("\n" + a.t("memoryCategories") + "\n")
-
-	return sb.String()
+// 各片段按稳定程度排序并独立缓存，尽量保持前缀不变以利于提供商侧的提示词缓存；超出预算时按 fitBudget 裁剪
+func (a *Agent) buildSystemPrompt(data PromptData) string {
+	return a.fitBudget(promptParts{
+		base:    a.renderSystemPrompt(data),
+		tools:   a.toolsSection(data.Lang),
+		rules:   a.rulesSection(data.Lang),
+		memory:  a.memorySection(data.Lang),
+		env:     a.envSection(data.Lang),
+		style:   a.styleSection(data),
+		profile: a.profileSection(data),
+		recap:   a.recapSection(data),
+		pinned:  a.pinnedSection(data),
+	}, data)
 }
 
 func (a *Agent) t(key string) string {
-	if a.I18n == nil {
-		a.I18n = i18n.New("en-US")
+	return a.tr(a.lang(), key)
+}
+
+// tr 按指定语言翻译，lang 为空时使用全局语言
+func (a *Agent) tr(lang, key string) string {
+	if lang == "" {
+		return a.I18n.T(key)
 	}
-	return a.I18n.T(key)
+	return a.I18n.TLang(lang, key)
 }
 
 // executeToolCall 执行工具调用
-func (a *Agent) executeToolCall(tc session.ToolCall) (string, error) {
+func (a *Agent) executeToolCall(ctx context.Context, tc session.ToolCall) (string, error) {
 	// 解析参数
 	var args map[string]interface{}
 	if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
@@ -364,11 +468,15 @@ func (a *Agent) executeToolCall(tc session.ToolCall) (string, error) {
 	}
 
 	// 执行工具
-	return a.ToolManager.Execute(tc.Function.Name, args)
+	return a.ToolManager.Execute(ctx, tc.Function.Name, args)
 }
 
-// CreateAgent 创建智能体实例
+// CreateAgent 创建智能体实例，i 为空时使用英文
 func CreateAgent(id string, cfg config.AgentConfig, provider llm.Provider, toolMgr *tools.Manager, sessionMgr *session.Manager, memoryMgr *memory.Manager, i *i18n.I18n, log *logger.Logger) *Agent {
+	// 翻译器在创建时确定，之后只读，可被并发的对话共用
+	if i == nil {
+		i = i18n.New("en-US")
+	}
 	return &Agent{
 		ID:           id,
 		Name:         cfg.Name,
@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/i18n"
+	"github.com/HaohanHe/mujibot/internal/llm"
+)
+
+// SetResponseStyle 设置按渠道读取回复风格的函数，每次请求时调用，配置热更新后立即生效
+func (a *Agent) SetResponseStyle(fn func(channel string) config.ResponseStyle) {
+	a.style = fn
+}
+
+// responseStyle 渠道的回复风格，未设置时不限制
+func (a *Agent) responseStyle(channel string) config.ResponseStyle {
+	if a.style == nil {
+		return config.ResponseStyle{}
+	}
+	return a.style(channel)
+}
+
+// chatOptions 渠道的请求选项，maxResponseTokens 作为 max_tokens 发送
+func (a *Agent) chatOptions(channel string) llm.ChatOptions {
+	return llm.ChatOptions{MaxTokens: a.responseStyle(channel).MaxResponseTokens}
+}
+
+// styleSection 渠道的回复风格要求。用户资料设置了 verbosity 时由用户资料片段说明，这里只保留长度上限
+func (a *Agent) styleSection(data PromptData) string {
+	style := a.responseStyle(data.Channel)
+	verbosity := style.Verbosity
+	if verbosity != "" && a.MemoryMgr != nil && a.MemoryMgr.IsEnabled() &&
+		a.MemoryMgr.Profile(data.Channel+":"+data.UserID).Verbosity != "" {
+		verbosity = ""
+	}
+
+	var lines []string
+	switch verbosity {
+	case "brief":
+		lines = append(lines, a.tr(data.Lang, "verbosityBrief"))
+	case "detailed":
+		lines = append(lines, a.tr(data.Lang, "verbosityDetailed"))
+	}
+	if style.MaxResponseTokens > 0 {
+		lines = append(lines, a.trf(data.Lang, "responseLimit", i18n.Params{"tokens": style.MaxResponseTokens}))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n## %s\n\n%s\n", a.tr(data.Lang, "responseStyle"), strings.Join(lines, "\n"))
+}
+
+// trf 按指定语言翻译并替换占位符，lang 为空时使用全局语言
+func (a *Agent) trf(lang, key string, params i18n.Params) string {
+	if lang == "" {
+		lang = a.lang()
+	}
+	if s, ok := a.I18n.Lookup(lang, key, params); ok {
+		return s
+	}
+	return key
+}
@@ -0,0 +1,245 @@
+package analytics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/encryption"
+)
+
+const (
+	DefaultFile = "./analytics.json"
+	// DefaultRetentionDays 按天统计默认保留的天数
+	DefaultRetentionDays = 90
+	// dateLayout 按天统计的日期格式，使用本地时区
+	dateLayout = "2006-01-02"
+)
+
+// Config 统计存储配置
+type Config struct {
+	File          string
+	RetentionDays int
+	Cipher        *encryption.Cipher
+}
+
+// Entry 一条已标注意图的消息
+type Entry struct {
+	Time    time.Time
+	Channel string
+	UserID  string
+	Agent   string
+	Intent  string
+}
+
+// counts 意图 -> 消息数
+type counts map[string]int
+
+// statsFile 统计文件内容，只保存计数，不保存消息内容
+type statsFile struct {
+	Since    time.Time         `json:"since"`
+	Intents  counts            `json:"intents"`
+	Days     map[string]counts `json:"days"`
+	Agents   map[string]counts `json:"agents"`
+	Channels map[string]counts `json:"channels"`
+	Users    map[string]counts `json:"users"` // channel:userID
+}
+
+// Store 按意图统计消息数，按天、智能体、渠道和用户分组，持久化到文件
+type Store struct {
+	cfg Config
+
+	mu    sync.Mutex
+	stats statsFile
+}
+
+// DayCount 某天各意图的消息数
+type DayCount struct {
+	Date    string         `json:"date"`
+	Total   int            `json:"total"`
+	Intents map[string]int `json:"intents"`
+}
+
+// Report /api/analytics 返回的统计，Days 为最近 N 天，其余为全部
+type Report struct {
+	Since    time.Time                 `json:"since"`
+	Total    int                       `json:"total"`
+	Intents  map[string]int            `json:"intents"`
+	Days     []DayCount                `json:"days"`
+	Agents   map[string]map[string]int `json:"agents"`
+	Channels map[string]map[string]int `json:"channels"`
+	Users    map[string]map[string]int `json:"users"`
+}
+
+// NewStore 加载统计文件，文件不存在时从零开始
+func NewStore(cfg Config) (*Store, error) {
+	if cfg.File == "" {
+		cfg.File = DefaultFile
+	}
+	if cfg.RetentionDays <= 0 {
+		cfg.RetentionDays = DefaultRetentionDays
+	}
+	s := &Store{cfg: cfg}
+
+	data, err := cfg.Cipher.ReadFile(cfg.File)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read analytics: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &s.stats); err != nil {
+			return nil, fmt.Errorf("failed to parse analytics: %w", err)
+		}
+	}
+	s.init()
+	return s, nil
+}
+
+func (s *Store) init() {
+	if s.stats.Intents == nil {
+		s.stats.Intents = counts{}
+	}
+	if s.stats.Days == nil {
+		s.stats.Days = map[string]counts{}
+	}
+	if s.stats.Agents == nil {
+		s.stats.Agents = map[string]counts{}
+	}
+	if s.stats.Channels == nil {
+		s.stats.Channels = map[string]counts{}
+	}
+	if s.stats.Users == nil {
+		s.stats.Users = map[string]counts{}
+	}
+}
+
+// Record 计入一条消息并保存
+func (s *Store) Record(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stats.Since.IsZero() {
+		s.stats.Since = e.Time
+	}
+	s.stats.Intents[e.Intent]++
+	add(s.stats.Days, e.Time.Local().Format(dateLayout), e.Intent)
+	if e.Agent != "" {
+		add(s.stats.Agents, e.Agent, e.Intent)
+	}
+	if e.Channel != "" {
+		add(s.stats.Channels, e.Channel, e.Intent)
+		add(s.stats.Users, e.Channel+":"+e.UserID, e.Intent)
+	}
+	s.prune(e.Time)
+	return s.save()
+}
+
+func add(groups map[string]counts, key, intent string) {
+	c := groups[key]
+	if c == nil {
+		c = counts{}
+		groups[key] = c
+	}
+	c[intent]++
+}
+
+// prune 删除超过保留天数的按天统计，调用方需持有锁
+func (s *Store) prune(now time.Time) {
+	cutoff := now.Local().AddDate(0, 0, -s.cfg.RetentionDays).Format(dateLayout)
+	for date := range s.stats.Days {
+		if date < cutoff {
+			delete(s.stats.Days, date)
+		}
+	}
+}
+
+// Report 返回统计，days 为按天统计返回的天数（含今天）
+func (s *Store) Report(days int, now time.Time) Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := Report{
+		Since:    s.stats.Since,
+		Intents:  copyCounts(s.stats.Intents),
+		Days:     []DayCount{},
+		Agents:   copyGroups(s.stats.Agents),
+		Channels: copyGroups(s.stats.Channels),
+		Users:    copyGroups(s.stats.Users),
+	}
+	for _, n := range s.stats.Intents {
+		r.Total += n
+	}
+
+	from := now.Local().AddDate(0, 0, 1-days).Format(dateLayout)
+	for date, c := range s.stats.Days {
+		if date < from {
+			continue
+		}
+		d := DayCount{Date: date, Intents: copyCounts(c)}
+		for _, n := range c {
+			d.Total += n
+		}
+		r.Days = append(r.Days, d)
+	}
+	sort.Slice(r.Days, func(i, j int) bool { return r.Days[i].Date < r.Days[j].Date })
+	return r
+}
+
+// DeleteUser 删除用户的统计（/forgetme），返回删除的消息数。
+// 全部和按天的计数不区分用户，予以保留
+func (s *Store) DeleteUser(channel, userID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	owner := channel + ":" + userID
+	c, ok := s.stats.Users[owner]
+	if !ok {
+		return 0, nil
+	}
+	n := 0
+	for _, v := range c {
+		n += v
+	}
+	delete(s.stats.Users, owner)
+	return n, s.save()
+}
+
+// save 写入统计文件，调用方需持有锁
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(s.cfg.File); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	tmp := s.cfg.File + ".tmp"
+	if err := s.cfg.Cipher.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write analytics: %w", err)
+	}
+	return os.Rename(tmp, s.cfg.File)
+}
+
+func copyCounts(c counts) map[string]int {
+	result := make(map[string]int, len(c))
+	for k, v := range c {
+		result[k] = v
+	}
+	return result
+}
+
+func copyGroups(groups map[string]counts) map[string]map[string]int {
+	result := make(map[string]map[string]int, len(groups))
+	for k, c := range groups {
+		result[k] = copyCounts(c)
+	}
+	return result
+}
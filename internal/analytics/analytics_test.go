@@ -0,0 +1,116 @@
+package analytics
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/llm"
+	"github.com/HaohanHe/mujibot/internal/session"
+)
+
+// intentProvider 返回固定回答并记录系统提示词
+type intentProvider struct {
+	answer string
+	prompt string
+}
+
+func (p *intentProvider) Chat(messages []session.Message, tools []llm.Tool) (*llm.Response, error) {
+	p.prompt = messages[0].Content
+	return &llm.Response{Content: p.answer}, nil
+}
+
+func (p *intentProvider) ChatStream(messages []session.Message, tools []llm.Tool, callback func(chunk string)) (*llm.Response, error) {
+	return p.Chat(messages, tools)
+}
+
+func (p *intentProvider) GetModel() string {
+	return "test"
+}
+
+func TestStore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "analytics.json")
+	store, err := NewStore(Config{File: file, RetentionDays: 30})
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+	entries := []Entry{
+		{Time: now.AddDate(0, 0, -40), Channel: "telegram", UserID: "1", Agent: "default", Intent: "question"},
+		{Time: now.AddDate(0, 0, -1), Channel: "telegram", UserID: "1", Agent: "default", Intent: "command"},
+		{Time: now, Channel: "telegram", UserID: "1", Agent: "default", Intent: "command"},
+		{Time: now, Channel: "discord", UserID: "2", Agent: "home", Intent: "smalltalk"},
+	}
+	for _, e := range entries {
+		if err := store.Record(e); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	// 重新加载，超过保留天数的按天统计已删除
+	store, err = NewStore(Config{File: file, RetentionDays: 30})
+	if err != nil {
+		t.Fatalf("reload error = %v", err)
+	}
+	r := store.Report(7, now)
+	if r.Total != 4 || r.Intents["command"] != 2 || r.Intents["question"] != 1 {
+		t.Errorf("totals = %d %v", r.Total, r.Intents)
+	}
+	if len(r.Days) != 2 || r.Days[0].Date != "2024-05-09" || r.Days[1].Total != 2 || r.Days[1].Intents["smalltalk"] != 1 {
+		t.Errorf("days = %+v", r.Days)
+	}
+	if r.Agents["home"]["smalltalk"] != 1 || r.Channels["telegram"]["command"] != 2 || r.Users["telegram:1"]["question"] != 1 {
+		t.Errorf("groups = %v %v %v", r.Agents, r.Channels, r.Users)
+	}
+	if !r.Since.Equal(entries[0].Time) {
+		t.Errorf("since = %v", r.Since)
+	}
+	if r := store.Report(1, now); len(r.Days) != 1 {
+		t.Errorf("1 day report = %+v", r.Days)
+	}
+
+	n, err := store.DeleteUser("telegram", "1")
+	if err != nil || n != 3 {
+		t.Fatalf("DeleteUser() = %d, %v", n, err)
+	}
+	if r := store.Report(7, now); len(r.Users) != 1 || r.Total != 4 {
+		t.Errorf("after delete users = %v total = %d", r.Users, r.Total)
+	}
+}
+
+func TestTagger(t *testing.T) {
+	p := &intentProvider{}
+	tagger := NewTagger(p, nil)
+
+	tests := map[string]string{
+		"command":                   "command",
+		"Automation.":               "automation",
+		"**smalltalk**":             "smalltalk",
+		"The category is: question": "question",
+		"shopping":                  IntentOther,
+	}
+	for answer, want := range tests {
+		p.answer = answer
+		got, err := tagger.Tag("turn on the kitchen light")
+		if err != nil {
+			t.Fatalf("Tag() error = %v", err)
+		}
+		if got != want {
+			t.Errorf("Tag() with answer %q = %q, want %q", answer, got, want)
+		}
+	}
+	if !strings.Contains(p.prompt, "- automation: sets up something recurring") {
+		t.Errorf("prompt = %s", p.prompt)
+	}
+
+	custom := NewTagger(p, []string{" Shopping ", ""})
+	p.answer = "shopping"
+	if got, _ := custom.Tag("buy milk"); got != "shopping" {
+		t.Errorf("custom Tag() = %q", got)
+	}
+	if !strings.Contains(p.prompt, "categories:\n- shopping\nAnswer") {
+		t.Errorf("custom prompt = %s", p.prompt)
+	}
+}
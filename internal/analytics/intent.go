@@ -0,0 +1,90 @@
+package analytics
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/HaohanHe/mujibot/internal/llm"
+	"github.com/HaohanHe/mujibot/internal/session"
+)
+
+// IntentOther 模型回答不属于任何分类时使用
+const IntentOther = "other"
+
+// maxIntentChars 发送给模型分类的消息最大字符数
+const maxIntentChars = 1000
+
+// DefaultCategories 默认的意图分类
+var DefaultCategories = []string{"question", "command", "automation", "smalltalk"}
+
+// categoryHints 默认分类的说明，帮助模型区分
+var categoryHints = map[string]string{
+	"question":   "asks for information, an explanation or advice",
+	"command":    "asks the assistant to do something now, e.g. control a device, run a command, send or edit something",
+	"automation": "sets up something recurring or in the future: reminders, schedules, subscriptions, routines",
+	"smalltalk":  "greetings, thanks, chit-chat or feedback without a task",
+}
+
+const intentPrompt = `Classify the user's message to a personal assistant into exactly one of these categories:
+%s
+Answer with the category name only.`
+
+// Tagger 用LLM给消息标注意图
+type Tagger struct {
+	provider   llm.Provider
+	categories []string
+}
+
+// NewTagger 创建意图标注器，categories 为空时使用默认分类
+func NewTagger(provider llm.Provider, categories []string) *Tagger {
+	var cats []string
+	for _, c := range categories {
+		if c = strings.ToLower(strings.TrimSpace(c)); c != "" {
+			cats = append(cats, c)
+		}
+	}
+	if len(cats) == 0 {
+		cats = DefaultCategories
+	}
+	return &Tagger{provider: provider, categories: cats}
+}
+
+// Tag 返回消息的意图，模型回答无法识别时返回 other
+func (t *Tagger) Tag(message string) (string, error) {
+	if r := []rune(message); len(r) > maxIntentChars {
+		message = string(r[:maxIntentChars]) + "…"
+	}
+
+	var sb strings.Builder
+	for _, c := range t.categories {
+		if hint, ok := categoryHints[c]; ok {
+			fmt.Fprintf(&sb, "- %s: %s\n", c, hint)
+		} else {
+			fmt.Fprintf(&sb, "- %s\n", c)
+		}
+	}
+	resp, err := t.provider.Chat([]session.Message{
+		{Role: "system", Content: fmt.Sprintf(intentPrompt, strings.TrimSuffix(sb.String(), "\n"))},
+		{Role: "user", Content: message},
+	}, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to classify message: %w", err)
+	}
+	return t.match(resp.Content), nil
+}
+
+// match 在模型回答中找到分类名，优先完全匹配
+func (t *Tagger) match(answer string) string {
+	answer = strings.ToLower(strings.Trim(strings.TrimSpace(answer), ".\"'`*"))
+	for _, c := range t.categories {
+		if answer == c {
+			return c
+		}
+	}
+	for _, c := range t.categories {
+		if strings.Contains(answer, c) {
+			return c
+		}
+	}
+	return IntentOther
+}
@@ -5,11 +5,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/HaohanHe/mujibot/internal/channel"
 	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/httpclient"
+	"github.com/HaohanHe/mujibot/internal/i18n"
 	"github.com/HaohanHe/mujibot/internal/logger"
 )
 
@@ -21,7 +25,11 @@ type Bot struct {
 	gatewayURL    string
 	client        *http.Client
 	wsConn        *WebSocketConn
+	maxMessages   int
 	handlers      []MessageHandler
+	onSendError   func(channelID, text string, err error)
+	claim         func(key string) bool
+	translate     func(userID, key string, params i18n.Params) string
 	mu            sync.RWMutex
 	running       bool
 	stopCh        chan struct{}
@@ -30,6 +38,15 @@ type Bot struct {
 	log           *logger.Logger
 }
 
+const (
+	// maxMessageLen 单条消息的最大字符数
+	maxMessageLen = 2000
+	// defaultMaxMessages 长回复默认最多拆成的消息数
+	defaultMaxMessages = 4
+	// attachmentPreviewLen 以附件发送时消息中预览的字符数
+	attachmentPreviewLen = 300
+)
+
 // MessageHandler 消息处理函数
 type MessageHandler func(userID, username, content, channelID string) (string, error)
 
@@ -73,12 +90,18 @@ func NewBot(cfg config.DiscordConfig, log *logger.Logger) *Bot {
 		allowedGuilds[gid] = true
 	}
 
+	maxMessages := cfg.MaxMessages
+	if maxMessages <= 0 {
+		maxMessages = defaultMaxMessages
+	}
+
 	return &Bot{
 		token:         cfg.Token,
 		allowedGuilds: allowedGuilds,
 		apiURL:        "https://discord.com/api/v10",
 		gatewayURL:    "wss://gateway.discord.gg/?v=10&encoding=json",
-		client:        &http.Client{Timeout: 30 * time.Second},
+		client:        httpclient.New(30 * time.Second),
+		maxMessages:   maxMessages,
 		handlers:      make([]MessageHandler, 0),
 		stopCh:        make(chan struct{}),
 		log:           log,
@@ -92,6 +115,38 @@ func (b *Bot) OnMessage(handler MessageHandler) {
 	b.handlers = append(b.handlers, handler)
 }
 
+// SetClaimer 设置事件抢占函数。多个实例共用同一个Bot Token时，只处理抢占成功的交互，避免重复回复
+func (b *Bot) SetClaimer(claim func(key string) bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.claim = claim
+}
+
+// SetTranslator 设置提示文本的翻译函数，按用户的语言返回 key 对应的文本并替换占位符
+func (b *Bot) SetTranslator(tr func(userID, key string, params i18n.Params) string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.translate = tr
+}
+
+// text 返回提示文本，未设置翻译函数时使用英文
+func (b *Bot) text(userID, key string, params i18n.Params) string {
+	b.mu.RLock()
+	tr := b.translate
+	b.mu.RUnlock()
+	if tr == nil {
+		return i18n.New("en-US").Tf(key, params)
+	}
+	return tr(userID, key, params)
+}
+
+// OnSendError 设置回复发送失败时的处理函数，如放入发件箱稍后重试
+func (b *Bot) OnSendError(fn func(channelID, text string, err error)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onSendError = fn
+}
+
 // Start 启动Bot
 func (b *Bot) Start() error {
 	b.mu.Lock()
@@ -142,18 +197,76 @@ func (b *Bot) IsRunning() bool {
 	return b.running
 }
 
-// SendMessage 发送消息
+// SendMessage 发送消息。超过2000字符时在空行和代码块边界处拆成多条，
+// 拆分后超过 maxMessages 条（如很长的日志、diff）时附上预览，完整内容作为 .txt 附件发送
 func (b *Bot) SendMessage(channelID, content string) error {
-	// 限制消息长度
-	if len(content) > 2000 {
-		content = content[:1997] + "..."
+	return b.sendMessage("", channelID, content)
+}
+
+// sendMessage 发送消息，userID 为回复的用户，用于选择附件说明的语言，主动发送时为空
+func (b *Bot) sendMessage(userID, channelID, content string) error {
+	chunks := channel.Split(content, maxMessageLen, channel.Runes)
+	if len(chunks) > b.maxMessages {
+		preview := channel.Split(content, attachmentPreviewLen, channel.Runes)[0]
+		note := preview + "\n\n📎 " + b.text(userID, "responseAttached", i18n.Params{"n": channel.Len(content, channel.Runes)})
+		return b.SendFile(channelID, "response.txt", []byte(content), note)
 	}
 
-	reqBody := map[string]interface{}{
-		"content": content,
+	for _, chunk := range chunks {
+		reqBody := map[string]interface{}{
+			"content": chunk,
+		}
+		if err := b.apiRequest("POST", "/channels/"+channelID+"/messages", reqBody); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	return b.apiRequest("POST", "/channels/"+channelID+"/messages", reqBody)
+// SendFile 发送文件附件
+func (b *Bot) SendFile(channelID, filename string, data []byte, content string) error {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	payload, err := json.Marshal(map[string]interface{}{"content": content})
+	if err != nil {
+		return err
+	}
+	if err := w.WriteField("payload_json", string(payload)); err != nil {
+		return err
+	}
+
+	part, err := w.CreateFormFile("files[0]", filename)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", b.apiURL+"/channels/"+channelID+"/messages", &buf)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bot "+b.token)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("discord api error: %s - %s", resp.Status, string(respBody))
+	}
+
+	return nil
 }
 
 // getGatewayURL 获取网关URL
@@ -244,13 +357,18 @@ func (b *Bot) HandleWebhook(body []byte) error {
 
 		content := "/" + interaction.Data.Name
 
-		b.log.Info("discord command received", "user_id", userID, "username", username, "command", content)
-
-		// 调用处理器
 		b.mu.RLock()
+		claim := b.claim
 		handlers := make([]MessageHandler, len(b.handlers))
 		copy(handlers, b.handlers)
 		b.mu.RUnlock()
+		if claim != nil && !claim("discord:interaction:"+interaction.ID) {
+			return nil
+		}
+
+		b.log.Info("discord command received", "user_id", userID, "username", username, "command", content)
+
+		// 调用处理器
 
 		for _, handler := range handlers {
 			go func(h MessageHandler) {
@@ -267,8 +385,14 @@ func (b *Bot) HandleWebhook(body []byte) error {
 				}
 
 				if response != "" {
-					if err := b.SendMessage(channelID, response); err != nil {
+					if err := b.sendMessage(userID, channelID, response); err != nil {
 						b.log.Error("failed to send message", "error", err)
+						b.mu.RLock()
+						onSendError := b.onSendError
+						b.mu.RUnlock()
+						if onSendError != nil {
+							onSendError(channelID, response, err)
+						}
 					}
 				}
 			}(handler)
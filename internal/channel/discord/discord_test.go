@@ -0,0 +1,125 @@
+package discord
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/i18n"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestSendMessageLong(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	var messages []string
+	var files, notes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+			f, _, err := r.FormFile("files[0]")
+			if err != nil {
+				t.Errorf("missing attachment: %v", err)
+				return
+			}
+			data, _ := io.ReadAll(f)
+			files = append(files, string(data))
+			var payload struct {
+				Content string `json:"content"`
+			}
+			json.Unmarshal([]byte(r.FormValue("payload_json")), &payload)
+			notes = append(notes, payload.Content)
+			return
+		}
+		var body struct {
+			Content string `json:"content"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		messages = append(messages, body.Content)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	b := NewBot(config.DiscordConfig{MaxMessages: 2}, log)
+	b.apiURL = srv.URL
+
+	para := strings.Repeat("a", 1500)
+	if err := b.SendMessage("c1", para+"\n\n"+para); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 || messages[0] != para || messages[1] != para {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+
+	long := strings.Repeat(para+"\n\n", 3)
+	if err := b.SendMessage("c1", long); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 || len(files) != 1 || files[0] != long {
+		t.Fatalf("expected attachment, got %d messages and %d files", len(messages), len(files))
+	}
+	if !strings.HasSuffix(notes[0], "📎 4506 characters, full response attached") {
+		t.Errorf("note = %q", notes[0])
+	}
+
+	// 回复时按用户的语言说明附件
+	b.SetTranslator(func(userID, key string, params i18n.Params) string {
+		if userID != "u1" {
+			t.Errorf("translator user = %q", userID)
+		}
+		return i18n.New("de-DE").Tf(key, params)
+	})
+	if err := b.sendMessage("u1", "c1", long); err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 2 || !strings.HasSuffix(notes[1], "📎 4506 Zeichen, vollständige Antwort im Anhang") {
+		t.Errorf("translated note = %q", notes[len(notes)-1])
+	}
+}
+
+func TestHandleWebhookClaim(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	// 两个实例共用同一份抢占记录
+	var mu sync.Mutex
+	claimed := map[string]bool{}
+	claim := func(key string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if claimed[key] {
+			return false
+		}
+		claimed[key] = true
+		return true
+	}
+
+	calls := make(chan string, 4)
+	body := []byte(`{"type": 2, "id": "i1", "channel_id": "c1", "data": {"name": "status"}, "member": {"user": {"id": "u1"}}}`)
+	for i := 0; i < 2; i++ {
+		b := NewBot(config.DiscordConfig{}, log)
+		b.SetClaimer(claim)
+		b.OnMessage(func(userID, username, content, channelID string) (string, error) {
+			calls <- content
+			return "", nil
+		})
+		if err := b.HandleWebhook(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := <-calls; got != "/status" {
+		t.Errorf("content = %q", got)
+	}
+	select {
+	case <-calls:
+		t.Error("interaction handled by both instances")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -14,6 +14,8 @@ import (
 	"time"
 
 	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/httpapi"
+	"github.com/HaohanHe/mujibot/internal/httpclient"
 	"github.com/HaohanHe/mujibot/internal/logger"
 )
 
@@ -28,12 +30,15 @@ type Bot struct {
 	accessToken    string
 	tokenExpireAt  time.Time
 	handlers       []MessageHandler
+	translate      func(userID, key string) string
+	claim          func(key string) bool
+	onSendError    func(userID, text string, err error)
 	mu             sync.RWMutex
 	log            *logger.Logger
 }
 
-// MessageHandler 消息处理函数
-type MessageHandler func(userID, username, content string) (string, error)
+// MessageHandler 消息处理函数，quoted 为用户回复的那条消息的内容，不是回复时为空
+type MessageHandler func(userID, username, content, quoted string) (string, error)
 
 // Event 飞书事件
 type Event struct {
@@ -58,6 +63,7 @@ type MessageEvent struct {
 	} `json:"sender"`
 	Message struct {
 		MessageID   string `json:"message_id"`
+		ParentID    string `json:"parent_id"` // 回复的消息ID
 		MessageType string `json:"message_type"`
 		Content     string `json:"content"`
 		ChatID      string `json:"chat_id"`
@@ -78,7 +84,7 @@ func NewBot(cfg config.FeishuConfig, log *logger.Logger) *Bot {
 		encryptKey:   cfg.EncryptKey,
 		allowedUsers: allowedUsers,
 		apiURL:       "https://open.feishu.cn/open-apis",
-		client:       &http.Client{Timeout: 30 * time.Second},
+		client:       httpclient.New(30 * time.Second),
 		handlers:     make([]MessageHandler, 0),
 		log:          log,
 	}
@@ -91,6 +97,38 @@ func (b *Bot) OnMessage(handler MessageHandler) {
 	b.handlers = append(b.handlers, handler)
 }
 
+// SetTranslator 设置提示文本的翻译函数，按用户的语言返回 key 对应的文本
+func (b *Bot) SetTranslator(tr func(userID, key string) string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.translate = tr
+}
+
+// SetClaimer 设置事件抢占函数。多个实例接收同一应用的事件时，只处理抢占成功的消息，避免重复回复
+func (b *Bot) SetClaimer(claim func(key string) bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.claim = claim
+}
+
+// OnSendError 设置回复发送失败时的处理函数，如放入发件箱稍后重试
+func (b *Bot) OnSendError(fn func(userID, text string, err error)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onSendError = fn
+}
+
+// text 返回提示文本，未设置翻译函数时使用默认文本
+func (b *Bot) text(userID, key, def string) string {
+	b.mu.RLock()
+	tr := b.translate
+	b.mu.RUnlock()
+	if tr == nil {
+		return def
+	}
+	return tr(userID, key)
+}
+
 // Start 启动Bot（飞书通过Webhook接收事件，不需要主动启动）
 func (b *Bot) Start() error {
 	b.log.Info("feishu bot initialized", "app_id", b.appID)
@@ -161,6 +199,14 @@ func (b *Bot) handleEventCallback(eventData json.RawMessage) error {
 		return err
 	}
 
+	// 多实例接收同一应用的事件时，每条消息只由一个实例处理
+	b.mu.RLock()
+	claim := b.claim
+	b.mu.RUnlock()
+	if claim != nil && !claim("feishu:message:"+msgEvent.Message.MessageID) {
+		return nil
+	}
+
 	userID := msgEvent.Sender.SenderID.OpenID
 	username := msgEvent.Sender.SenderID.UserID
 	content := b.parseMessageContent(msgEvent.Message.Content, msgEvent.Message.MessageType)
@@ -168,12 +214,21 @@ func (b *Bot) handleEventCallback(eventData json.RawMessage) error {
 	// 检查用户权限
 	if len(b.allowedUsers) > 0 && !b.allowedUsers[userID] {
 		b.log.Warn("unauthorized user", "user_id", userID)
-		b.SendMessage(userID, "⛔ 未授权的用户")
+		b.SendMessage(userID, "⛔ "+b.text(userID, "unauthorizedUser", "未授权的用户"))
 		return nil
 	}
 
 	b.log.Info("feishu message received", "user_id", userID, "username", username, "content", truncate(content, 50))
 
+	// 回复某条消息时答复也回复到用户的这条消息下
+	parentID := msgEvent.Message.ParentID
+	send := func(text string) error {
+		if parentID != "" {
+			return b.ReplyMessage(msgEvent.Message.MessageID, text)
+		}
+		return b.SendMessage(userID, text)
+	}
+
 	// 调用处理器
 	b.mu.RLock()
 	handlers := make([]MessageHandler, len(b.handlers))
@@ -188,16 +243,31 @@ func (b *Bot) handleEventCallback(eventData json.RawMessage) error {
 				}
 			}()
 
-			response, err := h(userID, username, content)
+			// 被回复的内容在处理协程中获取，不拖慢事件回调的响应
+			var quoted string
+			if parentID != "" {
+				var err error
+				if quoted, err = b.getMessageContent(parentID); err != nil {
+					b.log.Warn("failed to get replied message", "message_id", parentID, "error", err)
+				}
+			}
+
+			response, err := h(userID, username, content, quoted)
 			if err != nil {
 				b.log.Error("handler error", "error", err)
-				b.SendMessage(userID, "❌ 处理消息时出错: "+err.Error())
+				send("❌ " + b.text(userID, "processingError", "处理消息时出错") + ": " + err.Error())
 				return
 			}
 
 			if response != "" {
-				if err := b.SendMessage(userID, response); err != nil {
+				if err := send(response); err != nil {
 					b.log.Error("failed to send message", "error", err)
+					b.mu.RLock()
+					onSendError := b.onSendError
+					b.mu.RUnlock()
+					if onSendError != nil {
+						onSendError(userID, response, err)
+					}
 				}
 			}
 		}(handler)
@@ -228,6 +298,52 @@ func (b *Bot) SendMessage(userID, content string) error {
 	return b.apiRequest("POST", "/im/v1/messages?receive_id_type=open_id", reqBody)
 }
 
+// ReplyMessage 回复指定消息
+func (b *Bot) ReplyMessage(messageID, content string) error {
+	if err := b.ensureAccessToken(); err != nil {
+		return fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	contentData, _ := json.Marshal(map[string]interface{}{"text": content})
+	reqBody := map[string]interface{}{
+		"content":  string(contentData),
+		"msg_type": "text",
+	}
+
+	return b.apiRequest("POST", "/im/v1/messages/"+messageID+"/reply", reqBody)
+}
+
+// getMessageContent 获取指定消息的文本内容
+func (b *Bot) getMessageContent(messageID string) (string, error) {
+	if err := b.ensureAccessToken(); err != nil {
+		return "", fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			Items []struct {
+				MsgType string `json:"msg_type"`
+				Body    struct {
+					Content string `json:"content"`
+				} `json:"body"`
+			} `json:"items"`
+		} `json:"data"`
+	}
+	if err := b.apiCall("GET", "/im/v1/messages/"+messageID, nil, &result); err != nil {
+		return "", err
+	}
+	if result.Code != 0 {
+		return "", fmt.Errorf("feishu api error: %d - %s", result.Code, result.Msg)
+	}
+	if len(result.Data.Items) == 0 {
+		return "", fmt.Errorf("message not found: %s", messageID)
+	}
+	item := result.Data.Items[0]
+	return b.parseMessageContent(item.Body.Content, item.MsgType), nil
+}
+
 // SendRichMessage 发送富文本消息
 func (b *Bot) SendRichMessage(userID string, content map[string]interface{}) error {
 	// 确保有访问令牌
@@ -305,6 +421,11 @@ func (b *Bot) ensureAccessToken() error {
 
 // apiRequest 发送API请求
 func (b *Bot) apiRequest(method, endpoint string, reqBody map[string]interface{}) error {
+	return b.apiCall(method, endpoint, reqBody, nil)
+}
+
+// apiCall 发送API请求，out 不为nil时解析响应
+func (b *Bot) apiCall(method, endpoint string, reqBody map[string]interface{}, out interface{}) error {
 	var body io.Reader
 	if reqBody != nil {
 		data, err := json.Marshal(reqBody)
@@ -333,6 +454,9 @@ func (b *Bot) apiRequest(method, endpoint string, reqBody map[string]interface{}
 		return fmt.Errorf("feishu api error: %s - %s", resp.Status, string(respBody))
 	}
 
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
 	return nil
 }
 
@@ -405,13 +529,13 @@ func truncate(s string, maxLen int) string {
 func (b *Bot) GetWebhookHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
 			return
 		}
 
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
-			http.Error(w, "Failed to read body", http.StatusBadRequest)
+			httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "Failed to read body")
 			return
 		}
 		defer r.Body.Close()
@@ -419,7 +543,7 @@ func (b *Bot) GetWebhookHandler() http.HandlerFunc {
 		response, err := b.HandleEvent(body)
 		if err != nil {
 			b.log.Error("failed to handle event", "error", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			httpapi.RespondError(w, r, http.StatusInternalServerError, httpapi.CodeInternal, err.Error())
 			return
 		}
 
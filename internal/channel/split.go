@@ -0,0 +1,136 @@
+// Package channel 各消息渠道共用的辅助函数
+package channel
+
+import "strings"
+
+// Len 按 size 计算字符串长度，size 返回单个字符计入的长度（如 Telegram 按 UTF-16 单位计算）
+func Len(s string, size func(rune) int) int {
+	n := 0
+	for _, r := range s {
+		n += size(r)
+	}
+	return n
+}
+
+// Runes 每个字符计为 1，用于按字符数限制长度的渠道（如 Discord）
+func Runes(rune) int {
+	return 1
+}
+
+// IsFence 是否为 Markdown 代码块的开始或结束行
+func IsFence(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "```")
+}
+
+// Split 按长度拆分消息，优先在空行和代码块边界处拆分；
+// 代码块本身超长时按行拆开，每段都带上原来的开始标记并闭合
+func Split(text string, limit int, size func(rune) int) []string {
+	if Len(text, size) <= limit {
+		return []string{text}
+	}
+
+	var pieces []string
+	for _, group := range splitGroups(text) {
+		if Len(group, size) <= limit {
+			pieces = append(pieces, group)
+			continue
+		}
+		lines := strings.Split(group, "\n")
+		if !IsFence(lines[0]) {
+			pieces = append(pieces, pack(lines, limit, size)...)
+			continue
+		}
+		header, inner := lines[0], lines[1:]
+		if n := len(inner); n > 0 && IsFence(inner[n-1]) {
+			inner = inner[:n-1]
+		}
+		for _, p := range pack(inner, limit-Len(header, size)-len("\n\n```"), size) {
+			pieces = append(pieces, header+"\n"+p+"\n```")
+		}
+	}
+
+	var chunks []string
+	for _, chunk := range pack(pieces, limit, size) {
+		if chunk = strings.TrimRight(chunk, "\n"); strings.TrimSpace(chunk) != "" {
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks
+}
+
+// splitGroups 按空行和代码块边界把文本分组，代码块单独成组
+func splitGroups(text string) []string {
+	var groups, cur []string
+	inFence := false
+	flush := func() {
+		if len(cur) > 0 {
+			groups = append(groups, strings.Join(cur, "\n"))
+			cur = nil
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if IsFence(line) && !inFence {
+			flush()
+		}
+		cur = append(cur, line)
+		if IsFence(line) {
+			if inFence = !inFence; !inFence {
+				flush()
+			}
+			continue
+		}
+		if !inFence && strings.TrimSpace(line) == "" {
+			flush()
+		}
+	}
+	flush()
+	return groups
+}
+
+// pack 把多段文本用换行拼接成不超过 limit 的若干段，单段超长时硬拆
+func pack(items []string, limit int, size func(rune) int) []string {
+	var result []string
+	var cur strings.Builder
+	curLen := -1 // -1 表示当前段为空
+	emit := func() {
+		if curLen >= 0 {
+			result = append(result, cur.String())
+			cur.Reset()
+			curLen = -1
+		}
+	}
+	for _, item := range items {
+		for Len(item, size) > limit {
+			emit()
+			head, tail := cutAt(item, limit, size)
+			result = append(result, head)
+			item = tail
+		}
+		n := Len(item, size)
+		if curLen >= 0 && curLen+1+n > limit {
+			emit()
+		}
+		if curLen >= 0 {
+			cur.WriteByte('\n')
+			curLen++
+		} else {
+			curLen = 0
+		}
+		cur.WriteString(item)
+		curLen += n
+	}
+	emit()
+	return result
+}
+
+// cutAt 在长度 limit 处切开字符串，至少保留一个字符
+func cutAt(s string, limit int, size func(rune) int) (string, string) {
+	n := 0
+	for i, r := range s {
+		n += size(r)
+		if n > limit && i > 0 {
+			return s[:i], s[i:]
+		}
+	}
+	return s, ""
+}
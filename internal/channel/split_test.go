@@ -0,0 +1,43 @@
+package channel
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	if got := Split("short", 100, Runes); len(got) != 1 || got[0] != "short" {
+		t.Fatalf("short message = %q", got)
+	}
+
+	// 在空行处拆分
+	para := strings.Repeat("a", 40)
+	got := Split(para+"\n\n"+para+"\n\n"+para, 100, Runes)
+	if len(got) != 2 || got[0] != para+"\n\n"+para || got[1] != para {
+		t.Errorf("paragraphs = %q", got)
+	}
+
+	// 超长代码块拆开后每段的代码块标记都成对，续接的段重新打开原语言的代码块
+	code := "```go\n" + strings.Repeat("x := 1\n", 30) + "```"
+	got = Split("intro\n"+code+"\noutro", 100, Runes)
+	if len(got) < 3 || got[0] != "intro" || !strings.HasSuffix(got[len(got)-1], "```\noutro") {
+		t.Errorf("chunks = %q", got)
+	}
+	for _, chunk := range got {
+		if n := Len(chunk, Runes); n > 100 {
+			t.Errorf("chunk too long (%d): %q", n, chunk)
+		}
+		if strings.Count(chunk, "```")%2 != 0 {
+			t.Errorf("unbalanced fences: %q", chunk)
+		}
+		if strings.Contains(chunk, "x := 1") && !strings.HasPrefix(chunk, "```go\n") {
+			t.Errorf("code chunk not reopened: %q", chunk)
+		}
+	}
+
+	// 单行超长时硬拆
+	got = Split(strings.Repeat("字", 150), 100, Runes)
+	if len(got) != 2 || Len(got[0], Runes) != 100 || Len(got[1], Runes) != 50 {
+		t.Errorf("long line = %q", got)
+	}
+}
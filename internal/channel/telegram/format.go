@@ -0,0 +1,134 @@
+package telegram
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/HaohanHe/mujibot/internal/channel"
+)
+
+// maxMessageLen 单条消息的最大长度（UTF-16 单位），Telegram 上限为 4096
+const maxMessageLen = 4096
+
+// markdownV2Special MarkdownV2 中普通文本需要转义的字符
+const markdownV2Special = "_*[]()~`>#+-=|{}.!\\"
+
+var (
+	linkPattern    = regexp.MustCompile(`^\[([^\]\n]+)\]\(([^)\s]+)\)`)
+	headingPattern = regexp.MustCompile(`^#{1,6}\s+(.+)$`)
+)
+
+// textLen Telegram 按 UTF-16 单位计算长度
+func textLen(s string) int {
+	return channel.Len(s, runeLen)
+}
+
+// runeLen 字符的 UTF-16 长度，基本平面以外的字符（如表情）占两个单位
+func runeLen(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// splitMessage 按 Telegram 的长度计算方式拆分消息
+func splitMessage(text string, limit int) []string {
+	return channel.Split(text, limit, runeLen)
+}
+
+// toMarkdownV2 把模型输出的常见 Markdown 转换为 Telegram MarkdownV2：
+// 保留代码块、行内代码、**粗体**、链接，标题转为粗体，其余特殊字符全部转义
+func toMarkdownV2(text string) string {
+	var sb strings.Builder
+	inFence := false
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		if channel.IsFence(line) {
+			trimmed := strings.TrimSpace(line)
+			if inFence {
+				sb.WriteString("```")
+			} else {
+				// 去掉语言标记中可能破坏格式的字符
+				sb.WriteString("```" + strings.Map(func(r rune) rune {
+					if r == '_' || r == '-' || r == '+' || r == '#' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+						return r
+					}
+					return -1
+				}, strings.TrimPrefix(trimmed, "```")))
+			}
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			sb.WriteString(escapeCode(line))
+			continue
+		}
+		if m := headingPattern.FindStringSubmatch(line); m != nil {
+			sb.WriteString("*" + escapeMarkdownV2(strings.ReplaceAll(m[1], "**", "")) + "*")
+			continue
+		}
+		sb.WriteString(convertInline(line))
+	}
+	if inFence {
+		sb.WriteString("\n```")
+	}
+	return sb.String()
+}
+
+// convertInline 转换一行普通文本中的行内代码、粗体和链接
+func convertInline(line string) string {
+	var sb strings.Builder
+	for len(line) > 0 {
+		switch {
+		case line[0] == '`':
+			if end := strings.IndexByte(line[1:], '`'); end > 0 {
+				sb.WriteString("`" + escapeCode(line[1:end+1]) + "`")
+				line = line[end+2:]
+				continue
+			}
+		case strings.HasPrefix(line, "**"):
+			if end := strings.Index(line[2:], "**"); end > 0 {
+				sb.WriteString("*" + escapeMarkdownV2(line[2:end+2]) + "*")
+				line = line[end+4:]
+				continue
+			}
+		case line[0] == '[':
+			if m := linkPattern.FindStringSubmatch(line); m != nil {
+				url := strings.NewReplacer(`\`, `\\`, `)`, `\)`).Replace(m[2])
+				sb.WriteString("[" + escapeMarkdownV2(m[1]) + "](" + url + ")")
+				line = line[len(m[0]):]
+				continue
+			}
+		}
+		_, size := utf8.DecodeRuneInString(line)
+		sb.WriteString(escapeMarkdownV2(line[:size]))
+		line = line[size:]
+	}
+	return sb.String()
+}
+
+// escapeMarkdownV2 转义普通文本
+func escapeMarkdownV2(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(markdownV2Special, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// escapeCode 转义代码中的 ` 和 \
+func escapeCode(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(s)
+}
+
+// isParseError Telegram 无法解析格式时的错误
+func isParseError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "can't parse entities")
+}
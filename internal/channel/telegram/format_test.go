@@ -0,0 +1,34 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToMarkdownV2(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Hello. 1+1=2!", `Hello\. 1\+1\=2\!`},
+		{"use **write_file** or `a_b`", "use *write\\_file* or `a_b`"},
+		{"see [docs_v2](https://x.io/a_b?q=1)", `see [docs\_v2](https://x.io/a_b?q=1)`},
+		{"## Step 1.", `*Step 1\.*`},
+		{"```go\nfmt.Println(\"a`b\") // x_y\n```", "```go\nfmt.Println(\"a\\`b\") // x_y\n```"},
+		{"```\nunclosed", "```\nunclosed\n```"},
+		{"a * b_c", `a \* b\_c`},
+		{"中文（括号）", "中文（括号）"},
+	}
+	for _, tt := range tests {
+		if got := toMarkdownV2(tt.in); got != tt.want {
+			t.Errorf("toMarkdownV2(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	// 按 UTF-16 计算长度，表情占两个单位
+	got := splitMessage(strings.Repeat("😀", 60), 100)
+	if len(got) != 2 || textLen(got[0]) != 100 || textLen(got[1]) != 20 {
+		t.Errorf("long line = %d chunks", len(got))
+	}
+}
@@ -1,9 +1,11 @@
 package telegram
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,6 +13,7 @@ import (
 	"time"
 
 	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/httpclient"
 	"github.com/HaohanHe/mujibot/internal/logger"
 )
 
@@ -22,14 +25,21 @@ type Bot struct {
 	client       *http.Client
 	updateOffset int64
 	handlers     []MessageHandler
+	onReply      ReplyHandler
+	claim        func(key string) bool
+	translate    func(userID int64, key string) string
+	onSendError  func(chatID int64, text string, err error)
 	mu           sync.RWMutex
 	running      bool
 	stopCh       chan struct{}
 	log          *logger.Logger
 }
 
-// MessageHandler 消息处理函数
-type MessageHandler func(userID int64, username, text string, chatID int64) (string, error)
+// MessageHandler 消息处理函数，quoted 为用户回复的那条消息的文本，不是回复时为空
+type MessageHandler func(userID int64, username, text, quoted string, chatID int64) (string, error)
+
+// ReplyHandler 回复消息处理函数，replyTo 为被回复消息的文本，handled 为 false 时交给普通消息处理器
+type ReplyHandler func(userID int64, username, text, replyTo string, chatID int64) (response string, handled bool, err error)
 
 // Update Telegram更新
 type Update struct {
@@ -44,6 +54,7 @@ type Message struct {
 	Chat      *Chat    `json:"chat"`
 	Date      int64    `json:"date"`
 	Text      string   `json:"text"`
+	ReplyTo   *Message `json:"reply_to_message"`
 }
 
 // User Telegram用户
@@ -71,7 +82,7 @@ func NewBot(cfg config.TelegramConfig, log *logger.Logger) *Bot {
 		token:        cfg.Token,
 		allowedUsers: allowedUsers,
 		apiURL:       "https://api.telegram.org/bot" + cfg.Token,
-		client:       &http.Client{Timeout: 30 * time.Second},
+		client:       httpclient.New(30 * time.Second),
 		handlers:     make([]MessageHandler, 0),
 		stopCh:       make(chan struct{}),
 		log:          log,
@@ -85,6 +96,45 @@ func (b *Bot) OnMessage(handler MessageHandler) {
 	b.handlers = append(b.handlers, handler)
 }
 
+// OnReply 注册回复消息处理器
+func (b *Bot) OnReply(handler ReplyHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onReply = handler
+}
+
+// OnSendError 设置回复发送失败时的处理函数，如放入发件箱稍后重试
+func (b *Bot) OnSendError(fn func(chatID int64, text string, err error)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onSendError = fn
+}
+
+// SetClaimer 设置更新抢占函数。多个实例共用同一个Bot Token时，只处理抢占成功的更新，避免重复回复
+func (b *Bot) SetClaimer(claim func(key string) bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.claim = claim
+}
+
+// SetTranslator 设置提示文本的翻译函数，按用户的语言返回 key 对应的文本
+func (b *Bot) SetTranslator(tr func(userID int64, key string) string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.translate = tr
+}
+
+// text 返回提示文本，未设置翻译函数时使用默认文本
+func (b *Bot) text(userID int64, key, def string) string {
+	b.mu.RLock()
+	tr := b.translate
+	b.mu.RUnlock()
+	if tr == nil {
+		return def
+	}
+	return tr(userID, key)
+}
+
 // Start 启动Bot
 func (b *Bot) Start() error {
 	b.mu.Lock()
@@ -131,18 +181,37 @@ func (b *Bot) IsRunning() bool {
 
 // SendMessage 发送消息
 func (b *Bot) SendMessage(chatID int64, text string) error {
-	// 限制消息长度
-	if len(text) > 4096 {
-		text = text[:4093] + "..."
-	}
+	return b.SendReply(chatID, 0, text)
+}
 
-	reqBody := map[string]interface{}{
-		"chat_id": chatID,
-		"text":    text,
-		"parse_mode": "Markdown",
-	}
+// SendReply 作为对指定消息的回复发送，replyTo 为0时发送普通消息。
+// 超长消息在空行和代码块边界处拆成多条发送，只有第一条作为回复；
+// 以 MarkdownV2 格式发送，Telegram 无法解析格式时改为纯文本重发
+func (b *Bot) SendReply(chatID, replyTo int64, text string) error {
+	for i, chunk := range splitMessage(text, maxMessageLen) {
+		reqBody := map[string]interface{}{
+			"chat_id":    chatID,
+			"text":       toMarkdownV2(chunk),
+			"parse_mode": "MarkdownV2",
+		}
+		if replyTo != 0 && i == 0 {
+			reqBody["reply_to_message_id"] = replyTo
+			// 被回复的消息已删除时仍然发送
+			reqBody["allow_sending_without_reply"] = true
+		}
 
-	return b.apiRequest("sendMessage", reqBody)
+		err := b.apiRequest("sendMessage", reqBody)
+		if isParseError(err) {
+			b.log.Warn("markdown rejected, sending as plain text", "error", err)
+			reqBody["text"] = chunk
+			delete(reqBody, "parse_mode")
+			err = b.apiRequest("sendMessage", reqBody)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // SendHTMLMessage 发送HTML格式消息
@@ -161,6 +230,34 @@ func (b *Bot) SendHTMLMessage(chatID int64, text string) error {
 	return b.apiRequest("sendMessage", reqBody)
 }
 
+// SendDocument 发送文件附件
+func (b *Bot) SendDocument(chatID int64, filename string, data []byte, caption string) error {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	if err := w.WriteField("chat_id", strconv.FormatInt(chatID, 10)); err != nil {
+		return err
+	}
+	if caption != "" {
+		if err := w.WriteField("caption", caption); err != nil {
+			return err
+		}
+	}
+
+	part, err := w.CreateFormFile("document", filename)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return b.post("sendDocument", w.FormDataContentType(), &buf)
+}
+
 // getMe 获取Bot信息
 func (b *Bot) getMe() error {
 	resp, err := b.client.Get(b.apiURL + "/getMe")
@@ -219,9 +316,15 @@ func (b *Bot) pollLoop() {
 			// 重置退避
 			backoff = time.Second
 
+			b.mu.RLock()
+			claim := b.claim
+			b.mu.RUnlock()
+
 			// 处理更新
 			for _, update := range updates {
-				b.handleUpdate(update)
+				if claim == nil || claim(fmt.Sprintf("telegram:update:%d", update.UpdateID)) {
+					b.handleUpdate(update)
+				}
 				if update.UpdateID >= b.updateOffset {
 					b.updateOffset = update.UpdateID + 1
 				}
@@ -277,13 +380,47 @@ func (b *Bot) handleUpdate(update Update) {
 	// 检查用户权限
 	if len(b.allowedUsers) > 0 && !b.allowedUsers[userID] {
 		b.log.Warn("unauthorized user", "user_id", userID, "username", username)
-		b.SendMessage(msg.Chat.ID, "⛔ 未授权的用户")
+		b.SendMessage(msg.Chat.ID, "⛔ "+b.text(userID, "unauthorizedUser", "未授权的用户"))
 		return
 	}
 
 	b.log.Info("telegram message received", "user_id", userID, "username", username, "text", truncate(msg.Text, 50))
 
-	// 调用处理器
+	b.mu.RLock()
+	onReply := b.onReply
+	b.mu.RUnlock()
+
+	// 回复消息先交给回复处理器（如向终端会话输入），未处理时按普通消息处理
+	if msg.ReplyTo != nil && msg.ReplyTo.Text != "" && onReply != nil {
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					b.log.Error("reply handler panic", "error", r)
+				}
+			}()
+
+			response, handled, err := onReply(userID, username, msg.Text, msg.ReplyTo.Text, msg.Chat.ID)
+			if !handled {
+				b.dispatch(msg, userID, username)
+				return
+			}
+			b.respond(userID, msg.Chat.ID, 0, response, err)
+		}()
+		return
+	}
+
+	b.dispatch(msg, userID, username)
+}
+
+// dispatch 调用消息处理器。用户回复了某条消息时，把被回复的内容交给处理器，并以回复的形式答复
+func (b *Bot) dispatch(msg *Message, userID int64, username string) {
+	var quoted string
+	var replyTo int64
+	if msg.ReplyTo != nil {
+		quoted = msg.ReplyTo.Text
+		replyTo = msg.MessageID
+	}
+
 	b.mu.RLock()
 	handlers := make([]MessageHandler, len(b.handlers))
 	copy(handlers, b.handlers)
@@ -297,19 +434,30 @@ func (b *Bot) handleUpdate(update Update) {
 				}
 			}()
 
-			response, err := h(userID, username, msg.Text, msg.Chat.ID)
-			if err != nil {
-				b.log.Error("handler error", "error", err)
-				b.SendMessage(msg.Chat.ID, "❌ 处理消息时出错: "+err.Error())
-				return
-			}
+			response, err := h(userID, username, msg.Text, quoted, msg.Chat.ID)
+			b.respond(userID, msg.Chat.ID, replyTo, response, err)
+		}(handler)
+	}
+}
 
-			if response != "" {
-				if err := b.SendMessage(msg.Chat.ID, response); err != nil {
-					b.log.Error("failed to send message", "error", err)
-				}
+// respond 发送处理结果或错误，replyTo 不为0时回复到该消息
+func (b *Bot) respond(userID, chatID, replyTo int64, response string, err error) {
+	if err != nil {
+		b.log.Error("handler error", "error", err)
+		b.SendReply(chatID, replyTo, "❌ "+b.text(userID, "processingError", "处理消息时出错")+": "+err.Error())
+		return
+	}
+
+	if response != "" {
+		if err := b.SendReply(chatID, replyTo, response); err != nil {
+			b.log.Error("failed to send message", "error", err)
+			b.mu.RLock()
+			onSendError := b.onSendError
+			b.mu.RUnlock()
+			if onSendError != nil {
+				onSendError(chatID, response, err)
 			}
-		}(handler)
+		}
 	}
 }
 
@@ -320,11 +468,12 @@ func (b *Bot) apiRequest(method string, reqBody map[string]interface{}) error {
 		return err
 	}
 
-	resp, err := b.client.Post(
-		b.apiURL+"/"+method,
-		"application/json",
-		strings.NewReader(string(data)),
-	)
+	return b.post(method, "application/json", strings.NewReader(string(data)))
+}
+
+// post 发送请求并解析API结果
+func (b *Bot) post(method, contentType string, reader io.Reader) error {
+	resp, err := b.client.Post(b.apiURL+"/"+method, contentType, reader)
 	if err != nil {
 		return err
 	}
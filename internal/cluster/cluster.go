@@ -0,0 +1,143 @@
+// Package cluster 多实例协调：多个实例共用同一个机器人时，通过共享存储同步会话、确认请求，
+// 并用抢占（claim）保证每条消息、每次定时任务只由一个实例处理
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound 键不存在
+var ErrNotFound = errors.New("key not found")
+
+// DefaultPrefix 共享存储中所有键的默认前缀
+const DefaultPrefix = "mujibot:"
+
+// Store 共享存储
+type Store interface {
+	// Get 读取键值，不存在时返回 ErrNotFound
+	Get(key string) ([]byte, error)
+	// Set 写入键值，ttl 为0时不过期
+	Set(key string, value []byte, ttl time.Duration) error
+	// Delete 删除键，键不存在不算错误
+	Delete(key string) error
+	// Claim 键不存在时写入并返回 true，用于在实例间抢占一次性工作
+	Claim(key string, ttl time.Duration) (bool, error)
+	// Keys 列出指定前缀的键
+	Keys(prefix string) ([]string, error)
+	Close() error
+}
+
+// Config 共享存储配置
+type Config struct {
+	Backend  string // redis 或 memory
+	Addr     string
+	Password string
+	DB       int
+	Prefix   string
+}
+
+// New 按配置创建共享存储
+func New(cfg Config) (Store, error) {
+	prefix := cfg.Prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	switch cfg.Backend {
+	case "", "redis":
+		return NewRedis(cfg.Addr, cfg.Password, cfg.DB, prefix)
+	case "memory":
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unsupported cluster backend: %s", cfg.Backend)
+	}
+}
+
+// Memory 进程内存储，用于单实例和测试
+type Memory struct {
+	mu   sync.Mutex
+	data map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemory 创建进程内存储
+func NewMemory() *Memory {
+	return &Memory{data: make(map[string]memoryEntry)}
+}
+
+// get 读取未过期的条目，调用方需持有锁
+func (m *Memory) get(key string) (memoryEntry, bool) {
+	e, ok := m.data[key]
+	if ok && !e.expires.IsZero() && time.Now().After(e.expires) {
+		delete(m.data, key)
+		return memoryEntry{}, false
+	}
+	return e, ok
+}
+
+func (m *Memory) Get(key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.get(key)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), e.value...), nil
+}
+
+func (m *Memory) Set(key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = newMemoryEntry(value, ttl)
+	return nil
+}
+
+func (m *Memory) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, key)
+	return nil
+}
+
+func (m *Memory) Claim(key string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.get(key); ok {
+		return false, nil
+	}
+	m.data[key] = newMemoryEntry([]byte("1"), ttl)
+	return true, nil
+}
+
+func (m *Memory) Keys(prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	for key := range m.data {
+		if _, ok := m.get(key); ok && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+func newMemoryEntry(value []byte, ttl time.Duration) memoryEntry {
+	e := memoryEntry{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	return e
+}
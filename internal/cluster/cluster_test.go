@@ -0,0 +1,164 @@
+package cluster
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRedis 用 Memory 实现 PING/AUTH/SELECT/GET/SET/DEL/SCAN 的RESP服务器
+func fakeRedis(t *testing.T, password string) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	store := NewMemory()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakeRedis(conn, store, password)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func serveFakeRedis(conn net.Conn, store *Memory, password string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := password == ""
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, a := range reply.([]interface{}) {
+			args = append(args, string(a.([]byte)))
+		}
+
+		cmd := strings.ToUpper(args[0])
+		if !authed && cmd != "AUTH" {
+			fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+		switch cmd {
+		case "PING":
+			fmt.Fprint(conn, "+PONG\r\n")
+		case "AUTH":
+			if args[1] != password {
+				fmt.Fprint(conn, "-WRONGPASS invalid password\r\n")
+				continue
+			}
+			authed = true
+			fmt.Fprint(conn, "+OK\r\n")
+		case "SELECT":
+			fmt.Fprint(conn, "+OK\r\n")
+		case "GET":
+			value, err := store.Get(args[1])
+			if err != nil {
+				fmt.Fprint(conn, "$-1\r\n")
+				continue
+			}
+			fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(value), value)
+		case "SET":
+			var ttl time.Duration
+			nx := false
+			for i := 3; i < len(args); i++ {
+				switch strings.ToUpper(args[i]) {
+				case "NX":
+					nx = true
+				case "PX":
+					ms, _ := strconv.Atoi(args[i+1])
+					ttl = time.Duration(ms) * time.Millisecond
+					i++
+				}
+			}
+			if nx {
+				if ok, _ := store.Claim(args[1], ttl); !ok {
+					fmt.Fprint(conn, "$-1\r\n")
+					continue
+				}
+			}
+			store.Set(args[1], []byte(args[2]), ttl)
+			fmt.Fprint(conn, "+OK\r\n")
+		case "DEL":
+			store.Delete(args[1])
+			fmt.Fprint(conn, ":1\r\n")
+		case "SCAN":
+			keys, _ := store.Keys(strings.ReplaceAll(strings.TrimSuffix(args[3], "*"), "\\", ""))
+			fmt.Fprintf(conn, "*2\r\n$1\r\n0\r\n*%d\r\n", len(keys))
+			for _, k := range keys {
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(k), k)
+			}
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+		}
+	}
+}
+
+func TestStores(t *testing.T) {
+	redis, err := New(Config{Addr: fakeRedis(t, "secret"), Password: "secret", DB: 1})
+	if err != nil {
+		t.Fatalf("New redis: %v", err)
+	}
+	defer redis.Close()
+
+	stores := map[string]Store{"memory": NewMemory(), "redis": redis}
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.Get("missing"); err != ErrNotFound {
+				t.Errorf("Get missing = %v, want ErrNotFound", err)
+			}
+			if err := s.Set("session:a", []byte("hello\r\nworld"), 0); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			if v, err := s.Get("session:a"); err != nil || string(v) != "hello\r\nworld" {
+				t.Errorf("Get = %q, %v", v, err)
+			}
+			s.Set("session:b", []byte("x"), time.Minute)
+			s.Set("other", []byte("y"), 0)
+			if keys, err := s.Keys("session:"); err != nil || strings.Join(keys, ",") != "session:a,session:b" {
+				t.Errorf("Keys = %v, %v", keys, err)
+			}
+
+			if ok, err := s.Claim("claim:1", time.Minute); !ok || err != nil {
+				t.Errorf("first Claim = %v, %v", ok, err)
+			}
+			if ok, err := s.Claim("claim:1", time.Minute); ok || err != nil {
+				t.Errorf("second Claim = %v, %v", ok, err)
+			}
+			if ok, _ := s.Claim("claim:short", 10*time.Millisecond); !ok {
+				t.Error("Claim short failed")
+			}
+			time.Sleep(30 * time.Millisecond)
+			if ok, _ := s.Claim("claim:short", time.Minute); !ok {
+				t.Error("expired claim should be claimable again")
+			}
+
+			if err := s.Delete("session:a"); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if _, err := s.Get("session:a"); err != ErrNotFound {
+				t.Errorf("Get deleted = %v", err)
+			}
+		})
+	}
+}
+
+func TestRedisAuthFailure(t *testing.T) {
+	if _, err := NewRedis(fakeRedis(t, "secret"), "wrong", 0, DefaultPrefix); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("NewRedis with wrong password = %v", err)
+	}
+	if _, err := New(Config{Backend: "nats"}); err == nil {
+		t.Error("unsupported backend should fail")
+	}
+}
@@ -0,0 +1,270 @@
+package cluster
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// redisTimeout 单条命令的读写超时
+	redisTimeout = 5 * time.Second
+	// redisPoolSize 空闲连接池大小
+	redisPoolSize = 4
+)
+
+// redisError Redis返回的错误回复
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// Redis 基于RESP协议的最小Redis客户端，只实现共享存储需要的命令
+type Redis struct {
+	addr     string
+	password string
+	db       int
+	prefix   string
+	pool     chan *redisConn
+}
+
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+// NewRedis 连接Redis并验证可用
+func NewRedis(addr, password string, db int, prefix string) (*Redis, error) {
+	if addr == "" {
+		addr = "127.0.0.1:6379"
+	}
+	r := &Redis{
+		addr:     addr,
+		password: password,
+		db:       db,
+		prefix:   prefix,
+		pool:     make(chan *redisConn, redisPoolSize),
+	}
+	if _, err := r.do("PING"); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis %s: %w", addr, err)
+	}
+	return r, nil
+}
+
+func (r *Redis) Get(key string) ([]byte, error) {
+	reply, err := r.do("GET", r.prefix+key)
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, ErrNotFound
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("redis: unexpected reply %T", reply)
+	}
+	return value, nil
+}
+
+func (r *Redis) Set(key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", r.prefix + key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := r.do(args...)
+	return err
+}
+
+func (r *Redis) Delete(key string) error {
+	_, err := r.do("DEL", r.prefix+key)
+	return err
+}
+
+func (r *Redis) Claim(key string, ttl time.Duration) (bool, error) {
+	args := []string{"SET", r.prefix + key, "1", "NX"}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	reply, err := r.do(args...)
+	if err != nil {
+		return false, err
+	}
+	return reply != nil, nil
+}
+
+// Keys 用 SCAN 遍历前缀匹配的键，不阻塞服务器
+func (r *Redis) Keys(prefix string) ([]string, error) {
+	var keys []string
+	cursor := "0"
+	for {
+		reply, err := r.do("SCAN", cursor, "MATCH", escapeGlob(r.prefix+prefix)+"*", "COUNT", "100")
+		if err != nil {
+			return nil, err
+		}
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 2 {
+			return nil, fmt.Errorf("redis: unexpected SCAN reply")
+		}
+		next, _ := parts[0].([]byte)
+		batch, _ := parts[1].([]interface{})
+		for _, k := range batch {
+			if b, ok := k.([]byte); ok {
+				keys = append(keys, strings.TrimPrefix(string(b), r.prefix))
+			}
+		}
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			return keys, nil
+		}
+	}
+}
+
+// Close 关闭空闲连接
+func (r *Redis) Close() error {
+	for {
+		select {
+		case c := <-r.pool:
+			c.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do 执行一条命令，连接出错时丢弃该连接
+func (r *Redis) do(args ...string) (interface{}, error) {
+	c, err := r.get()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := c.do(args...)
+	var rerr redisError
+	if err != nil && !errors.As(err, &rerr) {
+		c.conn.Close()
+		return nil, err
+	}
+	r.put(c)
+	return reply, err
+}
+
+// get 从池中取连接，没有空闲连接时新建
+func (r *Redis) get() (*redisConn, error) {
+	select {
+	case c := <-r.pool:
+		return c, nil
+	default:
+	}
+
+	conn, err := net.DialTimeout("tcp", r.addr, redisTimeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &redisConn{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+	if r.password != "" {
+		if _, err := c.do("AUTH", r.password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if r.db != 0 {
+		if _, err := c.do("SELECT", strconv.Itoa(r.db)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (r *Redis) put(c *redisConn) {
+	select {
+	case r.pool <- c:
+	default:
+		c.conn.Close()
+	}
+}
+
+// do 发送命令并读取回复
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	c.conn.SetDeadline(time.Now().Add(redisTimeout))
+
+	fmt.Fprintf(c.w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(c.w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, err
+	}
+	return readReply(c.r)
+}
+
+// readReply 解析一条RESP回复：简单字符串返回string，整数返回int64，
+// 批量字符串返回[]byte（空值为nil），数组返回[]interface{}，错误回复返回 redisError
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+// escapeGlob 转义 SCAN MATCH 的通配符
+func escapeGlob(s string) string {
+	var sb strings.Builder
+	for _, ch := range s {
+		switch ch {
+		case '*', '?', '[', ']', '\\':
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(ch)
+	}
+	return sb.String()
+}
@@ -15,22 +15,58 @@ import (
 
 // Config 主配置结构
 type Config struct {
-	Server     ServerConfig            `json:"server"`
-	Channels   ChannelsConfig          `json:"channels"`
-	LLM        LLMConfig               `json:"llm"`
-	LLMPresets map[string]LLMPreset    `json:"llmPresets"`
-	Language   LanguageConfig          `json:"language"`
-	Agents     map[string]AgentConfig  `json:"agents"`
-	Tools      ToolsConfig             `json:"tools"`
-	Session    SessionConfig           `json:"session"`
-	Logging    LoggingConfig           `json:"logging"`
-	Memory     MemoryConfig            `json:"memory"`
+	Server        ServerConfig           `json:"server"`
+	Channels      ChannelsConfig         `json:"channels"`
+	LLM           LLMConfig              `json:"llm"`
+	LLMPresets    map[string]LLMPreset   `json:"llmPresets"`
+	Language      LanguageConfig         `json:"language"`
+	Agents        map[string]AgentConfig `json:"agents"`
+	Tools         ToolsConfig            `json:"tools"`
+	Session       SessionConfig          `json:"session"`
+	Logging       LoggingConfig          `json:"logging"`
+	Memory        MemoryConfig           `json:"memory"`
+	Guardrails    GuardrailsConfig       `json:"guardrails"`
+	Schedules     []ScheduleConfig       `json:"schedules"`
+	Alerts        AlertsConfig           `json:"alerts"`
+	Notifications NotificationsConfig    `json:"notifications"`
+	Crash         CrashConfig            `json:"crash"`
+	Feeds         FeedsConfig            `json:"feeds"`
+	Cluster       ClusterConfig          `json:"cluster"`
+	Encryption    EncryptionConfig       `json:"encryption"`
+	HTTP          HTTPConfig             `json:"http"`
+	Outbox        OutboxConfig           `json:"outbox"`
+	Analytics     AnalyticsConfig        `json:"analytics"`
+	Resources     ResourcesConfig        `json:"resources"`
+	Webhooks      WebhooksConfig         `json:"webhooks"`
+	Admins        []string               `json:"admins"` // 管理员，格式 channel:userID，如 telegram:123456789
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port        int  `json:"port"`
-	HealthCheck bool `json:"healthCheck"`
+	Port        int            `json:"port"`
+	HealthCheck bool           `json:"healthCheck"`
+	AdminToken  string         `json:"adminToken"` // Web控制台管理员令牌，设置后可在终端面板输入和取消会话、批准或拒绝危险操作、清除用户数据，为空时只读
+	DebugLog    DebugLogConfig `json:"debugLog"`   // 调试消息持久化
+	WebRoot     string         `json:"webRoot"`    // 控制台静态文件覆盖目录，同名文件替换内置的 index.html/style.css/app.js
+	// DrainTimeout 退出时等待进行中的消息处理完成的秒数，超时后取消仍在执行的工具，0 时为 30
+	DrainTimeout int        `json:"drainTimeout"`
+	CORS         CORSConfig `json:"cors"`
+	// CSRF 写操作（POST/PUT/PATCH/DELETE）的跨站校验："same-origin"（默认）只接受同源、
+	// 不带来源的请求（curl、脚本）和 cors.allowedOrigins 中明确列出的来源，"off" 关闭
+	CSRF string `json:"csrf"`
+}
+
+// CORSConfig 跨域配置，允许外部仪表盘从浏览器调用 /api/
+type CORSConfig struct {
+	AllowedOrigins []string `json:"allowedOrigins"` // 如 https://dash.example.com，"*" 允许任意来源只读访问
+	MaxAge         int      `json:"maxAge"`         // 预检结果缓存秒数，0 时为 600
+}
+
+// DebugLogConfig 调试控制台消息持久化配置
+type DebugLogConfig struct {
+	Enabled    bool   `json:"enabled"`
+	File       string `json:"file"`       // JSONL文件，默认 ./debug_messages.jsonl
+	MaxEntries int    `json:"maxEntries"` // 保留条数，默认10000
 }
 
 // ChannelsConfig 消息渠道配置
@@ -38,6 +74,26 @@ type ChannelsConfig struct {
 	Telegram TelegramConfig `json:"telegram"`
 	Discord  DiscordConfig  `json:"discord"`
 	Feishu   FeishuConfig   `json:"feishu"`
+	// Responses 按渠道控制回复长度，键为渠道名（telegram、discord、feishu、web、scheduler），未配置的渠道不限制
+	Responses map[string]ResponseStyle `json:"responses"`
+}
+
+// ResponseStyle 渠道的回复长度与风格
+type ResponseStyle struct {
+	MaxResponseTokens int    `json:"maxResponseTokens"` // 单次回复的最大token数，作为 max_tokens 随请求发送，0 表示使用提供商默认值
+	Verbosity         string `json:"verbosity"`         // brief、normal 或 detailed，在系统提示词中加入相应的要求，用户资料中设置了 verbosity 时以用户为准
+}
+
+// Verbosities 可选的回复详略程度
+var Verbosities = []string{"brief", "normal", "detailed"}
+
+func isVerbosity(v string) bool {
+	for _, name := range Verbosities {
+		if name == v {
+			return true
+		}
+	}
+	return false
 }
 
 // TelegramConfig Telegram配置
@@ -54,6 +110,7 @@ type DiscordConfig struct {
 	Token         string   `json:"token"`
 	AllowedGuilds []string `json:"allowedGuilds"`
 	NotifyEnabled bool     `json:"notifyEnabled"` // 启用通知
+	MaxMessages   int      `json:"maxMessages"`   // 超过2000字符的回复最多拆成几条消息，超出时作为 .txt 附件发送，默认4
 }
 
 // FeishuConfig 飞书配置
@@ -74,6 +131,35 @@ type LLMConfig struct {
 	BaseURL    string `json:"baseURL"`
 	Timeout    int    `json:"timeout"`
 	MaxRetries int    `json:"maxRetries"`
+	// ContextWindow 模型上下文窗口（token），用于系统提示词预算，0 时按 8192 计
+	ContextWindow int `json:"contextWindow"`
+	// PromptBudgetPercent 系统提示词最多占用上下文窗口的百分比，超出时裁剪记忆和工具说明；0 时为 30，100 不限制
+	PromptBudgetPercent int `json:"promptBudgetPercent"`
+	// Proxy 访问LLM API使用的代理，优先于 http.proxy；"direct" 表示直连
+	Proxy string `json:"proxy"`
+	// DebugLog 记录脱敏后的LLM请求/响应，用于排查提供商拒绝请求等问题
+	DebugLog LLMDebugLogConfig `json:"debugLog"`
+	// SummaryModel 同一提供商下用于总结工具输出的便宜模型，为空时使用 model
+	SummaryModel string `json:"summaryModel"`
+	// Replay 把请求/响应记录到文件或从文件回放，用于集成测试和问题复现
+	Replay LLMReplayConfig `json:"replay"`
+}
+
+// LLMReplayConfig LLM交互记录与回放配置
+type LLMReplayConfig struct {
+	// Mode 为 record 时记录每次请求和响应，为 replay 时只从文件回放、不访问API，为空时关闭
+	Mode string `json:"mode"`
+	// File JSONL文件路径，默认 ./llm_recordings.jsonl
+	File string `json:"file"`
+}
+
+// LLMDebugLogConfig LLM请求/响应调试日志配置
+type LLMDebugLogConfig struct {
+	Enabled bool `json:"enabled"`
+	// File JSONL文件路径，为空时输出到Web调试控制台
+	File string `json:"file"`
+	// MaxBodyChars 请求/响应体的最大字符数，0 时为 8000
+	MaxBodyChars int `json:"maxBodyChars"`
 }
 
 // LLMPreset LLM预设配置
@@ -89,28 +175,298 @@ type LanguageConfig struct {
 	Default  string   `json:"default"`
 	Current  string   `json:"current"`
 	Supported []string `json:"supported"`
+	AutoDetect bool   `json:"autoDetect"` // 按用户消息自动识别语言，用户可用 /lang 固定
 }
 
 // AgentConfig 智能体配置
 type AgentConfig struct {
+	Name         string            `json:"name"`
+	SystemPrompt string            `json:"systemPrompt"` // 支持模板变量，如 {{.UserName}}、{{.Vars.xxx}}
+	Tools        []string          `json:"tools"`
+	Variables    map[string]string `json:"variables"` // 自定义提示词变量
+	Citations    bool              `json:"citations"` // 用到网页搜索或HTTP请求结果时，在回复末尾附上来源标题和链接
+}
+
+// ScheduleConfig 定时任务配置
+type ScheduleConfig struct {
 	Name         string   `json:"name"`
-	SystemPrompt string   `json:"systemPrompt"`
-	Tools        []string `json:"tools"`
+	Enabled      bool     `json:"enabled"`
+	Schedule     string   `json:"schedule"`     // cron表达式（分 时 日 月 周），或 @hourly、@daily、@every 30m
+	Agent        string   `json:"agent"`        // 为空时使用默认智能体
+	Prompt       string   `json:"prompt"`
+	Channel      string   `json:"channel"`      // 结果发送渠道: telegram/discord/feishu
+	Target       string   `json:"target"`       // 聊天ID、频道ID或用户ID
+	AllowedTools []string `json:"allowedTools"` // 无人值守运行时允许的工具，为空则不允许任何工具
+}
+
+// AlertsConfig 管理员告警配置
+type AlertsConfig struct {
+	Enabled              bool   `json:"enabled"`
+	Channel              string `json:"channel"`              // 告警渠道: telegram/discord/feishu
+	Target               string `json:"target"`               // 管理员聊天ID
+	Interval             int    `json:"interval"`             // 检查间隔（秒）
+	Cooldown             int    `json:"cooldown"`             // 同一告警重复发送的冷却时间（秒）
+	MemoryThresholdMB    int    `json:"memoryThresholdMB"`    // 堆内存告警阈值
+	DiskThresholdPercent int    `json:"diskThresholdPercent"` // 磁盘使用率告警阈值（百分比）
+	LLMFailureThreshold  int    `json:"llmFailureThreshold"`  // LLM连续失败次数阈值
+}
+
+// NotificationsConfig 通知路由配置，未启用时通知发到来源会话（告警发到 alerts 渠道）
+type NotificationsConfig struct {
+	Enabled    bool               `json:"enabled"`
+	Timezone   string             `json:"timezone"`   // 免打扰时段使用的时区，默认本地时区
+	QuietHours QuietHoursConfig   `json:"quietHours"` // 免打扰时段
+	Rules      []NotificationRule `json:"rules"`      // 按顺序匹配，第一条匹配的规则生效
+}
+
+// QuietHoursConfig 免打扰时段，期间低于 minSeverity 的通知推迟到时段结束后发送
+type QuietHoursConfig struct {
+	Start       string `json:"start"`       // 开始时间，如 22:00
+	End         string `json:"end"`         // 结束时间，如 07:30
+	MinSeverity string `json:"minSeverity"` // 达到该级别的通知照常发送，默认 critical
+}
+
+// NotificationRule 通知路由规则
+type NotificationRule struct {
+	Events           []string             `json:"events"`           // 事件类型: alert/confirmation/scheduler/reminder/feed/task，为空匹配所有
+	MinSeverity      string               `json:"minSeverity"`      // 低于该级别的通知丢弃: info/warning/critical
+	Destinations     []NotificationTarget `json:"destinations"`     // 发送目标，为空时发到来源
+	AggregateSeconds int                  `json:"aggregateSeconds"` // 聚合窗口，窗口内发往同一目标的通知合并为一条
+}
+
+// NotificationTarget 通知发送目标
+type NotificationTarget struct {
+	Channel string `json:"channel"`
+	Target  string `json:"target"`
+}
+
+// CrashConfig 崩溃报告配置
+type CrashConfig struct {
+	Dir        string `json:"dir"`        // 崩溃报告目录，默认 ./crashes
+	MaxReports int    `json:"maxReports"` // 最多保留的报告数量
+	Notify     bool   `json:"notify"`     // 通过告警渠道通知管理员
+}
+
+// FeedsConfig RSS/Atom 订阅配置
+type FeedsConfig struct {
+	Enabled    bool   `json:"enabled"`
+	File       string `json:"file"`       // 订阅文件，默认 ./feeds.json
+	Interval   int    `json:"interval"`   // 拉取间隔（分钟），默认30
+	MaxPerUser int    `json:"maxPerUser"` // 每个用户最多订阅数，默认20
+}
+
+// OutboxConfig 发送失败消息的重试配置
+type OutboxConfig struct {
+	Enabled     bool   `json:"enabled"`
+	File        string `json:"file"`        // 发件箱文件，默认 ./outbox.json
+	MaxAttempts int    `json:"maxAttempts"` // 包括首次发送的最多次数，默认5，用尽后通知告警渠道
+	RetryDelay  int    `json:"retryDelay"`  // 第一次重试前的等待时间（秒），之后每次翻倍，默认30
+}
+
+// WebhooksConfig 出站Webhook：把机器人活动以签名的JSON事件推送给 n8n、Node-RED 等自动化工具
+type WebhooksConfig struct {
+	Endpoints []WebhookEndpoint `json:"endpoints"`
+	Timeout   int               `json:"timeout"`   // 单次请求超时（秒），默认10
+	QueueSize int               `json:"queueSize"` // 等待发送的事件数上限，排满后丢弃新事件，默认100
+}
+
+// WebhookEndpoint 一个接收事件的地址
+type WebhookEndpoint struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"` // HMAC-SHA256 签名密钥，为空时不签名
+	Events []string `json:"events"` // 订阅的事件，为空时接收全部
+}
+
+// WebhookEvents 出站Webhook支持的事件
+var WebhookEvents = []string{"message_received", "reply_sent", "tool_executed", "confirmation_pending", "alert"}
+
+func isWebhookEvent(name string) bool {
+	for _, e := range WebhookEvents {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyticsConfig 对话分析：用LLM给每条消息标注意图并计数，通过 /api/analytics 查看
+type AnalyticsConfig struct {
+	Enabled       bool     `json:"enabled"`
+	File          string   `json:"file"`          // 统计文件，默认 ./analytics.json
+	Categories    []string `json:"categories"`    // 意图分类，默认 question/command/automation/smalltalk
+	RetentionDays int      `json:"retentionDays"` // 按天统计保留的天数，默认90
+}
+
+// ResourcesConfig 资源配置档：preset 按设备一次性设置会话上限、日志缓冲、并发数、内存阈值和流式分块，
+// 只填充未设置（为0）的项，本节和其他节中写出的值优先
+type ResourcesConfig struct {
+	Preset           string `json:"preset"`           // pi-zero、pi-4 或 vps，为空时不使用配置档
+	Workers          int    `json:"workers"`          // 同时交给智能体处理的消息数，0 不限制
+	LogBuffer        int    `json:"logBuffer"`        // Web控制台内存中保留的调试消息条数，默认200
+	MemoryGCMB       int    `json:"memoryGCMB"`       // 堆内存超过时主动GC，默认80
+	MemoryCriticalMB int    `json:"memoryCriticalMB"` // 堆内存超过时优雅重启，默认120
+	StreamChunkBytes int    `json:"streamChunkBytes"` // 流式回复攒够多少字节再推送一次，0 时每个片段都推送
+}
+
+// ClusterConfig 多实例协调配置：多个实例共用同一个机器人（如树莓派+VPS故障切换）时，
+// 会话、确认请求和定时任务的执行记录保存在共享存储中，每条消息只由一个实例回复
+type ClusterConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Backend  string `json:"backend"`  // 共享存储，目前支持 redis
+	Addr     string `json:"addr"`     // Redis地址，默认 127.0.0.1:6379
+	Password string `json:"password"`
+	DB       int    `json:"db"`
+	Prefix   string `json:"prefix"` // 键前缀，默认 mujibot:，同一Redis上的不同部署应使用不同前缀
+}
+
+// EncryptionConfig 静态加密配置：记忆文件（MEMORY.md、每日笔记、归档、资料、通讯录）和共享存储中的会话使用 AES-256-GCM 加密
+type EncryptionConfig struct {
+	Enabled bool   `json:"enabled"`
+	KeyFile string `json:"keyFile"` // 密钥文件（base64 或十六进制编码的32字节），优先于环境变量
+	KeyEnv  string `json:"keyEnv"`  // 读取密钥的环境变量，默认 MUJIBOT_ENCRYPTION_KEY
+}
+
+// HTTPConfig 出站HTTP配置，LLM提供商、渠道和工具共用
+type HTTPConfig struct {
+	Proxy               string            `json:"proxy"`               // 全局代理（http/https/socks5），为空时使用 HTTP_PROXY/HTTPS_PROXY 环境变量，"direct" 表示直连
+	Proxies             map[string]string `json:"proxies"`             // 按目标主机指定代理，如 {"api.telegram.org": "socks5://127.0.0.1:1080", "*.internal": "direct"}
+	NoProxy             []string          `json:"noProxy"`             // 不使用全局代理的主机
+	CABundle            string            `json:"caBundle"`            // 额外信任的CA证书文件（PEM）
+	MaxIdleConns        int               `json:"maxIdleConns"`        // 空闲连接总数上限，默认8
+	MaxIdleConnsPerHost int               `json:"maxIdleConnsPerHost"` // 每个主机的空闲连接上限，默认2
+	IdleConnTimeout     int               `json:"idleConnTimeout"`     // 空闲连接保留秒数，默认60
 }
 
 // ToolsConfig 工具配置
 type ToolsConfig struct {
-	WorkDir              string            `json:"workDir"`
-	Timeout              int               `json:"timeout"`
-	ConfirmDangerous     bool              `json:"confirmDangerous"`     // 高危操作需确认
-	UnattendedMode       bool              `json:"unattendedMode"`       // 无人值守模式
-	AlwaysAllowDangerous []string          `json:"alwaysAllowDangerous"` // 始终允许的危险操作
-	AllowedCommands      []string          `json:"allowedCommands"`
-	BlockedCommands      []string          `json:"blockedCommands"`
-	EnabledTools         map[string]bool   `json:"enabledTools"`     // 工具开关
-	WebSearchEnabled     bool              `json:"webSearchEnabled"` // 联网搜索开关
-	TerminalEnabled      bool              `json:"terminalEnabled"`  // 终端接管开关
-	CustomAPIs           []CustomAPIConfig `json:"customAPIs"`       // 用户自定义API
+	WorkDir              string             `json:"workDir"`
+	Workspaces           []WorkspaceConfig  `json:"workspaces"`     // 额外的命名工作区，文件工具用 workspace 参数选择
+	PerUserWorkDir       bool               `json:"perUserWorkDir"` // 按用户隔离：非管理员只能访问 workDir/users 下自己的目录
+	SharedDir            string             `json:"sharedDir"`      // 按用户隔离时所有用户共享的目录（相对workDir），为空则不共享
+	Timeout              int                `json:"timeout"`
+	ConfirmDangerous     bool               `json:"confirmDangerous"`     // 高危操作需确认
+	UnattendedMode       bool               `json:"unattendedMode"`       // 无人值守模式
+	AlwaysAllowDangerous []string           `json:"alwaysAllowDangerous"` // 始终允许的危险操作
+	AllowedCommands      []string           `json:"allowedCommands"`
+	BlockedCommands      []string           `json:"blockedCommands"`
+	PolicyFile           string             `json:"policyFile"`       // 危险操作策略文件（JSON），其规则优先于内置规则
+	EnabledTools         map[string]bool    `json:"enabledTools"`     // 工具开关
+	WebSearchEnabled     bool               `json:"webSearchEnabled"` // 联网搜索开关
+	TerminalEnabled      bool               `json:"terminalEnabled"`  // 终端接管开关
+	TerminalRows         int                `json:"terminalRows"`     // 终端会话伪终端行数，默认24
+	TerminalCols         int                `json:"terminalCols"`     // 终端会话伪终端列数，默认120
+	CustomAPIs           []CustomAPIConfig  `json:"customAPIs"`       // 用户自定义API
+	Email                EmailConfig        `json:"email"`            // 邮件发送
+	ServiceUnits         []string           `json:"serviceUnits"`     // systemctl 工具允许管理的服务
+	MaxResultChars       int                `json:"maxResultChars"`   // 工具结果字符上限，超出部分保存到工作目录，默认16000
+	ToolTimeouts         map[string]int     `json:"toolTimeouts"`     // 按工具覆盖执行超时（秒），默认使用 timeout
+	Settings             ToolSettingsConfig `json:"settings"`         // 按工具调整参数默认值和上限，修改后需重启
+	Quotas               QuotaConfig        `json:"quotas"`           // 工具调用配额
+	// PostProcess 按工具名配置结果后处理链，修改后需重启
+	PostProcess map[string]PostProcessConfig `json:"postProcess"`
+	// Calendar CalDAV日历，启用后提供 calendar_list 和 calendar_add 工具
+	Calendar CalendarConfig `json:"calendar"`
+	// HomeAssistant Home Assistant REST API，启用后提供 homeassistant 工具
+	HomeAssistant HomeAssistantConfig `json:"homeAssistant"`
+}
+
+// PostProcessConfig 工具结果后处理配置
+type PostProcessConfig struct {
+	Steps    []string `json:"steps"`    // 按顺序执行：truncate、json-pretty、strip-ansi、summarize
+	MaxChars int      `json:"maxChars"` // truncate 保留的字符数，也是 summarize 的触发长度，默认2000
+}
+
+// ToolSettingsConfig 按工具调整参数默认值和上限，0 或空值使用内置默认值
+type ToolSettingsConfig struct {
+	Weather     WeatherToolConfig     `json:"weather"`
+	WebSearch   WebSearchToolConfig   `json:"web_search"`
+	HTTPRequest HTTPRequestToolConfig `json:"http_request"`
+	Grep        GrepToolConfig        `json:"grep"`
+	Quotes      QuotesToolConfig      `json:"quotes"`
+}
+
+// WeatherToolConfig weather 工具配置
+type WeatherToolConfig struct {
+	DefaultCity  string `json:"defaultCity"`  // 未指定城市时查询的城市
+	DefaultDays  int    `json:"defaultDays"`  // 默认预报天数，默认3
+	DefaultUnits string `json:"defaultUnits"` // 用户未设置单位制时使用，metric（默认）或 imperial
+}
+
+// WebSearchToolConfig web_search 工具配置
+type WebSearchToolConfig struct {
+	DefaultResults int `json:"defaultResults"` // 默认返回结果数，默认5
+	MaxResults     int `json:"maxResults"`     // 结果数上限，默认10
+}
+
+// HTTPRequestToolConfig http_request 工具配置
+type HTTPRequestToolConfig struct {
+	MaxBytes  int `json:"maxBytes"`  // 读取的响应体字节数上限，默认 5242880
+	MaxLength int `json:"maxLength"` // 返回字符数上限，默认20000
+}
+
+// GrepToolConfig grep 工具配置
+type GrepToolConfig struct {
+	MaxMatches int `json:"maxMatches"` // 默认总匹配数上限，默认50
+	MaxPerFile int `json:"maxPerFile"` // 每个文件默认显示的匹配数，默认10
+}
+
+// QuotesToolConfig quotes 工具配置
+type QuotesToolConfig struct {
+	Providers            []string `json:"providers"`            // 启用的数据源 yahoo、coingecko，第一个为默认，默认两者都启用
+	CacheSeconds         int      `json:"cacheSeconds"`         // 相同查询的缓存时间（秒），默认60
+	MaxRequestsPerMinute int      `json:"maxRequestsPerMinute"` // 每个数据源每分钟的请求数上限，默认20
+}
+
+// WorkspaceConfig 命名工作区配置，workDir 即名为 default 的可写工作区
+type WorkspaceConfig struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	ReadOnly bool   `json:"readOnly"` // 只读工作区不允许写入、移动或删除
+}
+
+// QuotaConfig 工具调用配额，0 表示不限制
+type QuotaConfig struct {
+	MaxCallsPerMessage    int `json:"maxCallsPerMessage"`    // 每条消息最多调用工具次数
+	MaxCommandsPerHour    int `json:"maxCommandsPerHour"`    // 每个用户每小时最多 execute_command 次数
+	MaxHTTPRequestsPerDay int `json:"maxHTTPRequestsPerDay"` // 每个用户每天最多外部HTTP请求次数
+}
+
+// EmailConfig SMTP发信配置
+type EmailConfig struct {
+	Enabled           bool     `json:"enabled"`
+	Host              string   `json:"host"`
+	Port              int      `json:"port"` // 默认按 security 取 587/465/25
+	Username          string   `json:"username"`
+	Password          string   `json:"password"`
+	From              string   `json:"from"`              // 发件人，默认同 username
+	Security          string   `json:"security"`          // starttls（默认）、tls 或 none
+	AllowedRecipients []string `json:"allowedRecipients"` // 无需确认的收件人，"@example.com" 表示整个域名
+	MaxAttachmentMB   int      `json:"maxAttachmentMB"`   // 附件总大小上限，默认10
+}
+
+// CalendarConfig CalDAV日历配置
+type CalendarConfig struct {
+	Enabled  bool                 `json:"enabled"`
+	URL      string               `json:"url"` // 日历集合地址
+	Username string               `json:"username"`
+	Password string               `json:"password"`
+	Timezone string               `json:"timezone"` // 用户资料未设置时区时使用，默认服务器时区
+	Google   GoogleCalendarConfig `json:"google"`   // Google 日历 OAuth，设置 refreshToken 时代替用户名密码
+}
+
+// GoogleCalendarConfig Google 日历 OAuth 客户端和刷新令牌
+type GoogleCalendarConfig struct {
+	ClientID     string `json:"clientID"`
+	ClientSecret string `json:"clientSecret"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+// HomeAssistantConfig Home Assistant 配置
+type HomeAssistantConfig struct {
+	Enabled         bool     `json:"enabled"`
+	URL             string   `json:"url"`             // 如 http://homeassistant.local:8123
+	Token           string   `json:"token"`           // 长期访问令牌
+	AllowedEntities []string `json:"allowedEntities"` // 允许访问的实体，支持通配符如 light.*，为空时不允许任何实体
 }
 
 // CustomAPIConfig 自定义API配置
@@ -134,10 +490,28 @@ type SessionConfig struct {
 
 // LoggingConfig 日志配置
 type LoggingConfig struct {
-	Level   string `json:"level"`
-	File    string `json:"file"`
-	MaxSize int    `json:"maxSize"`
-	Format  string `json:"format"`
+	Level      string              `json:"level"`
+	File       string              `json:"file"`
+	MaxSize    int                 `json:"maxSize"`
+	MaxBackups int                 `json:"maxBackups"` // 保留的轮转文件数，0 表示不限制
+	MaxAgeDays int                 `json:"maxAgeDays"` // 轮转文件保留天数，0 表示不限制
+	Format     string              `json:"format"`
+	Levels     map[string]string   `json:"levels"`    // 按模块覆盖日志级别，如 {"telegram": "debug", "llm": "warn"}
+	Exporters  []LogExporterConfig `json:"exporters"` // 额外日志输出: syslog、otlp
+}
+
+// LogExporterConfig 日志导出配置
+type LogExporterConfig struct {
+	Type          string            `json:"type"`          // syslog 或 otlp
+	Level         string            `json:"level"`         // 导出的最低级别
+	Network       string            `json:"network"`       // syslog: 为空时使用本地syslog/journald
+	Address       string            `json:"address"`       // syslog: 远程地址，如 192.168.1.10:514
+	Tag           string            `json:"tag"`           // syslog: 标识，默认 mujibot
+	Endpoint      string            `json:"endpoint"`      // otlp: 如 http://collector:4318/v1/logs
+	Headers       map[string]string `json:"headers"`       // otlp: 额外请求头
+	ServiceName   string            `json:"serviceName"`   // otlp: service.name
+	BatchSize     int               `json:"batchSize"`     // otlp: 每批条数
+	FlushInterval int               `json:"flushInterval"` // otlp: 发送间隔（秒）
 }
 
 // MemoryConfig 记忆系统配置
@@ -145,6 +519,65 @@ type MemoryConfig struct {
 	Enabled    bool   `json:"enabled"`
 	MemoryDir  string `json:"memoryDir"`
 	MaxFileSize int   `json:"maxFileSize"`
+	AutoJournal AutoJournalConfig `json:"autoJournal"` // 会话结束时自动写入每日笔记
+	Recap       RecapConfig       `json:"recap"`       // 会话空闲结束时保存对话摘要
+}
+
+// AutoJournalConfig 会话结束（空闲超时、淘汰、清空、关闭）时用LLM生成摘要追加到当天的每日笔记
+type AutoJournalConfig struct {
+	Enabled     bool `json:"enabled"`
+	MinMessages int  `json:"minMessages"` // 少于该消息数的会话不记录，默认 4
+	MaxChars    int  `json:"maxChars"`    // 发送给LLM的对话最大字符数，默认 8000
+}
+
+// RecapConfig 会话空闲超时、被淘汰或程序退出时用LLM把对话总结为一段话，保存到用户的记忆中，
+// 下次对话时注入系统提示词，避免会话清理后完全遗忘
+type RecapConfig struct {
+	Enabled     bool `json:"enabled"`
+	MinMessages int  `json:"minMessages"` // 少于该消息数的会话不总结，默认 2
+	MaxChars    int  `json:"maxChars"`    // 发送给LLM的对话最大字符数，默认 8000
+}
+
+// GuardrailsConfig 内容安全配置
+type GuardrailsConfig struct {
+	Enabled        bool                       `json:"enabled"`
+	Blocklist      []string                   `json:"blocklist"`      // 关键词黑名单（不区分大小写）
+	Patterns       []string                   `json:"patterns"`       // 正则过滤规则
+	Moderation     ModerationConfig           `json:"moderation"`     // 可选的审核API
+	RefusalMessage string                     `json:"refusalMessage"` // 拒绝回复模板，留空使用内置文案
+	Channels       map[string]GuardrailPolicy `json:"channels"`       // 按渠道覆盖
+	ToolOutputs    ToolOutputGuardConfig      `json:"toolOutputs"`    // 工具输出的提示注入防护，独立于 enabled
+}
+
+// ToolOutputGuardConfig 工具输出进入提示词之前的提示注入防护
+type ToolOutputGuardConfig struct {
+	Enabled    bool                      `json:"enabled"`
+	Tools      []string                  `json:"tools"`      // 需要处理的工具，默认 web_search、http_request、read_file
+	Classifier InjectionClassifierConfig `json:"classifier"` // 可选的LLM分类器
+}
+
+// InjectionClassifierConfig 提示注入分类器（OpenAI兼容 /chat/completions 接口，建议使用便宜的小模型）
+type InjectionClassifierConfig struct {
+	Enabled  bool   `json:"enabled"`
+	URL      string `json:"url"`
+	APIKey   string `json:"apiKey"`
+	Model    string `json:"model"`
+	MaxChars int    `json:"maxChars"` // 发送给分类器的最大字符数，默认 4000
+}
+
+// ModerationConfig 审核API配置（OpenAI兼容 /moderations 接口）
+type ModerationConfig struct {
+	Enabled bool   `json:"enabled"`
+	URL     string `json:"url"`
+	APIKey  string `json:"apiKey"`
+	Model   string `json:"model"`
+}
+
+// GuardrailPolicy 渠道级内容安全策略
+type GuardrailPolicy struct {
+	Mode      string   `json:"mode"`      // both(默认), input, output, off
+	Blocklist []string `json:"blocklist"` // 追加的关键词
+	Patterns  []string `json:"patterns"`  // 追加的正则
 }
 
 // Manager 配置管理器
@@ -204,6 +637,11 @@ func (m *Manager) Load() error {
 	// 替换环境变量
 	m.replaceEnvVars(&config)
 
+	// 资源配置档填充未设置的项
+	if err := applyResourcePreset(&config); err != nil {
+		return fmt.Errorf("config validation failed: %w", err)
+	}
+
 	// 验证配置
 	if err := m.validate(&config); err != nil {
 		return fmt.Errorf("config validation failed: %w", err)
@@ -241,6 +679,48 @@ func (m *Manager) Update(cfg *Config) {
 	}
 }
 
+// UpdateLogging 更新日志级别并写回配置文件
+func (m *Manager) UpdateLogging(level string, levels map[string]string) {
+	next := *m.Get()
+	next.Logging.Level = level
+	next.Logging.Levels = levels
+	m.Update(&next)
+}
+
+// AddEmailRecipient 将已确认的收件人加入白名单并写回配置文件
+func (m *Manager) AddEmailRecipient(address string) {
+	next := *m.Get()
+	for _, existing := range next.Tools.Email.AllowedRecipients {
+		if strings.EqualFold(existing, address) {
+			return
+		}
+	}
+	next.Tools.Email.AllowedRecipients = append(append([]string(nil), next.Tools.Email.AllowedRecipients...), address)
+	m.Update(&next)
+}
+
+// SetToolEnabled 设置工具开关并写回配置文件
+func (m *Manager) SetToolEnabled(name string, enabled bool) {
+	next := *m.Get()
+	enabledTools := make(map[string]bool, len(next.Tools.EnabledTools)+1)
+	for k, v := range next.Tools.EnabledTools {
+		enabledTools[k] = v
+	}
+	enabledTools[name] = enabled
+	next.Tools.EnabledTools = enabledTools
+	m.Update(&next)
+}
+
+// IsAdmin 检查用户是否为管理员
+func (c *Config) IsAdmin(channel, userID string) bool {
+	for _, admin := range c.Admins {
+		if admin == channel+":"+userID {
+			return true
+		}
+	}
+	return false
+}
+
 // OnChange 注册配置变更回调
 func (m *Manager) OnChange(fn func(*Config)) {
 	m.mu.Lock()
@@ -261,7 +741,14 @@ func (m *Manager) createDefaultConfig() error {
 	defaultConfig := `{
   "server": {
     "port": 8080,
-    "healthCheck": true
+    "healthCheck": true,
+    "adminToken": "",
+    "debugLog": {
+      "enabled": false,
+      "file": "./debug_messages.jsonl",
+      "maxEntries": 10000
+    },
+    "webRoot": ""
   },
   "channels": {
     "telegram": {
@@ -451,7 +938,8 @@ func (m *Manager) createDefaultConfig() error {
   "language": {
     "default": "en-US",
     "current": "en-US",
-    "supported": ["en-US", "zh-CN", "ja-JP"]
+    "supported": ["en-US", "zh-CN", "ja-JP", "ko-KR", "de-DE", "fr-FR", "es-ES", "ru-RU"],
+    "autoDetect": true
   },
   "agents": {
     "default": {
@@ -462,25 +950,46 @@ func (m *Manager) createDefaultConfig() error {
   },
   "tools": {
     "workDir": "/tmp/mujibot",
+    "workspaces": [],
+    "perUserWorkDir": false,
+    "sharedDir": "",
     "timeout": 30,
     "confirmDangerous": true,
     "unattendedMode": false,
     "alwaysAllowDangerous": [],
     "allowedCommands": [],
     "blockedCommands": ["reboot", "shutdown", "init", "poweroff", "halt"],
+    "quotas": {
+      "maxCallsPerMessage": 20,
+      "maxCommandsPerHour": 60,
+      "maxHTTPRequestsPerDay": 500
+    },
     "enabledTools": {
       "read_file": true,
       "write_file": true,
       "list_directory": true,
+      "move_file": true,
+      "copy_file": true,
+      "delete_file": true,
+      "make_directory": true,
+      "stat": true,
+      "archive": true,
+      "undo_edit": true,
       "execute_command": true,
+      "processes": true,
       "weather": true,
       "ip_info": true,
       "exchange_rate": true,
+      "quotes": true,
+      "datetime": true,
       "memory_read": true,
-      "memory_write": true
+      "memory_write": true,
+      "memory_search": true
     },
     "webSearchEnabled": false,
     "terminalEnabled": false,
+    "terminalRows": 24,
+    "terminalCols": 120,
     "customAPIs": []
   },
   "session": {
@@ -517,6 +1026,23 @@ func (m *Manager) replaceEnvVars(config *Config) {
 	config.Channels.Feishu.AppSecret = m.getEnvOrDefault(config.Channels.Feishu.AppSecret, "")
 	config.Channels.Feishu.EncryptKey = m.getEnvOrDefault(config.Channels.Feishu.EncryptKey, "")
 	config.LLM.APIKey = m.getEnvOrDefault(config.LLM.APIKey, "")
+	config.Guardrails.Moderation.APIKey = m.getEnvOrDefault(config.Guardrails.Moderation.APIKey, "")
+	config.Guardrails.ToolOutputs.Classifier.APIKey = m.getEnvOrDefault(config.Guardrails.ToolOutputs.Classifier.APIKey, "")
+	config.Tools.Email.Username = m.getEnvOrDefault(config.Tools.Email.Username, "")
+	config.Tools.Email.Password = m.getEnvOrDefault(config.Tools.Email.Password, "")
+	config.Tools.Calendar.Username = m.getEnvOrDefault(config.Tools.Calendar.Username, "")
+	config.Tools.Calendar.Password = m.getEnvOrDefault(config.Tools.Calendar.Password, "")
+	config.Tools.Calendar.Google.ClientSecret = m.getEnvOrDefault(config.Tools.Calendar.Google.ClientSecret, "")
+	config.Tools.Calendar.Google.RefreshToken = m.getEnvOrDefault(config.Tools.Calendar.Google.RefreshToken, "")
+	config.Tools.HomeAssistant.Token = m.getEnvOrDefault(config.Tools.HomeAssistant.Token, "")
+	for _, e := range config.Logging.Exporters {
+		for k, v := range e.Headers {
+			e.Headers[k] = m.getEnvOrDefault(v, "")
+		}
+	}
+	for i := range config.Webhooks.Endpoints {
+		config.Webhooks.Endpoints[i].Secret = m.getEnvOrDefault(config.Webhooks.Endpoints[i].Secret, "")
+	}
 }
 
 // getEnvOrDefault 获取环境变量值
@@ -539,7 +1065,43 @@ func (m *Manager) validate(config *Config) error {
 	if config.LLM.Provider == "" {
 		return fmt.Errorf("llm.provider is required")
 	}
-	if config.LLM.APIKey == "" && config.LLM.Provider != "ollama" {
+	switch config.Server.CSRF {
+	case "", "same-origin", "off":
+	default:
+		return fmt.Errorf("invalid server.csrf: %s (expected same-origin or off)", config.Server.CSRF)
+	}
+	for _, origin := range config.Server.CORS.AllowedOrigins {
+		if origin != "*" && !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return fmt.Errorf("invalid server.cors.allowedOrigins entry: %s (expected scheme://host[:port] or *)", origin)
+		}
+	}
+
+	for channel, style := range config.Channels.Responses {
+		if style.MaxResponseTokens < 0 {
+			return fmt.Errorf("invalid channels.responses.%s.maxResponseTokens: %d", channel, style.MaxResponseTokens)
+		}
+		if style.Verbosity != "" && !isVerbosity(style.Verbosity) {
+			return fmt.Errorf("invalid channels.responses.%s.verbosity: %s (expected one of %s)", channel, style.Verbosity, strings.Join(Verbosities, ", "))
+		}
+	}
+
+	for i, ep := range config.Webhooks.Endpoints {
+		if !strings.HasPrefix(ep.URL, "http://") && !strings.HasPrefix(ep.URL, "https://") {
+			return fmt.Errorf("invalid webhooks.endpoints[%d].url: %q (expected http:// or https://)", i, ep.URL)
+		}
+		for _, event := range ep.Events {
+			if !isWebhookEvent(event) {
+				return fmt.Errorf("invalid webhooks.endpoints[%d].events entry: %s (expected one of %s)", i, event, strings.Join(WebhookEvents, ", "))
+			}
+		}
+	}
+
+	switch config.LLM.Replay.Mode {
+	case "", "record", "replay":
+	default:
+		return fmt.Errorf("invalid llm.replay.mode: %s (expected record or replay)", config.LLM.Replay.Mode)
+	}
+	if config.LLM.APIKey == "" && config.LLM.Provider != "ollama" && config.LLM.Replay.Mode != "replay" {
 		return fmt.Errorf("llm.apiKey is required for provider %s", config.LLM.Provider)
 	}
 
@@ -120,3 +120,29 @@ func TestReplaceEnvVars(t *testing.T) {
 		t.Errorf("apiKey should be replaced with env var, got: %s", cfg.LLM.APIKey)
 	}
 }
+
+func TestApplyResourcePreset(t *testing.T) {
+	cfg := &Config{Resources: ResourcesConfig{Preset: "pi-zero", Workers: 2}}
+	cfg.Session.MaxMessages = 40
+	if err := applyResourcePreset(cfg); err != nil {
+		t.Fatalf("applyResourcePreset failed: %v", err)
+	}
+	// 显式设置的值优先，其余由配置档填充
+	if cfg.Session.MaxMessages != 40 || cfg.Resources.Workers != 2 {
+		t.Errorf("explicit values overwritten: %+v %+v", cfg.Session, cfg.Resources)
+	}
+	if cfg.Session.MaxSessions != 20 || cfg.Resources.LogBuffer != 50 || cfg.Resources.MemoryCriticalMB != 80 ||
+		cfg.Resources.StreamChunkBytes != 256 || cfg.Alerts.MemoryThresholdMB != 60 || cfg.Server.DebugLog.MaxEntries != 1000 {
+		t.Errorf("preset not applied: %+v %+v", cfg.Session, cfg.Resources)
+	}
+
+	cfg = &Config{}
+	if err := applyResourcePreset(cfg); err != nil || cfg.Resources.Workers != 0 {
+		t.Errorf("empty preset changed config: %+v, %v", cfg.Resources, err)
+	}
+
+	cfg = &Config{Resources: ResourcesConfig{Preset: "pi-5"}}
+	if err := applyResourcePreset(cfg); err == nil {
+		t.Error("unknown preset should fail")
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// resourcePreset 配置档中的各项取值
+type resourcePreset struct {
+	sessionMaxMessages int
+	sessionMaxSessions int
+	debugLogMaxEntries int
+	alertMemoryMB      int
+	workers            int
+	logBuffer          int
+	memoryGCMB         int
+	memoryCriticalMB   int
+	streamChunkBytes   int
+}
+
+// resourcePresets 内置配置档
+var resourcePresets = map[string]resourcePreset{
+	// 512MB 内存的单核板子，如树莓派 Zero 2W、玩客云
+	"pi-zero": {
+		sessionMaxMessages: 10,
+		sessionMaxSessions: 20,
+		debugLogMaxEntries: 1000,
+		alertMemoryMB:      60,
+		workers:            1,
+		logBuffer:          50,
+		memoryGCMB:         50,
+		memoryCriticalMB:   80,
+		streamChunkBytes:   256,
+	},
+	// 2GB 以上内存的四核板子，如树莓派 4B
+	"pi-4": {
+		sessionMaxMessages: 30,
+		sessionMaxSessions: 100,
+		debugLogMaxEntries: 10000,
+		alertMemoryMB:      150,
+		workers:            4,
+		logBuffer:          200,
+		memoryGCMB:         150,
+		memoryCriticalMB:   250,
+		streamChunkBytes:   64,
+	},
+	// 云服务器
+	"vps": {
+		sessionMaxMessages: 50,
+		sessionMaxSessions: 500,
+		debugLogMaxEntries: 50000,
+		alertMemoryMB:      400,
+		workers:            16,
+		logBuffer:          1000,
+		memoryGCMB:         400,
+		memoryCriticalMB:   800,
+	},
+}
+
+// resourcePresetNames 内置配置档的名称
+func resourcePresetNames() []string {
+	names := make([]string, 0, len(resourcePresets))
+	for name := range resourcePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyResourcePreset 用 resources.preset 填充未设置的项
+func applyResourcePreset(config *Config) error {
+	res := &config.Resources
+	if res.Preset == "" {
+		return nil
+	}
+	p, ok := resourcePresets[res.Preset]
+	if !ok {
+		return fmt.Errorf("unknown resources.preset: %s (expected %s)", res.Preset, strings.Join(resourcePresetNames(), ", "))
+	}
+
+	fill(&config.Session.MaxMessages, p.sessionMaxMessages)
+	fill(&config.Session.MaxSessions, p.sessionMaxSessions)
+	fill(&config.Server.DebugLog.MaxEntries, p.debugLogMaxEntries)
+	fill(&config.Alerts.MemoryThresholdMB, p.alertMemoryMB)
+	fill(&res.Workers, p.workers)
+	fill(&res.LogBuffer, p.logBuffer)
+	fill(&res.MemoryGCMB, p.memoryGCMB)
+	fill(&res.MemoryCriticalMB, p.memoryCriticalMB)
+	fill(&res.StreamChunkBytes, p.streamChunkBytes)
+	return nil
+}
+
+func fill(v *int, preset int) {
+	if *v == 0 {
+		*v = preset
+	}
+}
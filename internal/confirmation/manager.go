@@ -7,8 +7,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/HaohanHe/mujibot/internal/cluster"
 	"github.com/HaohanHe/mujibot/internal/config"
 	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/policy"
 )
 
 type ConfirmationStatus string
@@ -31,7 +33,37 @@ type ConfirmationRequest struct {
 	Status      ConfirmationStatus `json:"status"`
 	ApprovedBy  string             `json:"approvedBy,omitempty"`
 	Channel     string             `json:"channel,omitempty"`
+	UserID      string             `json:"userId,omitempty"`
+	Target      string             `json:"target,omitempty"`
 	MessageID   string             `json:"messageId,omitempty"`
+	Effects     []policy.Effect    `json:"effects,omitempty"` // 操作的影响摘要，显示在确认请求中
+}
+
+type originKey struct{}
+
+// Origin 发起确认的聊天来源，通知器据此把确认请求发回原会话
+type Origin struct {
+	Channel string
+	UserID  string
+	Target  string
+}
+
+// WithOrigin 在上下文中记录确认请求的来源
+func WithOrigin(ctx context.Context, o Origin) context.Context {
+	return context.WithValue(ctx, originKey{}, o)
+}
+
+// OriginFrom 读取上下文中的确认请求来源
+func OriginFrom(ctx context.Context) (Origin, bool) {
+	o, ok := ctx.Value(originKey{}).(Origin)
+	return o, ok
+}
+
+type effectsKey struct{}
+
+// WithEffects 在上下文中记录操作的影响摘要（删除的路径、卸载的软件包等），随确认请求一起展示，避免盲目批准
+func WithEffects(ctx context.Context, effects []policy.Effect) context.Context {
+	return context.WithValue(ctx, effectsKey{}, effects)
 }
 
 type ConfirmationManager struct {
@@ -41,6 +73,7 @@ type ConfirmationManager struct {
 	config    *config.Manager
 	notifiers []Notifier
 	timeout   time.Duration
+	store     cluster.Store
 }
 
 type Notifier interface {
@@ -87,15 +120,23 @@ func (m *ConfirmationManager) RequestConfirmation(ctx context.Context, opType, o
 		ExpiresAt: time.Now().Add(m.timeout),
 		Status:    StatusPending,
 	}
+	req.Effects, _ = ctx.Value(effectsKey{}).([]policy.Effect)
+	if o, ok := OriginFrom(ctx); ok {
+		req.Channel = o.Channel
+		req.UserID = o.UserID
+		req.Target = o.Target
+	}
 
 	m.mu.Lock()
 	m.requests[req.ID] = req
 	m.mu.Unlock()
+	m.share(req)
 
 	defer func() {
 		m.mu.Lock()
 		delete(m.requests, req.ID)
 		m.mu.Unlock()
+		m.unshare(req.ID)
 	}()
 
 	for _, n := range m.notifiers {
@@ -123,6 +164,7 @@ func (m *ConfirmationManager) waitForResponse(ctx context.Context, req *Confirma
 			if !ok {
 				return false, fmt.Errorf("request not found")
 			}
+			m.syncShared(current)
 
 			if time.Now().After(req.ExpiresAt) {
 				m.mu.Lock()
@@ -131,8 +173,11 @@ func (m *ConfirmationManager) waitForResponse(ctx context.Context, req *Confirma
 				return false, fmt.Errorf("confirmation timeout")
 			}
 
-			if current.Status != StatusPending {
-				return current.Status == StatusApproved, nil
+			m.mu.RLock()
+			status := current.Status
+			m.mu.RUnlock()
+			if status != StatusPending {
+				return status == StatusApproved, nil
 			}
 		}
 	}
@@ -144,11 +189,12 @@ func (m *ConfirmationManager) Approve(id, approvedBy string) error {
 
 	req, ok := m.requests[id]
 	if !ok {
-		return fmt.Errorf("request not found: %s", id)
+		return m.resolveShared(id, approvedBy, true)
 	}
 
 	req.Status = StatusApproved
 	req.ApprovedBy = approvedBy
+	m.share(req)
 
 	m.log.Info("operation approved", "id", id, "operation", req.Operation, "by", approvedBy)
 
@@ -165,11 +211,12 @@ func (m *ConfirmationManager) Reject(id, rejectedBy string) error {
 
 	req, ok := m.requests[id]
 	if !ok {
-		return fmt.Errorf("request not found: %s", id)
+		return m.resolveShared(id, rejectedBy, false)
 	}
 
 	req.Status = StatusRejected
 	req.ApprovedBy = rejectedBy
+	m.share(req)
 
 	m.log.Info("operation rejected", "id", id, "operation", req.Operation, "by", rejectedBy)
 
@@ -190,6 +237,11 @@ func (m *ConfirmationManager) GetPending() []*ConfirmationRequest {
 			pending = append(pending, req)
 		}
 	}
+	for _, req := range m.sharedPending() {
+		if _, ok := m.requests[req.ID]; !ok {
+			pending = append(pending, req)
+		}
+	}
 	return pending
 }
 
@@ -199,7 +251,7 @@ func (m *ConfirmationManager) GetRequest(id string) (*ConfirmationRequest, error
 
 	req, ok := m.requests[id]
 	if !ok {
-		return nil, fmt.Errorf("request not found: %s", id)
+		return m.loadShared(id)
 	}
 	return req, nil
 }
@@ -215,56 +267,3 @@ func (m *ConfirmationManager) ToJSON() string {
 func generateID() string {
 	return fmt.Sprintf("conf_%d", time.Now().UnixNano())
 }
-
-func IsDangerousOperation(operation string) bool {
-	dangerousPatterns := []string{
-		"rm -rf",
-		"rm -r",
-		"rm -f",
-		"del /",
-		"format",
-		"fdisk",
-		"mkfs",
-		"dd if=",
-		"chmod 777",
-		"chown -R",
-		"> /dev/",
-		":(){ :|:& };:",
-		"wget | sh",
-		"curl | sh",
-		"curl | bash",
-		"apt-get remove",
-		"apt-get purge",
-		"yum remove",
-		"dnf remove",
-		"pacman -R",
-		"pip uninstall",
-		"npm uninstall",
-		"git push --force",
-		"git reset --hard",
-		"DROP TABLE",
-		"DROP DATABASE",
-		"TRUNCATE",
-		"DELETE FROM",
-	}
-
-	for _, pattern := range dangerousPatterns {
-		if contains(operation, pattern) {
-			return true
-		}
-	}
-	return false
-}
-
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsHelper(s, substr))
-}
-
-func containsHelper(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
-	}
-	return false
-}
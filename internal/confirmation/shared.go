@@ -0,0 +1,118 @@
+package confirmation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/cluster"
+)
+
+// sharedPrefix 共享存储中确认请求的键前缀
+const sharedPrefix = "confirm:"
+
+// SetStore 设置共享存储，须在发起确认前调用。多实例部署时任一实例都能列出和处理其他实例发起的确认
+func (m *ConfirmationManager) SetStore(store cluster.Store) {
+	m.store = store
+}
+
+// share 把请求写入共享存储，过期后自动删除
+func (m *ConfirmationManager) share(req *ConfirmationRequest) {
+	if m.store == nil {
+		return
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return
+	}
+	ttl := time.Until(req.ExpiresAt) + time.Minute
+	if err := m.store.Set(sharedPrefix+req.ID, data, ttl); err != nil {
+		m.log.Warn("failed to share confirmation", "id", req.ID, "error", err)
+	}
+}
+
+func (m *ConfirmationManager) unshare(id string) {
+	if m.store == nil {
+		return
+	}
+	if err := m.store.Delete(sharedPrefix + id); err != nil {
+		m.log.Warn("failed to delete shared confirmation", "id", id, "error", err)
+	}
+}
+
+// loadShared 从共享存储读取请求
+func (m *ConfirmationManager) loadShared(id string) (*ConfirmationRequest, error) {
+	if m.store == nil {
+		return nil, fmt.Errorf("request not found: %s", id)
+	}
+	data, err := m.store.Get(sharedPrefix + id)
+	if err != nil {
+		return nil, fmt.Errorf("request not found: %s", id)
+	}
+	var req ConfirmationRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("invalid shared confirmation %s: %w", id, err)
+	}
+	return &req, nil
+}
+
+// syncShared 采用其他实例在共享存储中做出的决定
+func (m *ConfirmationManager) syncShared(req *ConfirmationRequest) {
+	shared, err := m.loadShared(req.ID)
+	if err != nil || shared.Status == StatusPending {
+		return
+	}
+	m.mu.Lock()
+	if req.Status == StatusPending {
+		req.Status = shared.Status
+		req.ApprovedBy = shared.ApprovedBy
+	}
+	m.mu.Unlock()
+}
+
+// resolveShared 处理其他实例发起的请求：写回共享存储，由发起实例在等待中读取结果
+func (m *ConfirmationManager) resolveShared(id, by string, approve bool) error {
+	req, err := m.loadShared(id)
+	if err != nil || req.Status != StatusPending {
+		return fmt.Errorf("request not found: %s", id)
+	}
+
+	req.Status = StatusRejected
+	if approve {
+		req.Status = StatusApproved
+	}
+	req.ApprovedBy = by
+	m.share(req)
+
+	if approve {
+		m.log.Info("operation approved", "id", id, "operation", req.Operation, "by", by, "shared", true)
+	} else {
+		m.log.Info("operation rejected", "id", id, "operation", req.Operation, "by", by, "shared", true)
+	}
+	for _, n := range m.notifiers {
+		go n.NotifyResult(req, approve)
+	}
+	return nil
+}
+
+// sharedPending 列出共享存储中未过期的待确认请求
+func (m *ConfirmationManager) sharedPending() []*ConfirmationRequest {
+	if m.store == nil {
+		return nil
+	}
+	keys, err := m.store.Keys(sharedPrefix)
+	if err != nil {
+		m.log.Warn("failed to list shared confirmations", "error", err)
+		return nil
+	}
+
+	var pending []*ConfirmationRequest
+	for _, key := range keys {
+		req, err := m.loadShared(strings.TrimPrefix(key, sharedPrefix))
+		if err == nil && req.Status == StatusPending && time.Now().Before(req.ExpiresAt) {
+			pending = append(pending, req)
+		}
+	}
+	return pending
+}
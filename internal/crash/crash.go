@@ -0,0 +1,259 @@
+package crash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+const (
+	DefaultDir        = "./crashes"
+	DefaultMaxReports = 50
+	recentMessages    = 20
+)
+
+// MessageMeta 消息元数据（不含内容）
+type MessageMeta struct {
+	Time    time.Time `json:"time"`
+	Channel string    `json:"channel"`
+	UserID  string    `json:"user_id"`
+	Length  int       `json:"length"`
+}
+
+// Report 崩溃报告
+type Report struct {
+	ID                string        `json:"id"`
+	Time              time.Time     `json:"time"`
+	Source            string        `json:"source"`
+	Panic             string        `json:"panic"`
+	Stack             string        `json:"stack"`
+	GoVersion         string        `json:"go_version"`
+	Goroutines        int           `json:"goroutines"`
+	HeapMB            uint64        `json:"heap_mb"`
+	ConfigFingerprint string        `json:"config_fingerprint"`
+	RecentMessages    []MessageMeta `json:"recent_messages"`
+}
+
+// Summary 崩溃报告摘要
+type Summary struct {
+	ID     string    `json:"id"`
+	Time   time.Time `json:"time"`
+	Source string    `json:"source"`
+	Panic  string    `json:"panic"`
+}
+
+// Notifier 发送崩溃通知
+type Notifier func(text string)
+
+// Reporter 崩溃报告器
+type Reporter struct {
+	config *config.Manager
+	log    *logger.Logger
+	notify Notifier
+
+	mu     sync.Mutex
+	recent []MessageMeta
+}
+
+// NewReporter 创建崩溃报告器
+func NewReporter(cfg *config.Manager, log *logger.Logger) *Reporter {
+	return &Reporter{
+		config: cfg,
+		log:    log,
+	}
+}
+
+// SetNotifier 设置管理员通知
+func (r *Reporter) SetNotifier(fn Notifier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notify = fn
+}
+
+// RecordMessage 记录最近消息的元数据
+func (r *Reporter) RecordMessage(channel, userID string, length int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.recent = append(r.recent, MessageMeta{
+		Time:    time.Now(),
+		Channel: channel,
+		UserID:  userID,
+		Length:  length,
+	})
+	if len(r.recent) > recentMessages {
+		r.recent = r.recent[len(r.recent)-recentMessages:]
+	}
+}
+
+// Report 写入崩溃报告，返回报告ID
+func (r *Reporter) Report(source string, recovered interface{}, stack []byte) string {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	now := time.Now()
+	cfg := r.config.Get()
+
+	r.mu.Lock()
+	recent := append([]MessageMeta(nil), r.recent...)
+	notify := r.notify
+	r.mu.Unlock()
+
+	report := Report{
+		ID:                now.Format("20060102-150405.000000"),
+		Time:              now,
+		Source:            source,
+		Panic:             fmt.Sprint(recovered),
+		Stack:             string(stack),
+		GoVersion:         runtime.Version(),
+		Goroutines:        runtime.NumGoroutine(),
+		HeapMB:            m.HeapAlloc / 1024 / 1024,
+		ConfigFingerprint: Fingerprint(cfg),
+		RecentMessages:    recent,
+	}
+
+	r.log.Error("panic recovered",
+		"source", source,
+		"error", report.Panic,
+		"crash_id", report.ID,
+		"stack", report.Stack,
+	)
+
+	if err := r.write(cfg, report); err != nil {
+		r.log.Error("failed to write crash report", "error", err)
+	}
+
+	if notify != nil && cfg.Crash.Notify {
+		notify(fmt.Sprintf("💥 Panic in %s: %s (crash %s)", source, truncate(report.Panic, 200), report.ID))
+	}
+
+	return report.ID
+}
+
+// List 列出崩溃报告，按时间倒序
+func (r *Reporter) List() ([]Summary, error) {
+	dir := r.dir(r.config.Get())
+	files, err := r.files(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]Summary, 0, len(files))
+	for i := len(files) - 1; i >= 0; i-- {
+		report, err := r.read(filepath.Join(dir, files[i]))
+		if err != nil {
+			continue
+		}
+		summaries = append(summaries, Summary{
+			ID:     report.ID,
+			Time:   report.Time,
+			Source: report.Source,
+			Panic:  report.Panic,
+		})
+	}
+	return summaries, nil
+}
+
+// Get 获取崩溃报告详情
+func (r *Reporter) Get(id string) (*Report, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return nil, fmt.Errorf("invalid crash id")
+	}
+	return r.read(filepath.Join(r.dir(r.config.Get()), id+".json"))
+}
+
+// Fingerprint 计算配置指纹，用于判断崩溃时的配置是否一致
+func Fingerprint(cfg *config.Config) string {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// write 写入报告并清理超出数量的旧报告
+func (r *Reporter) write(cfg *config.Config, report Report) error {
+	dir := r.dir(cfg)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, report.ID+".json"), data, 0600); err != nil {
+		return err
+	}
+
+	max := cfg.Crash.MaxReports
+	if max <= 0 {
+		max = DefaultMaxReports
+	}
+	files, err := r.files(dir)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(files)-max; i++ {
+		os.Remove(filepath.Join(dir, files[i]))
+	}
+	return nil
+}
+
+// files 列出报告文件名，按时间正序
+func (r *Reporter) files(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && filepath.Ext(e.Name()) == ".json" {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (r *Reporter) read(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *Reporter) dir(cfg *config.Config) string {
+	if cfg.Crash.Dir != "" {
+		return cfg.Crash.Dir
+	}
+	return DefaultDir
+}
+
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen] + "..."
+}
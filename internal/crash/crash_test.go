@@ -0,0 +1,87 @@
+package crash
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func newTestReporter(t *testing.T, maxReports int) (*Reporter, string) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	t.Cleanup(func() { log.Close() })
+
+	dir := t.TempDir()
+	crashDir := filepath.Join(dir, "crashes")
+	configPath := filepath.Join(dir, "config.json5")
+	content := `{
+		"llm": {"provider": "ollama"},
+		"crash": {"dir": "` + filepath.ToSlash(crashDir) + `", "maxReports": ` + strconv.Itoa(maxReports) + `, "notify": true}
+	}`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := config.NewManager(configPath, log)
+	if err != nil {
+		t.Fatalf("failed to create config manager: %v", err)
+	}
+	t.Cleanup(func() { cfg.Close() })
+
+	return NewReporter(cfg, log), crashDir
+}
+
+func TestReport(t *testing.T) {
+	r, dir := newTestReporter(t, 5)
+
+	var notified string
+	r.SetNotifier(func(text string) { notified = text })
+
+	r.RecordMessage("telegram", "123", 42)
+	id := r.Report("gateway:telegram", "boom", []byte("goroutine 1 [running]"))
+
+	if _, err := os.Stat(filepath.Join(dir, id+".json")); err != nil {
+		t.Fatalf("crash report not written: %v", err)
+	}
+	if !strings.Contains(notified, "boom") {
+		t.Errorf("admin should be notified, got: %q", notified)
+	}
+
+	report, err := r.Get(id)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if report.Panic != "boom" || report.Source != "gateway:telegram" || report.ConfigFingerprint == "" {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if len(report.RecentMessages) != 1 || report.RecentMessages[0].Length != 42 {
+		t.Errorf("recent messages not recorded: %+v", report.RecentMessages)
+	}
+
+	if _, err := r.Get("../config"); err == nil {
+		t.Error("Get should reject path traversal")
+	}
+}
+
+func TestReportRetention(t *testing.T) {
+	r, _ := newTestReporter(t, 2)
+
+	for i := 0; i < 4; i++ {
+		r.Report("test", i, nil)
+	}
+
+	list, err := r.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("should keep 2 reports, got: %d", len(list))
+	}
+	if list[0].Panic != "3" {
+		t.Errorf("newest report should be listed first, got: %s", list[0].Panic)
+	}
+}
@@ -0,0 +1,286 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 计算下一次运行时间
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// Parse 解析调度表达式
+// 支持标准5字段cron（分 时 日 月 周）以及 @hourly、@daily、@weekly、@monthly、@every <duration>
+func Parse(spec string) (Schedule, error) {
+	spec = expandMacro(spec)
+
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval: %w", err)
+		}
+		if d < time.Minute {
+			return nil, fmt.Errorf("interval must be at least 1m")
+		}
+		return everySchedule{interval: d}, nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+
+	var s cronSchedule
+	var err error
+	if s.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if s.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if s.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	// 7 与 0 都表示周日
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+
+	return s, nil
+}
+
+// expandMacro 将 @hourly 等简写展开为5字段表达式
+func expandMacro(spec string) string {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "@hourly":
+		return "0 * * * *"
+	case "@daily", "@midnight":
+		return "0 0 * * *"
+	case "@weekly":
+		return "0 0 * * 0"
+	case "@monthly":
+		return "0 0 1 * *"
+	}
+	return spec
+}
+
+// everySchedule 固定间隔调度
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Truncate(time.Minute).Add(s.interval)
+}
+
+// cronSchedule cron调度，各字段以位图表示
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+func (s cronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// dayMatches 日与周均有限制时满足其一即可（与标准cron一致）
+func (s cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// parseField 解析单个字段，支持 *、a-b、*/n、a-b/n 与逗号列表
+func parseField(field string, min, max int) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			lo = n
+			if step == 1 {
+				hi = n
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("value out of range %q", field)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+var (
+	monthNames   = []string{"", "January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+	weekdayNames = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+)
+
+// Describe 将调度表达式解释为可读的英文描述
+func Describe(spec string) (string, error) {
+	if _, err := Parse(spec); err != nil {
+		return "", err
+	}
+	spec = expandMacro(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		d, _ := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		return "every " + d.String(), nil
+	}
+
+	f := strings.Fields(spec)
+	minute, hour, dom, month, dow := f[0], f[1], f[2], f[3], f[4]
+
+	var parts []string
+	if isNumber(minute) && isNumber(hour) {
+		m, _ := strconv.Atoi(minute)
+		h, _ := strconv.Atoi(hour)
+		parts = append(parts, fmt.Sprintf("at %02d:%02d", h, m))
+	} else {
+		switch {
+		case minute == "*":
+			parts = append(parts, "every minute")
+		case strings.HasPrefix(minute, "*/"):
+			parts = append(parts, "every "+minute[2:]+" minutes")
+		default:
+			parts = append(parts, "at minute "+describeField(minute, nil))
+		}
+		switch {
+		case hour == "*":
+		case strings.HasPrefix(hour, "*/"):
+			parts = append(parts, "every "+hour[2:]+" hours")
+		default:
+			parts = append(parts, "past hour "+describeField(hour, nil))
+		}
+	}
+
+	var days []string
+	if dom != "*" {
+		days = append(days, "on day "+describeField(dom, nil)+" of the month")
+	}
+	if dow != "*" {
+		days = append(days, "on "+describeField(dow, weekdayNames))
+	}
+	if len(days) > 0 {
+		// 日与周同时限制时满足其一即可
+		parts = append(parts, strings.Join(days, " or "))
+	} else {
+		parts = append(parts, "every day")
+	}
+
+	if month != "*" {
+		parts = append(parts, "in "+describeField(month, monthNames))
+	}
+
+	return strings.Join(parts, ", "), nil
+}
+
+// describeField 描述单个字段，names 用于将数字转换为名称
+func describeField(field string, names []string) string {
+	name := func(s string) string {
+		n, err := strconv.Atoi(s)
+		if err != nil || names == nil || n < 0 || n >= len(names) {
+			return s
+		}
+		return names[n]
+	}
+
+	var parts []string
+	for _, part := range strings.Split(field, ",") {
+		step := ""
+		if i := strings.Index(part, "/"); i >= 0 {
+			step = part[i+1:]
+			part = part[:i]
+		}
+
+		var desc string
+		switch {
+		case part == "*":
+			desc = "every " + step
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			desc = name(bounds[0]) + " through " + name(bounds[1])
+			if step != "" {
+				desc = "every " + step + " from " + desc
+			}
+		default:
+			desc = name(part)
+			if step != "" {
+				desc = "every " + step + " starting at " + desc
+			}
+		}
+		parts = append(parts, desc)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
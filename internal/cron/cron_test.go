@@ -0,0 +1,78 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAndNext(t *testing.T) {
+	base := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC) // 周五
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"0 7 * * *", time.Date(2024, 3, 16, 7, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 3, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2024, 3, 15, 13, 0, 0, 0, time.UTC)},
+		{"30 8 * * 1", time.Date(2024, 3, 18, 8, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 0,6", time.Date(2024, 3, 16, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2024, 3, 17, 12, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 3, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"@every 90m", time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			sched, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.spec, err)
+			}
+			if got := sched.Next(base); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "0 24 * * *", "*/0 * * * *", "a * * * *", "@every 10s", "@every x"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) should fail", spec)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"0 7 * * *", "at 07:00, every day"},
+		{"*/15 * * * *", "every 15 minutes, every day"},
+		{"0 9-17/4 * * 1-5", "at minute 0, past hour every 4 from 9 through 17, on Monday through Friday"},
+		{"30 8 1,15 * *", "at 08:30, on day 1, 15 of the month"},
+		{"0 12 13 * 5", "at 12:00, on day 13 of the month or on Friday"},
+		{"0 0 1 1,7 *", "at 00:00, on day 1 of the month, in January, July"},
+		{"@weekly", "at 00:00, on Sunday"},
+		{"@every 90m", "every 1h30m0s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := Describe(tt.spec)
+			if err != nil {
+				t.Fatalf("Describe(%q) failed: %v", tt.spec, err)
+			}
+			if got != tt.want {
+				t.Errorf("Describe(%q) = %q, want %q", tt.spec, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Describe("61 * * * *"); err == nil {
+		t.Error("Describe should reject invalid expressions")
+	}
+}
@@ -0,0 +1,187 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// KeySize AES-256 密钥长度
+const KeySize = 32
+
+// DefaultKeyEnv 默认读取密钥的环境变量
+const DefaultKeyEnv = "MUJIBOT_ENCRYPTION_KEY"
+
+// magic 加密数据的文件头，没有文件头的数据视为明文
+const magic = "MJBENC1\x00"
+
+// ErrNoKey 数据已加密但没有配置密钥
+var ErrNoKey = errors.New("data is encrypted but no encryption key is configured")
+
+// Cipher AES-256-GCM 加解密。nil 表示未开启加密：写入明文，读取明文，遇到加密数据返回 ErrNoKey
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// New 用32字节密钥创建加密器
+func New(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// ParseKey 解析 base64 或十六进制编码的32字节密钥
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	for _, decode := range []func(string) ([]byte, error){
+		base64.StdEncoding.DecodeString,
+		base64.RawStdEncoding.DecodeString,
+		base64.URLEncoding.DecodeString,
+		base64.RawURLEncoding.DecodeString,
+		hex.DecodeString,
+	} {
+		if key, err := decode(s); err == nil && len(key) == KeySize {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("encryption key must be %d bytes encoded as base64 or hex", KeySize)
+}
+
+// LoadKey 读取密钥：优先使用密钥文件，其次是环境变量（为空时使用 DefaultKeyEnv）
+func LoadKey(keyFile, keyEnv string) ([]byte, error) {
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read encryption key file: %w", err)
+		}
+		return ParseKey(string(data))
+	}
+	if keyEnv == "" {
+		keyEnv = DefaultKeyEnv
+	}
+	value := os.Getenv(keyEnv)
+	if value == "" {
+		return nil, fmt.Errorf("encryption key not found: set %s or encryption.keyFile", keyEnv)
+	}
+	return ParseKey(value)
+}
+
+// GenerateKey 生成 base64 编码的随机密钥
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// IsEncrypted 判断数据是否为加密格式
+func IsEncrypted(data []byte) bool {
+	return strings.HasPrefix(string(data), magic)
+}
+
+// Seal 加密数据：文件头 + nonce + 密文。c 为 nil 时原样返回
+func (c *Cipher) Seal(plain []byte) ([]byte, error) {
+	if c == nil {
+		return plain, nil
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(magic)+len(nonce)+len(plain)+c.aead.Overhead())
+	out = append(out, magic...)
+	out = append(out, nonce...)
+	return c.aead.Seal(out, nonce, plain, []byte(magic)), nil
+}
+
+// Open 解密数据，明文数据原样返回，便于从未加密的文件迁移
+func (c *Cipher) Open(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	if c == nil {
+		return nil, ErrNoKey
+	}
+	data = data[len(magic):]
+	n := c.aead.NonceSize()
+	if len(data) < n {
+		return nil, errors.New("encrypted data is truncated")
+	}
+	plain, err := c.aead.Open(nil, data[:n], data[n:], []byte(magic))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data (wrong key?): %w", err)
+	}
+	return plain, nil
+}
+
+// ReadFile 读取并解密文件
+func (c *Cipher) ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := c.Open(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return plain, nil
+}
+
+// WriteFile 加密并写入文件，开启加密时权限收紧为 0600
+func (c *Cipher) WriteFile(path string, data []byte, perm os.FileMode) error {
+	sealed, err := c.Seal(data)
+	if err != nil {
+		return err
+	}
+	if c != nil {
+		perm = 0600
+	}
+	return os.WriteFile(path, sealed, perm)
+}
+
+// EncryptFiles 加密目录下所有未加密的 .md 和 .json 文件，返回加密的文件数
+func (c *Cipher) EncryptFiles(dir string) (int, error) {
+	if c == nil {
+		return 0, nil
+	}
+	count := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || (filepath.Ext(path) != ".md" && filepath.Ext(path) != ".json") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || IsEncrypted(data) {
+			return err
+		}
+		if err := c.WriteFile(path, data, 0600); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
@@ -0,0 +1,117 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testCipher(t *testing.T) *Cipher {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := ParseKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := New(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestSealOpen(t *testing.T) {
+	c := testCipher(t)
+	plain := []byte("# Memory\nuser likes tea")
+
+	sealed, err := c.Seal(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(sealed) || bytes.Contains(sealed, []byte("tea")) {
+		t.Fatalf("sealed data leaks plaintext: %q", sealed)
+	}
+	if again, _ := c.Seal(plain); bytes.Equal(again, sealed) {
+		t.Error("nonce should differ between seals")
+	}
+
+	opened, err := c.Open(sealed)
+	if err != nil || !bytes.Equal(opened, plain) {
+		t.Fatalf("Open = %q, %v", opened, err)
+	}
+	if opened, err := c.Open(plain); err != nil || !bytes.Equal(opened, plain) {
+		t.Errorf("plaintext should pass through, got %q, %v", opened, err)
+	}
+
+	var none *Cipher
+	if _, err := none.Open(sealed); !errors.Is(err, ErrNoKey) {
+		t.Errorf("nil cipher Open = %v, want ErrNoKey", err)
+	}
+	if _, err := testCipher(t).Open(sealed); err == nil {
+		t.Error("wrong key should fail")
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err := c.Open(sealed); err == nil {
+		t.Error("tampered data should fail")
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, KeySize)
+	if got, err := ParseKey(" " + hex.EncodeToString(key) + "\n"); err != nil || !bytes.Equal(got, key) {
+		t.Errorf("hex key = %x, %v", got, err)
+	}
+	if _, err := ParseKey("too-short"); err == nil {
+		t.Error("short key should fail")
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	key, _ := GenerateKey()
+	path := filepath.Join(t.TempDir(), "key")
+	os.WriteFile(path, []byte(key+"\n"), 0600)
+	if _, err := LoadKey(path, ""); err != nil {
+		t.Errorf("LoadKey(file) = %v", err)
+	}
+
+	t.Setenv("TEST_MUJIBOT_KEY", key)
+	if _, err := LoadKey("", "TEST_MUJIBOT_KEY"); err != nil {
+		t.Errorf("LoadKey(env) = %v", err)
+	}
+	if _, err := LoadKey("", "TEST_MUJIBOT_MISSING"); err == nil {
+		t.Error("missing env key should fail")
+	}
+}
+
+func TestEncryptFiles(t *testing.T) {
+	c := testCipher(t)
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "memory"), 0755)
+	os.WriteFile(filepath.Join(dir, "MEMORY.md"), []byte("secret"), 0644)
+	os.WriteFile(filepath.Join(dir, "memory", "2024-01-02.md"), []byte("note"), 0644)
+	os.WriteFile(filepath.Join(dir, "image.png"), []byte("png"), 0644)
+
+	n, err := c.EncryptFiles(dir)
+	if err != nil || n != 2 {
+		t.Fatalf("EncryptFiles = %d, %v", n, err)
+	}
+	if n, _ := c.EncryptFiles(dir); n != 0 {
+		t.Errorf("second pass encrypted %d files", n)
+	}
+
+	data, _ := c.ReadFile(filepath.Join(dir, "MEMORY.md"))
+	if string(data) != "secret" {
+		t.Errorf("ReadFile = %q", data)
+	}
+	if raw, _ := os.ReadFile(filepath.Join(dir, "image.png")); string(raw) != "png" {
+		t.Error("other files should be left alone")
+	}
+	if n, err := c.EncryptFiles(filepath.Join(dir, "missing")); n != 0 || err != nil {
+		t.Errorf("missing dir = %d, %v", n, err)
+	}
+}
@@ -0,0 +1,142 @@
+package eval
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/llm"
+	"github.com/HaohanHe/mujibot/internal/session"
+)
+
+func writeSuite(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadSuite(t *testing.T) {
+	t.Setenv("EVAL_TEST_KEY", "sk-test")
+	path := writeSuite(t, "suite.yaml", `
+name: smoke
+targets:
+  - preset: deepseek
+    model: deepseek-chat
+    apiKey: ${EVAL_TEST_KEY}
+  - name: local
+    provider: ollama
+cases:
+  - prompt: turn on the kitchen light
+    expectTools: [homeassistant]
+  - name: greeting
+    prompt: hi
+    noTools: true
+    reject: ["(?i)error"]
+`)
+	s, err := LoadSuite(path)
+	if err != nil {
+		t.Fatalf("LoadSuite() error = %v", err)
+	}
+	if s.Name != "smoke" || s.Runs != 1 || len(s.Cases) != 2 {
+		t.Fatalf("suite = %+v", s)
+	}
+	if s.Targets[0].Name != "deepseek-chat" || s.Targets[0].APIKey != "sk-test" || s.Targets[1].Name != "local" {
+		t.Errorf("targets = %+v", s.Targets)
+	}
+	if s.Cases[0].Name != "case1" || s.Cases[0].ExpectTools[0] != "homeassistant" || len(s.Cases[1].reject) != 1 {
+		t.Errorf("cases = %+v", s.Cases)
+	}
+
+	invalid := map[string]string{
+		"no cases":     "name: empty\n",
+		"no prompt":    "cases:\n  - name: a\n",
+		"bad pattern":  "cases:\n  - prompt: hi\n    expect: [\"(\"]\n",
+		"conflict":     "cases:\n  - prompt: hi\n    noTools: true\n    expectTools: [exec]\n",
+		"duplicate":    "targets:\n  - name: a\n  - name: a\ncases:\n  - prompt: hi\n",
+		"wrong type":   "runs: many\ncases:\n  - prompt: hi\n",
+		"bad indented": "cases:\n  - prompt: hi\n   name: x\n",
+	}
+	for name, content := range invalid {
+		if _, err := LoadSuite(writeSuite(t, "suite.yml", content)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	s, err = LoadSuite(writeSuite(t, "suite.json", `{"runs": 3, "cases": [{"prompt": "hi"}]}`))
+	if err != nil || s.Runs != 3 {
+		t.Errorf("json suite = %+v, %v", s, err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	s := &Suite{Name: "smoke", Runs: 2, Cases: []Case{
+		{Name: "light", Prompt: "turn on the light", ExpectTools: []string{"homeassistant"}, Expect: []string{`"entity_id":\s*"light\.kitchen"`}},
+		{Name: "greeting", Prompt: "hi", NoTools: true, Reject: []string{"(?i)sorry"}},
+	}}
+	if err := s.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	good := Target{Name: "good", Model: "m1", Ask: func(prompt string) (*llm.Response, error) {
+		resp := &llm.Response{Content: "Hello!", Usage: llm.Usage{PromptTokens: 10, CompletionTokens: 2}}
+		if strings.Contains(prompt, "light") {
+			var tc session.ToolCall
+			tc.Function.Name = "homeassistant"
+			tc.Function.Arguments = `{"action":"call","entity_id": "light.kitchen"}`
+			resp.Content = ""
+			resp.ToolCalls = []session.ToolCall{tc}
+		}
+		return resp, nil
+	}}
+	calls := 0
+	bad := Target{Name: "bad", Model: "m2", Ask: func(prompt string) (*llm.Response, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("timeout")
+		}
+		return &llm.Response{Content: "Sorry, I can't do that.", Usage: llm.Usage{PromptTokens: 5, CompletionTokens: 5}}, nil
+	}}
+
+	var progress []string
+	report := Run(s, []Target{good, bad}, func(r Result) {
+		progress = append(progress, FormatResult(r))
+	})
+	if len(report.Results) != 8 || len(progress) != 8 {
+		t.Fatalf("results = %d, progress = %d", len(report.Results), len(progress))
+	}
+	if !report.Failed() {
+		t.Error("Failed() = false")
+	}
+
+	g, b := report.Summary[0], report.Summary[1]
+	if g.Passed != 4 || g.PassRate != 1 || g.PromptTokens != 40 || g.CompletionTokens != 8 || g.Model != "m1" {
+		t.Errorf("good summary = %+v", g)
+	}
+	if b.Passed != 0 || b.Errors != 1 || b.PassRate != 0 || b.PromptTokens != 15 {
+		t.Errorf("bad summary = %+v", b)
+	}
+
+	first := report.Results[4]
+	if first.Error != "timeout" || !strings.Contains(progress[4], "error: timeout") {
+		t.Errorf("error result = %+v", first)
+	}
+	light := report.Results[5]
+	if len(light.Failures) != 2 || !strings.Contains(light.Failures[0], "expected tool homeassistant, got none") {
+		t.Errorf("light failures = %v", light.Failures)
+	}
+	greeting := report.Results[6]
+	if len(greeting.Failures) != 1 || !strings.Contains(greeting.Failures[0], `unexpected match for /(?i)sorry/: "Sorry"`) {
+		t.Errorf("greeting failures = %v", greeting.Failures)
+	}
+
+	var sb strings.Builder
+	report.WriteSummary(&sb)
+	if out := sb.String(); !strings.Contains(out, "good") || !strings.Contains(out, "4/4") || !strings.Contains(out, "0/4") {
+		t.Errorf("summary table = %s", out)
+	}
+}
@@ -0,0 +1,203 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/llm"
+)
+
+// Target 可评测的目标，Ask 把提示词发给模型一次并返回回复和请求的工具调用，不执行工具
+type Target struct {
+	Name  string
+	Agent string
+	Model string
+	Ask   func(prompt string) (*llm.Response, error)
+}
+
+// Result 一个用例在一个目标上的一次运行结果
+type Result struct {
+	Target           string   `json:"target"`
+	Case             string   `json:"case"`
+	Run              int      `json:"run"`
+	Passed           bool     `json:"passed"`
+	Failures         []string `json:"failures,omitempty"`
+	Error            string   `json:"error,omitempty"`
+	LatencyMs        int64    `json:"latencyMs"`
+	PromptTokens     int      `json:"promptTokens"`
+	CompletionTokens int      `json:"completionTokens"`
+	ToolCalls        []string `json:"toolCalls,omitempty"`
+	Reply            string   `json:"reply,omitempty"`
+}
+
+// Summary 目标的汇总
+type Summary struct {
+	Target           string  `json:"target"`
+	Agent            string  `json:"agent"`
+	Model            string  `json:"model"`
+	Runs             int     `json:"runs"`
+	Passed           int     `json:"passed"`
+	Errors           int     `json:"errors"`
+	PassRate         float64 `json:"passRate"`
+	AvgLatencyMs     int64   `json:"avgLatencyMs"`
+	MaxLatencyMs     int64   `json:"maxLatencyMs"`
+	PromptTokens     int     `json:"promptTokens"`
+	CompletionTokens int     `json:"completionTokens"`
+}
+
+// Report 评测报告
+type Report struct {
+	Suite   string    `json:"suite"`
+	Results []Result  `json:"results"`
+	Summary []Summary `json:"summary"`
+}
+
+// Run 依次在每个目标上运行所有用例，progress 在每次运行结束后调用
+func Run(s *Suite, targets []Target, progress func(Result)) *Report {
+	report := &Report{Suite: s.Name}
+	for _, t := range targets {
+		sum := Summary{Target: t.Name, Agent: t.Agent, Model: t.Model}
+		var totalLatency int64
+		for i := range s.Cases {
+			for run := 1; run <= s.Runs; run++ {
+				r := runCase(t, &s.Cases[i], run)
+				report.Results = append(report.Results, r)
+				if progress != nil {
+					progress(r)
+				}
+
+				sum.Runs++
+				if r.Passed {
+					sum.Passed++
+				}
+				if r.Error != "" {
+					sum.Errors++
+				}
+				totalLatency += r.LatencyMs
+				if r.LatencyMs > sum.MaxLatencyMs {
+					sum.MaxLatencyMs = r.LatencyMs
+				}
+				sum.PromptTokens += r.PromptTokens
+				sum.CompletionTokens += r.CompletionTokens
+			}
+		}
+		if sum.Runs > 0 {
+			sum.PassRate = float64(sum.Passed) / float64(sum.Runs)
+			sum.AvgLatencyMs = totalLatency / int64(sum.Runs)
+		}
+		report.Summary = append(report.Summary, sum)
+	}
+	return report
+}
+
+func runCase(t Target, c *Case, run int) Result {
+	r := Result{Target: t.Name, Case: c.Name, Run: run}
+	start := time.Now()
+	resp, err := t.Ask(c.Prompt)
+	r.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+
+	r.Reply = strings.TrimSpace(resp.Content)
+	r.PromptTokens = resp.Usage.PromptTokens
+	r.CompletionTokens = resp.Usage.CompletionTokens
+	called := make(map[string]bool)
+	for _, tc := range resp.ToolCalls {
+		called[tc.Function.Name] = true
+		r.ToolCalls = append(r.ToolCalls, tc.Function.Name+" "+tc.Function.Arguments)
+	}
+	r.Failures = c.check(r.Reply, called, r.ToolCalls)
+	r.Passed = len(r.Failures) == 0
+	return r
+}
+
+// check 检查断言，返回未通过的原因。正则同时匹配回复和"工具名 参数JSON"形式的工具调用
+func (c *Case) check(reply string, called map[string]bool, calls []string) []string {
+	var failures []string
+	for _, name := range c.ExpectTools {
+		if !called[name] {
+			failures = append(failures, fmt.Sprintf("expected tool %s, got %s", name, toolNames(called)))
+		}
+	}
+	if c.NoTools && len(called) > 0 {
+		failures = append(failures, fmt.Sprintf("expected no tools, got %s", toolNames(called)))
+	}
+
+	text := strings.Join(append([]string{reply}, calls...), "\n")
+	for _, re := range c.expect {
+		if !re.MatchString(text) {
+			failures = append(failures, fmt.Sprintf("expected match for /%s/", re))
+		}
+	}
+	for _, re := range c.reject {
+		if m := re.FindString(text); m != "" {
+			failures = append(failures, fmt.Sprintf("unexpected match for /%s/: %q", re, m))
+		}
+	}
+	return failures
+}
+
+func toolNames(called map[string]bool) string {
+	if len(called) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(called))
+	for name := range called {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// FormatResult 单次运行结果的文本，未通过时附上原因
+func FormatResult(r Result) string {
+	mark := "✓"
+	if !r.Passed {
+		mark = "✗"
+	}
+	line := fmt.Sprintf("%s [%s] %s", mark, r.Target, r.Case)
+	if r.Run > 1 {
+		line += fmt.Sprintf(" #%d", r.Run)
+	}
+	line += fmt.Sprintf("  %s  %d+%d tokens", formatLatency(r.LatencyMs), r.PromptTokens, r.CompletionTokens)
+	if r.Error != "" {
+		return line + "\n    error: " + r.Error
+	}
+	for _, f := range r.Failures {
+		line += "\n    " + f
+	}
+	return line
+}
+
+// WriteSummary 以表格输出每个目标的通过率、延迟和 token 用量
+func (r *Report) WriteSummary(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TARGET\tAGENT\tMODEL\tPASSED\tRATE\tAVG LATENCY\tMAX LATENCY\tPROMPT TOKENS\tCOMPLETION TOKENS\tERRORS")
+	for _, s := range r.Summary {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d/%d\t%.0f%%\t%s\t%s\t%d\t%d\t%d\n",
+			s.Target, s.Agent, s.Model, s.Passed, s.Runs, s.PassRate*100,
+			formatLatency(s.AvgLatencyMs), formatLatency(s.MaxLatencyMs),
+			s.PromptTokens, s.CompletionTokens, s.Errors)
+	}
+	tw.Flush()
+}
+
+// Failed 是否有未通过的运行
+func (r *Report) Failed() bool {
+	for _, res := range r.Results {
+		if !res.Passed {
+			return true
+		}
+	}
+	return false
+}
+
+func formatLatency(ms int64) string {
+	return (time.Duration(ms) * time.Millisecond).Round(10 * time.Millisecond).String()
+}
@@ -0,0 +1,131 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Suite 评测套件：一组提示词及其断言，在一个或多个目标上运行
+type Suite struct {
+	Name    string       `json:"name"`
+	Runs    int          `json:"runs"`    // 每个用例在每个目标上运行的次数，默认1
+	Targets []TargetSpec `json:"targets"` // 为空时使用配置中的 llm 和默认智能体
+	Cases   []Case       `json:"cases"`
+}
+
+// TargetSpec 评测目标，未填写的字段使用配置中的 llm
+type TargetSpec struct {
+	Name     string `json:"name"`
+	Agent    string `json:"agent"`    // 使用该智能体的系统提示词，默认为默认智能体
+	Preset   string `json:"preset"`   // llmPresets 中的预设，提供 baseURL 和默认模型
+	Provider string `json:"provider"` // openai/anthropic/ollama，填写 preset 时默认为预设名
+	Model    string `json:"model"`
+	BaseURL  string `json:"baseURL"`
+	APIKey   string `json:"apiKey"` // 支持 ${ENV_VAR}
+}
+
+// Case 评测用例
+type Case struct {
+	Name        string   `json:"name"`
+	Prompt      string   `json:"prompt"`
+	ExpectTools []string `json:"expectTools"` // 必须请求的工具
+	NoTools     bool     `json:"noTools"`     // 不应请求任何工具
+	Expect      []string `json:"expect"`      // 回复和工具调用必须匹配的正则
+	Reject      []string `json:"reject"`      // 回复和工具调用不能匹配的正则
+
+	expect []*regexp.Regexp
+	reject []*regexp.Regexp
+}
+
+// LoadSuite 读取评测套件，.json 文件按 JSON 解析，其余按 YAML 解析
+func LoadSuite(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var s Suite
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		return &s, s.validate()
+	}
+
+	tree, err := parseYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	// YAML 树转为 JSON 后按结构体解码，字段名与 JSON 格式一致
+	raw, err := json.Marshal(tree)
+	if err != nil {
+		return nil, err
+	}
+	var s Suite
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("invalid suite %s: %w", path, err)
+	}
+	return &s, s.validate()
+}
+
+// validate 检查用例并编译正则，补全默认名称
+func (s *Suite) validate() error {
+	if len(s.Cases) == 0 {
+		return fmt.Errorf("suite has no cases")
+	}
+	if s.Runs <= 0 {
+		s.Runs = 1
+	}
+
+	names := make(map[string]bool)
+	for i := range s.Targets {
+		t := &s.Targets[i]
+		if t.Name == "" {
+			t.Name = t.Model
+		}
+		if t.Name == "" {
+			t.Name = fmt.Sprintf("target%d", i+1)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("duplicate target name %q", t.Name)
+		}
+		names[t.Name] = true
+		t.APIKey = os.ExpandEnv(t.APIKey)
+	}
+
+	for i := range s.Cases {
+		c := &s.Cases[i]
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("case%d", i+1)
+		}
+		if strings.TrimSpace(c.Prompt) == "" {
+			return fmt.Errorf("case %s has no prompt", c.Name)
+		}
+		if c.NoTools && len(c.ExpectTools) > 0 {
+			return fmt.Errorf("case %s: noTools conflicts with expectTools", c.Name)
+		}
+		var err error
+		if c.expect, err = compileAll(c.Expect); err != nil {
+			return fmt.Errorf("case %s: %w", c.Name, err)
+		}
+		if c.reject, err = compileAll(c.Reject); err != nil {
+			return fmt.Errorf("case %s: %w", c.Name, err)
+		}
+	}
+	return nil
+}
+
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	result := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+		result = append(result, re)
+	}
+	return result, nil
+}
@@ -0,0 +1,417 @@
+package eval
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlParser 解析评测套件用到的 YAML 子集：缩进的映射和列表、注释、
+// 单双引号字符串、[a, b] 和 {k: v} 行内写法、| 和 > 多行文本。不支持锚点、标签和多文档
+type yamlParser struct {
+	lines []string
+	pos   int
+}
+
+// parseYAML 把 YAML 解析为 map[string]interface{}、[]interface{} 和标量组成的树，数字为 float64
+func parseYAML(data []byte) (interface{}, error) {
+	p := &yamlParser{lines: strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")}
+	p.skip()
+	if p.eof() {
+		return nil, nil
+	}
+	if p.indent() != 0 {
+		return nil, p.errorf("unexpected indentation")
+	}
+	v, err := p.block(0)
+	if err != nil {
+		return nil, err
+	}
+	p.skip()
+	if !p.eof() {
+		return nil, p.errorf("unexpected indentation")
+	}
+	return v, nil
+}
+
+func (p *yamlParser) eof() bool {
+	return p.pos >= len(p.lines)
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("yaml line %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+// skip 跳过空行、注释和文档分隔符
+func (p *yamlParser) skip() {
+	for !p.eof() {
+		t := strings.TrimSpace(p.lines[p.pos])
+		if t != "" && !strings.HasPrefix(t, "#") && t != "---" {
+			return
+		}
+		p.pos++
+	}
+}
+
+func (p *yamlParser) indent() int {
+	line := p.lines[p.pos]
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func (p *yamlParser) text() string {
+	return strings.TrimSpace(p.lines[p.pos])
+}
+
+func isSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// block 解析从当前行开始、缩进为 indent 的映射或列表
+func (p *yamlParser) block(indent int) (interface{}, error) {
+	if strings.HasPrefix(strings.TrimLeft(p.lines[p.pos], " "), "\t") {
+		return nil, p.errorf("tabs are not allowed for indentation")
+	}
+	if isSeqItem(p.text()) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) sequence(indent int) (interface{}, error) {
+	list := []interface{}{}
+	for !p.eof() && p.indent() == indent && isSeqItem(p.text()) {
+		line := p.lines[p.pos]
+		after := line[indent+1:]
+		rest := stripComment(strings.TrimSpace(after))
+
+		var item interface{}
+		var err error
+		switch {
+		case rest == "":
+			p.pos++
+			item, err = p.nested(indent, false)
+		case isBlockIndicator(rest):
+			p.pos++
+			item, err = p.blockScalar(rest, indent)
+		case isMapEntry(rest):
+			// "- key: value" 开始的映射，后续的键与 key 对齐
+			itemIndent := indent + 1 + len(after) - len(strings.TrimLeft(after, " "))
+			p.lines[p.pos] = strings.Repeat(" ", itemIndent) + strings.TrimLeft(after, " ")
+			item, err = p.mapping(itemIndent)
+		default:
+			item, err = p.scalar(rest)
+			p.pos++
+		}
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+		p.skip()
+	}
+	if !p.eof() && p.indent() > indent {
+		return nil, p.errorf("unexpected indentation")
+	}
+	return list, nil
+}
+
+func (p *yamlParser) mapping(indent int) (interface{}, error) {
+	m := map[string]interface{}{}
+	for !p.eof() && p.indent() == indent && !isSeqItem(p.text()) {
+		key, rest, ok := splitMapEntry(p.text())
+		if !ok {
+			return nil, p.errorf("expected key: value, got %q", p.text())
+		}
+		if _, dup := m[key]; dup {
+			return nil, p.errorf("duplicate key %q", key)
+		}
+		rest = stripComment(rest)
+
+		var v interface{}
+		var err error
+		switch {
+		case rest == "":
+			p.pos++
+			v, err = p.nested(indent, true)
+		case isBlockIndicator(rest):
+			p.pos++
+			v, err = p.blockScalar(rest, indent)
+		default:
+			v, err = p.scalar(rest)
+			p.pos++
+		}
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+		p.skip()
+	}
+	if !p.eof() && p.indent() > indent {
+		return nil, p.errorf("unexpected indentation")
+	}
+	return m, nil
+}
+
+// nested 解析值为空的键或列表项之后缩进更深的块，映射的值也可以是同一缩进的列表
+func (p *yamlParser) nested(indent int, sameIndentList bool) (interface{}, error) {
+	p.skip()
+	if p.eof() {
+		return nil, nil
+	}
+	if n := p.indent(); n > indent || (sameIndentList && n == indent && isSeqItem(p.text())) {
+		return p.block(n)
+	}
+	return nil, nil
+}
+
+func isBlockIndicator(s string) bool {
+	switch s {
+	case "|", "|-", "|+", ">", ">-", ">+":
+		return true
+	}
+	return false
+}
+
+// blockScalar 读取 | 或 > 之后缩进比 parent 深的行
+func (p *yamlParser) blockScalar(indicator string, parent int) (string, error) {
+	var lines []string
+	blockIndent := -1
+	for !p.eof() {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line) == "" {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " "))
+		if n <= parent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = n
+		}
+		if n < blockIndent {
+			return "", p.errorf("inconsistent indentation in block text")
+		}
+		lines = append(lines, line[blockIndent:])
+		p.pos++
+	}
+
+	// 末尾的空行按 chomping 处理
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var text string
+	if indicator[0] == '|' {
+		text = strings.Join(lines, "\n")
+	} else {
+		var sb strings.Builder
+		for i, line := range lines {
+			// 空行变为换行，相邻的非空行以空格连接
+			switch {
+			case i == 0 || lines[i-1] == "":
+			case line == "":
+				sb.WriteString("\n")
+			default:
+				sb.WriteString(" ")
+			}
+			sb.WriteString(line)
+		}
+		text = sb.String()
+	}
+	switch {
+	case strings.HasSuffix(indicator, "-") || text == "":
+	case strings.HasSuffix(indicator, "+"):
+		text += strings.Repeat("\n", trailing+1)
+	default:
+		text += "\n"
+	}
+	return text, nil
+}
+
+// splitMapEntry 拆分 key: value，键可以带引号
+func splitMapEntry(text string) (string, string, bool) {
+	if strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'") {
+		end := closingQuote(text)
+		if end < 0 || !strings.HasPrefix(text[end+1:], ":") {
+			return "", "", false
+		}
+		key, err := unquote(text[:end+1])
+		if err != nil {
+			return "", "", false
+		}
+		return key, strings.TrimSpace(text[end+2:]), true
+	}
+	if strings.HasSuffix(text, ":") && !strings.Contains(text[:len(text)-1], ": ") {
+		return strings.TrimSpace(text[:len(text)-1]), "", true
+	}
+	i := strings.Index(text, ": ")
+	if i <= 0 {
+		return "", "", false
+	}
+	return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+2:]), true
+}
+
+func isMapEntry(text string) bool {
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+		return false
+	}
+	_, _, ok := splitMapEntry(text)
+	return ok
+}
+
+// closingQuote 返回开头引号对应的结束引号位置
+func closingQuote(s string) int {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case s[i] == q && q == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == q:
+			return i
+		}
+	}
+	return -1
+}
+
+func unquote(s string) (string, error) {
+	if s[0] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	return strconv.Unquote(s)
+}
+
+// stripComment 去掉引号外以 " #" 开始的注释，单词中的撇号（如 it's）不算引号
+func stripComment(s string) string {
+	var q byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case q != 0:
+			if c == '\\' && q == '"' {
+				i++
+			} else if c == q {
+				q = 0
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" [{,", s[i-1]) >= 0):
+			q = c
+		case c == '#' && (i == 0 || s[i-1] == ' '):
+			return strings.TrimSpace(s[:i])
+		}
+	}
+	return s
+}
+
+// scalar 解析单行的值
+func (p *yamlParser) scalar(s string) (interface{}, error) {
+	v, err := parseScalar(s)
+	if err != nil {
+		return nil, p.errorf("%v", err)
+	}
+	return v, nil
+}
+
+func parseScalar(s string) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+		return nil, nil
+	case s[0] == '"' || s[0] == '\'':
+		if end := closingQuote(s); end != len(s)-1 {
+			return nil, fmt.Errorf("invalid quoted string %s", s)
+		}
+		v, err := unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted string %s", s)
+		}
+		return v, nil
+	case s[0] == '[':
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("unterminated list %s", s)
+		}
+		list := []interface{}{}
+		for _, part := range splitFlow(s[1 : len(s)-1]) {
+			v, err := parseScalar(part)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case s[0] == '{':
+		if !strings.HasSuffix(s, "}") {
+			return nil, fmt.Errorf("unterminated map %s", s)
+		}
+		m := map[string]interface{}{}
+		for _, part := range splitFlow(s[1 : len(s)-1]) {
+			k, rest, ok := splitMapEntry(part)
+			if !ok {
+				return nil, fmt.Errorf("invalid map entry %s", part)
+			}
+			v, err := parseScalar(rest)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = v
+		}
+		return m, nil
+	}
+
+	switch s {
+	case "null", "~":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strings.IndexFunc(s, isLetter) < 0 {
+		return f, nil
+	}
+	return s, nil
+}
+
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z' && r != 'e') || (r >= 'A' && r <= 'Z' && r != 'E')
+}
+
+// splitFlow 按引号和括号外的逗号拆分行内列表或映射
+func splitFlow(s string) []string {
+	var parts []string
+	var q byte
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case q != 0:
+			if c == '\\' && q == '"' {
+				i++
+			} else if c == q {
+				q = 0
+			}
+		case c == '"' || c == '\'':
+			q = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" || len(parts) > 0 {
+		parts = append(parts, s[start:])
+	}
+	var result []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
@@ -0,0 +1,76 @@
+package eval
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	src := `# comment
+name: "home tasks"   # it's a comment
+runs: 2
+enabled: true
+empty:
+tags: [a, 'b c', "d,e"]
+opts: {x: 1, y: two}
+items:
+- plain
+- key: value
+  other: 'it''s'
+  nested:
+    - 1
+    - 2.5
+-
+  deep: yes
+text: |
+  line one
+    indented
+
+  line three
+folded: >-
+  joined
+  words
+
+  new paragraph
+url: http://example.com/#anchor
+note: it's fine # comment
+`
+	got, err := parseYAML([]byte(src))
+	if err != nil {
+		t.Fatalf("parseYAML() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"name":    "home tasks",
+		"runs":    float64(2),
+		"enabled": true,
+		"empty":   nil,
+		"tags":    []interface{}{"a", "b c", "d,e"},
+		"opts":    map[string]interface{}{"x": float64(1), "y": "two"},
+		"items": []interface{}{
+			"plain",
+			map[string]interface{}{"key": "value", "other": "it's", "nested": []interface{}{float64(1), 2.5}},
+			map[string]interface{}{"deep": "yes"},
+		},
+		"text":   "line one\n  indented\n\nline three\n",
+		"folded": "joined words\nnew paragraph",
+		"url":    "http://example.com/#anchor",
+		"note":   "it's fine",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseYAML() =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	for _, src := range []string{
+		"a: 1\n  b: 2\n",
+		"a: 1\na: 2\n",
+		"just text\n",
+		"a: [1, 2\n",
+		"a: \"unterminated\n",
+	} {
+		if _, err := parseYAML([]byte(src)); err == nil {
+			t.Errorf("parseYAML(%q) should fail", src)
+		}
+	}
+}
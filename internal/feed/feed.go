@@ -0,0 +1,283 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// maxFeedSize 订阅源最大读取字节数
+	maxFeedSize = 2 * 1024 * 1024
+	userAgent   = "Mujibot/1.0 (+https://github.com/HaohanHe/mujibot)"
+)
+
+// Item 订阅条目
+type Item struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Link      string    `json:"link"`
+	Summary   string    `json:"summary,omitempty"`
+	Published time.Time `json:"published,omitempty"`
+}
+
+// Feed 解析后的订阅源
+type Feed struct {
+	Title string `json:"title"`
+	Link  string `json:"link"`
+	Items []Item `json:"items"`
+}
+
+// rssDoc RSS 2.0 与 RSS 1.0 (RDF) 文档
+type rssDoc struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Links []rssLink `xml:"link"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	// RDF 的 item 与 channel 同级
+	Items []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string    `xml:"title"`
+	Links       []rssLink `xml:"link"`
+	GUID        string    `xml:"guid"`
+	Description string    `xml:"description"`
+	Content     string    `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate     string    `xml:"pubDate"`
+	Date        string    `xml:"http://purl.org/dc/elements/1.1/ date"`
+}
+
+// rssLink RSS 中的 link 可能混有 atom:link（仅有 href 属性）
+type rssLink struct {
+	Text string `xml:",chardata"`
+	Href string `xml:"href,attr"`
+}
+
+// rssHref 优先返回文本形式的链接
+func rssHref(links []rssLink) string {
+	for _, l := range links {
+		if text := strings.TrimSpace(l.Text); text != "" {
+			return text
+		}
+	}
+	for _, l := range links {
+		if l.Href != "" {
+			return strings.TrimSpace(l.Href)
+		}
+	}
+	return ""
+}
+
+type atomDoc struct {
+	Title   string      `xml:"title"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Summary   string     `xml:"summary"`
+	Content   string     `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+}
+
+// dateLayouts 订阅源中常见的日期格式
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+var tagRe = regexp.MustCompile(`<[^>]*>`)
+
+// Parse 解析 RSS 2.0、RSS 1.0 或 Atom 文档
+func Parse(data []byte) (*Feed, error) {
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch root {
+	case "rss", "RDF":
+		return parseRSS(data)
+	case "feed":
+		return parseAtom(data)
+	default:
+		return nil, fmt.Errorf("unsupported feed format: <%s>", root)
+	}
+}
+
+// rootElement 返回文档根元素名
+func rootElement(data []byte) (string, error) {
+	dec := newDecoder(data)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", fmt.Errorf("invalid feed: %w", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+func newDecoder(data []byte) *xml.Decoder {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	// 非 UTF-8 编码按原样读取，个别字符可能乱码但不影响解析
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return dec
+}
+
+func parseRSS(data []byte) (*Feed, error) {
+	var doc rssDoc
+	if err := newDecoder(data).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid rss: %w", err)
+	}
+
+	feed := &Feed{Title: clean(doc.Channel.Title), Link: rssHref(doc.Channel.Links)}
+	items := doc.Channel.Items
+	if len(items) == 0 {
+		items = doc.Items
+	}
+	for _, it := range items {
+		summary := it.Description
+		if summary == "" {
+			summary = it.Content
+		}
+		date := it.PubDate
+		if date == "" {
+			date = it.Date
+		}
+		feed.Items = append(feed.Items, newItem(it.GUID, it.Title, rssHref(it.Links), summary, date))
+	}
+	return feed, nil
+}
+
+func parseAtom(data []byte) (*Feed, error) {
+	var doc atomDoc
+	if err := newDecoder(data).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid atom: %w", err)
+	}
+
+	feed := &Feed{Title: clean(doc.Title), Link: atomHref(doc.Links)}
+	for _, e := range doc.Entries {
+		summary := e.Summary
+		if summary == "" {
+			summary = e.Content
+		}
+		date := e.Published
+		if date == "" {
+			date = e.Updated
+		}
+		feed.Items = append(feed.Items, newItem(e.ID, e.Title, atomHref(e.Links), summary, date))
+	}
+	return feed, nil
+}
+
+// atomHref 优先返回 rel=alternate 的链接
+func atomHref(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return strings.TrimSpace(l.Href)
+		}
+	}
+	if len(links) > 0 {
+		return strings.TrimSpace(links[0].Href)
+	}
+	return ""
+}
+
+func newItem(id, title, link, summary, date string) Item {
+	item := Item{
+		ID:      strings.TrimSpace(id),
+		Title:   clean(title),
+		Link:    link,
+		Summary: clean(summary),
+	}
+	if item.ID == "" {
+		item.ID = link
+	}
+	if item.ID == "" {
+		item.ID = item.Title
+	}
+	date = strings.TrimSpace(date)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			item.Published = t
+			break
+		}
+	}
+	return item
+}
+
+// clean 去除HTML标签并压缩空白
+func clean(s string) string {
+	s = tagRe.ReplaceAllString(s, " ")
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
+
+// Fetch 下载并解析订阅源
+func Fetch(ctx context.Context, client *http.Client, url string) (*Feed, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, */*;q=0.8")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feed returned status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+	return Parse(data)
+}
+
+// Matches 检查条目是否包含任一关键词（不区分大小写），无关键词时全部匹配
+func (it Item) Matches(keywords []string) bool {
+	if len(keywords) == 0 {
+		return true
+	}
+	text := strings.ToLower(it.Title + " " + it.Summary)
+	for _, kw := range keywords {
+		if strings.Contains(text, strings.ToLower(kw)) {
+			return true
+		}
+	}
+	return false
+}
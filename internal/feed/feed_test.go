@@ -0,0 +1,233 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testRSS = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+<channel>
+  <title>Example News</title>
+  <atom:link href="https://example.com/rss" rel="self"/>
+  <link>https://example.com/</link>
+  <item>
+    <title>Go 1.22 released</title>
+    <link>https://example.com/go-1.22</link>
+    <guid>go-122</guid>
+    <description><![CDATA[<p>The <b>Go</b> team &amp; friends.</p>]]></description>
+    <pubDate>Tue, 06 Feb 2024 10:00:00 +0000</pubDate>
+  </item>
+  <item>
+    <title>Weather update</title>
+    <link>https://example.com/weather</link>
+    <pubDate>Mon, 05 Feb 2024 08:00:00 GMT</pubDate>
+  </item>
+</channel>
+</rss>`
+
+const testAtom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Example Blog</title>
+  <link href="https://blog.example.com/feed" rel="self"/>
+  <link href="https://blog.example.com/"/>
+  <entry>
+    <id>tag:blog.example.com,2024:1</id>
+    <title type="html">First &lt;em&gt;post&lt;/em&gt;</title>
+    <link rel="alternate" href="https://blog.example.com/1"/>
+    <updated>2024-02-01T12:00:00Z</updated>
+    <summary>Hello world</summary>
+  </entry>
+</feed>`
+
+const testRDF = `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel><title>RDF Feed</title><link>https://rdf.example.com/</link></channel>
+  <item>
+    <title>RDF item</title>
+    <link>https://rdf.example.com/1</link>
+    <dc:date>2024-02-03T09:00:00Z</dc:date>
+  </item>
+</rdf:RDF>`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		title     string
+		link      string
+		items     int
+		first     Item
+		published time.Time
+	}{
+		{
+			name:      "rss",
+			data:      testRSS,
+			title:     "Example News",
+			link:      "https://example.com/",
+			items:     2,
+			first:     Item{ID: "go-122", Title: "Go 1.22 released", Link: "https://example.com/go-1.22", Summary: "The Go team & friends."},
+			published: time.Date(2024, 2, 6, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "atom",
+			data:      testAtom,
+			title:     "Example Blog",
+			link:      "https://blog.example.com/",
+			items:     1,
+			first:     Item{ID: "tag:blog.example.com,2024:1", Title: "First post", Link: "https://blog.example.com/1", Summary: "Hello world"},
+			published: time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "rdf",
+			data:      testRDF,
+			title:     "RDF Feed",
+			link:      "https://rdf.example.com/",
+			items:     1,
+			first:     Item{ID: "https://rdf.example.com/1", Title: "RDF item", Link: "https://rdf.example.com/1"},
+			published: time.Date(2024, 2, 3, 9, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if f.Title != tt.title || f.Link != tt.link {
+				t.Errorf("feed = %q %q, want %q %q", f.Title, f.Link, tt.title, tt.link)
+			}
+			if len(f.Items) != tt.items {
+				t.Fatalf("items = %d, want %d", len(f.Items), tt.items)
+			}
+			got := f.Items[0]
+			if !got.Published.Equal(tt.published) {
+				t.Errorf("published = %v, want %v", got.Published, tt.published)
+			}
+			got.Published = time.Time{}
+			if got != tt.first {
+				t.Errorf("item = %+v, want %+v", got, tt.first)
+			}
+		})
+	}
+
+	if _, err := Parse([]byte("<html><body>not a feed</body></html>")); err == nil {
+		t.Error("Parse should reject non-feed documents")
+	}
+}
+
+func TestStorePoll(t *testing.T) {
+	var mu sync.Mutex
+	body := testRSS
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "feeds.json")
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	initial, err := Parse([]byte(testRSS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := store.Add(Subscription{URL: server.URL, Channel: "telegram", UserID: "1", Target: "100", Keywords: []string{"golang", "GO"}}, initial.Items)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := store.Add(Subscription{URL: server.URL, Channel: "telegram", UserID: "1"}, nil); err == nil {
+		t.Error("duplicate subscription should fail")
+	}
+
+	// 订阅时已有条目不推送
+	_, items, err := store.Poll(context.Background(), server.Client(), sub.ID)
+	if err != nil || len(items) != 0 {
+		t.Fatalf("Poll() = %v, %v; want no items", items, err)
+	}
+
+	mu.Lock()
+	body = strings.Replace(testRSS, "<item>", `<item><title>Go generics tips</title><guid>go-tips</guid><pubDate>Wed, 07 Feb 2024 10:00:00 +0000</pubDate></item>
+  <item><title>Rust news</title><guid>rust</guid></item>
+  <item>`, 1)
+	mu.Unlock()
+
+	updated, items, err := store.Poll(context.Background(), server.Client(), sub.ID)
+	if err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+	if len(items) != 1 || items[0].ID != "go-tips" {
+		t.Errorf("new items = %+v, want only go-tips", items)
+	}
+	if updated.Target != "100" || updated.Title != "Example News" {
+		t.Errorf("subscription = %+v", updated)
+	}
+
+	// 重新加载后保留已读记录
+	reloaded, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("reload error = %v", err)
+	}
+	if _, items, _ := reloaded.Poll(context.Background(), server.Client(), sub.ID); len(items) != 0 {
+		t.Errorf("reloaded store returned seen items: %+v", items)
+	}
+	if subs := reloaded.List("telegram", "1"); len(subs) != 1 || subs[0].ID != sub.ID {
+		t.Errorf("List() = %+v", subs)
+	}
+
+	if err := reloaded.Remove("telegram", "2", sub.ID); err == nil {
+		t.Error("other users should not remove the subscription")
+	}
+	if err := reloaded.Remove("telegram", "1", sub.ID); err != nil {
+		t.Errorf("Remove() error = %v", err)
+	}
+	if next, _ := reloaded.Add(Subscription{URL: server.URL, Channel: "telegram", UserID: "1"}, nil); next == nil || next.ID <= sub.ID {
+		t.Errorf("IDs should not be reused after removal: %+v", next)
+	}
+}
+
+func TestStoreDeleteUser(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feeds.json")
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sub := range []Subscription{
+		{URL: "https://a.example/feed", Channel: "telegram", UserID: "1"},
+		{URL: "https://b.example/feed", Channel: "telegram", UserID: "1"},
+		{URL: "https://a.example/feed", Channel: "telegram", UserID: "2"},
+		{URL: "https://a.example/feed", Channel: "feishu", UserID: "1"},
+	} {
+		if _, err := store.Add(sub, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, err := store.DeleteUser("telegram", "1"); err != nil || n != 2 {
+		t.Fatalf("DeleteUser() = %d, %v; want 2", n, err)
+	}
+	reloaded, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subs := reloaded.List("telegram", "1"); len(subs) != 0 {
+		t.Errorf("subscriptions left after delete: %+v", subs)
+	}
+	if len(reloaded.All()) != 2 {
+		t.Errorf("other users' subscriptions = %+v", reloaded.All())
+	}
+	if n, err := reloaded.DeleteUser("telegram", "1"); err != nil || n != 0 {
+		t.Errorf("second DeleteUser() = %d, %v", n, err)
+	}
+}
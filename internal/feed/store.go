@@ -0,0 +1,279 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultFile = "./feeds.json"
+	// seenLimit 每个订阅记住的条目数，超出后丢弃最早的
+	seenLimit = 500
+)
+
+// Subscription 订阅
+type Subscription struct {
+	ID          int       `json:"id"`
+	URL         string    `json:"url"`
+	Title       string    `json:"title"`
+	Channel     string    `json:"channel"`
+	UserID      string    `json:"userId"`
+	Target      string    `json:"target"` // 推送目标，如 Telegram chat ID
+	Keywords    []string  `json:"keywords,omitempty"`
+	Created     time.Time `json:"created"`
+	LastChecked time.Time `json:"lastChecked,omitempty"`
+	LastError   string    `json:"lastError,omitempty"`
+	Seen        []string  `json:"seen,omitempty"`
+}
+
+// Store 持久化的订阅列表
+type Store struct {
+	path string
+
+	mu     sync.Mutex
+	subs   []*Subscription
+	nextID int
+}
+
+type storeFile struct {
+	NextID        int             `json:"nextId"`
+	Subscriptions []*Subscription `json:"subscriptions"`
+}
+
+// NewStore 加载订阅文件，文件不存在时创建空列表
+func NewStore(path string) (*Store, error) {
+	if path == "" {
+		path = DefaultFile
+	}
+	s := &Store{path: path, nextID: 1}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feeds: %w", err)
+	}
+
+	var f storeFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse feeds: %w", err)
+	}
+	s.subs = f.Subscriptions
+	s.nextID = f.NextID
+	for _, sub := range s.subs {
+		if sub.ID >= s.nextID {
+			s.nextID = sub.ID + 1
+		}
+	}
+	return s, nil
+}
+
+// Add 添加订阅，同一用户重复订阅同一地址时返回错误
+func (s *Store) Add(sub Subscription, items []Item) (*Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.subs {
+		if existing.Channel == sub.Channel && existing.UserID == sub.UserID && existing.URL == sub.URL {
+			return nil, fmt.Errorf("already subscribed: #%d", existing.ID)
+		}
+	}
+
+	// 订阅时已有的条目视为已读，只推送之后的新条目
+	sub.Seen = nil
+	for _, item := range items {
+		sub.Seen = appendSeen(sub.Seen, item.ID)
+	}
+	sub.ID = s.nextID
+	sub.Created = time.Now()
+	s.nextID++
+	s.subs = append(s.subs, &sub)
+
+	if err := s.save(); err != nil {
+		return nil, err
+	}
+	c := sub.copy()
+	return &c, nil
+}
+
+// Remove 删除用户的订阅
+func (s *Store) Remove(channel, userID string, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, sub := range s.subs {
+		if sub.ID == id && sub.Channel == channel && sub.UserID == userID {
+			s.subs = append(s.subs[:i], s.subs[i+1:]...)
+			return s.save()
+		}
+	}
+	return fmt.Errorf("subscription not found: #%d", id)
+}
+
+// DeleteUser 删除用户的全部订阅，返回删除的条数
+func (s *Store) DeleteUser(channel, userID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.subs[:0]
+	for _, sub := range s.subs {
+		if sub.Channel != channel || sub.UserID != userID {
+			kept = append(kept, sub)
+		}
+	}
+	n := len(s.subs) - len(kept)
+	s.subs = kept
+	if n == 0 {
+		return 0, nil
+	}
+	return n, s.save()
+}
+
+// SetKeywords 修改订阅的关键词
+func (s *Store) SetKeywords(channel, userID string, id int, keywords []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sub := range s.subs {
+		if sub.ID == id && sub.Channel == channel && sub.UserID == userID {
+			sub.Keywords = keywords
+			return s.save()
+		}
+	}
+	return fmt.Errorf("subscription not found: #%d", id)
+}
+
+// List 返回用户的订阅
+func (s *Store) List(channel, userID string) []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []Subscription
+	for _, sub := range s.subs {
+		if sub.Channel == channel && sub.UserID == userID {
+			result = append(result, sub.copy())
+		}
+	}
+	return result
+}
+
+// All 返回全部订阅
+func (s *Store) All() []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]Subscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		result = append(result, sub.copy())
+	}
+	return result
+}
+
+// Poll 拉取订阅源，返回匹配关键词的新条目（按发布时间从旧到新）
+func (s *Store) Poll(ctx context.Context, client *http.Client, id int) (*Subscription, []Item, error) {
+	s.mu.Lock()
+	sub := s.find(id)
+	if sub == nil {
+		s.mu.Unlock()
+		return nil, nil, fmt.Errorf("subscription not found: #%d", id)
+	}
+	url := sub.URL
+	s.mu.Unlock()
+
+	// 拉取期间不持有锁
+	feed, fetchErr := Fetch(ctx, client, url)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 拉取期间订阅可能已被删除
+	if sub = s.find(id); sub == nil {
+		return nil, nil, fmt.Errorf("subscription not found: #%d", id)
+	}
+	sub.LastChecked = time.Now()
+	if fetchErr != nil {
+		sub.LastError = fetchErr.Error()
+		s.save()
+		return nil, nil, fetchErr
+	}
+	sub.LastError = ""
+	if sub.Title == "" {
+		sub.Title = feed.Title
+	}
+
+	seen := make(map[string]bool, len(sub.Seen))
+	for _, id := range sub.Seen {
+		seen[id] = true
+	}
+
+	var fresh []Item
+	for _, item := range feed.Items {
+		if seen[item.ID] {
+			continue
+		}
+		sub.Seen = appendSeen(sub.Seen, item.ID)
+		if item.Matches(sub.Keywords) {
+			fresh = append(fresh, item)
+		}
+	}
+	sort.SliceStable(fresh, func(i, j int) bool {
+		return fresh[i].Published.Before(fresh[j].Published)
+	})
+
+	if err := s.save(); err != nil {
+		return nil, nil, err
+	}
+	c := sub.copy()
+	return &c, fresh, nil
+}
+
+func (s *Store) find(id int) *Subscription {
+	for _, sub := range s.subs {
+		if sub.ID == id {
+			return sub
+		}
+	}
+	return nil
+}
+
+// save 写入临时文件后重命名，避免写入中断损坏订阅文件
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(storeFile{NextID: s.nextID, Subscriptions: s.subs}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create feeds directory: %w", err)
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write feeds: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// copy 返回不含已读记录的副本
+func (sub *Subscription) copy() Subscription {
+	c := *sub
+	c.Keywords = append([]string(nil), sub.Keywords...)
+	c.Seen = nil
+	return c
+}
+
+func appendSeen(seen []string, id string) []string {
+	seen = append(seen, id)
+	if len(seen) > seenLimit {
+		seen = seen[len(seen)-seenLimit:]
+	}
+	return seen
+}
@@ -0,0 +1,185 @@
+package gateway
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/i18n"
+)
+
+// maxListedSessions /sessions 最多列出的会话数
+const maxListedSessions = 20
+
+// restartDelay /restart 回复发出后再重启，避免回复丢失
+const restartDelay = 2 * time.Second
+
+// adminCommands 仅管理员可用的运维命令
+var adminCommands = map[string]bool{
+	"/status":   true,
+	"/sessions": true,
+	"/restart":  true,
+	"/tools":    true,
+	"/pending":  true,
+}
+
+// adminCommand 处理运维命令: /status /sessions /restart /tools /pending，非管理员返回提示
+func (g *Gateway) adminCommand(channel, userID, name string, args []string) (string, error) {
+	cfg := g.config.Get()
+	if !cfg.IsAdmin(channel, userID) {
+		return g.i18nFor(channel, userID).T("adminOnly"), nil
+	}
+
+	switch name {
+	case "/status":
+		return g.statusCommand(), nil
+	case "/sessions":
+		return g.sessionsCommand(g.i18nFor(channel, userID)), nil
+	case "/restart":
+		g.log.Warn("restart requested", "by", channel+":"+userID)
+		go func() {
+			time.Sleep(restartDelay)
+			g.restart("requested by " + channel + ":" + userID)
+		}()
+		return g.i18nFor(channel, userID).T("restartRequested"), nil
+	case "/tools":
+		return g.toolsCommand(channel, userID, args)
+	case "/pending":
+		return g.pendingCommand(g.i18nFor(channel, userID)), nil
+	}
+	return "", nil
+}
+
+// statusCommand 健康状况摘要
+func (g *Gateway) statusCommand() string {
+	st := g.healthCheck.GetStatus()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Status: %s, up %s\n", st.Status, st.Uptime)
+	fmt.Fprintf(&sb, "Memory: %s heap, %s sys, %d goroutines\n", formatMB(st.Memory.HeapAlloc), formatMB(st.Memory.Sys), st.Goroutines)
+	if st.CPU.Cores > 0 {
+		fmt.Fprintf(&sb, "CPU: %.1f%% of %d cores", st.CPU.UsagePercent, st.CPU.Cores)
+		if len(st.CPU.Load) > 0 {
+			fmt.Fprintf(&sb, ", load %.2f", st.CPU.Load[0])
+		}
+		if st.CPU.TemperatureC > 0 {
+			fmt.Fprintf(&sb, ", %.1f°C", st.CPU.TemperatureC)
+		}
+		sb.WriteString("\n")
+	}
+	for _, d := range st.Disk {
+		fmt.Fprintf(&sb, "Disk %s: %.1f%% used, %d MB free\n", d.Name, d.UsedPercent, d.FreeMB())
+	}
+	fmt.Fprintf(&sb, "Messages: %d total, %d in the last hour\n", st.Messages.Total, st.Messages.PerHour)
+	fmt.Fprintf(&sb, "LLM: %d ok, %d failed (%.1f%% success)\n", st.LLM.Success, st.LLM.Failed, st.LLM.Rate)
+	fmt.Fprintf(&sb, "Sessions: %d", len(g.sessionMgr.List()))
+	if g.confirmMgr != nil {
+		fmt.Fprintf(&sb, "\nPending confirmations: %d", len(g.confirmMgr.GetPending()))
+	}
+	if g.outbox != nil {
+		if n := len(g.outbox.Pending()); n > 0 {
+			fmt.Fprintf(&sb, "\nOutbox: %d messages waiting for retry", n)
+		}
+	}
+	if g.memoryGuard != nil && g.memoryGuard.IsEmergencyMode() {
+		sb.WriteString("\nMemory guard: emergency mode")
+	}
+	return sb.String()
+}
+
+// sessionsCommand 列出最近活跃的会话
+func (g *Gateway) sessionsCommand(t *i18n.I18n) string {
+	list := g.sessionMgr.List()
+	if len(list) == 0 {
+		return t.T("sessionsNone")
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Sessions: %d", len(list))
+	for i, s := range list {
+		if i == maxListedSessions {
+			fmt.Fprintf(&sb, "\n... and %d more", len(list)-i)
+			break
+		}
+		fmt.Fprintf(&sb, "\n%s:%s [%s] %d messages, active %s ago",
+			s.Channel, s.UserID, s.AgentID, s.Messages, time.Since(s.LastActivity).Round(time.Second))
+	}
+	return sb.String()
+}
+
+const toolsUsage = "Usage: /tools [list] | /tools on|off <name>"
+
+// toolsCommand 查看或开关工具: /tools [list] | /tools on|off <name>，开关会写回配置文件
+func (g *Gateway) toolsCommand(channel, userID string, args []string) (string, error) {
+	if len(args) == 0 || strings.ToLower(args[0]) == "list" {
+		var enabled, disabled []string
+		for _, tool := range g.toolMgr.GetAll() {
+			enabled = append(enabled, tool.Name())
+		}
+		for _, tool := range g.toolMgr.Disabled() {
+			disabled = append(disabled, tool.Name())
+		}
+		sort.Strings(enabled)
+		text := fmt.Sprintf("Enabled (%d): %s", len(enabled), strings.Join(enabled, ", "))
+		if len(disabled) > 0 {
+			text += fmt.Sprintf("\nDisabled (%d): %s", len(disabled), strings.Join(disabled, ", "))
+		}
+		return text, nil
+	}
+
+	if len(args) != 2 {
+		return toolsUsage, nil
+	}
+	var on bool
+	switch strings.ToLower(args[0]) {
+	case "on":
+		on = true
+	case "off":
+	default:
+		return toolsUsage, nil
+	}
+
+	name := args[1]
+	if err := g.toolMgr.SetEnabled(name, on); err != nil {
+		return err.Error(), nil
+	}
+	g.config.SetToolEnabled(name, on)
+	g.log.Info("tool toggled from chat", "name", name, "enabled", on, "by", channel+":"+userID)
+
+	t := g.i18nFor(channel, userID)
+	if on {
+		return t.Tf("toolEnabled", i18n.Params{"name": name}), nil
+	}
+	return t.Tf("toolDisabled", i18n.Params{"name": name}), nil
+}
+
+// pendingCommand 列出等待确认的危险操作
+func (g *Gateway) pendingCommand(t *i18n.I18n) string {
+	if g.confirmMgr == nil {
+		return t.T("confirmationsDisabled")
+	}
+	pending := g.confirmMgr.GetPending()
+	if len(pending) == 0 {
+		return t.T("pendingNone")
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].CreatedAt.Before(pending[j].CreatedAt) })
+
+	var sb strings.Builder
+	sb.WriteString(t.Tn("pendingCount", len(pending), nil))
+	for _, req := range pending {
+		fmt.Fprintf(&sb, "\n%s (%s risk) %s: %s", req.ID, req.RiskLevel, req.Type, req.Operation)
+		if req.UserID != "" {
+			fmt.Fprintf(&sb, "\n  from %s:%s, expires in %s", req.Channel, req.UserID, time.Until(req.ExpiresAt).Round(time.Second))
+		} else {
+			fmt.Fprintf(&sb, "\n  expires in %s", time.Until(req.ExpiresAt).Round(time.Second))
+		}
+	}
+	sb.WriteString("\n\nReply /approve <id> or /reject <id>.")
+	return sb.String()
+}
+
+// formatMB 以MB显示字节数
+func formatMB(bytes uint64) string {
+	return fmt.Sprintf("%.1f MB", float64(bytes)/1024/1024)
+}
@@ -0,0 +1,55 @@
+package gateway
+
+import (
+	"fmt"
+
+	"github.com/HaohanHe/mujibot/internal/analytics"
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/encryption"
+	"github.com/HaohanHe/mujibot/internal/llm"
+)
+
+// initAnalytics 开启 analytics 时创建统计存储和意图标注器，标注使用 llm.summaryModel
+func (g *Gateway) initAnalytics(cfg *config.Config, cipher *encryption.Cipher, main llm.Provider) error {
+	if !cfg.Analytics.Enabled {
+		return nil
+	}
+	store, err := analytics.NewStore(analytics.Config{
+		File:          cfg.Analytics.File,
+		RetentionDays: cfg.Analytics.RetentionDays,
+		Cipher:        cipher,
+	})
+	if err != nil {
+		return startupError(ComponentStorage, fmt.Errorf("failed to create analytics store: %w", err))
+	}
+	provider, err := g.summaryProvider(cfg, main)
+	if err != nil {
+		return startupError(ComponentLLM, err)
+	}
+	g.analytics = store
+	g.intents = analytics.NewTagger(provider, cfg.Analytics.Categories)
+	return nil
+}
+
+// tagIntent 在后台标注消息意图并计数，不影响回复
+func (g *Gateway) tagIntent(channel, userID, agentID, content string) {
+	if g.analytics == nil {
+		return
+	}
+	ctx, finish := g.startJob(channel + ":" + userID)
+	go func() {
+		defer finish()
+		intent, err := g.intents.Tag(content)
+		if err != nil {
+			g.log.Warn("failed to tag message intent", "channel", channel, "user_id", userID, "error", err)
+			return
+		}
+		// 标注期间用户数据已被清除时不再记录
+		if ctx.Err() != nil {
+			return
+		}
+		if err := g.analytics.Record(analytics.Entry{Channel: channel, UserID: userID, Agent: agentID, Intent: intent}); err != nil {
+			g.log.Warn("failed to save analytics", "error", err)
+		}
+	}()
+}
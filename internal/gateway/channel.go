@@ -0,0 +1,38 @@
+package gateway
+
+import "fmt"
+
+// MessageHandler 把一条收到的消息交给网关处理并返回回复，target 为主动推送和通知的目标
+type MessageHandler func(userID, username, content, target string) (string, error)
+
+// ConnectChannel 接入内置渠道之外的渠道（如测试中的 testkit.Channel）：send 用于定时任务结果、
+// 通知等主动推送，返回的处理函数与内置渠道收到消息时的处理相同，包括聊天命令和智能体路由。
+// 须在 NewGateway 之后、处理消息前调用，不需要 Start
+func (g *Gateway) ConnectChannel(name string, send func(target, text string) error) (MessageHandler, error) {
+	switch name {
+	case "telegram", "discord", "feishu":
+		return nil, fmt.Errorf("channel %s is built in", name)
+	}
+
+	g.channelsMu.Lock()
+	defer g.channelsMu.Unlock()
+	if g.channels == nil {
+		g.channels = make(map[string]func(target, text string) error)
+	}
+	if _, ok := g.channels[name]; ok {
+		return nil, fmt.Errorf("channel %s already connected", name)
+	}
+	g.channels[name] = send
+
+	return func(userID, username, content, target string) (string, error) {
+		return g.handleMessage(name, userID, username, content, "", target, nil)
+	}, nil
+}
+
+// connectedChannel 返回通过 ConnectChannel 接入的渠道的发送函数
+func (g *Gateway) connectedChannel(name string) (func(target, text string) error, bool) {
+	g.channelsMu.RLock()
+	defer g.channelsMu.RUnlock()
+	send, ok := g.channels[name]
+	return send, ok
+}
@@ -0,0 +1,49 @@
+package gateway
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/cluster"
+)
+
+// claimTTL 消息抢占记录的保留时间
+const claimTTL = 24 * time.Hour
+
+// initCluster 启用多实例协调时连接共享存储
+func (g *Gateway) initCluster() error {
+	cfg := g.config.Get().Cluster
+	if !cfg.Enabled {
+		return nil
+	}
+
+	store, err := cluster.New(cluster.Config{
+		Backend:  cfg.Backend,
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+		Prefix:   cfg.Prefix,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect cluster store: %w", err)
+	}
+	g.clusterStore = store
+	g.log.Info("cluster mode enabled", "backend", cfg.Backend, "addr", cfg.Addr)
+	return nil
+}
+
+// claim 在共享存储中抢占一次性工作（如处理某条渠道更新），未启用多实例或存储出错时照常处理
+func (g *Gateway) claim(key string) bool {
+	if g.clusterStore == nil {
+		return true
+	}
+	ok, err := g.clusterStore.Claim(key, claimTTL)
+	if err != nil {
+		g.log.Warn("failed to claim, handling anyway", "key", key, "error", err)
+		return true
+	}
+	if !ok {
+		g.log.Debug("already handled by another instance", "key", key)
+	}
+	return ok
+}
@@ -0,0 +1,449 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/feed"
+	"github.com/HaohanHe/mujibot/internal/httpclient"
+	"github.com/HaohanHe/mujibot/internal/i18n"
+	"github.com/HaohanHe/mujibot/internal/memory"
+	"github.com/HaohanHe/mujibot/internal/tools"
+)
+
+// defaultMaxFeeds 每个用户默认最多订阅数
+const defaultMaxFeeds = 20
+
+// fileSender 通过渠道发送文件附件，渠道不支持时为nil
+type fileSender func(filename string, data []byte, caption string) error
+
+// handleCommand 处理聊天命令，返回是否已处理
+func (g *Gateway) handleCommand(channel, userID, target, content string, sendFile fileSender) (string, bool, error) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", false, nil
+	}
+
+	name := strings.ToLower(fields[0])
+	if adminCommands[name] {
+		resp, err := g.adminCommand(channel, userID, name, fields[1:])
+		return resp, true, err
+	}
+
+	switch name {
+	case "/export":
+		resp, err := g.exportCommand(channel, userID, fields[1:], sendFile)
+		return resp, true, err
+	case "/loglevel":
+		resp, err := g.logLevelCommand(channel, userID, fields[1:])
+		return resp, true, err
+	case "/feed":
+		resp, err := g.feedCommand(channel, userID, target, fields[1:])
+		return resp, true, err
+	case "/safemode":
+		return g.safeModeCommand(channel, userID, fields[1:]), true, nil
+	case "/pin":
+		text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(content), fields[0]))
+		return g.pinCommand(channel, userID, text), true, nil
+	case "/unpin":
+		return g.unpinCommand(channel, userID, fields[1:]), true, nil
+	case "/save", "/load":
+		text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(content), fields[0]))
+		if name == "/save" {
+			resp, err := g.saveCommand(channel, userID, text)
+			return resp, true, err
+		}
+		resp, err := g.loadCommand(channel, userID, text)
+		return resp, true, err
+	case "/unsave":
+		text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(content), fields[0]))
+		return g.unsaveCommand(channel, userID, text), true, nil
+	case "/profile":
+		return g.profileCommand(channel, userID, fields[1:]), true, nil
+	case "/lang":
+		return g.langCommand(channel, userID, fields[1:]), true, nil
+	case "/forgetme":
+		resp, err := g.forgetMeCommand(channel, userID, fields[1:])
+		return resp, true, err
+	case "/approve", "/reject":
+		resp, err := g.confirmCommand(channel, userID, name == "/approve", fields[1:])
+		return resp, true, err
+	default:
+		return "", false, nil
+	}
+}
+
+// exportCommand 导出当前会话: /export [md|json]
+func (g *Gateway) exportCommand(channel, userID string, args []string, sendFile fileSender) (string, error) {
+	t := g.i18nFor(channel, userID)
+
+	agent, err := g.agentRouter.Route(userID, channel, "")
+	if err != nil {
+		return "", err
+	}
+
+	sess := g.sessionMgr.Get(userID, channel, agent.ID)
+	if sess == nil || len(g.sessionMgr.GetMessages(sess)) == 0 {
+		return t.T("exportEmpty"), nil
+	}
+
+	format := "md"
+	if len(args) > 0 {
+		format = strings.ToLower(args[0])
+	}
+
+	var data []byte
+	var ext string
+	switch format {
+	case "json":
+		data, err = g.sessionMgr.ExportJSON(sess)
+		if err != nil {
+			return "", err
+		}
+		ext = "json"
+	case "md", "markdown":
+		data = []byte(g.sessionMgr.ExportMarkdown(sess))
+		ext = "md"
+	default:
+		return t.T("exportUsage"), nil
+	}
+
+	if sendFile != nil {
+		filename := fmt.Sprintf("mujibot-%s-%s.%s", agent.ID, time.Now().Format("20060102-150405"), ext)
+		err := sendFile(filename, data, t.T("exportSent"))
+		if err == nil {
+			return "", nil
+		}
+		g.log.Warn("failed to send export file, falling back to text", "channel", channel, "error", err)
+	}
+
+	return string(data), nil
+}
+
+const logLevelUsage = "Usage: /loglevel [module] [debug|info|warn|error|default] [persist]"
+
+// logLevelCommand 查看或调整日志级别（仅管理员），末尾加 persist 时写回配置文件
+func (g *Gateway) logLevelCommand(channel, userID string, args []string) (string, error) {
+	cfg := g.config.Get()
+	if !cfg.IsAdmin(channel, userID) {
+		return g.i18nFor(channel, userID).T("adminOnly"), nil
+	}
+
+	persist := false
+	if n := len(args); n > 0 && strings.ToLower(args[n-1]) == "persist" {
+		persist = true
+		args = args[:n-1]
+	}
+
+	var err error
+	switch len(args) {
+	case 0:
+	case 1:
+		err = g.log.ApplyLevels(strings.ToLower(args[0]), nil)
+	case 2:
+		err = g.log.ApplyLevels("", map[string]string{args[0]: strings.ToLower(args[1])})
+	default:
+		return logLevelUsage, nil
+	}
+	if err != nil {
+		return err.Error() + "\n" + logLevelUsage, nil
+	}
+
+	level, modules := g.log.Levels()
+	if len(args) > 0 {
+		g.log.Info("log level changed", "level", level, "modules", modules, "by", channel+":"+userID, "persist", persist)
+		if persist {
+			g.config.UpdateLogging(level, modules)
+		}
+	}
+
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Log level: %s", level)
+	for _, name := range names {
+		fmt.Fprintf(&sb, "\n  %s: %s", name, modules[name])
+	}
+	return sb.String(), nil
+}
+
+// pinCommand 置顶一条事实或指令: /pin <text>，不带内容时列出当前置顶
+func (g *Gateway) pinCommand(channel, userID, text string) string {
+	owner := channel + ":" + userID
+	t := g.i18nFor(channel, userID)
+	if text == "" {
+		pins := g.memoryMgr.Pins(owner)
+		if len(pins) == 0 {
+			return t.T("pinNone")
+		}
+		return memory.FormatPins(pins) + "\n" + t.T("pinListHint")
+	}
+
+	n, err := g.memoryMgr.Pin(owner, text)
+	if err != nil {
+		return err.Error()
+	}
+	return t.Tf("pinned", i18n.Params{"n": n})
+}
+
+// unpinCommand 删除置顶: /unpin [n]，不带编号时全部清除
+func (g *Gateway) unpinCommand(channel, userID string, args []string) string {
+	index := 0
+	if len(args) > 0 {
+		n, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+		if err != nil || n < 1 {
+			return "Usage: /unpin [n]"
+		}
+		index = n
+	}
+
+	if err := g.memoryMgr.Unpin(channel+":"+userID, index); err != nil {
+		return err.Error()
+	}
+	t := g.i18nFor(channel, userID)
+	if index == 0 {
+		return t.T("pinsCleared")
+	}
+	return t.Tf("pinRemoved", i18n.Params{"n": index})
+}
+
+var profileUsage = "Usage: /profile | /profile set <field> <value> | /profile clear <field> | /profile reset\nFields: " +
+	strings.Join(memory.ProfileFields, ", ")
+
+// profileCommand 查看或修改用户资料: /profile [set|clear|reset]
+func (g *Gateway) profileCommand(channel, userID string, args []string) string {
+	owner := channel + ":" + userID
+
+	action := "show"
+	if len(args) > 0 {
+		action = strings.ToLower(args[0])
+	}
+
+	var err error
+	switch action {
+	case "show":
+	case "set":
+		if len(args) < 3 {
+			return profileUsage
+		}
+		_, err = g.memoryMgr.UpdateProfile(owner, func(p *memory.Profile) error {
+			return p.Set(args[1], strings.Join(args[2:], " "))
+		})
+	case "clear":
+		if len(args) < 2 {
+			return profileUsage
+		}
+		_, err = g.memoryMgr.UpdateProfile(owner, func(p *memory.Profile) error {
+			return p.Set(args[1], "")
+		})
+	case "reset":
+		if err := g.memoryMgr.DeleteProfile(owner); err != nil {
+			return err.Error()
+		}
+		return "Profile reset."
+	default:
+		return profileUsage
+	}
+	if err != nil {
+		return err.Error()
+	}
+
+	p := g.memoryMgr.Profile(owner)
+	if p.IsEmpty() {
+		return g.i18nFor(channel, userID).T("profileNone")
+	}
+	return p.Format()
+}
+
+// i18nFor 返回用户的命令回复语言：用户资料中设置的语言优先，否则使用全局语言
+func (g *Gateway) i18nFor(channel, userID string) *i18n.I18n {
+	if g.i18n != nil && g.memoryMgr != nil && g.memoryMgr.IsEnabled() {
+		if lang := g.i18n.Match(g.memoryMgr.Profile(channel + ":" + userID).Locale); lang != "" {
+			return i18n.New(lang)
+		}
+	}
+	return i18n.New(g.config.Get().Language.Current)
+}
+
+// langCommand 设置回复语言: /lang [code|auto]，不带参数时显示当前设置
+func (g *Gateway) langCommand(channel, userID string, args []string) string {
+	owner := channel + ":" + userID
+	t := g.i18nFor(channel, userID)
+	usage := t.Tf("langUsage", i18n.Params{"languages": strings.Join(i18n.SupportedLanguages(), "|")})
+
+	if len(args) > 0 {
+		locale := ""
+		if !strings.EqualFold(args[0], "auto") {
+			if locale = g.i18n.Match(args[0]); locale == "" {
+				return usage
+			}
+		}
+		if _, err := g.memoryMgr.UpdateProfile(owner, func(p *memory.Profile) error {
+			p.Locale = locale
+			return nil
+		}); err != nil {
+			return err.Error()
+		}
+	}
+
+	if lang := g.i18n.Match(g.memoryMgr.Profile(owner).Locale); lang != "" {
+		return i18n.New(lang).Tf("langCurrent", i18n.Params{"name": i18n.LanguageName(lang), "lang": lang})
+	}
+	cfg := g.config.Get()
+	if cfg.Language.AutoDetect {
+		return t.Tf("langAuto", i18n.Params{"lang": cfg.Language.Current, "usage": usage})
+	}
+	return t.Tf("langDefault", i18n.Params{"lang": cfg.Language.Current, "usage": usage})
+}
+
+// safeModeCommand 开关安全模式: /safemode [on|off]，不带参数时显示当前状态
+func (g *Gateway) safeModeCommand(channel, userID string, args []string) string {
+	t := g.i18nFor(channel, userID)
+
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "on":
+			g.toolMgr.SetSafeMode(channel, userID, true)
+		case "off":
+			g.toolMgr.SetSafeMode(channel, userID, false)
+		default:
+			return t.T("safeModeUsage")
+		}
+		g.log.Info("safe mode changed", "on", g.toolMgr.SafeMode(channel, userID), "by", channel+":"+userID)
+	}
+
+	if g.toolMgr.SafeMode(channel, userID) {
+		return t.T("safeModeOn")
+	}
+	return t.T("safeModeOff")
+}
+
+// feedCommand 管理订阅: /feed add|remove|keywords|list
+func (g *Gateway) feedCommand(channel, userID, target string, args []string) (string, error) {
+	cfg := g.config.Get()
+	t := g.i18nFor(channel, userID)
+	if !cfg.Feeds.Enabled || g.feeds == nil {
+		return t.T("feedsDisabled"), nil
+	}
+
+	action := "list"
+	if len(args) > 0 {
+		action = strings.ToLower(args[0])
+		args = args[1:]
+	}
+
+	switch action {
+	case "list":
+		subs := g.feeds.List(channel, userID)
+		if len(subs) == 0 {
+			return t.T("feedNone"), nil
+		}
+		var sb strings.Builder
+		for i, sub := range subs {
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			title := sub.Title
+			if title == "" {
+				title = sub.URL
+			}
+			fmt.Fprintf(&sb, "#%d %s\n  %s", sub.ID, title, sub.URL)
+			if len(sub.Keywords) > 0 {
+				sb.WriteString("\n  " + t.Tf("feedListKeywords", i18n.Params{"keywords": strings.Join(sub.Keywords, ", ")}))
+			}
+			if sub.LastError != "" {
+				sb.WriteString("\n  " + t.Tf("feedListError", i18n.Params{"error": sub.LastError}))
+			}
+		}
+		return sb.String(), nil
+
+	case "add":
+		if len(args) == 0 {
+			return t.T("feedUsage"), nil
+		}
+		limit := cfg.Feeds.MaxPerUser
+		if limit <= 0 {
+			limit = defaultMaxFeeds
+		}
+		if len(g.feeds.List(channel, userID)) >= limit {
+			return t.Tf("feedLimit", i18n.Params{"limit": limit}), nil
+		}
+
+		url := args[0]
+		if _, err := tools.CheckURL(url); err != nil {
+			return err.Error(), nil
+		}
+		ctx, cancel := context.WithTimeout(g.ctx, 20*time.Second)
+		defer cancel()
+		f, err := feed.Fetch(ctx, httpclient.New(0), url)
+		if err != nil {
+			return t.Tf("feedFetchFailed", i18n.Params{"error": err.Error()}), nil
+		}
+
+		sub, err := g.feeds.Add(feed.Subscription{
+			URL:      url,
+			Title:    f.Title,
+			Channel:  channel,
+			UserID:   userID,
+			Target:   target,
+			Keywords: args[1:],
+		}, f.Items)
+		if err != nil {
+			return err.Error(), nil
+		}
+		g.log.Info("feed subscribed", "id", sub.ID, "url", url, "by", channel+":"+userID)
+
+		if len(sub.Keywords) > 0 {
+			return t.Tf("feedSubscribedKeywords", i18n.Params{"title": sub.Title, "id": sub.ID, "keywords": strings.Join(sub.Keywords, ", ")}), nil
+		}
+		return t.Tf("feedSubscribed", i18n.Params{"title": sub.Title, "id": sub.ID}), nil
+
+	case "remove", "rm":
+		id, ok := feedID(args)
+		if !ok {
+			return t.T("feedUsage"), nil
+		}
+		if err := g.feeds.Remove(channel, userID, id); err != nil {
+			return err.Error(), nil
+		}
+		return t.Tf("feedUnsubscribed", i18n.Params{"id": id}), nil
+
+	case "keywords":
+		id, ok := feedID(args)
+		if !ok {
+			return t.T("feedUsage"), nil
+		}
+		if err := g.feeds.SetKeywords(channel, userID, id, args[1:]); err != nil {
+			return err.Error(), nil
+		}
+		if len(args) == 1 {
+			return t.Tf("feedKeywordsCleared", i18n.Params{"id": id}), nil
+		}
+		return t.Tf("feedKeywordsSet", i18n.Params{"id": id, "keywords": strings.Join(args[1:], ", ")}), nil
+
+	default:
+		return t.T("feedUsage"), nil
+	}
+}
+
+// feedID 解析订阅编号，允许 #3 或 3
+func feedID(args []string) (int, bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	return id, err == nil
+}
+
+// isAdmin 按当前配置判断用户是否为管理员，配置热更新后立即生效
+func (g *Gateway) isAdmin(channel, userID string) bool {
+	return g.config.Get().IsAdmin(channel, userID)
+}
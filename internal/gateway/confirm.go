@@ -0,0 +1,133 @@
+package gateway
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/confirmation"
+	"github.com/HaohanHe/mujibot/internal/i18n"
+	"github.com/HaohanHe/mujibot/internal/notify"
+	"github.com/HaohanHe/mujibot/internal/policy"
+	"github.com/HaohanHe/mujibot/internal/webhook"
+)
+
+// chatNotifier 把危险操作的确认请求发回发起操作的聊天，没有来源时发到告警渠道，通知路由规则可以改发到其他渠道
+type chatNotifier struct {
+	g *Gateway
+}
+
+func (n *chatNotifier) Name() string {
+	return "chat"
+}
+
+func (n *chatNotifier) SendConfirmation(req *confirmation.ConfirmationRequest) error {
+	n.g.emitWebhook(webhook.EventConfirmationPending, map[string]interface{}{
+		"id":        req.ID,
+		"type":      req.Type,
+		"operation": req.Operation,
+		"details":   req.Details,
+		"riskLevel": req.RiskLevel,
+		"channel":   req.Channel,
+		"userId":    req.UserID,
+		"expiresAt": req.ExpiresAt,
+	})
+	t := n.g.i18nFor(req.Channel, req.UserID)
+	text := t.Tf("confirmRequired", i18n.Params{
+		"risk":      riskLabel(t, req.RiskLevel),
+		"type":      req.Type,
+		"operation": req.Operation,
+		"details":   req.Details + formatEffects(t, req.Effects),
+		"id":        req.ID,
+		"timeout":   time.Until(req.ExpiresAt).Round(time.Minute),
+	})
+	e := notify.Event{Type: notify.EventConfirmation, Severity: notify.SeverityWarning, Channel: req.Channel, Target: req.Target, Text: text, Direct: true}
+	if req.Channel == "" || req.Target == "" {
+		cfg := n.g.config.Get()
+		e.Channel, e.Target = cfg.Alerts.Channel, cfg.Alerts.Target
+		if err := n.g.notify(e); err != nil {
+			n.g.log.Error("failed to notify admin", "error", err)
+		}
+		return nil
+	}
+	return n.g.notify(e)
+}
+
+func (n *chatNotifier) NotifyResult(req *confirmation.ConfirmationRequest, approved bool) {
+	if req.Channel == "" || req.Target == "" {
+		return
+	}
+	key := "confirmRejected"
+	if approved {
+		key = "confirmApproved"
+	}
+	text := n.g.i18nFor(req.Channel, req.UserID).Tf(key, i18n.Params{"id": req.ID, "by": req.ApprovedBy, "operation": req.Operation})
+	e := notify.Event{Type: notify.EventConfirmation, Severity: notify.SeverityInfo, Channel: req.Channel, Target: req.Target, Text: text, Direct: true}
+	if err := n.g.notify(e); err != nil {
+		n.g.log.Warn("failed to send confirmation result", "id", req.ID, "error", err)
+	}
+}
+
+// formatEffects 把操作的影响摘要格式化为列表，每项带风险等级
+func formatEffects(t *i18n.I18n, effects []policy.Effect) string {
+	if len(effects) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\n" + t.T("effectsTitle") + ":")
+	for _, e := range effects {
+		targets := strings.Join(e.Targets, ", ")
+		if targets == "" {
+			targets = "-"
+		}
+		fmt.Fprintf(&sb, "\n• %s [%s]", t.Tf("effect."+e.Kind, i18n.Params{"targets": targets}), riskLabel(t, e.Risk))
+	}
+	return sb.String()
+}
+
+// riskLabel 风险等级的翻译，未知等级原样显示
+func riskLabel(t *i18n.I18n, risk string) string {
+	if s, ok := t.Lookup(t.GetLanguage(), "risk."+risk, nil); ok {
+		return s
+	}
+	return risk
+}
+
+// confirmCommand 处理 /approve <id> 和 /reject <id>，仅发起者本人或管理员可操作
+func (g *Gateway) confirmCommand(channel, userID string, approve bool, args []string) (string, error) {
+	t := g.i18nFor(channel, userID)
+	if len(args) != 1 {
+		if approve {
+			return t.T("approveUsage"), nil
+		}
+		return t.T("rejectUsage"), nil
+	}
+
+	req, err := g.confirmMgr.GetRequest(args[0])
+	if err != nil || req.Status != confirmation.StatusPending {
+		return t.Tf("confirmNotFound", i18n.Params{"id": args[0]}), nil
+	}
+
+	cfg := g.config.Get()
+	if !cfg.IsAdmin(channel, userID) && (req.Channel != channel || req.UserID != userID) {
+		return t.T("adminOnly"), nil
+	}
+
+	by := channel + ":" + userID
+	if approve {
+		err = g.confirmMgr.Approve(req.ID, by)
+	} else {
+		err = g.confirmMgr.Reject(req.ID, by)
+	}
+	if err != nil {
+		return "", err
+	}
+	// 结果由 chatNotifier 发回原会话，这里只在其他会话操作时回复
+	if req.Channel == channel && req.UserID == userID {
+		return "", nil
+	}
+	if approve {
+		return t.Tf("confirmApprovedReply", i18n.Params{"id": req.ID}), nil
+	}
+	return t.Tf("confirmRejectedReply", i18n.Params{"id": req.ID}), nil
+}
@@ -0,0 +1,99 @@
+package gateway
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/HaohanHe/mujibot/internal/i18n"
+	"github.com/HaohanHe/mujibot/internal/session"
+)
+
+// saveCommand 把当前会话保存为命名对话: /save <name>，不带名称时列出已保存的对话
+func (g *Gateway) saveCommand(channel, userID, name string) (string, error) {
+	t := g.i18nFor(channel, userID)
+	if g.saved == nil {
+		return t.T("conversationsDisabled"), nil
+	}
+	if name == "" {
+		return g.listConversations(channel, userID)
+	}
+
+	agent, err := g.agentRouter.Route(userID, channel, "")
+	if err != nil {
+		return "", err
+	}
+	sess := g.sessionMgr.Get(userID, channel, agent.ID)
+	if sess == nil {
+		return t.T("conversationEmpty"), nil
+	}
+	messages := g.sessionMgr.GetMessages(sess)
+	if len(messages) == 0 {
+		return t.T("conversationEmpty"), nil
+	}
+
+	name, err = session.NormalizeSavedName(name)
+	if err != nil {
+		return err.Error(), nil
+	}
+	if _, err := g.saved.Save(channel+":"+userID, name, agent.ID, messages); err != nil {
+		return err.Error(), nil
+	}
+	return t.Tf("conversationSaved", i18n.Params{"name": name, "n": len(messages)}), nil
+}
+
+// loadCommand 用已保存的对话替换当前会话: /load <name>，不带名称时列出已保存的对话
+func (g *Gateway) loadCommand(channel, userID, name string) (string, error) {
+	t := g.i18nFor(channel, userID)
+	if g.saved == nil {
+		return t.T("conversationsDisabled"), nil
+	}
+	if name == "" {
+		return g.listConversations(channel, userID)
+	}
+
+	c, err := g.saved.Load(channel+":"+userID, name)
+	if err != nil {
+		return err.Error(), nil
+	}
+	// 载入到当前路由的智能体，保存时的智能体可能已不存在或已切换
+	agent, err := g.agentRouter.Route(userID, channel, "")
+	if err != nil {
+		return "", err
+	}
+	g.sessionMgr.Restore(g.sessionMgr.GetOrCreate(userID, channel, agent.ID), c.Messages)
+	g.log.Info("saved conversation loaded", "user", channel+":"+userID, "name", c.Name, "agent", agent.ID, "messages", len(c.Messages))
+	return t.Tf("conversationLoaded", i18n.Params{"name": c.Name, "n": len(c.Messages)}), nil
+}
+
+// unsaveCommand 删除已保存的对话: /unsave <name>
+func (g *Gateway) unsaveCommand(channel, userID, name string) string {
+	t := g.i18nFor(channel, userID)
+	if g.saved == nil {
+		return t.T("conversationsDisabled")
+	}
+	if name == "" {
+		return t.T("unsaveUsage")
+	}
+	if err := g.saved.Delete(channel+":"+userID, name); err != nil {
+		return err.Error()
+	}
+	return t.Tf("conversationDeleted", i18n.Params{"name": strings.Join(strings.Fields(name), " ")})
+}
+
+// listConversations 列出用户已保存的对话
+func (g *Gateway) listConversations(channel, userID string) (string, error) {
+	t := g.i18nFor(channel, userID)
+	list, err := g.saved.List(channel + ":" + userID)
+	if err != nil {
+		return "", err
+	}
+	if len(list) == 0 {
+		return t.T("conversationNone"), nil
+	}
+
+	var sb strings.Builder
+	for _, c := range list {
+		fmt.Fprintf(&sb, "- %s (%d, %s)\n", c.Name, c.Messages, c.Saved.Format("2006-01-02 15:04"))
+	}
+	return sb.String() + "\n" + t.T("conversationListHint"), nil
+}
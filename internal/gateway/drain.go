@@ -0,0 +1,61 @@
+package gateway
+
+// activeChat 有进行中请求的聊天
+type activeChat struct {
+	channel string
+	userID  string
+	target  string
+	n       int
+}
+
+// trackActive 登记进行中的请求，返回的函数在请求结束时调用
+func (g *Gateway) trackActive(channel, userID, target string) func() {
+	if target == "" {
+		return func() {}
+	}
+	key := channel + ":" + target
+
+	g.activeMu.Lock()
+	c, ok := g.active[key]
+	if !ok {
+		c = &activeChat{channel: channel, userID: userID, target: target}
+		g.active[key] = c
+	}
+	c.n++
+	g.activeMu.Unlock()
+
+	return func() {
+		g.activeMu.Lock()
+		defer g.activeMu.Unlock()
+		if c.n--; c.n == 0 {
+			delete(g.active, key)
+		}
+	}
+}
+
+// activeCount 有进行中请求的聊天数
+func (g *Gateway) activeCount() int {
+	g.activeMu.Lock()
+	defer g.activeMu.Unlock()
+	return len(g.active)
+}
+
+// notifyActiveChats 退出时告知有进行中请求的用户正在重启，按用户的语言发送
+func (g *Gateway) notifyActiveChats() {
+	g.activeMu.Lock()
+	chats := make([]activeChat, 0, len(g.active))
+	for _, c := range g.active {
+		chats = append(chats, *c)
+	}
+	g.activeMu.Unlock()
+
+	for _, c := range chats {
+		text := g.i18nFor(c.channel, c.userID).T("restarting")
+		if err := g.sendTo(c.channel, c.target, text); err != nil {
+			g.log.Warn("failed to send restart notice", "channel", c.channel, "target", c.target, "error", err)
+		}
+	}
+	if len(chats) > 0 {
+		g.log.Info("restart notice sent", "chats", len(chats))
+	}
+}
@@ -0,0 +1,26 @@
+package gateway
+
+import (
+	"fmt"
+
+	"github.com/HaohanHe/mujibot/internal/encryption"
+)
+
+// initEncryption 按配置加载静态加密密钥，未开启时返回 nil
+func (g *Gateway) initEncryption() (*encryption.Cipher, error) {
+	cfg := g.config.Get().Encryption
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	key, err := encryption.LoadKey(cfg.KeyFile, cfg.KeyEnv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encryption key: %w", err)
+	}
+	cipher, err := encryption.New(key)
+	if err != nil {
+		return nil, err
+	}
+	g.log.Info("encryption at rest enabled", "key_file", cfg.KeyFile != "")
+	return cipher, nil
+}
@@ -0,0 +1,79 @@
+package gateway
+
+import (
+	"fmt"
+
+	"github.com/HaohanHe/mujibot/internal/agent"
+	"github.com/HaohanHe/mujibot/internal/eval"
+	"github.com/HaohanHe/mujibot/internal/llm"
+)
+
+// EvalTargets 为 mujibot eval 创建评测目标：按目标的提供商和模型创建智能体，
+// 系统提示词和工具定义与聊天时相同，每个用例只请求一次模型，不执行工具。
+// specs 为空时使用配置中的 llm 和默认智能体
+func (g *Gateway) EvalTargets(specs []eval.TargetSpec) ([]eval.Target, error) {
+	cfg := g.config.Get()
+	if len(specs) == 0 {
+		specs = []eval.TargetSpec{{Name: cfg.LLM.Model}}
+	}
+
+	targets := make([]eval.Target, 0, len(specs))
+	for _, spec := range specs {
+		llmCfg := cfg.LLM
+		if spec.Preset != "" {
+			preset, ok := cfg.LLMPresets[spec.Preset]
+			if !ok {
+				return nil, fmt.Errorf("target %s: preset not found: %s", spec.Name, spec.Preset)
+			}
+			llmCfg.Provider = spec.Preset
+			llmCfg.BaseURL = preset.BaseURL
+			if len(preset.Models) > 0 {
+				llmCfg.Model = preset.Models[0]
+			}
+		}
+		if spec.Provider != "" {
+			llmCfg.Provider = spec.Provider
+		}
+		if spec.Model != "" {
+			llmCfg.Model = spec.Model
+		}
+		if spec.BaseURL != "" {
+			llmCfg.BaseURL = spec.BaseURL
+		}
+		if spec.APIKey != "" {
+			llmCfg.APIKey = spec.APIKey
+		}
+
+		// 不重试，延迟反映单次请求
+		provider, err := llm.NewProvider(llmCfg.Provider, llmCfg.APIKey, llmCfg.BaseURL, llmCfg.Model, llmCfg.Timeout, 0, g.log.Module("llm"))
+		if err != nil {
+			return nil, fmt.Errorf("target %s: %w", spec.Name, err)
+		}
+		if err := llm.UseProxy(provider, llmCfg.Proxy); err != nil {
+			return nil, fmt.Errorf("target %s: invalid llm.proxy: %w", spec.Name, err)
+		}
+		g.enableLLMDebugLog(provider)
+		// llm.replay 同样生效，评测套件可以在没有密钥的环境中回放
+		if provider, err = g.wrapLLMReplay(cfg, provider); err != nil {
+			return nil, fmt.Errorf("target %s: %w", spec.Name, err)
+		}
+
+		agentID := spec.Agent
+		if agentID == "" {
+			def, ok := g.agentRouter.GetDefaultAgent()
+			if !ok {
+				return nil, fmt.Errorf("no agent configured")
+			}
+			agentID = def.ID
+		}
+		agentCfg, ok := cfg.Agents[agentID]
+		if !ok {
+			return nil, fmt.Errorf("target %s: agent not found: %s", spec.Name, agentID)
+		}
+		a := agent.CreateAgent(agentID, agentCfg, provider, g.toolMgr, g.sessionMgr, g.memoryMgr, g.i18n, g.log.Module("agent"))
+		a.SetPromptBudget(g.promptBudget)
+
+		targets = append(targets, eval.Target{Name: spec.Name, Agent: agentID, Model: provider.GetModel(), Ask: a.Probe})
+	}
+	return targets, nil
+}
@@ -0,0 +1,114 @@
+package gateway
+
+import (
+	"errors"
+	"time"
+)
+
+// 启动事件，--json 模式下逐行输出到标准输出
+const (
+	EventConfigLoaded     = "config_loaded"
+	EventWebListening     = "web_listening"
+	EventChannelConnected = "channel_connected"
+	EventChannelFailed    = "channel_failed"
+	EventReady            = "ready"
+	EventStopping         = "stopping"
+	EventStopped          = "stopped"
+	EventError            = "error"
+)
+
+// Event 网关生命周期事件
+type Event struct {
+	Event     string    `json:"event"`
+	Time      time.Time `json:"time"`
+	Path      string    `json:"path,omitempty"`
+	Channel   string    `json:"channel,omitempty"`
+	Addr      string    `json:"addr,omitempty"`
+	Component string    `json:"component,omitempty"`
+	Code      int       `json:"code,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// emit 发出生命周期事件，未设置 Options.OnEvent 时忽略
+func (g *Gateway) emit(e Event) {
+	if g.opts.OnEvent == nil {
+		return
+	}
+	e.Time = time.Now()
+	g.opts.OnEvent(e)
+}
+
+// 启动失败的组件
+const (
+	ComponentConfig     = "config"
+	ComponentLogging    = "logging"
+	ComponentNetwork    = "network"
+	ComponentCluster    = "cluster"
+	ComponentEncryption = "encryption"
+	ComponentStorage    = "storage"
+	ComponentTools      = "tools"
+	ComponentLLM        = "llm"
+	ComponentWeb        = "web"
+)
+
+// 退出码：1 为未分类的错误，2 为命令行参数错误，3 为初始化向导失败，其余按失败的组件区分
+const (
+	ExitError = 1
+	ExitUsage = 2
+	ExitSetup = 3
+)
+
+var componentExitCodes = map[string]int{
+	ComponentConfig:     4,
+	ComponentLogging:    5,
+	ComponentNetwork:    6,
+	ComponentCluster:    7,
+	ComponentEncryption: 8,
+	ComponentStorage:    9,
+	ComponentTools:      10,
+	ComponentLLM:        11,
+	ComponentWeb:        12,
+}
+
+// StartupError 某个组件初始化失败
+type StartupError struct {
+	Component string
+	Err       error
+}
+
+func (e *StartupError) Error() string {
+	return e.Component + ": " + e.Err.Error()
+}
+
+func (e *StartupError) Unwrap() error {
+	return e.Err
+}
+
+// startupError 标记失败的组件，已标记的错误保持不变
+func startupError(component string, err error) error {
+	var se *StartupError
+	if errors.As(err, &se) {
+		return err
+	}
+	return &StartupError{Component: component, Err: err}
+}
+
+// ErrorComponent 返回启动失败的组件，未标记时为空
+func ErrorComponent(err error) string {
+	var se *StartupError
+	if errors.As(err, &se) {
+		return se.Component
+	}
+	return ""
+}
+
+// ExitCode 返回启动失败时的进程退出码
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if code, ok := componentExitCodes[ErrorComponent(err)]; ok {
+		return code
+	}
+	return ExitError
+}
@@ -6,25 +6,40 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/HaohanHe/mujibot/internal/agent"
+	"github.com/HaohanHe/mujibot/internal/analytics"
 	"github.com/HaohanHe/mujibot/internal/channel/discord"
 	"github.com/HaohanHe/mujibot/internal/channel/feishu"
 	"github.com/HaohanHe/mujibot/internal/channel/telegram"
+	"github.com/HaohanHe/mujibot/internal/cluster"
 	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/confirmation"
+	"github.com/HaohanHe/mujibot/internal/crash"
+	"github.com/HaohanHe/mujibot/internal/feed"
+	"github.com/HaohanHe/mujibot/internal/guardrail"
 	"github.com/HaohanHe/mujibot/internal/health"
+	"github.com/HaohanHe/mujibot/internal/httpapi"
 	"github.com/HaohanHe/mujibot/internal/i18n"
 	"github.com/HaohanHe/mujibot/internal/llm"
 	"github.com/HaohanHe/mujibot/internal/logger"
 	"github.com/HaohanHe/mujibot/internal/memory"
+	"github.com/HaohanHe/mujibot/internal/notify"
+	"github.com/HaohanHe/mujibot/internal/outbox"
+	"github.com/HaohanHe/mujibot/internal/policy"
+	"github.com/HaohanHe/mujibot/internal/scheduler"
 	"github.com/HaohanHe/mujibot/internal/session"
+	"github.com/HaohanHe/mujibot/internal/todo"
 	"github.com/HaohanHe/mujibot/internal/tools"
 	"github.com/HaohanHe/mujibot/internal/web"
+	"github.com/HaohanHe/mujibot/internal/webhook"
 )
 
 // Gateway 网关
@@ -35,10 +50,27 @@ type Gateway struct {
 	memoryMgr   *memory.Manager
 	toolMgr     *tools.Manager
 	llmProvider llm.Provider
+	llmDebug    llm.DebugFile
+	llmRecords  llm.RecordingFile
 	agentRouter *agent.Router
 	healthCheck *health.Checker
 	memoryGuard *health.MemoryGuard
 	webServer   *web.Server
+	scheduler   *scheduler.Scheduler
+	feeds       *feed.Store
+	todos       *todo.Store
+	contacts    *memory.ContactBook
+	saved       *session.SavedStore
+	outbox      *outbox.Outbox
+	notifications *notify.Router
+	webhooks    *webhook.Dispatcher
+	analytics   *analytics.Store
+	intents     *analytics.Tagger
+	watchdog    *health.Watchdog
+	crash       *crash.Reporter
+	confirmMgr  *confirmation.ConfirmationManager
+	clusterStore cluster.Store
+	i18n        *i18n.I18n
 
 	// 渠道
 	telegramBot *telegram.Bot
@@ -51,45 +83,106 @@ type Gateway struct {
 	wg     sync.WaitGroup
 	running bool
 	mu     sync.RWMutex
+
+	// 优雅退出
+	inflight   sync.WaitGroup
+	// msgCtx 消息处理的上下文，退出时等待超时后取消
+	msgCtx    context.Context
+	msgCancel context.CancelFunc
+	// active 有进行中请求的聊天，退出时通知这些用户
+	activeMu sync.Mutex
+	active   map[string]*activeChat
+	journals   sync.WaitGroup // 后台写入的会话摘要和意图标注
+	// jobs 按用户（channel:userID）登记的后台任务，清除用户数据前取消
+	jobsMu sync.Mutex
+	jobs   map[string]map[*backgroundJob]struct{}
+	draining   bool
+	restarting bool
+
+	// /forgetme 等待确认的请求，键为 channel:userID，值为过期时间
+	purgeMu       sync.Mutex
+	purgeRequests map[string]time.Time
+
+	opts Options
+
+	// 通过 ConnectChannel 接入的渠道，名称 -> 发送函数
+	channelsMu sync.RWMutex
+	channels   map[string]func(target, text string) error
+
+	// workers 限制同时交给智能体处理的消息数，resources.workers 为0时为 nil
+	workers chan struct{}
+}
+
+// defaultDrainTimeout 未配置 server.drainTimeout 时退出等待进行中消息处理完成的最长时间
+const defaultDrainTimeout = 30 * time.Second
+
+// Options 创建网关的可选项，用于测试和嵌入
+type Options struct {
+	// Provider 替代按 llm 配置创建的提供商，如 testkit.Provider
+	Provider llm.Provider
+	// OnEvent 接收配置加载、渠道连接、就绪等生命周期事件
+	OnEvent func(Event)
+	// LogStderr 未配置日志文件时日志输出到标准错误，标准输出只留给事件
+	LogStderr bool
 }
 
 // NewGateway 创建网关
 func NewGateway(configPath string) (*Gateway, error) {
+	return NewGatewayWithOptions(configPath, Options{})
+}
+
+// NewGatewayWithOptions 按选项创建网关
+func NewGatewayWithOptions(configPath string, opts Options) (*Gateway, error) {
 	// 创建临时日志记录器
-	tempLog, err := logger.New(logger.Config{Level: "info", Format: "json"})
+	tempLog, err := logger.New(logger.Config{Level: "info", Format: "json", Stderr: opts.LogStderr})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create temp logger: %w", err)
+		return nil, startupError(ComponentLogging, fmt.Errorf("failed to create temp logger: %w", err))
 	}
 
 	// 加载配置
 	cfg, err := config.NewManager(configPath, tempLog)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load config: %w", err)
+		return nil, startupError(ComponentConfig, fmt.Errorf("failed to load config: %w", err))
 	}
 
 	// 使用配置创建正式日志记录器
 	logConfig := cfg.Get().Logging
 	log, err := logger.New(logger.Config{
-		Level:   logConfig.Level,
-		File:    logConfig.File,
-		MaxSize: logConfig.MaxSize,
-		Format:  logConfig.Format,
+		Level:      logConfig.Level,
+		File:       logConfig.File,
+		MaxSize:    logConfig.MaxSize,
+		MaxBackups: logConfig.MaxBackups,
+		MaxAgeDays: logConfig.MaxAgeDays,
+		Format:     logConfig.Format,
+		Levels:     logConfig.Levels,
+		Exporters:  logExporters(logConfig.Exporters),
+		Stderr:     opts.LogStderr,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create logger: %w", err)
+		return nil, startupError(ComponentLogging, fmt.Errorf("failed to create logger: %w", err))
 	}
 
 	// 更新配置管理器的日志
 	cfg.Close()
 	cfg, err = config.NewManager(configPath, log)
 	if err != nil {
-		return nil, err
+		return nil, startupError(ComponentConfig, err)
 	}
 
 	g := &Gateway{
 		config: cfg,
 		log:    log,
+		opts:   opts,
+		active: make(map[string]*activeChat),
 	}
+	g.msgCtx, g.msgCancel = context.WithCancel(context.Background())
+	g.emit(Event{Event: EventConfigLoaded, Path: configPath})
+
+	// 配置热更新时同步日志级别
+	cfg.OnChange(func(c *config.Config) {
+		log.SetLevel(logger.ParseLevel(c.Logging.Level))
+		log.SetModuleLevels(c.Logging.Levels)
+	})
 
 	// 初始化组件
 	if err := g.initComponents(); err != nil {
@@ -99,83 +192,327 @@ func NewGateway(configPath string) (*Gateway, error) {
 	return g, nil
 }
 
+// promptBudget 按当前配置返回系统提示词预算
+func (g *Gateway) promptBudget() agent.PromptBudget {
+	cfg := g.config.Get().LLM
+	return agent.PromptBudget{ContextWindow: cfg.ContextWindow, Percent: cfg.PromptBudgetPercent}
+}
+
+// responseStyle 按当前配置返回渠道的回复长度与风格
+func (g *Gateway) responseStyle(channel string) config.ResponseStyle {
+	return g.config.Get().Channels.Responses[channel]
+}
+
+// newLLMProvider 按 llm 配置创建提供商，Options.Provider 不为空时直接使用
+func (g *Gateway) newLLMProvider(cfg *config.Config) (llm.Provider, error) {
+	if g.opts.Provider != nil {
+		return g.opts.Provider, nil
+	}
+	p, err := llm.NewProvider(
+		cfg.LLM.Provider,
+		cfg.LLM.APIKey,
+		cfg.LLM.BaseURL,
+		cfg.LLM.Model,
+		cfg.LLM.Timeout,
+		cfg.LLM.MaxRetries,
+		g.log.Module("llm"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create llm provider: %w", err)
+	}
+	if err := llm.UseProxy(p, cfg.LLM.Proxy); err != nil {
+		return nil, fmt.Errorf("invalid llm.proxy: %w", err)
+	}
+	g.enableLLMDebugLog(p)
+	if p, err = g.wrapLLMReplay(cfg, p); err != nil {
+		return nil, err
+	}
+	if mode := cfg.LLM.Replay.Mode; mode != "" {
+		g.log.Warn("llm replay is enabled", "mode", mode, "file", g.llmReplayFile(cfg))
+	}
+	return p, nil
+}
+
+// logExporters 转换日志导出配置
+func logExporters(cfgs []config.LogExporterConfig) []logger.ExporterConfig {
+	exporters := make([]logger.ExporterConfig, 0, len(cfgs))
+	for _, c := range cfgs {
+		exporters = append(exporters, logger.ExporterConfig{
+			Type:          c.Type,
+			Level:         c.Level,
+			Network:       c.Network,
+			Address:       c.Address,
+			Tag:           c.Tag,
+			Endpoint:      c.Endpoint,
+			Headers:       c.Headers,
+			ServiceName:   c.ServiceName,
+			BatchSize:     c.BatchSize,
+			FlushInterval: c.FlushInterval,
+		})
+	}
+	return exporters
+}
+
 // initComponents 初始化组件
 func (g *Gateway) initComponents() error {
 	cfg := g.config.Get()
 
+	// 出站HTTP：代理、CA证书和连接池，须在创建提供商和渠道之前设置
+	if err := g.initHTTP(); err != nil {
+		return startupError(ComponentNetwork, err)
+	}
+
 	// 创建会话管理器
 	g.sessionMgr = session.NewManager(
 		cfg.Session.MaxMessages,
 		cfg.Session.IdleTimeout,
 		cfg.Session.MaxSessions,
-		g.log,
+		g.log.Module("session"),
 	)
 
+	// 多实例协调：会话和确认请求保存在共享存储中
+	if err := g.initCluster(); err != nil {
+		return startupError(ComponentCluster, err)
+	}
+	// 静态加密：记忆文件和共享存储中的会话
+	cipher, err := g.initEncryption()
+	if err != nil {
+		return startupError(ComponentEncryption, err)
+	}
+
+	if g.clusterStore != nil {
+		g.sessionMgr.SetStore(g.clusterStore)
+		g.sessionMgr.SetCipher(cipher)
+	}
+
 	// 创建记忆管理器
 	memCfg := memory.Config{
 		Enabled:     cfg.Memory.Enabled,
 		MemoryDir:   cfg.Memory.MemoryDir,
 		MaxFileSize: cfg.Memory.MaxFileSize,
+		Cipher:      cipher,
 	}
-	memoryMgr, err := memory.NewManager(memCfg, g.log)
+	memoryMgr, err := memory.NewManager(memCfg, g.log.Module("memory"))
 	if err != nil {
-		return fmt.Errorf("failed to create memory manager: %w", err)
+		return startupError(ComponentStorage, fmt.Errorf("failed to create memory manager: %w", err))
 	}
 	g.memoryMgr = memoryMgr
+	if cipher != nil && memoryMgr.IsEnabled() {
+		n, err := cipher.EncryptFiles(cfg.Memory.MemoryDir)
+		if err != nil {
+			return startupError(ComponentEncryption, fmt.Errorf("failed to encrypt memory files: %w", err))
+		}
+		if n > 0 {
+			g.log.Info("existing memory files encrypted", "count", n)
+		}
+	}
+
+	// 待办、通讯录和已保存的对话存储在记忆目录下，记忆关闭时不启用
+	if memoryMgr.IsEnabled() {
+		todos, err := todo.NewEncryptedStore(filepath.Join(cfg.Memory.MemoryDir, "todos"), cipher)
+		if err != nil {
+			return startupError(ComponentStorage, fmt.Errorf("failed to create todo store: %w", err))
+		}
+		g.todos = todos
+
+		contacts, err := memory.NewContactBook(filepath.Join(cfg.Memory.MemoryDir, "contacts"))
+		if err != nil {
+			return startupError(ComponentStorage, fmt.Errorf("failed to create contact book: %w", err))
+		}
+		contacts.SetCipher(cipher)
+		g.contacts = contacts
+
+		saved, err := session.NewSavedStore(filepath.Join(cfg.Memory.MemoryDir, "conversations"), cipher)
+		if err != nil {
+			return startupError(ComponentStorage, fmt.Errorf("failed to create saved conversation store: %w", err))
+		}
+		g.saved = saved
+	}
+	if err := g.initOutbox(cipher); err != nil {
+		return startupError(ComponentStorage, err)
+	}
+	g.notifications = notify.New(g.config, g.send, g.sendTo, g.log.Module("notify"))
+	g.webhooks = webhook.New(g.config, g.log.Module("webhook"))
+
+	// 创建危险操作确认管理器，确认请求发回发起操作的聊天
+	g.confirmMgr = confirmation.NewConfirmationManager(g.config, g.log.Module("confirmation"))
+	g.confirmMgr.RegisterNotifier(&chatNotifier{g: g})
+	if g.clusterStore != nil {
+		g.confirmMgr.SetStore(g.clusterStore)
+	}
+
+	// 危险操作策略：策略文件中的规则优先于内置规则
+	rules := policy.Defaults(cfg.Tools.BlockedCommands)
+	if cfg.Tools.PolicyFile != "" {
+		custom, err := policy.Load(cfg.Tools.PolicyFile)
+		if err != nil {
+			return startupError(ComponentTools, err)
+		}
+		rules = append(custom, rules...)
+	}
+	toolPolicy, err := policy.New(rules)
+	if err != nil {
+		return startupError(ComponentTools, fmt.Errorf("invalid tool policy: %w", err))
+	}
 
 	// 创建工具管理器
+	workspaces := make([]tools.Workspace, 0, len(cfg.Tools.Workspaces))
+	for _, ws := range cfg.Tools.Workspaces {
+		workspaces = append(workspaces, tools.Workspace{Name: ws.Name, Path: ws.Path, ReadOnly: ws.ReadOnly})
+	}
 	toolCfg := tools.Config{
 		WorkDir:          cfg.Tools.WorkDir,
+		Workspaces:       workspaces,
+		PerUserWorkDir:   cfg.Tools.PerUserWorkDir,
+		SharedDir:        cfg.Tools.SharedDir,
+		IsAdmin:          g.isAdmin,
 		Timeout:          cfg.Tools.Timeout,
 		ConfirmDangerous: cfg.Tools.ConfirmDangerous,
 		UnattendedMode:   cfg.Tools.UnattendedMode,
 		BlockedCommands:  cfg.Tools.BlockedCommands,
+		Policy:           toolPolicy,
 		EnabledTools:     cfg.Tools.EnabledTools,
 		TerminalEnabled:  cfg.Tools.TerminalEnabled,
+		TerminalRows:     cfg.Tools.TerminalRows,
+		TerminalCols:     cfg.Tools.TerminalCols,
 		WebSearchEnabled: cfg.Tools.WebSearchEnabled,
 		MemoryMgr:        memoryMgr,
+		ConfirmMgr:       g.confirmMgr,
+		ServiceUnits:     cfg.Tools.ServiceUnits,
+		MaxResultChars:   cfg.Tools.MaxResultChars,
+		ToolTimeouts:     cfg.Tools.ToolTimeouts,
+		Settings: tools.ToolSettings{
+			Weather: tools.WeatherSettings{
+				DefaultCity:  cfg.Tools.Settings.Weather.DefaultCity,
+				DefaultDays:  cfg.Tools.Settings.Weather.DefaultDays,
+				DefaultUnits: cfg.Tools.Settings.Weather.DefaultUnits,
+			},
+			WebSearch: tools.WebSearchSettings{
+				DefaultResults: cfg.Tools.Settings.WebSearch.DefaultResults,
+				MaxResults:     cfg.Tools.Settings.WebSearch.MaxResults,
+			},
+			HTTPRequest: tools.HTTPRequestSettings{
+				MaxBytes:  cfg.Tools.Settings.HTTPRequest.MaxBytes,
+				MaxLength: cfg.Tools.Settings.HTTPRequest.MaxLength,
+			},
+			Grep: tools.GrepSettings{
+				MaxMatches: cfg.Tools.Settings.Grep.MaxMatches,
+				MaxPerFile: cfg.Tools.Settings.Grep.MaxPerFile,
+			},
+			Quotes: tools.QuotesSettings{
+				Providers:            cfg.Tools.Settings.Quotes.Providers,
+				CacheSeconds:         cfg.Tools.Settings.Quotes.CacheSeconds,
+				MaxRequestsPerMinute: cfg.Tools.Settings.Quotes.MaxRequestsPerMinute,
+			},
+		},
+		PostProcess:      toolPostProcess(cfg.Tools.PostProcess),
+		Todos:            g.todos,
+		Contacts:         g.contacts,
+		Quotas: tools.QuotaConfig{
+			MaxCallsPerMessage:    cfg.Tools.Quotas.MaxCallsPerMessage,
+			MaxCommandsPerHour:    cfg.Tools.Quotas.MaxCommandsPerHour,
+			MaxHTTPRequestsPerDay: cfg.Tools.Quotas.MaxHTTPRequestsPerDay,
+		},
+		Email: tools.EmailConfig{
+			Enabled:           cfg.Tools.Email.Enabled,
+			Host:              cfg.Tools.Email.Host,
+			Port:              cfg.Tools.Email.Port,
+			Username:          cfg.Tools.Email.Username,
+			Password:          cfg.Tools.Email.Password,
+			From:              cfg.Tools.Email.From,
+			Security:          cfg.Tools.Email.Security,
+			AllowedRecipients: cfg.Tools.Email.AllowedRecipients,
+			MaxAttachmentMB:   cfg.Tools.Email.MaxAttachmentMB,
+			OnNewRecipient:    g.config.AddEmailRecipient,
+		},
+		Calendar: tools.CalendarConfig{
+			Enabled:            cfg.Tools.Calendar.Enabled,
+			URL:                cfg.Tools.Calendar.URL,
+			Username:           cfg.Tools.Calendar.Username,
+			Password:           cfg.Tools.Calendar.Password,
+			Timezone:           cfg.Tools.Calendar.Timezone,
+			GoogleClientID:     cfg.Tools.Calendar.Google.ClientID,
+			GoogleClientSecret: cfg.Tools.Calendar.Google.ClientSecret,
+			GoogleRefreshToken: cfg.Tools.Calendar.Google.RefreshToken,
+		},
+		HomeAssistant: tools.HomeAssistantConfig{
+			Enabled:         cfg.Tools.HomeAssistant.Enabled,
+			URL:             cfg.Tools.HomeAssistant.URL,
+			Token:           cfg.Tools.HomeAssistant.Token,
+			AllowedEntities: cfg.Tools.HomeAssistant.AllowedEntities,
+		},
 	}
-	toolMgr, err := tools.NewManager(toolCfg, g.log)
+	toolMgr, err := tools.NewManager(toolCfg, g.log.Module("tools"))
 	if err != nil {
-		return fmt.Errorf("failed to create tool manager: %w", err)
+		return startupError(ComponentTools, fmt.Errorf("failed to create tool manager: %w", err))
 	}
 	g.toolMgr = toolMgr
+	// 控制台开关工具或手动编辑 tools.enabledTools 后立即生效
+	g.config.OnChange(func(c *config.Config) {
+		toolMgr.ApplyEnabled(c.Tools.EnabledTools)
+	})
+	g.toolMgr.SetNotifier(g.notifySender(notify.EventTask, notify.SeverityInfo))
+	g.toolMgr.SetTranslator(func(channel, userID, key string, params i18n.Params) string {
+		return g.i18nFor(channel, userID).Tf(key, params)
+	})
 
 	// 创建LLM提供商
-	llmProvider, err := llm.NewProvider(
-		cfg.LLM.Provider,
-		cfg.LLM.APIKey,
-		cfg.LLM.BaseURL,
-		cfg.LLM.Model,
-		cfg.LLM.Timeout,
-		cfg.LLM.MaxRetries,
-		g.log,
-	)
+	llmProvider, err := g.newLLMProvider(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to create llm provider: %w", err)
+		return startupError(ComponentLLM, err)
 	}
 	g.llmProvider = llmProvider
+	if len(cfg.Tools.PostProcess) > 0 {
+		summarizer, err := g.toolSummarizer(cfg, llmProvider)
+		if err != nil {
+			return startupError(ComponentLLM, err)
+		}
+		g.toolMgr.SetSummarizer(summarizer)
+	}
+	if err := g.initAnalytics(cfg, cipher, llmProvider); err != nil {
+		return startupError(ComponentStorage, err)
+	}
 
 	// 创建智能体路由器
-	g.agentRouter = agent.NewRouter(g.log)
+	g.agentRouter = agent.NewRouter(g.log.Module("agent"))
+	guard := guardrail.New(g.config, g.log.Module("guardrail"))
+	g.agentRouter.SetGuardrail(guard)
+	g.sessionMgr.SetOnClose(g.journalSession)
+	g.toolMgr.SetOutputFilter(guard.SanitizeToolOutput)
 
-	// 创建国际化实例
+	// 创建国际化实例，配置热更新时同步全局语言
 	i := i18n.New(cfg.Language.Current)
+	i.SetAutoDetect(cfg.Language.AutoDetect)
+	g.i18n = i
+	g.config.OnChange(func(c *config.Config) {
+		i.SetLanguage(c.Language.Current)
+		i.SetAutoDetect(c.Language.AutoDetect)
+	})
 
 	// 注册智能体
 	for agentID, agentCfg := range cfg.Agents {
-		a := agent.CreateAgent(agentID, agentCfg, llmProvider, g.toolMgr, g.sessionMgr, g.memoryMgr, i, g.log)
+		a := agent.CreateAgent(agentID, agentCfg, llmProvider, g.toolMgr, g.sessionMgr, g.memoryMgr, i, g.log.Module("agent"))
+		a.SetPromptBudget(g.promptBudget)
+		a.SetResponseStyle(g.responseStyle)
 		g.agentRouter.RegisterAgent(agentID, a)
 	}
 
 	// 创建健康检查器
-	g.healthCheck = health.NewChecker(g.log)
+	g.healthCheck = health.NewChecker(g.log.Module("health"))
+
+	// 创建崩溃报告器
+	g.crash = crash.NewReporter(g.config, g.log.Module("crash"))
+	g.crash.SetNotifier(func(text string) { g.notifyAdmin(notify.SeverityCritical, text) })
+	g.agentRouter.SetCrashReporter(g.crash)
 
 	// 创建内存保护器
-	g.memoryGuard = health.NewMemoryGuard(g.log, func() {
-		g.log.Error("critical memory situation, initiating graceful shutdown")
-		g.Stop()
+	g.memoryGuard = health.NewMemoryGuard(g.log.Module("health"), func() {
+		go g.restart("critical memory usage")
 	})
+	g.memoryGuard.SetThresholds(cfg.Resources.MemoryGCMB, cfg.Resources.MemoryCriticalMB)
+	if cfg.Resources.Workers > 0 {
+		g.workers = make(chan struct{}, cfg.Resources.Workers)
+	}
 
 	// 创建Web服务器
 	g.webServer = web.NewServer(
@@ -184,11 +521,23 @@ func (g *Gateway) initComponents() error {
 		g.sessionMgr,
 		g.agentRouter,
 		g.healthCheck,
-		g.log,
+		g.log.Module("web"),
 	)
 
 	toolsHandler := web.NewToolsHandler(g.config, g.toolMgr)
 	g.webServer.SetToolsHandler(toolsHandler)
+	g.webServer.SetMemoryGuard(g.memoryGuard)
+	g.webServer.SetCrashReporter(g.crash)
+	g.webServer.SetConfirmations(g.confirmMgr)
+	g.webServer.SetMemory(g.memoryMgr)
+	if g.saved != nil {
+		g.webServer.SetSavedConversations(g.saved)
+	}
+	g.webServer.SetPurger(g.purgeUser)
+	if g.analytics != nil {
+		g.webServer.SetAnalytics(g.analytics)
+	}
+	g.toolMgr.SetObserver(g.observeTool)
 
 	return nil
 }
@@ -210,32 +559,56 @@ func (g *Gateway) Start() error {
 
 	// 启动Web服务器
 	if err := g.webServer.Start(); err != nil {
-		return fmt.Errorf("failed to start web server: %w", err)
+		return startupError(ComponentWeb, fmt.Errorf("failed to start web server: %w", err))
 	}
+	g.emit(Event{Event: EventWebListening, Addr: g.webServer.Addr()})
 
 	// 启动Telegram Bot
 	if cfg.Channels.Telegram.Enabled {
-		if err := g.startTelegram(); err != nil {
-			g.log.Error("failed to start telegram", "error", err)
-		}
+		g.channelStarted("telegram", g.startTelegram())
 	}
 
 	// 启动Discord Bot
 	if cfg.Channels.Discord.Enabled {
-		if err := g.startDiscord(); err != nil {
-			g.log.Error("failed to start discord", "error", err)
-		}
+		g.channelStarted("discord", g.startDiscord())
 	}
 
 	// 启动飞书Bot
 	if cfg.Channels.Feishu.Enabled {
-		if err := g.startFeishu(); err != nil {
-			g.log.Error("failed to start feishu", "error", err)
-		} else {
+		err := g.startFeishu()
+		if err == nil {
 			g.webServer.SetFeishuHandler(g.GetFeishuWebhookHandler())
 		}
+		g.channelStarted("feishu", err)
 	}
 
+	// 启动定时任务
+	g.scheduler = scheduler.New(g.config, g.agentRouter, g.notify, g.log.Module("scheduler"))
+	if store, err := feed.NewStore(cfg.Feeds.File); err != nil {
+		g.log.Error("failed to load feed subscriptions", "error", err)
+	} else {
+		g.feeds = store
+		g.scheduler.SetFeeds(store)
+	}
+	if g.todos != nil {
+		g.scheduler.SetTodos(g.todos)
+	}
+	if g.clusterStore != nil {
+		g.scheduler.SetStore(g.clusterStore)
+	}
+	// 私聊的目标就是用户ID，按用户设置的语言发送；群聊使用全局语言
+	g.scheduler.SetTranslator(func(channel, target, key string, params i18n.Params) string {
+		return g.i18nFor(channel, target).Tf(key, params)
+	})
+	g.scheduler.Start()
+
+	// 重试发送失败的消息
+	if g.outbox != nil {
+		g.outbox.Start()
+	}
+	g.notifications.Start()
+	g.webhooks.Start()
+
 	// 启动监控协程
 	g.wg.Add(1)
 	go g.monitorLoop()
@@ -243,9 +616,22 @@ func (g *Gateway) Start() error {
 	// 启动内存保护器
 	g.memoryGuard.Start()
 
+	// 启动存活监控
+	g.watchdog = health.NewWatchdog(g.config, g.notifySender(notify.EventAlert, notify.SeverityWarning), g.log.Module("health"))
+	g.registerProbes()
+	g.watchdog.Start()
+
+	g.log.Info("gateway ready")
+	g.emit(Event{Event: EventReady})
+
 	// 等待退出信号
 	g.waitForShutdown()
 
+	// 重启由 restart 完成并退出进程
+	if g.isRestarting() {
+		select {}
+	}
+
 	return nil
 }
 
@@ -257,21 +643,29 @@ func (g *Gateway) Stop() {
 		return
 	}
 	g.running = false
+	g.draining = true
 	g.mu.Unlock()
 
 	g.log.Info("gateway stopping")
+	g.emit(Event{Event: EventStopping})
+	// 告知正在等待回复的用户，回复仍会在等待时间内发出
+	g.notifyActiveChats()
 
 	// 停止内存保护器
 	if g.memoryGuard != nil {
 		g.memoryGuard.Stop()
 	}
-
-	// 取消上下文
-	if g.cancel != nil {
-		g.cancel()
+	if g.watchdog != nil {
+		g.watchdog.Stop()
+	}
+	if g.notifications != nil {
+		g.notifications.Stop()
+	}
+	if g.outbox != nil {
+		g.outbox.Stop()
 	}
 
-	// 停止渠道
+	// 停止渠道，不再接收新消息
 	if g.telegramBot != nil {
 		g.telegramBot.Stop()
 	}
@@ -282,10 +676,45 @@ func (g *Gateway) Stop() {
 		g.feishuBot.Stop()
 	}
 
+	// 等待进行中的消息处理完成
+	g.drain()
+
+	// 停止定时任务
+	if g.scheduler != nil {
+		g.scheduler.Stop()
+	}
+	// 发出处理期间产生的事件
+	if g.webhooks != nil {
+		g.webhooks.Stop()
+	}
+
+	// 停止Web服务器
+	if g.webServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := g.webServer.Stop(ctx); err != nil {
+			g.log.Warn("web server shutdown error", "error", err)
+		}
+		cancel()
+	}
+
+	// 取消上下文
+	if g.cancel != nil {
+		g.cancel()
+	}
+
 	// 等待协程结束
 	g.wg.Wait()
 
 	// 关闭组件
+	if g.sessionMgr != nil {
+		g.sessionMgr.Close()
+		g.waitJournals()
+	}
+	if g.clusterStore != nil {
+		g.clusterStore.Close()
+	}
+	g.llmDebug.Close()
+	g.llmRecords.Close()
 	if g.log != nil {
 		g.log.Close()
 	}
@@ -294,6 +723,66 @@ func (g *Gateway) Stop() {
 	}
 
 	g.log.Info("gateway stopped")
+	g.emit(Event{Event: EventStopped})
+}
+
+// drain 等待进行中的消息处理完成，超过 server.drainTimeout 后取消仍在执行的工具并放弃等待
+func (g *Gateway) drain() {
+	timeout := defaultDrainTimeout
+	if sec := g.config.Get().Server.DrainTimeout; sec > 0 {
+		timeout = time.Duration(sec) * time.Second
+	}
+
+	done := make(chan struct{})
+	go func() {
+		g.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		g.log.Info("in-flight messages drained")
+	case <-time.After(timeout):
+		g.log.Warn("timed out waiting for in-flight messages", "timeout", timeout.String(), "chats", g.activeCount())
+		g.msgCancel()
+	}
+}
+
+// restart 优雅退出后重新启动进程
+func (g *Gateway) restart(reason string) {
+	g.mu.Lock()
+	if g.restarting {
+		g.mu.Unlock()
+		return
+	}
+	g.restarting = true
+	g.mu.Unlock()
+
+	g.log.Error("restarting gateway", "reason", reason)
+	g.Stop()
+
+	if err := health.SelfRestart(); err != nil {
+		fmt.Fprintf(os.Stderr, "self restart failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// isRestarting 检查是否正在重启
+func (g *Gateway) isRestarting() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.restarting
+}
+
+// beginMessage 登记进行中的消息，退出过程中返回false
+func (g *Gateway) beginMessage() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.draining {
+		return false
+	}
+	g.inflight.Add(1)
+	return true
 }
 
 // IsRunning 检查是否运行中
@@ -306,11 +795,29 @@ func (g *Gateway) IsRunning() bool {
 // startTelegram 启动Telegram
 func (g *Gateway) startTelegram() error {
 	cfg := g.config.Get()
-	g.telegramBot = telegram.NewBot(cfg.Channels.Telegram, g.log)
+	g.telegramBot = telegram.NewBot(cfg.Channels.Telegram, g.log.Module("telegram"))
 
 	// 注册消息处理器
-	g.telegramBot.OnMessage(func(userID int64, username, text string, chatID int64) (string, error) {
-		return g.handleMessage("telegram", fmt.Sprintf("%d", userID), username, text)
+	g.telegramBot.OnMessage(func(userID int64, username, text, quoted string, chatID int64) (string, error) {
+		sendFile := func(filename string, data []byte, caption string) error {
+			return g.telegramBot.SendDocument(chatID, filename, data, caption)
+		}
+		return g.handleMessage("telegram", fmt.Sprintf("%d", userID), username, text, quoted, fmt.Sprintf("%d", chatID), sendFile)
+	})
+	// 多实例共用同一个Bot Token时，每条更新只由一个实例处理
+	if g.clusterStore != nil {
+		g.telegramBot.SetClaimer(g.claim)
+	}
+	g.telegramBot.SetTranslator(func(userID int64, key string) string {
+		return g.i18nFor("telegram", fmt.Sprintf("%d", userID)).T(key)
+	})
+	queue := g.queueFailed("telegram")
+	g.telegramBot.OnSendError(func(chatID int64, text string, err error) {
+		queue(fmt.Sprintf("%d", chatID), text, err)
+	})
+	// 回复终端会话消息即向会话输入
+	g.telegramBot.OnReply(func(userID int64, username, text, replyTo string, chatID int64) (string, bool, error) {
+		return g.terminalReply("telegram", fmt.Sprintf("%d", userID), fmt.Sprintf("%d", chatID), text, replyTo)
 	})
 
 	if err := g.telegramBot.Start(); err != nil {
@@ -324,12 +831,22 @@ func (g *Gateway) startTelegram() error {
 // startDiscord 启动Discord
 func (g *Gateway) startDiscord() error {
 	cfg := g.config.Get()
-	g.discordBot = discord.NewBot(cfg.Channels.Discord, g.log)
+	g.discordBot = discord.NewBot(cfg.Channels.Discord, g.log.Module("discord"))
 
 	// 注册消息处理器
 	g.discordBot.OnMessage(func(userID, username, content, channelID string) (string, error) {
-		return g.handleMessage("discord", userID, username, content)
+		sendFile := func(filename string, data []byte, caption string) error {
+			return g.discordBot.SendFile(channelID, filename, data, caption)
+		}
+		return g.handleMessage("discord", userID, username, content, "", channelID, sendFile)
 	})
+	if g.clusterStore != nil {
+		g.discordBot.SetClaimer(g.claim)
+	}
+	g.discordBot.SetTranslator(func(userID, key string, params i18n.Params) string {
+		return g.i18nFor("discord", userID).Tf(key, params)
+	})
+	g.discordBot.OnSendError(g.queueFailed("discord"))
 
 	if err := g.discordBot.Start(); err != nil {
 		return err
@@ -342,11 +859,18 @@ func (g *Gateway) startDiscord() error {
 // startFeishu 启动飞书
 func (g *Gateway) startFeishu() error {
 	cfg := g.config.Get()
-	g.feishuBot = feishu.NewBot(cfg.Channels.Feishu, g.log)
+	g.feishuBot = feishu.NewBot(cfg.Channels.Feishu, g.log.Module("feishu"))
 
-	g.feishuBot.OnMessage(func(userID, username, content string) (string, error) {
-		return g.handleMessage("feishu", userID, username, content)
+	g.feishuBot.OnMessage(func(userID, username, content, quoted string) (string, error) {
+		return g.handleMessage("feishu", userID, username, content, quoted, userID, nil)
+	})
+	if g.clusterStore != nil {
+		g.feishuBot.SetClaimer(g.claim)
+	}
+	g.feishuBot.SetTranslator(func(userID, key string) string {
+		return g.i18nFor("feishu", userID).T(key)
 	})
+	g.feishuBot.OnSendError(g.queueFailed("feishu"))
 
 	if err := g.feishuBot.Start(); err != nil {
 		return err
@@ -356,25 +880,79 @@ func (g *Gateway) startFeishu() error {
 	return nil
 }
 
+// channelStarted 记录渠道启动结果，渠道启动失败不影响网关运行
+func (g *Gateway) channelStarted(channel string, err error) {
+	if err != nil {
+		g.log.Error("failed to start "+channel, "error", err)
+		g.emit(Event{Event: EventChannelFailed, Channel: channel, Error: err.Error()})
+		return
+	}
+	g.emit(Event{Event: EventChannelConnected, Channel: channel})
+}
+
 // GetFeishuWebhookHandler 获取飞书Webhook处理器
 func (g *Gateway) GetFeishuWebhookHandler() http.HandlerFunc {
 	if g.feishuBot == nil {
 		return func(w http.ResponseWriter, r *http.Request) {
-			http.Error(w, "Feishu not enabled", http.StatusServiceUnavailable)
+			httpapi.RespondError(w, r, http.StatusServiceUnavailable, httpapi.CodeUnavailable, "Feishu not enabled")
 		}
 	}
 	return g.feishuBot.GetWebhookHandler()
 }
 
-// handleMessage 处理消息
-func (g *Gateway) handleMessage(channel, userID, username, content string) (string, error) {
+// sendTo 主动向渠道发送消息
+func (g *Gateway) sendTo(channel, target, text string) error {
+	switch channel {
+	case "telegram":
+		if g.telegramBot == nil {
+			return fmt.Errorf("telegram not enabled")
+		}
+		chatID, err := telegram.ParseUserID(target)
+		if err != nil {
+			return err
+		}
+		return g.telegramBot.SendMessage(chatID, text)
+	case "discord":
+		if g.discordBot == nil {
+			return fmt.Errorf("discord not enabled")
+		}
+		return g.discordBot.SendMessage(target, text)
+	case "feishu":
+		if g.feishuBot == nil {
+			return fmt.Errorf("feishu not enabled")
+		}
+		return g.feishuBot.SendMessage(target, text)
+	default:
+		if send, ok := g.connectedChannel(channel); ok {
+			return send(target, text)
+		}
+		return fmt.Errorf("unknown channel: %s", channel)
+	}
+}
+
+// handleMessage 处理消息，target 为回复目标（如 Telegram chat ID），用于主动推送
+func (g *Gateway) handleMessage(channel, userID, username, content, quoted, target string, sendFile fileSender) (string, error) {
+	if !g.beginMessage() {
+		return "", fmt.Errorf("gateway is shutting down")
+	}
+	defer g.inflight.Done()
+	defer g.trackActive(channel, userID, target)()
+
 	defer func() {
 		if r := recover(); r != nil {
-			g.log.Error("message handler panic", "error", r, "stack", string(debug.Stack()))
+			g.crash.Report("gateway:"+channel, r, debug.Stack())
 		}
 	}()
 
-	g.log.Info("message received",
+	g.crash.RecordMessage(channel, userID, len(content))
+
+	// 每条消息生成请求ID，贯穿日志、工具审计与调试消息
+	requestID := logger.NewRequestID()
+	ctx := logger.WithRequestID(g.msgCtx, requestID)
+	ctx = tools.WithCaller(ctx, tools.Caller{Channel: channel, UserID: userID, Target: target})
+	log := g.log.With("request_id", requestID)
+
+	log.Info("message received",
 		"channel", channel,
 		"user_id", userID,
 		"username", username,
@@ -385,27 +963,63 @@ func (g *Gateway) handleMessage(channel, userID, username, content string) (stri
 	g.healthCheck.RecordMessage()
 
 	// 记录调试消息
-	g.webServer.LogMessage("user", channel, content, userID, channel)
+	g.webServer.LogMessage("user", channel, content, userID, channel, requestID)
+	g.emitWebhook(webhook.EventMessageReceived, map[string]interface{}{
+		"channel":   channel,
+		"userId":    userID,
+		"username":  username,
+		"content":   content,
+		"requestId": requestID,
+	})
+
+	// 聊天命令
+	if response, handled, err := g.handleCommand(channel, userID, target, content, sendFile); handled {
+		if err != nil {
+			log.Error("failed to handle command", "error", err)
+		} else if response != "" {
+			g.emitWebhook(webhook.EventReplySent, map[string]interface{}{
+				"channel":   channel,
+				"userId":    userID,
+				"content":   response,
+				"command":   true,
+				"requestId": requestID,
+			})
+		}
+		return response, err
+	}
 
 	// 路由到智能体
 	agent, err := g.agentRouter.Route(userID, channel, "")
 	if err != nil {
-		g.log.Error("failed to route message", "error", err)
+		log.Error("failed to route message", "error", err)
 		return "", err
 	}
 
-	// 处理消息
-	response, err := g.agentRouter.ProcessMessage(agent, userID, channel, content)
+	// 处理消息，并发数达到 resources.workers 时排队
+	release, err := g.acquireWorker(ctx)
 	if err != nil {
-		g.log.Error("failed to process message", "error", err)
+		return "", err
+	}
+	response, err := g.agentRouter.ProcessMessage(ctx, agent, userID, username, channel, withQuote(content, quoted))
+	release()
+	if err != nil {
+		log.Error("failed to process message", "error", err)
 		g.healthCheck.RecordLLMFailed()
-		g.webServer.LogMessage("error", channel, err.Error(), userID, channel)
+		g.webServer.LogMessage("error", channel, err.Error(), userID, channel, requestID)
 		return "", err
 	}
 
 	// 记录成功
 	g.healthCheck.RecordLLMSuccess()
-	g.webServer.LogMessage("assistant", channel, response, userID, channel)
+	g.webServer.LogMessage("assistant", channel, response, userID, channel, requestID)
+	g.emitWebhook(webhook.EventReplySent, map[string]interface{}{
+		"channel":   channel,
+		"userId":    userID,
+		"agent":     agent.ID,
+		"content":   response,
+		"requestId": requestID,
+	})
+	g.tagIntent(channel, userID, agent.ID, content)
 
 	return response, nil
 }
@@ -441,16 +1055,89 @@ func (g *Gateway) checkHealth() {
 	}
 
 	// 检查磁盘空间
-	if g.checkDiskSpace() {
-		g.log.Warn("low disk space detected")
+	if err := g.checkDiskSpace(); err != nil {
+		g.log.Warn("low disk space detected", "error", err)
 	}
 }
 
-// checkDiskSpace 检查磁盘空间
-func (g *Gateway) checkDiskSpace() bool {
-	// 简化实现：在Windows上跳过磁盘检查
-	// 实际部署时在Linux上运行，此代码不会执行
-	return false
+// registerProbes 注册存活监控探测项
+func (g *Gateway) registerProbes() {
+	g.watchdog.AddProbe("llm", func(cfg *config.Config) error {
+		threshold := cfg.Alerts.LLMFailureThreshold
+		if threshold <= 0 {
+			threshold = 3
+		}
+		if streak := g.healthCheck.LLMFailStreak(); streak >= threshold {
+			return fmt.Errorf("llm failed %d times in a row", streak)
+		}
+		if p, ok := g.llmProvider.(llm.Pinger); ok {
+			if err := p.Ping(); err != nil {
+				return fmt.Errorf("llm endpoint unreachable: %w", err)
+			}
+		}
+		return nil
+	})
+
+	g.watchdog.AddProbe("telegram", func(cfg *config.Config) error {
+		if cfg.Channels.Telegram.Enabled && (g.telegramBot == nil || !g.telegramBot.IsRunning()) {
+			return fmt.Errorf("telegram disconnected")
+		}
+		return nil
+	})
+
+	g.watchdog.AddProbe("discord", func(cfg *config.Config) error {
+		if cfg.Channels.Discord.Enabled && (g.discordBot == nil || !g.discordBot.IsRunning()) {
+			return fmt.Errorf("discord disconnected")
+		}
+		return nil
+	})
+
+	g.watchdog.AddProbe("memory", func(cfg *config.Config) error {
+		threshold := cfg.Alerts.MemoryThresholdMB
+		if threshold <= 0 {
+			threshold = health.MaxMemoryMB
+		}
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		if heapMB := int(m.HeapAlloc / 1024 / 1024); heapMB > threshold {
+			return fmt.Errorf("heap usage %dMB exceeds %dMB", heapMB, threshold)
+		}
+		return nil
+	})
+
+	g.watchdog.AddProbe("disk", func(cfg *config.Config) error {
+		return g.checkDiskSpace()
+	})
+}
+
+// checkDiskSpace 检查工作目录、记忆目录和日志目录的磁盘空间，超过阈值时返回错误
+func (g *Gateway) checkDiskSpace() error {
+	cfg := g.config.Get()
+
+	paths := map[string]string{
+		"workDir":   cfg.Tools.WorkDir,
+		"memoryDir": cfg.Memory.MemoryDir,
+	}
+	if cfg.Logging.File != "" {
+		paths["logDir"] = filepath.Dir(cfg.Logging.File)
+	}
+	g.healthCheck.SetDiskPaths(paths)
+
+	threshold := float64(cfg.Alerts.DiskThresholdPercent)
+	if threshold <= 0 {
+		threshold = 90
+	}
+
+	var low []string
+	for _, d := range g.healthCheck.DiskUsage() {
+		if d.UsedPercent >= threshold {
+			low = append(low, fmt.Sprintf("%s %.1f%% used (%dMB free)", d.Name, d.UsedPercent, d.FreeMB()))
+		}
+	}
+	if len(low) > 0 {
+		return fmt.Errorf("low disk space: %s", strings.Join(low, ", "))
+	}
+	return nil
 }
 
 // waitForShutdown 等待关闭信号
@@ -467,7 +1154,21 @@ func (g *Gateway) waitForShutdown() {
 	g.Stop()
 }
 
-// truncate 截断字符串
+// maxQuotedLen 被回复内容放入提示词的最大字符数
+const maxQuotedLen = 1000
+
+// withQuote 用户回复某条消息时，把被回复的内容以引用形式放在消息前作为上下文
+func withQuote(content, quoted string) string {
+	quoted = strings.TrimSpace(quoted)
+	if quoted == "" {
+		return content
+	}
+	if runes := []rune(quoted); len(runes) > maxQuotedLen {
+		quoted = string(runes[:maxQuotedLen]) + "..."
+	}
+	return "[Replying to]\n> " + strings.ReplaceAll(quoted, "\n", "\n> ") + "\n\n" + content
+}
+
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
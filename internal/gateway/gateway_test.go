@@ -0,0 +1,269 @@
+package gateway
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/llm"
+	"github.com/HaohanHe/mujibot/internal/memory"
+	"github.com/HaohanHe/mujibot/internal/session"
+	"github.com/HaohanHe/mujibot/internal/testkit"
+)
+
+func newTestGateway(t *testing.T, p *testkit.Provider, overrides map[string]interface{}) (*Gateway, *testkit.Channel) {
+	t.Helper()
+	g, err := NewGatewayWithOptions(testkit.WriteConfig(t, overrides), Options{Provider: p})
+	if err != nil {
+		t.Fatalf("NewGatewayWithOptions() error = %v", err)
+	}
+	t.Cleanup(func() {
+		g.sessionMgr.Close()
+		g.config.Close()
+	})
+
+	ch := testkit.NewChannel("test")
+	handler, err := g.ConnectChannel(ch.Name, ch.Send)
+	if err != nil {
+		t.Fatalf("ConnectChannel() error = %v", err)
+	}
+	ch.Connect(handler)
+	return g, ch
+}
+
+func TestGatewayToolLoop(t *testing.T) {
+	p := testkit.NewProvider(
+		testkit.CallTool("datetime", map[string]interface{}{"action": "now", "time": "2024-05-01 14:30", "timezone": "UTC"}),
+		testkit.Reply("It is 14:30."),
+	)
+	g, ch := newTestGateway(t, p, nil)
+
+	reply, err := ch.Receive("42", "what time is it?")
+	if err != nil || reply != "It is 14:30." {
+		t.Fatalf("Receive() = %q, %v", reply, err)
+	}
+
+	reqs := p.Requests()
+	if len(reqs) != 2 || p.Remaining() != 0 {
+		t.Fatalf("requests = %d, remaining = %d", len(reqs), p.Remaining())
+	}
+	if !strings.Contains(reqs[0].System(), "You are a test assistant.") || len(reqs[0].Tools) == 0 {
+		t.Errorf("first request system = %q, tools = %d", reqs[0].System(), len(reqs[0].Tools))
+	}
+	if last := reqs[1].Last(); last.Role != "tool" || !strings.Contains(last.Content, "2024-05-01") {
+		t.Errorf("tool result = %+v", last)
+	}
+
+	// 会话保留了本轮对话，下一条消息带上历史
+	p.Push(testkit.Reply("You're welcome."))
+	if _, err := ch.Receive("42", "thanks"); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(p.Requests()[2].Messages); n != 6 {
+		t.Errorf("history messages = %d, want 6", n)
+	}
+
+	// 脚本用完时返回错误
+	if _, err := ch.Receive("42", "one more"); err == nil {
+		t.Error("expected error when script is exhausted")
+	}
+
+	if err := g.sendTo("test", "42", "reminder"); err != nil {
+		t.Fatalf("sendTo() error = %v", err)
+	}
+	if sent := ch.Sent(); len(sent) != 1 || sent[0].Target != "42" || sent[0].Text != "reminder" {
+		t.Errorf("sent = %+v", sent)
+	}
+}
+
+func TestResponseStyle(t *testing.T) {
+	p := testkit.NewProvider(testkit.Reply("Short."), testkit.Reply("Also short."), testkit.Reply("Long answer."))
+	g, ch := newTestGateway(t, p, map[string]interface{}{
+		"channels": map[string]interface{}{
+			"responses": map[string]interface{}{
+				"test": map[string]interface{}{"maxResponseTokens": 200, "verbosity": "brief"},
+			},
+		},
+	})
+
+	if _, err := ch.Receive("42", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	req := p.Requests()[0]
+	if req.Options.MaxTokens != 200 {
+		t.Errorf("max tokens = %d, want 200", req.Options.MaxTokens)
+	}
+	if sys := req.System(); !strings.Contains(sys, "Keep replies short") || !strings.Contains(sys, "about 200 tokens") {
+		t.Errorf("system prompt missing style:\n%s", sys)
+	}
+
+	// 用户资料中的 verbosity 优先于渠道设置，长度上限仍然生效
+	if _, err := g.memoryMgr.UpdateProfile("test:42", func(pr *memory.Profile) error {
+		pr.Verbosity = "detailed"
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ch.Receive("42", "hello again"); err != nil {
+		t.Fatal(err)
+	}
+	if sys := p.Requests()[1].System(); strings.Contains(sys, "Keep replies short") || !strings.Contains(sys, "about 200 tokens") {
+		t.Errorf("profile verbosity not preferred:\n%s", sys)
+	}
+
+	// 热更新后立即生效
+	next := *g.config.Get()
+	next.Channels.Responses = nil
+	g.config.Update(&next)
+	if _, err := ch.Receive("7", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if req := p.Requests()[2]; req.Options.MaxTokens != 0 || strings.Contains(req.System(), "tokens;") {
+		t.Errorf("style still applied after reload: %+v", req.Options)
+	}
+}
+
+func TestConnectChannel(t *testing.T) {
+	g, _ := newTestGateway(t, testkit.NewProvider(), nil)
+	if _, err := g.ConnectChannel("test", nil); err == nil {
+		t.Error("expected error for duplicate channel")
+	}
+	if _, err := g.ConnectChannel("telegram", nil); err == nil {
+		t.Error("expected error for built-in channel")
+	}
+	if err := g.sendTo("missing", "1", "hi"); err == nil {
+		t.Error("expected error for unknown channel")
+	}
+}
+
+// blockingProvider 在 release 关闭前阻塞每次请求
+type blockingProvider struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProvider) Chat(messages []session.Message, tools []llm.Tool) (*llm.Response, error) {
+	p.started <- struct{}{}
+	<-p.release
+	return &llm.Response{Content: "done"}, nil
+}
+
+func (p *blockingProvider) ChatStream(messages []session.Message, tools []llm.Tool, callback func(chunk string)) (*llm.Response, error) {
+	return p.Chat(messages, tools)
+}
+
+func (p *blockingProvider) GetModel() string {
+	return "blocking"
+}
+
+func TestDrain(t *testing.T) {
+	p := &blockingProvider{started: make(chan struct{}, 1), release: make(chan struct{})}
+	g, err := NewGatewayWithOptions(testkit.WriteConfig(t, map[string]interface{}{
+		"server": map[string]interface{}{"drainTimeout": 1},
+	}), Options{Provider: p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.config.Close()
+	defer g.sessionMgr.Close()
+
+	ch := testkit.NewChannel("test")
+	handler, _ := g.ConnectChannel(ch.Name, ch.Send)
+	ch.Connect(handler)
+
+	replies := make(chan string, 1)
+	go func() {
+		reply, _ := ch.Receive("42", "long task")
+		replies <- reply
+	}()
+	<-p.started
+
+	g.mu.Lock()
+	g.draining = true
+	g.mu.Unlock()
+	if _, err := ch.Receive("7", "hello"); err == nil {
+		t.Error("new messages should be rejected while draining")
+	}
+
+	g.notifyActiveChats()
+	if sent := ch.Sent(); len(sent) != 1 || sent[0].Target != "42" || !strings.Contains(sent[0].Text, "restarting") {
+		t.Errorf("restart notice = %+v", sent)
+	}
+
+	start := time.Now()
+	g.drain()
+	if elapsed := time.Since(start); elapsed < time.Second || g.msgCtx.Err() == nil {
+		t.Errorf("drain returned after %v, ctx err = %v", elapsed, g.msgCtx.Err())
+	}
+
+	close(p.release)
+	if reply := <-replies; reply != "done" {
+		t.Errorf("in-flight reply = %q", reply)
+	}
+	if n := g.activeCount(); n != 0 {
+		t.Errorf("active chats = %d", n)
+	}
+}
+
+func TestStartupEvents(t *testing.T) {
+	var events []Event
+	g, err := NewGatewayWithOptions(testkit.WriteConfig(t, nil), Options{
+		Provider: testkit.NewProvider(),
+		OnEvent:  func(e Event) { events = append(events, e) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		g.sessionMgr.Close()
+		g.config.Close()
+	})
+	if len(events) != 1 || events[0].Event != EventConfigLoaded || events[0].Path == "" || events[0].Time.IsZero() {
+		t.Fatalf("events = %+v", events)
+	}
+
+	g.channelStarted("telegram", nil)
+	g.channelStarted("discord", errors.New("invalid token"))
+	if len(events) != 3 || events[1].Event != EventChannelConnected || events[2].Event != EventChannelFailed || events[2].Error != "invalid token" {
+		t.Errorf("channel events = %+v", events[1:])
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	_, err := NewGatewayWithOptions(filepath.Join(t.TempDir(), "missing.json5"), Options{Provider: testkit.NewProvider()})
+	if ErrorComponent(err) != ComponentConfig || ExitCode(err) != 4 {
+		t.Errorf("missing config: component = %q, code = %d, err = %v", ErrorComponent(err), ExitCode(err), err)
+	}
+
+	cfg := testkit.WriteConfig(t, map[string]interface{}{
+		"tools": map[string]interface{}{"policyFile": filepath.Join(t.TempDir(), "missing.json")},
+	})
+	_, err = NewGatewayWithOptions(cfg, Options{Provider: testkit.NewProvider()})
+	if ErrorComponent(err) != ComponentTools || ExitCode(err) != 10 {
+		t.Errorf("missing policy: component = %q, code = %d, err = %v", ErrorComponent(err), ExitCode(err), err)
+	}
+
+	// 已标记的错误不会被外层改写
+	wrapped := startupError(ComponentStorage, startupError(ComponentLLM, errors.New("boom")))
+	if ExitCode(wrapped) != 11 || ExitCode(errors.New("other")) != ExitError || ExitCode(nil) != 0 {
+		t.Errorf("ExitCode(wrapped) = %d", ExitCode(wrapped))
+	}
+}
+
+func TestCommandLanguage(t *testing.T) {
+	_, ch := newTestGateway(t, testkit.NewProvider(), nil)
+
+	reply, err := ch.Receive("42", "/lang zh-CN")
+	if err != nil || !strings.HasPrefix(reply, "语言：") {
+		t.Fatalf("/lang reply = %q, %v", reply, err)
+	}
+	// 之后的命令回复使用用户设置的语言，其他用户不受影响
+	if reply, _ := ch.Receive("42", "/safemode maybe"); reply != "用法：/safemode [on|off]" {
+		t.Errorf("/safemode usage = %q", reply)
+	}
+	if reply, _ := ch.Receive("7", "/safemode maybe"); reply != "Usage: /safemode [on|off]" {
+		t.Errorf("/safemode usage for another user = %q", reply)
+	}
+}
@@ -0,0 +1,35 @@
+package gateway
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/httpclient"
+)
+
+// initHTTP 按配置设置共享的出站HTTP连接池，配置热更新时重新设置
+func (g *Gateway) initHTTP() error {
+	if err := httpclient.Configure(httpConfig(g.config.Get().HTTP)); err != nil {
+		return fmt.Errorf("invalid http config: %w", err)
+	}
+	g.config.OnChange(func(c *config.Config) {
+		if err := httpclient.Configure(httpConfig(c.HTTP)); err != nil {
+			g.log.Error("failed to apply http config", "error", err)
+		}
+	})
+	return nil
+}
+
+// httpConfig 转换出站HTTP配置
+func httpConfig(c config.HTTPConfig) httpclient.Config {
+	return httpclient.Config{
+		Proxy:               c.Proxy,
+		Proxies:             c.Proxies,
+		NoProxy:             c.NoProxy,
+		CABundle:            c.CABundle,
+		MaxIdleConns:        c.MaxIdleConns,
+		MaxIdleConnsPerHost: c.MaxIdleConnsPerHost,
+		IdleConnTimeout:     time.Duration(c.IdleConnTimeout) * time.Second,
+	}
+}
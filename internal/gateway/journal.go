@@ -0,0 +1,113 @@
+package gateway
+
+import (
+	"context"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/session"
+)
+
+// journalTimeout 退出时等待会话摘要写完的最长时间
+const journalTimeout = 30 * time.Second
+
+// journalSession 会话结束时在后台把对话摘要写入每日笔记（memory.autoJournal），
+// 会话因空闲、淘汰或退出结束时保存用户的上次对话摘要（memory.recap），定时任务的会话不记录
+func (g *Gateway) journalSession(c session.Closed) {
+	cfg := g.config.Get().Memory
+	journal := cfg.AutoJournal.Enabled
+	recap := cfg.Recap.Enabled && (c.Reason == session.CloseIdle || c.Reason == session.CloseEvicted || c.Reason == session.CloseShutdown)
+	if !journal && !recap || c.Channel == "scheduler" {
+		return
+	}
+	agent, ok := g.agentRouter.GetAgent(c.AgentID)
+	if !ok {
+		return
+	}
+
+	ctx, finish := g.startJob(c.Channel + ":" + c.UserID)
+	go func() {
+		defer finish()
+		if journal {
+			if err := agent.Journal(ctx, c, cfg.AutoJournal.MinMessages, cfg.AutoJournal.MaxChars); err != nil {
+				g.log.Warn("failed to journal session", "session", c.ID, "error", err)
+			}
+		}
+		if recap && ctx.Err() == nil {
+			if err := agent.Recap(ctx, c, cfg.Recap.MinMessages, cfg.Recap.MaxChars); err != nil {
+				g.log.Warn("failed to save session recap", "session", c.ID, "error", err)
+			}
+		}
+	}()
+}
+
+// waitJournals 等待后台的会话摘要写完
+func (g *Gateway) waitJournals() {
+	done := make(chan struct{})
+	go func() {
+		g.journals.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(journalTimeout):
+		g.log.Warn("timed out waiting for session journals", "timeout", journalTimeout.String())
+	}
+}
+
+// backgroundJob 用户的一个后台任务（会话摘要、意图标注）
+type backgroundJob struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startJob 为用户登记后台任务。返回的 ctx 在清除该用户数据时取消，任务结束时须调用 finish
+func (g *Gateway) startJob(owner string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &backgroundJob{cancel: cancel, done: make(chan struct{})}
+
+	g.jobsMu.Lock()
+	if g.jobs == nil {
+		g.jobs = make(map[string]map[*backgroundJob]struct{})
+	}
+	if g.jobs[owner] == nil {
+		g.jobs[owner] = make(map[*backgroundJob]struct{})
+	}
+	g.jobs[owner][job] = struct{}{}
+	g.jobsMu.Unlock()
+	g.journals.Add(1)
+
+	return ctx, func() {
+		g.jobsMu.Lock()
+		delete(g.jobs[owner], job)
+		if len(g.jobs[owner]) == 0 {
+			delete(g.jobs, owner)
+		}
+		g.jobsMu.Unlock()
+		cancel()
+		close(job.done)
+		g.journals.Done()
+	}
+}
+
+// stopJobs 取消用户的后台任务并等待结束，最多等待 journalTimeout。
+// 任务在写入前检查 ctx，超时后仍在运行的任务也不会写回数据
+func (g *Gateway) stopJobs(owner string) {
+	g.jobsMu.Lock()
+	jobs := g.jobs[owner]
+	delete(g.jobs, owner)
+	g.jobsMu.Unlock()
+
+	timeout := time.After(journalTimeout)
+	for job := range jobs {
+		job.cancel()
+	}
+	for job := range jobs {
+		select {
+		case <-job.done:
+		case <-timeout:
+			g.log.Warn("timed out waiting for background jobs", "user", owner, "timeout", journalTimeout.String())
+			return
+		}
+	}
+}
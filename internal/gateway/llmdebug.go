@@ -0,0 +1,37 @@
+package gateway
+
+import (
+	"fmt"
+
+	"github.com/HaohanHe/mujibot/internal/llm"
+)
+
+// enableLLMDebugLog 按 llm.debugLog 记录脱敏后的请求/响应，配置了文件时写入文件，否则输出到Web调试控制台
+func (g *Gateway) enableLLMDebugLog(p llm.Provider) {
+	llm.EnableDebugLog(p, llm.DebugOptions{
+		Enabled:      func() bool { return g.config.Get().LLM.DebugLog.Enabled },
+		MaxBodyChars: g.config.Get().LLM.DebugLog.MaxBodyChars,
+		Sink:         g.logLLMCall,
+	})
+}
+
+// logLLMCall 输出一条LLM调用记录
+func (g *Gateway) logLLMCall(e llm.DebugEntry) {
+	if path := g.config.Get().LLM.DebugLog.File; path != "" {
+		if err := g.llmDebug.Write(path, e); err != nil {
+			g.log.Warn("failed to write llm debug log", "path", path, "error", err)
+		}
+		return
+	}
+	if g.webServer == nil {
+		return
+	}
+
+	content := fmt.Sprintf("%s %s -> %d (%dms)\n>>> %s", e.Method, e.URL, e.Status, e.DurationMs, e.Request)
+	if e.Error != "" {
+		content += "\n!!! " + e.Error
+	} else {
+		content += "\n<<< " + e.Response
+	}
+	g.webServer.LogMessage("llm", "llm", content, "", "", "")
+}
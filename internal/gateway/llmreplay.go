@@ -0,0 +1,33 @@
+package gateway
+
+import (
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/llm"
+)
+
+// llmReplayFile 返回 llm.replay.file，默认 ./llm_recordings.jsonl
+func (g *Gateway) llmReplayFile(cfg *config.Config) string {
+	if cfg.LLM.Replay.File != "" {
+		return cfg.LLM.Replay.File
+	}
+	return "./llm_recordings.jsonl"
+}
+
+// wrapLLMReplay 按 llm.replay 记录或回放提供商的交互，需在 UseProxy 和 enableLLMDebugLog 之后调用
+func (g *Gateway) wrapLLMReplay(cfg *config.Config, p llm.Provider) (llm.Provider, error) {
+	file := g.llmReplayFile(cfg)
+	switch cfg.LLM.Replay.Mode {
+	case "record":
+		g.llmRecords.Path = file
+		return llm.NewRecorder(p, &g.llmRecords, func(err error) {
+			g.log.Warn("failed to record llm call", "path", file, "error", err)
+		}), nil
+	case "replay":
+		r, err := llm.NewReplayer(file, p.GetModel())
+		if err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+	return p, nil
+}
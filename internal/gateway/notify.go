@@ -0,0 +1,43 @@
+package gateway
+
+import (
+	"github.com/HaohanHe/mujibot/internal/notify"
+	"github.com/HaohanHe/mujibot/internal/webhook"
+)
+
+// notify 通过通知路由发送，路由按规则决定发到哪里、是否推迟或合并。告警同时推送出站Webhook
+func (g *Gateway) notify(e notify.Event) error {
+	if e.Type == notify.EventAlert {
+		g.emitWebhook(webhook.EventAlert, map[string]interface{}{"severity": e.Severity, "text": e.Text})
+	}
+	if g.notifications == nil {
+		if e.Direct {
+			return g.sendTo(e.Channel, e.Target, e.Text)
+		}
+		return g.send(e.Channel, e.Target, e.Text)
+	}
+	return g.notifications.Notify(e)
+}
+
+// notifySender 返回按指定事件类型和级别路由的发送函数，供只接受渠道和目标的组件使用
+func (g *Gateway) notifySender(eventType, severity string) func(channel, target, text string) error {
+	return func(channel, target, text string) error {
+		return g.notify(notify.Event{Type: eventType, Severity: severity, Channel: channel, Target: target, Text: text})
+	}
+}
+
+// notifyAdmin 向告警渠道发送管理员通知
+func (g *Gateway) notifyAdmin(severity, text string) {
+	cfg := g.config.Get()
+	err := g.notify(notify.Event{
+		Type:     notify.EventAlert,
+		Severity: severity,
+		Channel:  cfg.Alerts.Channel,
+		Target:   cfg.Alerts.Target,
+		Text:     text,
+		Direct:   true,
+	})
+	if err != nil {
+		g.log.Error("failed to notify admin", "error", err)
+	}
+}
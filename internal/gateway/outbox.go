@@ -0,0 +1,60 @@
+package gateway
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/encryption"
+	"github.com/HaohanHe/mujibot/internal/notify"
+	"github.com/HaohanHe/mujibot/internal/outbox"
+)
+
+// initOutbox 创建发件箱，发送失败的回复和推送稍后重试
+func (g *Gateway) initOutbox(cipher *encryption.Cipher) error {
+	cfg := g.config.Get().Outbox
+	if !cfg.Enabled {
+		return nil
+	}
+	o, err := outbox.New(outbox.Config{
+		File:        cfg.File,
+		MaxAttempts: cfg.MaxAttempts,
+		RetryDelay:  time.Duration(cfg.RetryDelay) * time.Second,
+		Cipher:      cipher,
+	}, g.sendTo, g.log.Module("outbox"))
+	if err != nil {
+		return fmt.Errorf("failed to create outbox: %w", err)
+	}
+	o.OnDrop(g.outboxDropped)
+	g.outbox = o
+	return nil
+}
+
+// send 主动推送消息，启用发件箱时失败的消息稍后重试
+func (g *Gateway) send(channel, target, text string) error {
+	if g.outbox == nil {
+		return g.sendTo(channel, target, text)
+	}
+	return g.outbox.Send(channel, target, text)
+}
+
+// queueFailed 返回渠道回复发送失败时的处理函数，启用发件箱时放入发件箱
+func (g *Gateway) queueFailed(channel string) func(target, text string, err error) {
+	return func(target, text string, err error) {
+		if g.outbox == nil {
+			return
+		}
+		if qerr := g.outbox.Enqueue(channel, target, text, err); qerr != nil {
+			g.log.Error("failed to queue message", "channel", channel, "error", qerr)
+		}
+	}
+}
+
+// outboxDropped 重试次数用尽后通知管理员，告警渠道本身的消息不再通知
+func (g *Gateway) outboxDropped(m outbox.Message) {
+	cfg := g.config.Get()
+	if m.Channel == cfg.Alerts.Channel && m.Target == cfg.Alerts.Target {
+		return
+	}
+	g.notifyAdmin(notify.SeverityWarning, fmt.Sprintf("📭 Failed to deliver message to %s:%s after %d attempts: %s\n%s",
+		m.Channel, m.Target, m.Attempts, m.LastError, truncate(m.Text, 200)))
+}
@@ -0,0 +1,140 @@
+package gateway
+
+import (
+	"strings"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/i18n"
+	"github.com/HaohanHe/mujibot/internal/todo"
+	"github.com/HaohanHe/mujibot/internal/web"
+)
+
+// purgeConfirmTimeout /forgetme 发出后等待确认的时间
+const purgeConfirmTimeout = 5 * time.Minute
+
+// forgetMeCommand 清除用户自己的全部数据: /forgetme 发起，/forgetme confirm 确认，/forgetme cancel 取消
+func (g *Gateway) forgetMeCommand(channel, userID string, args []string) (string, error) {
+	t := g.i18nFor(channel, userID)
+	owner := channel + ":" + userID
+
+	action := ""
+	if len(args) > 0 {
+		action = strings.ToLower(args[0])
+	}
+
+	g.purgeMu.Lock()
+	expires, pending := g.purgeRequests[owner]
+	if pending && time.Now().After(expires) {
+		pending = false
+	}
+	switch action {
+	case "":
+		if g.purgeRequests == nil {
+			g.purgeRequests = make(map[string]time.Time)
+		}
+		g.purgeRequests[owner] = time.Now().Add(purgeConfirmTimeout)
+		g.purgeMu.Unlock()
+		return t.Tf("forgetMeConfirm", i18n.Params{"timeout": purgeConfirmTimeout}), nil
+	case "confirm", "cancel":
+		delete(g.purgeRequests, owner)
+		g.purgeMu.Unlock()
+	default:
+		g.purgeMu.Unlock()
+		return t.T("forgetMeUsage"), nil
+	}
+
+	if !pending {
+		return t.T("forgetMeNone"), nil
+	}
+	if action == "cancel" {
+		return t.T("forgetMeCancelled"), nil
+	}
+	if _, err := g.purgeUser(channel, userID, owner); err != nil {
+		return "", err
+	}
+	return t.T("forgetMeDone"), nil
+}
+
+// purgeUser 删除用户的会话、记忆（置顶、资料、每日笔记中的对话摘要、待办、通讯录）、订阅、个人目录中的文件及其编辑历史、
+// 工具调用记录、调试消息、对话分析中的用户统计和发往该用户的待重发消息。删除前先取消该用户进行中的后台摘要和意图标注。
+// 出错时继续删除其余数据并返回第一个错误。by 为操作者，记录在日志中
+func (g *Gateway) purgeUser(channel, userID, by string) (web.PurgeReport, error) {
+	owner := channel + ":" + userID
+	report := web.PurgeReport{User: owner}
+
+	var firstErr error
+	keep := func(what string, err error) {
+		if err == nil {
+			return
+		}
+		g.log.Error("failed to purge user data", "user", owner, "data", what, "error", err)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	report.Sessions = g.sessionMgr.DeleteUser(userID, channel)
+	// 会话已删除，不会再有新的摘要任务；取消进行中的任务，避免清除后写回
+	g.stopJobs(owner)
+
+	var err error
+	report.Memory, err = g.memoryMgr.PurgeOwner(owner)
+	keep("memory", err)
+	report.Journal, err = g.memoryMgr.DeleteJournal(owner)
+	keep("journal", err)
+	if g.saved != nil {
+		report.Conversations, err = g.saved.DeleteOwner(owner)
+		keep("conversations", err)
+	}
+	if g.todos != nil {
+		report.Todos, err = g.todos.Delete(todo.Owner{Channel: channel, UserID: userID})
+		keep("todos", err)
+	}
+	if g.contacts != nil {
+		report.Contacts, err = g.contacts.Delete(owner)
+		keep("contacts", err)
+	}
+	if g.feeds != nil {
+		report.Feeds, err = g.feeds.DeleteUser(channel, userID)
+		keep("feeds", err)
+	}
+	report.Edits, err = g.toolMgr.DeleteUserEdits(channel, userID)
+	keep("edits", err)
+	report.Files, err = g.toolMgr.DeleteUserFiles(channel, userID)
+	keep("files", err)
+	report.Audit, report.DebugMessages, err = g.webServer.DeleteUserMessages(channel, userID)
+	keep("debug messages", err)
+	if g.analytics != nil {
+		report.Analytics, err = g.analytics.DeleteUser(channel, userID)
+		keep("analytics", err)
+	}
+	// 私聊的目标就是用户ID，群聊中的消息不属于单个用户，不删除
+	if g.outbox != nil {
+		report.Outbox, err = g.outbox.DeleteTarget(channel, userID)
+		keep("outbox", err)
+	}
+
+	g.purgeMu.Lock()
+	delete(g.purgeRequests, owner)
+	g.purgeMu.Unlock()
+
+	g.log.Info("user data purged",
+		"user", owner,
+		"by", by,
+		"sessions", report.Sessions,
+		"memory", report.Memory,
+		"journal", report.Journal,
+		"conversations", report.Conversations,
+		"todos", report.Todos,
+		"contacts", report.Contacts,
+		"feeds", report.Feeds,
+		"files", report.Files,
+		"edits", report.Edits,
+		"audit", report.Audit,
+		"debug_messages", report.DebugMessages,
+		"analytics", report.Analytics,
+		"outbox", report.Outbox,
+		"ok", firstErr == nil,
+	)
+	return report, firstErr
+}
@@ -0,0 +1,71 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/llm"
+	"github.com/HaohanHe/mujibot/internal/session"
+	"github.com/HaohanHe/mujibot/internal/tools"
+)
+
+const toolSummaryPrompt = `Condense the output of the %q tool below for an assistant that will continue the task.
+Keep errors, warnings, exit codes, counts, numbers, file paths and anything that looks like a final result; drop repetitive progress lines.
+Stay under %d characters. Output only the condensed text.`
+
+// summaryProvider 返回摘要、分类等辅助任务使用的提供商，配置了 llm.summaryModel 时用该模型，否则用主模型。
+// 通过 Options.Provider 指定了提供商时始终用主模型
+func (g *Gateway) summaryProvider(cfg *config.Config, main llm.Provider) (llm.Provider, error) {
+	if cfg.LLM.SummaryModel == "" || cfg.LLM.SummaryModel == cfg.LLM.Model || g.opts.Provider != nil {
+		return main, nil
+	}
+	p, err := llm.NewProvider(
+		cfg.LLM.Provider,
+		cfg.LLM.APIKey,
+		cfg.LLM.BaseURL,
+		cfg.LLM.SummaryModel,
+		cfg.LLM.Timeout,
+		cfg.LLM.MaxRetries,
+		g.log.Module("llm"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create summary llm provider: %w", err)
+	}
+	if err := llm.UseProxy(p, cfg.LLM.Proxy); err != nil {
+		return nil, fmt.Errorf("invalid llm.proxy: %w", err)
+	}
+	g.enableLLMDebugLog(p)
+	return g.wrapLLMReplay(cfg, p)
+}
+
+// toolSummarizer 为 tools.postProcess 的 summarize 步骤创建摘要函数
+func (g *Gateway) toolSummarizer(cfg *config.Config, main llm.Provider) (tools.Summarizer, error) {
+	provider, err := g.summaryProvider(cfg, main)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, tool, text string, maxChars int) (string, error) {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		resp, err := provider.Chat([]session.Message{
+			{Role: "system", Content: fmt.Sprintf(toolSummaryPrompt, tool, maxChars)},
+			{Role: "user", Content: text},
+		}, nil)
+		if err != nil {
+			return "", err
+		}
+		return resp.Content, nil
+	}, nil
+}
+
+// toolPostProcess 转换 tools.postProcess 配置
+func toolPostProcess(cfg map[string]config.PostProcessConfig) map[string]tools.PostProcessConfig {
+	result := make(map[string]tools.PostProcessConfig, len(cfg))
+	for name, pp := range cfg {
+		result[name] = tools.PostProcessConfig{Steps: pp.Steps, MaxChars: pp.MaxChars}
+	}
+	return result
+}
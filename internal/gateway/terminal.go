@@ -0,0 +1,50 @@
+package gateway
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/tools"
+)
+
+// terminalSessionRe 匹配消息中的终端会话ID
+var terminalSessionRe = regexp.MustCompile(`\bterm_\d+\b`)
+
+// terminalReply 用户回复提到终端会话的消息时，把回复内容作为一行输入写入该会话。
+// 被回复的消息不含会话ID、回复内容是命令或终端未启用时不处理。
+// 输入与工具的 input 操作一样按策略检查，危险命令的确认请求发给回复的用户；
+// 网页或定时任务启动的会话没有所有者，只有管理员可以从聊天输入
+func (g *Gateway) terminalReply(channel, userID, target, content, replyTo string) (string, bool, error) {
+	id := terminalSessionRe.FindString(replyTo)
+	if id == "" || strings.HasPrefix(content, "/") {
+		return "", false, nil
+	}
+	tool, ok := g.toolMgr.Get("terminal")
+	if !ok {
+		return "", false, nil
+	}
+	term, ok := tool.(*tools.TerminalTool)
+	if !ok {
+		return "", false, nil
+	}
+
+	for _, s := range term.Sessions() {
+		if s.ID == id && s.Owner == "" && !g.isAdmin(channel, userID) {
+			g.log.Warn("terminal input from chat refused", "session", id, "channel", channel, "user_id", userID)
+			return "", true, fmt.Errorf("session %s was not started from this chat, only administrators can send input to it", id)
+		}
+	}
+
+	requestID := logger.NewRequestID()
+	ctx := logger.WithRequestID(g.msgCtx, requestID)
+	ctx = tools.WithCaller(ctx, tools.Caller{Channel: channel, UserID: userID, Target: target})
+	g.log.Info("terminal input from chat", "session", id, "channel", channel, "user_id", userID, "request_id", requestID)
+	output, err := term.ExecuteContext(ctx, map[string]interface{}{
+		"action":    "input",
+		"sessionId": id,
+		"input":     content,
+	})
+	return output, true, err
+}
@@ -0,0 +1,60 @@
+package gateway
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/testkit"
+	"github.com/HaohanHe/mujibot/internal/tools"
+)
+
+func TestTerminalReply(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	policyFile := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(policyFile, []byte(`{"rules": [{"name": "no-passwd", "command": "passwd", "action": "deny"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	g, _ := newTestGateway(t, testkit.NewProvider(), map[string]interface{}{
+		"admins": []string{"test:99"},
+		"tools":  map[string]interface{}{"terminalEnabled": true, "policyFile": policyFile},
+	})
+	tool, _ := g.toolMgr.Get("terminal")
+	term := tool.(*tools.TerminalTool)
+	run := func(ctx context.Context) string {
+		out, err := term.ExecuteContext(ctx, map[string]interface{}{"action": "run", "command": "cat", "background": true})
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		id := regexp.MustCompile(`term_\d+`).FindString(out)
+		t.Cleanup(func() { term.Cancel(id) })
+		return id
+	}
+
+	// 网页或定时任务启动的会话没有所有者，只有管理员可以输入
+	unowned := run(context.Background())
+	if _, handled, err := g.terminalReply("test", "42", "42", "hello", "started "+unowned); !handled || err == nil || !strings.Contains(err.Error(), "administrators") {
+		t.Errorf("non-admin input to unowned session: handled = %v, err = %v", handled, err)
+	}
+	if out, _, err := g.terminalReply("test", "99", "99", "hello", "started "+unowned); err != nil || !strings.Contains(out, "hello") {
+		t.Errorf("admin input = %q, %v", out, err)
+	}
+
+	// 与工具的 input 操作一样按策略检查
+	owned := run(tools.WithCaller(context.Background(), tools.Caller{Channel: "test", UserID: "42"}))
+	if _, _, err := g.terminalReply("test", "42", "42", "passwd root", owned); err == nil || !strings.Contains(err.Error(), "no-passwd") {
+		t.Errorf("denied input error = %v", err)
+	}
+	if _, _, err := g.terminalReply("test", "7", "7", "hello", owned); err == nil || !strings.Contains(err.Error(), "another user") {
+		t.Errorf("input from another user error = %v", err)
+	}
+	if out, _, err := g.terminalReply("test", "42", "42", "hi there", owned); err != nil || !strings.Contains(out, "hi there") {
+		t.Errorf("owner input = %q, %v", out, err)
+	}
+}
@@ -0,0 +1,39 @@
+package gateway
+
+import (
+	"context"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/tools"
+	"github.com/HaohanHe/mujibot/internal/webhook"
+)
+
+// emitWebhook 推送出站Webhook事件，没有地址订阅时忽略
+func (g *Gateway) emitWebhook(event string, data map[string]interface{}) {
+	if g.webhooks != nil {
+		g.webhooks.Emit(event, data)
+	}
+}
+
+// observeTool 把工具事件交给调试控制台，工具执行完成时推送 tool_executed。
+// 参数和结果可能含敏感内容，不随事件发出
+func (g *Gateway) observeTool(ctx context.Context, e tools.ToolEvent) {
+	g.webServer.LogToolEvent(ctx, e)
+	if e.Type != tools.ToolResultEvent {
+		return
+	}
+	data := map[string]interface{}{
+		"tool":       e.Tool,
+		"ok":         e.Err == nil,
+		"durationMs": e.Duration.Milliseconds(),
+		"requestId":  logger.RequestID(ctx),
+	}
+	if e.Err != nil {
+		data["error"] = e.Err.Error()
+	}
+	if c, ok := tools.CallerFrom(ctx); ok {
+		data["channel"] = c.Channel
+		data["userId"] = c.UserID
+	}
+	g.emitWebhook(webhook.EventToolExecuted, data)
+}
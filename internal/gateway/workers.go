@@ -0,0 +1,16 @@
+package gateway
+
+import "context"
+
+// acquireWorker 占用一个处理名额，未限制并发时立即返回。ctx 取消（退出超时）时放弃等待
+func (g *Gateway) acquireWorker(ctx context.Context) (func(), error) {
+	if g.workers == nil {
+		return func() {}, nil
+	}
+	select {
+	case g.workers <- struct{}{}:
+		return func() { <-g.workers }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
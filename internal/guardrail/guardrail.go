@@ -0,0 +1,288 @@
+package guardrail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/httpclient"
+	"github.com/HaohanHe/mujibot/internal/i18n"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+// Stage 检查阶段
+type Stage string
+
+const (
+	StageInput  Stage = "input"  // 发送给LLM之前
+	StageOutput Stage = "output" // 返回给用户之前
+)
+
+// Violation 违规信息
+type Violation struct {
+	Stage   Stage  `json:"stage"`
+	Checker string `json:"checker"`
+	Rule    string `json:"rule"`
+}
+
+// Checker 内容检查器，可通过 Engine.Register 扩展
+type Checker interface {
+	Name() string
+	Check(stage Stage, channel, text string) (*Violation, error)
+}
+
+// Engine 内容安全引擎
+type Engine struct {
+	config   *config.Manager
+	checkers []Checker
+	mu       sync.RWMutex
+	client   *http.Client
+	log      *logger.Logger
+
+	// 按配置快照缓存编译后的规则
+	rulesMu  sync.Mutex
+	rulesCfg *config.Config
+	rules    map[string]*ruleSet
+}
+
+// ruleSet 某个渠道生效的关键词与正则规则
+type ruleSet struct {
+	blocklist []string
+	patterns  []*regexp.Regexp
+}
+
+// New 创建内容安全引擎
+func New(cfg *config.Manager, log *logger.Logger) *Engine {
+	e := &Engine{
+		config: cfg,
+		client: httpclient.New(10 * time.Second),
+		log:    log,
+	}
+	e.Register(&moderationChecker{config: cfg, client: e.client})
+	return e
+}
+
+// Register 注册自定义检查器
+func (e *Engine) Register(c Checker) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.checkers = append(e.checkers, c)
+}
+
+// CheckInput 检查用户输入
+func (e *Engine) CheckInput(ctx context.Context, channel, userID, text string) *Violation {
+	return e.check(ctx, StageInput, channel, userID, text)
+}
+
+// CheckOutput 检查助手回复
+func (e *Engine) CheckOutput(ctx context.Context, channel, userID, text string) *Violation {
+	return e.check(ctx, StageOutput, channel, userID, text)
+}
+
+// Refusal 返回拒绝回复文案，lang 为用户语言，为空时使用全局语言
+func (e *Engine) Refusal(lang string) string {
+	cfg := e.config.Get()
+	if cfg.Guardrails.RefusalMessage != "" {
+		return cfg.Guardrails.RefusalMessage
+	}
+	if lang == "" {
+		lang = cfg.Language.Current
+	}
+	return i18n.New(lang).T("guardrailRefusal")
+}
+
+// check 执行指定阶段的检查
+func (e *Engine) check(ctx context.Context, stage Stage, channel, userID, text string) *Violation {
+	cfg := e.config.Get()
+	if !cfg.Guardrails.Enabled || text == "" {
+		return nil
+	}
+
+	policy := cfg.Guardrails.Channels[channel]
+	if !stageEnabled(policy.Mode, stage) {
+		return nil
+	}
+
+	v := e.checkRules(cfg, channel, text)
+	if v == nil {
+		e.mu.RLock()
+		checkers := make([]Checker, len(e.checkers))
+		copy(checkers, e.checkers)
+		e.mu.RUnlock()
+
+		for _, c := range checkers {
+			violation, err := c.Check(stage, channel, text)
+			if err != nil {
+				e.log.Ctx(ctx).Warn("guardrail checker failed", "checker", c.Name(), "error", err)
+				continue
+			}
+			if violation != nil {
+				v = violation
+				break
+			}
+		}
+	}
+
+	if v != nil {
+		v.Stage = stage
+		e.log.Ctx(ctx).Warn("guardrail violation",
+			"stage", stage,
+			"channel", channel,
+			"user_id", userID,
+			"checker", v.Checker,
+			"rule", v.Rule,
+		)
+	}
+	return v
+}
+
+// checkRules 关键词与正则检查
+func (e *Engine) checkRules(cfg *config.Config, channel, text string) *Violation {
+	rules := e.rulesFor(cfg, channel)
+	lower := strings.ToLower(text)
+
+	for _, word := range rules.blocklist {
+		if strings.Contains(lower, word) {
+			return &Violation{Checker: "blocklist", Rule: word}
+		}
+	}
+
+	for _, re := range rules.patterns {
+		if re.MatchString(text) {
+			return &Violation{Checker: "pattern", Rule: re.String()}
+		}
+	}
+
+	return nil
+}
+
+// rulesFor 获取渠道规则，配置变化时重新编译
+func (e *Engine) rulesFor(cfg *config.Config, channel string) *ruleSet {
+	e.rulesMu.Lock()
+	defer e.rulesMu.Unlock()
+
+	if e.rulesCfg != cfg {
+		e.rulesCfg = cfg
+		e.rules = make(map[string]*ruleSet)
+	}
+
+	if rs, ok := e.rules[channel]; ok {
+		return rs
+	}
+
+	g := cfg.Guardrails
+	policy := g.Channels[channel]
+
+	rs := &ruleSet{}
+	for _, word := range append(append([]string{}, g.Blocklist...), policy.Blocklist...) {
+		if word = strings.TrimSpace(word); word != "" {
+			rs.blocklist = append(rs.blocklist, strings.ToLower(word))
+		}
+	}
+	for _, p := range append(append([]string{}, g.Patterns...), policy.Patterns...) {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			e.log.Warn("invalid guardrail pattern", "pattern", p, "error", err)
+			continue
+		}
+		rs.patterns = append(rs.patterns, re)
+	}
+
+	e.rules[channel] = rs
+	return rs
+}
+
+// stageEnabled 判断渠道策略是否启用该阶段
+func stageEnabled(mode string, stage Stage) bool {
+	switch mode {
+	case "off":
+		return false
+	case "input":
+		return stage == StageInput
+	case "output":
+		return stage == StageOutput
+	default:
+		return true
+	}
+}
+
+// moderationChecker 调用OpenAI兼容的审核API
+type moderationChecker struct {
+	config *config.Manager
+	client *http.Client
+}
+
+func (c *moderationChecker) Name() string {
+	return "moderation"
+}
+
+func (c *moderationChecker) Check(stage Stage, channel, text string) (*Violation, error) {
+	mod := c.config.Get().Guardrails.Moderation
+	if !mod.Enabled || mod.URL == "" {
+		return nil, nil
+	}
+
+	reqBody := map[string]interface{}{"input": text}
+	if mod.Model != "" {
+		reqBody["model"] = mod.Model
+	}
+	data, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", mod.URL, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if mod.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+mod.APIKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("moderation api error: %s - %s", resp.Status, string(body))
+	}
+
+	var result struct {
+		Results []struct {
+			Flagged    bool            `json:"flagged"`
+			Categories map[string]bool `json:"categories"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	for _, r := range result.Results {
+		if !r.Flagged {
+			continue
+		}
+		var flagged []string
+		for category, hit := range r.Categories {
+			if hit {
+				flagged = append(flagged, category)
+			}
+		}
+		sort.Strings(flagged)
+		return &Violation{Checker: c.Name(), Rule: strings.Join(flagged, ",")}, nil
+	}
+
+	return nil, nil
+}
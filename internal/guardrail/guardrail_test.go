@@ -0,0 +1,93 @@
+package guardrail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func newTestEngine(t *testing.T, guardrails string) *Engine {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	t.Cleanup(func() { log.Close() })
+
+	configPath := filepath.Join(t.TempDir(), "config.json5")
+	content := `{
+		"llm": {"provider": "ollama"},
+		"language": {"current": "en-US"},
+		"guardrails": ` + guardrails + `
+	}`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := config.NewManager(configPath, log)
+	if err != nil {
+		t.Fatalf("failed to create config manager: %v", err)
+	}
+	t.Cleanup(func() { cfg.Close() })
+
+	return New(cfg, log)
+}
+
+func TestEngineCheck(t *testing.T) {
+	e := newTestEngine(t, `{
+		"enabled": true,
+		"blocklist": ["forbidden"],
+		"patterns": ["\\d{4}-\\d{4}-\\d{4}-\\d{4}"],
+		"channels": {
+			"web": {"mode": "off"},
+			"discord": {"mode": "output", "blocklist": ["spoiler"]}
+		}
+	}`)
+
+	tests := []struct {
+		name    string
+		stage   Stage
+		channel string
+		text    string
+		blocked bool
+	}{
+		{"clean input", StageInput, "telegram", "hello there", false},
+		{"blocklist case insensitive", StageInput, "telegram", "This is FORBIDDEN", true},
+		{"pattern", StageOutput, "telegram", "card 1234-5678-9012-3456", true},
+		{"channel off", StageInput, "web", "forbidden", false},
+		{"output only skips input", StageInput, "discord", "spoiler", false},
+		{"channel blocklist", StageOutput, "discord", "big spoiler", true},
+		{"channel blocklist not global", StageOutput, "telegram", "big spoiler", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := e.check(context.Background(), tt.stage, tt.channel, "u1", tt.text)
+			if (v != nil) != tt.blocked {
+				t.Errorf("check(%q) blocked = %v, want %v", tt.text, v != nil, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestEngineDisabled(t *testing.T) {
+	e := newTestEngine(t, `{"enabled": false, "blocklist": ["forbidden"]}`)
+	if v := e.CheckInput(context.Background(), "telegram", "u1", "forbidden"); v != nil {
+		t.Errorf("disabled engine should not block, got %+v", v)
+	}
+}
+
+func TestRefusal(t *testing.T) {
+	e := newTestEngine(t, `{"enabled": true}`)
+	if e.Refusal("") == "" {
+		t.Error("default refusal should not be empty")
+	}
+	if e.Refusal("zh-CN") == e.Refusal("en-US") {
+		t.Error("refusal should follow the user's language")
+	}
+
+	e = newTestEngine(t, `{"enabled": true, "refusalMessage": "nope"}`)
+	if e.Refusal("zh-CN") != "nope" {
+		t.Errorf("refusal = %q, want %q", e.Refusal("zh-CN"), "nope")
+	}
+}
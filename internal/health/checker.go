@@ -4,10 +4,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"runtime"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/HaohanHe/mujibot/internal/httpapi"
 	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/system"
 )
 
 // Checker 健康检查器
@@ -16,6 +19,10 @@ type Checker struct {
 	messageCount uint64
 	llmSuccess   uint64
 	llmFailed    uint64
+	llmFailStreak int
+	diskPaths    map[string]string
+	cpuMu        sync.Mutex
+	cpuPrev      system.CPUTimes
 	mu           sync.RWMutex
 	log          *logger.Logger
 }
@@ -30,6 +37,17 @@ type Status struct {
 	Goroutines    int                    `json:"goroutines"`
 	Messages      MessageStats           `json:"messages"`
 	LLM           LLMStats               `json:"llm"`
+	Disk          []system.DiskStats     `json:"disk,omitempty"`
+	CPU           CPUStats               `json:"cpu"`
+}
+
+// CPUStats CPU与负载统计（仅Linux可用，其他平台为零值）
+type CPUStats struct {
+	Cores          int       `json:"cores"`
+	UsagePercent   float64   `json:"usage_percent"`
+	Load           []float64 `json:"load,omitempty"`
+	ProcessSeconds float64   `json:"process_seconds"`
+	TemperatureC   float64   `json:"temperature_c,omitempty"`
 }
 
 // MemoryStats 内存统计
@@ -77,6 +95,8 @@ func (c *Checker) GetStatus() Status {
 	minutes := int(uptime.Minutes()) % 60
 	seconds := int(uptime.Seconds()) % 60
 
+	disk := c.diskUsage()
+
 	llmTotal := c.llmSuccess + c.llmFailed
 	llmRate := 0.0
 	if llmTotal > 0 {
@@ -107,7 +127,70 @@ func (c *Checker) GetStatus() Status {
 			Failed:  c.llmFailed,
 			Rate:    llmRate,
 		},
+		Disk: disk,
+		CPU:  c.CPU(),
+	}
+}
+
+// CPU 获取CPU使用率、负载、进程CPU时间与温度，使用率为距上次调用的区间值
+func (c *Checker) CPU() CPUStats {
+	stats := CPUStats{Cores: runtime.NumCPU()}
+
+	if times, err := system.ReadCPUTimes(); err == nil {
+		c.cpuMu.Lock()
+		if c.cpuPrev.Total > 0 {
+			stats.UsagePercent = times.UsageSince(c.cpuPrev)
+		}
+		c.cpuPrev = times
+		c.cpuMu.Unlock()
 	}
+	if load, err := system.LoadAverage(); err == nil {
+		stats.Load = load[:]
+	}
+	if d, err := system.ProcessCPUTime(); err == nil {
+		stats.ProcessSeconds = d.Seconds()
+	}
+	if temp, ok := system.Temperature(); ok {
+		stats.TemperatureC = temp
+	}
+
+	return stats
+}
+
+// SetDiskPaths 设置需要监控磁盘空间的目录（名称 -> 路径）
+func (c *Checker) SetDiskPaths(paths map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.diskPaths = paths
+}
+
+// DiskUsage 获取监控目录的磁盘使用情况
+func (c *Checker) DiskUsage() []system.DiskStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.diskUsage()
+}
+
+// diskUsage 调用方需持有锁
+func (c *Checker) diskUsage() []system.DiskStats {
+	names := make([]string, 0, len(c.diskPaths))
+	for name, path := range c.diskPaths {
+		if path != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	stats := make([]system.DiskStats, 0, len(names))
+	for _, name := range names {
+		d, err := system.DiskUsage(c.diskPaths[name])
+		if err != nil {
+			continue
+		}
+		d.Name = name
+		stats = append(stats, d)
+	}
+	return stats
 }
 
 // RecordMessage 记录消息
@@ -122,6 +205,7 @@ func (c *Checker) RecordLLMSuccess() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.llmSuccess++
+	c.llmFailStreak = 0
 }
 
 // RecordLLMFailed 记录LLM失败
@@ -129,6 +213,14 @@ func (c *Checker) RecordLLMFailed() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.llmFailed++
+	c.llmFailStreak++
+}
+
+// LLMFailStreak 获取LLM连续失败次数
+func (c *Checker) LLMFailStreak() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.llmFailStreak
 }
 
 // calculatePerHour 计算每小时消息数
@@ -164,7 +256,7 @@ func (c *Checker) CheckHealth() map[string]interface{} {
 func (c *Checker) Handler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
 			return
 		}
 
@@ -179,7 +271,7 @@ func (c *Checker) Handler() http.HandlerFunc {
 func (c *Checker) HealthHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
 			return
 		}
 
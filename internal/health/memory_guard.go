@@ -31,6 +31,8 @@ type MemoryGuard struct {
 	ctx              context.Context
 	cancel           context.CancelFunc
 	onCritical       func()
+	gcMB             uint64
+	criticalMB       uint64
 }
 
 func NewMemoryGuard(log *logger.Logger, onCritical func()) *MemoryGuard {
@@ -40,6 +42,20 @@ func NewMemoryGuard(log *logger.Logger, onCritical func()) *MemoryGuard {
 		ctx:        ctx,
 		cancel:     cancel,
 		onCritical: onCritical,
+		gcMB:       GCTriggerMemoryMB,
+		criticalMB: CriticalMemoryMB,
+	}
+}
+
+// SetThresholds 设置主动GC和优雅重启的堆内存阈值（MB），0 表示使用默认值
+func (g *MemoryGuard) SetThresholds(gcMB, criticalMB int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if gcMB > 0 {
+		g.gcMB = uint64(gcMB)
+	}
+	if criticalMB > 0 {
+		g.criticalMB = uint64(criticalMB)
 	}
 }
 
@@ -73,7 +89,7 @@ func (g *MemoryGuard) check() {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	if heapMB > CriticalMemoryMB {
+	if heapMB > g.criticalMB {
 		g.log.Error("critical memory usage, initiating graceful shutdown",
 			"heap_mb", heapMB,
 			"sys_mb", m.Sys/1024/1024,
@@ -85,7 +101,7 @@ func (g *MemoryGuard) check() {
 		return
 	}
 
-	if heapMB > GCTriggerMemoryMB {
+	if heapMB > g.gcMB {
 		g.consecutiveHigh++
 
 		if time.Since(g.lastGC) < CooldownPeriod {
@@ -186,10 +202,9 @@ func SelfRestart() error {
 		return err
 	}
 
-	args := os.Args[1:]
 	env := os.Environ()
 
-	p, err := os.StartProcess(self, args, &os.ProcAttr{
+	p, err := os.StartProcess(self, os.Args, &os.ProcAttr{
 		Env:   env,
 		Files: []*os.File{os.Stdin, os.Stdout, os.Stderr},
 	})
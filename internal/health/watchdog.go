@@ -0,0 +1,148 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+const (
+	DefaultWatchdogInterval = 60 * time.Second
+	DefaultAlertCooldown    = 30 * time.Minute
+)
+
+// Probe 存活探测，返回nil表示正常
+type Probe func(cfg *config.Config) error
+
+// Notifier 发送告警消息到指定渠道
+type Notifier func(channel, target, text string) error
+
+// Watchdog 存活监控，异常时向管理员渠道发送告警
+type Watchdog struct {
+	config *config.Manager
+	notify Notifier
+	log    *logger.Logger
+
+	mu       sync.Mutex
+	names    []string
+	probes   map[string]Probe
+	failing  map[string]bool
+	lastSent map[string]time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewWatchdog 创建存活监控
+func NewWatchdog(cfg *config.Manager, notify Notifier, log *logger.Logger) *Watchdog {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Watchdog{
+		config:   cfg,
+		notify:   notify,
+		log:      log,
+		probes:   make(map[string]Probe),
+		failing:  make(map[string]bool),
+		lastSent: make(map[string]time.Time),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// AddProbe 注册探测项
+func (w *Watchdog) AddProbe(name string, probe Probe) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.probes[name]; !ok {
+		w.names = append(w.names, name)
+	}
+	w.probes[name] = probe
+}
+
+func (w *Watchdog) Start() {
+	go w.monitorLoop()
+}
+
+func (w *Watchdog) Stop() {
+	w.cancel()
+}
+
+func (w *Watchdog) monitorLoop() {
+	interval := DefaultWatchdogInterval
+	if n := w.config.Get().Alerts.Interval; n > 0 {
+		interval = time.Duration(n) * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			w.Check()
+		}
+	}
+}
+
+// Check 执行一轮探测，状态变化或冷却结束时发送告警
+func (w *Watchdog) Check() {
+	cfg := w.config.Get()
+	if !cfg.Alerts.Enabled {
+		return
+	}
+
+	cooldown := DefaultAlertCooldown
+	if cfg.Alerts.Cooldown > 0 {
+		cooldown = time.Duration(cfg.Alerts.Cooldown) * time.Second
+	}
+
+	w.mu.Lock()
+	names := append([]string(nil), w.names...)
+	probes := make(map[string]Probe, len(w.probes))
+	for k, v := range w.probes {
+		probes[k] = v
+	}
+	w.mu.Unlock()
+
+	for _, name := range names {
+		err := probes[name](cfg)
+
+		w.mu.Lock()
+		wasFailing := w.failing[name]
+		var text string
+		switch {
+		case err != nil && (!wasFailing || time.Since(w.lastSent[name]) >= cooldown):
+			text = fmt.Sprintf("⚠️ [%s] %v", name, err)
+			w.failing[name] = true
+			w.lastSent[name] = time.Now()
+		case err == nil && wasFailing:
+			text = fmt.Sprintf("✅ [%s] recovered", name)
+			delete(w.failing, name)
+			delete(w.lastSent, name)
+		}
+		w.mu.Unlock()
+
+		if err != nil {
+			w.log.Warn("watchdog probe failed", "probe", name, "error", err)
+		}
+		if text != "" {
+			w.send(cfg, text)
+		}
+	}
+}
+
+// send 发送告警，失败仅记录日志
+func (w *Watchdog) send(cfg *config.Config, text string) {
+	if w.notify == nil || cfg.Alerts.Channel == "" {
+		return
+	}
+	if err := w.notify(cfg.Alerts.Channel, cfg.Alerts.Target, text); err != nil {
+		w.log.Error("failed to send alert", "channel", cfg.Alerts.Channel, "error", err)
+	}
+}
@@ -0,0 +1,57 @@
+package health
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestWatchdogDedup(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	configPath := filepath.Join(t.TempDir(), "config.json5")
+	content := `{
+		"llm": {"provider": "ollama"},
+		"alerts": {"enabled": true, "channel": "telegram", "target": "1", "cooldown": 3600}
+	}`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := config.NewManager(configPath, log)
+	if err != nil {
+		t.Fatalf("failed to create config manager: %v", err)
+	}
+	defer cfg.Close()
+
+	var sent []string
+	w := NewWatchdog(cfg, func(channel, target, text string) error {
+		sent = append(sent, text)
+		return nil
+	}, log)
+
+	var probeErr error
+	w.AddProbe("llm", func(*config.Config) error { return probeErr })
+
+	w.Check()
+	if len(sent) != 0 {
+		t.Fatalf("healthy probe should not alert, got: %v", sent)
+	}
+
+	probeErr = errors.New("unreachable")
+	w.Check()
+	w.Check()
+	if len(sent) != 1 {
+		t.Fatalf("repeated failure should alert once within cooldown, got: %v", sent)
+	}
+
+	probeErr = nil
+	w.Check()
+	if len(sent) != 2 || sent[1] != "✅ [llm] recovered" {
+		t.Errorf("recovery should be reported once, got: %v", sent)
+	}
+}
@@ -0,0 +1,114 @@
+// Package httpapi HTTP接口共用的响应格式
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+// 错误码，客户端按 code 而不是 message 判断错误类型
+const (
+	CodeBadRequest       = "bad_request"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodeConfirmRequired  = "confirmation_required"
+	CodeCSRFRejected     = "csrf_rejected"
+	CodeTooManyRequests  = "too_many_requests"
+	CodeInternal         = "internal_error"
+	CodeUnavailable      = "unavailable"
+)
+
+// RequestIDHeader 请求ID的请求头和响应头
+const RequestIDHeader = "X-Request-ID"
+
+// Error 错误响应体中的 error 字段
+type Error struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// ErrorResponse 错误响应体
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+// statusCodes 未指定错误码时按状态码选择
+var statusCodes = map[int]string{
+	http.StatusBadRequest:           CodeBadRequest,
+	http.StatusUnauthorized:         CodeUnauthorized,
+	http.StatusForbidden:            CodeForbidden,
+	http.StatusNotFound:             CodeNotFound,
+	http.StatusMethodNotAllowed:     CodeMethodNotAllowed,
+	http.StatusConflict:             CodeConflict,
+	http.StatusPreconditionRequired: CodeConfirmRequired,
+	http.StatusTooManyRequests:      CodeTooManyRequests,
+	http.StatusInternalServerError:  CodeInternal,
+	http.StatusServiceUnavailable:   CodeUnavailable,
+}
+
+// CodeForStatus 返回状态码对应的默认错误码
+func CodeForStatus(status int) string {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// RespondError 写出 {"error": {code, message, requestId}}。code 为空时按状态码选择，
+// requestId 取自请求的 context，没有时取请求头 X-Request-ID
+func RespondError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	if code == "" {
+		code = CodeForStatus(status)
+	}
+	resp := ErrorResponse{Error: Error{Code: code, Message: message}}
+	if r != nil {
+		resp.Error.RequestID = logger.RequestID(r.Context())
+		if resp.Error.RequestID == "" {
+			resp.Error.RequestID = r.Header.Get(RequestIDHeader)
+		}
+	}
+
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/json; charset=utf-8")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// WithRequestID 为每个请求分配请求ID，写入 context 和响应头。
+// 请求头已带合法的 X-Request-ID 时沿用，便于与调用方的日志关联
+func WithRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = logger.NewRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logger.WithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID 只接受不超过64个字符的字母、数字、-、_ 和 .，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
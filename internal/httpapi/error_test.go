@@ -0,0 +1,58 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRespondError(t *testing.T) {
+	handler := WithRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/conflict" {
+			RespondError(w, r, http.StatusConflict, "api_exists", "API already exists")
+			return
+		}
+		RespondError(w, r, http.StatusMethodNotAllowed, "", "Method not allowed")
+	}))
+
+	tests := []struct {
+		path, header string
+		status       int
+		code         string
+		keepID       bool
+	}{
+		{"/", "", http.StatusMethodNotAllowed, CodeMethodNotAllowed, false},
+		{"/conflict", "caller-123", http.StatusConflict, "api_exists", true},
+		{"/", "bad id\nwith newline", http.StatusMethodNotAllowed, CodeMethodNotAllowed, false},
+		{"/", strings.Repeat("a", 65), http.StatusMethodNotAllowed, CodeMethodNotAllowed, false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.header != "" {
+			req.Header.Set(RequestIDHeader, tt.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status || !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+			t.Errorf("%s: status = %d, Content-Type = %q", tt.path, rec.Code, rec.Header().Get("Content-Type"))
+		}
+		var body ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: invalid body %q: %v", tt.path, rec.Body.String(), err)
+		}
+		id := rec.Header().Get(RequestIDHeader)
+		if body.Error.Code != tt.code || body.Error.Message == "" || body.Error.RequestID == "" || body.Error.RequestID != id {
+			t.Errorf("%s: error = %+v, header id = %q", tt.path, body.Error, id)
+		}
+		if (id == tt.header) != tt.keepID {
+			t.Errorf("%s: request id %q, incoming %q", tt.path, id, tt.header)
+		}
+	}
+
+	if got := CodeForStatus(http.StatusBadGateway); got != CodeInternal {
+		t.Errorf("CodeForStatus(502) = %q", got)
+	}
+}
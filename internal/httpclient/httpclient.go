@@ -0,0 +1,233 @@
+// Package httpclient 出站HTTP客户端工厂：所有LLM提供商、渠道和工具共用同一个连接池，
+// 支持全局代理、按目标主机的代理、自定义CA证书，连接池参数按低内存设备调小
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Direct 代理配置为该值时直连，不使用环境变量中的代理
+const Direct = "direct"
+
+const (
+	defaultMaxIdleConns        = 8
+	defaultMaxIdleConnsPerHost = 2
+	defaultIdleConnTimeout     = 60 * time.Second
+)
+
+// Config 出站HTTP配置
+type Config struct {
+	Proxy               string            // 全局代理（http://、https://、socks5://），为空时使用 HTTP_PROXY/HTTPS_PROXY/NO_PROXY 环境变量，Direct 表示直连
+	Proxies             map[string]string // 按目标主机指定代理，键为主机名或 *.example.com，值为代理地址或 Direct，优先于全局代理
+	NoProxy             []string          // 全局代理不适用的主机，格式同 Proxies 的键
+	CABundle            string            // 额外信任的CA证书文件（PEM），追加到系统证书池
+	MaxIdleConns        int               // 空闲连接总数上限，默认8
+	MaxIdleConnsPerHost int               // 每个主机的空闲连接上限，默认2
+	IdleConnTimeout     time.Duration     // 空闲连接保留时间，默认60秒
+}
+
+var (
+	mu      sync.RWMutex
+	current = Config{}
+	shared  = mustTransport(Config{})
+)
+
+// Configure 替换共享连接池的配置，已创建的客户端在下一次请求时生效
+func Configure(cfg Config) error {
+	t, err := NewTransport(cfg)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	old := shared
+	shared, current = t, cfg
+	mu.Unlock()
+	old.CloseIdleConnections()
+	return nil
+}
+
+// New 创建使用共享连接池的客户端
+func New(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: Transport()}
+}
+
+// Transport 返回委托给共享连接池的 RoundTripper，始终使用 Configure 最近一次设置的配置
+func Transport() http.RoundTripper {
+	return sharedTransport{}
+}
+
+type sharedTransport struct{}
+
+func (sharedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	mu.RLock()
+	t := shared
+	mu.RUnlock()
+	return t.RoundTrip(req)
+}
+
+// WithProxy 创建使用指定代理的独立连接池，其余配置（CA证书、连接池大小）沿用全局配置。
+// proxy 为空时返回共享连接池，用于按提供商配置代理
+func WithProxy(proxy string) (http.RoundTripper, error) {
+	if proxy == "" {
+		return Transport(), nil
+	}
+	mu.RLock()
+	cfg := current
+	mu.RUnlock()
+	cfg.Proxy = proxy
+	cfg.Proxies = nil
+	cfg.NoProxy = nil
+	return NewTransport(cfg)
+}
+
+// NewTransport 按配置创建连接池
+func NewTransport(cfg Config) (*http.Transport, error) {
+	proxy, err := proxyFunc(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CABundle != "" {
+		pool, err := loadCABundle(cfg.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	maxIdle := cfg.MaxIdleConns
+	if maxIdle <= 0 {
+		maxIdle = defaultMaxIdleConns
+	}
+	perHost := cfg.MaxIdleConnsPerHost
+	if perHost <= 0 {
+		perHost = defaultMaxIdleConnsPerHost
+	}
+	idle := cfg.IdleConnTimeout
+	if idle <= 0 {
+		idle = defaultIdleConnTimeout
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          maxIdle,
+		MaxIdleConnsPerHost:   perHost,
+		IdleConnTimeout:       idle,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}, nil
+}
+
+func mustTransport(cfg Config) *http.Transport {
+	t, err := NewTransport(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// proxyFunc 按目标主机选择代理：Proxies 中最具体的匹配 > NoProxy > 全局代理 > 环境变量
+func proxyFunc(cfg Config) (func(*http.Request) (*url.URL, error), error) {
+	global, err := parseProxy(cfg.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http.proxy: %w", err)
+	}
+
+	type rule struct {
+		pattern string
+		proxy   *url.URL
+	}
+	rules := make([]rule, 0, len(cfg.Proxies))
+	for pattern, p := range cfg.Proxies {
+		if p == "" {
+			continue
+		}
+		u, err := parseProxy(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy for %s: %w", pattern, err)
+		}
+		rules = append(rules, rule{pattern: strings.ToLower(pattern), proxy: u})
+	}
+	// 更具体（更长）的模式优先
+	sort.Slice(rules, func(i, j int) bool { return len(rules[i].pattern) > len(rules[j].pattern) })
+
+	return func(req *http.Request) (*url.URL, error) {
+		host := strings.ToLower(req.URL.Hostname())
+		for _, r := range rules {
+			if matchHost(r.pattern, host) {
+				return r.proxy, nil
+			}
+		}
+		for _, pattern := range cfg.NoProxy {
+			if matchHost(strings.ToLower(pattern), host) {
+				return nil, nil
+			}
+		}
+		switch cfg.Proxy {
+		case "":
+			return http.ProxyFromEnvironment(req)
+		case Direct:
+			return nil, nil
+		}
+		return global, nil
+	}, nil
+}
+
+// parseProxy 解析代理地址，Direct 和空值返回 nil
+func parseProxy(s string) (*url.URL, error) {
+	if s == "" || s == Direct {
+		return nil, nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (use http, https or socks5)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy host is empty: %s", s)
+	}
+	return u, nil
+}
+
+// matchHost 模式为 *.example.com 时匹配 example.com 及其子域名，否则精确匹配
+func matchHost(pattern, host string) bool {
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return host == suffix || strings.HasSuffix(host, "."+suffix)
+	}
+	return host == pattern
+}
+
+// loadCABundle 把PEM文件中的证书追加到系统证书池
+func loadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+	return pool, nil
+}
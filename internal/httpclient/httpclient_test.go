@@ -0,0 +1,117 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProxySelection(t *testing.T) {
+	cfg := Config{
+		Proxy: "http://global:8080",
+		Proxies: map[string]string{
+			"api.telegram.org": "socks5://127.0.0.1:1080",
+			"*.example.com":    "http://example-proxy:3128",
+			"*.internal":       Direct,
+		},
+		NoProxy: []string{"localhost"},
+	}
+	proxy, err := proxyFunc(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://api.telegram.org/bot", "socks5://127.0.0.1:1080"},
+		{"https://example.com/", "http://example-proxy:3128"},
+		{"https://a.b.example.com/", "http://example-proxy:3128"},
+		{"http://svc.internal/", ""},
+		{"http://localhost:8080/", ""},
+		{"https://api.openai.com/v1", "http://global:8080"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+		u, err := proxy(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.url, err)
+		}
+		got := ""
+		if u != nil {
+			got = u.String()
+		}
+		if got != tt.want {
+			t.Errorf("proxy(%s) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	if _, err := NewTransport(Config{Proxy: "ftp://proxy"}); err == nil {
+		t.Error("unsupported proxy scheme should be rejected")
+	}
+	if _, err := NewTransport(Config{Proxies: map[string]string{"x.com": "://bad"}}); err == nil {
+		t.Error("invalid per-host proxy should be rejected")
+	}
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(bundle, []byte("not a certificate"), 0600)
+	if _, err := NewTransport(Config{CABundle: bundle}); err == nil {
+		t.Error("CA bundle without certificates should be rejected")
+	}
+}
+
+func TestConfigureAppliesToExistingClients(t *testing.T) {
+	defer Configure(Config{})
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("direct"))
+	}))
+	defer target.Close()
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("proxied"))
+	}))
+	defer proxy.Close()
+
+	client := New(5 * time.Second)
+	get := func() string {
+		resp, err := client.Get(target.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		buf := make([]byte, 16)
+		n, _ := resp.Body.Read(buf)
+		return string(buf[:n])
+	}
+
+	if err := Configure(Config{Proxy: Direct}); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(); got != "direct" {
+		t.Errorf("direct request = %q", got)
+	}
+
+	// 127.0.0.1 需通过按主机代理显式指定，环境变量方式会跳过本机地址
+	if err := Configure(Config{Proxies: map[string]string{"127.0.0.1": proxy.URL}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(); got != "proxied" {
+		t.Errorf("request after Configure = %q, want proxied", got)
+	}
+
+	rt, err := WithProxy(Direct)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: rt}).Get(target.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
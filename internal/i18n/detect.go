@@ -0,0 +1,123 @@
+package i18n
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minLatinLetters 判定为拉丁字母语言所需的最少字母数，过短的消息（如 ok）不足以判断
+const minLatinLetters = 4
+
+// latinStopwords 区分拉丁字母语言的常见虚词
+var latinStopwords = map[string][]string{
+	"en-US": {"the", "and", "is", "are", "you", "what", "how", "my", "to", "of", "it", "this", "please", "can"},
+	"de-DE": {"der", "die", "das", "und", "ist", "ich", "nicht", "wie", "was", "mein", "bitte", "ein", "eine", "mit"},
+	"fr-FR": {"le", "la", "les", "et", "est", "je", "pas", "comment", "quel", "quelle", "mon", "une", "des", "pour"},
+	"es-ES": {"el", "los", "las", "y", "es", "yo", "no", "cómo", "qué", "mi", "por", "una", "para", "con"},
+}
+
+// Detect 根据文字系统粗略识别消息语言，无法判断时返回空字符串。
+// 含假名判为日文，含谚文判为韩文，汉字多于拉丁单词判为中文，西里尔字母为主判为俄文；
+// 拉丁字母按常见虚词区分英、德、法、西，没有命中时判为英文
+func Detect(text string) string {
+	var kana, hangul, han, cyrillic, latin, words int
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana) && r != 'ー':
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+			if !inWord {
+				words++
+			}
+			inWord = true
+			continue
+		}
+		inWord = false
+	}
+
+	switch {
+	case kana > 0:
+		return "ja-JP"
+	case hangul > 0 && hangul >= han:
+		return "ko-KR"
+	case han > 0 && han >= words:
+		return "zh-CN"
+	case cyrillic >= minLatinLetters && cyrillic > latin:
+		return "ru-RU"
+	case latin >= minLatinLetters:
+		return detectLatin(text)
+	default:
+		return ""
+	}
+}
+
+// detectLatin 统计各语言虚词出现次数，得分最高者胜出，平局或全未命中时为英文
+func detectLatin(text string) string {
+	tokens := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+
+	best, bestScore := "en-US", 0
+	for _, lang := range []string{"en-US", "de-DE", "fr-FR", "es-ES"} {
+		score := 0
+		for _, token := range tokens {
+			for _, w := range latinStopwords[lang] {
+				if token == w {
+					score++
+				}
+			}
+		}
+		if score > bestScore {
+			best, bestScore = lang, score
+		}
+	}
+
+	// 特有字母比虚词更可靠
+	switch {
+	case strings.ContainsAny(text, "ßäöüÄÖÜ"):
+		return "de-DE"
+	case strings.ContainsAny(text, "ñ¿¡"):
+		return "es-ES"
+	case strings.ContainsAny(text, "çœèêàùâî"):
+		return "fr-FR"
+	}
+	return best
+}
+
+// Match 把语言标签（如 zh、en_GB、ja-jp）对应到已有翻译的语言，没有匹配时返回空字符串
+func (i *I18n) Match(tag string) string {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	if tag == "" {
+		return ""
+	}
+
+	langs := SupportedLanguages()
+	i.mu.RLock()
+	for lang := range i.overrides {
+		if _, ok := catalogs[lang]; !ok {
+			langs = append(langs, lang)
+		}
+	}
+	i.mu.RUnlock()
+
+	for _, lang := range langs {
+		if strings.EqualFold(lang, tag) {
+			return lang
+		}
+	}
+	base, _, _ := strings.Cut(tag, "-")
+	for _, lang := range langs {
+		if langBase, _, _ := strings.Cut(lang, "-"); strings.EqualFold(langBase, base) {
+			return lang
+		}
+	}
+	return ""
+}
@@ -1,114 +1,60 @@
 package i18n
 
 import (
+	"embed"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 )
 
-type Messages struct {
-	Hello            string `json:"hello"`
-	SelectLanguage   string `json:"selectLanguage"`
-	CurrentTime      string `json:"currentTime"`
-	Timezone         string `json:"timezone"`
-	SystemType       string `json:"systemType"`
-	AvailableTools   string `json:"availableTools"`
-	ToolsIntro       string `json:"toolsIntro"`
-	MemoryContext    string `json:"memoryContext"`
-	ToolUsage        string `json:"toolUsage"`
-	UserLanguage     string `json:"userLanguage"`
-	ReplyInSameLang  string `json:"replyInSameLang"`
-	MemoryRulesTitle string `json:"memoryRulesTitle"`
-	MemoryRules      string `json:"memoryRules"`
-	MemoryCategories string `json:"memoryCategories"`
-}
-
-var defaultMessages = map[string]Messages{
-	"en-US": {
-		Hello:            "Hello",
-		SelectLanguage:   "Please select your language",
-		CurrentTime:      "Current time",
-		Timezone:         "Timezone",
-		SystemType:       "System type",
-		AvailableTools:   "Available tools",
-		ToolsIntro:       "You can use the following tools to help users:",
-		MemoryContext:    "Memory context",
-		ToolUsage:        "When using tools, ensure parameters are correct. If a tool call fails, explain the reason to the user.",
-		UserLanguage:     "User language",
-		ReplyInSameLang:  "Please reply in the same language as the user.",
-		MemoryRulesTitle: "Memory rules",
-		MemoryRules: `When the user expresses the following intentions, automatically call the memory_write tool:
-1. "Remember..." / "Don't forget..." / "Write this down..."
-2. "I like..." / "I hate..." / "My..."
-3. Important dates, contacts, addresses
-4. Information the user repeatedly mentions`,
-		MemoryCategories: `Memory categories:
-- preference: User preferences
-- fact: Factual information
-- event: Events/dates
-- contact: Contact information`,
-	},
-	"zh-CN": {
-		Hello:            "你好",
-		SelectLanguage:   "请选择您的语言",
-		CurrentTime:      "当前时间",
-		Timezone:         "时区",
-		SystemType:       "系统类型",
-		AvailableTools:   "可用工具",
-		ToolsIntro:       "你可以使用以下工具来帮助用户:",
-		MemoryContext:    "记忆上下文",
-		ToolUsage:        "使用工具时，请确保参数正确。如果工具调用失败，向用户解释原因。",
-		UserLanguage:     "用户语言",
-		ReplyInSameLang:  "请使用与用户相同的语言回复。",
-		MemoryRulesTitle: "记忆规则",
-		MemoryRules: `当用户表达以下意图时，自动调用 memory_write 工具：
-1. "记住..." / "别忘了..." / "记下来..."
-2. "我喜欢..." / "我讨厌..." / "我的..."
-3. 重要日期、联系方式、地址等
-4. 用户反复提及的信息`,
-		MemoryCategories: `记忆分类：
-- preference: 用户偏好
-- fact: 事实信息
-- event: 事件/日期
-- contact: 联系人信息`,
-	},
-	"ja-JP": {
-		Hello:            "こんにちは",
-		SelectLanguage:   "言語を選択してください",
-		CurrentTime:      "現在時刻",
-		Timezone:         "タイムゾーン",
-		SystemType:       "システムタイプ",
-		AvailableTools:   "利用可能なツール",
-		ToolsIntro:       "以下のツールを使用してユーザーを支援できます:",
-		MemoryContext:    "メモリコンテキスト",
-		ToolUsage:        "ツールを使用する際は、パラメータが正しいことを確認してください。ツールの呼び出しに失敗した場合は、ユーザーに理由を説明してください。",
-		UserLanguage:     "ユーザー言語",
-		ReplyInSameLang:  "ユーザーと同じ言語で返信してください。",
-		MemoryRulesTitle: "メモリルール",
-		MemoryRules: `ユーザーが以下の意図を表現した場合、自動的にmemory_writeツールを呼び出します：
-1. 「覚えて...」/「忘れないで...」/「書き留めて...」
-2. 「私は...が好き」/「私は...が嫌い」/「私の...」
-3. 重要な日付、連絡先、住所
-4. ユーザーが繰り返し言及する情報`,
-		MemoryCategories: `メモリカテゴリ：
-- preference: ユーザーの好み
-- fact: 事実情報
-- event: イベント/日付
-- contact: 連絡先情報`,
-	},
+// fallbackLang 缺少翻译时回退的语言
+const fallbackLang = "en-US"
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// catalogs 内置翻译，按语言代码索引，加载后只读
+var catalogs = loadCatalogs()
+
+// Params 翻译模板参数，替换文本中的 {name} 占位符
+type Params map[string]interface{}
+
+// loadCatalogs 读取嵌入的 locales/*.json，每个文件是 key -> 文本 的扁平对象
+func loadCatalogs() map[string]map[string]string {
+	files, err := localeFS.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("i18n: failed to read embedded locales: %v", err))
+	}
+
+	result := make(map[string]map[string]string, len(files))
+	for _, file := range files {
+		data, err := localeFS.ReadFile("locales/" + file.Name())
+		if err != nil {
+			panic(fmt.Sprintf("i18n: failed to read %s: %v", file.Name(), err))
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("i18n: invalid %s: %v", file.Name(), err))
+		}
+		result[strings.TrimSuffix(file.Name(), ".json")] = catalog
+	}
+	return result
 }
 
 type I18n struct {
 	currentLang string
-	messages    map[string]Messages
+	autoDetect  bool
+	overrides   map[string]map[string]string // LoadCustomTranslations 加载的自定义翻译，优先于内置翻译
 	mu          sync.RWMutex
 }
 
 func New(defaultLang string) *I18n {
 	return &I18n{
 		currentLang: defaultLang,
-		messages:    defaultMessages,
 	}
 }
 
@@ -124,60 +70,127 @@ func (i *I18n) GetLanguage() string {
 	return i.currentLang
 }
 
-func (i *I18n) T(key string) string {
+// SetAutoDetect 开关按消息自动识别用户语言
+func (i *I18n) SetAutoDetect(on bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.autoDetect = on
+}
+
+// AutoDetect 是否按消息自动识别用户语言
+func (i *I18n) AutoDetect() bool {
 	i.mu.RLock()
 	defer i.mu.RUnlock()
+	return i.autoDetect
+}
 
-	msgs, ok := i.messages[i.currentLang]
-	if !ok {
-		msgs = i.messages["en-US"]
-	}
-
-	switch key {
-	case "hello":
-		return msgs.Hello
-	case "selectLanguage":
-		return msgs.SelectLanguage
-	case "currentTime":
-		return msgs.CurrentTime
-	case "timezone":
-		return msgs.Timezone
-	case "systemType":
-		return msgs.SystemType
-	case "availableTools":
-		return msgs.AvailableTools
-	case "toolsIntro":
-		return msgs.ToolsIntro
-	case "memoryContext":
-		return msgs.MemoryContext
-	case "toolUsage":
-		return msgs.ToolUsage
-	case "userLanguage":
-		return msgs.UserLanguage
-	case "replyInSameLang":
-		return msgs.ReplyInSameLang
-	case "memoryRulesTitle":
-		return msgs.MemoryRulesTitle
-	case "memoryRules":
-		return msgs.MemoryRules
-	case "memoryCategories":
-		return msgs.MemoryCategories
-	default:
-		return key
+func (i *I18n) T(key string) string {
+	return i.TLang(i.GetLanguage(), key)
+}
+
+// TLang 按指定语言翻译，不影响当前语言。依次查找自定义翻译、该语言、en-US，都没有时返回 key
+func (i *I18n) TLang(lang, key string) string {
+	if s, ok := i.lookup(lang, key); ok {
+		return s
+	}
+	if s, ok := i.lookup(fallbackLang, key); ok {
+		return s
+	}
+	return key
+}
+
+// Lookup 按指定语言查找并替换占位符，该语言和 en-US 都没有该键时 ok 为 false
+func (i *I18n) Lookup(lang, key string, params Params) (string, bool) {
+	if s, ok := i.lookup(lang, key); ok {
+		return format(s, params), true
+	}
+	if s, ok := i.lookup(fallbackLang, key); ok {
+		return format(s, params), true
 	}
+	return "", false
 }
 
-func (i *I18n) GetMessages() Messages {
+// Tf 翻译并替换 {name} 占位符
+func (i *I18n) Tf(key string, params Params) string {
+	return format(i.T(key), params)
+}
+
+// Tn 按数量选择复数形式（key.one、key.few、key.many、key.other）并替换占位符，{count} 为 n
+func (i *I18n) Tn(key string, n int, params Params) string {
+	lang := i.GetLanguage()
+	p := Params{"count": n}
+	for k, v := range params {
+		p[k] = v
+	}
+
+	for _, candidate := range []string{key + "." + pluralCategory(lang, n), key + ".other", key} {
+		if s, ok := i.lookup(lang, candidate); ok {
+			return format(s, p)
+		}
+	}
+	for _, candidate := range []string{key + "." + pluralCategory(fallbackLang, n), key + ".other", key} {
+		if s, ok := i.lookup(fallbackLang, candidate); ok {
+			return format(s, p)
+		}
+	}
+	return key
+}
+
+func (i *I18n) lookup(lang, key string) (string, bool) {
 	i.mu.RLock()
-	defer i.mu.RUnlock()
+	s, ok := i.overrides[lang][key]
+	i.mu.RUnlock()
+	if ok {
+		return s, true
+	}
+	s, ok = catalogs[lang][key]
+	return s, ok
+}
 
-	msgs, ok := i.messages[i.currentLang]
-	if !ok {
-		msgs = i.messages["en-US"]
+// format 替换 {name} 占位符，未提供的参数保持原样
+func format(s string, params Params) string {
+	if len(params) == 0 || !strings.Contains(s, "{") {
+		return s
 	}
-	return msgs
+	pairs := make([]string, 0, len(params)*2)
+	for k, v := range params {
+		pairs = append(pairs, "{"+k+"}", fmt.Sprint(v))
+	}
+	return strings.NewReplacer(pairs...).Replace(s)
 }
 
+// pluralCategory 返回整数 n 在该语言中的CLDR复数类别
+func pluralCategory(lang string, n int) string {
+	if n < 0 {
+		n = -n
+	}
+	base, _, _ := strings.Cut(lang, "-")
+	switch strings.ToLower(base) {
+	case "zh", "ja", "ko":
+		return "other"
+	case "fr":
+		if n == 0 || n == 1 {
+			return "one"
+		}
+		return "other"
+	case "ru", "uk":
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return "one"
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	default:
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	}
+}
+
+// LoadCustomTranslations 加载目录下的 <语言>.json 自定义翻译，只需包含要覆盖或新增的键
 func (i *I18n) LoadCustomTranslations(dir string) error {
 	files, err := os.ReadDir(dir)
 	if err != nil {
@@ -186,19 +199,22 @@ func (i *I18n) LoadCustomTranslations(dir string) error {
 
 	for _, file := range files {
 		if filepath.Ext(file.Name()) == ".json" {
-			lang := file.Name()[:len(file.Name())-5]
+			lang := strings.TrimSuffix(file.Name(), ".json")
 			data, err := os.ReadFile(filepath.Join(dir, file.Name()))
 			if err != nil {
 				continue
 			}
 
-			var msgs Messages
+			var msgs map[string]string
 			if err := json.Unmarshal(data, &msgs); err != nil {
 				continue
 			}
 
 			i.mu.Lock()
-			i.messages[lang] = msgs
+			if i.overrides == nil {
+				i.overrides = make(map[string]map[string]string)
+			}
+			i.overrides[lang] = msgs
 			i.mu.Unlock()
 		}
 	}
@@ -206,15 +222,18 @@ func (i *I18n) LoadCustomTranslations(dir string) error {
 	return nil
 }
 
+// SupportedLanguages 内置翻译的语言，en-US 在前，其余按代码排序
 func SupportedLanguages() []string {
-	return []string{"en-US", "zh-CN", "ja-JP"}
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		if lang != fallbackLang {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs)
+	return append([]string{fallbackLang}, langs...)
 }
 
 func LanguageName(code string) string {
-	names := map[string]string{
-		"en-US": "English (US)",
-		"zh-CN": "简体中文",
-		"ja-JP": "日本語",
-	}
-	return names[code]
+	return catalogs[code]["languageName"]
 }
@@ -0,0 +1,110 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCatalogsComplete 每个内置语言都要覆盖 en-US 的全部键（复数变体只要求 .other，工具描述 tool.* 可回退英文）
+func TestCatalogsComplete(t *testing.T) {
+	for _, lang := range SupportedLanguages() {
+		for key := range catalogs[fallbackLang] {
+			if strings.Contains(key, ".") && !strings.HasSuffix(key, ".other") {
+				continue
+			}
+			if _, ok := catalogs[lang][key]; !ok {
+				t.Errorf("%s is missing key %q", lang, key)
+			}
+		}
+		if LanguageName(lang) == "" {
+			t.Errorf("%s has no languageName", lang)
+		}
+	}
+}
+
+func TestParamsAndPlurals(t *testing.T) {
+	tests := []struct {
+		lang string
+		n    int
+		want string
+	}{
+		{"en-US", 1, "1 pending confirmation"},
+		{"en-US", 3, "3 pending confirmations"},
+		{"fr-FR", 0, "0 confirmation en attente"},
+		{"ru-RU", 21, "21 ожидающее подтверждение"},
+		{"ru-RU", 3, "3 ожидающих подтверждения"},
+		{"ru-RU", 12, "12 ожидающих подтверждений"},
+		{"zh-CN", 1, "1 个待确认操作"},
+		{"xx-XX", 2, "2 pending confirmations"},
+	}
+	for _, tt := range tests {
+		if got := New(tt.lang).Tn("pendingCount", tt.n, nil); got != tt.want {
+			t.Errorf("Tn(%s, %d) = %q, want %q", tt.lang, tt.n, got, tt.want)
+		}
+	}
+
+	i := New("de-DE")
+	if got := i.Tf("pinned", Params{"n": 4}); got != "#4 angeheftet. Es wird in jede Unterhaltung aufgenommen." {
+		t.Errorf("Tf = %q", got)
+	}
+	if got := i.T("no.such.key"); got != "no.such.key" {
+		t.Errorf("missing key = %q", got)
+	}
+}
+
+func TestDetectAndMatch(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"What's the weather like tomorrow?", "en-US"},
+		{"明天天气怎么样", "zh-CN"},
+		{"明日の天気はどう？", "ja-JP"},
+		{"내일 날씨 어때?", "ko-KR"},
+		{"Какая завтра погода?", "ru-RU"},
+		{"Wie ist das Wetter morgen?", "de-DE"},
+		{"Quel temps fera-t-il demain ?", "fr-FR"},
+		{"¿Qué tiempo hará mañana?", "es-ES"},
+		{"ok", ""},
+	}
+	for _, tt := range tests {
+		if got := Detect(tt.text); got != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	i := New("en-US")
+	for tag, want := range map[string]string{"zh": "zh-CN", "en_GB": "en-US", "KO-kr": "ko-KR", "pt-BR": ""} {
+		if got := i.Match(tag); got != want {
+			t.Errorf("Match(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
+
+func TestLoadCustomTranslations(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "en-US.json"), []byte(`{"hello": "Howdy"}`), 0644)
+	os.WriteFile(filepath.Join(dir, "pt-BR.json"), []byte(`{"hello": "Olá"}`), 0644)
+
+	i := New("en-US")
+	if err := i.LoadCustomTranslations(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := i.T("hello"); got != "Howdy" {
+		t.Errorf("override = %q", got)
+	}
+	if got := i.T("adminOnly"); got != "This command is for administrators only." {
+		t.Errorf("non-overridden key = %q", got)
+	}
+	if got := i.Match("pt"); got != "pt-BR" {
+		t.Errorf("Match custom language = %q", got)
+	}
+	if got := i.TLang("pt-BR", "exportSent"); got != "Conversation exported." {
+		t.Errorf("custom language fallback = %q", got)
+	}
+	if got := New("en-US").T("hello"); got != "Hello" {
+		t.Errorf("overrides leaked to another instance: %q", got)
+	}
+}
@@ -0,0 +1,265 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultDebugBodyChars 调试记录中请求/响应体的默认最大字符数
+	defaultDebugBodyChars = 8000
+	// debugStringChars 请求体中单个字符串字段（如系统提示词）的最大字符数，保证工具定义不被挤掉
+	debugStringChars = 500
+)
+
+// DebugEntry 一次LLM API调用的调试记录，密钥已脱敏，请求/响应体已截断
+type DebugEntry struct {
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	URL        string    `json:"url"`
+	Status     int       `json:"status,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+	Request    string    `json:"request,omitempty"`
+	Response   string    `json:"response,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// DebugOptions 请求/响应调试日志选项
+type DebugOptions struct {
+	// Enabled 每次请求时调用，返回 false 时不记录，便于配置热更新
+	Enabled func() bool
+	// MaxBodyChars 请求/响应体的最大字符数，0 时为 8000
+	MaxBodyChars int
+	// Sink 接收调试记录
+	Sink func(DebugEntry)
+}
+
+// EnableDebugLog 记录提供商的每次HTTP请求和响应，需在 UseProxy 之后调用
+func EnableDebugLog(p Provider, opts DebugOptions) {
+	h, ok := p.(clientHolder)
+	if !ok || opts.Sink == nil {
+		return
+	}
+	client := h.httpClient()
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	client.Transport = &debugTransport{next: next, opts: opts}
+}
+
+// debugTransport 记录请求/响应的 RoundTripper
+type debugTransport struct {
+	next http.RoundTripper
+	opts DebugOptions
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.opts.Enabled != nil && !t.opts.Enabled() {
+		return t.next.RoundTrip(req)
+	}
+
+	limit := t.opts.MaxBodyChars
+	if limit <= 0 {
+		limit = defaultDebugBodyChars
+	}
+	entry := DebugEntry{
+		Time:   time.Now(),
+		Method: req.Method,
+		URL:    redactURL(req.URL),
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		entry.Request = truncate(sanitizeBody(body), limit)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		entry.DurationMs = time.Since(entry.Time).Milliseconds()
+		entry.Error = err.Error()
+		t.opts.Sink(entry)
+		return nil, err
+	}
+	entry.Status = resp.StatusCode
+	// 流式响应在读取结束（或关闭）时才记录
+	resp.Body = &debugBody{ReadCloser: resp.Body, limit: limit, done: func(body []byte, truncated bool) {
+		entry.DurationMs = time.Since(entry.Time).Milliseconds()
+		entry.Response = sanitizeBody(body)
+		if truncated {
+			entry.Response += "...(truncated)"
+		}
+		entry.Response = truncate(entry.Response, limit)
+		t.opts.Sink(entry)
+	}}
+	return resp, nil
+}
+
+// debugBody 边读边保存响应体的前 limit 字节
+type debugBody struct {
+	io.ReadCloser
+	limit     int
+	buf       []byte
+	truncated bool
+	once      sync.Once
+	done      func(body []byte, truncated bool)
+}
+
+func (b *debugBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if room := b.limit - len(b.buf); room > 0 {
+			if room > n {
+				room = n
+			}
+			b.buf = append(b.buf, p[:room]...)
+		}
+		if len(b.buf) >= b.limit {
+			b.truncated = true
+		}
+	}
+	if err != nil {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *debugBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *debugBody) finish() {
+	b.once.Do(func() { b.done(b.buf, b.truncated) })
+}
+
+var (
+	// secretField 需要脱敏的JSON字段名
+	secretField = regexp.MustCompile(`(?i)(api[_-]?key|secret|token$|password|authorization)`)
+	// secretText 无法解析为JSON时按文本脱敏
+	secretText = regexp.MustCompile(`(?i)("?(?:api[_-]?key|secret|password|authorization)"?\s*[:=]\s*"?)(?:bearer\s+)?[^",\s}]+`)
+	// bearerText 文本中的 Bearer 令牌和常见的密钥格式
+	bearerText = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._\-]+|\b(sk|xai|gsk)-[A-Za-z0-9_\-]{8,}`)
+)
+
+// sanitizeBody 脱敏请求/响应体：JSON中的密钥字段替换为 ***，过长的字符串字段截断
+func sanitizeBody(body []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err == nil {
+		if data, err := json.Marshal(sanitizeValue(v)); err == nil {
+			return string(data)
+		}
+	}
+	s := secretText.ReplaceAllString(string(body), "${1}***")
+	return bearerText.ReplaceAllString(s, "${1}***")
+}
+
+func sanitizeValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			// max_tokens 等不是密钥
+			if secretField.MatchString(k) && !strings.Contains(strings.ToLower(k), "tokens") {
+				t[k] = "***"
+				continue
+			}
+			t[k] = sanitizeValue(val)
+		}
+		return t
+	case []interface{}:
+		for i := range t {
+			t[i] = sanitizeValue(t[i])
+		}
+		return t
+	case string:
+		return truncate(bearerText.ReplaceAllString(t, "${1}***"), debugStringChars)
+	}
+	return v
+}
+
+// redactURL 脱敏URL中的密钥参数（如 ?key=）
+func redactURL(u *url.URL) string {
+	c := *u
+	c.User = nil
+	q := c.Query()
+	changed := false
+	for k := range q {
+		if k == "key" || secretField.MatchString(k) {
+			q.Set(k, "***")
+			changed = true
+		}
+	}
+	if changed {
+		c.RawQuery = q.Encode()
+	}
+	return c.String()
+}
+
+// truncate 按字符截断
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max]) + "...(truncated)"
+}
+
+// DebugFile 以JSONL追加写入调试记录，路径变化时重新打开
+type DebugFile struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+}
+
+// Write 追加一条记录
+func (d *DebugFile) Write(path string, e DebugEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.f == nil || d.path != path {
+		if d.f != nil {
+			d.f.Close()
+			d.f = nil
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		d.f, d.path = f, path
+	}
+	_, err = d.f.Write(append(data, '\n'))
+	return err
+}
+
+// Close 关闭文件
+func (d *DebugFile) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.f == nil {
+		return nil
+	}
+	err := d.f.Close()
+	d.f = nil
+	return err
+}
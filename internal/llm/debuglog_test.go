@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/session"
+)
+
+func TestSanitizeBody(t *testing.T) {
+	body := `{"model":"m","api_key":"sk-abcdefghijklmnop","max_tokens":100,"messages":[{"role":"system","content":"` +
+		strings.Repeat("x", 1000) + `"}],"headers":{"Authorization":"Bearer abc.def"}}`
+	got := sanitizeBody([]byte(body))
+
+	if strings.Contains(got, "sk-abcdefghijklmnop") || strings.Contains(got, "abc.def") {
+		t.Errorf("secret leaked: %s", got)
+	}
+	if !strings.Contains(got, `"max_tokens":100`) {
+		t.Errorf("max_tokens should be kept: %s", got)
+	}
+	if strings.Contains(got, strings.Repeat("x", debugStringChars+1)) {
+		t.Errorf("long string not truncated: %s", got)
+	}
+
+	text := sanitizeBody([]byte("invalid key sk-abcdefghijklmnop, Authorization: Bearer xyz"))
+	if strings.Contains(text, "abcdefghijklmnop") || strings.Contains(text, "xyz") {
+		t.Errorf("secret leaked in text body: %s", text)
+	}
+}
+
+func TestEnableDebugLog(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"error":{"message":"invalid tool schema"}}`)
+	}))
+	defer srv.Close()
+
+	log, _ := logger.New(logger.Config{Level: "error"})
+	p, err := NewProvider("openai", "sk-secretsecretsecret", srv.URL, "m", 5, 0, log)
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+
+	enabled := false
+	var entries []DebugEntry
+	EnableDebugLog(p, DebugOptions{
+		Enabled: func() bool { return enabled },
+		Sink:    func(e DebugEntry) { entries = append(entries, e) },
+	})
+
+	msgs := []session.Message{{Role: "user", Content: "hi"}}
+	p.Chat(msgs, nil)
+	if len(entries) != 0 {
+		t.Fatalf("logged while disabled: %v", entries)
+	}
+
+	enabled = true
+	if _, err := p.Chat(msgs, nil); err == nil || !strings.Contains(err.Error(), "invalid tool schema") {
+		t.Fatalf("expected provider error, got %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Status != http.StatusBadRequest || !strings.Contains(e.Response, "invalid tool schema") || !strings.Contains(e.Request, `"hi"`) {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+}
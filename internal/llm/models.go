@@ -0,0 +1,108 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ModelLister 可列出可用模型的提供商
+type ModelLister interface {
+	ListModels() ([]string, error)
+}
+
+// ListModels 从 /models 列出模型
+func (p *OpenAIProvider) ListModels() ([]string, error) {
+	var result struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := getJSON(p.client, p.baseURL+"/models", map[string]string{"Authorization": "Bearer " + p.apiKey}, &result); err != nil {
+		return nil, err
+	}
+	models := make([]string, 0, len(result.Data))
+	for _, m := range result.Data {
+		models = append(models, m.ID)
+	}
+	return sortModels(models), nil
+}
+
+// ListModels 从 /v1/models 列出模型
+func (p *AnthropicProvider) ListModels() ([]string, error) {
+	var result struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	err := getJSON(p.client, "https://api.anthropic.com/v1/models?limit=1000", map[string]string{
+		"x-api-key":         p.apiKey,
+		"anthropic-version": "2023-06-01",
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+	models := make([]string, 0, len(result.Data))
+	for _, m := range result.Data {
+		models = append(models, m.ID)
+	}
+	return sortModels(models), nil
+}
+
+// ListModels 列出本地已拉取的模型
+func (p *OllamaProvider) ListModels() ([]string, error) {
+	var result struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := getJSON(p.client, p.baseURL+"/api/tags", nil, &result); err != nil {
+		return nil, err
+	}
+	models := make([]string, 0, len(result.Models))
+	for _, m := range result.Models {
+		models = append(models, m.Name)
+	}
+	return sortModels(models), nil
+}
+
+// getJSON 发送GET请求并解析JSON响应，非2xx响应返回包含状态和响应体的错误
+func getJSON(client *http.Client, url string, headers map[string]string, v interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	for k, val := range headers {
+		req.Header.Set(k, val)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("llm api error: %s - %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// sortModels 去掉空名称并排序
+func sortModels(models []string) []string {
+	out := models[:0]
+	for _, m := range models {
+		if m != "" {
+			out = append(out, m)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,90 @@
+package llm
+
+import "github.com/HaohanHe/mujibot/internal/session"
+
+// ChatOptions 单次请求的选项
+type ChatOptions struct {
+	MaxTokens int // 回复的最大token数，0 表示使用提供商默认值
+}
+
+// OptionsProvider 支持单次请求选项的提供商
+type OptionsProvider interface {
+	ChatWithOptions(messages []session.Message, tools []Tool, opts ChatOptions) (*Response, error)
+	ChatStreamWithOptions(messages []session.Message, tools []Tool, opts ChatOptions, callback func(chunk string)) (*Response, error)
+}
+
+// ChatWith 按选项发送请求，提供商不支持选项时忽略选项
+func ChatWith(p Provider, messages []session.Message, tools []Tool, opts ChatOptions) (*Response, error) {
+	if op, ok := p.(OptionsProvider); ok {
+		return op.ChatWithOptions(messages, tools, opts)
+	}
+	return p.Chat(messages, tools)
+}
+
+// ChatStreamWith 按选项发送流式请求，提供商不支持选项时忽略选项
+func ChatStreamWith(p Provider, messages []session.Message, tools []Tool, opts ChatOptions, callback func(chunk string)) (*Response, error) {
+	if op, ok := p.(OptionsProvider); ok {
+		return op.ChatStreamWithOptions(messages, tools, opts, callback)
+	}
+	return p.ChatStream(messages, tools, callback)
+}
+
+// ChatWithOptions 发送聊天请求，MaxTokens 作为 max_tokens 发送
+func (p *OpenAIProvider) ChatWithOptions(messages []session.Message, tools []Tool, opts ChatOptions) (*Response, error) {
+	reqBody := p.buildRequest(messages, tools, false)
+	if opts.MaxTokens > 0 {
+		reqBody["max_tokens"] = opts.MaxTokens
+	}
+	return p.doRequest(reqBody)
+}
+
+// ChatStreamWithOptions 发送流式聊天请求，MaxTokens 作为 max_tokens 发送
+func (p *OpenAIProvider) ChatStreamWithOptions(messages []session.Message, tools []Tool, opts ChatOptions, callback func(chunk string)) (*Response, error) {
+	reqBody := p.buildRequest(messages, tools, true)
+	if opts.MaxTokens > 0 {
+		reqBody["max_tokens"] = opts.MaxTokens
+	}
+	return p.doStreamRequest(reqBody, callback)
+}
+
+// ChatWithOptions 发送聊天请求，MaxTokens 替换默认的 max_tokens
+func (p *AnthropicProvider) ChatWithOptions(messages []session.Message, tools []Tool, opts ChatOptions) (*Response, error) {
+	reqBody := p.buildRequest(messages, tools, false)
+	if opts.MaxTokens > 0 {
+		reqBody["max_tokens"] = opts.MaxTokens
+	}
+	return p.doRequest(reqBody)
+}
+
+// ChatStreamWithOptions 发送流式聊天请求，MaxTokens 替换默认的 max_tokens
+func (p *AnthropicProvider) ChatStreamWithOptions(messages []session.Message, tools []Tool, opts ChatOptions, callback func(chunk string)) (*Response, error) {
+	reqBody := p.buildRequest(messages, tools, true)
+	if opts.MaxTokens > 0 {
+		reqBody["max_tokens"] = opts.MaxTokens
+	}
+	return p.doStreamRequest(reqBody, callback)
+}
+
+// ChatWithOptions 发送聊天请求，MaxTokens 作为 options.num_predict 发送
+func (p *OllamaProvider) ChatWithOptions(messages []session.Message, tools []Tool, opts ChatOptions) (*Response, error) {
+	return p.chat(messages, opts)
+}
+
+// ChatStreamWithOptions 与 ChatWithOptions 相同，非流式
+func (p *OllamaProvider) ChatStreamWithOptions(messages []session.Message, tools []Tool, opts ChatOptions, callback func(chunk string)) (*Response, error) {
+	return p.chat(messages, opts)
+}
+
+// ChatWithOptions 调用被包装的提供商并记录，选项不参与记录的匹配
+func (r *Recorder) ChatWithOptions(messages []session.Message, tools []Tool, opts ChatOptions) (*Response, error) {
+	resp, err := ChatWith(r.next, messages, tools, opts)
+	r.record(messages, tools, resp, err)
+	return resp, err
+}
+
+// ChatStreamWithOptions 调用被包装的提供商并记录完整响应
+func (r *Recorder) ChatStreamWithOptions(messages []session.Message, tools []Tool, opts ChatOptions, callback func(chunk string)) (*Response, error) {
+	resp, err := ChatStreamWith(r.next, messages, tools, opts, callback)
+	r.record(messages, tools, resp, err)
+	return resp, err
+}
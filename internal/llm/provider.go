@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/HaohanHe/mujibot/internal/httpclient"
 	"github.com/HaohanHe/mujibot/internal/logger"
 	"github.com/HaohanHe/mujibot/internal/session"
 )
@@ -21,6 +22,11 @@ type Provider interface {
 	GetModel() string
 }
 
+// Pinger 可探测连通性的提供商
+type Pinger interface {
+	Ping() error
+}
+
 // Tool 工具定义
 type Tool struct {
 	Type     string   `json:"type"`
@@ -74,7 +80,7 @@ func NewOpenAIProvider(apiKey, baseURL, model string, timeout, maxRetries int, l
 		model:      model,
 		timeout:    time.Duration(timeout) * time.Second,
 		maxRetries: maxRetries,
-		client:     &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		client:     httpclient.New(time.Duration(timeout) * time.Second),
 		log:        log,
 	}
 }
@@ -96,6 +102,11 @@ func (p *OpenAIProvider) GetModel() string {
 	return p.model
 }
 
+// Ping 探测API是否可达
+func (p *OpenAIProvider) Ping() error {
+	return ping(p.client, p.baseURL+"/models", map[string]string{"Authorization": "Bearer " + p.apiKey})
+}
+
 // buildRequest 构建请求体
 func (p *OpenAIProvider) buildRequest(messages []session.Message, tools []Tool, stream bool) map[string]interface{} {
 	reqBody := map[string]interface{}{
@@ -301,7 +312,7 @@ func NewAnthropicProvider(apiKey, model string, timeout, maxRetries int, log *lo
 		model:      model,
 		timeout:    time.Duration(timeout) * time.Second,
 		maxRetries: maxRetries,
-		client:     &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		client:     httpclient.New(time.Duration(timeout) * time.Second),
 		log:        log,
 	}
 }
@@ -323,6 +334,14 @@ func (p *AnthropicProvider) GetModel() string {
 	return p.model
 }
 
+// Ping 探测API是否可达
+func (p *AnthropicProvider) Ping() error {
+	return ping(p.client, "https://api.anthropic.com/v1/models", map[string]string{
+		"x-api-key":         p.apiKey,
+		"anthropic-version": "2023-06-01",
+	})
+}
+
 // buildRequest 构建请求体
 func (p *AnthropicProvider) buildRequest(messages []session.Message, tools []Tool, stream bool) map[string]interface{} {
 	systemMsg, userMsgs := p.separateMessages(messages)
@@ -502,18 +521,25 @@ func NewOllamaProvider(baseURL, model string, timeout, maxRetries int, log *logg
 		model:      model,
 		timeout:    time.Duration(timeout) * time.Second,
 		maxRetries: maxRetries,
-		client:     &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		client:     httpclient.New(time.Duration(timeout) * time.Second),
 		log:        log,
 	}
 }
 
 // Chat 发送聊天请求
 func (p *OllamaProvider) Chat(messages []session.Message, tools []Tool) (*Response, error) {
+	return p.chat(messages, ChatOptions{})
+}
+
+func (p *OllamaProvider) chat(messages []session.Message, opts ChatOptions) (*Response, error) {
 	reqBody := map[string]interface{}{
 		"model":    p.model,
 		"messages": p.convertMessages(messages),
 		"stream":   false,
 	}
+	if opts.MaxTokens > 0 {
+		reqBody["options"] = map[string]interface{}{"num_predict": opts.MaxTokens}
+	}
 
 	data, err := json.Marshal(reqBody)
 	if err != nil {
@@ -565,6 +591,33 @@ func (p *OllamaProvider) GetModel() string {
 	return p.model
 }
 
+// Ping 探测服务是否可达
+func (p *OllamaProvider) Ping() error {
+	return ping(p.client, p.baseURL+"/api/tags", nil)
+}
+
+// ping 发送GET请求，能收到非5xx响应即视为可达
+func ping(client *http.Client, url string, headers map[string]string) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("llm endpoint unhealthy: %s", resp.Status)
+	}
+	return nil
+}
+
 // convertMessages 转换消息格式
 func (p *OllamaProvider) convertMessages(messages []session.Message) []map[string]interface{} {
 	result := make([]map[string]interface{}, len(messages))
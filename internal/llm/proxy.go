@@ -0,0 +1,38 @@
+package llm
+
+import (
+	"net/http"
+
+	"github.com/HaohanHe/mujibot/internal/httpclient"
+)
+
+// clientHolder 可替换连接池的提供商
+type clientHolder interface {
+	httpClient() *http.Client
+}
+
+// UseProxy 让提供商通过指定代理（llm.proxy）访问API，proxy 为空时沿用全局出站配置
+func UseProxy(p Provider, proxy string) error {
+	h, ok := p.(clientHolder)
+	if !ok || proxy == "" {
+		return nil
+	}
+	rt, err := httpclient.WithProxy(proxy)
+	if err != nil {
+		return err
+	}
+	h.httpClient().Transport = rt
+	return nil
+}
+
+func (p *OpenAIProvider) httpClient() *http.Client {
+	return p.client
+}
+
+func (p *AnthropicProvider) httpClient() *http.Client {
+	return p.client
+}
+
+func (p *OllamaProvider) httpClient() *http.Client {
+	return p.client
+}
@@ -0,0 +1,282 @@
+package llm
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/session"
+)
+
+// Recording 一次记录的LLM交互，以JSONL保存
+type Recording struct {
+	Time     time.Time         `json:"time"`
+	Key      string            `json:"key"`
+	Model    string            `json:"model"`
+	Messages []session.Message `json:"messages"`
+	Tools    []string          `json:"tools,omitempty"`
+	Response RecordedResponse  `json:"response"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// RecordedResponse 记录的响应
+type RecordedResponse struct {
+	Content          string             `json:"content"`
+	ToolCalls        []session.ToolCall `json:"toolCalls,omitempty"`
+	PromptTokens     int                `json:"promptTokens,omitempty"`
+	CompletionTokens int                `json:"completionTokens,omitempty"`
+}
+
+// RecordingKey 计算请求的匹配键：模型、非系统消息的角色、内容和工具调用，加上工具名。
+// 系统提示词含当前时间，消息时间戳每次不同，都不参与匹配
+func RecordingKey(model string, messages []session.Message, tools []Tool) string {
+	type keyMessage struct {
+		Role      string             `json:"r"`
+		Content   string             `json:"c"`
+		ToolCalls []session.ToolCall `json:"t,omitempty"`
+	}
+	var key struct {
+		Model    string       `json:"model"`
+		Messages []keyMessage `json:"m"`
+		Tools    []string     `json:"t"`
+	}
+	for _, m := range messages {
+		if m.Role != "system" {
+			key.Messages = append(key.Messages, keyMessage{Role: m.Role, Content: m.Content, ToolCalls: m.ToolCalls})
+		}
+	}
+	key.Model = model
+	key.Tools = toolNames(tools)
+	data, _ := json.Marshal(key)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+func toolNames(tools []Tool) []string {
+	names := make([]string, 0, len(tools))
+	for _, t := range tools {
+		names = append(names, t.Function.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RecordingFile 以JSONL追加写入记录，多个 Recorder 可共用
+type RecordingFile struct {
+	Path string
+	mu   sync.Mutex
+	f    *os.File
+}
+
+// Write 追加一条记录
+func (w *RecordingFile) Write(rec Recording) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		if err := os.MkdirAll(filepath.Dir(w.Path), 0755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(w.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		w.f = f
+	}
+	_, err = w.f.Write(append(data, '\n'))
+	return err
+}
+
+// Close 关闭文件
+func (w *RecordingFile) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
+
+// Recorder 把提供商的每次请求和响应写入 RecordingFile，供 Replayer 回放
+type Recorder struct {
+	next    Provider
+	out     *RecordingFile
+	onError func(error)
+}
+
+// NewRecorder 包装提供商，需在 UseProxy 和 EnableDebugLog 之后调用。写入失败时调用 onError，不影响请求
+func NewRecorder(next Provider, out *RecordingFile, onError func(error)) *Recorder {
+	return &Recorder{next: next, out: out, onError: onError}
+}
+
+// Chat 调用被包装的提供商并记录
+func (r *Recorder) Chat(messages []session.Message, tools []Tool) (*Response, error) {
+	resp, err := r.next.Chat(messages, tools)
+	r.record(messages, tools, resp, err)
+	return resp, err
+}
+
+// ChatStream 调用被包装的提供商并记录完整响应
+func (r *Recorder) ChatStream(messages []session.Message, tools []Tool, callback func(chunk string)) (*Response, error) {
+	resp, err := r.next.ChatStream(messages, tools, callback)
+	r.record(messages, tools, resp, err)
+	return resp, err
+}
+
+// GetModel 获取模型名称
+func (r *Recorder) GetModel() string {
+	return r.next.GetModel()
+}
+
+// Ping 探测被包装的提供商
+func (r *Recorder) Ping() error {
+	if p, ok := r.next.(Pinger); ok {
+		return p.Ping()
+	}
+	return nil
+}
+
+func (r *Recorder) record(messages []session.Message, tools []Tool, resp *Response, err error) {
+	rec := Recording{
+		Time:     time.Now(),
+		Key:      RecordingKey(r.next.GetModel(), messages, tools),
+		Model:    r.next.GetModel(),
+		Messages: messages,
+		Tools:    toolNames(tools),
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	} else if resp != nil {
+		rec.Response = RecordedResponse{
+			Content:          resp.Content,
+			ToolCalls:        resp.ToolCalls,
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+		}
+	}
+	if err := r.out.Write(rec); err != nil && r.onError != nil {
+		r.onError(err)
+	}
+}
+
+// ErrNotRecorded 回放时没有与请求匹配的记录
+var ErrNotRecorded = errors.New("no recorded llm response for this request")
+
+// Replayer 按记录文件回放响应，不访问网络
+type Replayer struct {
+	model string
+	mu    sync.Mutex
+	// byKey 同一请求的多条记录按顺序返回，用完后重复返回最后一条
+	byKey map[string][]Recording
+	used  map[string]int
+}
+
+// NewReplayer 读取 Recorder 写入的文件，只回放 model 的记录
+func NewReplayer(path, model string) (*Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open llm recordings: %w", err)
+	}
+	defer f.Close()
+
+	r := &Replayer{model: model, byKey: make(map[string][]Recording), used: make(map[string]int)}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec Recording
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("invalid llm recording at %s:%d: %w", path, line, err)
+		}
+		// 手工编辑过的记录可以不填 key
+		if rec.Key == "" {
+			rec.Key = RecordingKey(rec.Model, rec.Messages, namedTools(rec.Tools))
+		}
+		r.byKey[rec.Key] = append(r.byKey[rec.Key], rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read llm recordings: %w", err)
+	}
+	return r, nil
+}
+
+func namedTools(names []string) []Tool {
+	tools := make([]Tool, len(names))
+	for i, name := range names {
+		tools[i].Function.Name = name
+	}
+	return tools
+}
+
+// Chat 返回与请求匹配的记录
+func (r *Replayer) Chat(messages []session.Message, tools []Tool) (*Response, error) {
+	key := RecordingKey(r.model, messages, tools)
+
+	r.mu.Lock()
+	recs := r.byKey[key]
+	if len(recs) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w (key %s)", ErrNotRecorded, key)
+	}
+	i := r.used[key]
+	if i >= len(recs) {
+		i = len(recs) - 1
+	} else {
+		r.used[key] = i + 1
+	}
+	rec := recs[i]
+	r.mu.Unlock()
+
+	if rec.Error != "" {
+		return nil, errors.New(rec.Error)
+	}
+	return &Response{
+		Content:   rec.Response.Content,
+		ToolCalls: rec.Response.ToolCalls,
+		Usage: Usage{
+			PromptTokens:     rec.Response.PromptTokens,
+			CompletionTokens: rec.Response.CompletionTokens,
+			TotalTokens:      rec.Response.PromptTokens + rec.Response.CompletionTokens,
+		},
+	}, nil
+}
+
+// ChatStream 回放时整段内容作为一个分块回调
+func (r *Replayer) ChatStream(messages []session.Message, tools []Tool, callback func(chunk string)) (*Response, error) {
+	resp, err := r.Chat(messages, tools)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Content != "" && callback != nil {
+		callback(resp.Content)
+	}
+	return resp, nil
+}
+
+// GetModel 获取模型名称
+func (r *Replayer) GetModel() string {
+	return r.model
+}
+
+// Ping 回放不需要网络，总是成功
+func (r *Replayer) Ping() error {
+	return nil
+}
@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/session"
+)
+
+// scriptedProvider 按顺序返回预设的回复
+type scriptedProvider struct {
+	replies []string
+	calls   int
+}
+
+func (p *scriptedProvider) Chat(messages []session.Message, tools []Tool) (*Response, error) {
+	if p.calls >= len(p.replies) {
+		return nil, errors.New("llm api error: 429")
+	}
+	p.calls++
+	return &Response{Content: p.replies[p.calls-1], Usage: Usage{PromptTokens: 10, CompletionTokens: 3}}, nil
+}
+
+func (p *scriptedProvider) ChatStream(messages []session.Message, tools []Tool, callback func(chunk string)) (*Response, error) {
+	resp, err := p.Chat(messages, tools)
+	if err == nil {
+		callback(resp.Content)
+	}
+	return resp, err
+}
+
+func (p *scriptedProvider) GetModel() string {
+	return "test-model"
+}
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recordings", "llm.jsonl")
+	tools := []Tool{{Type: "function", Function: Function{Name: "get_weather"}}}
+	conversation := func(system string) []session.Message {
+		return []session.Message{
+			{Role: "system", Content: system},
+			{Role: "user", Content: "hello", Timestamp: time.Now()},
+		}
+	}
+
+	out := &RecordingFile{Path: path}
+	rec := NewRecorder(&scriptedProvider{replies: []string{"hi", "hi again"}}, out, func(err error) { t.Error(err) })
+	rec.Chat(conversation("now 10:00"), tools)
+	var streamed string
+	rec.ChatStream(conversation("now 10:01"), tools, func(chunk string) { streamed += chunk })
+	rec.Chat([]session.Message{{Role: "user", Content: "bye"}}, nil)
+	out.Close()
+	if streamed != "hi again" {
+		t.Fatalf("streamed = %q", streamed)
+	}
+
+	r, err := NewReplayer(path, "test-model")
+	if err != nil {
+		t.Fatalf("NewReplayer() error = %v", err)
+	}
+	// 系统提示词和时间戳不同也能匹配，同一请求按记录顺序返回，用完后重复最后一条
+	for _, want := range []string{"hi", "hi again", "hi again"} {
+		resp, err := r.Chat(conversation("now 18:30"), tools)
+		if err != nil || resp.Content != want {
+			t.Fatalf("Chat() = %+v, %v, want %q", resp, err, want)
+		}
+		if resp.Usage.TotalTokens != 13 {
+			t.Errorf("usage = %+v", resp.Usage)
+		}
+	}
+	if _, err := r.Chat([]session.Message{{Role: "user", Content: "bye"}}, nil); err == nil || err.Error() != "llm api error: 429" {
+		t.Errorf("recorded error = %v", err)
+	}
+	if _, err := r.Chat(conversation("x"), nil); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("different tools error = %v", err)
+	}
+
+	other, _ := NewReplayer(path, "other-model")
+	if _, err := other.Chat(conversation("x"), tools); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("other model error = %v", err)
+	}
+	if _, err := NewReplayer(filepath.Join(t.TempDir(), "missing.jsonl"), "test-model"); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Record 导出的日志记录
+type Record struct {
+	Time    time.Time
+	Level   Level
+	Module  string
+	Message string
+	Fields  map[string]interface{}
+}
+
+// Exporter 日志导出器
+type Exporter interface {
+	Export(rec Record)
+	Close() error
+}
+
+// ExporterConfig 日志导出配置
+type ExporterConfig struct {
+	Type  string // syslog 或 otlp
+	Level string // 导出的最低级别，为空时与全局一致
+
+	// syslog
+	Network string // 为空时连接本地syslog/journald
+	Address string
+	Tag     string
+
+	// otlp
+	Endpoint      string // 如 http://collector:4318/v1/logs
+	Headers       map[string]string
+	ServiceName   string
+	BatchSize     int
+	FlushInterval int // 秒
+}
+
+// leveledExporter 带最低级别过滤的导出器
+type leveledExporter struct {
+	Exporter
+	min Level
+}
+
+// newExporter 根据配置创建导出器
+func newExporter(cfg ExporterConfig) (Exporter, error) {
+	switch cfg.Type {
+	case "syslog":
+		return newSyslogExporter(cfg)
+	case "otlp":
+		return newOTLPExporter(cfg)
+	default:
+		return nil, fmt.Errorf("unknown log exporter: %s", cfg.Type)
+	}
+}
+
+// exportError 导出失败时写到stderr，避免递归写日志
+func exportError(name string, err error) {
+	fmt.Fprintf(os.Stderr, "log exporter %s: %v\n", name, err)
+}
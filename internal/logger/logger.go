@@ -1,12 +1,16 @@
 package logger
 
 import (
+	"bufio"
 	"compress/gzip"
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -36,6 +40,29 @@ func (l Level) String() string {
 	}
 }
 
+// slogLevel 转换为slog级别
+func (l Level) slogLevel() slog.Level {
+	switch l {
+	case DEBUG:
+		return slog.LevelDebug
+	case WARN:
+		return slog.LevelWarn
+	case ERROR:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// ValidLevel 检查是否为有效的日志级别名称
+func ValidLevel(s string) bool {
+	switch s {
+	case "debug", "info", "warn", "error":
+		return true
+	}
+	return false
+}
+
 // ParseLevel 解析日志级别
 func ParseLevel(s string) Level {
 	switch s {
@@ -56,73 +83,172 @@ func ParseLevel(s string) Level {
 type LogEntry struct {
 	Time    string                 `json:"time"`
 	Level   string                 `json:"level"`
+	Module  string                 `json:"module,omitempty"`
 	Message string                 `json:"message"`
 	Fields  map[string]interface{} `json:"fields,omitempty"`
 }
 
-// Logger 日志记录器
+// Logger 日志记录器，基于 log/slog
 type Logger struct {
-	level      Level
-	output     io.Writer
-	file       *os.File
-	filePath   string
-	maxSize    int64
-	format     string
-	mu         sync.Mutex
-	buffer     []LogEntry
-	bufferSize int
-	stopCh     chan struct{}
+	core   *core
+	module string
+	fields []interface{}
+	slog   *slog.Logger
 }
 
 // Config 日志配置
 type Config struct {
-	Level   string
-	File    string
-	MaxSize int
-	Format  string
+	Level      string
+	File       string
+	MaxSize    int
+	MaxBackups int // 保留的轮转文件数，0 表示不限制
+	MaxAgeDays int // 轮转文件保留天数，0 表示不限制
+	Format     string
+	Levels     map[string]string // 按模块覆盖日志级别，如 {"telegram": "debug"}
+	Exporters  []ExporterConfig  // 额外的日志输出（syslog、OTLP）
+	Stderr     bool              // 未配置 File 时输出到标准错误，标准输出留给 --json 启动事件
+}
+
+// core 所有模块共享的输出、级别与最近日志
+type core struct {
+	writer *rotatingWriter
+	format string
+
+	levelMu sync.RWMutex
+	level   Level
+	modules map[string]Level
+
+	recentMu   sync.Mutex
+	recent     []LogEntry
+	recentSize int
+
+	exporters []leveledExporter
+
+	index fileIndex
+
+	stopCh    chan struct{}
+	closeOnce sync.Once
 }
 
 // New 创建日志记录器
 func New(cfg Config) (*Logger, error) {
-	l := &Logger{
-		level:      ParseLevel(cfg.Level),
+	w := &rotatingWriter{
 		filePath:   cfg.File,
 		maxSize:    int64(cfg.MaxSize) * 1024 * 1024,
-		format:     cfg.Format,
-		buffer:     make([]LogEntry, 0, 100),
-		bufferSize: 100,
-		stopCh:     make(chan struct{}),
+		maxBackups: cfg.MaxBackups,
+		maxAge:     time.Duration(cfg.MaxAgeDays) * 24 * time.Hour,
+		pending:    make(map[string]bool),
 	}
 
 	if cfg.File != "" {
-		if err := l.openFile(); err != nil {
+		if err := w.openFile(); err != nil {
 			return nil, err
 		}
+		w.prune()
+	} else if cfg.Stderr {
+		w.setOutput(os.Stderr)
 	} else {
-		l.output = os.Stdout
+		w.setOutput(os.Stdout)
+	}
+
+	c := &core{
+		writer:     w,
+		format:     cfg.Format,
+		level:      ParseLevel(cfg.Level),
+		recentSize: 100,
+		stopCh:     make(chan struct{}),
+	}
+	c.setModuleLevels(cfg.Levels)
+
+	for _, ec := range cfg.Exporters {
+		e, err := newExporter(ec)
+		if err != nil {
+			// 导出器不可用不影响本地日志
+			exportError(ec.Type, err)
+			continue
+		}
+		min := DEBUG
+		if ec.Level != "" {
+			min = ParseLevel(ec.Level)
+		}
+		c.exporters = append(c.exporters, leveledExporter{Exporter: e, min: min})
 	}
 
-	go l.flushLoop()
+	go c.flushLoop()
 
-	return l, nil
+	return c.logger(""), nil
 }
 
-// openFile 打开日志文件
-func (l *Logger) openFile() error {
-	// 确保目录存在
-	dir := filepath.Dir(l.filePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
+// Module 获取子模块日志记录器，输出带 module 字段并使用该模块的级别覆盖
+func (l *Logger) Module(name string) *Logger {
+	return l.core.logger(name)
+}
+
+// logger 创建指定模块的日志记录器
+func (c *core) logger(module string) *Logger {
+	opts := &slog.HandlerOptions{
+		Level:       moduleLeveler{core: c, module: module},
+		ReplaceAttr: redact,
 	}
 
-	file, err := os.OpenFile(l.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		return err
+	var handler slog.Handler
+	if c.format == "json" {
+		handler = slog.NewJSONHandler(c.writer, opts)
+	} else {
+		handler = slog.NewTextHandler(c.writer, opts)
 	}
 
-	l.file = file
-	l.output = file
-	return nil
+	s := slog.New(handler)
+	if module != "" {
+		s = s.With("module", module)
+	}
+
+	return &Logger{core: c, module: module, slog: s}
+}
+
+// With 返回附加固定字段的日志记录器
+func (l *Logger) With(fields ...interface{}) *Logger {
+	return &Logger{
+		core:   l.core,
+		module: l.module,
+		fields: append(l.fields[:len(l.fields):len(l.fields)], fields...),
+		slog:   l.slog.With(fields...),
+	}
+}
+
+// moduleLeveler 动态读取模块级别，支持运行时调整
+type moduleLeveler struct {
+	core   *core
+	module string
+}
+
+func (m moduleLeveler) Level() slog.Level {
+	return m.core.levelFor(m.module).slogLevel()
+}
+
+// levelFor 获取模块生效的级别
+func (c *core) levelFor(module string) Level {
+	c.levelMu.RLock()
+	defer c.levelMu.RUnlock()
+
+	if module != "" {
+		if level, ok := c.modules[module]; ok {
+			return level
+		}
+	}
+	return c.level
+}
+
+// setModuleLevels 设置模块级别覆盖
+func (c *core) setModuleLevels(levels map[string]string) {
+	modules := make(map[string]Level, len(levels))
+	for module, level := range levels {
+		modules[module] = ParseLevel(level)
+	}
+
+	c.levelMu.Lock()
+	c.modules = modules
+	c.levelMu.Unlock()
 }
 
 // Debug 记录调试日志
@@ -147,30 +273,44 @@ func (l *Logger) Error(msg string, fields ...interface{}) {
 
 // log 记录日志
 func (l *Logger) log(level Level, msg string, fields ...interface{}) {
-	if level < l.level {
+	ctx := context.Background()
+	if !l.slog.Enabled(ctx, level.slogLevel()) {
 		return
 	}
 
-	entry := LogEntry{
-		Time:    time.Now().Format(time.RFC3339),
+	now := time.Now()
+	parsed := parseFields(append(l.fields[:len(l.fields):len(l.fields)], fields...)...)
+
+	l.core.remember(LogEntry{
+		Time:    now.Format(time.RFC3339),
 		Level:   level.String(),
+		Module:  l.module,
 		Message: msg,
-		Fields:  l.parseFields(fields...),
-	}
+		Fields:  parsed,
+	})
+
+	l.slog.Log(ctx, level.slogLevel(), msg, fields...)
 
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	for _, e := range l.core.exporters {
+		if level >= e.min {
+			e.Export(Record{Time: now, Level: level, Module: l.module, Message: msg, Fields: parsed})
+		}
+	}
+}
 
-	l.buffer = append(l.buffer, entry)
+// remember 保存最近的日志条目
+func (c *core) remember(entry LogEntry) {
+	c.recentMu.Lock()
+	defer c.recentMu.Unlock()
 
-	// 如果缓冲区满了，立即刷新
-	if len(l.buffer) >= l.bufferSize {
-		l.flush()
+	c.recent = append(c.recent, entry)
+	if len(c.recent) > c.recentSize {
+		c.recent = c.recent[len(c.recent)-c.recentSize:]
 	}
 }
 
 // parseFields 解析字段
-func (l *Logger) parseFields(fields ...interface{}) map[string]interface{} {
+func parseFields(fields ...interface{}) map[string]interface{} {
 	if len(fields) == 0 {
 		return nil
 	}
@@ -182,7 +322,7 @@ func (l *Logger) parseFields(fields ...interface{}) map[string]interface{} {
 			continue
 		}
 		// 隐藏敏感信息
-		if l.isSensitive(key) {
+		if isSensitive(key) {
 			result[key] = "***"
 		} else {
 			result[key] = fields[i+1]
@@ -191,11 +331,20 @@ func (l *Logger) parseFields(fields ...interface{}) map[string]interface{} {
 	return result
 }
 
+// redact slog属性过滤，隐藏敏感字段
+func redact(groups []string, a slog.Attr) slog.Attr {
+	if isSensitive(a.Key) {
+		return slog.String(a.Key, "***")
+	}
+	return a
+}
+
 // isSensitive 检查是否为敏感字段
-func (l *Logger) isSensitive(key string) bool {
-	sensitive := []string{"token", "apiKey", "secret", "password", "credential"}
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	sensitive := []string{"token", "apikey", "secret", "password", "credential"}
 	for _, s := range sensitive {
-		if containsIgnoreCase(key, s) {
+		if strings.Contains(key, s) {
 			return true
 		}
 	}
@@ -203,164 +352,334 @@ func (l *Logger) isSensitive(key string) bool {
 }
 
 // flushLoop 定期刷新日志
-func (l *Logger) flushLoop() {
+func (c *core) flushLoop() {
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			l.mu.Lock()
-			if len(l.buffer) > 0 {
-				l.flush()
-			}
-			l.mu.Unlock()
-		case <-l.stopCh:
+			c.writer.Flush()
+		case <-c.stopCh:
 			return
 		}
 	}
 }
 
-// flush 刷新日志到输出
-func (l *Logger) flush() {
-	if len(l.buffer) == 0 {
-		return
+// Close 关闭日志记录器（所有模块共享输出，关闭任意一个即全部关闭）
+func (l *Logger) Close() error {
+	var err error
+	l.core.closeOnce.Do(func() {
+		close(l.core.stopCh)
+		for _, e := range l.core.exporters {
+			e.Close()
+		}
+		err = l.core.writer.Close()
+	})
+	return err
+}
+
+// GetLevel 获取当前日志级别
+func (l *Logger) GetLevel() Level {
+	return l.core.levelFor(l.module)
+}
+
+// SetLevel 设置全局日志级别
+func (l *Logger) SetLevel(level Level) {
+	l.core.levelMu.Lock()
+	defer l.core.levelMu.Unlock()
+	l.core.level = level
+}
+
+// SetModuleLevels 设置按模块覆盖的日志级别
+func (l *Logger) SetModuleLevels(levels map[string]string) {
+	l.core.setModuleLevels(levels)
+}
+
+// Levels 获取全局日志级别与按模块覆盖的级别
+func (l *Logger) Levels() (string, map[string]string) {
+	l.core.levelMu.RLock()
+	defer l.core.levelMu.RUnlock()
+
+	modules := make(map[string]string, len(l.core.modules))
+	for module, level := range l.core.modules {
+		modules[module] = strings.ToLower(level.String())
 	}
+	return strings.ToLower(l.core.level.String()), modules
+}
 
-	// 检查是否需要轮转
-	if l.file != nil && l.maxSize > 0 {
-		if info, err := l.file.Stat(); err == nil && info.Size() > l.maxSize {
-			l.rotate()
+// ApplyLevels 运行时调整日志级别，level 为空时保持不变；
+// modules 合并到现有模块覆盖中，值为空或 "default" 时移除该模块的覆盖
+func (l *Logger) ApplyLevels(level string, modules map[string]string) error {
+	if level != "" && !ValidLevel(level) {
+		return fmt.Errorf("invalid log level: %s", level)
+	}
+	for module, lv := range modules {
+		if lv != "" && lv != "default" && !ValidLevel(lv) {
+			return fmt.Errorf("invalid log level for %s: %s", module, lv)
 		}
 	}
 
-	for _, entry := range l.buffer {
-		var line string
-		if l.format == "json" {
-			data, _ := json.Marshal(entry)
-			line = string(data) + "\n"
+	c := l.core
+	c.levelMu.Lock()
+	defer c.levelMu.Unlock()
+
+	if level != "" {
+		c.level = ParseLevel(level)
+	}
+	next := make(map[string]Level, len(c.modules)+len(modules))
+	for module, lv := range c.modules {
+		next[module] = lv
+	}
+	for module, lv := range modules {
+		if lv == "" || lv == "default" {
+			delete(next, module)
 		} else {
-			line = fmt.Sprintf("[%s] %s: %s", entry.Time, entry.Level, entry.Message)
-			if len(entry.Fields) > 0 {
-				data, _ := json.Marshal(entry.Fields)
-				line += " " + string(data)
-			}
-			line += "\n"
+			next[module] = ParseLevel(lv)
 		}
-		l.output.Write([]byte(line))
 	}
-
-	// 清空缓冲区
-	l.buffer = l.buffer[:0]
+	c.modules = next
+	return nil
 }
 
-// rotate 轮转日志文件
-func (l *Logger) rotate() {
-	if l.file == nil {
-		return
+// GetRecentLogs 获取最近的日志条目（用于Web调试界面）
+func (l *Logger) GetRecentLogs(count int) []LogEntry {
+	c := l.core
+	c.recentMu.Lock()
+	defer c.recentMu.Unlock()
+
+	if len(c.recent) == 0 {
+		return nil
 	}
 
-	// 关闭当前文件
-	l.file.Close()
+	if count > len(c.recent) {
+		count = len(c.recent)
+	}
 
-	// 重命名旧文件
-	timestamp := time.Now().Format("20060102-150405")
-	backupPath := l.filePath + "." + timestamp + ".gz"
+	// 返回最近的日志
+	start := len(c.recent) - count
+	result := make([]LogEntry, count)
+	copy(result, c.recent[start:])
+	return result
+}
 
-	// 压缩旧文件
-	go func() {
-		oldFile, err := os.Open(l.filePath)
-		if err != nil {
-			return
-		}
-		defer oldFile.Close()
+// rotatingWriter 带缓冲和按大小轮转的输出
+type rotatingWriter struct {
+	mu         sync.Mutex
+	filePath   string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+	file       *os.File
+	buf        *bufio.Writer
+	closed     bool
 
-		gzipFile, err := os.Create(backupPath)
-		if err != nil {
-			return
-		}
-		defer gzipFile.Close()
+	// 正在压缩的轮转文件，清理时跳过
+	pendingMu   sync.Mutex
+	pending     map[string]bool
+	compressing sync.WaitGroup
+}
 
-		gzipWriter := gzip.NewWriter(gzipFile)
-		defer gzipWriter.Close()
+func (w *rotatingWriter) setOutput(out io.Writer) {
+	w.buf = bufio.NewWriterSize(out, 32*1024)
+}
 
-		io.Copy(gzipWriter, oldFile)
-		os.Remove(l.filePath)
-	}()
+// openFile 打开日志文件
+func (w *rotatingWriter) openFile() error {
+	// 确保目录存在
+	dir := filepath.Dir(w.filePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
 
-	// 打开新文件
-	l.openFile()
+	file, err := os.OpenFile(w.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	w.file = file
+	w.setOutput(file)
+	return nil
 }
 
-// Close 关闭日志记录器
-func (l *Logger) Close() error {
-	close(l.stopCh)
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.Stderr.Write(p)
+	}
+	return w.buf.Write(p)
+}
 
-	l.mu.Lock()
-	defer l.mu.Unlock()
+// Flush 刷新缓冲区，必要时轮转
+func (w *rotatingWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 
-	l.flush()
+	if w.closed {
+		return
+	}
+	w.buf.Flush()
 
-	if l.file != nil {
-		return l.file.Close()
+	// 检查是否需要轮转
+	if w.file != nil && w.maxSize > 0 {
+		if info, err := w.file.Stat(); err == nil && info.Size() > w.maxSize {
+			w.rotate()
+		}
 	}
-	return nil
 }
 
-// GetLevel 获取当前日志级别
-func (l *Logger) GetLevel() Level {
-	return l.level
+// rotate 轮转日志文件，调用方需持有锁
+func (w *rotatingWriter) rotate() {
+	// 关闭当前文件
+	w.file.Close()
+
+	// 重命名旧文件后异步压缩
+	rotatedPath := w.rotatedPath(time.Now())
+	if err := os.Rename(w.filePath, rotatedPath); err == nil {
+		w.pendingMu.Lock()
+		w.pending[rotatedPath] = true
+		w.pendingMu.Unlock()
+
+		w.compressing.Add(1)
+		go func() {
+			defer w.compressing.Done()
+			compress(rotatedPath)
+
+			w.pendingMu.Lock()
+			delete(w.pending, rotatedPath)
+			w.pendingMu.Unlock()
+
+			w.prune()
+		}()
+	}
+	w.prune()
+
+	// 打开新文件
+	if err := w.openFile(); err != nil {
+		w.file = nil
+		w.setOutput(os.Stderr)
+	}
 }
 
-// SetLevel 设置日志级别
-func (l *Logger) SetLevel(level Level) {
-	l.level = level
+// rotatedPath 生成轮转文件名，同一秒内多次轮转时追加序号，避免覆盖仍在压缩的文件
+func (w *rotatingWriter) rotatedPath(now time.Time) string {
+	base := w.filePath + "." + now.Format("20060102-150405")
+	path := base
+	for i := 1; ; i++ {
+		if !exists(path) && !exists(path+".gz") {
+			return path
+		}
+		path = fmt.Sprintf("%s-%d", base, i)
+	}
 }
 
-// GetRecentLogs 获取最近的日志条目（用于Web调试界面）
-func (l *Logger) GetRecentLogs(count int) []LogEntry {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+// prune 按数量和时间清理旧的轮转文件，跳过正在压缩的文件
+func (w *rotatingWriter) prune() {
+	if w.maxBackups <= 0 && w.maxAge <= 0 {
+		return
+	}
 
-	if len(l.buffer) == 0 {
-		return nil
+	prefix := filepath.Base(w.filePath) + "."
+	entries, err := os.ReadDir(filepath.Dir(w.filePath))
+	if err != nil {
+		return
 	}
 
-	if count > len(l.buffer) {
-		count = len(l.buffer)
+	type backup struct {
+		path    string
+		key     string
+		modTime time.Time
+	}
+	var backups []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{
+			path:    filepath.Join(filepath.Dir(w.filePath), name),
+			key:     strings.TrimSuffix(name, ".gz"),
+			modTime: info.ModTime(),
+		})
 	}
 
-	// 返回最近的日志
-	start := len(l.buffer) - count
-	result := make([]LogEntry, count)
-	copy(result, l.buffer[start:])
-	return result
-}
+	// 按轮转时间倒序，最新的在前
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].key > backups[j].key
+	})
 
-// containsIgnoreCase 检查字符串是否包含子串（忽略大小写）
-func containsIgnoreCase(s, substr string) bool {
-	return len(s) >= len(substr) && containsIgnoreCaseHelper(s, substr)
-}
+	w.pendingMu.Lock()
+	defer w.pendingMu.Unlock()
 
-func containsIgnoreCaseHelper(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		match := true
-		for j := 0; j < len(substr); j++ {
-			if toLower(s[i+j]) != toLower(substr[j]) {
-				match = false
-				break
-			}
+	// 压缩过程中原文件与 .gz 同时存在，按同一份备份计数
+	count := 0
+	for i, b := range backups {
+		if i == 0 || b.key != backups[i-1].key {
+			count++
 		}
-		if match {
-			return true
+		if w.pending[filepath.Join(filepath.Dir(w.filePath), b.key)] {
+			continue
+		}
+		expired := w.maxAge > 0 && time.Since(b.modTime) > w.maxAge
+		if expired || (w.maxBackups > 0 && count > w.maxBackups) {
+			os.Remove(b.path)
 		}
 	}
-	return false
 }
 
-func toLower(c byte) byte {
-	if c >= 'A' && c <= 'Z' {
-		return c + ('a' - 'A')
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// compress 压缩轮转出的日志文件
+func compress(path string) {
+	oldFile, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer oldFile.Close()
+
+	gzipFile, err := os.Create(path + ".gz")
+	if err != nil {
+		return
+	}
+	defer gzipFile.Close()
+
+	gzipWriter := gzip.NewWriter(gzipFile)
+	if _, err := io.Copy(gzipWriter, oldFile); err != nil {
+		gzipWriter.Close()
+		return
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return
+	}
+	os.Remove(path)
+}
+
+// Close 刷新并关闭
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	w.buf.Flush()
+
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
 	}
-	return c
+
+	// 等待后台压缩结束，避免进程退出时留下不完整的 .gz
+	w.compressing.Wait()
+	return err
 }
@@ -0,0 +1,377 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func readLines(t *testing.T, path string) []map[string]interface{} {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("invalid json line %q: %v", line, err)
+		}
+		lines = append(lines, m)
+	}
+	return lines
+}
+
+func TestModuleLevels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	log, err := New(Config{
+		Level:  "info",
+		File:   path,
+		Format: "json",
+		Levels: map[string]string{"telegram": "debug", "llm": "warn"},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	log.Debug("root debug")
+	log.Info("root info", "apiKey", "sk-secret")
+	log.Module("telegram").Debug("telegram debug")
+	log.Module("llm").Info("llm info")
+	log.Module("llm").Warn("llm warn")
+	log.Close()
+
+	lines := readLines(t, path)
+	var msgs []string
+	for _, l := range lines {
+		msgs = append(msgs, l["msg"].(string))
+	}
+	want := []string{"root info", "telegram debug", "llm warn"}
+	if strings.Join(msgs, ",") != strings.Join(want, ",") {
+		t.Fatalf("messages = %v, want %v", msgs, want)
+	}
+
+	if lines[0]["apiKey"] != "***" {
+		t.Errorf("sensitive field should be redacted, got: %v", lines[0]["apiKey"])
+	}
+	if lines[1]["module"] != "telegram" {
+		t.Errorf("module field missing: %v", lines[1])
+	}
+}
+
+func TestSetModuleLevels(t *testing.T) {
+	log, _ := New(Config{Level: "error"})
+	defer log.Close()
+
+	tg := log.Module("telegram")
+	if tg.GetLevel() != ERROR {
+		t.Errorf("module should inherit global level, got: %s", tg.GetLevel())
+	}
+
+	log.SetModuleLevels(map[string]string{"telegram": "debug"})
+	if tg.GetLevel() != DEBUG {
+		t.Errorf("module override should apply to existing loggers, got: %s", tg.GetLevel())
+	}
+
+	tg.Debug("hello")
+	recent := log.GetRecentLogs(10)
+	if len(recent) != 1 || recent[0].Module != "telegram" {
+		t.Errorf("unexpected recent logs: %+v", recent)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+		records  []map[string]interface{}
+		auth     string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			ResourceLogs []struct {
+				ScopeLogs []struct {
+					LogRecords []map[string]interface{} `json:"logRecords"`
+				} `json:"scopeLogs"`
+			} `json:"resourceLogs"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		auth = r.Header.Get("Authorization")
+		for _, rl := range payload.ResourceLogs {
+			for _, sl := range rl.ScopeLogs {
+				records = append(records, sl.LogRecords...)
+			}
+		}
+	}))
+	defer server.Close()
+
+	log, err := New(Config{
+		Level: "debug",
+		File:  filepath.Join(t.TempDir(), "test.log"),
+		Exporters: []ExporterConfig{{
+			Type:      "otlp",
+			Level:     "info",
+			Endpoint:  server.URL,
+			Headers:   map[string]string{"Authorization": "Bearer test"},
+			BatchSize: 2,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	log.Debug("skipped")
+	log.Module("agent").Info("hello", "user", "alice")
+	log.Warn("careful")
+	log.Error("boom", "apiKey", "secret")
+	log.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(records) != 3 {
+		t.Fatalf("expected 3 exported records, got %d", len(records))
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 batches, got %d", requests)
+	}
+	if auth != "Bearer test" {
+		t.Errorf("expected auth header, got %q", auth)
+	}
+
+	wantSeverity := []float64{9, 13, 17}
+	for i, rec := range records {
+		if rec["severityNumber"] != wantSeverity[i] {
+			t.Errorf("record %d: expected severity %v, got %v", i, wantSeverity[i], rec["severityNumber"])
+		}
+	}
+
+	data, _ := json.Marshal(records)
+	if !strings.Contains(string(data), `"module"`) || !strings.Contains(string(data), `"agent"`) {
+		t.Errorf("expected module attribute, got %s", data)
+	}
+	if strings.Contains(string(data), `"secret"`) {
+		t.Errorf("sensitive field leaked: %s", data)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	log, err := New(Config{Level: "info", File: path, Format: "json"})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	if got := RequestID(ctx); got != "req-1" {
+		t.Fatalf("expected req-1, got %q", got)
+	}
+
+	log.Module("tools").Ctx(ctx).Info("executing tool", "name", "read_file")
+	log.Ctx(context.Background()).Info("no request")
+	log.Close()
+
+	lines := readLines(t, path)
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	if lines[0]["request_id"] != "req-1" || lines[0]["module"] != "tools" {
+		t.Errorf("expected request_id and module, got %v", lines[0])
+	}
+	if _, ok := lines[1]["request_id"]; ok {
+		t.Errorf("unexpected request_id: %v", lines[1])
+	}
+
+	recent := log.GetRecentLogs(2)
+	if recent[0].Fields["request_id"] != "req-1" {
+		t.Errorf("expected request_id in recent logs, got %v", recent[0].Fields)
+	}
+
+	if a, b := NewRequestID(), NewRequestID(); a == "" || a == b {
+		t.Errorf("expected unique request ids, got %q and %q", a, b)
+	}
+}
+
+func TestRotationRetention(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	w := &rotatingWriter{
+		filePath:   path,
+		maxSize:    10,
+		maxBackups: 2,
+		pending:    make(map[string]bool),
+	}
+	if err := w.openFile(); err != nil {
+		t.Fatalf("failed to open log: %v", err)
+	}
+
+	// 同一秒内多次轮转，文件名不能冲突
+	for i := 0; i < 5; i++ {
+		w.Write([]byte(strings.Repeat("x", 20) + "\n"))
+		w.Flush()
+	}
+	w.Close()
+	w.prune()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read dir: %v", err)
+	}
+
+	var backups []string
+	for _, e := range entries {
+		if e.Name() == "app.log" {
+			continue
+		}
+		if !strings.HasSuffix(e.Name(), ".gz") {
+			t.Errorf("uncompressed backup left behind: %s", e.Name())
+		}
+		backups = append(backups, e.Name())
+	}
+	if len(backups) != 2 {
+		t.Errorf("expected 2 backups, got %v", backups)
+	}
+}
+
+func TestPruneMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	old := path + ".20200101-000000.gz"
+	recent := path + ".20200102-000000.gz"
+	for _, p := range []string{old, recent} {
+		if err := os.WriteFile(p, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	past := time.Now().Add(-10 * 24 * time.Hour)
+	os.Chtimes(old, past, past)
+
+	w := &rotatingWriter{filePath: path, maxAge: 7 * 24 * time.Hour, pending: make(map[string]bool)}
+	w.prune()
+
+	if exists(old) {
+		t.Error("expected expired backup to be removed")
+	}
+	if !exists(recent) {
+		t.Error("expected recent backup to be kept")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	for _, format := range []string{"json", "text"} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.log")
+			log, err := New(Config{Level: "debug", File: path, Format: format})
+			if err != nil {
+				t.Fatalf("failed to create logger: %v", err)
+			}
+			defer log.Close()
+
+			log.Debug("debug message")
+			log.Module("llm").Warn("slow response", "duration", "3s")
+			log.Module("agent").Info("hello world", "user", "alice")
+			log.Module("llm").Error("request failed", "error", "timeout")
+
+			tests := []struct {
+				name  string
+				query Query
+				want  []string
+			}{
+				{"all", Query{}, []string{"request failed", "hello world", "slow response", "debug message"}},
+				{"level", Query{Level: "warn"}, []string{"request failed", "slow response"}},
+				{"module", Query{Module: "llm"}, []string{"request failed", "slow response"}},
+				{"contains", Query{Contains: "alice"}, []string{"hello world"}},
+				{"paging", Query{Offset: 1, Limit: 2}, []string{"hello world", "slow response"}},
+				{"until", Query{Until: time.Now().Add(-time.Hour)}, nil},
+			}
+
+			for _, tt := range tests {
+				result, err := log.Query(tt.query)
+				if err != nil {
+					t.Fatalf("%s: query failed: %v", tt.name, err)
+				}
+				var got []string
+				for _, e := range result.Entries {
+					got = append(got, e.Message)
+				}
+				if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+					t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+				}
+			}
+
+			// 增量索引新写入的日志
+			log.Module("agent").Info("later message")
+			result, err := log.Query(Query{Module: "agent"})
+			if err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			if result.Total != 2 || result.Entries[0].Message != "later message" {
+				t.Errorf("expected incremental index, got %+v", result)
+			}
+			if result.Entries[1].Fields["user"] != "alice" {
+				t.Errorf("expected fields, got %v", result.Entries[1].Fields)
+			}
+		})
+	}
+}
+
+func TestQueryWithoutFile(t *testing.T) {
+	log, err := New(Config{Level: "error"})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer log.Close()
+
+	if _, err := log.Query(Query{}); err != ErrNoLogFile {
+		t.Errorf("expected ErrNoLogFile, got %v", err)
+	}
+}
+
+func TestApplyLevels(t *testing.T) {
+	log, err := New(Config{Level: "info", Levels: map[string]string{"llm": "debug"}})
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer log.Close()
+
+	if err := log.ApplyLevels("verbose", nil); err == nil {
+		t.Error("expected error for invalid level")
+	}
+	if err := log.ApplyLevels("", map[string]string{"agent": "loud"}); err == nil {
+		t.Error("expected error for invalid module level")
+	}
+
+	if err := log.ApplyLevels("warn", map[string]string{"agent": "debug", "llm": "default"}); err != nil {
+		t.Fatalf("ApplyLevels failed: %v", err)
+	}
+
+	level, modules := log.Levels()
+	if level != "warn" {
+		t.Errorf("expected warn, got %s", level)
+	}
+	if len(modules) != 1 || modules["agent"] != "debug" {
+		t.Errorf("expected only agent override, got %v", modules)
+	}
+	if log.Module("llm").GetLevel() != WARN || log.Module("agent").GetLevel() != DEBUG {
+		t.Error("module levels not applied")
+	}
+}
@@ -0,0 +1,245 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/httpclient"
+)
+
+const (
+	defaultOTLPBatchSize     = 100
+	defaultOTLPFlushInterval = 5 * time.Second
+	otlpQueueSize            = 1000
+)
+
+// otlpExporter 以OTLP/HTTP JSON格式批量导出日志
+type otlpExporter struct {
+	endpoint  string
+	headers   map[string]string
+	resource  []otlpAttr
+	batchSize int
+	interval  time.Duration
+	client    *http.Client
+	queue     chan Record
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+type otlpAttr struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano   string     `json:"timeUnixNano"`
+	SeverityNumber int        `json:"severityNumber"`
+	SeverityText   string     `json:"severityText"`
+	Body           otlpValue  `json:"body"`
+	Attributes     []otlpAttr `json:"attributes,omitempty"`
+}
+
+func newOTLPExporter(cfg ExporterConfig) (Exporter, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("otlp exporter requires endpoint")
+	}
+
+	service := cfg.ServiceName
+	if service == "" {
+		service = "mujibot"
+	}
+	resource := []otlpAttr{stringAttr("service.name", service)}
+	if host, err := os.Hostname(); err == nil {
+		resource = append(resource, stringAttr("host.name", host))
+	}
+
+	e := &otlpExporter{
+		endpoint:  cfg.Endpoint,
+		headers:   cfg.Headers,
+		resource:  resource,
+		batchSize: cfg.BatchSize,
+		interval:  time.Duration(cfg.FlushInterval) * time.Second,
+		client:    httpclient.New(10 * time.Second),
+		queue:     make(chan Record, otlpQueueSize),
+		done:      make(chan struct{}),
+	}
+	if e.batchSize <= 0 {
+		e.batchSize = defaultOTLPBatchSize
+	}
+	if e.interval <= 0 {
+		e.interval = defaultOTLPFlushInterval
+	}
+
+	e.wg.Add(1)
+	go e.loop()
+	return e, nil
+}
+
+// Export 加入发送队列，队列满时丢弃
+func (e *otlpExporter) Export(rec Record) {
+	select {
+	case e.queue <- rec:
+	default:
+	}
+}
+
+// Close 发送剩余日志后退出
+func (e *otlpExporter) Close() error {
+	e.closeOnce.Do(func() {
+		close(e.done)
+		e.wg.Wait()
+	})
+	return nil
+}
+
+func (e *otlpExporter) loop() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	batch := make([]Record, 0, e.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.send(batch); err != nil {
+			exportError("otlp", err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case rec := <-e.queue:
+			batch = append(batch, rec)
+			if len(batch) >= e.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.done:
+			for {
+				select {
+				case rec := <-e.queue:
+					batch = append(batch, rec)
+					if len(batch) >= e.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// send 发送一批日志
+func (e *otlpExporter) send(batch []Record) error {
+	records := make([]otlpLogRecord, 0, len(batch))
+	for _, rec := range batch {
+		records = append(records, toOTLP(rec))
+	}
+
+	payload := map[string]interface{}{
+		"resourceLogs": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{"attributes": e.resource},
+				"scopeLogs": []interface{}{
+					map[string]interface{}{
+						"scope":      map[string]string{"name": "mujibot"},
+						"logRecords": records,
+					},
+				},
+			},
+		},
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", e.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("otlp export failed: %s - %s", resp.Status, string(body))
+	}
+	return nil
+}
+
+// toOTLP 转换为OTLP日志记录
+func toOTLP(rec Record) otlpLogRecord {
+	severity := map[Level]int{DEBUG: 5, INFO: 9, WARN: 13, ERROR: 17}[rec.Level]
+
+	attrs := make([]otlpAttr, 0, len(rec.Fields)+1)
+	if rec.Module != "" {
+		attrs = append(attrs, stringAttr("module", rec.Module))
+	}
+	for k, v := range rec.Fields {
+		attrs = append(attrs, otlpAttr{Key: k, Value: toOTLPValue(v)})
+	}
+
+	msg := rec.Message
+	return otlpLogRecord{
+		TimeUnixNano:   strconv.FormatInt(rec.Time.UnixNano(), 10),
+		SeverityNumber: severity,
+		SeverityText:   rec.Level.String(),
+		Body:           otlpValue{StringValue: &msg},
+		Attributes:     attrs,
+	}
+}
+
+func toOTLPValue(v interface{}) otlpValue {
+	switch val := v.(type) {
+	case bool:
+		return otlpValue{BoolValue: &val}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		s := fmt.Sprint(val)
+		return otlpValue{IntValue: &s}
+	case float32:
+		f := float64(val)
+		return otlpValue{DoubleValue: &f}
+	case float64:
+		return otlpValue{DoubleValue: &val}
+	case string:
+		return otlpValue{StringValue: &val}
+	default:
+		s := fmt.Sprint(val)
+		return otlpValue{StringValue: &s}
+	}
+}
+
+func stringAttr(key, value string) otlpAttr {
+	return otlpAttr{Key: key, Value: otlpValue{StringValue: &value}}
+}
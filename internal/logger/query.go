@@ -0,0 +1,275 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoLogFile 未配置日志文件，无法查询
+var ErrNoLogFile = errors.New("log file not configured")
+
+const (
+	defaultQueryLimit = 100
+	maxQueryLimit     = 1000
+)
+
+// Query 日志查询条件
+type Query struct {
+	Level    string    // 最低级别
+	Module   string    // 模块名
+	Since    time.Time // 起始时间（含）
+	Until    time.Time // 结束时间（不含）
+	Contains string    // 消息或字段包含的文本
+	Offset   int       // 跳过的条数（从最新开始）
+	Limit    int       // 返回条数
+}
+
+// QueryResult 日志查询结果，按时间倒序
+type QueryResult struct {
+	Total   int        `json:"total"`
+	Offset  int        `json:"offset"`
+	Entries []LogEntry `json:"entries"`
+}
+
+// indexedLine 日志行索引
+type indexedLine struct {
+	offset int64
+	length int
+	time   int64
+	level  Level
+	module string
+}
+
+// fileIndex 当前日志文件的行索引，按需增量更新，文件轮转后重建
+type fileIndex struct {
+	mu      sync.Mutex
+	info    os.FileInfo
+	size    int64
+	lines   []indexedLine
+	modules map[string]string
+}
+
+// Query 查询当前日志文件
+func (l *Logger) Query(q Query) (*QueryResult, error) {
+	w := l.core.writer
+	if w.filePath == "" {
+		return nil, ErrNoLogFile
+	}
+
+	// 先刷新缓冲区，保证能查到最新日志
+	w.Flush()
+
+	file, err := os.Open(w.filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	idx := &l.core.index
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if err := idx.update(file); err != nil {
+		return nil, err
+	}
+
+	if q.Limit <= 0 {
+		q.Limit = defaultQueryLimit
+	}
+	if q.Limit > maxQueryLimit {
+		q.Limit = maxQueryLimit
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+
+	minLevel := DEBUG
+	if q.Level != "" {
+		minLevel = ParseLevel(strings.ToLower(q.Level))
+	}
+
+	result := &QueryResult{Offset: q.Offset, Entries: []LogEntry{}}
+	buf := make([]byte, 0, 4096)
+
+	for i := len(idx.lines) - 1; i >= 0; i-- {
+		line := idx.lines[i]
+		if line.level < minLevel || (q.Module != "" && line.module != q.Module) {
+			continue
+		}
+		if !q.Since.IsZero() && line.time < q.Since.UnixNano() {
+			continue
+		}
+		if !q.Until.IsZero() && line.time >= q.Until.UnixNano() {
+			continue
+		}
+
+		// 只有文本过滤或需要返回时才读取整行
+		needed := result.Total >= q.Offset && len(result.Entries) < q.Limit
+		if q.Contains == "" && !needed {
+			result.Total++
+			continue
+		}
+
+		if cap(buf) < line.length {
+			buf = make([]byte, line.length)
+		}
+		buf = buf[:line.length]
+		if _, err := file.ReadAt(buf, line.offset); err != nil {
+			continue
+		}
+		if q.Contains != "" && !strings.Contains(string(buf), q.Contains) {
+			continue
+		}
+
+		if needed {
+			if entry, ok := parseLine(buf); ok {
+				result.Entries = append(result.Entries, entry)
+			}
+		}
+		result.Total++
+	}
+
+	return result, nil
+}
+
+// update 增量索引新写入的行
+func (idx *fileIndex) update(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	// 文件被轮转或截断时重建索引
+	if idx.info == nil || !os.SameFile(idx.info, info) || info.Size() < idx.size {
+		idx.lines = nil
+		idx.size = 0
+		idx.modules = make(map[string]string)
+	}
+	idx.info = info
+
+	if info.Size() == idx.size {
+		return nil
+	}
+
+	if _, err := file.Seek(idx.size, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+	offset := idx.size
+	for {
+		data, err := reader.ReadBytes('\n')
+		if err != nil {
+			// 不完整的最后一行留到下次索引
+			break
+		}
+
+		length := len(data) - 1
+		if entry, ok := parseLine(data[:length]); ok {
+			t, _ := time.Parse(time.RFC3339Nano, entry.Time)
+			idx.lines = append(idx.lines, indexedLine{
+				offset: offset,
+				length: length,
+				time:   t.UnixNano(),
+				level:  ParseLevel(strings.ToLower(entry.Level)),
+				module: idx.intern(entry.Module),
+			})
+		}
+		offset += int64(len(data))
+	}
+	idx.size = offset
+	return nil
+}
+
+// intern 复用模块名字符串，减少索引内存
+func (idx *fileIndex) intern(s string) string {
+	if v, ok := idx.modules[s]; ok {
+		return v
+	}
+	idx.modules[s] = s
+	return s
+}
+
+// parseLine 解析 JSON 或 key=value 格式的日志行
+func parseLine(data []byte) (LogEntry, bool) {
+	var fields map[string]interface{}
+	if len(data) > 0 && data[0] == '{' {
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return LogEntry{}, false
+		}
+	} else {
+		fields = parseLogfmt(string(data))
+	}
+
+	level, _ := fields["level"].(string)
+	if level == "" {
+		return LogEntry{}, false
+	}
+
+	entry := LogEntry{Level: level}
+	entry.Time, _ = fields["time"].(string)
+	entry.Message, _ = fields["msg"].(string)
+	entry.Module, _ = fields["module"].(string)
+
+	for _, k := range []string{"time", "level", "msg", "module"} {
+		delete(fields, k)
+	}
+	if len(fields) > 0 {
+		entry.Fields = fields
+	}
+	return entry, true
+}
+
+// parseLogfmt 解析 slog 文本格式: key=value key="quoted value"
+func parseLogfmt(s string) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " ")
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			break
+		}
+		key := s[:eq]
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := quotedEnd(s)
+			unquoted, err := strconv.Unquote(s[:end])
+			if err != nil {
+				unquoted = strings.Trim(s[:end], `"`)
+			}
+			value = unquoted
+			s = s[end:]
+		} else {
+			end := strings.IndexByte(s, ' ')
+			if end < 0 {
+				end = len(s)
+			}
+			value = s[:end]
+			s = s[end:]
+		}
+		fields[key] = value
+	}
+	return fields
+}
+
+// quotedEnd 返回引号字符串结束位置（含结尾引号）
+func quotedEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(s)
+}
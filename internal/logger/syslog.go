@@ -0,0 +1,56 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"encoding/json"
+	"log/syslog"
+)
+
+// syslogExporter 输出到syslog/journald
+type syslogExporter struct {
+	w *syslog.Writer
+}
+
+func newSyslogExporter(cfg ExporterConfig) (Exporter, error) {
+	tag := cfg.Tag
+	if tag == "" {
+		tag = "mujibot"
+	}
+
+	w, err := syslog.Dial(cfg.Network, cfg.Address, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogExporter{w: w}, nil
+}
+
+func (e *syslogExporter) Export(rec Record) {
+	line := rec.Message
+	if rec.Module != "" {
+		line = "[" + rec.Module + "] " + line
+	}
+	if len(rec.Fields) > 0 {
+		data, _ := json.Marshal(rec.Fields)
+		line += " " + string(data)
+	}
+
+	var err error
+	switch rec.Level {
+	case DEBUG:
+		err = e.w.Debug(line)
+	case WARN:
+		err = e.w.Warning(line)
+	case ERROR:
+		err = e.w.Err(line)
+	default:
+		err = e.w.Info(line)
+	}
+	if err != nil {
+		exportError("syslog", err)
+	}
+}
+
+func (e *syslogExporter) Close() error {
+	return e.w.Close()
+}
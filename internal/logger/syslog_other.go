@@ -0,0 +1,9 @@
+//go:build windows || plan9
+
+package logger
+
+import "fmt"
+
+func newSyslogExporter(cfg ExporterConfig) (Exporter, error) {
+	return nil, fmt.Errorf("syslog is not supported on this platform")
+}
@@ -0,0 +1,197 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	archiveDirName   = "archive"
+	archiveIndexName = "index.json"
+	longTermFile     = "MEMORY.md"
+)
+
+// ArchiveEntry 归档索引中的一条记录
+type ArchiveEntry struct {
+	File       string    `json:"file"`   // archive 目录下的文件名
+	Source     string    `json:"source"` // 来源文件：MEMORY.md 或 YYYY-MM-DD.md
+	ArchivedAt time.Time `json:"archivedAt"`
+	Sections   int       `json:"sections"`
+	Titles     []string  `json:"titles"` // 各段的第一行，便于浏览
+	Bytes      int       `json:"bytes"`
+}
+
+// archiveDir 归档目录 memory/archive
+func (m *Manager) archiveDir() string {
+	return filepath.Join(m.memoryDir, "memory", archiveDirName)
+}
+
+// Archives 返回归档索引，最早的在前
+func (m *Manager) Archives() ([]ArchiveEntry, error) {
+	if m.memoryDir == "" {
+		return nil, nil
+	}
+
+	data, err := m.cipher.ReadFile(filepath.Join(m.archiveDir(), archiveIndexName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []ArchiveEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid archive index: %w", err)
+	}
+	return entries, nil
+}
+
+// ReadArchive 读取索引中登记的归档文件，file 可以带 memory/archive/ 前缀
+func (m *Manager) ReadArchive(file string) (string, error) {
+	file = path.Base(filepath.ToSlash(file))
+	entries, err := m.Archives()
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if e.File == file {
+			content, err := m.cipher.ReadFile(filepath.Join(m.archiveDir(), e.File))
+			if err != nil {
+				return "", err
+			}
+			return string(content), nil
+		}
+	}
+	return "", fmt.Errorf("archive not found: %s", file)
+}
+
+// rollover 内容超过 maxFileSize 时把最早的段落移到归档，返回保留的内容。
+// 只剩最后一段仍然超出时返回错误
+func (m *Manager) rollover(source, content string) (string, error) {
+	if len(content) <= m.maxFileSize {
+		return content, nil
+	}
+
+	sections := splitSections(content)
+	kept := len(content)
+	cut := 0
+	for cut < len(sections)-1 && kept > m.maxFileSize {
+		kept -= len(sections[cut])
+		cut++
+	}
+	if kept > m.maxFileSize {
+		return "", fmt.Errorf("memory entry too large (max %d bytes)", m.maxFileSize)
+	}
+
+	if err := m.archive(source, sections[:cut]); err != nil {
+		return "", err
+	}
+	return strings.TrimLeft(strings.Join(sections[cut:], ""), "\n"), nil
+}
+
+// archive 把段落写入新的归档文件并登记到索引
+func (m *Manager) archive(source string, sections []string) error {
+	dir := m.archiveDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	entries, err := m.Archives()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	content := strings.Join(sections, "")
+	entry := ArchiveEntry{
+		File:       fmt.Sprintf("%s-%s-%d.md", strings.TrimSuffix(source, ".md"), now.Format("20060102-150405"), len(entries)+1),
+		Source:     source,
+		ArchivedAt: now,
+		Sections:   len(sections),
+		Bytes:      len(content),
+	}
+	for _, s := range sections {
+		entry.Titles = append(entry.Titles, sectionTitle(s))
+	}
+
+	if err := m.cipher.WriteFile(filepath.Join(dir, entry.File), []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	data, err := json.MarshalIndent(append(entries, entry), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := m.cipher.WriteFile(filepath.Join(dir, archiveIndexName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write archive index: %w", err)
+	}
+
+	m.log.Info("memory sections archived", "source", source, "file", entry.File, "sections", entry.Sections)
+	return nil
+}
+
+// archiveHint 来源文件有归档时提示模型如何查找
+func (m *Manager) archiveHint(source string) string {
+	entries, _ := m.Archives()
+	sections := 0
+	for _, e := range entries {
+		if e.Source == source {
+			sections += e.Sections
+		}
+	}
+	if sections == 0 {
+		return ""
+	}
+	return fmt.Sprintf("> %d older section(s) were archived to memory/archive/. Use memory_search to find them.", sections)
+}
+
+// splitSections 按Markdown标题或时间戳注释把内容切成段落，段落拼接后与原文相同。
+// 段落还没有正文时遇到的标题（如时间戳注释后的标题）不另起一段
+func splitSections(content string) []string {
+	var sections []string
+	start := 0
+	hasBody := false
+	for pos := 0; pos < len(content); {
+		end := strings.IndexByte(content[pos:], '\n')
+		if end < 0 {
+			end = len(content)
+		} else {
+			end += pos + 1
+		}
+		line := strings.TrimSpace(content[pos:end])
+
+		marker := strings.HasPrefix(line, "#") || strings.HasPrefix(line, "<!--")
+		if marker && hasBody {
+			sections = append(sections, content[start:pos])
+			start = pos
+			hasBody = false
+		}
+		if line != "" && !strings.HasPrefix(line, "<!--") {
+			hasBody = true
+		}
+		pos = end
+	}
+	if start < len(content) {
+		sections = append(sections, content[start:])
+	}
+	return sections
+}
+
+// sectionTitle 段落中第一行非空内容
+func sectionTitle(section string) string {
+	for _, line := range strings.Split(section, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			if r := []rune(line); len(r) > 80 {
+				line = string(r[:80]) + "…"
+			}
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func newTestManager(t *testing.T, maxFileSize int) *Manager {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	t.Cleanup(func() { log.Close() })
+
+	m, err := NewManager(Config{Enabled: true, MemoryDir: t.TempDir(), MaxFileSize: maxFileSize}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestSplitSections(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{"plain", "just text\nmore", 1},
+		{"headings", "# A\none\n## B\ntwo\n", 2},
+		{"daily entries", "\n### 10:00:00\n\nfirst\n\n### 11:00:00\n\nsecond\n", 2},
+		{"timestamp then heading", "<!-- t1 -->\n## Prefs\ntea\n\n<!-- t2 -->\ncoffee", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections := splitSections(tt.content)
+			if len(sections) != tt.want {
+				t.Errorf("splitSections = %q, want %d sections", sections, tt.want)
+			}
+			if strings.Join(sections, "") != tt.content {
+				t.Errorf("sections do not reassemble the content: %q", sections)
+			}
+		})
+	}
+}
+
+func TestLongTermRollover(t *testing.T) {
+	m := newTestManager(t, 120)
+
+	for i := 1; i <= 5; i++ {
+		if err := m.AppendToLongTermMemory(fmt.Sprintf("fact %d %s", i, strings.Repeat("x", 20))); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+
+	raw, _ := m.readLongTerm()
+	if len(raw) > 120 || !strings.Contains(raw, "fact 5") || strings.Contains(raw, "fact 1") {
+		t.Errorf("active window = %q", raw)
+	}
+
+	content, _ := m.ReadLongTermMemory()
+	if !strings.Contains(content, "archived to memory/archive/") {
+		t.Errorf("read should hint at archives: %q", content)
+	}
+
+	archives, err := m.Archives()
+	if err != nil || len(archives) == 0 || archives[0].Source != longTermFile {
+		t.Fatalf("archives = %+v, %v", archives, err)
+	}
+
+	results, _ := m.SearchMemory(SearchQuery{Terms: []string{"fact 1 "}})
+	if len(results) != 1 || results[0].Kind != "archive" {
+		t.Fatalf("search results = %+v", results)
+	}
+	archived, err := m.ReadArchive(results[0].File)
+	if err != nil || !strings.Contains(archived, "fact 1") {
+		t.Errorf("ReadArchive = %q, %v", archived, err)
+	}
+	if _, err := m.ReadArchive("../../MEMORY.md"); err == nil {
+		t.Error("files outside the index should not be readable")
+	}
+
+	if err := m.WriteLongTermMemory(strings.Repeat("y", 200)); err == nil {
+		t.Error("a single section larger than maxFileSize should fail")
+	}
+}
+
+func TestDailyNoteRollover(t *testing.T) {
+	m := newTestManager(t, 100)
+	date := time.Now().Format("2006-01-02")
+
+	for i := 1; i <= 4; i++ {
+		if err := m.WriteDailyNote(date, fmt.Sprintf("entry %d %s", i, strings.Repeat("z", 20))); err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+	}
+
+	note, _ := m.ReadDailyNote(date)
+	if len(note) > 100 || !strings.Contains(note, "entry 4") || strings.Contains(note, "entry 1") {
+		t.Errorf("daily note = %q", note)
+	}
+
+	archives, _ := m.Archives()
+	if len(archives) == 0 || archives[0].Source != date+".md" {
+		t.Fatalf("archives = %+v", archives)
+	}
+	if dates, _ := m.ListDailyNotes(); len(dates) != 1 {
+		t.Errorf("archive directory should not be listed as a note: %v", dates)
+	}
+}
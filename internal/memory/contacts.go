@@ -0,0 +1,258 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/encryption"
+)
+
+// Contact 联系人，对应记忆分类 CategoryContact 的结构化存储
+type Contact struct {
+	ID      int       `json:"id"`
+	Name    string    `json:"name"`
+	Phone   string    `json:"phone,omitempty"`
+	Email   string    `json:"email,omitempty"`
+	Notes   string    `json:"notes,omitempty"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+// ContactUpdate 联系人修改，nil 字段保持不变
+type ContactUpdate struct {
+	Name  *string
+	Phone *string
+	Email *string
+	Notes *string
+}
+
+// contactList 单个用户的通讯录文件
+type contactList struct {
+	Owner    string     `json:"owner"`
+	NextID   int        `json:"nextId"`
+	Contacts []*Contact `json:"contacts"`
+}
+
+// ContactBook 按用户保存联系人，每个用户一个JSON文件
+type ContactBook struct {
+	dir    string
+	cipher *encryption.Cipher
+
+	mu    sync.Mutex
+	lists map[string]*contactList
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// NewContactBook 创建通讯录，文件按需加载
+func NewContactBook(dir string) (*ContactBook, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create contacts directory: %w", err)
+	}
+	return &ContactBook{dir: dir, lists: make(map[string]*contactList)}, nil
+}
+
+// SetCipher 设置静态加密，之后写入的通讯录文件都会加密
+func (b *ContactBook) SetCipher(c *encryption.Cipher) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cipher = c
+}
+
+// Add 添加联系人，同名联系人已存在时返回错误
+func (b *ContactBook) Add(owner string, c Contact) (*Contact, error) {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	l, err := b.load(owner)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range l.Contacts {
+		if strings.EqualFold(existing.Name, c.Name) {
+			return nil, fmt.Errorf("contact %q already exists (#%d), update it instead", existing.Name, existing.ID)
+		}
+	}
+
+	now := time.Now()
+	c.ID = l.NextID
+	c.Created = now
+	c.Updated = now
+	l.NextID++
+	l.Contacts = append(l.Contacts, &c)
+
+	if err := b.save(l); err != nil {
+		return nil, err
+	}
+	result := c
+	return &result, nil
+}
+
+// Update 修改联系人
+func (b *ContactBook) Update(owner string, id int, u ContactUpdate) (*Contact, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	l, err := b.load(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range l.Contacts {
+		if c.ID != id {
+			continue
+		}
+		if u.Name != nil {
+			name := strings.TrimSpace(*u.Name)
+			if name == "" {
+				return nil, fmt.Errorf("name cannot be empty")
+			}
+			for _, other := range l.Contacts {
+				if other.ID != id && strings.EqualFold(other.Name, name) {
+					return nil, fmt.Errorf("contact %q already exists (#%d)", other.Name, other.ID)
+				}
+			}
+			c.Name = name
+		}
+		if u.Phone != nil {
+			c.Phone = strings.TrimSpace(*u.Phone)
+		}
+		if u.Email != nil {
+			c.Email = strings.TrimSpace(*u.Email)
+		}
+		if u.Notes != nil {
+			c.Notes = strings.TrimSpace(*u.Notes)
+		}
+		c.Updated = time.Now()
+
+		if err := b.save(l); err != nil {
+			return nil, err
+		}
+		result := *c
+		return &result, nil
+	}
+	return nil, fmt.Errorf("contact not found: #%d", id)
+}
+
+// Search 搜索联系人，名字完全匹配的排在最前，query 为空时返回全部
+func (b *ContactBook) Search(owner, query string) ([]Contact, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	l, err := b.load(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	type scored struct {
+		contact Contact
+		score   int
+	}
+	var matches []scored
+	for _, c := range l.Contacts {
+		score := contactScore(c, query)
+		if score > 0 {
+			matches = append(matches, scored{*c, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].contact.Name < matches[j].contact.Name
+	})
+
+	result := make([]Contact, len(matches))
+	for i, m := range matches {
+		result[i] = m.contact
+	}
+	return result, nil
+}
+
+// contactScore 匹配程度，0 表示不匹配
+func contactScore(c *Contact, query string) int {
+	if query == "" {
+		return 1
+	}
+	name := strings.ToLower(c.Name)
+	switch {
+	case name == query:
+		return 4
+	case strings.HasPrefix(name, query):
+		return 3
+	case strings.Contains(name, query):
+		return 2
+	}
+	for _, field := range []string{c.Phone, c.Email, c.Notes} {
+		if strings.Contains(strings.ToLower(field), query) {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Delete 删除用户的整个通讯录，返回删除的联系人数，文件损坏时同样删除
+func (b *ContactBook) Delete(owner string) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := 0
+	if l, err := b.load(owner); err == nil {
+		n = len(l.Contacts)
+	}
+	if err := removeFile(b.path(owner)); err != nil {
+		return 0, fmt.Errorf("failed to remove contacts: %w", err)
+	}
+	delete(b.lists, owner)
+	return n, nil
+}
+
+// load 读取用户的通讯录，调用方需持有锁
+func (b *ContactBook) load(owner string) (*contactList, error) {
+	if l, ok := b.lists[owner]; ok {
+		return l, nil
+	}
+
+	l := &contactList{Owner: owner, NextID: 1}
+	data, err := b.cipher.ReadFile(b.path(owner))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read contacts: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, l); err != nil {
+			return nil, fmt.Errorf("failed to parse contacts: %w", err)
+		}
+	}
+	b.lists[owner] = l
+	return l, nil
+}
+
+func (b *ContactBook) save(l *contactList) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := b.path(l.Owner)
+	tmp := path + ".tmp"
+	if err := b.cipher.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write contacts: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+func (b *ContactBook) path(owner string) string {
+	return filepath.Join(b.dir, unsafeFileChars.ReplaceAllString(owner, "_")+".json")
+}
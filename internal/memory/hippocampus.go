@@ -8,6 +8,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/HaohanHe/mujibot/internal/encryption"
 )
 
 type MemoryCategory string
@@ -39,9 +41,15 @@ type Hippocampus struct {
 	mu              sync.RWMutex
 	dataDir         string
 	maxItems        int
+	cipher          *encryption.Cipher
 }
 
 func NewHippocampus(dataDir string, maxItems int) (*Hippocampus, error) {
+	return NewEncryptedHippocampus(dataDir, maxItems, nil)
+}
+
+// NewEncryptedHippocampus 创建海马体，hippocampus.json 使用给定的加密器读写
+func NewEncryptedHippocampus(dataDir string, maxItems int, c *encryption.Cipher) (*Hippocampus, error) {
 	h := &Hippocampus{
 		LongTermMemory:  make(map[string]*MemoryItem),
 		RecentFacts:     make([]*MemoryItem, 0),
@@ -49,6 +57,7 @@ func NewHippocampus(dataDir string, maxItems int) (*Hippocampus, error) {
 		KeywordsIndex:   make(map[string][]string),
 		dataDir:         dataDir,
 		maxItems:        maxItems,
+		cipher:          c,
 	}
 
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
@@ -63,7 +72,7 @@ func NewHippocampus(dataDir string, maxItems int) (*Hippocampus, error) {
 }
 
 func (h *Hippocampus) load() error {
-	data, err := os.ReadFile(filepath.Join(h.dataDir, "hippocampus.json"))
+	data, err := h.cipher.ReadFile(filepath.Join(h.dataDir, "hippocampus.json"))
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil
@@ -80,7 +89,7 @@ func (h *Hippocampus) save() error {
 		return err
 	}
 
-	return os.WriteFile(filepath.Join(h.dataDir, "hippocampus.json"), data, 0644)
+	return h.cipher.WriteFile(filepath.Join(h.dataDir, "hippocampus.json"), data, 0644)
 }
 
 func (h *Hippocampus) Remember(content string, category MemoryCategory, source string) (*MemoryItem, error) {
@@ -7,8 +7,11 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/HaohanHe/mujibot/internal/encryption"
 	"github.com/HaohanHe/mujibot/internal/logger"
 )
 
@@ -16,7 +19,21 @@ import (
 type Manager struct {
 	memoryDir   string
 	maxFileSize int
+	cipher      *encryption.Cipher
 	log         *logger.Logger
+	version     atomic.Uint64 // arm32 上普通 uint64 字段不保证 8 字节对齐
+
+	// fileMu 串行化记忆文件的写入和归档
+	fileMu sync.Mutex
+
+	pinMu sync.Mutex
+	pins  map[string][]string
+
+	profileMu sync.Mutex
+	profiles  map[string]*Profile
+
+	recapMu sync.Mutex
+	recaps  map[string]Recap
 }
 
 // Config 记忆配置
@@ -24,6 +41,7 @@ type Config struct {
 	Enabled     bool
 	MemoryDir   string
 	MaxFileSize int
+	Cipher      *encryption.Cipher // 静态加密，为空时明文存储
 }
 
 // NewManager 创建记忆管理器
@@ -50,6 +68,7 @@ func NewManager(cfg Config, log *logger.Logger) (*Manager, error) {
 	return &Manager{
 		memoryDir:   cfg.MemoryDir,
 		maxFileSize: cfg.MaxFileSize,
+		cipher:      cfg.Cipher,
 		log:         log,
 	}, nil
 }
@@ -84,7 +103,7 @@ func (m *Manager) ReadDailyNote(date string) (string, error) {
 	}
 
 	filePath := filepath.Join(m.memoryDir, "memory", date+".md")
-	content, err := os.ReadFile(filePath)
+	content, err := m.cipher.ReadFile(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return "", nil
@@ -95,48 +114,76 @@ func (m *Manager) ReadDailyNote(date string) (string, error) {
 	return string(content), nil
 }
 
-// WriteDailyNote 写入每日笔记
+// WriteDailyNote 写入每日笔记，超过 maxFileSize 时把最早的条目移到归档
 func (m *Manager) WriteDailyNote(date string, content string) error {
 	if m.memoryDir == "" {
 		return nil
 	}
 
-	filePath := filepath.Join(m.memoryDir, "memory", date+".md")
+	m.fileMu.Lock()
+	defer m.fileMu.Unlock()
 
-	// 检查文件大小
-	if info, err := os.Stat(filePath); err == nil {
-		if info.Size() > int64(m.maxFileSize) {
-			return fmt.Errorf("daily note file too large (max %d bytes)", m.maxFileSize)
-		}
-	}
-
-	// 追加内容
-	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open daily note file: %w", err)
-	}
-	defer f.Close()
+	filePath := filepath.Join(m.memoryDir, "memory", date+".md")
 
 	// 添加时间戳
 	timestamp := time.Now().Format("15:04:05")
 	entry := fmt.Sprintf("\n### %s\n\n%s\n", timestamp, content)
 
-	if _, err := f.WriteString(entry); err != nil {
-		return fmt.Errorf("failed to write daily note: %w", err)
+	existing, err := m.cipher.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read daily note file: %w", err)
+	}
+
+	// 加密文件不能直接追加，整体重写
+	if m.cipher != nil || len(existing)+len(entry) > m.maxFileSize {
+		kept, err := m.rollover(date+".md", string(existing)+entry)
+		if err != nil {
+			return err
+		}
+		if err := m.cipher.WriteFile(filePath, []byte(kept), 0644); err != nil {
+			return fmt.Errorf("failed to write daily note: %w", err)
+		}
+	} else {
+		// 追加内容
+		f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open daily note file: %w", err)
+		}
+		_, err = f.WriteString(entry)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write daily note: %w", err)
+		}
 	}
 
+	m.version.Add(1)
 	m.log.Info("daily note written", "date", date, "file", filePath)
 	return nil
 }
 
-// ReadLongTermMemory 读取长期记忆
+// ReadLongTermMemory 读取长期记忆，有归档时在末尾附上查找归档的提示
 func (m *Manager) ReadLongTermMemory() (string, error) {
+	content, err := m.readLongTerm()
+	if err != nil {
+		return "", err
+	}
+	if hint := m.archiveHint(longTermFile); hint != "" {
+		if content != "" {
+			content += "\n\n"
+		}
+		content += hint
+	}
+	return content, nil
+}
+
+// readLongTerm 读取 MEMORY.md 的当前内容
+func (m *Manager) readLongTerm() (string, error) {
 	if m.memoryDir == "" {
 		return "", nil
 	}
 
-	filePath := filepath.Join(m.memoryDir, "MEMORY.md")
-	content, err := os.ReadFile(filePath)
+	filePath := filepath.Join(m.memoryDir, longTermFile)
+	content, err := m.cipher.ReadFile(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return "", nil
@@ -147,68 +194,35 @@ func (m *Manager) ReadLongTermMemory() (string, error) {
 	return string(content), nil
 }
 
-// WriteLongTermMemory 写入长期记忆
+// WriteLongTermMemory 写入长期记忆，超过 maxFileSize 时把最早的段落移到归档
 func (m *Manager) WriteLongTermMemory(content string) error {
 	if m.memoryDir == "" {
 		return nil
 	}
 
-	filePath := filepath.Join(m.memoryDir, "MEMORY.md")
+	m.fileMu.Lock()
+	defer m.fileMu.Unlock()
+	return m.writeLongTerm(content)
+}
+
+// writeLongTerm 写入 MEMORY.md，调用方持有 fileMu
+func (m *Manager) writeLongTerm(content string) error {
+	filePath := filepath.Join(m.memoryDir, longTermFile)
 
-	// 检查文件大小
-	if len(content) > m.maxFileSize {
-		return fmt.Errorf("memory content too large (max %d bytes)", m.maxFileSize)
+	content, err := m.rollover(longTermFile, content)
+	if err != nil {
+		return err
 	}
 
-	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+	if err := m.cipher.WriteFile(filePath, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to write memory file: %w", err)
 	}
 
+	m.version.Add(1)
 	m.log.Info("long-term memory written", "file", filePath)
 	return nil
 }
 
-// SearchMemory 搜索记忆内容
-func (m *Manager) SearchMemory(keyword string) ([]string, error) {
-	if m.memoryDir == "" {
-		return nil, nil
-	}
-
-	var results []string
-	keywordLower := strings.ToLower(keyword)
-
-	// 搜索每日笔记
-	dailyDir := filepath.Join(m.memoryDir, "memory")
-	entries, err := os.ReadDir(dailyDir)
-	if err == nil {
-		for _, entry := range entries {
-			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
-				continue
-			}
-
-			content, err := os.ReadFile(filepath.Join(dailyDir, entry.Name()))
-			if err != nil {
-				continue
-			}
-
-			if strings.Contains(strings.ToLower(string(content)), keywordLower) {
-				date := strings.TrimSuffix(entry.Name(), ".md")
-				results = append(results, fmt.Sprintf("[Daily Note %s]", date))
-			}
-		}
-	}
-
-	// 搜索长期记忆
-	longTerm, err := m.ReadLongTermMemory()
-	if err == nil && longTerm != "" {
-		if strings.Contains(strings.ToLower(longTerm), keywordLower) {
-			results = append(results, "[Long-term Memory]")
-		}
-	}
-
-	return results, nil
-}
-
 // GetMemoryContext 获取记忆上下文（用于LLM提示）
 func (m *Manager) GetMemoryContext() string {
 	if m.memoryDir == "" {
@@ -241,7 +255,10 @@ func (m *Manager) AppendToLongTermMemory(content string) error {
 		return nil
 	}
 
-	existing, _ := m.ReadLongTermMemory()
+	m.fileMu.Lock()
+	defer m.fileMu.Unlock()
+
+	existing, _ := m.readLongTerm()
 
 	var newContent strings.Builder
 	if existing != "" {
@@ -254,7 +271,7 @@ func (m *Manager) AppendToLongTermMemory(content string) error {
 	newContent.WriteString(fmt.Sprintf("<!-- %s -->\n", timestamp))
 	newContent.WriteString(content)
 
-	return m.WriteLongTermMemory(newContent.String())
+	return m.writeLongTerm(newContent.String())
 }
 
 // ListDailyNotes 列出所有每日笔记
@@ -317,10 +334,16 @@ func (m *Manager) CleanOldNotes(keepDays int) error {
 			m.log.Info("old note removed", "date", date)
 		}
 	}
+	m.version.Add(1)
 
 	return nil
 }
 
+// Version 返回记忆内容版本号，每次写入后递增
+func (m *Manager) Version() uint64 {
+	return m.version.Load()
+}
+
 // IsEnabled 检查记忆功能是否启用
 func (m *Manager) IsEnabled() bool {
 	return m.memoryDir != ""
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/encryption"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestEncryptedMemory(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	key, _ := encryption.GenerateKey()
+	raw, _ := encryption.ParseKey(key)
+	c, err := encryption.New(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	m, err := NewManager(Config{Enabled: true, MemoryDir: dir, MaxFileSize: 1 << 20, Cipher: c}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := time.Now().Format("2006-01-02")
+	if err := m.AppendToLongTermMemory("user's wifi password is hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	for _, note := range []string{"met alice", "met bob"} {
+		if err := m.WriteDailyNote(date, note); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, file := range []string{longTermFile, filepath.Join("memory", date+".md")} {
+		data, _ := os.ReadFile(filepath.Join(dir, file))
+		if !encryption.IsEncrypted(data) || strings.Contains(string(data), "hunter2") || strings.Contains(string(data), "alice") {
+			t.Errorf("%s is not encrypted: %q", file, data)
+		}
+	}
+
+	if content, _ := m.ReadLongTermMemory(); !strings.Contains(content, "hunter2") {
+		t.Errorf("ReadLongTermMemory = %q", content)
+	}
+	if note, _ := m.ReadDailyNote(date); !strings.Contains(note, "met alice") || !strings.Contains(note, "met bob") {
+		t.Errorf("ReadDailyNote = %q", note)
+	}
+	if results, _ := m.SearchMemory(SearchQuery{Terms: []string{"bob"}}); len(results) != 1 {
+		t.Errorf("search over encrypted notes = %+v", results)
+	}
+
+	plain, _ := NewManager(Config{Enabled: true, MemoryDir: dir, MaxFileSize: 1 << 20}, log)
+	if _, err := plain.ReadLongTermMemory(); err == nil {
+		t.Error("reading encrypted memory without a key should fail")
+	}
+}
@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// maxPins 每个用户最多置顶条数
+	maxPins = 20
+	// maxPinLen 单条置顶内容的最大字符数
+	maxPinLen = 1000
+)
+
+// Pins 返回用户的置顶内容，按添加顺序排列
+func (m *Manager) Pins(owner string) []string {
+	if m.memoryDir == "" {
+		return nil
+	}
+
+	m.pinMu.Lock()
+	defer m.pinMu.Unlock()
+
+	pins, err := m.loadPins(owner)
+	if err != nil {
+		m.log.Warn("failed to load pins", "owner", owner, "error", err)
+		return nil
+	}
+	return append([]string(nil), pins...)
+}
+
+// Pin 添加一条置顶内容，返回添加后的条数
+func (m *Manager) Pin(owner, text string) (int, error) {
+	if m.memoryDir == "" {
+		return 0, fmt.Errorf("memory is disabled")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, fmt.Errorf("pin text is empty")
+	}
+	if len([]rune(text)) > maxPinLen {
+		return 0, fmt.Errorf("pin too long (max %d characters)", maxPinLen)
+	}
+
+	m.pinMu.Lock()
+	defer m.pinMu.Unlock()
+
+	pins, err := m.loadPins(owner)
+	if err != nil {
+		return 0, err
+	}
+	if len(pins) >= maxPins {
+		return 0, fmt.Errorf("too many pins (max %d), remove one with /unpin <n> first", maxPins)
+	}
+	pins = append(pins, text)
+	if err := m.savePins(owner, pins); err != nil {
+		return 0, err
+	}
+	return len(pins), nil
+}
+
+// Unpin 删除第 index 条置顶内容（从1开始），index 为0时全部清除
+func (m *Manager) Unpin(owner string, index int) error {
+	if m.memoryDir == "" {
+		return fmt.Errorf("memory is disabled")
+	}
+
+	m.pinMu.Lock()
+	defer m.pinMu.Unlock()
+
+	pins, err := m.loadPins(owner)
+	if err != nil {
+		return err
+	}
+	if index == 0 {
+		return m.savePins(owner, nil)
+	}
+	if index < 0 || index > len(pins) {
+		return fmt.Errorf("no pin #%d", index)
+	}
+	pins = append(pins[:index-1:index-1], pins[index:]...)
+	return m.savePins(owner, pins)
+}
+
+// FormatPins 格式化置顶内容，用于提示词注入
+func FormatPins(pins []string) string {
+	var sb strings.Builder
+	for i, pin := range pins {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, pin)
+	}
+	return sb.String()
+}
+
+// loadPins 读取用户的置顶文件，调用方需持有 pinMu
+func (m *Manager) loadPins(owner string) ([]string, error) {
+	if pins, ok := m.pins[owner]; ok {
+		return pins, nil
+	}
+
+	var pins []string
+	data, err := m.cipher.ReadFile(m.pinPath(owner))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read pins: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &pins); err != nil {
+			return nil, fmt.Errorf("failed to parse pins: %w", err)
+		}
+	}
+
+	if m.pins == nil {
+		m.pins = make(map[string][]string)
+	}
+	m.pins[owner] = pins
+	return pins, nil
+}
+
+func (m *Manager) savePins(owner string, pins []string) error {
+	path := m.pinPath(owner)
+	if len(pins) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove pins: %w", err)
+		}
+		m.pins[owner] = nil
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create pins directory: %w", err)
+	}
+	data, err := json.MarshalIndent(pins, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := m.cipher.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write pins: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	m.pins[owner] = pins
+	return nil
+}
+
+func (m *Manager) pinPath(owner string) string {
+	return filepath.Join(m.memoryDir, "pinned", unsafeFileChars.ReplaceAllString(owner, "_")+".json")
+}
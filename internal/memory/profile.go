@@ -0,0 +1,247 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProfileFields 可通过 /profile set 修改的字段
+var ProfileFields = []string{"name", "locale", "timezone", "units", "verbosity"}
+
+var (
+	localeRe    = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`)
+	utcOffsetRe = regexp.MustCompile(`^(?i:utc|gmt)?[+-]\d{1,2}(:?\d{2})?$`)
+)
+
+// maxDisplayNameLen 称呼的最大字符数
+const maxDisplayNameLen = 64
+
+// Profile 用户资料，注入系统提示词并作为天气、时间等工具的默认值
+type Profile struct {
+	Owner       string    `json:"owner"`
+	DisplayName string    `json:"displayName,omitempty"`
+	Locale      string    `json:"locale,omitempty"`
+	Timezone    string    `json:"timezone,omitempty"`
+	Units       string    `json:"units,omitempty"`     // metric 或 imperial
+	Verbosity   string    `json:"verbosity,omitempty"` // brief、normal 或 detailed
+	Updated     time.Time `json:"updated"`
+}
+
+// IsEmpty 是否未设置任何字段
+func (p Profile) IsEmpty() bool {
+	return p.DisplayName == "" && p.Locale == "" && p.Timezone == "" && p.Units == "" && p.Verbosity == ""
+}
+
+// Set 按字段名修改资料，值为空时清除该字段
+func (p *Profile) Set(field, value string) error {
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(field) {
+	case "name", "displayname":
+		p.DisplayName = value
+	case "locale", "language", "lang":
+		p.Locale = value
+	case "timezone", "tz":
+		p.Timezone = value
+	case "units", "unit":
+		p.Units = strings.ToLower(value)
+	case "verbosity":
+		p.Verbosity = strings.ToLower(value)
+	default:
+		return fmt.Errorf("unknown profile field %q (available: %s)", field, strings.Join(ProfileFields, ", "))
+	}
+	return p.Validate()
+}
+
+// Validate 校验各字段取值
+func (p Profile) Validate() error {
+	if len([]rune(p.DisplayName)) > maxDisplayNameLen {
+		return fmt.Errorf("name too long (max %d characters)", maxDisplayNameLen)
+	}
+	if p.Locale != "" && !localeRe.MatchString(p.Locale) {
+		return fmt.Errorf("invalid locale %q (use a tag like en-US or zh-CN)", p.Locale)
+	}
+	if p.Timezone != "" && !utcOffsetRe.MatchString(p.Timezone) {
+		if _, err := time.LoadLocation(p.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q (use an IANA name like Asia/Shanghai or an offset like UTC+8)", p.Timezone)
+		}
+	}
+	switch p.Units {
+	case "", "metric", "imperial":
+	default:
+		return fmt.Errorf("invalid units %q (metric or imperial)", p.Units)
+	}
+	switch p.Verbosity {
+	case "", "brief", "normal", "detailed":
+	default:
+		return fmt.Errorf("invalid verbosity %q (brief, normal or detailed)", p.Verbosity)
+	}
+	return nil
+}
+
+// Format 格式化已设置的字段，每行一项
+func (p Profile) Format() string {
+	var sb strings.Builder
+	line := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(&sb, "- %s: %s\n", label, value)
+		}
+	}
+	line("Display name", p.DisplayName)
+	line("Locale", p.Locale)
+	line("Timezone", p.Timezone)
+	line("Units", p.Units)
+	line("Verbosity", p.Verbosity)
+	return sb.String()
+}
+
+// Profile 返回用户资料，未设置时返回空资料
+func (m *Manager) Profile(owner string) Profile {
+	if m.memoryDir == "" {
+		return Profile{Owner: owner}
+	}
+
+	m.profileMu.Lock()
+	defer m.profileMu.Unlock()
+
+	p, err := m.loadProfile(owner)
+	if err != nil {
+		m.log.Warn("failed to load profile", "owner", owner, "error", err)
+		return Profile{Owner: owner}
+	}
+	return *p
+}
+
+// UpdateProfile 修改并保存用户资料，update 返回错误时不保存
+func (m *Manager) UpdateProfile(owner string, update func(p *Profile) error) (Profile, error) {
+	if m.memoryDir == "" {
+		return Profile{}, fmt.Errorf("memory is disabled")
+	}
+
+	m.profileMu.Lock()
+	defer m.profileMu.Unlock()
+
+	current, err := m.loadProfile(owner)
+	if err != nil {
+		return Profile{}, err
+	}
+	p := *current
+	if err := update(&p); err != nil {
+		return Profile{}, err
+	}
+	if err := p.Validate(); err != nil {
+		return Profile{}, err
+	}
+	p.Owner = owner
+	p.Updated = time.Now()
+
+	if err := m.saveProfile(&p); err != nil {
+		return Profile{}, err
+	}
+	return p, nil
+}
+
+// DeleteProfile 删除用户资料
+func (m *Manager) DeleteProfile(owner string) error {
+	if m.memoryDir == "" {
+		return fmt.Errorf("memory is disabled")
+	}
+
+	m.profileMu.Lock()
+	defer m.profileMu.Unlock()
+
+	if err := os.Remove(m.profilePath(owner)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove profile: %w", err)
+	}
+	delete(m.profiles, owner)
+	return nil
+}
+
+// ListProfiles 列出所有已保存的用户资料，按用户排序
+func (m *Manager) ListProfiles() ([]Profile, error) {
+	if m.memoryDir == "" {
+		return nil, nil
+	}
+
+	m.profileMu.Lock()
+	defer m.profileMu.Unlock()
+
+	entries, err := os.ReadDir(filepath.Join(m.memoryDir, "profiles"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var profiles []Profile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := m.cipher.ReadFile(filepath.Join(m.memoryDir, "profiles", entry.Name()))
+		if err != nil {
+			continue
+		}
+		var p Profile
+		if err := json.Unmarshal(data, &p); err != nil || p.Owner == "" {
+			continue
+		}
+		profiles = append(profiles, p)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Owner < profiles[j].Owner })
+	return profiles, nil
+}
+
+// loadProfile 读取用户资料文件，调用方需持有 profileMu
+func (m *Manager) loadProfile(owner string) (*Profile, error) {
+	if p, ok := m.profiles[owner]; ok {
+		return p, nil
+	}
+
+	p := &Profile{Owner: owner}
+	data, err := m.cipher.ReadFile(m.profilePath(owner))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, p); err != nil {
+			return nil, fmt.Errorf("failed to parse profile: %w", err)
+		}
+	}
+
+	if m.profiles == nil {
+		m.profiles = make(map[string]*Profile)
+	}
+	m.profiles[owner] = p
+	return p, nil
+}
+
+func (m *Manager) saveProfile(p *Profile) error {
+	path := m.profilePath(p.Owner)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create profiles directory: %w", err)
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := m.cipher.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write profile: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	m.profiles[p.Owner] = p
+	return nil
+}
+
+func (m *Manager) profilePath(owner string) string {
+	return filepath.Join(m.memoryDir, "profiles", unsafeFileChars.ReplaceAllString(owner, "_")+".json")
+}
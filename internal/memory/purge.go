@@ -0,0 +1,188 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// PurgeOwner 删除用户在记忆目录下的置顶、资料和对话摘要，返回删除的条目数（置顶条数加资料和摘要）。
+// 文件损坏无法读取时同样删除
+func (m *Manager) PurgeOwner(owner string) (int, error) {
+	if m.memoryDir == "" {
+		return 0, nil
+	}
+
+	n := 0
+	m.pinMu.Lock()
+	if pins, err := m.loadPins(owner); err == nil {
+		n = len(pins)
+	}
+	err := removeFile(m.pinPath(owner))
+	delete(m.pins, owner)
+	m.pinMu.Unlock()
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove pins: %w", err)
+	}
+
+	m.recapMu.Lock()
+	if _, err := os.Stat(m.recapPath(owner)); err == nil {
+		n++
+	}
+	err = removeFile(m.recapPath(owner))
+	delete(m.recaps, owner)
+	m.recapMu.Unlock()
+	if err != nil {
+		return n, fmt.Errorf("failed to remove recap: %w", err)
+	}
+
+	m.profileMu.Lock()
+	defer m.profileMu.Unlock()
+	if _, err := os.Stat(m.profilePath(owner)); err == nil {
+		n++
+	}
+	if err := removeFile(m.profilePath(owner)); err != nil {
+		return n, fmt.Errorf("failed to remove profile: %w", err)
+	}
+	delete(m.profiles, owner)
+	return n, nil
+}
+
+// removeFile 删除文件，文件不存在不算错误
+func removeFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// dailyEntryRe 每日笔记中每条记录的时间戳标题，见 WriteDailyNote
+var dailyEntryRe = regexp.MustCompile(`(?m)^### \d{2}:\d{2}:\d{2}$`)
+
+// dailyNoteRe 每日笔记的文件名
+var dailyNoteRe = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}\.md$`)
+
+// JournalEntry 自动日记条目的内容，首行标明对话所属的用户，清除用户数据时据此删除
+func JournalEntry(owner, reason, summary string) string {
+	return fmt.Sprintf("%s%s)\n\n%s", journalPrefix(owner), reason, summary)
+}
+
+// journalPrefix 用户的自动日记条目的开头
+func journalPrefix(owner string) string {
+	return "Conversation (" + owner + ", "
+}
+
+// DeleteJournal 从每日笔记及其归档中删除用户的自动日记条目，返回删除的条数
+func (m *Manager) DeleteJournal(owner string) (int, error) {
+	if m.memoryDir == "" {
+		return 0, nil
+	}
+	dates, err := m.ListDailyNotes()
+	if err != nil {
+		return 0, err
+	}
+
+	m.fileMu.Lock()
+	defer m.fileMu.Unlock()
+
+	n := 0
+	defer func() {
+		if n > 0 {
+			m.version.Add(1)
+		}
+	}()
+	for _, date := range dates {
+		removed, _, err := m.removeJournal(filepath.Join(m.memoryDir, "memory", date+".md"), owner)
+		n += removed
+		if err != nil {
+			return n, err
+		}
+	}
+
+	entries, err := m.Archives()
+	if err != nil {
+		return n, err
+	}
+	changed := false
+	for i := range entries {
+		e := &entries[i]
+		if !dailyNoteRe.MatchString(e.Source) {
+			continue
+		}
+		removed, content, err := m.removeJournal(filepath.Join(m.archiveDir(), e.File), owner)
+		n += removed
+		if err != nil {
+			return n, err
+		}
+		if removed == 0 {
+			continue
+		}
+		sections := splitSections(content)
+		e.Sections, e.Bytes, e.Titles = len(sections), len(content), nil
+		for _, sec := range sections {
+			e.Titles = append(e.Titles, sectionTitle(sec))
+		}
+		changed = true
+	}
+	if !changed {
+		return n, nil
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return n, err
+	}
+	if err := m.cipher.WriteFile(filepath.Join(m.archiveDir(), archiveIndexName), data, 0644); err != nil {
+		return n, fmt.Errorf("failed to write archive index: %w", err)
+	}
+	return n, nil
+}
+
+// removeJournal 删除文件中用户的自动日记条目并写回，返回删除的条数和剩余内容。调用方持有 fileMu
+func (m *Manager) removeJournal(path, owner string) (int, string, error) {
+	data, err := m.cipher.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, "", nil
+		}
+		return 0, "", fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	content := string(data)
+	locs := dailyEntryRe.FindAllStringIndex(content, -1)
+	if len(locs) == 0 {
+		return 0, content, nil
+	}
+
+	// 每条记录从时间戳标题前的换行开始，到下一条记录前结束
+	starts := make([]int, len(locs))
+	for i, loc := range locs {
+		starts[i] = loc[0]
+		if starts[i] > 0 && content[starts[i]-1] == '\n' {
+			starts[i]--
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString(content[:starts[0]])
+	n := 0
+	prefix := journalPrefix(owner)
+	for i, loc := range locs {
+		end := len(content)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		if strings.HasPrefix(strings.TrimLeft(content[loc[1]:end], "\n"), prefix) {
+			n++
+			continue
+		}
+		sb.WriteString(content[starts[i]:end])
+	}
+	if n == 0 {
+		return 0, content, nil
+	}
+	if err := m.cipher.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		return 0, "", fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return n, sb.String(), nil
+}
@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestPurgeOwner(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	dir := t.TempDir()
+	m, err := NewManager(Config{Enabled: true, MemoryDir: dir, MaxFileSize: 1 << 20}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	owner, other := "telegram:1", "telegram:2"
+	m.Pin(owner, "likes tea")
+	m.Pin(owner, "lives in Berlin")
+	m.Pin(other, "likes coffee")
+	if _, err := m.UpdateProfile(owner, func(p *Profile) error { return p.Set("name", "Alex") }); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetRecap(owner, "was planning a trip"); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := m.PurgeOwner(owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Errorf("purged = %d, want 4", n)
+	}
+	if len(m.Pins(owner)) != 0 || !m.Profile(owner).IsEmpty() || !m.Recap(owner).IsEmpty() {
+		t.Error("owner data should be gone")
+	}
+	if _, err := os.Stat(m.profilePath(owner)); !os.IsNotExist(err) {
+		t.Errorf("profile file should be removed, stat err = %v", err)
+	}
+	if len(m.Pins(other)) != 1 {
+		t.Error("other user's pins should be kept")
+	}
+
+	// 没有数据时不报错
+	if n, err := m.PurgeOwner(owner); err != nil || n != 0 {
+		t.Errorf("second purge = %d, %v", n, err)
+	}
+}
+
+func TestContactBookDelete(t *testing.T) {
+	dir := t.TempDir()
+	b, err := NewContactBook(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Add("telegram:1", Contact{Name: "Bob"})
+	b.Add("telegram:1", Contact{Name: "Carol"})
+
+	n, err := b.Delete("telegram:1")
+	if err != nil || n != 2 {
+		t.Fatalf("Delete = %d, %v", n, err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(files) != 0 {
+		t.Errorf("files left: %v", files)
+	}
+	if found, _ := b.Search("telegram:1", "bob"); len(found) != 0 {
+		t.Errorf("contacts left: %v", found)
+	}
+}
+
+func TestDeleteJournal(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	dir := t.TempDir()
+	m, err := NewManager(Config{Enabled: true, MemoryDir: dir, MaxFileSize: 300}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := "2024-05-01"
+	notes := []string{
+		JournalEntry("telegram:1", "idle", "- planned a trip to Berlin"),
+		JournalEntry("telegram:12", "idle", "- fixed the build"),
+		"Remember to water the plants",
+		JournalEntry("telegram:1", "shutdown", "- booked a hotel\n\n## Details\n\n- near the station"),
+		JournalEntry("telegram:2", "idle", "- asked about the weather"),
+	}
+	for _, note := range notes {
+		if err := m.WriteDailyNote(date, note); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archives, _ := m.Archives()
+	if len(archives) == 0 {
+		t.Fatal("expected older entries to be archived")
+	}
+
+	n, err := m.DeleteJournal("telegram:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("deleted = %d, want 2", n)
+	}
+
+	var all strings.Builder
+	current, _ := m.ReadDailyNote(date)
+	all.WriteString(current)
+	archives, _ = m.Archives()
+	for _, a := range archives {
+		content, err := m.ReadArchive(a.File)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Bytes != len(content) {
+			t.Errorf("archive %s bytes = %d, want %d", a.File, a.Bytes, len(content))
+		}
+		all.WriteString(content)
+	}
+	for _, gone := range []string{"Berlin", "hotel", "near the station"} {
+		if strings.Contains(all.String(), gone) {
+			t.Errorf("%q still in daily notes:\n%s", gone, all.String())
+		}
+	}
+	for _, kept := range []string{"fixed the build", "water the plants", "weather"} {
+		if !strings.Contains(all.String(), kept) {
+			t.Errorf("%q should be kept:\n%s", kept, all.String())
+		}
+	}
+
+	if n, err := m.DeleteJournal("telegram:1"); err != nil || n != 0 {
+		t.Errorf("second delete = %d, %v", n, err)
+	}
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxRecapLen 对话摘要的最大字符数
+const maxRecapLen = 2000
+
+// Recap 用户上一次对话的摘要，会话因空闲等原因结束时生成，下次对话时注入提示词
+type Recap struct {
+	Summary string    `json:"summary"`
+	Updated time.Time `json:"updated"`
+}
+
+// IsEmpty 是否没有摘要
+func (r Recap) IsEmpty() bool {
+	return r.Summary == ""
+}
+
+// Recap 返回用户上一次对话的摘要
+func (m *Manager) Recap(owner string) Recap {
+	if m.memoryDir == "" {
+		return Recap{}
+	}
+
+	m.recapMu.Lock()
+	defer m.recapMu.Unlock()
+
+	r, err := m.loadRecap(owner)
+	if err != nil {
+		m.log.Warn("failed to load recap", "owner", owner, "error", err)
+		return Recap{}
+	}
+	return r
+}
+
+// SetRecap 保存用户的对话摘要，覆盖之前的摘要，summary 为空时删除
+func (m *Manager) SetRecap(owner, summary string) error {
+	if m.memoryDir == "" {
+		return fmt.Errorf("memory is disabled")
+	}
+	summary = strings.TrimSpace(summary)
+	if r := []rune(summary); len(r) > maxRecapLen {
+		summary = string(r[:maxRecapLen]) + "…"
+	}
+
+	m.recapMu.Lock()
+	defer m.recapMu.Unlock()
+
+	path := m.recapPath(owner)
+	if summary == "" {
+		if err := removeFile(path); err != nil {
+			return fmt.Errorf("failed to remove recap: %w", err)
+		}
+		delete(m.recaps, owner)
+		return nil
+	}
+
+	r := Recap{Summary: summary, Updated: time.Now()}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create recaps directory: %w", err)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := m.cipher.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write recap: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	if m.recaps == nil {
+		m.recaps = make(map[string]Recap)
+	}
+	m.recaps[owner] = r
+	return nil
+}
+
+// loadRecap 读取用户的摘要文件，调用方需持有 recapMu
+func (m *Manager) loadRecap(owner string) (Recap, error) {
+	if r, ok := m.recaps[owner]; ok {
+		return r, nil
+	}
+
+	var r Recap
+	data, err := m.cipher.ReadFile(m.recapPath(owner))
+	if err != nil && !os.IsNotExist(err) {
+		return Recap{}, fmt.Errorf("failed to read recap: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &r); err != nil {
+			return Recap{}, fmt.Errorf("failed to parse recap: %w", err)
+		}
+	}
+
+	if m.recaps == nil {
+		m.recaps = make(map[string]Recap)
+	}
+	m.recaps[owner] = r
+	return r, nil
+}
+
+func (m *Manager) recapPath(owner string) string {
+	return filepath.Join(m.memoryDir, "recaps", unsafeFileChars.ReplaceAllString(owner, "_")+".json")
+}
@@ -0,0 +1,193 @@
+package memory
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	defaultSearchContext = 1
+	defaultSearchLimit   = 20
+)
+
+// SearchQuery 记忆搜索条件
+type SearchQuery struct {
+	Terms   []string // 关键词或短语（不区分大小写），一行包含任意一个即匹配
+	From    string   // 起始日期 YYYY-MM-DD（含），只搜索该日期之后的每日笔记和归档
+	To      string   // 结束日期 YYYY-MM-DD（含）
+	Context int      // 片段前后的上下文行数，默认 1，负数表示不带上下文
+	Limit   int      // 最多返回的片段数，默认 20
+}
+
+// SearchResult 一个匹配片段
+type SearchResult struct {
+	Kind    string   `json:"kind"` // daily, longterm, archive
+	File    string   `json:"file"` // 相对记忆目录的路径
+	Date    string   `json:"date,omitempty"`
+	Line    int      `json:"line"`    // 第一处匹配的行号（从1开始）
+	Snippet string   `json:"snippet"` // 带行号的上下文
+	Matched []string `json:"matched"` // 片段中出现的搜索词
+}
+
+// ParseTerms 把查询字符串拆成搜索词，双引号括起的部分作为一个短语
+func ParseTerms(query string) []string {
+	var terms []string
+	var sb strings.Builder
+	quoted := false
+	flush := func() {
+		if t := strings.TrimSpace(sb.String()); t != "" {
+			terms = append(terms, t)
+		}
+		sb.Reset()
+	}
+	for _, r := range query {
+		switch {
+		case r == '"':
+			flush()
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			flush()
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	flush()
+	return terms
+}
+
+// searchSource 待搜索的文件
+type searchSource struct {
+	kind, file, date string
+}
+
+// SearchMemory 在每日笔记、长期记忆和归档中搜索，返回带上下文和行号的片段。
+// 匹配多个搜索词的片段排在前面，其次是较新的日期；设置了日期范围时不搜索长期记忆
+func (m *Manager) SearchMemory(q SearchQuery) ([]SearchResult, error) {
+	if m.memoryDir == "" {
+		return nil, nil
+	}
+
+	var terms []string
+	for _, t := range q.Terms {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			terms = append(terms, t)
+		}
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("no search terms")
+	}
+	if q.Context < 0 {
+		q.Context = 0
+	} else if q.Context == 0 {
+		q.Context = defaultSearchContext
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultSearchLimit
+	}
+
+	inRange := func(date string) bool {
+		return (q.From == "" || date >= q.From) && (q.To == "" || date <= q.To)
+	}
+
+	var sources []searchSource
+	dates, err := m.ListDailyNotes()
+	if err != nil {
+		return nil, err
+	}
+	for _, date := range dates {
+		if inRange(date) {
+			sources = append(sources, searchSource{"daily", filepath.ToSlash(filepath.Join("memory", date+".md")), date})
+		}
+	}
+	if q.From == "" && q.To == "" {
+		sources = append(sources, searchSource{"longterm", longTermFile, ""})
+	}
+	archives, _ := m.Archives()
+	for _, a := range archives {
+		date := strings.TrimSuffix(a.Source, ".md")
+		if a.Source == longTermFile {
+			date = a.ArchivedAt.Format("2006-01-02")
+		}
+		if inRange(date) {
+			sources = append(sources, searchSource{"archive", filepath.ToSlash(filepath.Join("memory", archiveDirName, a.File)), date})
+		}
+	}
+
+	var results []SearchResult
+	for _, src := range sources {
+		content, err := m.cipher.ReadFile(filepath.Join(m.memoryDir, filepath.FromSlash(src.file)))
+		if err != nil {
+			continue
+		}
+		results = append(results, searchLines(src, strings.Split(string(content), "\n"), terms, q.Context)...)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if len(results[i].Matched) != len(results[j].Matched) {
+			return len(results[i].Matched) > len(results[j].Matched)
+		}
+		return results[i].Date > results[j].Date
+	})
+	if len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, nil
+}
+
+// searchLines 找出匹配的行，相邻或重叠的上下文合并为一个片段
+func searchLines(src searchSource, lines []string, terms []string, context int) []SearchResult {
+	var results []SearchResult
+	start, end, first := -1, -1, 0 // 当前片段的行范围 [start, end] 和第一处匹配
+
+	flush := func() {
+		if start < 0 {
+			return
+		}
+		var sb strings.Builder
+		for i := start; i <= end; i++ {
+			fmt.Fprintf(&sb, "%d| %s\n", i+1, lines[i])
+		}
+		snippet := strings.TrimRight(sb.String(), "\n")
+		r := SearchResult{
+			Kind:    src.kind,
+			File:    src.file,
+			Date:    src.date,
+			Line:    first + 1,
+			Snippet: snippet,
+		}
+		lower := strings.ToLower(strings.Join(lines[start:end+1], "\n"))
+		for _, t := range terms {
+			if strings.Contains(lower, t) {
+				r.Matched = append(r.Matched, t)
+			}
+		}
+		results = append(results, r)
+	}
+
+	for i, line := range lines {
+		if !containsAny(strings.ToLower(line), terms) {
+			continue
+		}
+		from, to := max(i-context, 0), min(i+context, len(lines)-1)
+		if start < 0 || from > end+1 {
+			flush()
+			start, first = from, i
+		}
+		end = to
+	}
+	flush()
+	return results
+}
+
+// containsAny 判断文本是否包含任一搜索词
+func containsAny(text string, terms []string) bool {
+	for _, t := range terms {
+		if strings.Contains(text, t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseTerms(t *testing.T) {
+	got := ParseTerms(`nginx  "backup script" 备份`)
+	want := []string{"nginx", "backup script", "备份"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTerms = %q, want %q", got, want)
+	}
+}
+
+func TestSearchMemory(t *testing.T) {
+	m := newTestManager(t, 1<<20)
+	daily := filepath.Join(m.memoryDir, "memory")
+	os.WriteFile(filepath.Join(daily, "2024-01-02.md"), []byte("### 10:00\n\nfixed the backup script\nnginx reload\n\nunrelated\nmore\nnginx again\n"), 0644)
+	os.WriteFile(filepath.Join(daily, "2024-03-05.md"), []byte("### 09:00\n\nBackup Script moved to cron\n"), 0644)
+	os.WriteFile(filepath.Join(m.memoryDir, longTermFile), []byte("User prefers nginx over apache\n"), 0644)
+
+	results, err := m.SearchMemory(SearchQuery{Terms: []string{"backup script", "nginx"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("results = %+v, want 4 snippets", results)
+	}
+	first := results[0]
+	if first.File != "memory/2024-01-02.md" || first.Line != 3 || len(first.Matched) != 2 {
+		t.Errorf("best result = %+v", first)
+	}
+	if first.Snippet != "2| \n3| fixed the backup script\n4| nginx reload\n5| " {
+		t.Errorf("snippet = %q", first.Snippet)
+	}
+	// 单个词的匹配按日期从新到旧，长期记忆没有日期排在最后
+	if results[1].Date != "2024-03-05" || results[3].Kind != "longterm" {
+		t.Errorf("order = %+v", results)
+	}
+
+	ranged, _ := m.SearchMemory(SearchQuery{Terms: []string{"nginx", "backup"}, From: "2024-02-01", Context: -1})
+	if len(ranged) != 1 || ranged[0].Date != "2024-03-05" || ranged[0].Snippet != "3| Backup Script moved to cron" {
+		t.Errorf("ranged results = %+v", ranged)
+	}
+
+	limited, _ := m.SearchMemory(SearchQuery{Terms: []string{"nginx"}, Limit: 1})
+	if len(limited) != 1 {
+		t.Errorf("limit ignored: %d results", len(limited))
+	}
+
+	if _, err := m.SearchMemory(SearchQuery{Terms: []string{" "}}); err == nil {
+		t.Error("empty terms should fail")
+	}
+}
@@ -0,0 +1,300 @@
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+// 事件类型
+const (
+	EventAlert        = "alert"        // 健康告警、崩溃报告、发件箱投递失败
+	EventConfirmation = "confirmation" // 危险操作确认请求和结果
+	EventScheduler    = "scheduler"    // 定时任务结果
+	EventReminder     = "reminder"     // 待办提醒
+	EventFeed         = "feed"         // 订阅源更新
+	EventTask         = "task"         // 后台任务完成
+)
+
+// 严重级别
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// flushInterval 检查到期的聚合和免打扰消息的间隔
+const flushInterval = 5 * time.Second
+
+// Event 一条通知，Channel/Target 为来源会话，规则没有指定目标时发到这里
+type Event struct {
+	Type     string
+	Severity string
+	Channel  string
+	Target   string
+	Text     string
+	Direct   bool // 不经过发件箱直接发送，用于告警和确认，避免发件箱失败的通知再次进入发件箱
+}
+
+// Sender 发送消息到渠道
+type Sender func(channel, target, text string) error
+
+// batch 发往同一目标、等待一起发送的消息
+type batch struct {
+	channel string
+	target  string
+	direct  bool
+	texts   []string
+	due     time.Time
+}
+
+// Router 按规则把通知发到对应的渠道，处理级别过滤、免打扰时段和聚合
+type Router struct {
+	config *config.Manager
+	send   Sender
+	direct Sender
+	log    *logger.Logger
+	now    func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]*batch
+	stopped bool
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+}
+
+// New 创建通知路由，send 经过发件箱，direct 直接发送
+func New(cfg *config.Manager, send, direct Sender, log *logger.Logger) *Router {
+	return &Router{
+		config:  cfg,
+		send:    send,
+		direct:  direct,
+		log:     log,
+		now:     time.Now,
+		pending: make(map[string]*batch),
+		stopCh:  make(chan struct{}),
+	}
+}
+
+// Start 启动定时发送聚合和推迟的消息
+func (r *Router) Start() {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stopCh:
+				return
+			case <-ticker.C:
+				r.flush(r.now(), false)
+			}
+		}
+	}()
+}
+
+// Stop 停止定时发送，立即发出所有等待中的消息，之后的通知不再推迟
+func (r *Router) Stop() {
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+	close(r.stopCh)
+	r.wg.Wait()
+	r.flush(r.now(), true)
+}
+
+// Notify 按第一条匹配的规则发送通知，没有匹配的规则或未启用时发到来源
+func (r *Router) Notify(e Event) error {
+	cfg := r.config.Get().Notifications
+	if !cfg.Enabled {
+		return r.deliver(e.Direct, e.Channel, e.Target, e.Text)
+	}
+
+	rule := matchRule(cfg.Rules, e.Type)
+	if rule != nil && severityLevel(e.Severity) < severityLevel(rule.MinSeverity) {
+		r.log.Debug("notification dropped by severity", "type", e.Type, "severity", e.Severity)
+		return nil
+	}
+
+	dests := []config.NotificationTarget{{Channel: e.Channel, Target: e.Target}}
+	if rule != nil && len(rule.Destinations) > 0 {
+		dests = rule.Destinations
+	}
+
+	r.mu.Lock()
+	stopped := r.stopped
+	r.mu.Unlock()
+	now := r.now()
+	var due time.Time
+	kind := ""
+	// 确认请求需要及时处理，不推迟也不合并；停止后不再推迟
+	if e.Type != EventConfirmation && !stopped {
+		if end, quiet := quietUntil(cfg, now); quiet && severityLevel(e.Severity) < quietMinSeverity(cfg.QuietHours) {
+			due, kind = end, "quiet"
+		} else if rule != nil && rule.AggregateSeconds > 0 {
+			due, kind = now.Add(time.Duration(rule.AggregateSeconds)*time.Second), "aggregate"
+		}
+	}
+
+	var errs []string
+	for _, d := range dests {
+		if d.Channel == "" {
+			continue
+		}
+		if kind != "" {
+			r.hold(kind, d, e, due)
+			continue
+		}
+		if err := r.deliver(e.Direct, d.Channel, d.Target, e.Text); err != nil {
+			errs = append(errs, fmt.Sprintf("%s:%s: %v", d.Channel, d.Target, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to send notification: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// hold 把消息放入目标的等待批次，免打扰和聚合分开等待
+func (r *Router) hold(kind string, d config.NotificationTarget, e Event, due time.Time) {
+	key := kind + "|" + d.Channel + "|" + d.Target
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.pending[key]
+	if !ok {
+		b = &batch{channel: d.Channel, target: d.Target, due: due}
+		r.pending[key] = b
+	}
+	b.direct = b.direct || e.Direct
+	b.texts = append(b.texts, e.Text)
+}
+
+// flush 发送到期的批次，all 时发送全部
+func (r *Router) flush(now time.Time, all bool) {
+	r.mu.Lock()
+	var ready []*batch
+	for key, b := range r.pending {
+		if all || !now.Before(b.due) {
+			ready = append(ready, b)
+			delete(r.pending, key)
+		}
+	}
+	r.mu.Unlock()
+
+	sort.Slice(ready, func(i, j int) bool { return ready[i].due.Before(ready[j].due) })
+	for _, b := range ready {
+		if err := r.deliver(b.direct, b.channel, b.target, b.text()); err != nil {
+			r.log.Error("failed to send held notifications", "channel", b.channel, "count", len(b.texts), "error", err)
+		}
+	}
+}
+
+// Pending 等待发送的消息数
+func (r *Router) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, b := range r.pending {
+		n += len(b.texts)
+	}
+	return n
+}
+
+func (r *Router) deliver(direct bool, channel, target, text string) error {
+	if channel == "" {
+		return nil
+	}
+	if direct && r.direct != nil {
+		return r.direct(channel, target, text)
+	}
+	return r.send(channel, target, text)
+}
+
+// text 合并批次中的消息
+func (b *batch) text() string {
+	if len(b.texts) == 1 {
+		return b.texts[0]
+	}
+	return fmt.Sprintf("🔔 %d notifications\n\n%s", len(b.texts), strings.Join(b.texts, "\n\n———\n\n"))
+}
+
+// matchRule 返回第一条匹配事件类型的规则
+func matchRule(rules []config.NotificationRule, eventType string) *config.NotificationRule {
+	for i := range rules {
+		if len(rules[i].Events) == 0 {
+			return &rules[i]
+		}
+		for _, t := range rules[i].Events {
+			if t == eventType || t == "*" {
+				return &rules[i]
+			}
+		}
+	}
+	return nil
+}
+
+// severityLevel 级别排序，未知或为空视为 info
+func severityLevel(s string) int {
+	switch strings.ToLower(s) {
+	case SeverityWarning:
+		return 1
+	case SeverityCritical:
+		return 2
+	default:
+		return 0
+	}
+}
+
+func quietMinSeverity(q config.QuietHoursConfig) int {
+	if q.MinSeverity == "" {
+		return severityLevel(SeverityCritical)
+	}
+	return severityLevel(q.MinSeverity)
+}
+
+// quietUntil 当前是否处于免打扰时段，是则返回时段结束时间
+func quietUntil(cfg config.NotificationsConfig, now time.Time) (time.Time, bool) {
+	start, ok1 := parseClock(cfg.QuietHours.Start)
+	end, ok2 := parseClock(cfg.QuietHours.End)
+	if !ok1 || !ok2 || start == end {
+		return time.Time{}, false
+	}
+	loc := time.Local
+	if cfg.Timezone != "" {
+		if l, err := time.LoadLocation(cfg.Timezone); err == nil {
+			loc = l
+		}
+	}
+
+	now = now.In(loc)
+	minute := now.Hour()*60 + now.Minute()
+	quiet := minute >= start && minute < end
+	if start > end {
+		// 跨午夜，如 22:00-07:00
+		quiet = minute >= start || minute < end
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+
+	until := time.Date(now.Year(), now.Month(), now.Day(), end/60, end%60, 0, 0, loc)
+	if !until.After(now) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until, true
+}
+
+// parseClock 解析 HH:MM，返回当天的分钟数
+func parseClock(s string) (int, bool) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+type sentMessage struct {
+	direct  bool
+	channel string
+	target  string
+	text    string
+}
+
+func newTestRouter(t *testing.T, notifications string) (*Router, *[]sentMessage) {
+	t.Helper()
+	log, _ := logger.New(logger.Config{Level: "error"})
+	t.Cleanup(func() { log.Close() })
+
+	configPath := filepath.Join(t.TempDir(), "config.json5")
+	content := `{"llm": {"provider": "ollama"}, "notifications": ` + notifications + `}`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := config.NewManager(configPath, log)
+	if err != nil {
+		t.Fatalf("failed to create config manager: %v", err)
+	}
+	t.Cleanup(func() { cfg.Close() })
+
+	var sent []sentMessage
+	sender := func(direct bool) Sender {
+		return func(channel, target, text string) error {
+			sent = append(sent, sentMessage{direct, channel, target, text})
+			return nil
+		}
+	}
+	return New(cfg, sender(false), sender(true), log), &sent
+}
+
+func TestRouterDisabledSendsToOrigin(t *testing.T) {
+	r, sent := newTestRouter(t, `{"enabled": false, "rules": [{"minSeverity": "critical"}]}`)
+
+	r.Notify(Event{Type: EventScheduler, Channel: "telegram", Target: "1", Text: "done"})
+	r.Notify(Event{Type: EventAlert, Severity: SeverityWarning, Channel: "discord", Target: "2", Text: "disk", Direct: true})
+	r.Notify(Event{Type: EventAlert, Text: "no alerts channel"})
+
+	want := []sentMessage{{false, "telegram", "1", "done"}, {true, "discord", "2", "disk"}}
+	if len(*sent) != 2 || (*sent)[0] != want[0] || (*sent)[1] != want[1] {
+		t.Errorf("sent = %v, want %v", *sent, want)
+	}
+}
+
+func TestRouterRules(t *testing.T) {
+	r, sent := newTestRouter(t, `{
+		"enabled": true,
+		"rules": [
+			{"events": ["alert"], "minSeverity": "warning", "destinations": [{"channel": "telegram", "target": "admin"}, {"channel": "discord", "target": "ops"}]},
+			{"events": ["feed"], "aggregateSeconds": 600}
+		]
+	}`)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	r.Notify(Event{Type: EventAlert, Severity: SeverityInfo, Text: "ignored"})
+	r.Notify(Event{Type: EventAlert, Severity: SeverityCritical, Channel: "feishu", Target: "x", Text: "crash"})
+	if len(*sent) != 2 || (*sent)[0].target != "admin" || (*sent)[1].target != "ops" || (*sent)[0].text != "crash" {
+		t.Fatalf("alert sent = %v", *sent)
+	}
+
+	// 没有匹配的规则时发到来源
+	*sent = nil
+	r.Notify(Event{Type: EventReminder, Channel: "telegram", Target: "1", Text: "⏰ #1 milk"})
+	if len(*sent) != 1 || (*sent)[0].target != "1" {
+		t.Fatalf("reminder sent = %v", *sent)
+	}
+
+	*sent = nil
+	r.Notify(Event{Type: EventFeed, Channel: "telegram", Target: "1", Text: "item a"})
+	now = now.Add(5 * time.Minute)
+	r.Notify(Event{Type: EventFeed, Channel: "telegram", Target: "1", Text: "item b"})
+	r.Notify(Event{Type: EventFeed, Channel: "telegram", Target: "2", Text: "item c"})
+	r.flush(now, false)
+	if len(*sent) != 0 || r.Pending() != 3 {
+		t.Fatalf("aggregated feed items sent early: %v", *sent)
+	}
+
+	now = now.Add(5 * time.Minute)
+	r.flush(now, false)
+	if len(*sent) != 1 || !strings.HasPrefix((*sent)[0].text, "🔔 2 notifications") ||
+		!strings.Contains((*sent)[0].text, "item a") || !strings.Contains((*sent)[0].text, "item b") {
+		t.Fatalf("aggregated sent = %v", *sent)
+	}
+
+	r.Stop()
+	if len(*sent) != 2 || (*sent)[1].text != "item c" || r.Pending() != 0 {
+		t.Errorf("Stop should flush pending notifications, sent = %v", *sent)
+	}
+}
+
+func TestRouterQuietHours(t *testing.T) {
+	r, sent := newTestRouter(t, `{
+		"enabled": true,
+		"timezone": "Asia/Shanghai",
+		"quietHours": {"start": "22:00", "end": "07:30"}
+	}`)
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	now := time.Date(2024, 5, 1, 23, 15, 0, 0, loc)
+	r.now = func() time.Time { return now }
+
+	r.Notify(Event{Type: EventScheduler, Channel: "telegram", Target: "1", Text: "daily report"})
+	r.Notify(Event{Type: EventAlert, Severity: SeverityCritical, Channel: "telegram", Target: "1", Text: "llm down"})
+	r.Notify(Event{Type: EventConfirmation, Severity: SeverityWarning, Channel: "telegram", Target: "1", Text: "approve?"})
+	if len(*sent) != 2 || (*sent)[0].text != "llm down" || (*sent)[1].text != "approve?" {
+		t.Fatalf("quiet hours sent = %v", *sent)
+	}
+
+	r.flush(time.Date(2024, 5, 2, 7, 29, 0, 0, loc), false)
+	if len(*sent) != 2 {
+		t.Fatalf("held notification sent before quiet hours end: %v", *sent)
+	}
+	r.flush(time.Date(2024, 5, 2, 7, 30, 0, 0, loc), false)
+	if len(*sent) != 3 || (*sent)[2].text != "daily report" {
+		t.Errorf("held notification not sent after quiet hours: %v", *sent)
+	}
+}
+
+func TestQuietUntil(t *testing.T) {
+	cfg := config.NotificationsConfig{Timezone: "UTC", QuietHours: config.QuietHoursConfig{Start: "01:00", End: "06:00"}}
+	tests := []struct {
+		now   time.Time
+		quiet bool
+		until time.Time
+	}{
+		{time.Date(2024, 5, 1, 0, 59, 0, 0, time.UTC), false, time.Time{}},
+		{time.Date(2024, 5, 1, 1, 0, 0, 0, time.UTC), true, time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC), false, time.Time{}},
+	}
+	for _, tt := range tests {
+		until, quiet := quietUntil(cfg, tt.now)
+		if quiet != tt.quiet || !until.Equal(tt.until) {
+			t.Errorf("quietUntil(%v) = %v, %v, want %v, %v", tt.now, until, quiet, tt.until, tt.quiet)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/encryption"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+const (
+	DefaultFile = "./outbox.json"
+	// DefaultMaxAttempts 默认最多重试次数
+	DefaultMaxAttempts = 5
+	// DefaultRetryDelay 第一次重试前的等待时间，之后每次翻倍
+	DefaultRetryDelay = 30 * time.Second
+	// maxRetryDelay 重试间隔上限
+	maxRetryDelay = time.Hour
+	// pollInterval 检查到期消息的间隔
+	pollInterval = 5 * time.Second
+)
+
+// Message 待重发的消息
+type Message struct {
+	ID          int       `json:"id"`
+	Channel     string    `json:"channel"`
+	Target      string    `json:"target"`
+	Text        string    `json:"text"`
+	Created     time.Time `json:"created"`
+	Attempts    int       `json:"attempts"` // 包括首次发送
+	NextAttempt time.Time `json:"nextAttempt"`
+	LastError   string    `json:"lastError,omitempty"`
+}
+
+// Config 发件箱配置
+type Config struct {
+	File        string
+	MaxAttempts int
+	RetryDelay  time.Duration
+	Cipher      *encryption.Cipher
+}
+
+// SendFunc 发送消息
+type SendFunc func(channel, target, text string) error
+
+// Outbox 发送失败的消息按指数退避重试，持久化到文件，重启后继续
+type Outbox struct {
+	cfg    Config
+	send   SendFunc
+	log    *logger.Logger
+	onDrop func(m Message)
+
+	mu       sync.Mutex
+	messages []*Message
+	nextID   int
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+}
+
+type outboxFile struct {
+	NextID   int        `json:"nextId"`
+	Messages []*Message `json:"messages"`
+}
+
+// New 加载发件箱文件，文件不存在时创建空发件箱
+func New(cfg Config, send SendFunc, log *logger.Logger) (*Outbox, error) {
+	if cfg.File == "" {
+		cfg.File = DefaultFile
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = DefaultRetryDelay
+	}
+	o := &Outbox{cfg: cfg, send: send, log: log, nextID: 1}
+
+	data, err := cfg.Cipher.ReadFile(cfg.File)
+	if os.IsNotExist(err) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	var f outboxFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse outbox: %w", err)
+	}
+	o.messages = f.Messages
+	o.nextID = f.NextID
+	for _, m := range o.messages {
+		if m.ID >= o.nextID {
+			o.nextID = m.ID + 1
+		}
+	}
+	return o, nil
+}
+
+// OnDrop 设置重试次数用尽后的回调，如通知管理员
+func (o *Outbox) OnDrop(fn func(m Message)) {
+	o.onDrop = fn
+}
+
+// Enqueue 登记首次发送失败的消息，稍后重试
+func (o *Outbox) Enqueue(channel, target, text string, sendErr error) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	m := &Message{
+		ID:          o.nextID,
+		Channel:     channel,
+		Target:      target,
+		Text:        text,
+		Created:     now,
+		Attempts:    1,
+		NextAttempt: now.Add(o.backoff(1)),
+	}
+	if sendErr != nil {
+		m.LastError = sendErr.Error()
+	}
+	o.nextID++
+	o.messages = append(o.messages, m)
+	o.log.Warn("message queued for retry", "id", m.ID, "channel", channel, "target", target, "error", m.LastError)
+	return o.save()
+}
+
+// Send 发送消息，失败时放入发件箱稍后重试
+func (o *Outbox) Send(channel, target, text string) error {
+	err := o.send(channel, target, text)
+	if err == nil {
+		return nil
+	}
+	if qerr := o.Enqueue(channel, target, text, err); qerr != nil {
+		return fmt.Errorf("%w (failed to queue: %v)", err, qerr)
+	}
+	return nil
+}
+
+// Pending 返回待重发的消息，按下次重试时间排序
+func (o *Outbox) Pending() []Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	result := make([]Message, 0, len(o.messages))
+	for _, m := range o.messages {
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].NextAttempt.Before(result[j].NextAttempt) })
+	return result
+}
+
+// DeleteTarget 删除发往指定目标的待重发消息，返回删除的条数
+func (o *Outbox) DeleteTarget(channel, target string) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	kept := o.messages[:0]
+	for _, m := range o.messages {
+		if m.Channel != channel || m.Target != target {
+			kept = append(kept, m)
+		}
+	}
+	n := len(o.messages) - len(kept)
+	o.messages = kept
+	if n == 0 {
+		return 0, nil
+	}
+	return n, o.save()
+}
+
+// Start 启动重试协程
+func (o *Outbox) Start() {
+	o.stopCh = make(chan struct{})
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				o.Flush(time.Now())
+			case <-o.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止重试协程，未发出的消息保留在文件中
+func (o *Outbox) Stop() {
+	if o.stopCh == nil {
+		return
+	}
+	close(o.stopCh)
+	o.wg.Wait()
+	o.stopCh = nil
+}
+
+// Flush 重试已到期的消息，返回发送成功的条数
+func (o *Outbox) Flush(now time.Time) int {
+	o.mu.Lock()
+	var due []Message
+	for _, m := range o.messages {
+		if !m.NextAttempt.After(now) {
+			due = append(due, *m)
+		}
+	}
+	o.mu.Unlock()
+
+	// 发送时不持锁，避免阻塞新消息入队
+	sent := 0
+	var dropped []Message
+	results := make(map[int]error, len(due))
+	for _, m := range due {
+		results[m.ID] = o.send(m.Channel, m.Target, m.Text)
+	}
+
+	o.mu.Lock()
+	kept := o.messages[:0]
+	for _, m := range o.messages {
+		err, tried := results[m.ID]
+		switch {
+		case !tried:
+			kept = append(kept, m)
+		case err == nil:
+			sent++
+			o.log.Info("queued message delivered", "id", m.ID, "channel", m.Channel, "attempts", m.Attempts+1)
+		default:
+			m.Attempts++
+			m.LastError = err.Error()
+			if m.Attempts >= o.cfg.MaxAttempts {
+				o.log.Error("queued message dropped", "id", m.ID, "channel", m.Channel, "target", m.Target, "attempts", m.Attempts, "error", err)
+				dropped = append(dropped, *m)
+				continue
+			}
+			m.NextAttempt = now.Add(o.backoff(m.Attempts))
+			o.log.Warn("queued message retry failed", "id", m.ID, "channel", m.Channel, "attempts", m.Attempts, "error", err)
+			kept = append(kept, m)
+		}
+	}
+	o.messages = kept
+	if len(due) > 0 {
+		if err := o.save(); err != nil {
+			o.log.Error("failed to save outbox", "error", err)
+		}
+	}
+	o.mu.Unlock()
+
+	if o.onDrop != nil {
+		for _, m := range dropped {
+			o.onDrop(m)
+		}
+	}
+	return sent
+}
+
+// backoff 第 attempts 次失败后的等待时间
+func (o *Outbox) backoff(attempts int) time.Duration {
+	d := o.cfg.RetryDelay
+	for i := 1; i < attempts && d < maxRetryDelay; i++ {
+		d *= 2
+	}
+	if d > maxRetryDelay {
+		d = maxRetryDelay
+	}
+	return d
+}
+
+// save 写入发件箱文件，调用方需持有锁
+func (o *Outbox) save() error {
+	data, err := json.MarshalIndent(outboxFile{NextID: o.nextID, Messages: o.messages}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(o.cfg.File); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	tmp := o.cfg.File + ".tmp"
+	if err := o.cfg.Cipher.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+	return os.Rename(tmp, o.cfg.File)
+}
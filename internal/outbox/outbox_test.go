@@ -0,0 +1,111 @@
+package outbox
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestOutbox(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	file := filepath.Join(t.TempDir(), "outbox.json")
+	failing := map[string]bool{"1": true, "2": true}
+	var sent []string
+	send := func(channel, target, text string) error {
+		if failing[target] {
+			return errors.New("network down")
+		}
+		sent = append(sent, target+":"+text)
+		return nil
+	}
+
+	o, err := New(Config{File: file, MaxAttempts: 3, RetryDelay: time.Minute}, send, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dropped []Message
+	o.OnDrop(func(m Message) { dropped = append(dropped, m) })
+
+	if err := o.Send("telegram", "1", "hello"); err != nil {
+		t.Fatalf("Send should queue on failure: %v", err)
+	}
+	if err := o.Send("telegram", "2", "bye"); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Send("telegram", "3", "direct"); err != nil || len(sent) != 1 {
+		t.Fatalf("direct send: err=%v sent=%v", err, sent)
+	}
+
+	// 重启后仍在队列中
+	o, err = New(Config{File: file, MaxAttempts: 3, RetryDelay: time.Minute}, send, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.OnDrop(func(m Message) { dropped = append(dropped, m) })
+	pending := o.Pending()
+	if len(pending) != 2 || pending[0].LastError != "network down" {
+		t.Fatalf("pending = %+v", pending)
+	}
+
+	now := time.Now()
+	if n := o.Flush(now); n != 0 {
+		t.Fatalf("nothing is due yet, sent %d", n)
+	}
+
+	// 第一次重试：1 恢复，2 仍失败，等待时间翻倍
+	failing["1"] = false
+	if n := o.Flush(now.Add(time.Minute)); n != 1 {
+		t.Fatalf("expected 1 delivered, got %d", n)
+	}
+	pending = o.Pending()
+	if len(pending) != 1 || pending[0].Target != "2" || pending[0].Attempts != 2 {
+		t.Fatalf("pending = %+v", pending)
+	}
+	if got := pending[0].NextAttempt.Sub(now.Add(time.Minute)); got != 2*time.Minute {
+		t.Errorf("backoff = %v, want 2m", got)
+	}
+
+	// 达到最大次数后丢弃并回调
+	o.Flush(now.Add(time.Hour))
+	if len(o.Pending()) != 0 || len(dropped) != 1 || dropped[0].Text != "bye" || dropped[0].Attempts != 3 {
+		t.Fatalf("pending = %+v, dropped = %+v", o.Pending(), dropped)
+	}
+}
+
+func TestOutboxDeleteTarget(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	file := filepath.Join(t.TempDir(), "outbox.json")
+	send := func(channel, target, text string) error { return errors.New("network down") }
+	o, err := New(Config{File: file, MaxAttempts: 3, RetryDelay: time.Minute}, send, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Send("telegram", "1", "a")
+	o.Send("telegram", "1", "b")
+	o.Send("telegram", "2", "c")
+	o.Send("feishu", "1", "d")
+
+	if n, err := o.DeleteTarget("telegram", "1"); err != nil || n != 2 {
+		t.Fatalf("DeleteTarget = %d, %v, want 2", n, err)
+	}
+
+	// 删除结果已保存
+	o, err = New(Config{File: file, MaxAttempts: 3, RetryDelay: time.Minute}, send, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := o.Pending()
+	if len(pending) != 2 || pending[0].Text != "c" || pending[1].Text != "d" {
+		t.Fatalf("pending = %+v", pending)
+	}
+	if n, err := o.DeleteTarget("telegram", "1"); err != nil || n != 0 {
+		t.Errorf("second DeleteTarget = %d, %v", n, err)
+	}
+}
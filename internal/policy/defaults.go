@@ -0,0 +1,82 @@
+package policy
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CommandTools 执行shell命令的工具，命令规则默认作用于它们
+var CommandTools = []string{"execute_command", "terminal"}
+
+// dangerousCommands 内置的危险命令片段，需要用户确认
+var dangerousCommands = []string{
+	"rm -rf",
+	"rm -r",
+	"rm -f",
+	"del /",
+	"format",
+	"fdisk",
+	"mkfs",
+	"dd if=",
+	"chmod 777",
+	"chown -R",
+	"> /dev/",
+	":(){ :|:& };:",
+	"wget | sh",
+	"curl | sh",
+	"curl | bash",
+	"apt-get remove",
+	"apt-get purge",
+	"yum remove",
+	"dnf remove",
+	"pacman -R",
+	"pip uninstall",
+	"npm uninstall",
+	"git push --force",
+	"git reset --hard",
+	"DROP TABLE",
+	"DROP DATABASE",
+	"TRUNCATE",
+	"DELETE FROM",
+}
+
+// Defaults 内置规则：包含黑名单命令（tools.blockedCommands）或危险命令片段的命令、删除文件都需要确认。
+// 策略文件中的规则排在内置规则之前，可以用 allow 或 deny 覆盖
+func Defaults(blockedCommands []string) []Rule {
+	var rules []Rule
+	if blocked := anyOf(blockedCommands); blocked != "" {
+		rules = append(rules, Rule{
+			Name:    "blocked-commands",
+			Tools:   CommandTools,
+			Command: blocked,
+			Risk:    RiskCritical,
+			Action:  Confirm,
+		})
+	}
+	return append(rules,
+		Rule{
+			Name:    "dangerous-commands",
+			Tools:   CommandTools,
+			Command: anyOf(dangerousCommands),
+			Risk:    RiskHigh,
+			Action:  Confirm,
+		},
+		Rule{
+			Name:   "delete-files",
+			Tools:  []string{"delete_file"},
+			Risk:   RiskHigh,
+			Action: Confirm,
+		},
+	)
+}
+
+// anyOf 匹配任一字面片段的正则
+func anyOf(fragments []string) string {
+	quoted := make([]string, 0, len(fragments))
+	for _, f := range fragments {
+		if f != "" {
+			quoted = append(quoted, regexp.QuoteMeta(f))
+		}
+	}
+	return strings.Join(quoted, "|")
+}
@@ -0,0 +1,483 @@
+package policy
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Effect 命令的一项影响，Kind 对应翻译键 effect.<kind>
+type Effect struct {
+	Kind    string   `json:"kind"`
+	Targets []string `json:"targets,omitempty"`
+	Risk    string   `json:"risk"`
+}
+
+// Summary 命令的影响摘要，Risk 为各项影响中最高的风险等级
+type Summary struct {
+	Effects []Effect `json:"effects,omitempty"`
+	Risk    string   `json:"risk"`
+}
+
+// 影响类型
+const (
+	EffectRoot           = "root"           // 以 root 身份运行
+	EffectDelete         = "delete"         // 删除文件
+	EffectOverwrite      = "overwrite"      // 覆盖写入文件
+	EffectDevice         = "device"         // 直接写入块设备
+	EffectFormat         = "format"         // 格式化或分区
+	EffectMove           = "move"           // 移动或重命名
+	EffectPermissions    = "permissions"    // 修改权限或所有者
+	EffectRemovePackages = "removePackages" // 卸载软件包
+	EffectInstall        = "installPackages"
+	EffectDiscard        = "discardChanges" // 丢弃未提交的修改
+	EffectForcePush      = "forcePush"      // 强制推送覆盖远程分支
+	EffectService        = "service"        // 停止或重启服务
+	EffectPower          = "power"          // 重启或关机
+	EffectKill           = "kill"           // 结束进程
+	EffectRemoteScript   = "remoteScript"   // 执行下载的脚本
+	EffectDatabase       = "database"       // 删除数据库数据
+	EffectForkBomb       = "forkBomb"
+)
+
+// maxTargets 每项影响最多列出的目标数
+const maxTargets = 5
+
+var riskOrder = map[string]int{RiskLow: 0, RiskMedium: 1, RiskHigh: 2, RiskCritical: 3}
+
+// MaxRisk 返回较高的风险等级
+func MaxRisk(a, b string) string {
+	if riskOrder[b] > riskOrder[a] {
+		return b
+	}
+	if a == "" {
+		return RiskLow
+	}
+	return a
+}
+
+var sqlRe = regexp.MustCompile(`(?i)\b(DROP\s+(?:TABLE|DATABASE|SCHEMA)(?:\s+IF\s+EXISTS)?|TRUNCATE(?:\s+TABLE)?|DELETE\s+FROM)\s+([\w.` + "`" + `"]+)`)
+
+// Explain 按规则分析shell命令会做什么、影响哪些路径或软件包，无法识别的命令没有影响项
+func Explain(command string) Summary {
+	e := &explainer{}
+	segments, pipes := splitCommand(command)
+	for i, seg := range segments {
+		// curl ... | sh：执行下载的脚本
+		if pipes[i] && i+1 < len(segments) && isFetch(seg) && isShell(segments[i+1]) {
+			e.add(EffectRemoteScript, RiskCritical, urls(seg)...)
+		}
+		e.segment(seg)
+	}
+	for _, m := range sqlRe.FindAllStringSubmatch(command, -1) {
+		e.add(EffectDatabase, RiskCritical, strings.ToUpper(strings.Fields(m[1])[0])+" "+strings.Trim(m[2], "`\""))
+	}
+	if strings.Contains(command, ":(){") {
+		e.add(EffectForkBomb, RiskCritical)
+	}
+	if e.summary.Risk == "" {
+		e.summary.Risk = RiskLow
+	}
+	return e.summary
+}
+
+type explainer struct {
+	summary Summary
+}
+
+// add 合并同类影响的目标
+func (e *explainer) add(kind, risk string, targets ...string) {
+	e.summary.Risk = MaxRisk(e.summary.Risk, risk)
+	for i := range e.summary.Effects {
+		eff := &e.summary.Effects[i]
+		if eff.Kind != kind {
+			continue
+		}
+		eff.Risk = MaxRisk(eff.Risk, risk)
+		for _, t := range targets {
+			eff.Targets = appendTarget(eff.Targets, t)
+		}
+		return
+	}
+	eff := Effect{Kind: kind, Risk: risk}
+	for _, t := range targets {
+		eff.Targets = appendTarget(eff.Targets, t)
+	}
+	e.summary.Effects = append(e.summary.Effects, eff)
+}
+
+func appendTarget(targets []string, t string) []string {
+	for _, existing := range targets {
+		if existing == t {
+			return targets
+		}
+	}
+	if len(targets) == maxTargets {
+		// 超出部分省略
+		targets[maxTargets-1] = "…"
+		return targets
+	}
+	return append(targets, t)
+}
+
+// segment 分析一条简单命令
+func (e *explainer) segment(args []string) {
+	args = e.redirects(args)
+	args = e.stripPrefix(args)
+	if len(args) == 0 {
+		return
+	}
+
+	name := path.Base(args[0])
+	flags, operands := splitFlags(args[1:])
+	recursive := hasFlag(flags, "r", "R", "recursive")
+
+	switch {
+	case name == "rm" || name == "rmdir" || name == "unlink" || name == "shred" || name == "del":
+		risk := RiskHigh
+		for _, op := range operands {
+			if recursive && isBroadPath(op) {
+				risk = RiskCritical
+			}
+		}
+		e.add(EffectDelete, risk, operands...)
+	case name == "mv":
+		if len(operands) > 0 {
+			e.add(EffectMove, RiskMedium, operands...)
+		}
+	case name == "chmod" || name == "chown" || name == "chgrp":
+		if len(operands) < 2 {
+			return
+		}
+		risk := RiskMedium
+		if recursive || operands[0] == "777" {
+			risk = RiskHigh
+		}
+		e.add(EffectPermissions, risk, operands[1:]...)
+	case name == "dd":
+		for _, a := range args[1:] {
+			if strings.HasPrefix(a, "of=") {
+				e.write(strings.TrimPrefix(a, "of="))
+			}
+		}
+	case strings.HasPrefix(name, "mkfs") || name == "fdisk" || name == "parted" || name == "wipefs" || name == "format" || name == "sfdisk":
+		e.add(EffectFormat, RiskCritical, operands...)
+	case name == "tee":
+		if !hasFlag(flags, "a", "append") {
+			for _, op := range operands {
+				e.write(op)
+			}
+		}
+	case name == "reboot" || name == "shutdown" || name == "poweroff" || name == "halt":
+		e.add(EffectPower, RiskCritical, name)
+	case name == "init" && len(operands) > 0 && (operands[0] == "0" || operands[0] == "6"):
+		e.add(EffectPower, RiskCritical, "init "+operands[0])
+	case name == "kill" || name == "killall" || name == "pkill":
+		e.add(EffectKill, RiskMedium, operands...)
+	case name == "systemctl" || name == "service":
+		e.service(name, operands)
+	case name == "git":
+		e.git(operands, flags)
+	default:
+		e.packages(name, operands)
+	}
+}
+
+// write 写入文件或设备
+func (e *explainer) write(target string) {
+	switch {
+	case target == "" || target == "/dev/null" || target == "/dev/stdout" || target == "/dev/stderr" || strings.HasPrefix(target, "/dev/tty"):
+	case strings.HasPrefix(target, "/dev/"):
+		e.add(EffectDevice, RiskCritical, target)
+	default:
+		e.add(EffectOverwrite, RiskMedium, target)
+	}
+}
+
+// redirects 记录 > 覆盖写入的目标并从参数中移除重定向
+func (e *explainer) redirects(args []string) []string {
+	out := args[:0:0]
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		op := strings.TrimLeft(a, "0123456789&")
+		if !strings.HasPrefix(op, ">") && !strings.HasPrefix(op, "<") {
+			out = append(out, a)
+			continue
+		}
+		appendMode := strings.HasPrefix(op, ">>")
+		target := strings.TrimLeft(op, "<>|&")
+		if target == "" && i+1 < len(args) {
+			i++
+			target = args[i]
+		}
+		if strings.HasPrefix(op, ">") && !appendMode && !strings.HasPrefix(op, ">&") {
+			e.write(target)
+		}
+	}
+	return out
+}
+
+// stripPrefix 去掉环境变量赋值和 sudo、env、nohup 等前缀命令
+func (e *explainer) stripPrefix(args []string) []string {
+	for len(args) > 0 {
+		switch a := args[0]; {
+		case strings.Contains(a, "=") && !strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "="):
+			args = args[1:]
+		case a == "sudo" || a == "doas":
+			e.add(EffectRoot, RiskMedium)
+			args = args[1:]
+			for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+				// -u user 等带参数的选项
+				if (args[0] == "-u" || args[0] == "-g") && len(args) > 1 {
+					args = args[1:]
+				}
+				args = args[1:]
+			}
+		case a == "env" || a == "nohup" || a == "time" || a == "nice" || a == "exec" || a == "command":
+			args = args[1:]
+		default:
+			return args
+		}
+	}
+	return args
+}
+
+func (e *explainer) service(name string, operands []string) {
+	if len(operands) < 2 {
+		return
+	}
+	action, units := operands[0], operands[1:]
+	if name == "service" {
+		action, units = operands[1], operands[:1]
+	}
+	switch action {
+	case "stop", "restart", "disable", "mask", "kill":
+		e.add(EffectService, RiskMedium, prefixAll(action+" ", units)...)
+	}
+}
+
+func (e *explainer) git(operands, flags []string) {
+	if len(operands) == 0 {
+		return
+	}
+	switch operands[0] {
+	case "push":
+		if hasFlag(flags, "f", "force", "force-with-lease") || containsPrefix(operands[1:], "+") {
+			e.add(EffectForcePush, RiskHigh, strings.Join(operands[1:], " "))
+		}
+	case "reset":
+		if hasFlag(flags, "hard") {
+			e.add(EffectDiscard, RiskHigh, "git reset --hard")
+		}
+	case "clean":
+		if hasFlag(flags, "f", "force") {
+			e.add(EffectDiscard, RiskHigh, "git clean")
+		}
+	case "checkout", "restore":
+		for _, op := range operands[1:] {
+			if op == "." {
+				e.add(EffectDiscard, RiskHigh, "git "+operands[0]+" .")
+			}
+		}
+	}
+}
+
+// packages 软件包管理器的卸载和安装
+func (e *explainer) packages(name string, operands []string) {
+	if len(operands) == 0 {
+		return
+	}
+	sub, pkgs := operands[0], operands[1:]
+	switch name {
+	case "apt", "apt-get", "aptitude":
+		switch sub {
+		case "remove", "purge", "autoremove":
+			e.add(EffectRemovePackages, RiskHigh, pkgs...)
+		case "install":
+			e.add(EffectInstall, RiskMedium, pkgs...)
+		}
+	case "yum", "dnf", "zypper":
+		switch sub {
+		case "remove", "erase", "rm":
+			e.add(EffectRemovePackages, RiskHigh, pkgs...)
+		case "install", "in":
+			e.add(EffectInstall, RiskMedium, pkgs...)
+		}
+	case "apk":
+		switch sub {
+		case "del":
+			e.add(EffectRemovePackages, RiskHigh, pkgs...)
+		case "add":
+			e.add(EffectInstall, RiskMedium, pkgs...)
+		}
+	case "pip", "pip3", "npm", "pnpm", "yarn", "brew", "snap", "gem", "cargo":
+		switch sub {
+		case "uninstall", "remove", "rm", "un":
+			e.add(EffectRemovePackages, RiskHigh, pkgs...)
+		case "install", "add", "i":
+			if len(pkgs) > 0 {
+				e.add(EffectInstall, RiskMedium, pkgs...)
+			}
+		}
+	case "pacman":
+		if strings.HasPrefix(sub, "-R") {
+			e.add(EffectRemovePackages, RiskHigh, pkgs...)
+		} else if strings.HasPrefix(sub, "-S") && len(pkgs) > 0 {
+			e.add(EffectInstall, RiskMedium, pkgs...)
+		}
+	}
+}
+
+// splitCommand 按 ; && || | 和换行拆分为简单命令并分词，pipes[i] 表示第 i 条命令的输出通过管道传给下一条
+func splitCommand(command string) (segments [][]string, pipes []bool) {
+	var args []string
+	var cur strings.Builder
+	inWord := false
+	var quote rune
+
+	flushWord := func() {
+		if inWord {
+			args = append(args, cur.String())
+			cur.Reset()
+			inWord = false
+		}
+	}
+	flushSegment := func(pipe bool) {
+		flushWord()
+		if len(args) > 0 {
+			segments = append(segments, args)
+			pipes = append(pipes, pipe)
+		}
+		args = nil
+	}
+
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else if r == '\\' && quote == '"' && i+1 < len(runes) {
+				i++
+				cur.WriteRune(runes[i])
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\' && i+1 < len(runes):
+			i++
+			cur.WriteRune(runes[i])
+			inWord = true
+		case r == ' ' || r == '\t':
+			flushWord()
+		case r == ';' || r == '\n':
+			flushSegment(false)
+		case r == '&' && i+1 < len(runes) && runes[i+1] == '&':
+			i++
+			flushSegment(false)
+		case r == '|':
+			if i+1 < len(runes) && runes[i+1] == '|' {
+				i++
+				flushSegment(false)
+			} else {
+				flushSegment(true)
+			}
+		case r == '&' && !(i > 0 && (runes[i-1] == '>' || runes[i-1] == '<')) && !(i+1 < len(runes) && runes[i+1] == '>'):
+			// 后台运行
+			flushSegment(false)
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	flushSegment(false)
+	return segments, pipes
+}
+
+// splitFlags 分开选项和操作数，-- 之后都是操作数
+func splitFlags(args []string) (flags, operands []string) {
+	for i, a := range args {
+		if a == "--" {
+			return flags, append(operands, args[i+1:]...)
+		}
+		if strings.HasPrefix(a, "-") && len(a) > 1 {
+			flags = append(flags, a)
+		} else {
+			operands = append(operands, a)
+		}
+	}
+	return flags, operands
+}
+
+// hasFlag 判断是否有某个选项，单字母选项可以合写（如 -rf）
+func hasFlag(flags []string, names ...string) bool {
+	for _, f := range flags {
+		for _, n := range names {
+			if len(n) == 1 {
+				if !strings.HasPrefix(f, "--") && strings.Contains(f[1:], n) {
+					return true
+				}
+			} else if strings.TrimLeft(f, "-") == n || strings.HasPrefix(f, "--"+n+"=") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isBroadPath 递归删除这些路径影响范围极大
+func isBroadPath(p string) bool {
+	switch strings.TrimSuffix(p, "/") {
+	case "", "/*", "~", "*", ".", "..", "$HOME", "/home", "/etc", "/usr", "/var", "/boot":
+		return true
+	}
+	return false
+}
+
+func isFetch(args []string) bool {
+	return len(args) > 0 && (path.Base(args[0]) == "curl" || path.Base(args[0]) == "wget")
+}
+
+func isShell(args []string) bool {
+	for len(args) > 0 && (args[0] == "sudo" || args[0] == "-E") {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return false
+	}
+	switch path.Base(args[0]) {
+	case "sh", "bash", "zsh", "dash", "ksh", "python", "python3", "perl":
+		return true
+	}
+	return false
+}
+
+func urls(args []string) []string {
+	var out []string
+	for _, a := range args[1:] {
+		if strings.Contains(a, "://") {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+func prefixAll(prefix string, items []string) []string {
+	out := make([]string, len(items))
+	for i, s := range items {
+		out[i] = prefix + s
+	}
+	return out
+}
+
+func containsPrefix(items []string, prefix string) bool {
+	for _, s := range items {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		command string
+		effects []Effect
+		risk    string
+	}{
+		{"ls -la", nil, RiskLow},
+		{"rm -rf build dist", []Effect{{Kind: EffectDelete, Targets: []string{"build", "dist"}, Risk: RiskHigh}}, RiskHigh},
+		{"sudo rm -rf /", []Effect{
+			{Kind: EffectRoot, Risk: RiskMedium},
+			{Kind: EffectDelete, Targets: []string{"/"}, Risk: RiskCritical},
+		}, RiskCritical},
+		{"sudo -u postgres apt-get -y purge nginx nginx-common && apt autoremove", []Effect{
+			{Kind: EffectRoot, Risk: RiskMedium},
+			{Kind: EffectRemovePackages, Targets: []string{"nginx", "nginx-common"}, Risk: RiskHigh},
+		}, RiskHigh},
+		{"curl -fsSL https://get.example.com/install.sh | sudo bash", []Effect{
+			{Kind: EffectRemoteScript, Targets: []string{"https://get.example.com/install.sh"}, Risk: RiskCritical},
+			{Kind: EffectRoot, Risk: RiskMedium},
+		}, RiskCritical},
+		{"dd if=image.img of=/dev/sdb bs=4M", []Effect{{Kind: EffectDevice, Targets: []string{"/dev/sdb"}, Risk: RiskCritical}}, RiskCritical},
+		{`echo "x > y" > config.yml 2>/dev/null; cat a >> log.txt 2>&1`, []Effect{{Kind: EffectOverwrite, Targets: []string{"config.yml"}, Risk: RiskMedium}}, RiskMedium},
+		{"git reset --hard HEAD~1 && git push -f origin main", []Effect{
+			{Kind: EffectDiscard, Targets: []string{"git reset --hard"}, Risk: RiskHigh},
+			{Kind: EffectForcePush, Targets: []string{"origin main"}, Risk: RiskHigh},
+		}, RiskHigh},
+		{"systemctl restart nginx php-fpm; service mysql stop", []Effect{{Kind: EffectService, Targets: []string{"restart nginx", "restart php-fpm", "stop mysql"}, Risk: RiskMedium}}, RiskMedium},
+		{`mysql -e 'DROP TABLE IF EXISTS users; DELETE FROM logs'`, []Effect{{Kind: EffectDatabase, Targets: []string{"DROP users", "DELETE logs"}, Risk: RiskCritical}}, RiskCritical},
+		{"chmod -R 777 /var/www", []Effect{{Kind: EffectPermissions, Targets: []string{"/var/www"}, Risk: RiskHigh}}, RiskHigh},
+		{"rm a b c d e f g", []Effect{{Kind: EffectDelete, Targets: []string{"a", "b", "c", "d", "…"}, Risk: RiskHigh}}, RiskHigh},
+		{"shutdown -h now", []Effect{{Kind: EffectPower, Targets: []string{"shutdown"}, Risk: RiskCritical}}, RiskCritical},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			got := Explain(tt.command)
+			if !reflect.DeepEqual(got.Effects, tt.effects) || got.Risk != tt.risk {
+				t.Errorf("Explain = %+v, want %+v (%s)", got, tt.effects, tt.risk)
+			}
+		})
+	}
+}
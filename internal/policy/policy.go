@@ -0,0 +1,219 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Action 规则匹配后的处理方式
+type Action string
+
+const (
+	Allow   Action = "allow"   // 直接执行
+	Deny    Action = "deny"    // 拒绝执行
+	Confirm Action = "confirm" // 需要用户确认
+)
+
+// 风险等级，用于确认提示和日志
+const (
+	RiskLow      = "low"
+	RiskMedium   = "medium"
+	RiskHigh     = "high"
+	RiskCritical = "critical"
+)
+
+// Rule 一条策略规则，所有设置了的条件都满足时匹配
+type Rule struct {
+	Name    string   `json:"name"`
+	Tools   []string `json:"tools"`   // 工具名，为空时匹配所有工具
+	Command string   `json:"command"` // 命令正则（不区分大小写），设置后只匹配执行命令的工具
+	Paths   []string `json:"paths"`   // 路径glob，任一路径匹配即可，支持 **；不含 / 的模式匹配任意目录下的文件名
+	Risk    string   `json:"risk"`    // 匹配操作的风险等级 low/medium/high/critical，默认 medium
+	Action  Action   `json:"action"`
+}
+
+// File 策略文件格式
+type File struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Request 待检查的操作
+type Request struct {
+	Tool    string
+	Command string
+	Paths   []string // 绝对路径
+	Root    string   // 相对路径模式基于的目录（工作目录）
+}
+
+// Decision 检查结果，Rule 为匹配的规则名，没有规则匹配时为空并允许执行
+type Decision struct {
+	Action Action
+	Rule   string
+	Risk   string
+}
+
+type compiledRule struct {
+	Rule
+	tools   map[string]bool
+	command *regexp.Regexp
+	paths   []*regexp.Regexp
+}
+
+// Policy 编译后的规则列表，按顺序匹配，第一条匹配的规则生效
+type Policy struct {
+	rules []compiledRule
+}
+
+// New 编译规则
+func New(rules []Rule) (*Policy, error) {
+	p := &Policy{rules: make([]compiledRule, 0, len(rules))}
+	for i, r := range rules {
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		switch r.Action {
+		case Allow, Deny, Confirm:
+		default:
+			return nil, fmt.Errorf("rule %s: invalid action %q (allow, deny or confirm)", r.Name, r.Action)
+		}
+		switch r.Risk {
+		case "":
+			r.Risk = RiskMedium
+		case RiskLow, RiskMedium, RiskHigh, RiskCritical:
+		default:
+			return nil, fmt.Errorf("rule %s: invalid risk %q", r.Name, r.Risk)
+		}
+
+		c := compiledRule{Rule: r}
+		if len(r.Tools) > 0 {
+			c.tools = make(map[string]bool, len(r.Tools))
+			for _, t := range r.Tools {
+				c.tools[t] = true
+			}
+		}
+		if r.Command != "" {
+			re, err := regexp.Compile("(?i)" + r.Command)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: invalid command pattern: %w", r.Name, err)
+			}
+			c.command = re
+		}
+		for _, glob := range r.Paths {
+			re, err := compileGlob(glob)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: invalid path pattern %q: %w", r.Name, glob, err)
+			}
+			c.paths = append(c.paths, re)
+		}
+		p.rules = append(p.rules, c)
+	}
+	return p, nil
+}
+
+// Load 读取策略文件
+func Load(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+	return f.Rules, nil
+}
+
+// Evaluate 返回第一条匹配规则的决定，没有规则匹配（或 p 为 nil）时允许执行
+func (p *Policy) Evaluate(req Request) Decision {
+	if p != nil {
+		for _, r := range p.rules {
+			if r.matches(req) {
+				return Decision{Action: r.Action, Rule: r.Name, Risk: r.Risk}
+			}
+		}
+	}
+	return Decision{Action: Allow, Risk: RiskLow}
+}
+
+// Rules 返回生效的规则（按匹配顺序）
+func (p *Policy) Rules() []Rule {
+	if p == nil {
+		return nil
+	}
+	rules := make([]Rule, len(p.rules))
+	for i, r := range p.rules {
+		rules[i] = r.Rule
+	}
+	return rules
+}
+
+func (r compiledRule) matches(req Request) bool {
+	if r.tools != nil && !r.tools[req.Tool] {
+		return false
+	}
+	if r.command != nil && (req.Command == "" || !r.command.MatchString(req.Command)) {
+		return false
+	}
+	if len(r.paths) > 0 && !r.matchesPath(req) {
+		return false
+	}
+	return true
+}
+
+func (r compiledRule) matchesPath(req Request) bool {
+	for _, path := range req.Paths {
+		candidates := []string{filepath.ToSlash(filepath.Clean(path))}
+		if req.Root != "" {
+			if rel, err := filepath.Rel(req.Root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				candidates = append(candidates, filepath.ToSlash(rel))
+			}
+		}
+		for _, re := range r.paths {
+			for _, c := range candidates {
+				if re.MatchString(c) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// compileGlob 把glob转换为正则：** 匹配任意层目录，* 和 ? 不跨越 /。
+// 不含 / 的模式匹配任意目录下的文件名，目录模式同时匹配其下的所有文件
+func compileGlob(glob string) (*regexp.Regexp, error) {
+	glob = filepath.ToSlash(glob)
+	if !strings.Contains(strings.TrimSuffix(glob, "/"), "/") {
+		glob = "**/" + glob
+	}
+	glob = strings.TrimSuffix(glob, "/")
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("(?:/.*)?$")
+	return regexp.Compile(sb.String())
+}
@@ -0,0 +1,89 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	root := filepath.FromSlash("/srv/work")
+	custom := []Rule{
+		{Name: "secrets", Paths: []string{"*.pem", ".env", "config/private/"}, Action: Deny},
+		{Name: "etc", Tools: []string{"write_file"}, Paths: []string{"/etc/**"}, Risk: RiskCritical, Action: Confirm},
+		{Name: "docker-read", Tools: CommandTools, Command: `^docker (ps|logs)\b`, Action: Allow},
+		{Name: "force-push", Command: "git push .*--force", Action: Deny},
+	}
+	p, err := New(append(custom, Defaults([]string{"reboot"})...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		req    Request
+		action Action
+		rule   string
+		risk   string
+	}{
+		{"nested pem", Request{Tool: "read_file", Paths: []string{filepath.Join(root, "keys", "id.pem")}}, Deny, "secrets", RiskMedium},
+		{"dotenv", Request{Tool: "write_file", Paths: []string{filepath.Join(root, ".env")}}, Deny, "secrets", RiskMedium},
+		{"directory pattern", Request{Tool: "delete_file", Paths: []string{filepath.Join(root, "config", "private", "a.json")}}, Deny, "secrets", RiskMedium},
+		{"relative glob is not a prefix match", Request{Tool: "write_file", Paths: []string{filepath.Join(root, "x", "config", "private")}}, Allow, "", RiskLow},
+		{"absolute glob", Request{Tool: "write_file", Paths: []string{"/etc/nginx/nginx.conf"}}, Confirm, "etc", RiskCritical},
+		{"absolute glob other tool", Request{Tool: "read_file", Paths: []string{"/etc/nginx/nginx.conf"}}, Allow, "", RiskLow},
+		{"allow overrides default", Request{Tool: "terminal", Command: "docker logs web | tail -n 5 && rm -rf /tmp/x"}, Allow, "docker-read", RiskMedium},
+		{"any tool deny", Request{Tool: "execute_command", Command: "git push origin main --force"}, Deny, "force-push", RiskMedium},
+		{"blocked command", Request{Tool: "execute_command", Command: "sudo REBOOT"}, Confirm, "blocked-commands", RiskCritical},
+		{"dangerous command", Request{Tool: "terminal", Command: "rm -rf build"}, Confirm, "dangerous-commands", RiskHigh},
+		{"command rule needs a command", Request{Tool: "delete_file", Paths: []string{filepath.Join(root, "a.txt")}}, Confirm, "delete-files", RiskHigh},
+		{"safe command", Request{Tool: "execute_command", Command: "ls -la"}, Allow, "", RiskLow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Root = root
+			d := p.Evaluate(tt.req)
+			if d.Action != tt.action || d.Rule != tt.rule || d.Risk != tt.risk {
+				t.Errorf("Evaluate = %+v, want %s/%s/%s", d, tt.action, tt.rule, tt.risk)
+			}
+		})
+	}
+
+	var none *Policy
+	if d := none.Evaluate(Request{Tool: "execute_command", Command: "rm -rf /"}); d.Action != Allow {
+		t.Errorf("nil policy = %+v", d)
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	for _, r := range []Rule{
+		{Name: "no-action"},
+		{Name: "bad-action", Action: "maybe"},
+		{Name: "bad-risk", Risk: "extreme", Action: Deny},
+		{Name: "bad-regex", Command: "(", Action: Deny},
+	} {
+		if _, err := New([]Rule{r}); err == nil {
+			t.Errorf("New(%s) should fail", r.Name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	os.WriteFile(path, []byte(`{"rules": [{"tools": ["execute_command"], "command": "curl", "action": "deny"}]}`), 0644)
+
+	rules, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := New(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := p.Evaluate(Request{Tool: "execute_command", Command: "curl example.com"}); d.Action != Deny || d.Rule != "rule-1" {
+		t.Errorf("Evaluate = %+v", d)
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("missing policy file should fail")
+	}
+}
@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/feed"
+	"github.com/HaohanHe/mujibot/internal/httpclient"
+	"github.com/HaohanHe/mujibot/internal/notify"
+)
+
+const (
+	// DefaultFeedInterval 默认订阅拉取间隔
+	DefaultFeedInterval = 30 * time.Minute
+	minFeedInterval     = 5 * time.Minute
+	// maxPushItems 单条推送消息最多包含的条目数
+	maxPushItems = 10
+)
+
+// SetFeeds 设置订阅存储，调度器按 feeds.interval 拉取并推送新条目
+func (s *Scheduler) SetFeeds(store *feed.Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.feeds = store
+}
+
+// checkFeeds 到达拉取间隔时异步拉取全部订阅
+func (s *Scheduler) checkFeeds(now time.Time) {
+	cfg := s.config.Get().Feeds
+
+	s.mu.Lock()
+	store := s.feeds
+	if store == nil || !cfg.Enabled || s.feedsPolling || now.Before(s.nextFeedPoll) {
+		s.mu.Unlock()
+		return
+	}
+	interval := DefaultFeedInterval
+	if cfg.Interval > 0 {
+		interval = time.Duration(cfg.Interval) * time.Minute
+	}
+	if interval < minFeedInterval {
+		interval = minFeedInterval
+	}
+	s.nextFeedPoll = now.Add(interval)
+	s.feedsPolling = true
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			s.feedsPolling = false
+			s.mu.Unlock()
+		}()
+		s.pollFeeds(store)
+	}()
+}
+
+// pollFeeds 依次拉取订阅并推送匹配关键词的新条目
+func (s *Scheduler) pollFeeds(store *feed.Store) {
+	client := httpclient.New(30 * time.Second)
+
+	for _, sub := range store.All() {
+		if s.ctx.Err() != nil {
+			return
+		}
+
+		updated, items, err := store.Poll(s.ctx, client, sub.ID)
+		if err != nil {
+			s.log.Warn("feed poll failed", "id", sub.ID, "url", sub.URL, "error", err)
+			continue
+		}
+		if len(items) == 0 || s.send == nil {
+			continue
+		}
+		if !s.claim(fmt.Sprintf("feed:%s:%s:%s:%s", updated.Channel, updated.Target, updated.URL, items[0].ID)) {
+			continue
+		}
+
+		s.log.Info("feed has new items", "id", sub.ID, "url", sub.URL, "count", len(items))
+		e := notify.Event{Type: notify.EventFeed, Severity: notify.SeverityInfo, Channel: updated.Channel, Target: updated.Target, Text: formatFeedItems(updated, items)}
+		if err := s.send(e); err != nil {
+			s.log.Error("failed to deliver feed items", "id", sub.ID, "channel", updated.Channel, "error", err)
+		}
+	}
+}
+
+// formatFeedItems 生成推送消息
+func formatFeedItems(sub *feed.Subscription, items []feed.Item) string {
+	title := sub.Title
+	if title == "" {
+		title = sub.URL
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📰 %s (#%d)", title, sub.ID)
+	for i, item := range items {
+		if i == maxPushItems {
+			fmt.Fprintf(&sb, "\n… +%d more", len(items)-maxPushItems)
+			break
+		}
+		fmt.Fprintf(&sb, "\n\n• %s", item.Title)
+		if item.Link != "" {
+			fmt.Fprintf(&sb, "\n%s", item.Link)
+		}
+	}
+	return sb.String()
+}
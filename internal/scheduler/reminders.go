@@ -0,0 +1,51 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/notify"
+	"github.com/HaohanHe/mujibot/internal/todo"
+)
+
+// SetTodos 设置待办存储，到期的待办会推送提醒到创建时的渠道
+func (s *Scheduler) SetTodos(store *todo.Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.todos = store
+}
+
+// checkReminders 推送已到期的待办提醒
+func (s *Scheduler) checkReminders(now time.Time) {
+	s.mu.Lock()
+	store := s.todos
+	s.mu.Unlock()
+	if store == nil || s.send == nil {
+		return
+	}
+
+	reminders, err := store.DueReminders(now)
+	if err != nil {
+		s.log.Error("failed to save reminder state", "error", err)
+	}
+	if len(reminders) == 0 {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for _, r := range reminders {
+			if !s.claim(fmt.Sprintf("reminder:%s:%s:%d:%d", r.Owner.Channel, r.Owner.UserID, r.Item.ID, r.Item.Due.Unix())) {
+				continue
+			}
+			text := fmt.Sprintf("⏰ #%d %s", r.Item.ID, r.Item.Text)
+			e := notify.Event{Type: notify.EventReminder, Severity: notify.SeverityInfo, Channel: r.Owner.Channel, Target: r.Owner.Target, Text: text}
+			if err := s.send(e); err != nil {
+				s.log.Error("failed to deliver reminder", "id", r.Item.ID, "channel", r.Owner.Channel, "user_id", r.Owner.UserID, "error", err)
+				continue
+			}
+			s.log.Info("reminder sent", "id", r.Item.ID, "channel", r.Owner.Channel, "user_id", r.Owner.UserID)
+		}
+	}()
+}
@@ -0,0 +1,281 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/agent"
+	"github.com/HaohanHe/mujibot/internal/cluster"
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/cron"
+	"github.com/HaohanHe/mujibot/internal/feed"
+	"github.com/HaohanHe/mujibot/internal/i18n"
+	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/notify"
+	"github.com/HaohanHe/mujibot/internal/todo"
+)
+
+// CheckInterval 调度检查间隔
+const CheckInterval = 15 * time.Second
+
+// longTaskThreshold 运行超过此时长的任务，结果前附带完成状态和耗时
+const longTaskThreshold = time.Minute
+
+// Sender 将任务结果、提醒和订阅更新作为通知发送，由通知路由决定最终渠道
+type Sender func(e notify.Event) error
+
+// Translator 按接收者的语言翻译 key 并替换占位符
+type Translator func(channel, target, key string, params i18n.Params) string
+
+// Scheduler 定时任务调度器
+type Scheduler struct {
+	config *config.Manager
+	router *agent.Router
+	send   Sender
+	log    *logger.Logger
+
+	mu      sync.Mutex
+	entries map[string]*entry
+
+	feeds        *feed.Store
+	nextFeedPoll time.Time
+	feedsPolling bool
+
+	todos *todo.Store
+
+	store cluster.Store
+
+	translate Translator
+
+	// now 返回当前时间，测试时可用 SetClock 替换
+	now func() time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// entry 单个任务的调度状态
+type entry struct {
+	spec     string
+	schedule cron.Schedule
+	next     time.Time
+	running  bool
+}
+
+// New 创建调度器
+func New(cfg *config.Manager, router *agent.Router, send Sender, log *logger.Logger) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		config:  cfg,
+		router:  router,
+		send:    send,
+		log:     log,
+		entries: make(map[string]*entry),
+		now:     time.Now,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Start 启动调度器
+func (s *Scheduler) Start() {
+	s.wg.Add(1)
+	go s.loop()
+}
+
+// Stop 停止调度器并等待正在运行的任务结束
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// claimTTL 抢占记录的保留时间，需长于实例间的时钟偏差和调度间隔
+const claimTTL = 24 * time.Hour
+
+// SetStore 设置共享存储，须在 Start 前调用。多实例部署时每次任务运行、提醒和订阅推送只由一个实例执行
+func (s *Scheduler) SetStore(store cluster.Store) {
+	s.store = store
+}
+
+// claim 在共享存储中抢占一次性工作，未设置共享存储或存储出错时照常执行
+func (s *Scheduler) claim(key string) bool {
+	if s.store == nil {
+		return true
+	}
+	ok, err := s.store.Claim(key, claimTTL)
+	if err != nil {
+		s.log.Warn("failed to claim scheduled work, running anyway", "key", key, "error", err)
+		return true
+	}
+	if !ok {
+		s.log.Debug("scheduled work claimed by another instance", "key", key)
+	}
+	return ok
+}
+
+// SetTranslator 设置通知文本的翻译函数，须在 Start 前调用。未设置时使用英文
+func (s *Scheduler) SetTranslator(tr Translator) {
+	s.translate = tr
+}
+
+// tr 按任务接收者的语言翻译通知文本
+func (s *Scheduler) tr(task config.ScheduleConfig, key string, params i18n.Params) string {
+	if s.translate == nil {
+		return i18n.New("en-US").Tf(key, params)
+	}
+	return s.translate(task.Channel, task.Target, key, params)
+}
+
+// SetClock 替换获取当前时间的函数，须在 Start 前调用。测试中配合 Tick 模拟时间流逝
+func (s *Scheduler) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// Tick 立即按当前时间检查一次到期的任务、提醒和订阅，不等待检查间隔
+func (s *Scheduler) Tick() {
+	s.tick(s.now())
+}
+
+// loop 调度循环
+func (s *Scheduler) loop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.tick(s.now())
+		}
+	}
+}
+
+// tick 检查到期任务，配置变化时重新计算下次运行时间
+func (s *Scheduler) tick(now time.Time) {
+	tasks := s.config.Get().Schedules
+
+	s.mu.Lock()
+	seen := make(map[string]bool, len(tasks))
+	var due []config.ScheduleConfig
+	var dueAt []time.Time
+	var dueEntries []*entry
+
+	for _, task := range tasks {
+		if !task.Enabled || task.Name == "" {
+			continue
+		}
+		seen[task.Name] = true
+
+		e, ok := s.entries[task.Name]
+		if !ok || e.spec != task.Schedule {
+			sched, err := cron.Parse(task.Schedule)
+			if err != nil {
+				s.log.Error("invalid schedule", "name", task.Name, "schedule", task.Schedule, "error", err)
+				s.entries[task.Name] = &entry{spec: task.Schedule}
+				continue
+			}
+			e = &entry{spec: task.Schedule, schedule: sched, next: sched.Next(now)}
+			s.entries[task.Name] = e
+			s.log.Info("schedule registered", "name", task.Name, "next", e.next.Format(time.RFC3339))
+			continue
+		}
+
+		if e.schedule == nil || now.Before(e.next) {
+			continue
+		}
+		dueAt = append(dueAt, e.next)
+		e.next = e.schedule.Next(now)
+		due = append(due, task)
+		dueEntries = append(dueEntries, e)
+	}
+
+	for name := range s.entries {
+		if !seen[name] {
+			delete(s.entries, name)
+		}
+	}
+	s.mu.Unlock()
+
+	for i, task := range due {
+		if s.claim(fmt.Sprintf("schedule:%s:%d", task.Name, dueAt[i].Unix())) {
+			s.launch(task, dueEntries[i])
+		}
+	}
+
+	s.checkFeeds(now)
+	s.checkReminders(now)
+}
+
+// launch 异步执行任务，同名任务不会并发运行。e 为 tick 中取出的条目，条目只由 tick 创建和删除
+func (s *Scheduler) launch(task config.ScheduleConfig, e *entry) {
+	s.mu.Lock()
+	if e.running {
+		s.mu.Unlock()
+		s.log.Warn("schedule still running, skipping", "name", task.Name)
+		return
+	}
+	e.running = true
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			e.running = false
+			s.mu.Unlock()
+		}()
+		s.run(task)
+	}()
+}
+
+// run 执行任务并发送结果
+func (s *Scheduler) run(task config.ScheduleConfig) {
+	start := s.now()
+	requestID := logger.NewRequestID()
+	ctx := logger.WithRequestID(s.ctx, requestID)
+	log := s.log.With("request_id", requestID)
+	log.Info("schedule started", "name", task.Name, "agent", task.Agent)
+
+	a, err := s.router.Route("", "scheduler", task.Agent)
+	if err != nil {
+		log.Error("schedule failed", "name", task.Name, "error", err)
+		s.deliver(log, task, notify.SeverityWarning, s.tr(task, "scheduleFailed", i18n.Params{"name": task.Name, "error": err}))
+		return
+	}
+
+	response, err := s.router.RunTask(ctx, a, task.Name, task.Prompt, task.AllowedTools)
+	duration := s.now().Sub(start)
+	if err != nil {
+		log.Error("schedule failed", "name", task.Name, "error", err)
+		// 退出时被取消的任务不算失败
+		if s.ctx.Err() == nil {
+			s.deliver(log, task, notify.SeverityWarning, s.tr(task, "scheduleFailedAfter", i18n.Params{"name": task.Name, "duration": duration.Round(time.Second), "error": err}))
+		}
+		return
+	}
+
+	log.Info("schedule finished", "name", task.Name, "duration", duration.String())
+
+	if response != "" && duration >= longTaskThreshold {
+		response = s.tr(task, "scheduleFinished", i18n.Params{"name": task.Name, "duration": duration.Round(time.Second)}) + "\n\n" + response
+	}
+	s.deliver(log, task, notify.SeverityInfo, response)
+}
+
+// deliver 把任务结果或失败原因发送到任务配置的渠道，失败原因为 warning 级别
+func (s *Scheduler) deliver(log *logger.Logger, task config.ScheduleConfig, severity, text string) {
+	if text == "" || task.Channel == "" || s.send == nil {
+		return
+	}
+	e := notify.Event{Type: notify.EventScheduler, Severity: severity, Channel: task.Channel, Target: task.Target, Text: text}
+	if err := s.send(e); err != nil {
+		log.Error("failed to deliver schedule result", "name", task.Name, "channel", task.Channel, "error", err)
+	}
+}
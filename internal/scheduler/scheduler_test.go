@@ -0,0 +1,118 @@
+package scheduler
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/agent"
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/i18n"
+	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/memory"
+	"github.com/HaohanHe/mujibot/internal/notify"
+	"github.com/HaohanHe/mujibot/internal/session"
+	"github.com/HaohanHe/mujibot/internal/testkit"
+	"github.com/HaohanHe/mujibot/internal/tools"
+)
+
+func TestScheduleRun(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	cfg, err := config.NewManager(testkit.WriteConfig(t, map[string]interface{}{
+		"schedules": []map[string]interface{}{
+			{"name": "digest", "enabled": true, "schedule": "0 8 * * *", "prompt": "summarize", "channel": "test", "target": "42"},
+		},
+	}), log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cfg.Close()
+
+	toolMgr, err := tools.NewManager(tools.Config{WorkDir: t.TempDir()}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem, _ := memory.NewManager(memory.Config{}, log)
+	sessions := session.NewManager(20, 3600, 10, log)
+	defer sessions.Close()
+
+	p := testkit.NewProvider(testkit.Reply("Good morning"))
+	router := agent.NewRouter(log)
+	router.RegisterAgent("default", agent.CreateAgent("default", cfg.Get().Agents["default"], p, toolMgr, sessions, mem, nil, log))
+
+	var mu sync.Mutex
+	var events []notify.Event
+	s := New(cfg, router, func(e notify.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+		return nil
+	}, log)
+	clock := testkit.NewClock(time.Date(2024, 5, 1, 7, 59, 0, 0, time.Local))
+	s.SetClock(clock.Now)
+
+	// 第一次检查只登记下次运行时间
+	s.Tick()
+	clock.Advance(30 * time.Second)
+	s.Tick()
+	if len(p.Requests()) != 0 {
+		t.Fatal("schedule ran before it was due")
+	}
+
+	clock.Advance(30 * time.Second)
+	s.Tick()
+	s.Stop()
+
+	if len(p.Requests()) != 1 || p.Requests()[0].Last().Content != "summarize" {
+		t.Fatalf("requests = %+v", p.Requests())
+	}
+	if len(events) != 1 || events[0].Text != "Good morning" || events[0].Channel != "test" || events[0].Type != notify.EventScheduler {
+		t.Errorf("events = %+v", events)
+	}
+}
+
+func TestScheduleFailureTranslated(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	cfg, err := config.NewManager(testkit.WriteConfig(t, map[string]interface{}{
+		"schedules": []map[string]interface{}{
+			{"name": "digest", "enabled": true, "schedule": "0 8 * * *", "agent": "missing", "prompt": "summarize", "channel": "test", "target": "42"},
+		},
+	}), log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cfg.Close()
+
+	var mu sync.Mutex
+	var events []notify.Event
+	s := New(cfg, agent.NewRouter(log), func(e notify.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+		return nil
+	}, log)
+	var recipients []string
+	s.SetTranslator(func(channel, target, key string, params i18n.Params) string {
+		recipients = append(recipients, channel+":"+target)
+		return i18n.New("zh-CN").Tf(key, params)
+	})
+	clock := testkit.NewClock(time.Date(2024, 5, 1, 7, 59, 0, 0, time.Local))
+	s.SetClock(clock.Now)
+
+	s.Tick()
+	clock.Advance(time.Minute)
+	s.Tick()
+	s.Stop()
+
+	want := "定时任务 digest 执行失败：agent not found: missing"
+	if len(events) != 1 || events[0].Text != want || events[0].Severity != notify.SeverityWarning {
+		t.Fatalf("events = %+v", events)
+	}
+	if len(recipients) != 1 || recipients[0] != "test:42" {
+		t.Errorf("translated for %v, want test:42", recipients)
+	}
+}
@@ -0,0 +1,66 @@
+package session
+
+// 会话结束的原因
+const (
+	CloseIdle     = "idle"     // 空闲超时被清理
+	CloseEvicted  = "evicted"  // 超过最大会话数被淘汰
+	CloseCleared  = "cleared"  // 消息被清空
+	CloseReplaced = "replaced" // 被载入的已保存对话替换
+	CloseDeleted  = "deleted"  // 会话被删除
+	CloseShutdown = "shutdown" // 会话管理器关闭
+)
+
+// Closed 结束的会话快照，传给 SetOnClose 设置的回调
+type Closed struct {
+	ID       string
+	UserID   string
+	Channel  string
+	AgentID  string
+	Reason   string
+	Messages []Message
+}
+
+// SetOnClose 设置会话结束回调（空闲清理、淘汰、清空、替换、删除、关闭时），
+// 回调在释放锁之后同步调用，耗时操作应自行放到后台；没有消息的会话不会触发
+func (m *Manager) SetOnClose(fn func(c Closed)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onClose = fn
+}
+
+// snapshot 生成会话快照，没有设置回调或没有消息时返回 nil
+func (m *Manager) snapshot(session *Session, reason string) *Closed {
+	if m.onClose == nil {
+		return nil
+	}
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+	if len(session.Messages) == 0 {
+		return nil
+	}
+	messages := make([]Message, len(session.Messages))
+	copy(messages, session.Messages)
+	return &Closed{
+		ID:       session.ID,
+		UserID:   session.UserID,
+		Channel:  session.Channel,
+		AgentID:  session.AgentID,
+		Reason:   reason,
+		Messages: messages,
+	}
+}
+
+// notifyClosed 调用会话结束回调，调用方不能持有 m.mu
+func (m *Manager) notifyClosed(closed []*Closed) {
+	m.mu.RLock()
+	fn := m.onClose
+	m.mu.RUnlock()
+	if fn == nil {
+		return
+	}
+	for _, c := range closed {
+		if c != nil {
+			fn(*c)
+		}
+	}
+}
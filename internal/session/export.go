@@ -0,0 +1,88 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Transcript 会话导出结构
+type Transcript struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	Channel    string    `json:"channel"`
+	AgentID    string    `json:"agent_id"`
+	ExportedAt time.Time `json:"exported_at"`
+	Messages   []Message `json:"messages"`
+}
+
+// GetByID 按会话ID获取会话（不更新LRU）
+func (m *Manager) GetByID(id string) *Session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if elem, ok := m.sessions[id]; ok {
+		return elem.Value.(*sessionEntry).session
+	}
+	return nil
+}
+
+// Transcript 生成会话记录快照
+func (m *Manager) Transcript(session *Session) Transcript {
+	return Transcript{
+		ID:         session.ID,
+		UserID:     session.UserID,
+		Channel:    session.Channel,
+		AgentID:    session.AgentID,
+		ExportedAt: time.Now(),
+		Messages:   m.GetMessages(session),
+	}
+}
+
+// ExportJSON 导出为JSON
+func (m *Manager) ExportJSON(session *Session) ([]byte, error) {
+	return json.MarshalIndent(m.Transcript(session), "", "  ")
+}
+
+// ExportMarkdown 导出为Markdown
+func (m *Manager) ExportMarkdown(session *Session) string {
+	t := m.Transcript(session)
+
+	var sb strings.Builder
+	sb.WriteString("# Conversation Export\n\n")
+	sb.WriteString(fmt.Sprintf("- Session: %s\n", t.ID))
+	sb.WriteString(fmt.Sprintf("- User: %s\n", t.UserID))
+	sb.WriteString(fmt.Sprintf("- Channel: %s\n", t.Channel))
+	sb.WriteString(fmt.Sprintf("- Agent: %s\n", t.AgentID))
+	sb.WriteString(fmt.Sprintf("- Exported: %s\n", t.ExportedAt.Format("2006-01-02 15:04:05")))
+
+	for _, msg := range t.Messages {
+		sb.WriteString(fmt.Sprintf("\n## %s · %s\n\n", roleTitle(msg.Role), msg.Timestamp.Format("2006-01-02 15:04:05")))
+		if msg.Content != "" {
+			sb.WriteString(msg.Content)
+			sb.WriteString("\n")
+		}
+		for _, tc := range msg.ToolCalls {
+			sb.WriteString(fmt.Sprintf("\n> Tool call: `%s`\n>\n> ```json\n> %s\n> ```\n", tc.Function.Name, tc.Function.Arguments))
+		}
+	}
+
+	return sb.String()
+}
+
+// roleTitle 角色显示名称
+func roleTitle(role string) string {
+	switch role {
+	case "user":
+		return "User"
+	case "assistant":
+		return "Assistant"
+	case "tool":
+		return "Tool"
+	case "system":
+		return "System"
+	default:
+		return role
+	}
+}
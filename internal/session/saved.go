@@ -0,0 +1,253 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/encryption"
+)
+
+const (
+	// maxSavedPerOwner 每个用户最多保存的对话数
+	maxSavedPerOwner = 20
+	// maxSavedNameLen 对话名称的最大字符数
+	maxSavedNameLen = 64
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// SavedConversation 用户命名保存的对话快照
+type SavedConversation struct {
+	Name     string    `json:"name"`
+	AgentID  string    `json:"agent_id"`
+	Saved    time.Time `json:"saved"`
+	Messages []Message `json:"messages"`
+}
+
+// SavedInfo 已保存对话的概要，用于列表
+type SavedInfo struct {
+	Name     string    `json:"name"`
+	AgentID  string    `json:"agent_id"`
+	Saved    time.Time `json:"saved"`
+	Messages int       `json:"messages"`
+}
+
+// savedList 单个用户的已保存对话，对应一个文件
+type savedList struct {
+	Owner         string               `json:"owner"`
+	Conversations []*SavedConversation `json:"conversations"`
+}
+
+// SavedStore 按用户保存命名对话（/save、/load），每个用户一个JSON文件
+type SavedStore struct {
+	dir    string
+	cipher *encryption.Cipher
+
+	mu    sync.Mutex
+	lists map[string]*savedList
+}
+
+// NewSavedStore 创建已保存对话的存储，文件按需加载，c 为空时明文存储
+func NewSavedStore(dir string, c *encryption.Cipher) (*SavedStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create conversations directory: %w", err)
+	}
+	return &SavedStore{dir: dir, cipher: c, lists: make(map[string]*savedList)}, nil
+}
+
+// NormalizeSavedName 整理对话名称：合并空白，检查长度
+func NormalizeSavedName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return "", fmt.Errorf("name is required")
+	}
+	if len([]rune(name)) > maxSavedNameLen {
+		return "", fmt.Errorf("name too long (max %d characters)", maxSavedNameLen)
+	}
+	return name, nil
+}
+
+// Save 保存对话快照，同名（不区分大小写）的对话被覆盖，返回是否覆盖了已有对话
+func (s *SavedStore) Save(owner, name, agentID string, messages []Message) (bool, error) {
+	name, err := NormalizeSavedName(name)
+	if err != nil {
+		return false, err
+	}
+	if len(messages) == 0 {
+		return false, fmt.Errorf("conversation is empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, err := s.load(owner)
+	if err != nil {
+		return false, err
+	}
+
+	c := &SavedConversation{
+		Name:     name,
+		AgentID:  agentID,
+		Saved:    time.Now(),
+		Messages: append([]Message(nil), messages...),
+	}
+	replaced := false
+	if i := l.find(name); i >= 0 {
+		l.Conversations[i] = c
+		replaced = true
+	} else {
+		if len(l.Conversations) >= maxSavedPerOwner {
+			return false, fmt.Errorf("too many saved conversations (max %d), remove one with /unsave <name> first", maxSavedPerOwner)
+		}
+		l.Conversations = append(l.Conversations, c)
+	}
+	return replaced, s.save(l)
+}
+
+// Load 返回已保存的对话，不存在时返回错误
+func (s *SavedStore) Load(owner, name string) (*SavedConversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, err := s.load(owner)
+	if err != nil {
+		return nil, err
+	}
+	i := l.find(name)
+	if i < 0 {
+		return nil, fmt.Errorf("no saved conversation %q", strings.TrimSpace(name))
+	}
+	c := *l.Conversations[i]
+	c.Messages = append([]Message(nil), c.Messages...)
+	return &c, nil
+}
+
+// List 返回用户已保存的对话，最近保存的在前
+func (s *SavedStore) List(owner string) ([]SavedInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, err := s.load(owner)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]SavedInfo, 0, len(l.Conversations))
+	for _, c := range l.Conversations {
+		result = append(result, SavedInfo{Name: c.Name, AgentID: c.AgentID, Saved: c.Saved, Messages: len(c.Messages)})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Saved.After(result[j].Saved) })
+	return result, nil
+}
+
+// Delete 删除一个已保存的对话
+func (s *SavedStore) Delete(owner, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, err := s.load(owner)
+	if err != nil {
+		return err
+	}
+	i := l.find(name)
+	if i < 0 {
+		return fmt.Errorf("no saved conversation %q", strings.TrimSpace(name))
+	}
+	l.Conversations = append(l.Conversations[:i:i], l.Conversations[i+1:]...)
+	return s.save(l)
+}
+
+// DeleteOwner 删除用户的全部已保存对话，返回删除的对话数。文件损坏无法读取时同样删除
+func (s *SavedStore) DeleteOwner(owner string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	if l, err := s.load(owner); err == nil {
+		n = len(l.Conversations)
+	}
+	delete(s.lists, owner)
+	if err := os.Remove(s.path(owner)); err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to remove saved conversations: %w", err)
+	}
+	return n, nil
+}
+
+// find 按名称查找对话（不区分大小写），返回下标，不存在时返回 -1
+func (l *savedList) find(name string) int {
+	name = strings.Join(strings.Fields(name), " ")
+	for i, c := range l.Conversations {
+		if strings.EqualFold(c.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// load 读取用户的文件，调用方需持有锁
+func (s *SavedStore) load(owner string) (*savedList, error) {
+	if l, ok := s.lists[owner]; ok {
+		return l, nil
+	}
+
+	l := &savedList{Owner: owner}
+	data, err := s.cipher.ReadFile(s.path(owner))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read saved conversations: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, l); err != nil {
+			return nil, fmt.Errorf("failed to parse saved conversations: %w", err)
+		}
+	}
+	s.lists[owner] = l
+	return l, nil
+}
+
+// save 写入用户的文件，没有对话时删除文件，调用方需持有锁
+func (s *SavedStore) save(l *savedList) error {
+	path := s.path(l.Owner)
+	if len(l.Conversations) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove saved conversations: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := s.cipher.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write saved conversations: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *SavedStore) path(owner string) string {
+	return filepath.Join(s.dir, unsafeFileChars.ReplaceAllString(owner, "_")+".json")
+}
+
+// Restore 用已保存的消息替换会话内容，原有消息按 CloseReplaced 结束，超过 maxMessages 时只保留最后部分
+func (m *Manager) Restore(session *Session, messages []Message) {
+	m.mu.RLock()
+	closed := m.snapshot(session, CloseReplaced)
+	m.mu.RUnlock()
+	defer m.notifyClosed([]*Closed{closed})
+	defer m.save(session)
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if len(messages) > m.maxMessages {
+		messages = messages[len(messages)-m.maxMessages:]
+	}
+	session.Messages = append(session.Messages[:0:0], messages...)
+	session.LastActivity = time.Now()
+}
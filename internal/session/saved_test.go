@@ -0,0 +1,91 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestSavedStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewSavedStore(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	owner := "telegram:1"
+	msgs := []Message{{Role: "user", Content: "check the NAS"}, {Role: "assistant", Content: "disk 2 is degraded"}}
+	if replaced, err := store.Save(owner, "  Homelab   maintenance ", "default", msgs); err != nil || replaced {
+		t.Fatalf("Save = %v, %v", replaced, err)
+	}
+	if _, err := store.Save(owner, "empty", "default", nil); err == nil {
+		t.Error("saving an empty conversation should fail")
+	}
+	if replaced, err := store.Save(owner, "homelab MAINTENANCE", "default", msgs[:1]); err != nil || !replaced {
+		t.Fatalf("Save same name = %v, %v", replaced, err)
+	}
+
+	// 重新打开后仍然存在
+	store, err = NewSavedStore(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := store.List(owner)
+	if err != nil || len(list) != 1 || list[0].Name != "homelab MAINTENANCE" || list[0].Messages != 1 {
+		t.Fatalf("List = %+v, %v", list, err)
+	}
+	c, err := store.Load(owner, "Homelab Maintenance")
+	if err != nil || len(c.Messages) != 1 || c.Messages[0].Content != "check the NAS" {
+		t.Fatalf("Load = %+v, %v", c, err)
+	}
+	if _, err := store.Load("telegram:2", "homelab maintenance"); err == nil {
+		t.Error("other users should not see the conversation")
+	}
+
+	store.Save(owner, "trip planning", "default", msgs)
+	if err := store.Delete(owner, "trip planning"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := store.DeleteOwner(owner); err != nil || n != 1 {
+		t.Errorf("DeleteOwner = %d, %v", n, err)
+	}
+	if list, _ := store.List(owner); len(list) != 0 {
+		t.Errorf("List after DeleteOwner = %+v", list)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	mgr := NewManager(3, 3600, 100, log)
+	defer mgr.Close()
+
+	var closed []Closed
+	mgr.SetOnClose(func(c Closed) { closed = append(closed, c) })
+
+	sess := mgr.GetOrCreate("user1", "telegram", "default")
+	mgr.AddMessage(sess, "user", "current topic")
+
+	saved := []Message{
+		{Role: "user", Content: "1"},
+		{Role: "assistant", Content: "2"},
+		{Role: "user", Content: "3"},
+		{Role: "assistant", Content: "4"},
+	}
+	mgr.Restore(sess, saved)
+
+	got := mgr.GetMessages(sess)
+	if len(got) != 3 || got[0].Content != "2" {
+		t.Errorf("messages after restore = %+v", got)
+	}
+	if len(closed) != 1 || closed[0].Reason != CloseReplaced || closed[0].Messages[0].Content != "current topic" {
+		t.Errorf("closed = %+v", closed)
+	}
+
+	// 会话与传入的快照互不影响
+	saved[3].Content = "changed"
+	if got := mgr.GetMessages(sess); got[2].Content != "4" {
+		t.Error("restore should copy messages")
+	}
+}
@@ -2,9 +2,12 @@ package session
 
 import (
 	"container/list"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/HaohanHe/mujibot/internal/cluster"
+	"github.com/HaohanHe/mujibot/internal/encryption"
 	"github.com/HaohanHe/mujibot/internal/logger"
 )
 
@@ -33,8 +36,10 @@ type Session struct {
 	Channel      string
 	AgentID      string
 	Messages     []Message
+	Created      time.Time
 	LastActivity time.Time
 	mu           sync.RWMutex
+	version      int64 // 共享存储中的版本号
 }
 
 // Manager 会话管理器
@@ -48,6 +53,13 @@ type Manager struct {
 	log          *logger.Logger
 	cleanupTimer *time.Timer
 	stopCh       chan struct{}
+	store        cluster.Store
+	cipher       *encryption.Cipher
+	onClose      func(c Closed)
+	stats        *usageStats
+
+	// now 返回当前时间，测试时可用 SetClock 替换
+	now func() time.Time
 }
 
 // sessionEntry LRU列表中的条目
@@ -66,6 +78,8 @@ func NewManager(maxMessages, idleTimeoutSec, maxSessions int, log *logger.Logger
 		maxSessions: maxSessions,
 		log:         log,
 		stopCh:      make(chan struct{}),
+		stats:       newUsageStats(),
+		now:         time.Now,
 	}
 
 	go m.cleanupLoop()
@@ -75,8 +89,17 @@ func NewManager(maxMessages, idleTimeoutSec, maxSessions int, log *logger.Logger
 
 // GetOrCreate 获取或创建会话
 func (m *Manager) GetOrCreate(userID, channel, agentID string) *Session {
+	session := m.getOrCreate(userID, channel, agentID)
+	m.refresh(session)
+	return session
+}
+
+func (m *Manager) getOrCreate(userID, channel, agentID string) *Session {
 	key := m.makeKey(userID, channel, agentID)
 
+	var evicted *Closed
+	defer func() { m.notifyClosed([]*Closed{evicted}) }()
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -84,24 +107,27 @@ func (m *Manager) GetOrCreate(userID, channel, agentID string) *Session {
 		// 移动到队首（最近使用）
 		m.lruList.MoveToFront(elem)
 		session := elem.Value.(*sessionEntry).session
-		session.LastActivity = time.Now()
+		session.LastActivity = m.now()
 		return session
 	}
 
 	// 检查是否超过最大会话数
 	if len(m.sessions) >= m.maxSessions {
-		m.evictLRU()
+		evicted = m.evictLRU()
 	}
 
 	// 创建新会话
+	now := m.now()
 	session := &Session{
 		ID:           key,
 		UserID:       userID,
 		Channel:      channel,
 		AgentID:      agentID,
 		Messages:     make([]Message, 0, m.maxMessages),
-		LastActivity: time.Now(),
+		Created:      now,
+		LastActivity: now,
 	}
+	m.stats.created(session)
 
 	entry := &sessionEntry{key: key, session: session}
 	elem := m.lruList.PushFront(entry)
@@ -113,6 +139,20 @@ func (m *Manager) GetOrCreate(userID, channel, agentID string) *Session {
 
 // Get 获取会话（不更新LRU）
 func (m *Manager) Get(userID, channel, agentID string) *Session {
+	session := m.get(userID, channel, agentID)
+	if session == nil && m.store != nil {
+		// 其他实例创建的会话
+		if _, err := m.store.Get(sharedKey(m.makeKey(userID, channel, agentID))); err == nil {
+			session = m.getOrCreate(userID, channel, agentID)
+		}
+	}
+	if session != nil {
+		m.refresh(session)
+	}
+	return session
+}
+
+func (m *Manager) get(userID, channel, agentID string) *Session {
 	key := m.makeKey(userID, channel, agentID)
 
 	m.mu.Lock()
@@ -127,17 +167,21 @@ func (m *Manager) Get(userID, channel, agentID string) *Session {
 
 // AddMessage 添加消息到会话
 func (m *Manager) AddMessage(session *Session, role, content string) {
+	defer m.save(session)
+	if role == "user" {
+		m.stats.message(session)
+	}
 	session.mu.Lock()
 	defer session.mu.Unlock()
 
 	msg := Message{
 		Role:      role,
 		Content:   content,
-		Timestamp: time.Now(),
+		Timestamp: m.now(),
 	}
 
 	session.Messages = append(session.Messages, msg)
-	session.LastActivity = time.Now()
+	session.LastActivity = m.now()
 
 	// 限制消息数量
 	if len(session.Messages) > m.maxMessages {
@@ -147,18 +191,19 @@ func (m *Manager) AddMessage(session *Session, role, content string) {
 
 // AddToolCallMessage 添加带工具调用的消息
 func (m *Manager) AddToolCallMessage(session *Session, role, content string, toolCalls []ToolCall) {
+	defer m.save(session)
 	session.mu.Lock()
 	defer session.mu.Unlock()
 
 	msg := Message{
 		Role:      role,
 		Content:   content,
-		Timestamp: time.Now(),
+		Timestamp: m.now(),
 		ToolCalls: toolCalls,
 	}
 
 	session.Messages = append(session.Messages, msg)
-	session.LastActivity = time.Now()
+	session.LastActivity = m.now()
 
 	// 限制消息数量
 	if len(session.Messages) > m.maxMessages {
@@ -179,38 +224,131 @@ func (m *Manager) GetMessages(session *Session) []Message {
 
 // Clear 清空会话消息
 func (m *Manager) Clear(session *Session) {
+	m.mu.RLock()
+	closed := m.snapshot(session, CloseCleared)
+	m.mu.RUnlock()
+	defer m.notifyClosed([]*Closed{closed})
+	defer m.save(session)
 	session.mu.Lock()
 	defer session.mu.Unlock()
 
 	session.Messages = session.Messages[:0]
-	session.LastActivity = time.Now()
+	session.LastActivity = m.now()
 }
 
 // Delete 删除会话
 func (m *Manager) Delete(userID, channel, agentID string) {
 	key := m.makeKey(userID, channel, agentID)
+	if m.store != nil {
+		if err := m.store.Delete(sharedKey(key)); err != nil {
+			m.log.Warn("failed to delete shared session", "key", key, "error", err)
+		}
+	}
+
+	var closed *Closed
+	defer func() { m.notifyClosed([]*Closed{closed}) }()
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if elem, ok := m.sessions[key]; ok {
+		closed = m.snapshot(elem.Value.(*sessionEntry).session, CloseDeleted)
+		m.stats.endedSession(elem.Value.(*sessionEntry).session)
 		m.lruList.Remove(elem)
 		delete(m.sessions, key)
 		m.log.Debug("session deleted", "key", key)
 	}
 }
 
-// GetStats 获取会话统计
+// DeleteUser 删除用户在所有智能体下的会话（包括共享存储中的），返回删除的会话数。
+// 用于清除用户数据，不触发会话结束回调，避免对话摘要被写入每日笔记
+func (m *Manager) DeleteUser(userID, channel string) int {
+	prefix := channel + ":" + userID + ":"
+	deleted := make(map[string]bool)
+
+	if m.store != nil {
+		keys, err := m.store.Keys(sharedKey(prefix))
+		if err != nil {
+			m.log.Warn("failed to list shared sessions", "prefix", prefix, "error", err)
+		}
+		for _, key := range keys {
+			if err := m.store.Delete(key); err != nil {
+				m.log.Warn("failed to delete shared session", "key", key, "error", err)
+				continue
+			}
+			deleted[strings.TrimPrefix(key, sharedKey(""))] = true
+		}
+	}
+
+	m.stats.forget(channel, userID)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, elem := range m.sessions {
+		session := elem.Value.(*sessionEntry).session
+		if session.UserID != userID || session.Channel != channel {
+			continue
+		}
+		m.lruList.Remove(elem)
+		delete(m.sessions, key)
+		deleted[key] = true
+	}
+	if len(deleted) > 0 {
+		m.log.Debug("user sessions deleted", "prefix", prefix, "count", len(deleted))
+	}
+	return len(deleted)
+}
+
+// GetStats 获取会话统计，包括各渠道的使用量和最活跃的用户
 func (m *Manager) GetStats() map[string]interface{} {
+	st := m.Stats(5)
+
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	return map[string]interface{}{
-		"total_sessions": len(m.sessions),
-		"max_sessions":   m.maxSessions,
-		"max_messages":   m.maxMessages,
-		"idle_timeout":   m.idleTimeout.Seconds(),
+		"total_sessions":           len(m.sessions),
+		"max_sessions":             m.maxSessions,
+		"max_messages":             m.maxMessages,
+		"idle_timeout":             m.idleTimeout.Seconds(),
+		"channels":                 st.Channels,
+		"avg_messages_per_session": st.AvgMessagesPerSession,
+		"avg_session_seconds":      st.AvgSessionSeconds,
+		"top_users":                st.TopUsers,
+	}
+}
+
+// Summary 会话概要
+type Summary struct {
+	ID           string
+	UserID       string
+	Channel      string
+	AgentID      string
+	Messages     int
+	LastActivity time.Time
+}
+
+// List 列出所有会话概要，最近使用的在前（不更新LRU）
+func (m *Manager) List() []Summary {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]Summary, 0, len(m.sessions))
+	for elem := m.lruList.Front(); elem != nil; elem = elem.Next() {
+		session := elem.Value.(*sessionEntry).session
+		session.mu.RLock()
+		result = append(result, Summary{
+			ID:           session.ID,
+			UserID:       session.UserID,
+			Channel:      session.Channel,
+			AgentID:      session.AgentID,
+			Messages:     len(session.Messages),
+			LastActivity: session.LastActivity,
+		})
+		session.mu.RUnlock()
 	}
+	return result
 }
 
 // makeKey 生成会话键
@@ -218,18 +356,20 @@ func (m *Manager) makeKey(userID, channel, agentID string) string {
 	return channel + ":" + userID + ":" + agentID
 }
 
-// evictLRU 淘汰最久未使用的会话
-func (m *Manager) evictLRU() {
+// evictLRU 淘汰最久未使用的会话，返回其快照
+func (m *Manager) evictLRU() *Closed {
 	elem := m.lruList.Back()
 	if elem == nil {
-		return
+		return nil
 	}
 
 	entry := elem.Value.(*sessionEntry)
 	m.lruList.Remove(elem)
 	delete(m.sessions, entry.key)
 
+	m.stats.endedSession(entry.session)
 	m.log.Debug("session evicted", "key", entry.key, "reason", "lru")
+	return m.snapshot(entry.session, CloseEvicted)
 }
 
 // cleanupLoop 定期清理空闲会话
@@ -247,12 +387,25 @@ func (m *Manager) cleanupLoop() {
 	}
 }
 
+// SetClock 替换获取当前时间的函数，须在处理消息前调用，测试中配合 CleanupIdle 模拟会话空闲超时
+func (m *Manager) SetClock(now func() time.Time) {
+	m.now = now
+}
+
+// CleanupIdle 立即清理空闲超时的会话，不等待定期清理
+func (m *Manager) CleanupIdle() {
+	m.cleanup()
+}
+
 // cleanup 清理空闲会话
 func (m *Manager) cleanup() {
+	var closed []*Closed
+	defer func() { m.notifyClosed(closed) }()
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	now := time.Now()
+	now := m.now()
 	toDelete := make([]string, 0)
 
 	for elem := m.lruList.Back(); elem != nil; {
@@ -261,6 +414,8 @@ func (m *Manager) cleanup() {
 
 		if now.Sub(entry.session.LastActivity) > m.idleTimeout {
 			toDelete = append(toDelete, entry.key)
+			closed = append(closed, m.snapshot(entry.session, CloseIdle))
+			m.stats.endedSession(entry.session)
 			m.lruList.Remove(elem)
 			delete(m.sessions, entry.key)
 		}
@@ -277,9 +432,15 @@ func (m *Manager) cleanup() {
 func (m *Manager) Close() {
 	close(m.stopCh)
 
+	var closed []*Closed
+	defer func() { m.notifyClosed(closed) }()
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	for elem := m.lruList.Front(); elem != nil; elem = elem.Next() {
+		closed = append(closed, m.snapshot(elem.Value.(*sessionEntry).session, CloseShutdown))
+	}
 	m.sessions = make(map[string]*list.Element)
 	m.lruList.Init()
 }
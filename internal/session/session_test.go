@@ -1,9 +1,12 @@
 package session
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/HaohanHe/mujibot/internal/cluster"
 	"github.com/HaohanHe/mujibot/internal/logger"
 )
 
@@ -186,6 +189,33 @@ func TestLRU(t *testing.T) {
 	_ = sess4
 }
 
+func TestList(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	mgr := NewManager(20, 3600, 10, log)
+	defer mgr.Close()
+
+	sess1 := mgr.GetOrCreate("user1", "telegram", "default")
+	mgr.GetOrCreate("user2", "discord", "coder")
+	mgr.AddMessage(sess1, "user", "hello")
+	mgr.AddMessage(sess1, "assistant", "hi")
+
+	// 访问user1使其变为最近使用
+	_ = mgr.Get("user1", "telegram", "default")
+
+	list := mgr.List()
+	if len(list) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(list))
+	}
+	if list[0].UserID != "user1" || list[0].Messages != 2 {
+		t.Errorf("first session = %+v, want user1 with 2 messages", list[0])
+	}
+	if list[1].Channel != "discord" || list[1].AgentID != "coder" || list[1].Messages != 0 {
+		t.Errorf("second session = %+v", list[1])
+	}
+}
+
 func TestConcurrentAccess(t *testing.T) {
 	log, _ := logger.New(logger.Config{Level: "error"})
 	defer log.Close()
@@ -218,3 +248,257 @@ func TestConcurrentAccess(t *testing.T) {
 		t.Errorf("should have 10 sessions, got: %v", stats["total_sessions"])
 	}
 }
+
+func TestExport(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	mgr := NewManager(20, 3600, 100, log)
+	defer mgr.Close()
+
+	sess := mgr.GetOrCreate("user1", "telegram", "default")
+	mgr.AddMessage(sess, "user", "list files")
+	call := ToolCall{ID: "call_1", Type: "function"}
+	call.Function.Name = "list_directory"
+	call.Function.Arguments = `{"path":"."}`
+	mgr.AddToolCallMessage(sess, "assistant", "", []ToolCall{call})
+	mgr.AddMessage(sess, "tool", "a.txt")
+
+	if mgr.GetByID(sess.ID) != sess {
+		t.Fatal("GetByID should return the session")
+	}
+
+	md := mgr.ExportMarkdown(sess)
+	for _, want := range []string{"Session: " + sess.ID, "## User", "list files", "Tool call: `list_directory`", "a.txt"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown export missing %q", want)
+		}
+	}
+
+	data, err := mgr.ExportJSON(sess)
+	if err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+	var tr Transcript
+	if err := json.Unmarshal(data, &tr); err != nil {
+		t.Fatalf("invalid JSON export: %v", err)
+	}
+	if len(tr.Messages) != 3 || len(tr.Messages[1].ToolCalls) != 1 {
+		t.Errorf("unexpected transcript: %+v", tr)
+	}
+}
+
+func TestSharedStore(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	store := cluster.NewMemory()
+	a := NewManager(20, 3600, 10, log)
+	defer a.Close()
+	a.SetStore(store)
+	b := NewManager(20, 3600, 10, log)
+	defer b.Close()
+	b.SetStore(store)
+
+	sessA := a.GetOrCreate("user1", "telegram", "default")
+	a.AddMessage(sessA, "user", "hello")
+	a.AddMessage(sessA, "assistant", "hi")
+
+	// 另一个实例读取到同一会话
+	sessB := b.Get("user1", "telegram", "default")
+	if sessB == nil {
+		t.Fatal("session created on a should be visible on b")
+	}
+	if msgs := b.GetMessages(sessB); len(msgs) != 2 || msgs[1].Content != "hi" {
+		t.Fatalf("b messages = %+v", msgs)
+	}
+
+	// 故障切换后在b上继续对话，a再次处理时看到完整历史
+	b.AddMessage(sessB, "user", "how are you")
+	sessA = a.GetOrCreate("user1", "telegram", "default")
+	if msgs := a.GetMessages(sessA); len(msgs) != 3 || msgs[2].Content != "how are you" {
+		t.Errorf("a messages after b update = %+v", msgs)
+	}
+
+	b.Delete("user1", "telegram", "default")
+	if _, err := store.Get(sharedKey(sessA.ID)); err != cluster.ErrNotFound {
+		t.Errorf("shared session not deleted: %v", err)
+	}
+	if b.Get("user2", "telegram", "default") != nil {
+		t.Error("unknown session should be nil")
+	}
+}
+
+func TestOnClose(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	mgr := NewManager(20, 3600, 2, log)
+
+	var closed []Closed
+	mgr.SetOnClose(func(c Closed) {
+		closed = append(closed, c)
+	})
+
+	a := mgr.GetOrCreate("a", "telegram", "default")
+	mgr.AddMessage(a, "user", "hello")
+	mgr.Clear(a)
+	mgr.Clear(a) // 没有消息，不触发
+
+	mgr.AddMessage(a, "user", "again")
+	mgr.GetOrCreate("b", "telegram", "default")
+	mgr.GetOrCreate("c", "telegram", "default") // 淘汰 a
+
+	d := mgr.GetOrCreate("d", "telegram", "default") // 淘汰 b（空会话）
+	mgr.AddMessage(d, "user", "bye")
+	mgr.Delete("d", "telegram", "default")
+
+	e := mgr.GetOrCreate("e", "telegram", "default")
+	mgr.AddMessage(e, "user", "later")
+	mgr.Close()
+
+	want := []struct {
+		user, reason, content string
+	}{
+		{"a", CloseCleared, "hello"},
+		{"a", CloseEvicted, "again"},
+		{"d", CloseDeleted, "bye"},
+		{"e", CloseShutdown, "later"},
+	}
+	if len(closed) != len(want) {
+		t.Fatalf("closed = %+v, want %d entries", closed, len(want))
+	}
+	for i, w := range want {
+		c := closed[i]
+		if c.UserID != w.user || c.Reason != w.reason || len(c.Messages) != 1 || c.Messages[0].Content != w.content {
+			t.Errorf("closed[%d] = %+v, want %s/%s/%s", i, c, w.user, w.reason, w.content)
+		}
+	}
+}
+
+func TestOnCloseIdle(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	mgr := NewManager(20, 0, 10, log)
+	defer mgr.Close()
+
+	var reasons []string
+	mgr.SetOnClose(func(c Closed) {
+		reasons = append(reasons, c.Reason)
+	})
+
+	sess := mgr.GetOrCreate("user1", "telegram", "default")
+	mgr.AddMessage(sess, "user", "hello")
+	time.Sleep(time.Millisecond)
+	mgr.cleanup()
+
+	if len(reasons) != 1 || reasons[0] != CloseIdle {
+		t.Errorf("reasons = %v, want [%s]", reasons, CloseIdle)
+	}
+}
+
+func TestSetClock(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	mgr := NewManager(20, 60, 10, log)
+	defer mgr.Close()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mgr.SetClock(func() time.Time { return now })
+
+	sess := mgr.GetOrCreate("user1", "telegram", "default")
+	mgr.AddMessage(sess, "user", "hello")
+	if msgs := mgr.GetMessages(sess); !msgs[0].Timestamp.Equal(now) {
+		t.Errorf("timestamp = %v, want %v", msgs[0].Timestamp, now)
+	}
+
+	now = now.Add(59 * time.Second)
+	mgr.CleanupIdle()
+	if mgr.Get("user1", "telegram", "default") == nil {
+		t.Fatal("session expired before idle timeout")
+	}
+
+	now = now.Add(61 * time.Second)
+	mgr.CleanupIdle()
+	if mgr.Get("user1", "telegram", "default") != nil {
+		t.Error("session should expire after idle timeout")
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	store := cluster.NewMemory()
+	mgr := NewManager(20, 3600, 10, log)
+	defer mgr.Close()
+	mgr.SetStore(store)
+
+	var closed int
+	mgr.SetOnClose(func(c Closed) { closed++ })
+
+	for _, agentID := range []string{"default", "coder"} {
+		sess := mgr.GetOrCreate("user1", "telegram", agentID)
+		mgr.AddMessage(sess, "user", "hello")
+	}
+	other := mgr.GetOrCreate("user2", "telegram", "default")
+	mgr.AddMessage(other, "user", "hello")
+	// 只存在于共享存储中的会话
+	store.Set(sharedKey("telegram:user1:writer"), []byte(`{}`), 0)
+
+	if n := mgr.DeleteUser("user1", "telegram"); n != 3 {
+		t.Errorf("deleted = %d, want 3", n)
+	}
+	if mgr.Get("user1", "telegram", "default") != nil {
+		t.Error("session should be deleted")
+	}
+	if keys, _ := store.Keys(sharedKey("telegram:user1:")); len(keys) != 0 {
+		t.Errorf("shared sessions left: %v", keys)
+	}
+	if mgr.Get("user2", "telegram", "default") == nil {
+		t.Error("other user's session should be kept")
+	}
+	if closed != 0 {
+		t.Errorf("purge should not trigger close callback, got %d", closed)
+	}
+}
+
+func TestStats(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	mgr := NewManager(20, 3600, 2, log)
+	defer mgr.Close()
+
+	a := mgr.GetOrCreate("alice", "telegram", "default")
+	mgr.AddMessage(a, "user", "one")
+	mgr.AddMessage(a, "assistant", "reply")
+	mgr.AddMessage(a, "user", "two")
+	mgr.AddMessage(a, "user", "three")
+	b := mgr.GetOrCreate("bob", "discord", "default")
+	mgr.AddMessage(b, "user", "hi")
+	// 淘汰 alice 的会话，统计仍保留
+	mgr.GetOrCreate("carol", "discord", "default")
+
+	st := mgr.Stats(2)
+	if st.Sessions != 3 || st.Messages != 4 || st.Users != 3 || st.ActiveSessions != 2 {
+		t.Errorf("stats = %+v", st)
+	}
+	if st.AvgMessagesPerSession < 1.33 || st.AvgMessagesPerSession > 1.34 {
+		t.Errorf("avg messages per session = %v", st.AvgMessagesPerSession)
+	}
+	if len(st.TopUsers) != 2 || st.TopUsers[0].UserID != "alice" || st.TopUsers[0].Messages != 3 || st.TopUsers[1].UserID != "bob" {
+		t.Errorf("top users = %+v", st.TopUsers)
+	}
+	if len(st.Channels) != 2 || st.Channels[0].Channel != "telegram" || st.Channels[1].Users != 2 || st.Channels[1].Active != 2 {
+		t.Errorf("channels = %+v", st.Channels)
+	}
+
+	// 清除用户数据时同时清除统计
+	mgr.DeleteUser("alice", "telegram")
+	if st := mgr.Stats(0); st.Users != 2 || st.Messages != 1 {
+		t.Errorf("stats after delete = %+v", st)
+	}
+}
@@ -0,0 +1,95 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/cluster"
+	"github.com/HaohanHe/mujibot/internal/encryption"
+)
+
+// sharedSession 共享存储中的会话快照
+type sharedSession struct {
+	Version      int64     `json:"version"`
+	Messages     []Message `json:"messages"`
+	LastActivity time.Time `json:"last_activity"`
+}
+
+// SetStore 设置共享存储，须在处理消息前调用。多实例部署时每次修改都写入共享存储，读取会话时同步其他实例的修改
+func (m *Manager) SetStore(store cluster.Store) {
+	m.store = store
+}
+
+// SetCipher 设置共享存储中会话数据的加密器，须在 SetStore 之后、处理消息前调用
+func (m *Manager) SetCipher(c *encryption.Cipher) {
+	m.cipher = c
+}
+
+func sharedKey(key string) string {
+	return "session:" + key
+}
+
+// save 把会话写入共享存储，空闲超时后自动过期
+func (m *Manager) save(session *Session) {
+	if m.store == nil {
+		return
+	}
+
+	session.mu.Lock()
+	session.version++
+	data, err := json.Marshal(sharedSession{
+		Version:      session.version,
+		Messages:     session.Messages,
+		LastActivity: session.LastActivity,
+	})
+	session.mu.Unlock()
+	if err == nil {
+		data, err = m.cipher.Seal(data)
+	}
+	if err != nil {
+		m.log.Error("failed to encode shared session", "key", session.ID, "error", err)
+		return
+	}
+
+	if err := m.store.Set(sharedKey(session.ID), data, m.idleTimeout); err != nil {
+		m.log.Warn("failed to save shared session", "key", session.ID, "error", err)
+	}
+}
+
+// refresh 共享存储中的版本更新时，用它替换本地消息
+func (m *Manager) refresh(session *Session) {
+	if m.store == nil {
+		return
+	}
+
+	data, err := m.store.Get(sharedKey(session.ID))
+	if errors.Is(err, cluster.ErrNotFound) {
+		return
+	}
+	if err != nil {
+		m.log.Warn("failed to load shared session", "key", session.ID, "error", err)
+		return
+	}
+	if data, err = m.cipher.Open(data); err != nil {
+		m.log.Warn("failed to decrypt shared session", "key", session.ID, "error", err)
+		return
+	}
+	var shared sharedSession
+	if err := json.Unmarshal(data, &shared); err != nil {
+		m.log.Warn("invalid shared session", "key", session.ID, "error", err)
+		return
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if shared.Version <= session.version {
+		return
+	}
+	session.Messages = shared.Messages
+	if len(session.Messages) > m.maxMessages {
+		session.Messages = session.Messages[len(session.Messages)-m.maxMessages:]
+	}
+	session.LastActivity = shared.LastActivity
+	session.version = shared.Version
+}
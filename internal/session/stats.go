@@ -0,0 +1,164 @@
+package session
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ChannelStats 单个渠道的使用统计
+type ChannelStats struct {
+	Channel  string `json:"channel"`
+	Active   int    `json:"active_sessions"`
+	Sessions int    `json:"sessions"`
+	Messages int    `json:"messages"`
+	Users    int    `json:"users"`
+}
+
+// UserStats 单个用户的使用统计
+type UserStats struct {
+	Channel  string    `json:"channel"`
+	UserID   string    `json:"user_id"`
+	Sessions int       `json:"sessions"`
+	Messages int       `json:"messages"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Stats 自启动以来的会话统计，消息数只计用户发送的消息（约等于LLM请求数）
+type Stats struct {
+	Since                 time.Time      `json:"since"`
+	ActiveSessions        int            `json:"active_sessions"`
+	Sessions              int            `json:"sessions"`
+	Messages              int            `json:"messages"`
+	Users                 int            `json:"users"`
+	AvgMessagesPerSession float64        `json:"avg_messages_per_session"`
+	AvgSessionSeconds     float64        `json:"avg_session_seconds"`
+	Channels              []ChannelStats `json:"channels"`
+	TopUsers              []UserStats    `json:"top_users"`
+}
+
+// usageStats 累计的使用统计
+type usageStats struct {
+	mu       sync.Mutex
+	since    time.Time
+	users    map[string]*UserStats // 键为 channel:userID
+	ended    int                   // 已结束的会话数
+	duration time.Duration         // 已结束会话的总时长
+}
+
+func newUsageStats() *usageStats {
+	return &usageStats{since: time.Now(), users: make(map[string]*UserStats)}
+}
+
+func (u *usageStats) user(channel, userID string) *UserStats {
+	key := channel + ":" + userID
+	s := u.users[key]
+	if s == nil {
+		s = &UserStats{Channel: channel, UserID: userID}
+		u.users[key] = s
+	}
+	return s
+}
+
+// created 记录新会话
+func (u *usageStats) created(s *Session) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	us := u.user(s.Channel, s.UserID)
+	us.Sessions++
+	us.LastSeen = time.Now()
+}
+
+// message 记录用户消息
+func (u *usageStats) message(s *Session) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	us := u.user(s.Channel, s.UserID)
+	us.Messages++
+	us.LastSeen = time.Now()
+}
+
+// endedSession 记录会话结束（空闲清理、淘汰、删除）时的时长
+func (u *usageStats) endedSession(s *Session) {
+	s.mu.RLock()
+	d := s.LastActivity.Sub(s.Created)
+	s.mu.RUnlock()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.ended++
+	u.duration += d
+}
+
+// forget 清除用户的统计，用于清除用户数据
+func (u *usageStats) forget(channel, userID string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.users, channel+":"+userID)
+}
+
+// Stats 返回会话统计，top 为返回的最活跃用户数
+func (m *Manager) Stats(top int) Stats {
+	m.mu.RLock()
+	active := make(map[string]int)
+	var activeDuration time.Duration
+	for elem := m.lruList.Front(); elem != nil; elem = elem.Next() {
+		session := elem.Value.(*sessionEntry).session
+		active[session.Channel]++
+		session.mu.RLock()
+		activeDuration += session.LastActivity.Sub(session.Created)
+		session.mu.RUnlock()
+	}
+	activeCount := len(m.sessions)
+	m.mu.RUnlock()
+
+	u := m.stats
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	st := Stats{Since: u.since, ActiveSessions: activeCount, Users: len(u.users)}
+	channels := make(map[string]*ChannelStats)
+	users := make([]UserStats, 0, len(u.users))
+	for _, us := range u.users {
+		cs := channels[us.Channel]
+		if cs == nil {
+			cs = &ChannelStats{Channel: us.Channel, Active: active[us.Channel]}
+			channels[us.Channel] = cs
+		}
+		cs.Sessions += us.Sessions
+		cs.Messages += us.Messages
+		cs.Users++
+		st.Sessions += us.Sessions
+		st.Messages += us.Messages
+		users = append(users, *us)
+	}
+
+	if st.Sessions > 0 {
+		st.AvgMessagesPerSession = float64(st.Messages) / float64(st.Sessions)
+	}
+	if n := u.ended + activeCount; n > 0 {
+		st.AvgSessionSeconds = (u.duration + activeDuration).Seconds() / float64(n)
+	}
+
+	st.Channels = make([]ChannelStats, 0, len(channels))
+	for _, cs := range channels {
+		st.Channels = append(st.Channels, *cs)
+	}
+	sort.Slice(st.Channels, func(i, j int) bool {
+		if st.Channels[i].Messages != st.Channels[j].Messages {
+			return st.Channels[i].Messages > st.Channels[j].Messages
+		}
+		return st.Channels[i].Channel < st.Channels[j].Channel
+	})
+
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Messages != users[j].Messages {
+			return users[i].Messages > users[j].Messages
+		}
+		return users[i].LastSeen.After(users[j].LastSeen)
+	})
+	if top > 0 && len(users) > top {
+		users = users[:top]
+	}
+	st.TopUsers = users
+	return st
+}
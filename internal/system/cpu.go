@@ -0,0 +1,53 @@
+package system
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CPUTimes 系统CPU累计时间，单位随平台不同（Linux 为 tick，Windows 为 100ns），只用于计算使用率
+type CPUTimes struct {
+	Idle  uint64
+	Total uint64
+}
+
+// UsageSince 计算与上一次采样之间的CPU使用率（百分比）
+func (t CPUTimes) UsageSince(prev CPUTimes) float64 {
+	total := t.Total - prev.Total
+	if t.Total <= prev.Total || total == 0 {
+		return 0
+	}
+	idle := t.Idle - prev.Idle
+	if t.Idle < prev.Idle || idle > total {
+		idle = total
+	}
+	return float64(total-idle) / float64(total) * 100
+}
+
+// parseCPUTimes 解析 /proc/stat 的第一行
+func parseCPUTimes(data string) (CPUTimes, error) {
+	line := data
+	if i := strings.IndexByte(data, '\n'); i >= 0 {
+		line = data[:i]
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return CPUTimes{}, fmt.Errorf("unexpected /proc/stat format")
+	}
+
+	var t CPUTimes
+	for i, f := range fields[1:] {
+		v, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return CPUTimes{}, err
+		}
+		t.Total += v
+		// idle 与 iowait
+		if i == 3 || i == 4 {
+			t.Idle += v
+		}
+	}
+	return t, nil
+}
@@ -0,0 +1,27 @@
+//go:build darwin
+
+package system
+
+import (
+	"syscall"
+	"time"
+)
+
+// ReadCPUTimes macOS 的总CPU时间只能通过 Mach 接口（host_statistics）读取，sysctl 没有提供
+func ReadCPUTimes() (CPUTimes, error) {
+	return CPUTimes{}, ErrHostInfoUnsupported
+}
+
+// ProcessCPUTime 通过 getrusage 读取当前进程累计占用的CPU时间
+func ProcessCPUTime() (time.Duration, error) {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0, err
+	}
+	return time.Duration(ru.Utime.Nano() + ru.Stime.Nano()), nil
+}
+
+// Temperature macOS 的温度传感器需要通过 SMC 读取，不支持
+func Temperature() (float64, bool) {
+	return 0, false
+}
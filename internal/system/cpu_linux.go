@@ -0,0 +1,76 @@
+//go:build linux
+
+package system
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks /proc中CPU时间的单位（USER_HZ），Linux上几乎总是100
+const clockTicks = 100
+
+// ReadCPUTimes 读取 /proc/stat 中的总CPU时间
+func ReadCPUTimes() (CPUTimes, error) {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return CPUTimes{}, err
+	}
+	return parseCPUTimes(string(data))
+}
+
+// ProcessCPUTime 读取当前进程累计占用的CPU时间（用户态+内核态）
+func ProcessCPUTime() (time.Duration, error) {
+	data, err := os.ReadFile("/proc/self/stat")
+	if err != nil {
+		return 0, err
+	}
+
+	// 进程名可能包含空格，从最后一个右括号之后开始解析
+	s := string(data)
+	i := strings.LastIndexByte(s, ')')
+	if i < 0 {
+		return 0, fmt.Errorf("unexpected /proc/self/stat format")
+	}
+	fields := strings.Fields(s[i+1:])
+	// utime、stime 分别是第14、15个字段，此处下标从state（第3个字段）开始
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("unexpected /proc/self/stat format")
+	}
+
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(utime+stime) * time.Second / clockTicks, nil
+}
+
+// Temperature 读取最高的温度传感器读数（摄氏度），ARM开发板常见于 thermal_zone
+func Temperature() (float64, bool) {
+	zones, _ := filepath.Glob("/sys/class/thermal/thermal_zone*/temp")
+
+	max, found := 0.0, false
+	for _, zone := range zones {
+		data, err := os.ReadFile(zone)
+		if err != nil {
+			continue
+		}
+		milli, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+		if err != nil || milli <= 0 {
+			continue
+		}
+		if c := milli / 1000; !found || c > max {
+			max, found = c, true
+		}
+	}
+	return max, found
+}
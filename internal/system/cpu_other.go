@@ -0,0 +1,20 @@
+//go:build !linux && !darwin && !windows
+
+package system
+
+import "time"
+
+// ReadCPUTimes 读取总CPU时间
+func ReadCPUTimes() (CPUTimes, error) {
+	return CPUTimes{}, ErrHostInfoUnsupported
+}
+
+// ProcessCPUTime 读取当前进程累计占用的CPU时间
+func ProcessCPUTime() (time.Duration, error) {
+	return 0, ErrHostInfoUnsupported
+}
+
+// Temperature 读取温度传感器
+func Temperature() (float64, bool) {
+	return 0, false
+}
@@ -0,0 +1,26 @@
+package system
+
+import "testing"
+
+func TestParseCPUTimes(t *testing.T) {
+	prev, err := parseCPUTimes("cpu  100 0 100 700 100 0 0 0 0 0\ncpu0 1 2 3 4\n")
+	if err != nil {
+		t.Fatalf("parseCPUTimes failed: %v", err)
+	}
+	if prev.Total != 1000 || prev.Idle != 800 {
+		t.Errorf("unexpected times: %+v", prev)
+	}
+
+	cur, _ := parseCPUTimes("cpu  200 0 200 800 100 0 0 0 0 0")
+	if got := cur.UsageSince(prev); got < 66.6 || got > 66.7 {
+		t.Errorf("UsageSince() = %f, want ~66.7", got)
+	}
+
+	if got := prev.UsageSince(cur); got != 0 {
+		t.Errorf("UsageSince() with older sample = %f, want 0", got)
+	}
+
+	if _, err := parseCPUTimes("intr 1 2 3"); err == nil {
+		t.Error("parseCPUTimes should fail on unexpected input")
+	}
+}
@@ -0,0 +1,49 @@
+//go:build windows
+
+package system
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// filetimeTicks FILETIME 作为时长时的值，单位 100ns
+func filetimeTicks(ft syscall.Filetime) uint64 {
+	return uint64(ft.HighDateTime)<<32 | uint64(ft.LowDateTime)
+}
+
+// ReadCPUTimes 通过 GetSystemTimes 读取总CPU时间，内核态时间已包含空闲时间
+func ReadCPUTimes() (CPUTimes, error) {
+	var idle, kernel, user syscall.Filetime
+	r, _, err := procGetSystemTimes.Call(
+		uintptr(unsafe.Pointer(&idle)),
+		uintptr(unsafe.Pointer(&kernel)),
+		uintptr(unsafe.Pointer(&user)),
+	)
+	if r == 0 {
+		return CPUTimes{}, err
+	}
+	return CPUTimes{
+		Idle:  filetimeTicks(idle),
+		Total: filetimeTicks(kernel) + filetimeTicks(user),
+	}, nil
+}
+
+// ProcessCPUTime 通过 GetProcessTimes 读取当前进程累计占用的CPU时间
+func ProcessCPUTime() (time.Duration, error) {
+	h, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return 0, err
+	}
+	var creation, exit, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(h, &creation, &exit, &kernel, &user); err != nil {
+		return 0, err
+	}
+	return time.Duration(filetimeTicks(kernel)+filetimeTicks(user)) * 100, nil
+}
+
+// Temperature Windows 需要管理员权限的 WMI 才能读取温度，不支持
+func Temperature() (float64, bool) {
+	return 0, false
+}
@@ -0,0 +1,20 @@
+package system
+
+import "errors"
+
+// ErrDiskUsageUnsupported 当前平台不支持磁盘统计
+var ErrDiskUsageUnsupported = errors.New("disk usage not supported on this platform")
+
+// DiskStats 磁盘使用情况
+type DiskStats struct {
+	Name        string  `json:"name"`
+	Path        string  `json:"path"`
+	TotalBytes  uint64  `json:"total_bytes"`
+	FreeBytes   uint64  `json:"free_bytes"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
+// FreeMB 可用空间（MB）
+func (d DiskStats) FreeMB() uint64 {
+	return d.FreeBytes / 1024 / 1024
+}
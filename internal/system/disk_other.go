@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package system
+
+// DiskUsage 获取路径所在文件系统的使用情况
+func DiskUsage(path string) (DiskStats, error) {
+	return DiskStats{Path: path}, ErrDiskUsageUnsupported
+}
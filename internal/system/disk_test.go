@@ -0,0 +1,27 @@
+package system
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestDiskUsage(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" && runtime.GOOS != "windows" {
+		t.Skip("disk usage not supported on " + runtime.GOOS)
+	}
+
+	d, err := DiskUsage(t.TempDir())
+	if err != nil {
+		t.Fatalf("DiskUsage failed: %v", err)
+	}
+	if d.TotalBytes == 0 || d.FreeBytes > d.TotalBytes {
+		t.Errorf("unexpected disk stats: %+v", d)
+	}
+	if d.UsedPercent < 0 || d.UsedPercent > 100 {
+		t.Errorf("used percent out of range: %f", d.UsedPercent)
+	}
+
+	if _, err := DiskUsage("/nonexistent/path/for/test"); err == nil {
+		t.Error("DiskUsage should fail for missing path")
+	}
+}
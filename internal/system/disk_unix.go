@@ -0,0 +1,23 @@
+//go:build linux || darwin
+
+package system
+
+import "syscall"
+
+// DiskUsage 获取路径所在文件系统的使用情况
+func DiskUsage(path string) (DiskStats, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return DiskStats{}, err
+	}
+
+	bsize := uint64(st.Bsize)
+	total := st.Blocks * bsize
+	free := st.Bavail * bsize
+
+	stats := DiskStats{Path: path, TotalBytes: total, FreeBytes: free}
+	if total > 0 {
+		stats.UsedPercent = float64(total-st.Bfree*bsize) / float64(total) * 100
+	}
+	return stats, nil
+}
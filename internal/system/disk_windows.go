@@ -0,0 +1,33 @@
+//go:build windows
+
+package system
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// DiskUsage 获取路径所在卷的使用情况
+func DiskUsage(path string) (DiskStats, error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return DiskStats{}, err
+	}
+
+	var avail, total, free uint64
+	r, _, err := procGetDiskFreeSpaceExW.Call(
+		uintptr(unsafe.Pointer(p)),
+		uintptr(unsafe.Pointer(&avail)),
+		uintptr(unsafe.Pointer(&total)),
+		uintptr(unsafe.Pointer(&free)),
+	)
+	if r == 0 {
+		return DiskStats{}, err
+	}
+
+	stats := DiskStats{Path: path, TotalBytes: total, FreeBytes: avail}
+	if total > 0 {
+		stats.UsedPercent = float64(total-free) / float64(total) * 100
+	}
+	return stats, nil
+}
@@ -0,0 +1,66 @@
+package system
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrHostInfoUnsupported 当前平台不支持该项系统信息
+var ErrHostInfoUnsupported = errors.New("host info not supported on this platform")
+
+// MemInfo 系统内存情况
+type MemInfo struct {
+	TotalBytes     uint64 `json:"total_bytes"`
+	AvailableBytes uint64 `json:"available_bytes"`
+	SwapTotalBytes uint64 `json:"swap_total_bytes"`
+	SwapFreeBytes  uint64 `json:"swap_free_bytes"`
+}
+
+// UsedPercent 已用内存百分比
+func (m MemInfo) UsedPercent() float64 {
+	if m.TotalBytes == 0 || m.AvailableBytes > m.TotalBytes {
+		return 0
+	}
+	return float64(m.TotalBytes-m.AvailableBytes) / float64(m.TotalBytes) * 100
+}
+
+// parseMemInfo 解析 /proc/meminfo（单位 kB）
+func parseMemInfo(data string) MemInfo {
+	var info MemInfo
+	var free, buffers, cached uint64
+	hasAvailable := false
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		v *= 1024
+		switch fields[0] {
+		case "MemTotal:":
+			info.TotalBytes = v
+		case "MemAvailable:":
+			info.AvailableBytes = v
+			hasAvailable = true
+		case "MemFree:":
+			free = v
+		case "Buffers:":
+			buffers = v
+		case "Cached:":
+			cached = v
+		case "SwapTotal:":
+			info.SwapTotalBytes = v
+		case "SwapFree:":
+			info.SwapFreeBytes = v
+		}
+	}
+	// 旧内核没有 MemAvailable
+	if !hasAvailable {
+		info.AvailableBytes = free + buffers + cached
+	}
+	return info
+}
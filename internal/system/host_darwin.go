@@ -0,0 +1,73 @@
+//go:build darwin
+
+package system
+
+import (
+	"encoding/binary"
+	"os"
+	"syscall"
+	"time"
+)
+
+// sysctlRaw 读取二进制 sysctl 值，syscall.Sysctl 会去掉末尾的零字节，需要补齐
+func sysctlRaw(name string, size int) ([]byte, error) {
+	s, err := syscall.Sysctl(name)
+	if err != nil {
+		return nil, err
+	}
+	b := []byte(s)
+	for len(b) < size {
+		b = append(b, 0)
+	}
+	return b, nil
+}
+
+// ReadMemInfo 通过 sysctl 读取内存情况
+func ReadMemInfo() (MemInfo, error) {
+	b, err := sysctlRaw("hw.memsize", 8)
+	if err != nil {
+		return MemInfo{}, err
+	}
+	info := MemInfo{TotalBytes: binary.LittleEndian.Uint64(b)}
+
+	if b, err := sysctlRaw("vm.page_free_count", 4); err == nil {
+		info.AvailableBytes = uint64(binary.LittleEndian.Uint32(b)) * uint64(os.Getpagesize())
+	}
+	// struct xsw_usage { xsu_total, xsu_avail, xsu_used uint64 ... }
+	if b, err := sysctlRaw("vm.swapusage", 24); err == nil {
+		info.SwapTotalBytes = binary.LittleEndian.Uint64(b[0:8])
+		info.SwapFreeBytes = binary.LittleEndian.Uint64(b[8:16])
+	}
+	return info, nil
+}
+
+// Uptime 根据 kern.boottime 计算系统已运行时间
+func Uptime() (time.Duration, error) {
+	// struct timeval { tv_sec int64; tv_usec int32 }
+	b, err := sysctlRaw("kern.boottime", 16)
+	if err != nil {
+		return 0, err
+	}
+	sec := int64(binary.LittleEndian.Uint64(b[0:8]))
+	usec := int64(binary.LittleEndian.Uint32(b[8:12]))
+	return time.Since(time.Unix(sec, usec*1000)), nil
+}
+
+// LoadAverage 读取1、5、15分钟平均负载
+func LoadAverage() ([3]float64, error) {
+	var load [3]float64
+
+	// struct loadavg { fixpt_t ldavg[3]; long fscale }
+	b, err := sysctlRaw("vm.loadavg", 24)
+	if err != nil {
+		return load, err
+	}
+	scale := float64(binary.LittleEndian.Uint64(b[16:24]))
+	if scale == 0 {
+		return load, ErrHostInfoUnsupported
+	}
+	for i := 0; i < 3; i++ {
+		load[i] = float64(binary.LittleEndian.Uint32(b[i*4:])) / scale
+	}
+	return load, nil
+}
@@ -0,0 +1,62 @@
+//go:build linux
+
+package system
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReadMemInfo 读取 /proc/meminfo
+func ReadMemInfo() (MemInfo, error) {
+	data, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return MemInfo{}, err
+	}
+	info := parseMemInfo(string(data))
+	if info.TotalBytes == 0 {
+		return info, fmt.Errorf("unexpected /proc/meminfo format")
+	}
+	return info, nil
+}
+
+// Uptime 读取系统已运行时间
+func Uptime() (time.Duration, error) {
+	data, err := os.ReadFile("/proc/uptime")
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("unexpected /proc/uptime format")
+	}
+	secs, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(secs * float64(time.Second)), nil
+}
+
+// LoadAverage 读取1、5、15分钟平均负载
+func LoadAverage() ([3]float64, error) {
+	var load [3]float64
+
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return load, err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return load, fmt.Errorf("unexpected /proc/loadavg format")
+	}
+	for i := 0; i < 3; i++ {
+		if load[i], err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return load, err
+		}
+	}
+	return load, nil
+}
@@ -0,0 +1,20 @@
+//go:build !linux && !darwin && !windows
+
+package system
+
+import "time"
+
+// ReadMemInfo 读取内存情况
+func ReadMemInfo() (MemInfo, error) {
+	return MemInfo{}, ErrHostInfoUnsupported
+}
+
+// Uptime 读取系统已运行时间
+func Uptime() (time.Duration, error) {
+	return 0, ErrHostInfoUnsupported
+}
+
+// LoadAverage 读取1、5、15分钟平均负载
+func LoadAverage() ([3]float64, error) {
+	return [3]float64{}, ErrHostInfoUnsupported
+}
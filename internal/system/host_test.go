@@ -0,0 +1,29 @@
+package system
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestHostInfo(t *testing.T) {
+	switch runtime.GOOS {
+	case "linux", "darwin", "windows":
+	default:
+		t.Skip("host info not supported on " + runtime.GOOS)
+	}
+
+	mem, err := ReadMemInfo()
+	if err != nil {
+		t.Fatalf("ReadMemInfo failed: %v", err)
+	}
+	if mem.TotalBytes == 0 || mem.AvailableBytes > mem.TotalBytes {
+		t.Errorf("unexpected meminfo: %+v", mem)
+	}
+	if p := mem.UsedPercent(); p < 0 || p > 100 {
+		t.Errorf("UsedPercent() = %f", p)
+	}
+
+	if uptime, err := Uptime(); err != nil || uptime <= 0 {
+		t.Errorf("Uptime() = %v, %v", uptime, err)
+	}
+}
@@ -0,0 +1,65 @@
+//go:build windows
+
+package system
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	kernel32                 = syscall.NewLazyDLL("kernel32.dll")
+	procGlobalMemoryStatusEx = kernel32.NewProc("GlobalMemoryStatusEx")
+	procGetTickCount64       = kernel32.NewProc("GetTickCount64")
+	procGetDiskFreeSpaceExW  = kernel32.NewProc("GetDiskFreeSpaceExW")
+	procGetSystemTimes       = kernel32.NewProc("GetSystemTimes")
+)
+
+// memoryStatusEx MEMORYSTATUSEX
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
+// ReadMemInfo 通过 GlobalMemoryStatusEx 读取内存情况
+func ReadMemInfo() (MemInfo, error) {
+	var st memoryStatusEx
+	st.Length = uint32(unsafe.Sizeof(st))
+	if r, _, err := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&st))); r == 0 {
+		return MemInfo{}, err
+	}
+
+	info := MemInfo{TotalBytes: st.TotalPhys, AvailableBytes: st.AvailPhys}
+	// 页面文件大小包含物理内存，差值近似为交换空间
+	if st.TotalPageFile > st.TotalPhys {
+		info.SwapTotalBytes = st.TotalPageFile - st.TotalPhys
+		if st.AvailPageFile > st.AvailPhys {
+			info.SwapFreeBytes = st.AvailPageFile - st.AvailPhys
+		}
+	}
+	return info, nil
+}
+
+// Uptime 通过 GetTickCount64 读取系统已运行时间
+func Uptime() (time.Duration, error) {
+	r1, r2, _ := procGetTickCount64.Call()
+	ms := uint64(r1)
+	// 32位系统上高位在 r2
+	if unsafe.Sizeof(r1) == 4 {
+		ms |= uint64(r2) << 32
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// LoadAverage Windows 没有平均负载
+func LoadAverage() ([3]float64, error) {
+	return [3]float64{}, ErrHostInfoUnsupported
+}
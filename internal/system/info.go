@@ -40,6 +40,12 @@ func GetInfo() *SystemInfo {
 		info.getWindowsInfo()
 	}
 
+	if info.MemoryTotal == 0 {
+		if mem, err := ReadMemInfo(); err == nil {
+			info.MemoryTotal = mem.TotalBytes / 1024 / 1024
+		}
+	}
+
 	return info
 }
 
@@ -102,6 +108,9 @@ func (i *SystemInfo) getDarwinInfo() {
 	if out, err := exec.Command("sw_vers", "-productVersion").Output(); err == nil {
 		i.DistroVersion = strings.TrimSpace(string(out))
 	}
+	if out, err := exec.Command("sysctl", "-n", "machdep.cpu.brand_string").Output(); err == nil {
+		i.CPUModel = strings.TrimSpace(string(out))
+	}
 }
 
 func (i *SystemInfo) getWindowsInfo() {
@@ -109,6 +118,7 @@ func (i *SystemInfo) getWindowsInfo() {
 		i.KernelVersion = strings.TrimSpace(string(out))
 	}
 	i.Distro = "Windows"
+	i.CPUModel = os.Getenv("PROCESSOR_IDENTIFIER")
 }
 
 func (i *SystemInfo) Format() string {
@@ -0,0 +1,261 @@
+package system
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Process 进程信息，Linux 读取 /proc，macOS 调用 ps，Windows 通过 WMI 查询。
+// 平台没有提供的字段为零值，如 macOS 的线程数和 Windows 的状态
+type Process struct {
+	PID        int           `json:"pid"`
+	PPID       int           `json:"ppid"`
+	Name       string        `json:"name"`
+	State      string        `json:"state"`
+	User       string        `json:"user"`
+	Threads    int           `json:"threads"`
+	RSSBytes   uint64        `json:"rss_bytes"`
+	VSizeBytes uint64        `json:"vsize_bytes"`
+	CPUTime    time.Duration `json:"cpu_time"`
+	Uptime     time.Duration `json:"uptime"`
+	CPUPercent float64       `json:"cpu_percent"`
+	Cmdline    string        `json:"cmdline"`
+
+	uid string
+}
+
+// procStat /proc/[pid]/stat 中需要的字段
+type procStat struct {
+	name     string
+	state    string
+	ppid     int
+	ticks    uint64
+	threads  int
+	start    uint64
+	vsize    uint64
+	rssPages uint64
+}
+
+func parseProcStat(data string) (procStat, error) {
+	// 进程名可能包含空格和括号，取第一个左括号与最后一个右括号之间的内容
+	open := strings.IndexByte(data, '(')
+	close := strings.LastIndexByte(data, ')')
+	if open < 0 || close < open {
+		return procStat{}, fmt.Errorf("unexpected stat format")
+	}
+
+	// 下标从 state（第3个字段）开始
+	fields := strings.Fields(data[close+1:])
+	if len(fields) < 22 {
+		return procStat{}, fmt.Errorf("unexpected stat format")
+	}
+
+	st := procStat{name: data[open+1 : close], state: fields[0]}
+	nums := make([]uint64, len(fields))
+	for _, i := range []int{1, 11, 12, 17, 19, 20, 21} {
+		v, err := strconv.ParseUint(fields[i], 10, 64)
+		if err != nil {
+			return procStat{}, fmt.Errorf("unexpected stat format: %w", err)
+		}
+		nums[i] = v
+	}
+	st.ppid = int(nums[1])
+	st.ticks = nums[11] + nums[12]
+	st.threads = int(nums[17])
+	st.start = nums[19]
+	st.vsize = nums[20]
+	st.rssPages = nums[21]
+	return st, nil
+}
+
+// resolveUsers 将 UID 解析为用户名
+func resolveUsers(procs []Process) {
+	names := make(map[string]string)
+	for i := range procs {
+		uid := procs[i].uid
+		if uid == "" {
+			continue
+		}
+		name, ok := names[uid]
+		if !ok {
+			name = uid
+			if u, err := user.LookupId(uid); err == nil {
+				name = u.Username
+			}
+			names[uid] = name
+		}
+		procs[i].User = name
+	}
+}
+
+// errProcessNotFound 各平台统一的进程不存在错误
+func errProcessNotFound(pid int) error {
+	return fmt.Errorf("process %d not found", pid)
+}
+
+// psFields macOS ps 输出的列，command 含空格放在最后
+const psFields = "pid=,ppid=,state=,user=,rss=,vsz=,time=,etime=,command="
+
+// parsePS 解析 ps -o psFields 的输出，rss 和 vsz 单位为 KB
+func parsePS(out string) ([]Process, error) {
+	var procs []Process
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 8 {
+			return nil, fmt.Errorf("unexpected ps output: %q", line)
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		rss, err3 := strconv.ParseUint(fields[4], 10, 64)
+		vsz, err4 := strconv.ParseUint(fields[5], 10, 64)
+		cpu, err5 := parseClock(fields[6])
+		elapsed, err6 := parseClock(fields[7])
+		for _, err := range []error{err1, err2, err3, err4, err5, err6} {
+			if err != nil {
+				return nil, fmt.Errorf("unexpected ps output: %q: %w", line, err)
+			}
+		}
+
+		p := Process{
+			PID:        pid,
+			PPID:       ppid,
+			State:      fields[2][:1],
+			User:       fields[3],
+			RSSBytes:   rss * 1024,
+			VSizeBytes: vsz * 1024,
+			CPUTime:    cpu,
+			Uptime:     elapsed,
+			Cmdline:    strings.Join(fields[8:], " "),
+		}
+		if len(fields) > 8 {
+			p.Name = filepath.Base(fields[8])
+		}
+		procs = append(procs, p)
+	}
+	return procs, nil
+}
+
+// parseClock 解析 ps 的时间格式 [[dd-]hh:]mm:ss[.ss]，分钟数可以超过60
+func parseClock(s string) (time.Duration, error) {
+	var d time.Duration
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		days, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, err
+		}
+		d = time.Duration(days) * 24 * time.Hour
+		s = s[i+1:]
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	secs, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil {
+		return 0, err
+	}
+	d += time.Duration(secs * float64(time.Second))
+	unit := time.Minute
+	for i := len(parts) - 2; i >= 0; i-- {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return 0, err
+		}
+		d += time.Duration(n) * unit
+		unit = time.Hour
+	}
+	return d, nil
+}
+
+// cimProcess Windows 上 PowerShell 查询 Win32_Process 输出的JSON
+type cimProcess struct {
+	PID     int    `json:"pid"`
+	PPID    int    `json:"ppid"`
+	Name    string `json:"name"`
+	User    string `json:"user"`
+	Threads int    `json:"threads"`
+	RSS     uint64 `json:"rss"`
+	VSize   uint64 `json:"vsize"`
+	// CPU 内核态与用户态时间之和，Start 为创建时间的 FILETIME，单位都是 100ns
+	CPU     uint64 `json:"cpu"`
+	Start   int64  `json:"start"`
+	Cmdline string `json:"cmdline"`
+}
+
+// filetimeEpoch 1601-01-01 到 1970-01-01 之间的 100ns 数
+const filetimeEpoch = 116444736000000000
+
+// parseCimProcesses 解析 ConvertTo-Json 的输出，只有一个进程时输出的是对象而不是数组
+func parseCimProcesses(out []byte, now time.Time) ([]Process, error) {
+	text := strings.TrimSpace(string(out))
+	if text == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(text, "{") {
+		text = "[" + text + "]"
+	}
+	var items []cimProcess
+	if err := json.Unmarshal([]byte(text), &items); err != nil {
+		return nil, fmt.Errorf("unexpected Win32_Process output: %w", err)
+	}
+
+	procs := make([]Process, 0, len(items))
+	for _, c := range items {
+		p := Process{
+			PID:        c.PID,
+			PPID:       c.PPID,
+			Name:       c.Name,
+			User:       c.User,
+			Threads:    c.Threads,
+			RSSBytes:   c.RSS,
+			VSizeBytes: c.VSize,
+			CPUTime:    time.Duration(c.CPU) * 100,
+			Cmdline:    c.Cmdline,
+		}
+		if c.Start > filetimeEpoch {
+			if started := time.Unix(0, (c.Start-filetimeEpoch)*100); now.After(started) {
+				p.Uptime = now.Sub(started)
+			}
+		}
+		procs = append(procs, p)
+	}
+	return procs, nil
+}
+
+// SampleProcesses 间隔 interval 两次读取进程列表，计算各进程的CPU使用率（单核为100%）
+func SampleProcesses(interval time.Duration) ([]Process, error) {
+	before, err := ListProcesses()
+	if err != nil {
+		return nil, err
+	}
+	prev := make(map[int]time.Duration, len(before))
+	for _, p := range before {
+		prev[p.PID] = p.CPUTime
+	}
+
+	start := time.Now()
+	time.Sleep(interval)
+	procs, err := ListProcesses()
+	if err != nil {
+		return nil, err
+	}
+	elapsed := time.Since(start).Seconds()
+
+	for i := range procs {
+		last, ok := prev[procs[i].PID]
+		if !ok || procs[i].CPUTime < last || elapsed <= 0 {
+			continue
+		}
+		procs[i].CPUPercent = (procs[i].CPUTime - last).Seconds() / elapsed * 100
+	}
+	return procs, nil
+}
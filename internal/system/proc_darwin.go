@@ -0,0 +1,43 @@
+//go:build darwin
+
+package system
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// psTimeout 调用 ps 的超时
+const psTimeout = 10 * time.Second
+
+func runPS(args ...string) ([]Process, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), psTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "ps", append([]string{"-ww", "-o", psFields}, args...)...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return parsePS(string(out))
+}
+
+// ReadProcess 读取指定 PID 的进程信息
+func ReadProcess(pid int) (Process, error) {
+	procs, err := runPS("-p", strconv.Itoa(pid))
+	// 进程不存在时 ps 以状态码 1 退出且没有输出
+	var exitErr *exec.ExitError
+	if (err == nil && len(procs) == 0) || errors.As(err, &exitErr) {
+		return Process{}, errProcessNotFound(pid)
+	}
+	if err != nil {
+		return Process{}, err
+	}
+	return procs[0], nil
+}
+
+// ListProcesses 列出所有进程（CPUPercent 为 0，需要用 SampleProcesses 采样）
+func ListProcesses() ([]Process, error) {
+	return runPS("-ax")
+}
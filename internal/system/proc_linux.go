@@ -0,0 +1,94 @@
+//go:build linux
+
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readProcess 读取单个进程，uptime 用于计算进程已运行时间
+func readProcess(pid int, uptime time.Duration) (Process, error) {
+	dir := filepath.Join("/proc", strconv.Itoa(pid))
+	data, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return Process{}, err
+	}
+	st, err := parseProcStat(string(data))
+	if err != nil {
+		return Process{}, err
+	}
+
+	p := Process{
+		PID:        pid,
+		PPID:       st.ppid,
+		Name:       st.name,
+		State:      st.state,
+		Threads:    st.threads,
+		RSSBytes:   st.rssPages * uint64(os.Getpagesize()),
+		VSizeBytes: st.vsize,
+		CPUTime:    time.Duration(st.ticks) * time.Second / clockTicks,
+	}
+	if started := time.Duration(st.start) * time.Second / clockTicks; uptime > started {
+		p.Uptime = uptime - started
+	}
+
+	if status, err := os.ReadFile(filepath.Join(dir, "status")); err == nil {
+		for _, line := range strings.Split(string(status), "\n") {
+			if strings.HasPrefix(line, "Uid:") {
+				if fields := strings.Fields(line); len(fields) > 1 {
+					p.uid = fields[1]
+				}
+				break
+			}
+		}
+	}
+
+	if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		p.Cmdline = strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+	}
+	return p, nil
+}
+
+// ReadProcess 读取指定 PID 的进程信息
+func ReadProcess(pid int) (Process, error) {
+	uptime, _ := Uptime()
+	p, err := readProcess(pid, uptime)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Process{}, errProcessNotFound(pid)
+		}
+		return Process{}, err
+	}
+	procs := []Process{p}
+	resolveUsers(procs)
+	return procs[0], nil
+}
+
+// ListProcesses 列出所有进程（CPUPercent 为 0，需要用 SampleProcesses 采样）
+func ListProcesses() ([]Process, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	uptime, _ := Uptime()
+
+	var procs []Process
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		// 进程可能在读取期间退出
+		p, err := readProcess(pid, uptime)
+		if err != nil {
+			continue
+		}
+		procs = append(procs, p)
+	}
+	resolveUsers(procs)
+	return procs, nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !windows
+
+package system
+
+// ReadProcess 读取指定 PID 的进程信息
+func ReadProcess(pid int) (Process, error) {
+	return Process{}, ErrHostInfoUnsupported
+}
+
+// ListProcesses 列出所有进程
+func ListProcesses() ([]Process, error) {
+	return nil, ErrHostInfoUnsupported
+}
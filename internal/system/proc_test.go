@@ -0,0 +1,127 @@
+package system
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestParseProcStat(t *testing.T) {
+	data := "1234 (my (odd) proc) S 1 1234 1234 0 -1 4194560 500 0 0 0 150 50 0 0 20 0 3 0 9000 104857600 2560 18446744073709551615"
+	st, err := parseProcStat(data)
+	if err != nil {
+		t.Fatalf("parseProcStat failed: %v", err)
+	}
+	if st.name != "my (odd) proc" || st.state != "S" || st.ppid != 1 {
+		t.Errorf("unexpected header: %+v", st)
+	}
+	if st.ticks != 200 || st.threads != 3 || st.start != 9000 || st.vsize != 104857600 || st.rssPages != 2560 {
+		t.Errorf("unexpected fields: %+v", st)
+	}
+
+	if _, err := parseProcStat("1234 (short) S 1 2 3"); err == nil {
+		t.Error("parseProcStat should fail on truncated input")
+	}
+}
+
+func TestParsePS(t *testing.T) {
+	out := `    1     0 Ss   root        12864  410943232   0:41.52 15-02:03:04 /sbin/launchd
+  812     1 S+   alice      204800 5000000000 1:02:03.50    01:30 /Applications/My App.app/Contents/MacOS/My App --flag
+`
+	procs, err := parsePS(out)
+	if err != nil {
+		t.Fatalf("parsePS failed: %v", err)
+	}
+	if len(procs) != 2 {
+		t.Fatalf("got %d processes", len(procs))
+	}
+	p := procs[0]
+	if p.PID != 1 || p.State != "S" || p.User != "root" || p.Name != "launchd" || p.RSSBytes != 12864*1024 {
+		t.Errorf("unexpected process: %+v", p)
+	}
+	if p.CPUTime != 41520*time.Millisecond || p.Uptime != 15*24*time.Hour+2*time.Hour+3*time.Minute+4*time.Second {
+		t.Errorf("cpu = %v, uptime = %v", p.CPUTime, p.Uptime)
+	}
+	p = procs[1]
+	if p.Cmdline != "/Applications/My App.app/Contents/MacOS/My App --flag" || p.CPUTime != time.Hour+2*time.Minute+3500*time.Millisecond || p.Uptime != 90*time.Second {
+		t.Errorf("unexpected process: %+v", p)
+	}
+
+	if _, err := parsePS("1 0 S root"); err == nil {
+		t.Error("parsePS should fail on truncated input")
+	}
+}
+
+func TestParseCimProcesses(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	// 2024-05-01 11:00 UTC 的 FILETIME
+	start := now.Add(-time.Hour).UnixNano()/100 + filetimeEpoch
+	out := fmt.Sprintf(`{"pid":4321,"ppid":600,"name":"mujibot.exe","user":"alice","threads":12,"rss":52428800,"vsize":104857600,"cpu":25000000,"start":%d,"cmdline":"C:\\mujibot\\mujibot.exe --json"}`, start)
+	procs, err := parseCimProcesses([]byte(out), now)
+	if err != nil {
+		t.Fatalf("parseCimProcesses failed: %v", err)
+	}
+	if len(procs) != 1 {
+		t.Fatalf("got %d processes", len(procs))
+	}
+	p := procs[0]
+	if p.PID != 4321 || p.PPID != 600 || p.User != "alice" || p.Threads != 12 || p.RSSBytes != 52428800 || p.Cmdline != `C:\mujibot\mujibot.exe --json` {
+		t.Errorf("unexpected process: %+v", p)
+	}
+	if p.CPUTime != 2500*time.Millisecond || p.Uptime != time.Hour {
+		t.Errorf("cpu = %v, uptime = %v", p.CPUTime, p.Uptime)
+	}
+
+	procs, err = parseCimProcesses([]byte(`[{"pid":1,"start":0},{"pid":2,"start":0}]`), now)
+	if err != nil || len(procs) != 2 || procs[1].Uptime != 0 {
+		t.Errorf("array output = %+v, %v", procs, err)
+	}
+	if procs, err := parseCimProcesses([]byte(""), now); err != nil || len(procs) != 0 {
+		t.Errorf("empty output = %+v, %v", procs, err)
+	}
+}
+
+func TestParseMemInfo(t *testing.T) {
+	info := parseMemInfo("MemTotal:        2048 kB\nMemFree:          256 kB\nMemAvailable:    1024 kB\nSwapTotal:        512 kB\nSwapFree:         128 kB\n")
+	if info.TotalBytes != 2048*1024 || info.AvailableBytes != 1024*1024 || info.SwapTotalBytes != 512*1024 || info.SwapFreeBytes != 128*1024 {
+		t.Errorf("unexpected meminfo: %+v", info)
+	}
+
+	// 旧内核没有 MemAvailable
+	info = parseMemInfo("MemTotal: 1000 kB\nMemFree: 100 kB\nBuffers: 20 kB\nCached: 30 kB\n")
+	if info.AvailableBytes != 150*1024 {
+		t.Errorf("AvailableBytes = %d, want %d", info.AvailableBytes, 150*1024)
+	}
+}
+
+func TestReadProcess(t *testing.T) {
+	switch runtime.GOOS {
+	case "linux", "darwin":
+	default:
+		t.Skip("process listing via ps or WMI is not exercised on " + runtime.GOOS)
+	}
+
+	p, err := ReadProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("ReadProcess failed: %v", err)
+	}
+	if p.PID != os.Getpid() || p.PPID != os.Getppid() || p.RSSBytes == 0 || p.Cmdline == "" {
+		t.Errorf("unexpected process: %+v", p)
+	}
+
+	procs, err := SampleProcesses(10 * time.Millisecond)
+	if err != nil {
+		t.Fatalf("SampleProcesses failed: %v", err)
+	}
+	found := false
+	for _, proc := range procs {
+		if proc.PID == os.Getpid() {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("SampleProcesses did not include the current process")
+	}
+}
@@ -0,0 +1,60 @@
+//go:build windows
+
+package system
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// wmiTimeout 通过 PowerShell 查询 WMI 的超时，PowerShell 冷启动较慢
+const wmiTimeout = 30 * time.Second
+
+// wmiProcessScript 查询 Win32_Process 并输出 cimProcess 格式的JSON，%s 为查询条件和取所有者的表达式
+const wmiProcessScript = `$ErrorActionPreference = 'Stop'
+$procs = @(Get-CimInstance Win32_Process %s | ForEach-Object {
+  [pscustomobject]@{
+    pid = $_.ProcessId; ppid = $_.ParentProcessId; name = $_.Name; user = %s
+    threads = $_.ThreadCount; rss = $_.WorkingSetSize; vsize = $_.VirtualSize
+    cpu = $_.KernelModeTime + $_.UserModeTime
+    start = $(if ($_.CreationDate) { $_.CreationDate.ToFileTimeUtc() } else { 0 })
+    cmdline = $_.CommandLine
+  }
+})
+ConvertTo-Json -Compress -InputObject $procs`
+
+// queryProcesses filter 为空时查询所有进程。取所有者需要逐个调用 GetOwner，只在查询单个进程时取
+func queryProcesses(filter string, owner bool) ([]Process, error) {
+	user := `''`
+	if owner {
+		user = `$((Invoke-CimMethod -InputObject $_ -MethodName GetOwner).User)`
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), wmiTimeout)
+	defer cancel()
+	script := fmt.Sprintf(wmiProcessScript, filter, user)
+	out, err := exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", script).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Win32_Process: %w", err)
+	}
+	return parseCimProcesses(out, time.Now())
+}
+
+// ReadProcess 读取指定 PID 的进程信息
+func ReadProcess(pid int) (Process, error) {
+	procs, err := queryProcesses(fmt.Sprintf("-Filter 'ProcessId = %d'", pid), true)
+	if err != nil {
+		return Process{}, err
+	}
+	if len(procs) == 0 {
+		return Process{}, errProcessNotFound(pid)
+	}
+	return procs[0], nil
+}
+
+// ListProcesses 列出所有进程（CPUPercent 为 0，需要用 SampleProcesses 采样）
+func ListProcesses() ([]Process, error) {
+	return queryProcesses("", false)
+}
@@ -0,0 +1,6 @@
+package system
+
+import "errors"
+
+// ErrPTYUnsupported 当前平台不支持伪终端
+var ErrPTYUnsupported = errors.New("pseudo-terminal not supported on this platform")
@@ -0,0 +1,36 @@
+//go:build darwin
+
+package system
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// openPTY 打开 /dev/ptmx，授权并解锁从设备后返回其路径
+func openPTY() (*os.File, string, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := ioctl(master, syscall.TIOCPTYGRANT, 0); err != nil {
+		master.Close()
+		return nil, "", err
+	}
+	if err := ioctl(master, syscall.TIOCPTYUNLK, 0); err != nil {
+		master.Close()
+		return nil, "", err
+	}
+	var name [128]byte
+	if err := ioctl(master, syscall.TIOCPTYGNAME, uintptr(unsafe.Pointer(&name[0]))); err != nil {
+		master.Close()
+		return nil, "", err
+	}
+	if i := bytes.IndexByte(name[:], 0); i >= 0 {
+		return master, string(name[:i]), nil
+	}
+	return master, string(name[:]), nil
+}
@@ -0,0 +1,21 @@
+//go:build !linux && !darwin
+
+package system
+
+import (
+	"os"
+	"os/exec"
+)
+
+// OpenPTY 打开一对伪终端并设置窗口大小，返回主设备和从设备
+func OpenPTY(rows, cols int) (master, slave *os.File, err error) {
+	return nil, nil, ErrPTYUnsupported
+}
+
+// SetPTYSize 设置伪终端窗口大小
+func SetPTYSize(f *os.File, rows, cols int) error {
+	return ErrPTYUnsupported
+}
+
+// SetControllingTerminal 让子进程以伪终端为控制终端
+func SetControllingTerminal(cmd *exec.Cmd) {}
@@ -0,0 +1,60 @@
+//go:build linux || darwin
+
+package system
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+// OpenPTY 打开一对伪终端并设置窗口大小，返回主设备和从设备
+func OpenPTY(rows, cols int) (master, slave *os.File, err error) {
+	master, name, err := openPTY()
+	if err != nil {
+		return nil, nil, err
+	}
+	slave, err = os.OpenFile(name, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	if err := SetPTYSize(master, rows, cols); err != nil {
+		master.Close()
+		slave.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
+
+// SetPTYSize 设置伪终端窗口大小
+func SetPTYSize(f *os.File, rows, cols int) error {
+	ws := struct {
+		Row, Col, X, Y uint16
+	}{Row: uint16(rows), Col: uint16(cols)}
+	return ioctl(f, syscall.TIOCSWINSZ, uintptr(unsafe.Pointer(&ws)))
+}
+
+// SetControllingTerminal 让子进程在新会话中以标准输入（伪终端从设备）为控制终端
+func SetControllingTerminal(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+}
+
+// ioctl 通过 RawConn 调用，不把文件切换为阻塞模式
+func ioctl(f *os.File, req, arg uintptr) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	if err := conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req, arg)
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
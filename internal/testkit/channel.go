@@ -0,0 +1,85 @@
+package testkit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Message 渠道发出的一条消息
+type Message struct {
+	Target string
+	Text   string
+}
+
+// Channel 内存渠道：Receive 模拟用户发来消息，Send 记录主动推送的消息
+type Channel struct {
+	Name string
+
+	mu      sync.Mutex
+	handler func(userID, username, content, target string) (string, error)
+	sent    []Message
+	notify  chan struct{}
+	// SendErr 不为空时 Send 返回该错误，用于测试发送失败
+	SendErr error
+}
+
+// NewChannel 创建内存渠道
+func NewChannel(name string) *Channel {
+	return &Channel{Name: name, notify: make(chan struct{}, 1)}
+}
+
+// Connect 设置消息处理函数，通常为 gateway.ConnectChannel 的返回值
+func (c *Channel) Connect(h func(userID, username, content, target string) (string, error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handler = h
+}
+
+// Receive 模拟用户 userID 在私聊中发来消息，返回网关的回复
+func (c *Channel) Receive(userID, content string) (string, error) {
+	c.mu.Lock()
+	h := c.handler
+	c.mu.Unlock()
+	if h == nil {
+		return "", fmt.Errorf("testkit: channel %s is not connected", c.Name)
+	}
+	return h(userID, "user"+userID, content, userID)
+}
+
+// Send 记录主动推送的消息
+func (c *Channel) Send(target, text string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.SendErr != nil {
+		return c.SendErr
+	}
+	c.sent = append(c.sent, Message{Target: target, Text: text})
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Sent 返回已推送的消息
+func (c *Channel) Sent() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Message(nil), c.sent...)
+}
+
+// WaitSent 等待至少 n 条推送消息，超时后返回已有的消息和 false。定时任务等在后台发送时使用
+func (c *Channel) WaitSent(n int, timeout time.Duration) ([]Message, bool) {
+	deadline := time.After(timeout)
+	for {
+		if sent := c.Sent(); len(sent) >= n {
+			return sent, true
+		}
+		select {
+		case <-c.notify:
+		case <-deadline:
+			return c.Sent(), false
+		}
+	}
+}
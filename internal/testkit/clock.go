@@ -0,0 +1,39 @@
+package testkit
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 手动推进的时钟，Now 可传给 session.Manager.SetClock 和 scheduler.Scheduler.SetClock
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock 创建停在 start 的时钟
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now 返回时钟的当前时间
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance 把时钟向前推进 d 并返回新的时间
+func (c *Clock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return c.now
+}
+
+// Set 把时钟设置为 t
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
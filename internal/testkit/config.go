@@ -0,0 +1,49 @@
+package testkit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// WriteConfig 在 t.TempDir() 中写入可直接用于 gateway.NewGatewayWithOptions 的配置文件并返回路径。
+// 默认配置使用 ollama（不需要密钥）、一个名为 default 的智能体，工作目录和记忆目录都在临时目录下；
+// overrides 按层级合并到默认配置，如 {"tools": {"enabledTools": {"datetime": true}}}
+func WriteConfig(t testing.TB, overrides map[string]interface{}) string {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := map[string]interface{}{
+		"llm": map[string]interface{}{"provider": "ollama", "model": "testkit"},
+		"agents": map[string]interface{}{
+			"default": map[string]interface{}{"name": "Test", "systemPrompt": "You are a test assistant."},
+		},
+		"tools":   map[string]interface{}{"workDir": filepath.Join(dir, "work")},
+		"session": map[string]interface{}{"maxMessages": 20, "idleTimeout": 3600, "maxSessions": 100},
+		"memory":  map[string]interface{}{"enabled": true, "memoryDir": filepath.Join(dir, "memory"), "maxFileSize": 102400},
+		"logging": map[string]interface{}{"level": "error"},
+	}
+	merge(cfg, overrides)
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		t.Fatalf("testkit: invalid config overrides: %v", err)
+	}
+	path := filepath.Join(dir, "config.json5")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("testkit: failed to write config: %v", err)
+	}
+	return path
+}
+
+// merge 把 src 合并到 dst，两边都是对象的键递归合并，其余直接覆盖
+func merge(dst, src map[string]interface{}) {
+	for k, v := range src {
+		sub, ok := v.(map[string]interface{})
+		if cur, isMap := dst[k].(map[string]interface{}); ok && isMap {
+			merge(cur, sub)
+			continue
+		}
+		dst[k] = v
+	}
+}
@@ -0,0 +1,143 @@
+// Package testkit 提供网关和智能体集成测试用的替身：按脚本回复的LLM提供商、
+// 内存渠道、可手动推进的时钟和临时配置文件
+package testkit
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/HaohanHe/mujibot/internal/llm"
+	"github.com/HaohanHe/mujibot/internal/session"
+)
+
+// Step 脚本中的一步：一次 Chat 调用的回复或错误
+type Step struct {
+	Response llm.Response
+	Err      error
+}
+
+// Reply 返回文本回复的一步
+func Reply(text string) Step {
+	return Step{Response: llm.Response{Content: text}}
+}
+
+// CallTool 请求调用工具的一步，args 编码为JSON参数
+func CallTool(name string, args map[string]interface{}) Step {
+	data, err := json.Marshal(args)
+	if err != nil {
+		panic(fmt.Sprintf("testkit: invalid tool arguments: %v", err))
+	}
+	var tc session.ToolCall
+	tc.ID = "call_" + name
+	tc.Type = "function"
+	tc.Function.Name = name
+	tc.Function.Arguments = string(data)
+	return Step{Response: llm.Response{ToolCalls: []session.ToolCall{tc}}}
+}
+
+// Fail 返回错误的一步
+func Fail(err error) Step {
+	return Step{Err: err}
+}
+
+// Request 提供商收到的一次请求
+type Request struct {
+	Messages []session.Message
+	Tools    []llm.Tool
+	Options  llm.ChatOptions
+}
+
+// System 请求的系统提示词
+func (r Request) System() string {
+	if len(r.Messages) > 0 && r.Messages[0].Role == "system" {
+		return r.Messages[0].Content
+	}
+	return ""
+}
+
+// Last 请求的最后一条消息
+func (r Request) Last() session.Message {
+	if len(r.Messages) == 0 {
+		return session.Message{}
+	}
+	return r.Messages[len(r.Messages)-1]
+}
+
+// Provider 按脚本依次回复的 llm.Provider，记录收到的每次请求。脚本用完后返回错误
+type Provider struct {
+	Model string
+
+	mu       sync.Mutex
+	steps    []Step
+	requests []Request
+}
+
+// NewProvider 创建按 steps 依次回复的提供商
+func NewProvider(steps ...Step) *Provider {
+	return &Provider{Model: "testkit", steps: steps}
+}
+
+// Push 追加脚本步骤
+func (p *Provider) Push(steps ...Step) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.steps = append(p.steps, steps...)
+}
+
+// Chat 记录请求并返回下一步
+func (p *Provider) Chat(messages []session.Message, tools []llm.Tool) (*llm.Response, error) {
+	return p.ChatWithOptions(messages, tools, llm.ChatOptions{})
+}
+
+// ChatWithOptions 与 Chat 相同，同时记录请求选项
+func (p *Provider) ChatWithOptions(messages []session.Message, tools []llm.Tool, opts llm.ChatOptions) (*llm.Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	req := Request{Messages: append([]session.Message(nil), messages...), Tools: tools, Options: opts}
+	p.requests = append(p.requests, req)
+	if len(p.steps) == 0 {
+		return nil, fmt.Errorf("testkit: unexpected llm request #%d (script exhausted), last message: %q", len(p.requests), req.Last().Content)
+	}
+	step := p.steps[0]
+	p.steps = p.steps[1:]
+	if step.Err != nil {
+		return nil, step.Err
+	}
+	resp := step.Response
+	return &resp, nil
+}
+
+// ChatStream 与 Chat 相同，回复内容作为一个分块回调
+func (p *Provider) ChatStream(messages []session.Message, tools []llm.Tool, callback func(chunk string)) (*llm.Response, error) {
+	return p.ChatStreamWithOptions(messages, tools, llm.ChatOptions{}, callback)
+}
+
+// ChatStreamWithOptions 与 ChatWithOptions 相同，回复内容作为一个分块回调
+func (p *Provider) ChatStreamWithOptions(messages []session.Message, tools []llm.Tool, opts llm.ChatOptions, callback func(chunk string)) (*llm.Response, error) {
+	resp, err := p.ChatWithOptions(messages, tools, opts)
+	if err == nil && resp.Content != "" && callback != nil {
+		callback(resp.Content)
+	}
+	return resp, err
+}
+
+// GetModel 获取模型名称
+func (p *Provider) GetModel() string {
+	return p.Model
+}
+
+// Requests 返回收到的请求
+func (p *Provider) Requests() []Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Request(nil), p.requests...)
+}
+
+// Remaining 返回尚未使用的步骤数，测试结束时通常应为 0
+func (p *Provider) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.steps)
+}
@@ -0,0 +1,247 @@
+package todo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/encryption"
+)
+
+// Owner 待办所属用户
+type Owner struct {
+	Channel string `json:"channel"`
+	UserID  string `json:"userId"`
+	Target  string `json:"target"` // 提醒推送目标
+}
+
+func (o Owner) key() string {
+	return o.Channel + ":" + o.UserID
+}
+
+// Item 待办事项
+type Item struct {
+	ID       int       `json:"id"`
+	Text     string    `json:"text"`
+	Due      time.Time `json:"due,omitempty"`
+	Created  time.Time `json:"created"`
+	Done     bool      `json:"done"`
+	DoneAt   time.Time `json:"doneAt,omitempty"`
+	Reminded bool      `json:"reminded,omitempty"`
+}
+
+// Reminder 到期需要提醒的待办
+type Reminder struct {
+	Owner Owner
+	Item  Item
+}
+
+// list 单个用户的待办列表，对应一个文件
+type list struct {
+	Owner  Owner   `json:"owner"`
+	NextID int     `json:"nextId"`
+	Items  []*Item `json:"items"`
+}
+
+// Store 按用户保存待办，每个用户一个JSON文件
+type Store struct {
+	dir    string
+	cipher *encryption.Cipher
+
+	mu    sync.Mutex
+	lists map[string]*list
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// NewStore 加载目录下的全部待办
+func NewStore(dir string) (*Store, error) {
+	return NewEncryptedStore(dir, nil)
+}
+
+// NewEncryptedStore 加载目录下的全部待办，文件使用给定的加密器读写
+func NewEncryptedStore(dir string, c *encryption.Cipher) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create todo directory: %w", err)
+	}
+
+	s := &Store{dir: dir, cipher: c, lists: make(map[string]*list)}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := c.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		var l list
+		if err := json.Unmarshal(data, &l); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		s.lists[l.Owner.key()] = &l
+	}
+	return s, nil
+}
+
+// Add 添加待办，due 为零值表示无截止时间
+func (s *Store) Add(owner Owner, text string, due time.Time) (*Item, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("text is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := s.lists[owner.key()]
+	if l == nil {
+		l = &list{Owner: owner, NextID: 1}
+		s.lists[owner.key()] = l
+	}
+	// 记录最近一次的推送目标
+	if owner.Target != "" {
+		l.Owner.Target = owner.Target
+	}
+
+	item := &Item{ID: l.NextID, Text: text, Due: due, Created: time.Now()}
+	l.NextID++
+	l.Items = append(l.Items, item)
+
+	if err := s.save(l); err != nil {
+		return nil, err
+	}
+	return copyItem(item), nil
+}
+
+// List 返回用户的待办，未完成的按截止时间排序在前
+func (s *Store) List(owner Owner, includeDone bool) []Item {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := s.lists[owner.key()]
+	if l == nil {
+		return nil
+	}
+
+	var result []Item
+	for _, item := range l.Items {
+		if item.Done && !includeDone {
+			continue
+		}
+		result = append(result, *item)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Done != b.Done {
+			return !a.Done
+		}
+		if a.Due.IsZero() != b.Due.IsZero() {
+			return !a.Due.IsZero()
+		}
+		return a.Due.Before(b.Due)
+	})
+	return result
+}
+
+// Complete 标记待办为已完成
+func (s *Store) Complete(owner Owner, id int) (*Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := s.lists[owner.key()]
+	if l == nil {
+		return nil, fmt.Errorf("todo not found: #%d", id)
+	}
+	for _, item := range l.Items {
+		if item.ID != id {
+			continue
+		}
+		if item.Done {
+			return nil, fmt.Errorf("todo #%d is already done", id)
+		}
+		item.Done = true
+		item.DoneAt = time.Now()
+		if err := s.save(l); err != nil {
+			return nil, err
+		}
+		return copyItem(item), nil
+	}
+	return nil, fmt.Errorf("todo not found: #%d", id)
+}
+
+// Delete 删除用户的全部待办及其文件，返回删除的条数
+func (s *Store) Delete(owner Owner) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	if l := s.lists[owner.key()]; l != nil {
+		n = len(l.Items)
+	}
+	if err := os.Remove(s.path(owner)); err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to remove todos: %w", err)
+	}
+	delete(s.lists, owner.key())
+	return n, nil
+}
+
+// DueReminders 取出已到期且未提醒的待办，并标记为已提醒
+func (s *Store) DueReminders(now time.Time) ([]Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []Reminder
+	var firstErr error
+	for _, l := range s.lists {
+		changed := false
+		for _, item := range l.Items {
+			if item.Done || item.Reminded || item.Due.IsZero() || item.Due.After(now) {
+				continue
+			}
+			item.Reminded = true
+			changed = true
+			result = append(result, Reminder{Owner: l.Owner, Item: *item})
+		}
+		if changed {
+			if err := s.save(l); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Item.Due.Before(result[j].Item.Due)
+	})
+	return result, firstErr
+}
+
+// save 写入用户的待办文件
+func (s *Store) save(l *list) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := s.path(l.Owner)
+	tmp := path + ".tmp"
+	if err := s.cipher.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write todos: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *Store) path(owner Owner) string {
+	return filepath.Join(s.dir, unsafeChars.ReplaceAllString(owner.Channel+"_"+owner.UserID, "_")+".json")
+}
+
+func copyItem(item *Item) *Item {
+	c := *item
+	return &c
+}
@@ -0,0 +1,112 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	alice := Owner{Channel: "telegram", UserID: "1", Target: "100"}
+	bob := Owner{Channel: "discord", UserID: "2", Target: "chan-2"}
+	now := time.Now()
+
+	if _, err := store.Add(alice, "buy milk", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	call, err := store.Add(alice, "call mom", now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Add(alice, "pay rent", now.Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Add(bob, "review PR", now.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Add(alice, "  ", time.Time{}); err == nil {
+		t.Error("empty text should be rejected")
+	}
+
+	// 有截止时间的排在前面，按时间排序
+	items := store.List(alice, false)
+	if len(items) != 3 || items[0].Text != "pay rent" || items[1].Text != "call mom" || items[2].Text != "buy milk" {
+		t.Fatalf("List() = %+v", items)
+	}
+	if len(store.List(bob, false)) != 1 {
+		t.Error("lists should be per user")
+	}
+
+	reminders, err := store.DueReminders(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reminders) != 1 || reminders[0].Item.Text != "pay rent" || reminders[0].Owner.Target != "100" {
+		t.Fatalf("DueReminders() = %+v", reminders)
+	}
+	// 同一待办只提醒一次
+	if reminders, _ := store.DueReminders(now); len(reminders) != 0 {
+		t.Errorf("reminded twice: %+v", reminders)
+	}
+
+	if _, err := store.Complete(alice, call.ID); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if _, err := store.Complete(bob, call.ID); err == nil {
+		t.Error("other users should not complete the todo")
+	}
+	if _, err := store.Complete(alice, call.ID); err == nil {
+		t.Error("completing twice should fail")
+	}
+
+	// 重新加载后保留状态，已完成的不再提醒
+	reloaded, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("reload error = %v", err)
+	}
+	if items := reloaded.List(alice, true); len(items) != 3 || !items[2].Done {
+		t.Errorf("reloaded List() = %+v", items)
+	}
+	reminders, _ = reloaded.DueReminders(now.Add(3 * time.Hour))
+	if len(reminders) != 1 || reminders[0].Item.Text != "review PR" || reminders[0].Owner.Target != "chan-2" {
+		t.Errorf("reloaded DueReminders() = %+v", reminders)
+	}
+	if next, _ := reloaded.Add(alice, "new", time.Time{}); next == nil || next.ID != 4 {
+		t.Errorf("next ID = %+v, want 4", next)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alice := Owner{Channel: "telegram", UserID: "1"}
+	bob := Owner{Channel: "telegram", UserID: "2"}
+	store.Add(alice, "buy milk", time.Time{})
+	store.Add(alice, "call mom", time.Time{})
+	store.Add(bob, "review PR", time.Time{})
+
+	if n, err := store.Delete(alice); err != nil || n != 2 {
+		t.Fatalf("Delete() = %d, %v", n, err)
+	}
+	if items := store.List(alice, true); len(items) != 0 {
+		t.Errorf("alice todos left: %+v", items)
+	}
+
+	// 重新加载后同样不存在
+	reloaded, err := NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.List(alice, true)) != 0 || len(reloaded.List(bob, true)) != 1 {
+		t.Error("only alice's todos should be deleted")
+	}
+}
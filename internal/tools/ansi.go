@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// ansiClearRe 清屏或终端复位，之前的画面已被覆盖
+	ansiClearRe = regexp.MustCompile(`\x1b\[[23]J|\x1bc`)
+	// ansiEscapeRe CSI、OSC、字符集切换等转义序列
+	ansiEscapeRe = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[()][0-9A-Za-z]|\x1b[@-Z\\-_=>78]`)
+)
+
+// cleanTerminalOutput 把终端输出转换为纯文本：只保留最后一次清屏后的画面，
+// 去掉转义序列，按回车和退格覆盖行内内容（如进度条），统一换行为 \n
+func cleanTerminalOutput(s string) string {
+	if !strings.ContainsAny(s, "\x1b\r\b") {
+		return s
+	}
+	if locs := ansiClearRe.FindAllStringIndex(s, -1); len(locs) > 0 {
+		s = s[locs[len(locs)-1][1]:]
+	}
+	s = ansiEscapeRe.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.ContainsAny(line, "\r\b") {
+			lines[i] = overstrike(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// overstrike 模拟终端在一行内的回车和退格
+func overstrike(line string) string {
+	var buf []rune
+	col := 0
+	for _, r := range line {
+		switch r {
+		case '\r':
+			col = 0
+		case '\b':
+			if col > 0 {
+				col--
+			}
+		default:
+			if col < len(buf) {
+				buf[col] = r
+			} else {
+				buf = append(buf, r)
+			}
+			col++
+		}
+	}
+	return string(buf)
+}
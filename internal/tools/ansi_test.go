@@ -0,0 +1,29 @@
+package tools
+
+import "testing"
+
+func TestCleanTerminalOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "hello\nworld", "hello\nworld"},
+		{"colors", "\x1b[1;31merror\x1b[0m: failed\r\n", "error: failed\n"},
+		{"progress", "downloading 10%\rdownloading 50%\rdownloading 100%\r\ndone", "downloading 100%\ndone"},
+		{"shorter overwrite", "abcdef\rxy", "xycdef"},
+		{"backspace", "ab\bc", "ac"},
+		{"clear screen", "old frame\x1b[H\x1b[2Jnew frame", "new frame"},
+		{"title", "\x1b]0;user@host: ~\x07prompt$ ", "prompt$ "},
+		{"charset and keypad", "\x1b(B\x1b=text\x1b>", "text"},
+		{"erase line", "50%\x1b[K\r100%\x1b[K", "100%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanTerminalOutput(tt.in); got != tt.want {
+				t.Errorf("cleanTerminalOutput(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,504 @@
+package tools
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// archiveMaxBytes 打包/解压的总大小上限
+	archiveMaxBytes = 200 * 1024 * 1024
+	// archiveMaxEntries 解压的文件数上限
+	archiveMaxEntries = 10000
+)
+
+// archiveFormat 根据扩展名判断压缩格式
+func archiveFormat(path string) (string, error) {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip", nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz", nil
+	}
+	return "", fmt.Errorf("unsupported archive format: %s (use .zip, .tar.gz or .tgz)", filepath.Base(path))
+}
+
+// archiveEntryPath 校验压缩包内的路径，防止 zip-slip
+func archiveEntryPath(dest, name string) (string, error) {
+	name = filepath.FromSlash(name)
+	if filepath.IsAbs(name) || strings.HasPrefix(name, `\`) {
+		return "", fmt.Errorf("illegal path in archive: %s", name)
+	}
+	target := filepath.Join(dest, name)
+	rel, err := filepath.Rel(dest, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("illegal path in archive: %s", name)
+	}
+	return target, nil
+}
+
+// ArchiveTool 压缩/解压工具
+type ArchiveTool struct {
+	manager *Manager
+}
+
+func (t *ArchiveTool) Name() string {
+	return "archive"
+}
+
+func (t *ArchiveTool) Description() string {
+	return "创建或解压 zip / tar.gz 压缩包，格式由文件扩展名决定。"
+}
+
+func (t *ArchiveTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"workspace": t.manager.workspaceParam(),
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"create", "extract"},
+				"description": "create 打包，extract 解压",
+			},
+			"archive": map[string]interface{}{
+				"type":        "string",
+				"description": "压缩包路径，如 logs.zip、backup.tar.gz",
+			},
+			"sources": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "create 时要打包的文件或目录",
+			},
+			"destination": map[string]interface{}{
+				"type":        "string",
+				"description": "extract 的目标目录，默认为压缩包所在目录下的同名目录",
+			},
+			"overwrite": map[string]interface{}{
+				"type":        "boolean",
+				"description": "目标已存在时是否覆盖",
+			},
+		},
+		"required": []string{"action", "archive"},
+	}
+}
+
+func (t *ArchiveTool) Execute(args map[string]interface{}) (string, error) {
+	action, _ := args["action"].(string)
+	archive, _ := args["archive"].(string)
+	if archive == "" {
+		return "", fmt.Errorf("archive is required")
+	}
+
+	format, err := archiveFormat(archive)
+	if err != nil {
+		return "", err
+	}
+	archivePath, err := t.manager.resolvePath(args, archive, action == "create")
+	if err != nil {
+		return "", err
+	}
+	if err := t.manager.enforcePolicy(t.Name(), args, "", archivePath); err != nil {
+		return "", err
+	}
+	overwrite, _ := args["overwrite"].(bool)
+
+	switch action {
+	case "create":
+		return t.create(archivePath, format, args, overwrite)
+	case "extract":
+		return t.extract(archivePath, format, args, overwrite)
+	}
+	return "", fmt.Errorf("unknown action: %s", action)
+}
+
+// archiveFile 待打包的文件
+type archiveFile struct {
+	path string
+	name string
+	info fs.FileInfo
+}
+
+// collect 收集要打包的文件，跳过符号链接和压缩包本身
+func (t *ArchiveTool) collect(args map[string]interface{}, archivePath string, sources []string) ([]archiveFile, error) {
+	var files []archiveFile
+	var total int64
+	for _, source := range sources {
+		root, err := t.manager.resolvePath(args, source, false)
+		if err != nil {
+			return nil, err
+		}
+		base := filepath.Dir(root)
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if path == archivePath {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && !info.Mode().IsRegular() {
+				return nil
+			}
+			total += info.Size()
+			if total > archiveMaxBytes {
+				return fmt.Errorf("sources exceed %d MB", archiveMaxBytes/1024/1024)
+			}
+			name, err := filepath.Rel(base, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, archiveFile{path: path, name: filepath.ToSlash(name), info: info})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", source, err)
+		}
+	}
+	return files, nil
+}
+
+func (t *ArchiveTool) create(archivePath, format string, args map[string]interface{}, overwrite bool) (string, error) {
+	var sources []string
+	switch v := args["sources"].(type) {
+	case string:
+		sources = []string{v}
+	case []interface{}:
+		for _, s := range v {
+			if str, ok := s.(string); ok && str != "" {
+				sources = append(sources, str)
+			}
+		}
+	}
+	if len(sources) == 0 {
+		return "", fmt.Errorf("sources is required for create")
+	}
+
+	if _, err := os.Stat(archivePath); err == nil && !overwrite {
+		return "", fmt.Errorf("archive already exists: %s，设置 overwrite=true 来覆盖", t.manager.relPath(archivePath))
+	}
+
+	files, err := t.collect(args, archivePath, sources)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	tmp := archivePath + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return "", fmt.Errorf("failed to create archive: %w", err)
+	}
+
+	if format == "zip" {
+		err = writeZip(out, files)
+	} else {
+		err = writeTarGz(out, files)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to create archive: %w", err)
+	}
+	if err := os.Rename(tmp, archivePath); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to create archive: %w", err)
+	}
+
+	info, _ := os.Stat(archivePath)
+	return fmt.Sprintf("Created %s (%d entries, %d bytes)", t.manager.relPath(archivePath), len(files), info.Size()), nil
+}
+
+func writeZip(w io.Writer, files []archiveFile) error {
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		header, err := zip.FileInfoHeader(f.info)
+		if err != nil {
+			return err
+		}
+		header.Name = f.name
+		if f.info.IsDir() {
+			header.Name += "/"
+		} else {
+			header.Method = zip.Deflate
+		}
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if f.info.IsDir() {
+			continue
+		}
+		if err := copyFromFile(fw, f.path); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeTarGz(w io.Writer, files []archiveFile) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for _, f := range files {
+		header, err := tar.FileInfoHeader(f.info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = f.name
+		if f.info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if f.info.IsDir() {
+			continue
+		}
+		if err := copyFromFile(tw, f.path); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func copyFromFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// extractor 解压状态，统计大小和数量
+type extractor struct {
+	manager   *Manager
+	args      map[string]interface{}
+	dest      string
+	overwrite bool
+	total     int64
+	entries   int
+}
+
+// file 写入一个解压出的文件，超过总大小上限时返回错误
+func (e *extractor) file(name string, mode fs.FileMode, r io.Reader) error {
+	target, err := e.entry(name)
+	if err != nil {
+		return err
+	}
+	if err := e.mkdirs(filepath.Dir(target)); err != nil {
+		return err
+	}
+	if info, err := os.Lstat(target); err == nil {
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			return fmt.Errorf("refusing to overwrite symlink: %s", name)
+		case info.IsDir():
+			return fmt.Errorf("destination is a directory: %s", name)
+		case !e.overwrite:
+			return fmt.Errorf("file already exists: %s，设置 overwrite=true 来覆盖", name)
+		}
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+
+	// O_EXCL 保证不会跟随检查之后才出现的符号链接
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm()|0600)
+	if err != nil {
+		return err
+	}
+	remaining := archiveMaxBytes - e.total
+	n, err := io.Copy(out, io.LimitReader(r, remaining+1))
+	e.total += n
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if n > remaining {
+		return fmt.Errorf("extracted size exceeds %d MB", archiveMaxBytes/1024/1024)
+	}
+	return nil
+}
+
+// dir 创建一个解压出的目录
+func (e *extractor) dir(name string) error {
+	target, err := e.entry(name)
+	if err != nil {
+		return err
+	}
+	return e.mkdirs(target)
+}
+
+// entry 计数并校验条目路径，按调用者的工作区、个人目录和只读限制解析
+func (e *extractor) entry(name string) (string, error) {
+	e.entries++
+	if e.entries > archiveMaxEntries {
+		return "", fmt.Errorf("archive has more than %d entries", archiveMaxEntries)
+	}
+	target, err := archiveEntryPath(e.dest, name)
+	if err != nil {
+		return "", err
+	}
+	return e.manager.resolvePath(e.args, target, true)
+}
+
+// mkdirs 从解压目录逐级创建 dir，已有的符号链接一律拒绝，避免写到链接指向的位置
+func (e *extractor) mkdirs(dir string) error {
+	rel, err := filepath.Rel(e.dest, dir)
+	if err != nil {
+		return err
+	}
+	path := e.dest
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if part == "." || part == "" {
+			continue
+		}
+		path = filepath.Join(path, part)
+		info, err := os.Lstat(path)
+		switch {
+		case os.IsNotExist(err):
+			if err := os.Mkdir(path, 0755); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case info.Mode()&fs.ModeSymlink != 0:
+			return fmt.Errorf("refusing to follow symlink: %s", e.manager.relPath(path))
+		case !info.IsDir():
+			return fmt.Errorf("not a directory: %s", e.manager.relPath(path))
+		}
+	}
+	return nil
+}
+
+func (t *ArchiveTool) extract(archivePath, format string, args map[string]interface{}, overwrite bool) (string, error) {
+	destination, _ := args["destination"].(string)
+	if destination == "" {
+		name := filepath.Base(archivePath)
+		for _, ext := range []string{".zip", ".tar.gz", ".tgz"} {
+			if strings.HasSuffix(strings.ToLower(name), ext) {
+				name = name[:len(name)-len(ext)]
+				break
+			}
+		}
+		destination = filepath.Join(filepath.Dir(archivePath), name)
+	}
+	dest, err := t.manager.resolvePath(args, destination, true)
+	if err != nil {
+		return "", err
+	}
+	if err := t.manager.enforcePolicy(t.Name(), args, "", dest); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	e := &extractor{manager: t.manager, args: args, dest: dest, overwrite: overwrite}
+	var skipped int
+	if format == "zip" {
+		skipped, err = e.zip(archivePath)
+	} else {
+		skipped, err = e.tarGz(archivePath)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to extract: %w", err)
+	}
+
+	result := fmt.Sprintf("Extracted %s to %s (%d entries, %d bytes)", t.manager.relPath(archivePath), t.manager.relPath(dest), e.entries, e.total)
+	if skipped > 0 {
+		result += fmt.Sprintf(", skipped %d links/special files", skipped)
+	}
+	return result, nil
+}
+
+func (e *extractor) zip(path string) (int, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return 0, err
+	}
+	defer zr.Close()
+
+	skipped := 0
+	for _, f := range zr.File {
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			if err := e.dir(f.Name); err != nil {
+				return skipped, err
+			}
+		case mode.IsRegular():
+			rc, err := f.Open()
+			if err != nil {
+				return skipped, err
+			}
+			err = e.file(f.Name, mode, rc)
+			rc.Close()
+			if err != nil {
+				return skipped, err
+			}
+		default:
+			skipped++
+		}
+	}
+	return skipped, nil
+}
+
+func (e *extractor) tarGz(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return 0, err
+	}
+	defer gr.Close()
+
+	skipped := 0
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return skipped, nil
+		}
+		if err != nil {
+			return skipped, err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := e.dir(header.Name); err != nil {
+				return skipped, err
+			}
+		case tar.TypeReg:
+			if err := e.file(header.Name, fs.FileMode(header.Mode), tr); err != nil {
+				return skipped, err
+			}
+		default:
+			skipped++
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestArchiveTool(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	workDir := t.TempDir()
+	m, err := NewManager(Config{WorkDir: workDir}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := &ArchiveTool{manager: m}
+
+	if err := os.MkdirAll(filepath.Join(workDir, "logs/old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{"logs/app.log": "started\n", "logs/old/app.1.log": "rotated\n"}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(workDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, archive := range []string{"logs.zip", "out/logs.tar.gz"} {
+		t.Run(archive, func(t *testing.T) {
+			if _, err := tool.Execute(map[string]interface{}{"action": "create", "archive": archive, "sources": []interface{}{"logs"}}); err != nil {
+				t.Fatalf("create error = %v", err)
+			}
+			if _, err := tool.Execute(map[string]interface{}{"action": "create", "archive": archive, "sources": "logs"}); err == nil {
+				t.Error("existing archive without overwrite should fail")
+			}
+
+			dest := "extracted/" + strings.ReplaceAll(archive, "/", "_")
+			if _, err := tool.Execute(map[string]interface{}{"action": "extract", "archive": archive, "destination": dest}); err != nil {
+				t.Fatalf("extract error = %v", err)
+			}
+			for name, content := range files {
+				data, err := os.ReadFile(filepath.Join(workDir, dest, name))
+				if err != nil || string(data) != content {
+					t.Errorf("%s = %q, %v", name, data, err)
+				}
+			}
+			if _, err := tool.Execute(map[string]interface{}{"action": "extract", "archive": archive, "destination": dest}); err == nil {
+				t.Error("extracting over existing files without overwrite should fail")
+			}
+		})
+	}
+
+	// zip-slip
+	evil, err := os.Create(filepath.Join(workDir, "evil.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(evil)
+	w, _ := zw.Create("../../escaped.txt")
+	w.Write([]byte("pwned"))
+	zw.Close()
+	evil.Close()
+
+	if _, err := tool.Execute(map[string]interface{}{"action": "extract", "archive": "evil.zip"}); err == nil || !strings.Contains(err.Error(), "illegal path") {
+		t.Errorf("zip-slip error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(workDir), "escaped.txt")); err == nil {
+		t.Error("zip-slip entry was written outside destination")
+	}
+
+	// 解压目录中已有的符号链接不能被跟随，即使指向工作区内
+	outside := t.TempDir()
+	victim := filepath.Join(workDir, "victim.txt")
+	if err := os.WriteFile(victim, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	linkDest := filepath.Join(workDir, "linked")
+	if err := os.MkdirAll(linkDest, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(linkDest, "dir")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(victim, filepath.Join(linkDest, "victim.txt")); err != nil {
+		t.Fatal(err)
+	}
+	linked, err := os.Create(filepath.Join(workDir, "linked.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw = zip.NewWriter(linked)
+	w, _ = zw.Create("dir/new.txt")
+	w.Write([]byte("pwned"))
+	w, _ = zw.Create("victim.txt")
+	w.Write([]byte("pwned"))
+	zw.Close()
+	linked.Close()
+
+	if _, err := tool.Execute(map[string]interface{}{"action": "extract", "archive": "linked.zip", "destination": "linked", "overwrite": true}); err == nil || !strings.Contains(err.Error(), "symlink") {
+		t.Errorf("symlinked directory error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new.txt")); err == nil {
+		t.Error("entry was written through a symlinked directory")
+	}
+	os.Remove(filepath.Join(linkDest, "dir"))
+	if _, err := tool.Execute(map[string]interface{}{"action": "extract", "archive": "linked.zip", "destination": "linked", "overwrite": true}); err == nil || !strings.Contains(err.Error(), "symlink") {
+		t.Errorf("symlinked file error = %v", err)
+	}
+	if data, _ := os.ReadFile(victim); string(data) != "original" {
+		t.Errorf("symlink target was overwritten: %q", data)
+	}
+
+	if _, err := tool.Execute(map[string]interface{}{"action": "create", "archive": "logs.rar", "sources": "logs"}); err == nil {
+		t.Error("unsupported format should be rejected")
+	}
+	if _, err := tool.Execute(map[string]interface{}{"action": "create", "archive": "../logs.zip", "sources": "logs"}); err == nil {
+		t.Error("archive outside workDir should be rejected")
+	}
+}
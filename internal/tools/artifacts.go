@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// ArtifactsDirName 过长工具结果的完整输出目录，位于工作目录下
+	ArtifactsDirName = ".mujibot-artifacts"
+	// defaultMaxResultChars 工具结果默认字符上限
+	defaultMaxResultChars = 16000
+	// artifactsMaxFiles 保留的输出文件数
+	artifactsMaxFiles = 50
+)
+
+var artifactNameRe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// limitResult 工具结果超过上限时保留首尾预览，完整输出保存到 ArtifactsDirName 供按行读取
+func (m *Manager) limitResult(name string, args map[string]interface{}, result string) string {
+	max := m.maxResultChars
+	if max <= 0 {
+		max = defaultMaxResultChars
+	}
+	runes := []rune(result)
+	if len(runes) <= max {
+		return result
+	}
+
+	head := cutAtLine(string(runes[:max*2/3]), true)
+	tail := cutAtLine(string(runes[len(runes)-max/3:]), false)
+	totalLines := strings.Count(result, "\n") + 1
+	headLines := strings.Count(head, "\n") + 1
+	tailStart := totalLines - strings.Count(tail, "\n")
+
+	summary := fmt.Sprintf("output truncated: %d characters, %d lines", len(runes), totalLines)
+	if headLines+1 <= tailStart-1 {
+		summary += fmt.Sprintf("; lines %d-%d omitted", headLines+1, tailStart-1)
+	}
+
+	var note string
+	if m.isArtifact(args) {
+		// 读取的就是输出文件本身，不再生成新文件
+		note = fmt.Sprintf("[%s, read a smaller start_line/end_line range]", summary)
+	} else if path, err := m.saveArtifact(m.homeDir(callerArg(args)), name, result); err != nil {
+		m.log.Warn("failed to save tool output", "tool", name, "error", err)
+		note = fmt.Sprintf("[%s]", summary)
+	} else {
+		note = fmt.Sprintf("[%s. Full output saved to %s, use read_file with start_line/end_line to read it]", summary, m.relPath(path))
+	}
+
+	return head + "\n...\n" + tail + "\n" + note
+}
+
+// cutAtLine 在换行处截断预览，避免半行；keepHead 为 true 时保留开头
+func cutAtLine(s string, keepHead bool) string {
+	if keepHead {
+		if i := strings.LastIndexByte(s, '\n'); i > len(s)/2 {
+			return s[:i]
+		}
+		return s
+	}
+	if i := strings.IndexByte(s, '\n'); i >= 0 && i < len(s)/2 {
+		return s[i+1:]
+	}
+	return s
+}
+
+// isArtifact 判断工具参数中的 path 是否指向输出文件目录
+func (m *Manager) isArtifact(args map[string]interface{}) bool {
+	path, _ := args["path"].(string)
+	if path == "" {
+		return false
+	}
+	safePath, err := m.resolvePath(args, path, false)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(filepath.Join(m.homeDir(callerArg(args)), ArtifactsDirName), safePath)
+	return err == nil && !strings.HasPrefix(rel, "..")
+}
+
+// saveArtifact 在调用者目录下保存完整输出并清理旧文件
+func (m *Manager) saveArtifact(home, name, content string) (string, error) {
+	dir := filepath.Join(home, ArtifactsDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	base := fmt.Sprintf("%s-%03d-%s", now.Format("20060102-150405"), now.Nanosecond()/1e6, artifactNameRe.ReplaceAllString(name, "_"))
+	path := filepath.Join(dir, base+".txt")
+	for i := 2; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d.txt", base, i))
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", err
+	}
+
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > artifactsMaxFiles {
+		// 文件名以时间开头，按名称排序即按时间排序
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+		for _, e := range entries[:len(entries)-artifactsMaxFiles] {
+			os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+	return path, nil
+}
@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+// echoTool 原样返回 text 参数
+type echoTool struct{}
+
+func (echoTool) Name() string                       { return "echo" }
+func (echoTool) Description() string                { return "" }
+func (echoTool) Parameters() map[string]interface{} { return nil }
+func (echoTool) Execute(args map[string]interface{}) (string, error) {
+	text, _ := args["text"].(string)
+	return text, nil
+}
+
+func TestLimitResult(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	workDir := t.TempDir()
+	m, err := NewManager(Config{WorkDir: workDir, MaxResultChars: 300}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Register(echoTool{})
+	ctx := context.Background()
+
+	if result, _ := m.Execute(ctx, "echo", map[string]interface{}{"text": "short"}); result != "short" {
+		t.Errorf("short result = %q", result)
+	}
+
+	var lines []string
+	for i := 1; i <= 100; i++ {
+		lines = append(lines, fmt.Sprintf("line %03d", i))
+	}
+	full := strings.Join(lines, "\n")
+
+	result, err := m.Execute(ctx, "echo", map[string]interface{}{"text": full})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(result, "line 001\n") || !strings.Contains(result, "line 100\n[output truncated") {
+		t.Errorf("preview should keep head and tail:\n%s", result)
+	}
+	if len([]rune(result)) > 600 {
+		t.Errorf("truncated result too long: %d", len([]rune(result)))
+	}
+
+	match := regexp.MustCompile(`lines (\d+)-(\d+) omitted. Full output saved to (\S+),`).FindStringSubmatch(result)
+	if match == nil {
+		t.Fatalf("missing artifact note:\n%s", result)
+	}
+	if !strings.Contains(result, fmt.Sprintf("line %03d\n...\nline %03d\n", mustAtoi(t, match[1])-1, mustAtoi(t, match[2])+1)) {
+		t.Errorf("omitted range %s-%s does not follow the head:\n%s", match[1], match[2], result)
+	}
+	saved, err := os.ReadFile(filepath.Join(workDir, match[3]))
+	if err != nil || string(saved) != full {
+		t.Fatalf("artifact = %v, content matches: %v", err, string(saved) == full)
+	}
+
+	// 按行读取输出文件，超长时不再生成新文件
+	result, err = m.Execute(ctx, "read_file", map[string]interface{}{"path": match[3], "start_line": float64(50), "end_line": float64(52)})
+	if err != nil || !strings.Contains(result, "line 050") || !strings.Contains(result, "line 052") {
+		t.Errorf("read artifact range = %q, %v", result, err)
+	}
+	result, _ = m.Execute(ctx, "read_file", map[string]interface{}{"path": match[3], "start_line": float64(1), "end_line": float64(100)})
+	if !strings.Contains(result, "read a smaller start_line/end_line range") {
+		t.Errorf("re-reading the whole artifact = %q", result)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(workDir, ArtifactsDirName)); len(entries) != 1 {
+		t.Errorf("artifacts = %d, want 1", len(entries))
+	}
+
+	// 旧文件按数量清理
+	for i := 0; i < artifactsMaxFiles+5; i++ {
+		if _, err := m.saveArtifact(m.workDir, "echo", "x"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if entries, _ := os.ReadDir(filepath.Join(workDir, ArtifactsDirName)); len(entries) != artifactsMaxFiles {
+		t.Errorf("artifacts after pruning = %d, want %d", len(entries), artifactsMaxFiles)
+	}
+}
+
+func mustAtoi(t *testing.T, s string) int {
+	t.Helper()
+	var n int
+	if _, err := fmt.Sscan(s, &n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
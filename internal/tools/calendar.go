@@ -0,0 +1,453 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/httpclient"
+)
+
+const (
+	googleTokenURL = "https://oauth2.googleapis.com/token"
+
+	// maxCalendarDays calendar_list 一次最多查询的天数
+	maxCalendarDays = 31
+	// defaultEventMinutes 未指定结束时间时事件的时长
+	defaultEventMinutes = 60
+)
+
+// CalendarConfig CalDAV日历配置。Google 日历使用 CalDAV 地址并配置 OAuth 刷新令牌
+type CalendarConfig struct {
+	Enabled  bool
+	URL      string // 日历集合地址，如 https://cloud.example.com/remote.php/dav/calendars/me/personal/
+	Username string
+	Password string
+	Timezone string // 用户资料未设置时区时使用，默认服务器时区
+
+	// 设置 GoogleRefreshToken 时用 OAuth 访问令牌代替用户名密码
+	GoogleClientID     string
+	GoogleClientSecret string
+	GoogleRefreshToken string
+}
+
+// calendarEvent 日历事件，全天事件的 End 为结束日期的次日零点
+type calendarEvent struct {
+	UID         string
+	Summary     string
+	Location    string
+	Description string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+}
+
+// calendarClient CalDAV客户端，calendar_list 和 calendar_add 共用
+type calendarClient struct {
+	cfg      CalendarConfig
+	tokenURL string // 测试时可替换
+
+	mu           sync.Mutex
+	token        string
+	tokenExpires time.Time
+}
+
+// NewCalendarTools 创建 calendar_list 和 calendar_add 工具
+func NewCalendarTools(m *Manager, cfg CalendarConfig) []Tool {
+	c := &calendarClient{cfg: cfg, tokenURL: googleTokenURL}
+	return []Tool{
+		&CalendarListTool{manager: m, client: c},
+		&CalendarAddTool{manager: m, client: c},
+	}
+}
+
+// CalendarListTool 列出日历事件
+type CalendarListTool struct {
+	manager *Manager
+	client  *calendarClient
+}
+
+func (t *CalendarListTool) Name() string {
+	return "calendar_list"
+}
+
+func (t *CalendarListTool) Description() string {
+	return "列出日历中某天起若干天内的事件（CalDAV），重复事件已展开。"
+}
+
+func (t *CalendarListTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"date": map[string]interface{}{
+				"type":        "string",
+				"description": "开始日期 YYYY-MM-DD，默认今天",
+			},
+			"days": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("查询天数（1-%d，默认1）", maxCalendarDays),
+			},
+		},
+	}
+}
+
+func (t *CalendarListTool) Execute(args map[string]interface{}) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+func (t *CalendarListTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (string, error) {
+	loc, err := t.client.location(t.manager.callerProfile(ctx).Timezone)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().In(loc)
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if s, _ := args["date"].(string); strings.TrimSpace(s) != "" {
+		if start, err = time.ParseInLocation("2006-01-02", strings.TrimSpace(s), loc); err != nil {
+			return "", fmt.Errorf("invalid date %q, use YYYY-MM-DD", s)
+		}
+	}
+	days := 1
+	if d, ok := args["days"].(float64); ok && d >= 1 {
+		days = int(d)
+	}
+	if days > maxCalendarDays {
+		days = maxCalendarDays
+	}
+	end := start.AddDate(0, 0, days)
+
+	events, err := t.client.events(start, end, loc)
+	if err != nil {
+		return "", err
+	}
+	last := end.AddDate(0, 0, -1).Format("2006-01-02")
+	if len(events) == 0 {
+		return fmt.Sprintf("No events from %s to %s.", start.Format("2006-01-02"), last), nil
+	}
+	return formatEvents(events, loc), nil
+}
+
+// CalendarAddTool 添加日历事件
+type CalendarAddTool struct {
+	manager *Manager
+	client  *calendarClient
+}
+
+func (t *CalendarAddTool) Name() string {
+	return "calendar_add"
+}
+
+func (t *CalendarAddTool) Description() string {
+	return "在日历中添加事件（CalDAV）。只给日期时为全天事件。"
+}
+
+func (t *CalendarAddTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "事件标题",
+			},
+			"start": map[string]interface{}{
+				"type":        "string",
+				"description": "开始时间 YYYY-MM-DD HH:MM（用户时区），只给 YYYY-MM-DD 时为全天事件",
+			},
+			"end": map[string]interface{}{
+				"type":        "string",
+				"description": "结束时间，格式同 start，可选",
+			},
+			"duration_minutes": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("未指定 end 时的时长（分钟），默认%d", defaultEventMinutes),
+			},
+			"location": map[string]interface{}{
+				"type":        "string",
+				"description": "地点，可选",
+			},
+			"description": map[string]interface{}{
+				"type":        "string",
+				"description": "备注，可选",
+			},
+		},
+		"required": []string{"title", "start"},
+	}
+}
+
+func (t *CalendarAddTool) Execute(args map[string]interface{}) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+func (t *CalendarAddTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (string, error) {
+	str := func(key string) string {
+		s, _ := args[key].(string)
+		return strings.TrimSpace(s)
+	}
+	title := str("title")
+	if title == "" {
+		return "", fmt.Errorf("title is required")
+	}
+	loc, err := t.client.location(t.manager.callerProfile(ctx).Timezone)
+	if err != nil {
+		return "", err
+	}
+
+	ev := calendarEvent{Summary: title, Location: str("location"), Description: str("description")}
+	if ev.Start, ev.AllDay, err = parseEventTime(str("start"), loc); err != nil {
+		return "", err
+	}
+	if s := str("end"); s != "" {
+		var allDay bool
+		if ev.End, allDay, err = parseEventTime(s, loc); err != nil {
+			return "", err
+		}
+		if allDay != ev.AllDay {
+			return "", fmt.Errorf("start and end must both be dates or both be date and time")
+		}
+		if ev.AllDay {
+			// 结束日期包含在内，iCalendar 的 DTEND 为次日
+			ev.End = ev.End.AddDate(0, 0, 1)
+		}
+	} else if ev.AllDay {
+		ev.End = ev.Start.AddDate(0, 0, 1)
+	} else {
+		minutes := defaultEventMinutes
+		if d, ok := args["duration_minutes"].(float64); ok && d > 0 {
+			minutes = int(d)
+		}
+		ev.End = ev.Start.Add(time.Duration(minutes) * time.Minute)
+	}
+	if !ev.End.After(ev.Start) {
+		return "", fmt.Errorf("end must be after start")
+	}
+
+	if err := t.client.add(&ev); err != nil {
+		return "", err
+	}
+	return "Event added:\n" + formatEvents([]calendarEvent{ev}, loc), nil
+}
+
+// parseEventTime 解析事件时间，只有日期时为全天
+func parseEventTime(s string, loc *time.Location) (time.Time, bool, error) {
+	if s == "" {
+		return time.Time{}, false, fmt.Errorf("start is required")
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, loc); err == nil {
+		return t, true, nil
+	}
+	t, err := parseTime(s, loc)
+	return t, false, err
+}
+
+// formatEvents 按日期分组列出事件
+func formatEvents(events []calendarEvent, loc *time.Location) string {
+	var sb strings.Builder
+	day := ""
+	for _, ev := range events {
+		start := ev.Start.In(loc)
+		if d := start.Format("2006-01-02 (Mon)"); d != day {
+			if day != "" {
+				sb.WriteString("\n")
+			}
+			sb.WriteString(d + "\n")
+			day = d
+		}
+		if ev.AllDay {
+			sb.WriteString("  all day")
+			if n := int(ev.End.Sub(ev.Start).Hours()/24 + 0.5); n > 1 {
+				fmt.Fprintf(&sb, " (%d days, until %s)", n, ev.End.AddDate(0, 0, -1).Format("2006-01-02"))
+			}
+		} else {
+			end := ev.End.In(loc)
+			layout := "15:04"
+			if end.Format("2006-01-02") != start.Format("2006-01-02") {
+				layout = "2006-01-02 15:04"
+			}
+			fmt.Fprintf(&sb, "  %s-%s", start.Format("15:04"), end.Format(layout))
+		}
+		sb.WriteString(" " + ev.Summary)
+		if ev.Location != "" {
+			sb.WriteString(" @ " + ev.Location)
+		}
+		if ev.Description != "" {
+			sb.WriteString(" — " + strings.ReplaceAll(ev.Description, "\n", " "))
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// location 用户资料的时区优先，其次是配置的时区
+func (c *calendarClient) location(profileTZ string) (*time.Location, error) {
+	tz := profileTZ
+	if tz == "" {
+		tz = c.cfg.Timezone
+	}
+	return loadLocation(tz)
+}
+
+// events 用 calendar-query REPORT 查询时间范围内的事件，由服务器展开重复事件
+func (c *calendarClient) events(start, end time.Time, loc *time.Location) ([]calendarEvent, error) {
+	rangeAttrs := fmt.Sprintf(`start="%s" end="%s"`, start.UTC().Format(icsUTCLayout), end.UTC().Format(icsUTCLayout))
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop>
+    <C:calendar-data><C:expand ` + rangeAttrs + `/></C:calendar-data>
+  </D:prop>
+  <C:filter>
+    <C:comp-filter name="VCALENDAR">
+      <C:comp-filter name="VEVENT"><C:time-range ` + rangeAttrs + `/></C:comp-filter>
+    </C:comp-filter>
+  </C:filter>
+</C:calendar-query>`
+
+	resp, err := c.do("REPORT", c.cfg.URL, map[string]string{
+		"Depth":        "1",
+		"Content-Type": "application/xml; charset=utf-8",
+	}, []byte(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4*1024*1024))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read calendar response: %w", err)
+	}
+	if resp.StatusCode != http.StatusMultiStatus && resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("calendar server returned status %d", resp.StatusCode)
+	}
+
+	var ms struct {
+		Responses []struct {
+			Propstats []struct {
+				CalendarData string `xml:"prop>calendar-data"`
+			} `xml:"propstat"`
+		} `xml:"response"`
+	}
+	if err := xml.Unmarshal(data, &ms); err != nil {
+		return nil, fmt.Errorf("failed to parse calendar response: %w", err)
+	}
+
+	var events []calendarEvent
+	for _, r := range ms.Responses {
+		for _, ps := range r.Propstats {
+			for _, ev := range parseICS(ps.CalendarData, loc) {
+				// 服务器不支持 expand 时可能返回范围外的主事件
+				if ev.Start.Before(end) && ev.End.After(start) {
+					events = append(events, ev)
+				}
+			}
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+	return events, nil
+}
+
+// add 用 PUT 创建事件，If-None-Match 防止覆盖已有事件
+func (c *calendarClient) add(ev *calendarEvent) error {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	ev.UID = hex.EncodeToString(buf) + "@mujibot"
+
+	u := strings.TrimSuffix(c.cfg.URL, "/") + "/" + url.PathEscape(ev.UID) + ".ics"
+	resp, err := c.do(http.MethodPut, u, map[string]string{
+		"Content-Type":  "text/calendar; charset=utf-8",
+		"If-None-Match": "*",
+	}, []byte(ev.ics(time.Now())))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("calendar server returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// do 发送带认证的请求
+func (c *calendarClient) do(method, u string, headers map[string]string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if c.cfg.GoogleRefreshToken != "" {
+		token, err := c.accessToken()
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if c.cfg.Username != "" {
+		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+
+	resp, err := httpclient.New(15 * time.Second).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("calendar request failed: %w", err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		resp.Body.Close()
+		c.mu.Lock()
+		c.token = ""
+		c.mu.Unlock()
+		return nil, fmt.Errorf("calendar server rejected the credentials (status %d)", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// accessToken 用刷新令牌换取 Google 访问令牌，过期前复用
+func (c *calendarClient) accessToken() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.tokenExpires) {
+		return c.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("client_id", c.cfg.GoogleClientID)
+	form.Set("client_secret", c.cfg.GoogleClientSecret)
+	form.Set("refresh_token", c.cfg.GoogleRefreshToken)
+	resp, err := httpclient.New(15*time.Second).PostForm(c.tokenURL, form)
+	if err != nil {
+		return "", fmt.Errorf("google token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var data struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		Error       string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&data); err != nil {
+		return "", fmt.Errorf("failed to parse google token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || data.AccessToken == "" {
+		return "", fmt.Errorf("google token request failed: status %d %s", resp.StatusCode, data.Error)
+	}
+
+	c.token = data.AccessToken
+	// 提前一分钟刷新
+	c.tokenExpires = time.Now().Add(time.Duration(data.ExpiresIn)*time.Second - time.Minute)
+	return c.token, nil
+}
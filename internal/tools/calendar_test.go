@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testCalendarData = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\nUID:a\r\nDTSTART;TZID=Europe/Berlin:20240503T150000\r\nDTEND;TZID=Europe/Berlin:20240503T160000\r\n" +
+	"SUMMARY:Dentist\r\nLOCATION:Main St 5\\, Berlin\r\nDESCRIPTION:bring the\r\n  insurance card\r\n" +
+	"BEGIN:VALARM\r\nACTION:DISPLAY\r\nDESCRIPTION:Reminder\r\nEND:VALARM\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:b\r\nDTSTART;VALUE=DATE:20240503\r\nDTEND;VALUE=DATE:20240504\r\nSUMMARY:Holiday\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:c\r\nDTSTART:20240503T060000Z\r\nDURATION:PT30M\r\nSUMMARY:Standup\r\nEND:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestCalendarTools(t *testing.T) {
+	var mu sync.Mutex
+	var report, put string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "me" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "REPORT":
+			report = string(body)
+			if r.Header.Get("Depth") != "1" {
+				t.Errorf("Depth = %q", r.Header.Get("Depth"))
+			}
+			w.WriteHeader(http.StatusMultiStatus)
+			w.Write([]byte(`<?xml version="1.0"?><d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">` +
+				`<d:response><d:href>/cal/a.ics</d:href><d:propstat><d:prop><cal:calendar-data>` +
+				strings.ReplaceAll(testCalendarData, "&", "&amp;") +
+				`</cal:calendar-data></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response></d:multistatus>`))
+		case http.MethodPut:
+			if r.Header.Get("If-None-Match") != "*" || !strings.HasSuffix(r.URL.Path, ".ics") {
+				t.Errorf("PUT %s If-None-Match %q", r.URL.Path, r.Header.Get("If-None-Match"))
+			}
+			put = string(body)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	cfg := CalendarConfig{Enabled: true, URL: server.URL + "/cal/", Username: "me", Password: "secret", Timezone: "Europe/Berlin"}
+	tools := NewCalendarTools(nil, cfg)
+	list, add := tools[0], tools[1]
+
+	result, err := list.Execute(map[string]interface{}{"date": "2024-05-03"})
+	if err != nil {
+		t.Fatalf("calendar_list error = %v", err)
+	}
+	want := "2024-05-03 (Fri)\n" +
+		"  all day Holiday\n" +
+		"  08:00-08:30 Standup\n" +
+		"  15:00-16:00 Dentist @ Main St 5, Berlin — bring the insurance card"
+	if result != want {
+		t.Errorf("calendar_list = %q, want %q", result, want)
+	}
+	// 柏林夏令时 UTC+2，查询范围按UTC发送
+	if !strings.Contains(report, `<C:time-range start="20240502T220000Z" end="20240503T220000Z"/>`) || !strings.Contains(report, "<C:expand") {
+		t.Errorf("REPORT body = %s", report)
+	}
+
+	result, err = list.Execute(map[string]interface{}{"date": "2024-05-10"})
+	if err != nil || result != "No events from 2024-05-10 to 2024-05-10." {
+		t.Errorf("empty calendar_list = %q, %v", result, err)
+	}
+
+	result, err = add.Execute(map[string]interface{}{"title": "Call; Bob", "start": "2024-05-06 09:30", "duration_minutes": float64(15)})
+	if err != nil {
+		t.Fatalf("calendar_add error = %v", err)
+	}
+	if result != "Event added:\n2024-05-06 (Mon)\n  09:30-09:45 Call; Bob" {
+		t.Errorf("calendar_add = %q", result)
+	}
+	for _, line := range []string{"DTSTART:20240506T073000Z\r\n", "DTEND:20240506T074500Z\r\n", "SUMMARY:Call\\; Bob\r\n", "UID:"} {
+		if !strings.Contains(put, line) {
+			t.Errorf("PUT body missing %q:\n%s", line, put)
+		}
+	}
+
+	if _, err := add.Execute(map[string]interface{}{"title": "Trip", "start": "2024-06-01", "end": "2024-06-03"}); err != nil {
+		t.Fatalf("all-day calendar_add error = %v", err)
+	}
+	if !strings.Contains(put, "DTSTART;VALUE=DATE:20240601\r\n") || !strings.Contains(put, "DTEND;VALUE=DATE:20240604\r\n") {
+		t.Errorf("all-day PUT body:\n%s", put)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"start": "2024-05-06 09:30"},
+		{"title": "x", "start": "tomorrow"},
+		{"title": "x", "start": "2024-05-06 09:30", "end": "2024-05-06 09:00"},
+		{"title": "x", "start": "2024-05-06", "end": "2024-05-07 10:00"},
+	} {
+		if _, err := add.Execute(args); err == nil {
+			t.Errorf("calendar_add(%v) should fail", args)
+		}
+	}
+
+	bad := NewCalendarTools(nil, CalendarConfig{URL: server.URL + "/cal/", Username: "me", Password: "wrong"})
+	if _, err := bad[0].Execute(map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), "credentials") {
+		t.Errorf("wrong password error = %v", err)
+	}
+}
+
+func TestCalendarGoogleToken(t *testing.T) {
+	var tokenRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			tokenRequests++
+			r.ParseForm()
+			if r.Form.Get("refresh_token") != "refresh" || r.Form.Get("grant_type") != "refresh_token" {
+				t.Errorf("token form = %v", r.Form)
+			}
+			w.Write([]byte(`{"access_token":"abc","expires_in":3600}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		w.Write([]byte(`<multistatus xmlns="DAV:"></multistatus>`))
+	}))
+	defer server.Close()
+
+	tools := NewCalendarTools(nil, CalendarConfig{URL: server.URL + "/caldav/", GoogleClientID: "id", GoogleClientSecret: "s", GoogleRefreshToken: "refresh"})
+	tools[0].(*CalendarListTool).client.tokenURL = server.URL + "/token"
+	for i := 0; i < 2; i++ {
+		if _, err := tools[0].Execute(map[string]interface{}{"days": float64(7)}); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Errorf("token requests = %d, want 1", tokenRequests)
+	}
+}
+
+func TestParseICSDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"PT1H30M": 90 * time.Minute,
+		"P1D":     24 * time.Hour,
+		"P1W":     7 * 24 * time.Hour,
+		"P1DT2H":  26 * time.Hour,
+		"bogus":   0,
+	}
+	for in, want := range tests {
+		if got := parseICSDuration(in); got != want {
+			t.Errorf("parseICSDuration(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
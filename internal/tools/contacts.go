@@ -0,0 +1,238 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/HaohanHe/mujibot/internal/memory"
+)
+
+// contactOwner 从context获取通讯录所属用户
+func contactOwner(ctx context.Context) (string, error) {
+	c, ok := CallerFrom(ctx)
+	if !ok {
+		return "", errors.New("contacts tools are only available in chat conversations")
+	}
+	return c.Channel + ":" + c.UserID, nil
+}
+
+// contactFieldParams 联系人字段参数定义
+func contactFieldParams() map[string]interface{} {
+	return map[string]interface{}{
+		"name": map[string]interface{}{
+			"type":        "string",
+			"description": "姓名",
+		},
+		"phone": map[string]interface{}{
+			"type":        "string",
+			"description": "电话",
+		},
+		"email": map[string]interface{}{
+			"type":        "string",
+			"description": "邮箱",
+		},
+		"notes": map[string]interface{}{
+			"type":        "string",
+			"description": "备注，如关系、生日、地址",
+		},
+	}
+}
+
+// validateContactEmail 校验邮箱格式，空字符串视为清空
+func validateContactEmail(email string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return fmt.Errorf("invalid email address: %s", email)
+	}
+	return nil
+}
+
+// formatContact 格式化联系人
+func formatContact(c memory.Contact) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "#%d %s", c.ID, c.Name)
+	if c.Phone != "" {
+		fmt.Fprintf(&sb, "\n  phone: %s", c.Phone)
+	}
+	if c.Email != "" {
+		fmt.Fprintf(&sb, "\n  email: %s", c.Email)
+	}
+	if c.Notes != "" {
+		fmt.Fprintf(&sb, "\n  notes: %s", c.Notes)
+	}
+	return sb.String()
+}
+
+// ContactsAddTool 添加联系人
+type ContactsAddTool struct {
+	manager *Manager
+	book    *memory.ContactBook
+}
+
+func (t *ContactsAddTool) Name() string {
+	return "contacts_add"
+}
+
+func (t *ContactsAddTool) Description() string {
+	return "保存联系人（姓名、电话、邮箱、备注）。用户提供联系方式时使用此工具，而不是 memory_write。"
+}
+
+func (t *ContactsAddTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": contactFieldParams(),
+		"required":   []string{"name"},
+	}
+}
+
+func (t *ContactsAddTool) Execute(args map[string]interface{}) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+func (t *ContactsAddTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (string, error) {
+	owner, err := contactOwner(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var c memory.Contact
+	c.Name, _ = args["name"].(string)
+	c.Phone, _ = args["phone"].(string)
+	c.Email, _ = args["email"].(string)
+	c.Notes, _ = args["notes"].(string)
+	c.Email = strings.TrimSpace(c.Email)
+	if err := validateContactEmail(c.Email); err != nil {
+		return "", err
+	}
+
+	added, err := t.book.Add(owner, c)
+	if err != nil {
+		return "", err
+	}
+	return "Saved contact " + formatContact(*added), nil
+}
+
+// ContactsSearchTool 查找联系人
+type ContactsSearchTool struct {
+	manager *Manager
+	book    *memory.ContactBook
+}
+
+func (t *ContactsSearchTool) Name() string {
+	return "contacts_search"
+}
+
+func (t *ContactsSearchTool) Description() string {
+	return "查找联系人，按姓名、电话、邮箱或备注匹配。询问某人的联系方式时优先使用此工具。"
+}
+
+func (t *ContactsSearchTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "搜索关键词，为空时列出全部联系人",
+			},
+		},
+	}
+}
+
+func (t *ContactsSearchTool) Execute(args map[string]interface{}) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+func (t *ContactsSearchTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (string, error) {
+	owner, err := contactOwner(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query, _ := args["query"].(string)
+	contacts, err := t.book.Search(owner, query)
+	if err != nil {
+		return "", err
+	}
+	if len(contacts) == 0 {
+		return "No matching contacts.", nil
+	}
+
+	lines := make([]string, len(contacts))
+	for i, c := range contacts {
+		lines[i] = formatContact(c)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// ContactsUpdateTool 修改联系人
+type ContactsUpdateTool struct {
+	manager *Manager
+	book    *memory.ContactBook
+}
+
+func (t *ContactsUpdateTool) Name() string {
+	return "contacts_update"
+}
+
+func (t *ContactsUpdateTool) Description() string {
+	return "修改联系人，只更新提供的字段，传空字符串可清空字段。先用 contacts_search 获取编号。"
+}
+
+func (t *ContactsUpdateTool) Parameters() map[string]interface{} {
+	props := contactFieldParams()
+	props["id"] = map[string]interface{}{
+		"type":        "integer",
+		"description": "联系人编号",
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+		"required":   []string{"id"},
+	}
+}
+
+func (t *ContactsUpdateTool) Execute(args map[string]interface{}) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+func (t *ContactsUpdateTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (string, error) {
+	owner, err := contactOwner(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	id, ok := args["id"].(float64)
+	if !ok {
+		return "", fmt.Errorf("id is required")
+	}
+
+	var u memory.ContactUpdate
+	fields := map[string]**string{"name": &u.Name, "phone": &u.Phone, "email": &u.Email, "notes": &u.Notes}
+	for key, field := range fields {
+		if s, ok := args[key].(string); ok {
+			value := strings.TrimSpace(s)
+			*field = &value
+		}
+	}
+	if u.Name == nil && u.Phone == nil && u.Email == nil && u.Notes == nil {
+		return "", fmt.Errorf("nothing to update")
+	}
+	if u.Email != nil {
+		if err := validateContactEmail(*u.Email); err != nil {
+			return "", err
+		}
+	}
+
+	updated, err := t.book.Update(owner, int(id), u)
+	if err != nil {
+		return "", err
+	}
+	return "Updated contact " + formatContact(*updated), nil
+}
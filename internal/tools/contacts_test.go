@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/memory"
+)
+
+func TestContactsTools(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	dir := t.TempDir()
+	book, err := memory.NewContactBook(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewManager(Config{WorkDir: t.TempDir(), Contacts: book}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 没有调用者身份时不可用
+	if _, err := m.Execute(context.Background(), "contacts_search", map[string]interface{}{}); err == nil {
+		t.Error("contacts_search without caller should fail")
+	}
+
+	ctx := WithCaller(context.Background(), Caller{Channel: "telegram", UserID: "1"})
+	if _, err := m.Execute(ctx, "contacts_add", map[string]interface{}{"name": "Alice Smith", "email": "alice@example.com", "notes": "coworker"}); err != nil {
+		t.Fatalf("contacts_add error = %v", err)
+	}
+	if _, err := m.Execute(ctx, "contacts_add", map[string]interface{}{"name": "Ali", "phone": "+1 555 0100"}); err != nil {
+		t.Fatalf("contacts_add error = %v", err)
+	}
+	if _, err := m.Execute(ctx, "contacts_add", map[string]interface{}{"name": "alice smith"}); err == nil {
+		t.Error("duplicate name should be rejected")
+	}
+	if _, err := m.Execute(ctx, "contacts_add", map[string]interface{}{"name": "Bob", "email": "not-an-email"}); err == nil {
+		t.Error("invalid email should be rejected")
+	}
+
+	// 名字匹配优先于备注匹配，前缀匹配优先于包含
+	tests := []struct {
+		query string
+		first string
+		count int
+	}{
+		{"alice", "#1 Alice Smith", 1},
+		{"ali", "#2 Ali", 2},
+		{"coworker", "#1 Alice Smith", 1},
+		{"555", "#2 Ali", 1},
+		{"", "#2 Ali", 2},
+	}
+	for _, tt := range tests {
+		result, err := m.Execute(ctx, "contacts_search", map[string]interface{}{"query": tt.query})
+		if err != nil {
+			t.Fatalf("contacts_search(%q) error = %v", tt.query, err)
+		}
+		if !strings.HasPrefix(result, tt.first) || strings.Count(result, "#") != tt.count {
+			t.Errorf("contacts_search(%q) = %q", tt.query, result)
+		}
+	}
+
+	other := WithCaller(context.Background(), Caller{Channel: "discord", UserID: "1"})
+	if result, _ := m.Execute(other, "contacts_search", map[string]interface{}{"query": "alice"}); result != "No matching contacts." {
+		t.Errorf("other user's contacts_search = %q", result)
+	}
+
+	result, err := m.Execute(ctx, "contacts_update", map[string]interface{}{"id": float64(1), "email": "alice@new.example.com", "notes": ""})
+	if err != nil {
+		t.Fatalf("contacts_update error = %v", err)
+	}
+	if !strings.Contains(result, "alice@new.example.com") || strings.Contains(result, "coworker") {
+		t.Errorf("contacts_update = %q", result)
+	}
+	if _, err := m.Execute(ctx, "contacts_update", map[string]interface{}{"id": float64(2), "name": "Alice Smith"}); err == nil {
+		t.Error("renaming to an existing name should be rejected")
+	}
+	if _, err := m.Execute(other, "contacts_update", map[string]interface{}{"id": float64(1), "phone": "1"}); err == nil {
+		t.Error("other users should not update the contact")
+	}
+
+	// 重新加载后保留修改
+	reloaded, err := memory.NewContactBook(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contacts, err := reloaded.Search("telegram:1", "alice")
+	if err != nil || len(contacts) != 1 || contacts[0].Email != "alice@new.example.com" || contacts[0].Notes != "" {
+		t.Errorf("reloaded Search() = %+v, %v", contacts, err)
+	}
+}
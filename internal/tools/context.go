@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/HaohanHe/mujibot/internal/memory"
+)
+
+// Caller 工具调用者身份，由网关在处理聊天消息时写入context
+type Caller struct {
+	Channel string
+	UserID  string
+	Target  string // 主动推送目标，如 Telegram chat ID
+}
+
+// callerKey context中调用者的键
+type callerKey struct{}
+
+// ContextTool 需要请求context（如调用者身份）的工具
+type ContextTool interface {
+	Tool
+	ExecuteContext(ctx context.Context, args map[string]interface{}) (string, error)
+}
+
+// WithCaller 将调用者写入context
+func WithCaller(ctx context.Context, c Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, c)
+}
+
+// CallerFrom 从context读取调用者
+func CallerFrom(ctx context.Context) (Caller, bool) {
+	if ctx == nil {
+		return Caller{}, false
+	}
+	c, ok := ctx.Value(callerKey{}).(Caller)
+	return c, ok && c.UserID != ""
+}
+
+// callerProfile 读取调用者的用户资料，作为工具参数的默认值；非聊天调用或记忆关闭时返回空资料
+func (m *Manager) callerProfile(ctx context.Context) memory.Profile {
+	c, ok := CallerFrom(ctx)
+	if !ok || m == nil || m.memoryMgr == nil || !m.memoryMgr.IsEnabled() {
+		return memory.Profile{}
+	}
+	return m.memoryMgr.Profile(c.Channel + ":" + c.UserID)
+}
+
+// callerArgKey 工具参数中调用者的保留键。值为 Caller 类型，模型生成的JSON参数无法伪造
+const callerArgKey = "\x00caller"
+
+// withCallerArg 复制参数并写入调用者，供只接收参数的文件工具按用户解析路径
+func withCallerArg(ctx context.Context, args map[string]interface{}) map[string]interface{} {
+	c, ok := CallerFrom(ctx)
+	if !ok {
+		return args
+	}
+	out := make(map[string]interface{}, len(args)+1)
+	for k, v := range args {
+		out[k] = v
+	}
+	out[callerArgKey] = c
+	return out
+}
+
+// callerArg 从工具参数读取调用者
+func callerArg(args map[string]interface{}) Caller {
+	c, _ := args[callerArgKey].(Caller)
+	return c
+}
+
+// contextArgKey 工具参数中请求context的保留键，供只接收参数的工具发送确认请求
+const contextArgKey = "\x00ctx"
+
+// withContextArg 复制参数并写入请求context
+func withContextArg(ctx context.Context, args map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(args)+1)
+	for k, v := range args {
+		out[k] = v
+	}
+	out[contextArgKey] = ctx
+	return out
+}
+
+// contextArg 从工具参数读取请求context，没有时返回 context.Background()
+func contextArg(args map[string]interface{}) context.Context {
+	if ctx, ok := args[contextArgKey].(context.Context); ok {
+		return ctx
+	}
+	return context.Background()
+}
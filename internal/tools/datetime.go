@@ -0,0 +1,441 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/cron"
+)
+
+const (
+	defaultCronRuns = 5
+	maxCronRuns     = 20
+)
+
+// timeLayouts 支持的时间输入格式
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+var (
+	utcOffsetRe = regexp.MustCompile(`^(?i:utc|gmt)?([+-])(\d{1,2})(?::?(\d{2}))?$`)
+	relativeRe  = regexp.MustCompile(`^(?:in\s+)?([+-]?\d+)\s*(minute|min|hour|h|day|d|week|w|month|year|y)s?(\s+ago)?$`)
+)
+
+// DateTimeTool 时区与日期计算工具，完全本地计算
+type DateTimeTool struct {
+	manager *Manager
+
+	// now 返回当前时间，测试时可替换
+	now func() time.Time
+}
+
+func (t *DateTimeTool) Name() string {
+	return "datetime"
+}
+
+func (t *DateTimeTool) Description() string {
+	return "日期时间计算（本地计算，结果确定）。action: now 当前时间；convert 时区转换；diff 计算两个时间的间隔；" +
+		"resolve 解析相对日期（如 next tuesday、tomorrow、in 3 days、2 weeks ago）；cron 解释cron表达式并列出接下来的运行时间。"
+}
+
+func (t *DateTimeTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"now", "convert", "diff", "resolve", "cron"},
+				"description": "操作类型",
+			},
+			"time": map[string]interface{}{
+				"type":        "string",
+				"description": "时间，如 2024-05-01 14:30 或 RFC3339，默认当前时间。diff 时为起始时间，resolve 时为基准时间",
+			},
+			"end": map[string]interface{}{
+				"type":        "string",
+				"description": "diff 的结束时间，默认当前时间",
+			},
+			"timezone": map[string]interface{}{
+				"type":        "string",
+				"description": "时区，IANA名称（如 Asia/Shanghai）或偏移（如 UTC+8），默认用户资料中的时区，未设置时为本机时区。convert 时为源时区",
+			},
+			"to_timezone": map[string]interface{}{
+				"type":        "string",
+				"description": "convert 的目标时区",
+			},
+			"expression": map[string]interface{}{
+				"type":        "string",
+				"description": "resolve 的英文相对日期表达式，或 cron 的调度表达式（如 0 7 * * 1-5）",
+			},
+			"count": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("cron 列出的运行次数（默认%d，最多%d）", defaultCronRuns, maxCronRuns),
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *DateTimeTool) Execute(args map[string]interface{}) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+func (t *DateTimeTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (string, error) {
+	action, _ := args["action"].(string)
+	str := func(key string) string {
+		s, _ := args[key].(string)
+		return strings.TrimSpace(s)
+	}
+
+	tz := str("timezone")
+	if tz == "" {
+		tz = t.manager.callerProfile(ctx).Timezone
+	}
+	loc, err := loadLocation(tz)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now
+	if t.now != nil {
+		now = t.now
+	}
+	base := now().In(loc)
+	if s := str("time"); s != "" {
+		if base, err = parseTime(s, loc); err != nil {
+			return "", err
+		}
+	}
+
+	var result map[string]interface{}
+	switch action {
+	case "now":
+		result = describeTime(base)
+
+	case "convert":
+		target := str("to_timezone")
+		if target == "" {
+			return "", fmt.Errorf("to_timezone is required")
+		}
+		toLoc, err := loadLocation(target)
+		if err != nil {
+			return "", err
+		}
+		result = map[string]interface{}{
+			"from": describeTime(base),
+			"to":   describeTime(base.In(toLoc)),
+		}
+
+	case "diff":
+		end := now().In(loc)
+		if s := str("end"); s != "" {
+			if end, err = parseTime(s, loc); err != nil {
+				return "", err
+			}
+		}
+		result = describeDiff(base, end)
+
+	case "resolve":
+		expr := str("expression")
+		if expr == "" {
+			return "", fmt.Errorf("expression is required")
+		}
+		resolved, err := resolveDate(expr, base)
+		if err != nil {
+			return "", err
+		}
+		result = describeTime(resolved)
+		result["expression"] = expr
+
+	case "cron":
+		expr := str("expression")
+		sched, err := cron.Parse(expr)
+		if err != nil {
+			return "", fmt.Errorf("invalid cron expression: %w", err)
+		}
+		desc, _ := cron.Describe(expr)
+
+		count := defaultCronRuns
+		if c, ok := args["count"].(float64); ok && c >= 1 {
+			count = int(c)
+		}
+		if count > maxCronRuns {
+			count = maxCronRuns
+		}
+
+		runs := []string{}
+		next := base
+		for i := 0; i < count; i++ {
+			if next = sched.Next(next); next.IsZero() {
+				break
+			}
+			runs = append(runs, next.Format("2006-01-02 15:04 Mon"))
+		}
+		result = map[string]interface{}{
+			"expression":  expr,
+			"description": desc,
+			"timezone":    loc.String(),
+			"next_runs":   runs,
+		}
+
+	default:
+		return "", fmt.Errorf("unknown action: %s", action)
+	}
+
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// loadLocation 解析 IANA 时区名或 UTC 偏移，空字符串表示本机时区
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" || strings.EqualFold(name, "local") {
+		return time.Local, nil
+	}
+	if strings.EqualFold(name, "utc") || strings.EqualFold(name, "gmt") || name == "Z" {
+		return time.UTC, nil
+	}
+
+	if m := utcOffsetRe.FindStringSubmatch(name); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes, _ := strconv.Atoi(m[3])
+		if hours > 14 || minutes > 59 {
+			return nil, fmt.Errorf("invalid UTC offset: %s", name)
+		}
+		offset := hours*3600 + minutes*60
+		if m[1] == "-" {
+			offset = -offset
+		}
+		label := fmt.Sprintf("UTC%s%02d:%02d", m[1], hours, minutes)
+		return time.FixedZone(label, offset), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q (use an IANA name like Asia/Shanghai or an offset like UTC+8)", name)
+	}
+	return loc, nil
+}
+
+// parseTime 按支持的格式解析时间，无时区信息时使用 loc
+func parseTime(s string, loc *time.Location) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use e.g. 2006-01-02 15:04 or RFC3339)", s)
+}
+
+// describeTime 时间的结构化描述
+func describeTime(t time.Time) map[string]interface{} {
+	_, offset := t.Zone()
+	return map[string]interface{}{
+		"datetime":   t.Format(time.RFC3339),
+		"date":       t.Format("2006-01-02"),
+		"time":       t.Format("15:04:05"),
+		"weekday":    t.Weekday().String(),
+		"timezone":   t.Location().String(),
+		"utc_offset": formatOffset(offset),
+		"unix":       t.Unix(),
+	}
+}
+
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+// describeDiff 两个时间的间隔，包括总量和日历差
+func describeDiff(start, end time.Time) map[string]interface{} {
+	d := end.Sub(start)
+	sign := 1
+	from, to := start, end
+	if d < 0 {
+		sign = -1
+		from, to = end, start
+	}
+
+	years, months, days := calendarDiff(from, to)
+	return map[string]interface{}{
+		"start":         start.Format(time.RFC3339),
+		"end":           end.Format(time.RFC3339),
+		"total_seconds": int64(d.Seconds()),
+		"total_minutes": math.Round(d.Minutes()*100) / 100,
+		"total_hours":   math.Round(d.Hours()*100) / 100,
+		"total_days":    math.Round(d.Hours()/24*100) / 100,
+		"calendar": map[string]int{
+			"years":  sign * years,
+			"months": sign * months,
+			"days":   sign * days,
+		},
+		"human": humanDuration(d),
+	}
+}
+
+// calendarDiff 按日历计算 from 到 to 相差的年、月、日
+func calendarDiff(from, to time.Time) (years, months, days int) {
+	to = to.In(from.Location())
+	months = (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+	if addMonths(from, months).After(to) {
+		months--
+	}
+	anchor := addMonths(from, months)
+	for anchor.AddDate(0, 0, days+1).Compare(to) <= 0 {
+		days++
+	}
+	return months / 12, months % 12, days
+}
+
+// addMonths 增加月份，日期超出目标月天数时取月末（1月31日加一个月为2月末）
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// humanDuration 以天、小时、分钟描述时长
+func humanDuration(d time.Duration) string {
+	prefix := ""
+	if d < 0 {
+		prefix = "-"
+		d = -d
+	}
+	days := int(d / (24 * time.Hour))
+	d -= time.Duration(days) * 24 * time.Hour
+	hours := int(d / time.Hour)
+	d -= time.Duration(hours) * time.Hour
+	minutes := int(d / time.Minute)
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+	return prefix + strings.Join(parts, " ")
+}
+
+// resolveDate 解析相对日期表达式
+func resolveDate(expr string, base time.Time) (time.Time, error) {
+	s := strings.Join(strings.Fields(strings.ToLower(expr)), " ")
+	today := time.Date(base.Year(), base.Month(), base.Day(), 0, 0, 0, 0, base.Location())
+
+	switch s {
+	case "now":
+		return base, nil
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "day after tomorrow":
+		return today.AddDate(0, 0, 2), nil
+	case "day before yesterday":
+		return today.AddDate(0, 0, -2), nil
+	case "next week":
+		return today.AddDate(0, 0, 7), nil
+	case "last week":
+		return today.AddDate(0, 0, -7), nil
+	case "next month":
+		return addMonths(today, 1), nil
+	case "last month":
+		return addMonths(today, -1), nil
+	case "next year":
+		return today.AddDate(1, 0, 0), nil
+	case "last year":
+		return today.AddDate(-1, 0, 0), nil
+	}
+
+	// 星期: next/last/this <weekday> 或单独的 <weekday>
+	modifier, name := "", s
+	if i := strings.IndexByte(s, ' '); i > 0 {
+		modifier, name = s[:i], s[i+1:]
+	}
+	if wd, ok := parseWeekday(name); ok {
+		diff := int(wd - today.Weekday())
+		switch modifier {
+		case "next", "":
+			// 严格晚于今天的下一个该星期
+			if diff <= 0 {
+				diff += 7
+			}
+		case "last":
+			if diff >= 0 {
+				diff -= 7
+			}
+		case "this":
+			// 本周（周一开始）的该星期
+			diff = (int(wd)+6)%7 - (int(today.Weekday())+6)%7
+		default:
+			return time.Time{}, fmt.Errorf("unsupported expression: %s", expr)
+		}
+		return today.AddDate(0, 0, diff), nil
+	}
+
+	// 偏移量: in 3 days、2 weeks ago、+1 month
+	if m := relativeRe.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if m[3] != "" {
+			n = -n
+		}
+		switch m[2] {
+		case "minute", "min":
+			return base.Add(time.Duration(n) * time.Minute), nil
+		case "hour", "h":
+			return base.Add(time.Duration(n) * time.Hour), nil
+		case "day", "d":
+			return base.AddDate(0, 0, n), nil
+		case "week", "w":
+			return base.AddDate(0, 0, 7*n), nil
+		case "month":
+			return addMonths(base, n), nil
+		case "year", "y":
+			return base.AddDate(n, 0, 0), nil
+		}
+	}
+
+	if t, err := parseTime(expr, base.Location()); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("unsupported expression: %s (try e.g. next tuesday, tomorrow, in 3 days, 2 weeks ago)", expr)
+}
+
+func parseWeekday(s string) (time.Weekday, bool) {
+	if len(s) < 3 {
+		return 0, false
+	}
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if strings.HasPrefix(strings.ToLower(wd.String()), s) {
+			return wd, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/memory"
+)
+
+func TestResolveDate(t *testing.T) {
+	shanghai := time.FixedZone("UTC+08:00", 8*3600)
+	base := time.Date(2024, 3, 15, 10, 30, 0, 0, shanghai) // 周五
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"today", "2024-03-15 00:00"},
+		{"tomorrow", "2024-03-16 00:00"},
+		{"next tuesday", "2024-03-19 00:00"},
+		{"Next Friday", "2024-03-22 00:00"},
+		{"friday", "2024-03-22 00:00"},
+		{"last friday", "2024-03-08 00:00"},
+		{"last mon", "2024-03-11 00:00"},
+		{"this sunday", "2024-03-17 00:00"},
+		{"this monday", "2024-03-11 00:00"},
+		{"in 3 days", "2024-03-18 10:30"},
+		{"2 weeks ago", "2024-03-01 10:30"},
+		{"+90 minutes", "2024-03-15 12:00"},
+		{"in 1 month", "2024-04-15 10:30"},
+		{"2024-12-25", "2024-12-25 00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := resolveDate(tt.expr, base)
+			if err != nil {
+				t.Fatalf("resolveDate(%q) error = %v", tt.expr, err)
+			}
+			if s := got.Format("2006-01-02 15:04"); s != tt.want {
+				t.Errorf("resolveDate(%q) = %s, want %s", tt.expr, s, tt.want)
+			}
+		})
+	}
+
+	endOfMonth := time.Date(2024, 1, 31, 9, 0, 0, 0, shanghai)
+	if got, _ := resolveDate("in 1 month", endOfMonth); got.Format("2006-01-02") != "2024-02-29" {
+		t.Errorf("in 1 month from Jan 31 = %s, want 2024-02-29", got.Format("2006-01-02"))
+	}
+
+	if _, err := resolveDate("sometime soon", base); err == nil {
+		t.Error("resolveDate should reject unknown expressions")
+	}
+}
+
+func TestDateTimeTool(t *testing.T) {
+	tool := &DateTimeTool{now: func() time.Time {
+		return time.Date(2024, 3, 15, 2, 30, 0, 0, time.UTC)
+	}}
+
+	run := func(args map[string]interface{}) map[string]interface{} {
+		t.Helper()
+		out, err := tool.Execute(args)
+		if err != nil {
+			t.Fatalf("Execute(%v) error = %v", args, err)
+		}
+		var result map[string]interface{}
+		if err := json.Unmarshal([]byte(out), &result); err != nil {
+			t.Fatalf("result is not JSON: %v", err)
+		}
+		return result
+	}
+
+	result := run(map[string]interface{}{"action": "convert", "time": "2024-03-15 09:00", "timezone": "UTC+8", "to_timezone": "UTC-5"})
+	to := result["to"].(map[string]interface{})
+	if to["datetime"] != "2024-03-14T20:00:00-05:00" || to["weekday"] != "Thursday" {
+		t.Errorf("convert = %v", to)
+	}
+
+	result = run(map[string]interface{}{"action": "diff", "time": "2024-01-31", "end": "2024-03-15 12:00", "timezone": "UTC"})
+	calendar := result["calendar"].(map[string]interface{})
+	if calendar["months"] != float64(1) || calendar["days"] != float64(15) || result["human"] != "44d 12h" {
+		t.Errorf("diff = %v", result)
+	}
+
+	result = run(map[string]interface{}{"action": "diff", "time": "2024-03-20", "timezone": "UTC"})
+	if result["total_days"] != -4.9 {
+		t.Errorf("diff until now = %v", result["total_days"])
+	}
+
+	result = run(map[string]interface{}{"action": "cron", "expression": "0 7 * * 1-5", "timezone": "UTC", "count": float64(3)})
+	runs := result["next_runs"].([]interface{})
+	if result["description"] != "at 07:00, on Monday through Friday" || len(runs) != 3 || runs[0] != "2024-03-15 07:00 Fri" || runs[1] != "2024-03-18 07:00 Mon" {
+		t.Errorf("cron = %v", result)
+	}
+
+	if _, err := tool.Execute(map[string]interface{}{"action": "convert", "to_timezone": "Mars/Olympus"}); err == nil {
+		t.Error("unknown timezone should fail")
+	}
+}
+
+func TestDateTimeProfileTimezone(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	defer log.Close()
+
+	mem, err := memory.NewManager(memory.Config{Enabled: true, MemoryDir: t.TempDir(), MaxFileSize: 1 << 20}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mem.UpdateProfile("telegram:42", func(p *memory.Profile) error {
+		return p.Set("timezone", "Asia/Tokyo")
+	}); err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	if _, err := mem.UpdateProfile("telegram:42", func(p *memory.Profile) error {
+		return p.Set("units", "kelvin")
+	}); err == nil {
+		t.Error("invalid units should fail")
+	}
+
+	tool := &DateTimeTool{manager: &Manager{memoryMgr: mem}, now: func() time.Time {
+		return time.Date(2024, 3, 15, 2, 30, 0, 0, time.UTC)
+	}}
+	ctx := WithCaller(context.Background(), Caller{Channel: "telegram", UserID: "42"})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		args map[string]interface{}
+		want string
+	}{
+		{"profile default", ctx, map[string]interface{}{"action": "now"}, "2024-03-15T11:30:00+09:00"},
+		{"explicit timezone wins", ctx, map[string]interface{}{"action": "now", "timezone": "UTC"}, "2024-03-15T02:30:00Z"},
+		{"no caller", context.Background(), map[string]interface{}{"action": "now", "timezone": "UTC+1"}, "2024-03-15T03:30:00+01:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := tool.ExecuteContext(tt.ctx, tt.args)
+			if err != nil {
+				t.Fatalf("ExecuteContext() error = %v", err)
+			}
+			var result map[string]interface{}
+			json.Unmarshal([]byte(out), &result)
+			if result["datetime"] != tt.want {
+				t.Errorf("datetime = %v, want %s", result["datetime"], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package tools
+
+import "github.com/HaohanHe/mujibot/internal/i18n"
+
+// DescriptionParams 描述中含运行时信息（如允许的服务列表）的工具实现此接口，用于填充翻译文本的占位符
+type DescriptionParams interface {
+	DescriptionParams() i18n.Params
+}
+
+// Describe 返回工具在指定语言下的描述：翻译目录中有 tool.<名称> 时使用翻译，否则使用 Description()
+func Describe(t *i18n.I18n, lang string, tool Tool) string {
+	if t == nil {
+		return tool.Description()
+	}
+	var params i18n.Params
+	if p, ok := tool.(DescriptionParams); ok {
+		params = p.DescriptionParams()
+	}
+	if s, ok := t.Lookup(lang, "tool."+tool.Name(), params); ok {
+		return s
+	}
+	return tool.Description()
+}
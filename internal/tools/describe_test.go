@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/i18n"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestDescribe(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	m, err := NewManager(Config{WorkDir: t.TempDir()}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := append(m.GetAll(), NewSystemctlTool(m, []string{"nginx", "backup.timer"}))
+
+	tr := i18n.New("en-US")
+	for _, tool := range all {
+		// 中文翻译与工具自带的描述保持一致
+		if got := Describe(tr, "zh-CN", tool); got != tool.Description() {
+			t.Errorf("%s: zh-CN description %q differs from Description() %q", tool.Name(), got, tool.Description())
+		}
+		if _, ok := tr.Lookup("en-US", "tool."+tool.Name(), nil); !ok {
+			t.Errorf("%s has no en-US description", tool.Name())
+		}
+	}
+
+	systemctl := NewSystemctlTool(m, []string{"nginx", "backup.timer"})
+	if got := Describe(tr, "en-US", systemctl); !strings.HasSuffix(got, ": backup.timer, nginx") {
+		t.Errorf("systemctl description = %q", got)
+	}
+	// 其他语言没有工具描述的翻译时使用英文
+	if got, want := Describe(tr, "de-DE", systemctl), Describe(tr, "en-US", systemctl); got != want {
+		t.Errorf("de-DE description = %q, want %q", got, want)
+	}
+	if got := Describe(nil, "en-US", systemctl); got != systemctl.Description() {
+		t.Errorf("nil translator = %q", got)
+	}
+}
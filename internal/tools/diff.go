@@ -0,0 +1,165 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// diffContext 统一diff的上下文行数
+	diffContext = 3
+	// diffMaxCells 超过此规模不再求最长公共子序列，改动部分整体替换
+	diffMaxCells = 4000000
+)
+
+// diffOp diff中的一行，kind 为 ' '、'-' 或 '+'
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// splitDiffLines 按行拆分，忽略末尾换行
+func splitDiffLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines 计算两组行之间的编辑序列，先去掉相同的首尾再求最长公共子序列
+func diffLines(a, b []string) []diffOp {
+	p := 0
+	for p < len(a) && p < len(b) && a[p] == b[p] {
+		p++
+	}
+	s := 0
+	for s < len(a)-p && s < len(b)-p && a[len(a)-1-s] == b[len(b)-1-s] {
+		s++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:p] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	ma, mb := a[p:len(a)-s], b[p:len(b)-s]
+	n, m := len(ma), len(mb)
+	if n*m > diffMaxCells {
+		for _, line := range ma {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range mb {
+			ops = append(ops, diffOp{'+', line})
+		}
+	} else {
+		// lcs[i][j] 为 ma[i:] 与 mb[j:] 的最长公共子序列长度
+		lcs := make([][]int32, n+1)
+		for i := range lcs {
+			lcs[i] = make([]int32, m+1)
+		}
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				switch {
+				case ma[i] == mb[j]:
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				case lcs[i+1][j] >= lcs[i][j+1]:
+					lcs[i][j] = lcs[i+1][j]
+				default:
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < n || j < m {
+			switch {
+			case i < n && j < m && ma[i] == mb[j]:
+				ops = append(ops, diffOp{' ', ma[i]})
+				i++
+				j++
+			case j < m && (i == n || lcs[i][j+1] > lcs[i+1][j]):
+				ops = append(ops, diffOp{'+', mb[j]})
+				j++
+			default:
+				ops = append(ops, diffOp{'-', ma[i]})
+				i++
+			}
+		}
+	}
+
+	for _, line := range a[len(a)-s:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// unifiedDiff 生成统一diff，内容相同时返回空字符串
+func unifiedDiff(name, before, after string) string {
+	if before == after {
+		return ""
+	}
+	ops := diffLines(splitDiffLines(before), splitDiffLines(after))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", name, name)
+	for i := 0; i < len(ops); {
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		start := max(i-diffContext, 0)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			gap := 0
+			for end+gap < len(ops) && ops[end+gap].kind == ' ' {
+				gap++
+			}
+			// 两处改动间隔超过两倍上下文时拆成两个hunk
+			if end+gap == len(ops) || gap > 2*diffContext {
+				end += min(gap, diffContext)
+				break
+			}
+			end += gap
+		}
+
+		aStart, bStart := 0, 0
+		for _, op := range ops[:start] {
+			if op.kind != '+' {
+				aStart++
+			}
+			if op.kind != '-' {
+				bStart++
+			}
+		}
+		aCount, bCount := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		if aCount > 0 {
+			aStart++
+		}
+		if bCount > 0 {
+			bStart++
+		}
+
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+		for _, op := range ops[start:end] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.text)
+			sb.WriteByte('\n')
+		}
+		i = end
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
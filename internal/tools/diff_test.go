@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	got := unifiedDiff("a.txt", "one\ntwo\nthree\n", "one\n2\nthree\nfour\n")
+	want := "--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,4 @@\n one\n-two\n+2\n three\n+four"
+	if got != want {
+		t.Errorf("unifiedDiff() = %q, want %q", got, want)
+	}
+	if got := unifiedDiff("a.txt", "same\n", "same\n"); got != "" {
+		t.Errorf("unifiedDiff() of equal content = %q", got)
+	}
+	if got := unifiedDiff("new.txt", "", "x\ny\n"); !strings.Contains(got, "@@ -0,0 +1,2 @@\n+x\n+y") {
+		t.Errorf("unifiedDiff() of new file = %q", got)
+	}
+
+	// 生成的diff应能被 apply_patch 还原
+	rng := rand.New(rand.NewSource(1))
+	for n := 0; n < 50; n++ {
+		var a, b []string
+		for i := 0; i < 40; i++ {
+			line := fmt.Sprintf("line %d", i)
+			switch rng.Intn(6) {
+			case 0:
+				a = append(a, line)
+			case 1:
+				b = append(b, line+" new")
+			case 2:
+				a = append(a, line)
+				b = append(b, line+" changed")
+			default:
+				a = append(a, line)
+				b = append(b, line)
+			}
+		}
+		before, after := strings.Join(a, "\n")+"\n", strings.Join(b, "\n")+"\n"
+		diff := unifiedDiff("f.txt", before, after)
+		if before == after {
+			continue
+		}
+		files, err := parseUnifiedDiff(diff)
+		if err != nil || len(files) != 1 {
+			t.Fatalf("case %d: parse = %v\n%s", n, err, diff)
+		}
+		result, _, err := applyHunks(before, files[0].hunks, 0)
+		if err != nil || result != after {
+			t.Fatalf("case %d: apply = %v, match %v\n%s", n, err, result == after, diff)
+		}
+	}
+}
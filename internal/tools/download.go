@@ -0,0 +1,497 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/httpclient"
+)
+
+const (
+	// downloadMaxBytes 单个下载的大小上限
+	downloadMaxBytes = 200 * 1024 * 1024
+	// downloadWait 前台等待时间，超时后转为后台继续下载
+	downloadWait = 30 * time.Second
+)
+
+// downloadContentTypes 允许下载的内容类型前缀
+var downloadContentTypes = []string{
+	"application/octet-stream",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/x-tar",
+	"application/x-bzip2",
+	"application/x-xz",
+	"application/x-7z-compressed",
+	"application/pdf",
+	"application/json",
+	"application/xml",
+	"application/vnd.",
+	"text/",
+	"image/",
+	"audio/",
+	"video/",
+	"font/",
+}
+
+// DownloadSession 下载会话，进度查询方式与终端会话一致
+type DownloadSession struct {
+	ID        string
+	URL       string
+	Path      string
+	Total     int64
+	Received  int64
+	Resumed   int64
+	StartTime time.Time
+	EndTime   time.Time
+	Running   bool
+	Err       error
+	SHA256    string
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	mu     sync.RWMutex
+}
+
+// DownloadTool 下载文件到工作目录
+type DownloadTool struct {
+	manager  *Manager
+	sessions map[string]*DownloadSession
+	mu       sync.RWMutex
+
+	// 可在测试中替换
+	maxBytes int64
+	wait     time.Duration
+	checkURL func(string) (*url.URL, error)
+}
+
+func NewDownloadTool(manager *Manager) *DownloadTool {
+	return &DownloadTool{
+		manager:  manager,
+		sessions: make(map[string]*DownloadSession),
+		maxBytes: downloadMaxBytes,
+		wait:     downloadWait,
+		checkURL: CheckURL,
+	}
+}
+
+func (t *DownloadTool) Name() string {
+	return "download_file"
+}
+
+func (t *DownloadTool) Description() string {
+	return "下载URL到工作目录，支持断点续传和SHA-256校验。大文件会转入后台下载，用 status 查询进度。"
+}
+
+func (t *DownloadTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"workspace": t.manager.workspaceParam(),
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "操作类型: download(下载，默认), status(查询进度), cancel(取消), list(列出下载)",
+				"enum":        []string{"download", "status", "cancel", "list"},
+			},
+			"url": map[string]interface{}{
+				"type":        "string",
+				"description": "下载地址（http/https）",
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "保存路径（相对workDir），默认使用URL中的文件名",
+			},
+			"sha256": map[string]interface{}{
+				"type":        "string",
+				"description": "期望的SHA-256校验值，不匹配时删除文件",
+			},
+			"overwrite": map[string]interface{}{
+				"type":        "boolean",
+				"description": "目标文件已存在时是否覆盖",
+			},
+			"sessionId": map[string]interface{}{
+				"type":        "string",
+				"description": "下载ID（用于status/cancel操作）",
+			},
+		},
+	}
+}
+
+func (t *DownloadTool) Execute(args map[string]interface{}) (string, error) {
+	action, _ := args["action"].(string)
+	sessionID, _ := args["sessionId"].(string)
+
+	switch action {
+	case "", "download":
+		return t.download(args)
+	case "status":
+		session, err := t.session(sessionID)
+		if err != nil {
+			return "", err
+		}
+		return t.status(session), nil
+	case "cancel":
+		return t.cancelSession(sessionID)
+	case "list":
+		return t.listSessions(), nil
+	default:
+		return "", fmt.Errorf("unknown action: %s", action)
+	}
+}
+
+func (t *DownloadTool) download(args map[string]interface{}) (string, error) {
+	rawURL, _ := args["url"].(string)
+	if rawURL == "" {
+		return "", fmt.Errorf("url is required")
+	}
+	u, err := t.checkURL(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	target, _ := args["path"].(string)
+	if target == "" {
+		target = path.Base(u.Path)
+		if target == "" || target == "." || target == "/" {
+			target = "download"
+		}
+	}
+	safePath, err := t.manager.resolvePath(args, target, true)
+	if err != nil {
+		return "", err
+	}
+	if err := t.manager.enforcePolicy(t.Name(), args, "", safePath); err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(safePath); err == nil {
+		if info.IsDir() {
+			return "", fmt.Errorf("path is a directory: %s", target)
+		}
+		if overwrite, _ := args["overwrite"].(bool); !overwrite {
+			return "", fmt.Errorf("file already exists: %s，设置 overwrite=true 来覆盖", t.manager.relPath(safePath))
+		}
+	}
+
+	checksum, _ := args["sha256"].(string)
+	checksum = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(checksum), "sha256:"))
+	if checksum != "" {
+		if b, err := hex.DecodeString(checksum); err != nil || len(b) != sha256.Size {
+			return "", fmt.Errorf("invalid sha256 checksum")
+		}
+	}
+
+	t.mu.Lock()
+	for _, s := range t.sessions {
+		s.mu.RLock()
+		busy := s.Running && s.Path == safePath
+		s.mu.RUnlock()
+		if busy {
+			t.mu.Unlock()
+			return "", fmt.Errorf("%s is already being downloaded (%s)", t.manager.relPath(safePath), s.ID)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &DownloadSession{
+		ID:        fmt.Sprintf("dl_%d", time.Now().UnixNano()),
+		URL:       u.String(),
+		Path:      safePath,
+		StartTime: time.Now(),
+		Running:   true,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	t.sessions[session.ID] = session
+	t.mu.Unlock()
+
+	go func() {
+		err := t.run(ctx, session, checksum)
+		cancel()
+		session.mu.Lock()
+		session.Running = false
+		session.EndTime = time.Now()
+		session.Err = err
+		session.mu.Unlock()
+		close(session.done)
+	}()
+
+	select {
+	case <-session.done:
+		session.mu.RLock()
+		err := session.Err
+		session.mu.RUnlock()
+		if err != nil {
+			return "", err
+		}
+		return t.status(session), nil
+	case <-time.After(t.wait):
+		return t.status(session) + "\nDownload continues in background. Use 'status' action with sessionId to check progress.", nil
+	}
+}
+
+// run 执行下载，已有 .part 文件时使用 Range 续传
+func (t *DownloadTool) run(ctx context.Context, session *DownloadSession, checksum string) error {
+	partPath := session.Path + ".part"
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, session.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Mujibot/1.0)")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	client := &http.Client{
+		Transport: httpclient.Transport(),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("too many redirects")
+			}
+			_, err := t.checkURL(req.URL.String())
+			return err
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// .part 已完整
+		resp.Body.Close()
+		return t.finish(session, partPath, offset, checksum)
+	case resp.StatusCode == http.StatusOK:
+		offset = 0
+		flags |= os.O_TRUNC
+	default:
+		return fmt.Errorf("download failed: HTTP %d", resp.StatusCode)
+	}
+
+	if err := checkDownloadType(resp.Header.Get("Content-Type")); err != nil {
+		return err
+	}
+	if resp.ContentLength > 0 {
+		total := offset + resp.ContentLength
+		if total > t.maxBytes {
+			return fmt.Errorf("file too large: %d bytes (max %d MB)", total, t.maxBytes/1024/1024)
+		}
+		session.mu.Lock()
+		session.Total = total
+		session.mu.Unlock()
+	}
+	session.mu.Lock()
+	session.Resumed = offset
+	session.Received = offset
+	session.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(partPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	out, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	received := offset
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			received += int64(n)
+			if received > t.maxBytes {
+				out.Close()
+				os.Remove(partPath)
+				return fmt.Errorf("file too large (max %d MB)", t.maxBytes/1024/1024)
+			}
+			if _, err := out.Write(buf[:n]); err != nil {
+				out.Close()
+				return fmt.Errorf("failed to write file: %w", err)
+			}
+			session.mu.Lock()
+			session.Received = received
+			session.mu.Unlock()
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			out.Close()
+			if ctx.Err() != nil {
+				return fmt.Errorf("download cancelled, partial data kept for resumption")
+			}
+			return fmt.Errorf("download interrupted at %d bytes, retry to resume: %w", received, readErr)
+		}
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return t.finish(session, partPath, received, checksum)
+}
+
+// finish 校验并将 .part 文件改名为目标文件
+func (t *DownloadTool) finish(session *DownloadSession, partPath string, size int64, checksum string) error {
+	f, err := os.Open(partPath)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to hash file: %w", err)
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	if checksum != "" && sum != checksum {
+		os.Remove(partPath)
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", checksum, sum)
+	}
+	if err := os.Rename(partPath, session.Path); err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+
+	session.mu.Lock()
+	session.Received = size
+	session.Total = size
+	session.SHA256 = sum
+	session.mu.Unlock()
+	return nil
+}
+
+// checkDownloadType 检查内容类型是否在白名单中，未声明类型时放行
+func checkDownloadType(contentType string) error {
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("invalid content type: %s", contentType)
+	}
+	for _, allowed := range downloadContentTypes {
+		if strings.HasPrefix(mediaType, allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("content type not allowed: %s", mediaType)
+}
+
+func (t *DownloadTool) session(sessionID string) (*DownloadSession, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	session, ok := t.sessions[sessionID]
+	if !ok {
+		return nil, fmt.Errorf("download not found: %s", sessionID)
+	}
+	return session, nil
+}
+
+// status 格式化下载进度
+func (t *DownloadTool) status(session *DownloadSession) string {
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+
+	status := "running"
+	end := time.Now()
+	if !session.Running {
+		end = session.EndTime
+		status = "completed"
+		if session.Err != nil {
+			status = "failed"
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Download: %s\nStatus: %s\nFile: %s\n", session.ID, status, t.manager.relPath(session.Path))
+	if session.Total > 0 {
+		fmt.Fprintf(&sb, "Progress: %d / %d bytes (%.1f%%)\n", session.Received, session.Total, float64(session.Received)*100/float64(session.Total))
+	} else {
+		fmt.Fprintf(&sb, "Progress: %d bytes\n", session.Received)
+	}
+	if session.Resumed > 0 {
+		fmt.Fprintf(&sb, "Resumed from: %d bytes\n", session.Resumed)
+	}
+	fmt.Fprintf(&sb, "Duration: %s", end.Sub(session.StartTime).Round(time.Second))
+	if session.SHA256 != "" {
+		fmt.Fprintf(&sb, "\nSHA-256: %s", session.SHA256)
+	}
+	if session.Err != nil {
+		fmt.Fprintf(&sb, "\nError: %v", session.Err)
+	}
+	return sb.String()
+}
+
+func (t *DownloadTool) cancelSession(sessionID string) (string, error) {
+	session, err := t.session(sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	session.mu.RLock()
+	running := session.Running
+	session.mu.RUnlock()
+	if !running {
+		return "Download already finished", nil
+	}
+
+	session.cancel()
+	<-session.done
+	return t.status(session) + "\n[DOWNLOAD CANCELLED]", nil
+}
+
+func (t *DownloadTool) listSessions() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if len(t.sessions) == 0 {
+		return "No downloads"
+	}
+
+	ids := make([]string, 0, len(t.sessions))
+	for id := range t.sessions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var sb strings.Builder
+	sb.WriteString("Downloads:\n")
+	for _, id := range ids {
+		session := t.sessions[id]
+		session.mu.RLock()
+		status := "running"
+		if !session.Running {
+			status = "completed"
+			if session.Err != nil {
+				status = "failed"
+			}
+		}
+		fmt.Fprintf(&sb, "- %s: %s %s (%d bytes)\n", id, status, t.manager.relPath(session.Path), session.Received)
+		session.mu.RUnlock()
+	}
+	return sb.String()
+}
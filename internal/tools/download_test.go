@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestDownloadTool(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	workDir := t.TempDir()
+	m, err := NewManager(Config{WorkDir: workDir}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := bytes.Repeat([]byte("0123456789"), 1000)
+	sum := sha256.Sum256(payload)
+	checksum := hex.EncodeToString(sum[:])
+
+	release := make(chan struct{})
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/data.bin":
+			ranges = append(ranges, r.Header.Get("Range"))
+			w.Header().Set("Content-Type", "application/octet-stream")
+			http.ServeContent(w, r, "data.bin", time.Time{}, bytes.NewReader(payload))
+		case "/setup.exe":
+			w.Header().Set("Content-Type", "application/x-msdownload")
+			w.Write(payload)
+		case "/slow.bin":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Length", "100")
+			w.Write([]byte("partial"))
+			w.(http.Flusher).Flush()
+			<-release
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	tool := NewDownloadTool(m)
+	tool.checkURL = func(raw string) (*url.URL, error) { return url.Parse(raw) }
+
+	result, err := tool.Execute(map[string]interface{}{"url": srv.URL + "/data.bin", "sha256": checksum})
+	if err != nil {
+		t.Fatalf("download error = %v", err)
+	}
+	if !strings.Contains(result, "Status: completed") || !strings.Contains(result, checksum) {
+		t.Errorf("result = %q", result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workDir, "data.bin")); !bytes.Equal(data, payload) {
+		t.Error("downloaded content mismatch")
+	}
+
+	if _, err := tool.Execute(map[string]interface{}{"url": srv.URL + "/data.bin"}); err == nil {
+		t.Error("existing file without overwrite should fail")
+	}
+
+	// 断点续传
+	if err := os.WriteFile(filepath.Join(workDir, "resumed.bin.part"), payload[:4000], 0644); err != nil {
+		t.Fatal(err)
+	}
+	ranges = nil
+	result, err = tool.Execute(map[string]interface{}{"url": srv.URL + "/data.bin", "path": "resumed.bin", "sha256": "sha256:" + checksum})
+	if err != nil {
+		t.Fatalf("resume error = %v", err)
+	}
+	if len(ranges) != 1 || ranges[0] != "bytes=4000-" || !strings.Contains(result, "Resumed from: 4000 bytes") {
+		t.Errorf("ranges = %v, result = %q", ranges, result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workDir, "resumed.bin")); !bytes.Equal(data, payload) {
+		t.Error("resumed content mismatch")
+	}
+
+	if _, err := tool.Execute(map[string]interface{}{"url": srv.URL + "/data.bin", "path": "bad.bin", "sha256": strings.Repeat("0", 64)}); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("checksum mismatch error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "bad.bin")); err == nil {
+		t.Error("file with wrong checksum should not be kept")
+	}
+
+	if _, err := tool.Execute(map[string]interface{}{"url": srv.URL + "/setup.exe"}); err == nil || !strings.Contains(err.Error(), "content type not allowed") {
+		t.Errorf("content type error = %v", err)
+	}
+
+	tool.maxBytes = 1000
+	if _, err := tool.Execute(map[string]interface{}{"url": srv.URL + "/data.bin", "path": "big.bin"}); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("size cap error = %v", err)
+	}
+	tool.maxBytes = downloadMaxBytes
+
+	// 超过等待时间转入后台，可查询和取消
+	tool.wait = 100 * time.Millisecond
+	result, err = tool.Execute(map[string]interface{}{"url": srv.URL + "/slow.bin"})
+	if err != nil || !strings.Contains(result, "Status: running") {
+		t.Fatalf("background result = %q, %v", result, err)
+	}
+	id := strings.TrimPrefix(strings.SplitN(result, "\n", 2)[0], "Download: ")
+	if status, _ := tool.Execute(map[string]interface{}{"action": "status", "sessionId": id}); !strings.Contains(status, "Progress: 7 / 100 bytes") {
+		t.Errorf("status = %q", status)
+	}
+	if list, _ := tool.Execute(map[string]interface{}{"action": "list"}); !strings.Contains(list, id+": running") {
+		t.Errorf("list = %q", list)
+	}
+	if result, err := tool.Execute(map[string]interface{}{"action": "cancel", "sessionId": id}); err != nil || !strings.Contains(result, "CANCELLED") {
+		t.Errorf("cancel = %q, %v", result, err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "slow.bin.part")); err != nil {
+		t.Error("partial data should be kept for resumption")
+	}
+
+	if _, err := NewDownloadTool(m).Execute(map[string]interface{}{"url": "http://127.0.0.1/x"}); err == nil {
+		t.Error("private addresses should be rejected by default")
+	}
+}
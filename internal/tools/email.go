@@ -0,0 +1,430 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxAttachmentMB = 10
+	maxEmailRecipients     = 20
+)
+
+// EmailConfig SMTP发信配置
+type EmailConfig struct {
+	Enabled           bool
+	Host              string
+	Port              int
+	Username          string
+	Password          string
+	From              string
+	Security          string   // starttls（默认）、tls 或 none
+	AllowedRecipients []string // 允许直接发送的地址，"@example.com" 表示整个域名
+	MaxAttachmentMB   int
+
+	// OnNewRecipient 首次发送给新收件人后回调，用于持久化
+	OnNewRecipient func(address string)
+}
+
+// smtpSender 发送邮件，测试时可替换
+type smtpSender func(cfg EmailConfig, from string, to []string, msg []byte) error
+
+// EmailTool 通过SMTP发送邮件，新收件人需要确认
+type EmailTool struct {
+	manager *Manager
+	config  EmailConfig
+	send    smtpSender
+
+	mu    sync.Mutex
+	known map[string]bool
+}
+
+// NewEmailTool 创建邮件工具
+func NewEmailTool(manager *Manager, cfg EmailConfig) *EmailTool {
+	t := &EmailTool{
+		manager: manager,
+		config:  cfg,
+		send:    sendSMTP,
+		known:   make(map[string]bool),
+	}
+	for _, addr := range cfg.AllowedRecipients {
+		t.known[strings.ToLower(strings.TrimSpace(addr))] = true
+	}
+	return t
+}
+
+func (t *EmailTool) Name() string {
+	return "email_send"
+}
+
+func (t *EmailTool) Description() string {
+	return "发送邮件，可附带工作目录中的文件。首次发送给不在白名单中的收件人需要用户确认。"
+}
+
+func (t *EmailTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"to": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "收件人邮箱地址",
+			},
+			"subject": map[string]interface{}{
+				"type":        "string",
+				"description": "邮件主题",
+			},
+			"body": map[string]interface{}{
+				"type":        "string",
+				"description": "邮件正文（纯文本）",
+			},
+			"attachments": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "附件路径（工作目录内）",
+			},
+			"confirm": map[string]interface{}{
+				"type":        "boolean",
+				"description": "用户已确认发送给新收件人",
+			},
+		},
+		"required": []string{"to", "subject", "body"},
+	}
+}
+
+func (t *EmailTool) Execute(args map[string]interface{}) (string, error) {
+	to, err := parseRecipients(args["to"])
+	if err != nil {
+		return "", err
+	}
+	subject, _ := args["subject"].(string)
+	body, _ := args["body"].(string)
+	if strings.TrimSpace(subject) == "" {
+		return "", fmt.Errorf("subject is required")
+	}
+
+	// 新收件人需要确认
+	var unknown []string
+	for _, addr := range to {
+		if !t.isKnown(addr) {
+			unknown = append(unknown, addr)
+		}
+	}
+	if len(unknown) > 0 && t.manager.confirmDangerous && !t.manager.unattendedMode {
+		if confirmed, _ := args["confirm"].(bool); !confirmed {
+			return "", fmt.Errorf("首次发送给 %s，需要用户确认。请向用户确认收件人后设置 confirm=true", strings.Join(unknown, ", "))
+		}
+	}
+
+	attachments, err := t.loadAttachments(args)
+	if err != nil {
+		return "", err
+	}
+
+	from := t.config.From
+	if from == "" {
+		from = t.config.Username
+	}
+	fromAddr, err := mail.ParseAddress(from)
+	if err != nil {
+		return "", fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	msg, err := buildMessage(fromAddr, to, subject, body, attachments)
+	if err != nil {
+		return "", err
+	}
+
+	if err := t.send(t.config, fromAddr.Address, to, msg); err != nil {
+		return "", fmt.Errorf("failed to send email: %w", err)
+	}
+
+	for _, addr := range unknown {
+		t.remember(addr)
+	}
+
+	result := fmt.Sprintf("Email sent to %s", strings.Join(to, ", "))
+	if len(attachments) > 0 {
+		names := make([]string, len(attachments))
+		for i, a := range attachments {
+			names[i] = a.name
+		}
+		result += fmt.Sprintf(" with attachments: %s", strings.Join(names, ", "))
+	}
+	return result, nil
+}
+
+// isKnown 检查收件人是否在白名单或已确认过
+func (t *EmailTool) isKnown(addr string) bool {
+	addr = strings.ToLower(addr)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.known[addr] {
+		return true
+	}
+	if at := strings.LastIndexByte(addr, '@'); at >= 0 && t.known[addr[at:]] {
+		return true
+	}
+	return false
+}
+
+// remember 记住已确认的收件人
+func (t *EmailTool) remember(addr string) {
+	t.mu.Lock()
+	t.known[strings.ToLower(addr)] = true
+	t.mu.Unlock()
+
+	t.manager.log.Info("new email recipient confirmed", "address", addr)
+	if t.config.OnNewRecipient != nil {
+		t.config.OnNewRecipient(addr)
+	}
+}
+
+// parseRecipients 解析收件人，支持字符串数组或逗号分隔的字符串
+func parseRecipients(v interface{}) ([]string, error) {
+	var raw []string
+	switch val := v.(type) {
+	case string:
+		raw = strings.Split(val, ",")
+	case []interface{}:
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				raw = append(raw, s)
+			}
+		}
+	}
+
+	var result []string
+	seen := make(map[string]bool)
+	for _, s := range raw {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		addr, err := mail.ParseAddress(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %w", s, err)
+		}
+		key := strings.ToLower(addr.Address)
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, addr.Address)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("to is required")
+	}
+	if len(result) > maxEmailRecipients {
+		return nil, fmt.Errorf("too many recipients (max %d)", maxEmailRecipients)
+	}
+	return result, nil
+}
+
+type emailAttachment struct {
+	name string
+	data []byte
+}
+
+// loadAttachments 读取工作目录内的附件
+func (t *EmailTool) loadAttachments(args map[string]interface{}) ([]emailAttachment, error) {
+	list, _ := args["attachments"].([]interface{})
+	if len(list) == 0 {
+		return nil, nil
+	}
+
+	limit := t.config.MaxAttachmentMB
+	if limit <= 0 {
+		limit = defaultMaxAttachmentMB
+	}
+	maxBytes := int64(limit) * 1024 * 1024
+
+	var result []emailAttachment
+	var total int64
+	for _, item := range list {
+		p, ok := item.(string)
+		if !ok || p == "" {
+			continue
+		}
+		path, err := t.manager.resolvePath(args, p, false)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("attachment not found: %s", p)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("attachment is a directory: %s", p)
+		}
+		total += info.Size()
+		if total > maxBytes {
+			return nil, fmt.Errorf("attachments exceed %dMB", limit)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attachment: %w", err)
+		}
+		result = append(result, emailAttachment{name: filepath.Base(path), data: data})
+	}
+	return result, nil
+}
+
+// buildMessage 构造MIME邮件，有附件时使用 multipart/mixed
+func buildMessage(from *mail.Address, to []string, subject, body string, attachments []emailAttachment) ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(k, v string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", k, v)
+	}
+
+	header("From", from.String())
+	header("To", strings.Join(to, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", fmt.Sprintf("<%s@%s>", randomToken(), domainOf(from.Address)))
+	header("MIME-Version", "1.0")
+
+	if len(attachments) == 0 {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "base64")
+		buf.WriteString("\r\n")
+		writeBase64(&buf, []byte(body))
+		return buf.Bytes(), nil
+	}
+
+	boundary := "mujibot-" + randomToken()
+	header("Content-Type", fmt.Sprintf("multipart/mixed; boundary=%q", boundary))
+	buf.WriteString("\r\n")
+
+	fmt.Fprintf(&buf, "--%s\r\n", boundary)
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: base64\r\n\r\n")
+	writeBase64(&buf, []byte(body))
+
+	for _, a := range attachments {
+		contentType := mime.TypeByExtension(filepath.Ext(a.name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		name := mime.QEncoding.Encode("utf-8", a.name)
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		fmt.Fprintf(&buf, "Content-Type: %s; name=%q\r\n", contentType, name)
+		fmt.Fprintf(&buf, "Content-Disposition: attachment; filename=%q\r\n", name)
+		buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+		writeBase64(&buf, a.data)
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	return buf.Bytes(), nil
+}
+
+// writeBase64 按76字符换行写入base64
+func writeBase64(buf *bytes.Buffer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76])
+		buf.WriteString("\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded)
+	buf.WriteString("\r\n")
+}
+
+func randomToken() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func domainOf(addr string) string {
+	if at := strings.LastIndexByte(addr, '@'); at >= 0 {
+		return addr[at+1:]
+	}
+	return "localhost"
+}
+
+// sendSMTP 通过SMTP服务器发送邮件
+func sendSMTP(cfg EmailConfig, from string, to []string, msg []byte) error {
+	port := cfg.Port
+	security := strings.ToLower(cfg.Security)
+	if port == 0 {
+		switch security {
+		case "tls":
+			port = 465
+		case "none":
+			port = 25
+		default:
+			port = 587
+		}
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+	if security == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(time.Minute))
+
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if security != "tls" && security != "none" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
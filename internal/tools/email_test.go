@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestEmailTool(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	workDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workDir, "report.csv"), []byte("a,b\n1,2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewManager(Config{WorkDir: workDir, ConfirmDangerous: true}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var persisted []string
+	tool := NewEmailTool(m, EmailConfig{
+		Host:              "smtp.example.com",
+		From:              "Mujibot <bot@example.com>",
+		AllowedRecipients: []string{"me@example.com", "@team.example.com"},
+		OnNewRecipient:    func(addr string) { persisted = append(persisted, addr) },
+	})
+
+	var sentTo []string
+	var sent []byte
+	tool.send = func(cfg EmailConfig, from string, to []string, msg []byte) error {
+		if from != "bot@example.com" {
+			t.Errorf("from = %q", from)
+		}
+		sentTo, sent = to, msg
+		return nil
+	}
+
+	// 白名单地址和域名无需确认
+	if _, err := tool.Execute(map[string]interface{}{
+		"to":          []interface{}{"me@example.com", "Bob <bob@team.example.com>"},
+		"subject":     "每日报告",
+		"body":        "见附件",
+		"attachments": []interface{}{"report.csv"},
+	}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if strings.Join(sentTo, ",") != "me@example.com,bob@team.example.com" {
+		t.Errorf("recipients = %v", sentTo)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(sent)))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); subject != "每日报告" {
+		t.Errorf("subject = %q", subject)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	var parts []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(part)
+		parts = append(parts, part.FileName()+"="+strings.TrimSpace(string(data)))
+	}
+	if len(parts) != 2 || !strings.HasPrefix(parts[1], "report.csv=") {
+		t.Errorf("parts = %v", parts)
+	}
+
+	// 新收件人需要确认
+	sent = nil
+	_, err = tool.Execute(map[string]interface{}{"to": "stranger@example.org", "subject": "hi", "body": "hello"})
+	if err == nil || !strings.Contains(err.Error(), "confirm=true") || sent != nil {
+		t.Fatalf("unconfirmed recipient: err = %v, sent = %v", err, sent != nil)
+	}
+	if _, err := tool.Execute(map[string]interface{}{"to": "stranger@example.org", "subject": "hi", "body": "hello", "confirm": true}); err != nil {
+		t.Fatalf("confirmed send error = %v", err)
+	}
+	if len(persisted) != 1 || persisted[0] != "stranger@example.org" {
+		t.Errorf("persisted = %v", persisted)
+	}
+	// 确认过的收件人之后无需再次确认
+	if _, err := tool.Execute(map[string]interface{}{"to": "Stranger@Example.org", "subject": "again", "body": "hello"}); err != nil {
+		t.Errorf("known recipient error = %v", err)
+	}
+
+	if _, err := tool.Execute(map[string]interface{}{"to": "me@example.com", "subject": "x", "body": "y", "attachments": []interface{}{"../etc/passwd"}}); err == nil {
+		t.Error("attachments outside workDir should be rejected")
+	}
+	if _, err := tool.Execute(map[string]interface{}{"to": "not an address", "subject": "x", "body": "y"}); err == nil {
+		t.Error("invalid recipient should be rejected")
+	}
+}
@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"context"
+	"time"
+)
+
+// 工具调用事件类型
+const (
+	ToolCallEvent   = "tool_call"
+	ToolResultEvent = "tool_result"
+)
+
+// ToolEvent 工具调用事件，供调试控制台绘制每个请求的工具时间线。
+// 同一次调用的 tool_call 和 tool_result 事件 ID 相同
+type ToolEvent struct {
+	Type     string
+	ID       uint64
+	Tool     string
+	Args     map[string]interface{} // 仅 tool_call
+	Result   string                 // 仅 tool_result
+	Err      error                  // 仅 tool_result
+	Duration time.Duration          // 仅 tool_result
+}
+
+// SetObserver 设置工具调用事件回调，回调在执行工具的协程中同步调用，不应阻塞
+func (m *Manager) SetObserver(fn func(ctx context.Context, e ToolEvent)) {
+	m.observer = fn
+}
+
+// Execute 执行工具并在前后发出 tool_call 和 tool_result 事件
+func (m *Manager) Execute(ctx context.Context, name string, args map[string]interface{}) (string, error) {
+	if m.observer == nil {
+		return m.execute(ctx, name, args)
+	}
+
+	id := m.callSeq.Add(1)
+	m.observer(ctx, ToolEvent{Type: ToolCallEvent, ID: id, Tool: name, Args: args})
+	start := time.Now()
+	result, err := m.execute(ctx, name, args)
+	m.observer(ctx, ToolEvent{Type: ToolResultEvent, ID: id, Tool: name, Result: result, Err: err, Duration: time.Since(start)})
+	return result, err
+}
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestToolEvents(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+
+	m, err := NewManager(Config{WorkDir: t.TempDir()}, log)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	var events []ToolEvent
+	m.SetObserver(func(ctx context.Context, e ToolEvent) {
+		events = append(events, e)
+	})
+
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		wantErr bool
+	}{
+		{"success", map[string]interface{}{"path": "a.txt", "content": "x"}, false},
+		{"failure", map[string]interface{}{"path": "../outside.txt", "content": "x"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events = nil
+			_, err := m.Execute(context.Background(), "write_file", tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(events) != 2 {
+				t.Fatalf("got %d events, want 2", len(events))
+			}
+			call, result := events[0], events[1]
+			if call.Type != ToolCallEvent || result.Type != ToolResultEvent {
+				t.Errorf("event types = %s, %s", call.Type, result.Type)
+			}
+			if call.ID == 0 || call.ID != result.ID || call.Tool != "write_file" {
+				t.Errorf("call = %+v, result = %+v", call, result)
+			}
+			if call.Args["path"] != tt.args["path"] {
+				t.Errorf("call args = %v", call.Args)
+			}
+			if (result.Err != nil) != tt.wantErr || result.Duration < 0 {
+				t.Errorf("result err = %v, duration = %v", result.Err, result.Duration)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// exchangeRateURL exchangerate-api.com 免费API，查询最新汇率
+	exchangeRateURL = "https://api.exchangerate-api.com/v4/latest"
+	// historicalRateURL Frankfurter（欧洲央行参考汇率）免费API，查询历史汇率
+	historicalRateURL = "https://api.frankfurter.app"
+
+	// latestRateCacheTTL 最新汇率表的缓存时间，上游每天更新一次
+	latestRateCacheTTL = time.Hour
+	// historicalRateCacheTTL 历史汇率表不会变化，缓存更久
+	historicalRateCacheTTL = 24 * time.Hour
+	// maxRateCacheEntries 缓存的汇率表数量上限
+	maxRateCacheEntries = 64
+)
+
+// ExchangeRateTool 汇率查询工具，按基准货币和日期缓存汇率表，在本地完成金额换算；raw 时返回接口原始JSON
+type ExchangeRateTool struct {
+	manager *Manager
+
+	// 测试时可替换的接口地址
+	apiURL        string
+	historicalURL string
+
+	mu    sync.Mutex
+	cache map[string]rateCacheEntry
+}
+
+type rateCacheEntry struct {
+	table   *rateTable
+	raw     string
+	expires time.Time
+}
+
+// rateTable 一个基准货币某天的汇率表，两个接口的字段相同
+type rateTable struct {
+	Base  string             `json:"base"`
+	Date  string             `json:"date"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+func (t *ExchangeRateTool) Name() string {
+	return "exchange_rate"
+}
+
+func (t *ExchangeRateTool) Description() string {
+	return "查询货币汇率并换算金额，支持历史日期。最新汇率来自 exchangerate-api.com，历史汇率来自欧洲央行（Frankfurter），均为免费API。"
+}
+
+func (t *ExchangeRateTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"from": map[string]interface{}{
+				"type":        "string",
+				"description": "源货币代码，如 USD, CNY, EUR",
+			},
+			"to": map[string]interface{}{
+				"type":        "string",
+				"description": "目标货币代码，如 CNY, USD, EUR",
+			},
+			"amount": map[string]interface{}{
+				"type":        "number",
+				"description": "换算的金额（源货币），默认1，由工具计算结果",
+			},
+			"date": map[string]interface{}{
+				"type":        "string",
+				"description": "历史日期 YYYY-MM-DD，留空查询最新汇率。历史汇率为欧洲央行参考汇率，只支持主要货币，非工作日返回之前最近一天的汇率",
+			},
+			"raw": map[string]interface{}{
+				"type":        "boolean",
+				"description": "返回接口原始JSON（包含所有货币），默认只返回两种货币的汇率",
+			},
+		},
+		"required": []string{"from", "to"},
+	}
+}
+
+func (t *ExchangeRateTool) Execute(args map[string]interface{}) (string, error) {
+	from, ok := args["from"].(string)
+	if !ok || strings.TrimSpace(from) == "" {
+		return "", fmt.Errorf("from currency is required")
+	}
+	from = strings.ToUpper(strings.TrimSpace(from))
+
+	to, ok := args["to"].(string)
+	if !ok || strings.TrimSpace(to) == "" {
+		return "", fmt.Errorf("to currency is required")
+	}
+	to = strings.ToUpper(strings.TrimSpace(to))
+	raw, _ := args["raw"].(bool)
+
+	amount := 1.0
+	if a, ok := args["amount"].(float64); ok {
+		if a <= 0 || math.IsInf(a, 0) || math.IsNaN(a) {
+			return "", fmt.Errorf("amount must be a positive number")
+		}
+		amount = a
+	}
+
+	date, _ := args["date"].(string)
+	date = strings.TrimSpace(date)
+	if date != "" {
+		d, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return "", fmt.Errorf("invalid date %q, use YYYY-MM-DD", date)
+		}
+		if d.After(time.Now()) {
+			return "", fmt.Errorf("date %s is in the future", date)
+		}
+	}
+
+	entry, err := t.rates(from, date)
+	if err != nil {
+		return "", err
+	}
+	if raw {
+		return entry.raw, nil
+	}
+
+	rate := 1.0
+	if to != from {
+		r, ok := entry.table.Rates[to]
+		if !ok || r <= 0 {
+			return "", fmt.Errorf("unknown currency: %s", to)
+		}
+		rate = r
+	}
+
+	var sb strings.Builder
+	if amount != 1 {
+		fmt.Fprintf(&sb, "%s %s = %s %s\n", formatDecimal(amount), from, formatDecimal(amount*rate), to)
+	}
+	fmt.Fprintf(&sb, "1 %s = %s %s\n1 %s = %s %s", from, formatDecimal(rate), to, to, formatDecimal(1/rate), from)
+	if d := entry.table.Date; d != "" {
+		sb.WriteString("\nDate: " + d)
+		if date != "" && d != date {
+			sb.WriteString(" (nearest available to " + date + ")")
+		}
+	}
+	return sb.String(), nil
+}
+
+// rates 返回基准货币的汇率表，date 为空时查询最新汇率，命中缓存时不请求接口
+func (t *ExchangeRateTool) rates(base, date string) (rateCacheEntry, error) {
+	key := base + "|" + date
+	if entry, ok := t.cached(key); ok {
+		return entry, nil
+	}
+
+	u := fmt.Sprintf("%s/%s", t.endpoint(), url.PathEscape(base))
+	ttl := latestRateCacheTTL
+	if date != "" {
+		u = fmt.Sprintf("%s/%s?from=%s", t.historicalEndpoint(), date, url.QueryEscape(base))
+		ttl = historicalRateCacheTTL
+	}
+	body, err := fetchBody(u)
+	if err != nil {
+		return rateCacheEntry{}, fmt.Errorf("exchange rate request failed: %w", err)
+	}
+
+	var table rateTable
+	if err := json.Unmarshal(body, &table); err != nil {
+		return rateCacheEntry{}, fmt.Errorf("failed to parse exchange response: %w", err)
+	}
+	if len(table.Rates) == 0 {
+		return rateCacheEntry{}, fmt.Errorf("no exchange rates for %s", base)
+	}
+
+	entry := rateCacheEntry{table: &table, raw: string(body), expires: time.Now().Add(ttl)}
+	t.store(key, entry)
+	return entry, nil
+}
+
+// cached 读取未过期的汇率表
+func (t *ExchangeRateTool) cached(key string) (rateCacheEntry, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.cache[key]
+	if !ok || time.Now().After(entry.expires) {
+		return rateCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// store 写入缓存，清理过期项，超过上限时淘汰最早过期的一项
+func (t *ExchangeRateTool) store(key string, entry rateCacheEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cache == nil {
+		t.cache = make(map[string]rateCacheEntry)
+	}
+	now := time.Now()
+	for k, e := range t.cache {
+		if now.After(e.expires) {
+			delete(t.cache, k)
+		}
+	}
+	if len(t.cache) >= maxRateCacheEntries {
+		oldest := ""
+		for k, e := range t.cache {
+			if oldest == "" || e.expires.Before(t.cache[oldest].expires) {
+				oldest = k
+			}
+		}
+		delete(t.cache, oldest)
+	}
+	t.cache[key] = entry
+}
+
+func (t *ExchangeRateTool) endpoint() string {
+	if t.apiURL != "" {
+		return t.apiURL
+	}
+	return exchangeRateURL
+}
+
+func (t *ExchangeRateTool) historicalEndpoint() string {
+	if t.historicalURL != "" {
+		return t.historicalURL
+	}
+	return historicalRateURL
+}
+
+// formatDecimal 格式化金额和汇率：大于1时保留4位小数，小于1时保留约6位有效数字，去掉末尾的0
+func formatDecimal(v float64) string {
+	prec := 4
+	if a := math.Abs(v); a > 0 && a < 1 {
+		prec = int(-math.Floor(math.Log10(a))) + 5
+	}
+	s := strconv.FormatFloat(v, 'f', prec, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExchangeRateTool(t *testing.T) {
+	var latest, historical int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&latest, 1)
+		if r.URL.Path != "/latest/USD" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"base":"USD","date":"2024-05-01","rates":{"USD":1,"CNY":7.2345,"JPY":155.8,"EUR":0.93}}`))
+	})
+	mux.HandleFunc("/history/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&historical, 1)
+		if r.URL.Path != "/history/2020-03-07" || r.URL.Query().Get("from") != "USD" {
+			http.NotFound(w, r)
+			return
+		}
+		// 周末返回之前最近一个工作日的汇率，不含基准货币本身
+		w.Write([]byte(`{"amount":1.0,"base":"USD","date":"2020-03-06","rates":{"CNY":6.9321,"EUR":0.88}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tool := &ExchangeRateTool{apiURL: server.URL + "/latest", historicalURL: server.URL + "/history"}
+
+	result, err := tool.Execute(map[string]interface{}{"from": "usd", "to": " cny "})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	want := "1 USD = 7.2345 CNY\n1 CNY = 0.138227 USD\nDate: 2024-05-01"
+	if result != want {
+		t.Errorf("result = %q, want %q", result, want)
+	}
+
+	// 金额在本地换算，同一基准货币的汇率表命中缓存
+	result, err = tool.Execute(map[string]interface{}{"from": "USD", "to": "JPY", "amount": float64(250)})
+	if err != nil {
+		t.Fatalf("Execute() with amount error = %v", err)
+	}
+	if !strings.HasPrefix(result, "250 USD = 38950 JPY\n1 USD = 155.8 JPY\n") {
+		t.Errorf("amount result = %q", result)
+	}
+	result, err = tool.Execute(map[string]interface{}{"from": "USD", "to": "JPY", "raw": true})
+	if err != nil || !strings.Contains(result, `"EUR":0.93`) {
+		t.Errorf("raw result = %q, %v", result, err)
+	}
+	if latest != 1 {
+		t.Errorf("latest requests = %d, want 1", latest)
+	}
+
+	result, err = tool.Execute(map[string]interface{}{"from": "USD", "to": "CNY", "amount": float64(10), "date": "2020-03-07"})
+	if err != nil {
+		t.Fatalf("historical Execute() error = %v", err)
+	}
+	want = "10 USD = 69.321 CNY\n1 USD = 6.9321 CNY\n1 CNY = 0.144256 USD\nDate: 2020-03-06 (nearest available to 2020-03-07)"
+	if result != want {
+		t.Errorf("historical result = %q, want %q", result, want)
+	}
+	if result, err := tool.Execute(map[string]interface{}{"from": "USD", "to": "usd", "amount": float64(3), "date": "2020-03-07"}); err != nil || !strings.HasPrefix(result, "3 USD = 3 USD") {
+		t.Errorf("same currency result = %q, %v", result, err)
+	}
+	if historical != 1 {
+		t.Errorf("historical requests = %d, want 1", historical)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"from": "USD", "to": "XYZ"},
+		{"from": "XYZ", "to": "USD"},
+		{"from": "USD", "to": "CNY", "amount": float64(-5)},
+		{"from": "USD", "to": "CNY", "date": "2020/03/07"},
+		{"from": "USD", "to": "CNY", "date": time.Now().AddDate(0, 0, 2).Format("2006-01-02")},
+	} {
+		if _, err := tool.Execute(args); err == nil {
+			t.Errorf("Execute(%v) should fail", args)
+		}
+	}
+}
+
+func TestFormatDecimal(t *testing.T) {
+	tests := map[float64]string{
+		7.2345:     "7.2345",
+		1234567.89: "1234567.89",
+		100:        "100",
+		0.138227:   "0.138227",
+		0.00012345: "0.00012345",
+		1.23456789: "1.2346",
+	}
+	for v, want := range tests {
+		if got := formatDecimal(v); got != want {
+			t.Errorf("formatDecimal(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
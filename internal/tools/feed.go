@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/feed"
+	"github.com/HaohanHe/mujibot/internal/httpclient"
+)
+
+const (
+	defaultFeedItems = 10
+	maxFeedItems     = 50
+	// maxFeedSummary 每个条目摘要的最大字符数
+	maxFeedSummary = 300
+)
+
+// ReadFeedTool 读取 RSS/Atom 订阅源
+type ReadFeedTool struct {
+	manager *Manager
+}
+
+func (t *ReadFeedTool) Name() string {
+	return "read_feed"
+}
+
+func (t *ReadFeedTool) Description() string {
+	return "读取 RSS/Atom 订阅源，返回最新条目的标题、链接、发布时间和摘要，可按关键词过滤。"
+}
+
+func (t *ReadFeedTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"url": map[string]interface{}{
+				"type":        "string",
+				"description": "订阅源地址",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("返回条目数（默认%d，最多%d）", defaultFeedItems, maxFeedItems),
+			},
+			"keywords": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "只返回标题或摘要包含任一关键词的条目",
+			},
+		},
+		"required": []string{"url"},
+	}
+}
+
+func (t *ReadFeedTool) Execute(args map[string]interface{}) (string, error) {
+	urlStr, ok := args["url"].(string)
+	if !ok || urlStr == "" {
+		return "", fmt.Errorf("url is required")
+	}
+	if _, err := CheckURL(urlStr); err != nil {
+		return "", err
+	}
+
+	limit := defaultFeedItems
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		limit = int(v)
+	}
+	if limit > maxFeedItems {
+		limit = maxFeedItems
+	}
+
+	var keywords []string
+	if list, ok := args["keywords"].([]interface{}); ok {
+		for _, v := range list {
+			if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+				keywords = append(keywords, strings.TrimSpace(s))
+			}
+		}
+	}
+
+	client := httpclient.New(15 * time.Second)
+	f, err := feed.Fetch(context.Background(), client, urlStr)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Feed: %s\n", f.Title)
+	if f.Link != "" {
+		fmt.Fprintf(&sb, "Link: %s\n", f.Link)
+	}
+
+	count := 0
+	for _, item := range f.Items {
+		if count >= limit {
+			break
+		}
+		if !item.Matches(keywords) {
+			continue
+		}
+		count++
+
+		fmt.Fprintf(&sb, "\n%d. %s\n", count, item.Title)
+		if !item.Published.IsZero() {
+			fmt.Fprintf(&sb, "   Published: %s\n", item.Published.Format("2006-01-02 15:04 MST"))
+		}
+		if item.Link != "" {
+			fmt.Fprintf(&sb, "   Link: %s\n", item.Link)
+		}
+		if item.Summary != "" {
+			summary := []rune(item.Summary)
+			if len(summary) > maxFeedSummary {
+				summary = append(summary[:maxFeedSummary], '…')
+			}
+			fmt.Fprintf(&sb, "   %s\n", string(summary))
+		}
+	}
+
+	if count == 0 {
+		sb.WriteString("\nNo matching items.")
+	}
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
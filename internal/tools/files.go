@@ -0,0 +1,484 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// isWorkDirRoot 判断路径是否为工作目录或某个工作区的根目录
+func (m *Manager) isWorkDirRoot(path string) bool {
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	for _, ws := range m.workspaces {
+		if root, err := filepath.EvalSymlinks(ws.Path); err == nil && realPath == root {
+			return true
+		}
+	}
+	return false
+}
+
+// relPath 返回相对工作目录的路径，用于输出。其他工作区和用户个人目录中的路径保持绝对路径，
+// 因为用户的相对路径基于其个人目录
+func (m *Manager) relPath(path string) string {
+	rel, err := filepath.Rel(m.workDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	if m.perUserWorkDir && (rel == UsersDirName || strings.HasPrefix(rel, UsersDirName+string(filepath.Separator))) {
+		return path
+	}
+	return rel
+}
+
+// resolveTransfer 解析移动/复制的源和目标路径，目标为已存在目录时放入其中。
+// 目标工作区默认与源相同，移动时源所在工作区也须可写
+func (m *Manager) resolveTransfer(args map[string]interface{}, move bool) (string, string, error) {
+	source, _ := args["source"].(string)
+	destination, _ := args["destination"].(string)
+	if source == "" || destination == "" {
+		return "", "", fmt.Errorf("source and destination are required")
+	}
+
+	src, err := m.resolvePath(args, source, move)
+	if err != nil {
+		return "", "", err
+	}
+	// 只替换工作区，其余参数（包括调用者）保持不变，按用户隔离时仍检查目标是否在个人目录内
+	dstArgs := args
+	if ws, _ := args["destination_workspace"].(string); ws != "" {
+		dstArgs = make(map[string]interface{}, len(args))
+		for k, v := range args {
+			dstArgs[k] = v
+		}
+		dstArgs["workspace"] = ws
+	}
+	dst, err := m.resolvePath(dstArgs, destination, true)
+	if err != nil {
+		return "", "", err
+	}
+
+	srcInfo, err := os.Lstat(src)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to stat source: %w", err)
+	}
+	if m.isWorkDirRoot(src) {
+		return "", "", fmt.Errorf("cannot move or copy the work directory itself")
+	}
+
+	if info, err := os.Stat(dst); err == nil && info.IsDir() {
+		dst = filepath.Join(dst, filepath.Base(src))
+	}
+
+	if srcInfo.IsDir() {
+		if rel, err := filepath.Rel(src, dst); err == nil && !strings.HasPrefix(rel, "..") {
+			return "", "", fmt.Errorf("cannot move or copy a directory into itself")
+		}
+	}
+
+	if _, err := os.Lstat(dst); err == nil {
+		if overwrite, _ := args["overwrite"].(bool); !overwrite {
+			return "", "", fmt.Errorf("destination already exists: %s，设置 overwrite=true 来覆盖", m.relPath(dst))
+		}
+		if err := os.RemoveAll(dst); err != nil {
+			return "", "", fmt.Errorf("failed to remove destination: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	return src, dst, nil
+}
+
+// transferParams 移动/复制工具的参数定义
+func (m *Manager) transferParams() map[string]interface{} {
+	destWorkspace := m.workspaceParam()
+	destWorkspace["description"] = "目标路径所在的工作区，默认与 workspace 相同"
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"workspace":             m.workspaceParam(),
+			"destination_workspace": destWorkspace,
+			"source": map[string]interface{}{
+				"type":        "string",
+				"description": "源文件或目录路径（相对workDir或绝对路径）",
+			},
+			"destination": map[string]interface{}{
+				"type":        "string",
+				"description": "目标路径，若为已存在的目录则放入该目录",
+			},
+			"overwrite": map[string]interface{}{
+				"type":        "boolean",
+				"description": "目标已存在时是否覆盖",
+			},
+		},
+		"required": []string{"source", "destination"},
+	}
+}
+
+// copyPath 递归复制文件或目录，符号链接按链接本身复制
+func copyPath(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// MoveFileTool 移动/重命名文件工具
+type MoveFileTool struct {
+	manager *Manager
+}
+
+func (t *MoveFileTool) Name() string {
+	return "move_file"
+}
+
+func (t *MoveFileTool) Description() string {
+	return "移动或重命名文件/目录。"
+}
+
+func (t *MoveFileTool) Parameters() map[string]interface{} {
+	return t.manager.transferParams()
+}
+
+func (t *MoveFileTool) Execute(args map[string]interface{}) (string, error) {
+	src, dst, err := t.manager.resolveTransfer(args, true)
+	if err != nil {
+		return "", err
+	}
+	if err := t.manager.enforcePolicy(t.Name(), args, "", src, dst); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(src, dst); err != nil {
+		// 跨文件系统时改为复制后删除
+		if !errors.Is(err, syscall.EXDEV) {
+			return "", fmt.Errorf("failed to move: %w", err)
+		}
+		if err := copyPath(src, dst); err != nil {
+			os.RemoveAll(dst)
+			return "", fmt.Errorf("failed to move: %w", err)
+		}
+		if err := os.RemoveAll(src); err != nil {
+			return "", fmt.Errorf("copied but failed to remove source: %w", err)
+		}
+	}
+
+	return fmt.Sprintf("Moved %s -> %s", t.manager.relPath(src), t.manager.relPath(dst)), nil
+}
+
+// CopyFileTool 复制文件工具
+type CopyFileTool struct {
+	manager *Manager
+}
+
+func (t *CopyFileTool) Name() string {
+	return "copy_file"
+}
+
+func (t *CopyFileTool) Description() string {
+	return "复制文件或目录（目录递归复制）。"
+}
+
+func (t *CopyFileTool) Parameters() map[string]interface{} {
+	return t.manager.transferParams()
+}
+
+func (t *CopyFileTool) Execute(args map[string]interface{}) (string, error) {
+	src, dst, err := t.manager.resolveTransfer(args, false)
+	if err != nil {
+		return "", err
+	}
+	if err := t.manager.enforcePolicy(t.Name(), args, "", src, dst); err != nil {
+		return "", err
+	}
+
+	if err := copyPath(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return "", fmt.Errorf("failed to copy: %w", err)
+	}
+
+	return fmt.Sprintf("Copied %s -> %s", t.manager.relPath(src), t.manager.relPath(dst)), nil
+}
+
+// DeleteFileTool 删除文件工具
+type DeleteFileTool struct {
+	manager *Manager
+}
+
+func (t *DeleteFileTool) Name() string {
+	return "delete_file"
+}
+
+func (t *DeleteFileTool) Description() string {
+	return "删除文件或目录。删除非空目录需要 recursive=true，开启危险操作确认时需要用户确认。"
+}
+
+func (t *DeleteFileTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"workspace": t.manager.workspaceParam(),
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "文件或目录路径（相对workDir或绝对路径）",
+			},
+			"recursive": map[string]interface{}{
+				"type":        "boolean",
+				"description": "是否递归删除非空目录",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+// Plan 安全模式下展示将要删除的内容
+func (t *DeleteFileTool) Plan(args map[string]interface{}) (string, error) {
+	path, _ := args["path"].(string)
+	if path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+
+	safePath, err := t.manager.resolvePath(args, path, true)
+	if err != nil {
+		return "", err
+	}
+	if t.manager.isWorkDirRoot(safePath) {
+		return "", fmt.Errorf("cannot delete the work directory itself")
+	}
+	info, err := os.Lstat(safePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat path: %w", err)
+	}
+
+	rel := t.manager.relPath(safePath)
+	if !info.IsDir() {
+		return fmt.Sprintf("Delete file %s (%d bytes)", rel, info.Size()), nil
+	}
+	files, size := 0, int64(0)
+	filepath.WalkDir(safePath, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files++
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return fmt.Sprintf("Delete directory %s and everything in it (%d files, %d bytes)", rel, files, size), nil
+}
+
+func (t *DeleteFileTool) Execute(args map[string]interface{}) (string, error) {
+	path, ok := args["path"].(string)
+	if !ok || path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+
+	safePath, err := t.manager.resolvePath(args, path, true)
+	if err != nil {
+		return "", err
+	}
+	if t.manager.isWorkDirRoot(safePath) {
+		return "", fmt.Errorf("cannot delete the work directory itself")
+	}
+
+	info, err := os.Lstat(safePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat path: %w", err)
+	}
+
+	recursive, _ := args["recursive"].(bool)
+	if info.IsDir() && !recursive {
+		entries, err := os.ReadDir(safePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read directory: %w", err)
+		}
+		if len(entries) > 0 {
+			return "", fmt.Errorf("directory is not empty (%d entries)，设置 recursive=true 来删除", len(entries))
+		}
+	}
+
+	if err := t.manager.enforcePolicy(t.Name(), args, "", safePath); err != nil {
+		return "", err
+	}
+
+	err = t.manager.history.Track(t.Name(), safePath, func() error {
+		return os.RemoveAll(safePath)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to delete: %w", err)
+	}
+
+	return fmt.Sprintf("Deleted %s", t.manager.relPath(safePath)), nil
+}
+
+// MakeDirectoryTool 创建目录工具
+type MakeDirectoryTool struct {
+	manager *Manager
+}
+
+func (t *MakeDirectoryTool) Name() string {
+	return "make_directory"
+}
+
+func (t *MakeDirectoryTool) Description() string {
+	return "创建目录，父目录不存在时一并创建。"
+}
+
+func (t *MakeDirectoryTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"workspace": t.manager.workspaceParam(),
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "目录路径（相对workDir或绝对路径）",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+func (t *MakeDirectoryTool) Execute(args map[string]interface{}) (string, error) {
+	path, ok := args["path"].(string)
+	if !ok || path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+
+	safePath, err := t.manager.resolvePath(args, path, true)
+	if err != nil {
+		return "", err
+	}
+	if err := t.manager.enforcePolicy(t.Name(), args, "", safePath); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(safePath, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	return fmt.Sprintf("Directory created: %s", t.manager.relPath(safePath)), nil
+}
+
+// StatTool 查看文件信息工具
+type StatTool struct {
+	manager *Manager
+}
+
+func (t *StatTool) Name() string {
+	return "stat"
+}
+
+func (t *StatTool) Description() string {
+	return "查看文件或目录的信息：类型、大小、权限、修改时间。"
+}
+
+func (t *StatTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"workspace": t.manager.workspaceParam(),
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "文件或目录路径（相对workDir或绝对路径）",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+func (t *StatTool) Execute(args map[string]interface{}) (string, error) {
+	path, ok := args["path"].(string)
+	if !ok || path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+
+	safePath, err := t.manager.resolvePath(args, path, false)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Lstat(safePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat path: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"path":     t.manager.relPath(safePath),
+		"size":     info.Size(),
+		"mode":     info.Mode().Perm().String(),
+		"modified": info.ModTime().Format(time.RFC3339),
+	}
+	switch {
+	case info.IsDir():
+		result["type"] = "directory"
+		if entries, err := os.ReadDir(safePath); err == nil {
+			result["entries"] = len(entries)
+		}
+	case info.Mode()&os.ModeSymlink != 0:
+		result["type"] = "symlink"
+		if link, err := os.Readlink(safePath); err == nil {
+			result["target"] = link
+		}
+	default:
+		result["type"] = "file"
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
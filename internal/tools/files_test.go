@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestFileTools(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	workDir := t.TempDir()
+	m, err := NewManager(Config{WorkDir: workDir, ConfirmDangerous: true}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	exec := func(name string, args map[string]interface{}) (string, error) {
+		return m.Execute(ctx, name, args)
+	}
+	exists := func(rel string) bool {
+		_, err := os.Lstat(filepath.Join(workDir, rel))
+		return err == nil
+	}
+
+	if _, err := exec("make_directory", map[string]interface{}{"path": "docs/2024"}); err != nil {
+		t.Fatalf("make_directory error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "notes.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := exec("stat", map[string]interface{}{"path": "notes.txt"})
+	if err != nil || !strings.Contains(result, `"type": "file"`) || !strings.Contains(result, `"size": 5`) {
+		t.Errorf("stat = %q, %v", result, err)
+	}
+
+	// 目标为目录时放入其中
+	if _, err := exec("copy_file", map[string]interface{}{"source": "notes.txt", "destination": "docs"}); err != nil {
+		t.Fatalf("copy_file error = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workDir, "docs/notes.txt")); string(data) != "hello" {
+		t.Errorf("copied content = %q", data)
+	}
+	if _, err := exec("copy_file", map[string]interface{}{"source": "notes.txt", "destination": "docs/notes.txt"}); err == nil {
+		t.Error("copy over existing file without overwrite should fail")
+	}
+	if _, err := exec("copy_file", map[string]interface{}{"source": "docs", "destination": "docs/2024/backup"}); err == nil {
+		t.Error("copying a directory into itself should fail")
+	}
+	if _, err := exec("copy_file", map[string]interface{}{"source": "docs", "destination": "archive"}); err != nil || !exists("archive/notes.txt") || !exists("archive/2024") {
+		t.Errorf("recursive copy_file error = %v", err)
+	}
+
+	if _, err := exec("move_file", map[string]interface{}{"source": "notes.txt", "destination": "docs/2024/renamed.txt"}); err != nil {
+		t.Fatalf("move_file error = %v", err)
+	}
+	if exists("notes.txt") || !exists("docs/2024/renamed.txt") {
+		t.Error("move_file did not move the file")
+	}
+	if _, err := exec("move_file", map[string]interface{}{"source": "docs/2024/renamed.txt", "destination": "../outside.txt"}); err == nil {
+		t.Error("destination outside workDir should be rejected")
+	}
+
+	// 删除需要用户确认（未配置确认渠道时拒绝，confirm=true 不算确认），非空目录需要 recursive
+	if _, err := exec("delete_file", map[string]interface{}{"path": "archive/notes.txt", "confirm": true}); err == nil || !strings.Contains(err.Error(), "未配置确认渠道") {
+		t.Errorf("unconfirmed delete_file error = %v", err)
+	}
+	if _, err := exec("delete_file", WithApproval(map[string]interface{}{"path": "archive"})); err == nil || !strings.Contains(err.Error(), "recursive=true") {
+		t.Errorf("non-recursive delete_file error = %v", err)
+	}
+	if _, err := exec("delete_file", WithApproval(map[string]interface{}{"path": "archive", "recursive": true})); err != nil || exists("archive") {
+		t.Errorf("delete_file error = %v", err)
+	}
+	if _, err := exec("delete_file", WithApproval(map[string]interface{}{"path": ".", "recursive": true})); err == nil {
+		t.Error("deleting the work directory should be rejected")
+	}
+}
@@ -0,0 +1,507 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// grepMaxFileSize 超过此大小的文件不搜索
+	grepMaxFileSize = 10 * 1024 * 1024
+	// grepSniffSize 二进制检测读取的字节数
+	grepSniffSize = 8000
+	// grepMaxLineLen 输出中单行的最大长度
+	grepMaxLineLen = 300
+	// grepMaxWorkers 并行搜索的最大协程数
+	grepMaxWorkers = 8
+	// grepMaxResults max_results 参数的上限
+	grepMaxResults = 500
+)
+
+// grepSkipDirs 始终跳过的目录
+var grepSkipDirs = map[string]bool{
+	".git":           true,
+	".hg":            true,
+	".svn":           true,
+	HistoryDirName:   true,
+	ArtifactsDirName: true,
+}
+
+// ignoreRule .gitignore 中的一条规则
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreSet 一个 .gitignore 文件的规则，base 为其所在目录
+type ignoreSet struct {
+	base  string
+	rules []ignoreRule
+}
+
+// parseGitignore 解析 .gitignore 内容
+func parseGitignore(base string, data []byte) *ignoreSet {
+	set := &ignoreSet{base: base}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimRight(line, " ")
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		// 不含中间斜杠的模式匹配任意层级
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		expr := globToRegexp(line)
+		if !anchored {
+			expr = "(?:.*/)?" + expr
+		}
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			continue
+		}
+		rule.re = re
+		set.rules = append(set.rules, rule)
+	}
+	return set
+}
+
+// globToRegexp 将 gitignore 风格的通配符转换为正则
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					// **/ 匹配零或多级目录
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			if j := strings.IndexByte(glob[i:], ']'); j > 0 {
+				class := glob[i+1 : i+j]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				sb.WriteString("[" + class + "]")
+				i += j
+			} else {
+				sb.WriteString(`\[`)
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// ignored 按从外到内的顺序应用规则，最后匹配的规则生效
+func ignored(sets []*ignoreSet, path string, isDir bool) bool {
+	result := false
+	for _, set := range sets {
+		rel, err := filepath.Rel(set.base, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, rule := range set.rules {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.re.MatchString(rel) {
+				result = !rule.negate
+			}
+		}
+	}
+	return result
+}
+
+// grepOptions 搜索参数
+type grepOptions struct {
+	re         *regexp.Regexp
+	context    int
+	maxPerFile int
+	maxResults int
+}
+
+// grepFileResult 单个文件的搜索结果
+type grepFileResult struct {
+	path      string
+	lines     []string
+	matches   int
+	truncated bool
+}
+
+// isBinary 根据开头是否含有NUL字节判断二进制文件
+func isBinary(head []byte) bool {
+	return bytes.IndexByte(head, 0) >= 0
+}
+
+// grepFile 搜索单个文件，total 为全局匹配计数
+func grepFile(path, rel string, opts grepOptions, total *int64) (*grepFileResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReaderSize(f, 64*1024)
+	head, _ := reader.Peek(grepSniffSize)
+	if isBinary(head) {
+		return nil, nil
+	}
+
+	result := &grepFileResult{path: rel}
+	var before []string // 上文环形缓冲
+	after := 0          // 还需输出的下文行数
+	lastPrinted := 0
+	lineNo := 0
+
+	emit := func(no int, sep, text string) {
+		if lastPrinted > 0 && no > lastPrinted+1 && opts.context > 0 {
+			result.lines = append(result.lines, "--")
+		}
+		text = strings.TrimSpace(text)
+		if len(text) > grepMaxLineLen {
+			text = strings.ToValidUTF8(text[:grepMaxLineLen], "") + "..."
+		}
+		result.lines = append(result.lines, fmt.Sprintf("%s%s%d%s %s", rel, sep, no, sep, text))
+		lastPrinted = no
+	}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if line == "" && err != nil {
+			if err == io.EOF {
+				break
+			}
+			return result, err
+		}
+		lineNo++
+		line = strings.TrimRight(line, "\r\n")
+
+		if opts.re.MatchString(line) {
+			if result.matches >= opts.maxPerFile {
+				result.truncated = true
+				break
+			}
+			if atomic.AddInt64(total, 1) > int64(opts.maxResults) {
+				break
+			}
+			for i, l := range before {
+				emit(lineNo-len(before)+i, "-", l)
+			}
+			before = before[:0]
+			emit(lineNo, ":", line)
+			result.matches++
+			after = opts.context
+		} else if after > 0 {
+			emit(lineNo, "-", line)
+			after--
+		} else if opts.context > 0 {
+			before = append(before, line)
+			if len(before) > opts.context {
+				before = before[1:]
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	return result, nil
+}
+
+// GrepTool 并行搜索文件内容，遵循 .gitignore 并跳过二进制文件
+type GrepTool struct {
+	manager  *Manager
+	settings GrepSettings
+}
+
+func (t *GrepTool) Name() string {
+	return "grep"
+}
+
+func (t *GrepTool) Description() string {
+	return "在工作目录中搜索文件内容。支持正则表达式和上下文行，自动跳过 .gitignore 忽略的文件和二进制文件。"
+}
+
+func (t *GrepTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"workspace": t.manager.workspaceParam(),
+			"pattern": map[string]interface{}{
+				"type":        "string",
+				"description": "搜索模式（正则表达式）",
+			},
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "搜索路径（默认为workDir）",
+			},
+			"include": map[string]interface{}{
+				"type":        "string",
+				"description": "文件匹配模式（如 *.go）",
+			},
+			"ignore_case": map[string]interface{}{
+				"type":        "boolean",
+				"description": "忽略大小写",
+			},
+			"context": map[string]interface{}{
+				"type":        "integer",
+				"description": "匹配行前后显示的上下文行数（0-10）",
+			},
+			"max_per_file": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("每个文件最多显示的匹配数，默认%d", t.settings.maxPerFile()),
+			},
+			"max_results": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("总匹配数上限，默认%d，最大%d", t.settings.maxMatches(), t.settings.matchLimit()),
+			},
+			"no_ignore": map[string]interface{}{
+				"type":        "boolean",
+				"description": "不使用 .gitignore 规则",
+			},
+		},
+		"required": []string{"pattern"},
+	}
+}
+
+func (t *GrepTool) Execute(args map[string]interface{}) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+func (t *GrepTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (string, error) {
+	pattern, ok := args["pattern"].(string)
+	if !ok || pattern == "" {
+		return "", fmt.Errorf("pattern is required")
+	}
+
+	searchPath := "."
+	if p, ok := args["path"].(string); ok && p != "" {
+		searchPath = p
+	}
+
+	include := "*"
+	if i, ok := args["include"].(string); ok && i != "" {
+		include = i
+	}
+	if _, err := filepath.Match(include, ""); err != nil {
+		return "", fmt.Errorf("invalid include pattern: %w", err)
+	}
+
+	safePath, err := t.manager.resolvePath(args, searchPath, false)
+	if err != nil {
+		return "", err
+	}
+
+	if ignoreCase, _ := args["ignore_case"].(bool); ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	opts := grepOptions{re: re, maxPerFile: t.settings.maxPerFile(), maxResults: t.settings.maxMatches()}
+	if c, ok := args["context"].(float64); ok && c > 0 {
+		opts.context = int(c)
+		if opts.context > 10 {
+			opts.context = 10
+		}
+	}
+	if n, ok := args["max_per_file"].(float64); ok && n > 0 {
+		opts.maxPerFile = int(n)
+	}
+	if n, ok := args["max_results"].(float64); ok && n > 0 {
+		opts.maxResults = int(n)
+		if limit := t.settings.matchLimit(); opts.maxResults > limit {
+			opts.maxResults = limit
+		}
+	}
+	noIgnore, _ := args["no_ignore"].(bool)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var total int64
+	files := make(chan string, 64)
+	results := make(chan *grepFileResult, 64)
+
+	workers := runtime.NumCPU()
+	if workers > grepMaxWorkers {
+		workers = grepMaxWorkers
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range files {
+				if atomic.LoadInt64(&total) >= int64(opts.maxResults) {
+					cancel()
+					continue
+				}
+				result, err := grepFile(path, filepath.ToSlash(t.manager.relPath(path)), opts, &total)
+				if err == nil && result != nil && result.matches > 0 {
+					results <- result
+				}
+			}
+		}()
+	}
+
+	var collected []*grepFileResult
+	done := make(chan struct{})
+	go func() {
+		for r := range results {
+			collected = append(collected, r)
+		}
+		close(done)
+	}()
+
+	walkErr := t.walk(ctx, safePath, include, noIgnore, files)
+	close(files)
+	wg.Wait()
+	close(results)
+	<-done
+
+	if walkErr != nil && ctx.Err() == nil {
+		return "", walkErr
+	}
+	if len(collected) == 0 {
+		return "No matches found", nil
+	}
+
+	sort.Slice(collected, func(i, j int) bool {
+		return collected[i].path < collected[j].path
+	})
+
+	var sb strings.Builder
+	for i, r := range collected {
+		if i > 0 && opts.context > 0 {
+			sb.WriteString("--\n")
+		}
+		for _, line := range r.lines {
+			sb.WriteString(line + "\n")
+		}
+		if r.truncated {
+			fmt.Fprintf(&sb, "%s: [more matches omitted, max_per_file=%d]\n", r.path, opts.maxPerFile)
+		}
+	}
+	if atomic.LoadInt64(&total) >= int64(opts.maxResults) {
+		fmt.Fprintf(&sb, "[result limit %d reached, narrow the search with path or include]\n", opts.maxResults)
+	}
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
+
+// walk 遍历目录并把待搜索的文件发送给工作协程
+func (t *GrepTool) walk(ctx context.Context, root, include string, noIgnore bool, files chan<- string) error {
+	// 收集搜索根目录以上（到所在工作区根目录为止）的 .gitignore
+	var sets []*ignoreSet
+	if !noIgnore {
+		_, _, ws, _ := t.manager.checkPath(root)
+		var parents []string
+		for dir := filepath.Dir(root); ; dir = filepath.Dir(dir) {
+			if rel, err := filepath.Rel(ws.Path, dir); err != nil || strings.HasPrefix(rel, "..") {
+				break
+			}
+			parents = append([]string{dir}, parents...)
+			if dir == filepath.Dir(dir) {
+				break
+			}
+		}
+		for _, dir := range parents {
+			if data, err := os.ReadFile(filepath.Join(dir, ".gitignore")); err == nil {
+				sets = append(sets, parseGitignore(dir, data))
+			}
+		}
+	}
+
+	// 每个目录的规则集，子目录继承父目录
+	dirSets := map[string][]*ignoreSet{}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // 跳过错误
+		}
+		if ctx.Err() != nil {
+			return filepath.SkipAll
+		}
+
+		parentSets := sets
+		if path != root {
+			parentSets = dirSets[filepath.Dir(path)]
+		}
+
+		if d.IsDir() {
+			if path != root && (grepSkipDirs[d.Name()] || ignored(parentSets, path, true)) {
+				return filepath.SkipDir
+			}
+			current := parentSets
+			if !noIgnore {
+				if data, err := os.ReadFile(filepath.Join(path, ".gitignore")); err == nil {
+					current = append(append([]*ignoreSet{}, parentSets...), parseGitignore(path, data))
+				}
+			}
+			dirSets[path] = current
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if matched, _ := filepath.Match(include, d.Name()); !matched {
+			return nil
+		}
+		if path != root && ignored(parentSets, path, false) {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > grepMaxFileSize {
+			return nil
+		}
+
+		select {
+		case files <- path:
+		case <-ctx.Done():
+			return filepath.SkipAll
+		}
+		return nil
+	})
+}
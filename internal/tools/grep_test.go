@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestGitignore(t *testing.T) {
+	set := parseGitignore("/repo", []byte("# comment\n*.log\n!keep.log\nbuild/\n/vendor\ndocs/**/*.tmp\n"))
+	sets := []*ignoreSet{set}
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"/repo/app.log", false, true},
+		{"/repo/sub/deep/app.log", false, true},
+		{"/repo/keep.log", false, false},
+		{"/repo/build", true, true},
+		{"/repo/build", false, false},
+		{"/repo/src/build", true, true},
+		{"/repo/vendor", true, true},
+		{"/repo/src/vendor", true, false},
+		{"/repo/docs/a/b/x.tmp", false, true},
+		{"/repo/docs/x.tmp", false, true},
+		{"/repo/main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := ignored(sets, tt.path, tt.isDir); got != tt.ignored {
+			t.Errorf("ignored(%q, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.ignored)
+		}
+	}
+}
+
+func TestGrepTool(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	workDir := t.TempDir()
+	m, err := NewManager(Config{WorkDir: workDir}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := &GrepTool{manager: m}
+
+	files := map[string]string{
+		".gitignore":         "dist/\n*.min.js\n",
+		"main.go":            "package main\n\nfunc main() {\n\tTODO()\n}\n",
+		"lib/util.go":        "package lib\n// TODO one\n// TODO two\n// TODO three\n",
+		"dist/bundle.js":     "// TODO generated\n",
+		"lib/app.min.js":     "TODO minified\n",
+		"lib/data.bin":       "TODO\x00binary",
+		"lib/sub/.gitignore": "local.txt\n",
+		"lib/sub/local.txt":  "TODO local\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(workDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := tool.Execute(map[string]interface{}{"pattern": "TODO"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "lib/util.go:2: // TODO one\nlib/util.go:3: // TODO two\nlib/util.go:4: // TODO three\nmain.go:4: TODO()"
+	if result != want {
+		t.Errorf("grep = %q, want %q", result, want)
+	}
+
+	result, _ = tool.Execute(map[string]interface{}{"pattern": "todo", "ignore_case": true, "no_ignore": true, "include": "*.js"})
+	if !strings.Contains(result, "dist/bundle.js:1:") || !strings.Contains(result, "lib/app.min.js:1:") {
+		t.Errorf("no_ignore grep = %q", result)
+	}
+
+	result, _ = tool.Execute(map[string]interface{}{"pattern": "TODO", "path": "main.go", "context": float64(1)})
+	if result != "main.go-3- func main() {\nmain.go:4: TODO()\nmain.go-5- }" {
+		t.Errorf("context grep = %q", result)
+	}
+
+	result, _ = tool.Execute(map[string]interface{}{"pattern": "TODO", "path": "lib", "max_per_file": float64(2)})
+	if !strings.Contains(result, "lib/util.go:3:") || strings.Contains(result, "lib/util.go:4:") || !strings.Contains(result, "more matches omitted") {
+		t.Errorf("max_per_file grep = %q", result)
+	}
+
+	result, _ = tool.Execute(map[string]interface{}{"pattern": "TODO", "max_results": float64(1)})
+	if strings.Count(result, ": ") != 1 || !strings.Contains(result, "result limit 1 reached") {
+		t.Errorf("max_results grep = %q", result)
+	}
+
+	if result, _ := tool.Execute(map[string]interface{}{"pattern": "nothing-here"}); result != "No matches found" {
+		t.Errorf("no match grep = %q", result)
+	}
+}
@@ -0,0 +1,427 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// HistoryDirName 编辑历史目录，位于工作目录下
+	HistoryDirName = ".mujibot-history"
+	// historyMaxEntries 保留的历史记录数
+	historyMaxEntries = 50
+	// historyMaxSnapshot 超过此大小的文件不记录快照
+	historyMaxSnapshot = 5 * 1024 * 1024
+)
+
+// EditEntry 一次文件修改记录
+type EditEntry struct {
+	ID        int       `json:"id"`
+	Time      time.Time `json:"time"`
+	Tool      string    `json:"tool"`
+	Path      string    `json:"path"`      // 相对工作目录
+	HasBefore bool      `json:"hasBefore"` // false 表示修改前文件不存在
+	HasAfter  bool      `json:"hasAfter"`  // false 表示文件被删除
+	Undone    bool      `json:"undone"`
+}
+
+// editIndex 历史索引文件
+type editIndex struct {
+	NextID  int         `json:"nextId"`
+	Entries []EditEntry `json:"entries"`
+}
+
+// EditHistory 文件修改日志，保存修改前后的快照用于撤销
+type EditHistory struct {
+	dir     string
+	workDir string
+
+	mu    sync.Mutex
+	index editIndex
+}
+
+func newEditHistory(workDir string) *EditHistory {
+	h := &EditHistory{
+		dir:     filepath.Join(workDir, HistoryDirName),
+		workDir: workDir,
+		index:   editIndex{NextID: 1},
+	}
+	if data, err := os.ReadFile(filepath.Join(h.dir, "index.json")); err == nil {
+		json.Unmarshal(data, &h.index)
+	}
+	return h
+}
+
+// snapshot 读取文件当前内容，ok 为 false 表示不应记录（目录或文件过大）
+func snapshot(path string) (data []byte, exists, ok bool) {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil, false, true
+	}
+	if err != nil || !info.Mode().IsRegular() || info.Size() > historyMaxSnapshot {
+		return nil, false, false
+	}
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return nil, false, false
+	}
+	return data, true, true
+}
+
+// Track 执行修改并记录前后快照，记录失败不影响修改本身
+func (h *EditHistory) Track(tool, path string, modify func() error) error {
+	before, hadBefore, ok := snapshot(path)
+	if err := modify(); err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	after, hasAfter, ok := snapshot(path)
+	if !ok || (hadBefore == hasAfter && bytes.Equal(before, after)) {
+		return nil
+	}
+
+	// 其他工作区中的文件记录绝对路径
+	rel, err := filepath.Rel(h.workDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		rel = path
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry := EditEntry{
+		ID:        h.index.NextID,
+		Time:      time.Now(),
+		Tool:      tool,
+		Path:      rel,
+		HasBefore: hadBefore,
+		HasAfter:  hasAfter,
+	}
+	if err := os.MkdirAll(h.dir, 0755); err != nil {
+		return nil
+	}
+	if hadBefore {
+		if err := os.WriteFile(h.snapshotPath(entry.ID, "before"), before, 0600); err != nil {
+			return nil
+		}
+	}
+	if hasAfter {
+		if err := os.WriteFile(h.snapshotPath(entry.ID, "after"), after, 0600); err != nil {
+			return nil
+		}
+	}
+
+	h.index.NextID++
+	h.index.Entries = append(h.index.Entries, entry)
+	for len(h.index.Entries) > historyMaxEntries {
+		old := h.index.Entries[0]
+		os.Remove(h.snapshotPath(old.ID, "before"))
+		os.Remove(h.snapshotPath(old.ID, "after"))
+		h.index.Entries = h.index.Entries[1:]
+	}
+	h.save()
+	return nil
+}
+
+// List 返回历史记录，最新的在前
+func (h *EditHistory) List() []EditEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result := make([]EditEntry, len(h.index.Entries))
+	for i, e := range h.index.Entries {
+		result[len(result)-1-i] = e
+	}
+	return result
+}
+
+// Get 返回记录及修改前后的内容
+func (h *EditHistory) Get(id int) (EditEntry, []byte, []byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry, ok := h.find(id)
+	if !ok {
+		return EditEntry{}, nil, nil, fmt.Errorf("edit not found: #%d", id)
+	}
+	var before, after []byte
+	var err error
+	if entry.HasBefore {
+		if before, err = os.ReadFile(h.snapshotPath(id, "before")); err != nil {
+			return entry, nil, nil, fmt.Errorf("snapshot missing: %w", err)
+		}
+	}
+	if entry.HasAfter {
+		if after, err = os.ReadFile(h.snapshotPath(id, "after")); err != nil {
+			return entry, nil, nil, fmt.Errorf("snapshot missing: %w", err)
+		}
+	}
+	return entry, before, after, nil
+}
+
+// Undo 恢复修改前的内容，id 为 0 时撤销最近一次未撤销的修改。
+// 文件在修改后又被改动过时需要 force。撤销本身也会被记录，指定 id 撤销它即为重做。
+// 索引文件可能被篡改，写入前用 check 重新检查目标路径，见 Manager.UndoCheck
+func (h *EditHistory) Undo(id int, force bool, check func(path string) (string, error)) (EditEntry, error) {
+	if id == 0 {
+		h.mu.Lock()
+		for i := len(h.index.Entries) - 1; i >= 0; i-- {
+			if e := h.index.Entries[i]; !e.Undone && e.Tool != "undo_edit" {
+				id = h.index.Entries[i].ID
+				break
+			}
+		}
+		h.mu.Unlock()
+		if id == 0 {
+			return EditEntry{}, fmt.Errorf("nothing to undo")
+		}
+	}
+
+	entry, before, after, err := h.Get(id)
+	if err != nil {
+		return entry, err
+	}
+	if entry.Undone {
+		return entry, fmt.Errorf("edit #%d is already undone", id)
+	}
+
+	path, err := check(h.absPath(entry.Path))
+	if err != nil {
+		return entry, err
+	}
+	current, exists, _ := snapshot(path)
+	if !force && (exists != entry.HasAfter || !bytes.Equal(current, after)) {
+		return entry, fmt.Errorf("%s has changed since edit #%d，设置 force=true 来覆盖", entry.Path, id)
+	}
+
+	err = h.Track("undo_edit", path, func() error {
+		if !entry.HasBefore {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(path, before, 0644)
+	})
+	if err != nil {
+		return entry, fmt.Errorf("failed to undo: %w", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := range h.index.Entries {
+		if h.index.Entries[i].ID == id {
+			h.index.Entries[i].Undone = true
+			entry = h.index.Entries[i]
+		}
+	}
+	h.save()
+	return entry, nil
+}
+
+// DeleteUnder 删除路径位于 dir 下的记录及其快照，返回删除的记录数
+func (h *EditHistory) DeleteUnder(dir string) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n := 0
+	var firstErr error
+	kept := h.index.Entries[:0]
+	for _, e := range h.index.Entries {
+		rel, err := filepath.Rel(dir, h.absPath(e.Path))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			kept = append(kept, e)
+			continue
+		}
+		for _, kind := range []string{"before", "after"} {
+			if err := os.Remove(h.snapshotPath(e.ID, kind)); err != nil && !os.IsNotExist(err) && firstErr == nil {
+				firstErr = fmt.Errorf("failed to remove snapshot: %w", err)
+			}
+		}
+		n++
+	}
+	h.index.Entries = kept
+	if n > 0 {
+		h.save()
+	}
+	return n, firstErr
+}
+
+func (h *EditHistory) find(id int) (EditEntry, bool) {
+	for _, e := range h.index.Entries {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return EditEntry{}, false
+}
+
+// absPath 把记录中的路径还原为绝对路径
+func (h *EditHistory) absPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(h.workDir, path)
+}
+
+func (h *EditHistory) snapshotPath(id int, kind string) string {
+	return filepath.Join(h.dir, strconv.Itoa(id)+"."+kind)
+}
+
+// save 写入索引，调用方需持有锁
+func (h *EditHistory) save() {
+	data, err := json.MarshalIndent(h.index, "", "  ")
+	if err != nil {
+		return
+	}
+	path := filepath.Join(h.dir, "index.json")
+	if err := os.WriteFile(path+".tmp", data, 0600); err == nil {
+		os.Rename(path+".tmp", path)
+	}
+}
+
+// UndoEditTool 撤销文件修改工具
+type UndoEditTool struct {
+	manager *Manager
+}
+
+func (t *UndoEditTool) Name() string {
+	return "undo_edit"
+}
+
+func (t *UndoEditTool) Description() string {
+	return "查看或撤销 write_file、apply_patch、delete_file 对文件的修改。"
+}
+
+func (t *UndoEditTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "操作类型: undo(撤销，默认), list(列出最近的修改)",
+				"enum":        []string{"undo", "list"},
+			},
+			"id": map[string]interface{}{
+				"type":        "integer",
+				"description": "要撤销的修改编号，默认最近一次",
+			},
+			"force": map[string]interface{}{
+				"type":        "boolean",
+				"description": "文件之后又被修改过时仍然撤销",
+			},
+		},
+	}
+}
+
+func (t *UndoEditTool) Execute(args map[string]interface{}) (string, error) {
+	history := t.manager.history
+	action, _ := args["action"].(string)
+
+	switch action {
+	case "list":
+		entries := t.visible(args)
+		if len(entries) == 0 {
+			return "No edits recorded.", nil
+		}
+		var sb strings.Builder
+		for i, e := range entries {
+			if i >= 20 {
+				break
+			}
+			fmt.Fprintf(&sb, "#%d %s %s %s", e.ID, e.Time.Local().Format("01-02 15:04:05"), e.Tool, e.Path)
+			switch {
+			case !e.HasBefore:
+				sb.WriteString(" (created)")
+			case !e.HasAfter:
+				sb.WriteString(" (deleted)")
+			}
+			if e.Undone {
+				sb.WriteString(" [undone]")
+			}
+			sb.WriteString("\n")
+		}
+		return strings.TrimRight(sb.String(), "\n"), nil
+	case "", "undo":
+		id, _ := args["id"].(float64)
+		force, _ := args["force"].(bool)
+		if t.manager.perUserWorkDir {
+			// 按用户隔离时只能撤销自己能访问的文件
+			target, err := t.ownEdit(args, int(id))
+			if err != nil {
+				return "", err
+			}
+			id = float64(target)
+		}
+		entry, err := history.Undo(int(id), force, t.manager.UndoCheck(args))
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case !entry.HasBefore:
+			return fmt.Sprintf("Undid edit #%d: removed %s", entry.ID, entry.Path), nil
+		case !entry.HasAfter:
+			return fmt.Sprintf("Undid edit #%d: restored deleted %s", entry.ID, entry.Path), nil
+		}
+		return fmt.Sprintf("Undid edit #%d: restored %s", entry.ID, entry.Path), nil
+	}
+	return "", fmt.Errorf("unknown action: %s", action)
+}
+
+// UndoCheck 返回撤销前检查目标路径的函数：路径须位于可写的工作区内（按用户隔离时还须在调用者的目录内），
+// 且不被策略拒绝，需要确认的路径向用户发送确认请求，args 带有 WithApproval 标记时跳过
+func (m *Manager) UndoCheck(args map[string]interface{}) func(path string) (string, error) {
+	return func(path string) (string, error) {
+		safePath, err := m.resolvePath(args, path, true)
+		if err != nil {
+			return "", err
+		}
+		if err := m.enforcePolicy("undo_edit", args, "", safePath); err != nil {
+			return "", err
+		}
+		return safePath, nil
+	}
+}
+
+// visible 返回调用者能访问的文件的修改记录，最新的在前
+func (t *UndoEditTool) visible(args map[string]interface{}) []EditEntry {
+	entries := t.manager.history.List()
+	if !t.manager.perUserWorkDir {
+		return entries
+	}
+	result := entries[:0]
+	for _, e := range entries {
+		if _, err := t.manager.resolvePath(args, t.manager.history.absPath(e.Path), false); err == nil {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// ownEdit 在调用者能访问的记录中查找要撤销的修改，id 为 0 时取最近一次未撤销的修改
+func (t *UndoEditTool) ownEdit(args map[string]interface{}, id int) (int, error) {
+	for _, e := range t.visible(args) {
+		if id == 0 && !e.Undone && e.Tool != "undo_edit" {
+			return e.ID, nil
+		}
+		if id != 0 && e.ID == id {
+			return id, nil
+		}
+	}
+	if id == 0 {
+		return 0, fmt.Errorf("nothing to undo")
+	}
+	return 0, fmt.Errorf("edit not found: #%d", id)
+}
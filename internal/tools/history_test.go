@@ -0,0 +1,153 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/policy"
+)
+
+func TestEditHistory(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	workDir := t.TempDir()
+	m, err := NewManager(Config{WorkDir: workDir, UnattendedMode: true}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exec := func(name string, args map[string]interface{}) string {
+		t.Helper()
+		tool, _ := m.Get(name)
+		result, err := tool.Execute(args)
+		if err != nil {
+			t.Fatalf("%s error = %v", name, err)
+		}
+		return result
+	}
+	read := func(name string) (string, bool) {
+		data, err := os.ReadFile(filepath.Join(workDir, name))
+		return string(data), err == nil
+	}
+
+	exec("write_file", map[string]interface{}{"path": "a.txt", "content": "v1\n"})
+	exec("write_file", map[string]interface{}{"path": "a.txt", "content": "v2\n"})
+	exec("apply_patch", map[string]interface{}{"path": "a.txt", "patch": "@@ -1 +1 @@\n-v2\n+v3\n", "backup": false})
+	exec("write_file", map[string]interface{}{"path": "b.txt", "content": "keep\n"})
+	exec("delete_file", map[string]interface{}{"path": "b.txt"})
+
+	entries := m.History().List()
+	if len(entries) != 5 || entries[0].Tool != "delete_file" || entries[0].HasAfter || entries[4].HasBefore {
+		t.Fatalf("List() = %+v", entries)
+	}
+
+	// 默认撤销最近一次：恢复被删除的文件
+	exec("undo_edit", map[string]interface{}{})
+	if content, ok := read("b.txt"); !ok || content != "keep\n" {
+		t.Errorf("b.txt = %q, %v", content, ok)
+	}
+
+	// 连续撤销逐步回退，跳过撤销记录本身
+	exec("undo_edit", map[string]interface{}{})
+	if _, ok := read("b.txt"); ok {
+		t.Error("b.txt should be removed after undoing its creation")
+	}
+	exec("undo_edit", map[string]interface{}{})
+	if content, _ := read("a.txt"); content != "v2\n" {
+		t.Errorf("a.txt = %q, want v2", content)
+	}
+
+	// 文件在编辑后又被修改，需要 force
+	if err := os.WriteFile(filepath.Join(workDir, "a.txt"), []byte("manual\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tool, _ := m.Get("undo_edit")
+	if _, err := tool.Execute(map[string]interface{}{"id": float64(2)}); err == nil || !strings.Contains(err.Error(), "force=true") {
+		t.Errorf("undo of changed file error = %v", err)
+	}
+	exec("undo_edit", map[string]interface{}{"id": float64(2), "force": true})
+	if content, _ := read("a.txt"); content != "v1\n" {
+		t.Errorf("a.txt = %q, want v1", content)
+	}
+
+	// 历史在重新创建管理器后保留
+	m2, err := NewManager(Config{WorkDir: workDir}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := (&UndoEditTool{manager: m2}).Execute(map[string]interface{}{"action": "list"})
+	if err != nil || !strings.Contains(result, "#5 ") || !strings.Contains(result, "[undone]") {
+		t.Errorf("list = %q, %v", result, err)
+	}
+	entry, before, after, err := m2.History().Get(3)
+	if err != nil || entry.Tool != "apply_patch" || string(before) != "v2\n" || string(after) != "v3\n" {
+		t.Errorf("Get(3) = %+v, %q, %q, %v", entry, before, after, err)
+	}
+}
+
+func TestEditHistoryTampering(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	workDir := t.TempDir()
+	p, err := policy.New([]policy.Rule{{Name: "locked", Tools: []string{"undo_edit"}, Paths: []string{"locked.txt"}, Action: policy.Deny}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewManager(Config{WorkDir: workDir, Policy: p}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	execute := func(name string, args map[string]interface{}) (string, error) {
+		tool, _ := m.Get(name)
+		return tool.Execute(args)
+	}
+
+	// 文件工具不能读写编辑历史目录
+	if _, err := execute("write_file", map[string]interface{}{"path": "x.txt", "content": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		tool string
+		args map[string]interface{}
+	}{
+		{"write_file", map[string]interface{}{"path": HistoryDirName + "/index.json", "content": "{}"}},
+		{"read_file", map[string]interface{}{"path": HistoryDirName + "/1.after"}},
+		{"move_file", map[string]interface{}{"source": "x.txt", "destination": HistoryDirName + "/2.before"}},
+	} {
+		if _, err := execute(tt.tool, tt.args); err == nil || !strings.Contains(err.Error(), "reserved for edit history") {
+			t.Errorf("%s into history dir error = %v", tt.tool, err)
+		}
+	}
+
+	// 策略拒绝的路径不能通过撤销写入
+	if _, err := execute("write_file", map[string]interface{}{"path": "locked.txt", "content": "v1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := execute("undo_edit", map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), "locked") {
+		t.Errorf("undo denied by policy error = %v", err)
+	}
+
+	// 伪造的索引指向工作目录外，重启后撤销时拒绝
+	outside := filepath.Join(t.TempDir(), "victim.txt")
+	if err := os.WriteFile(outside, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	historyDir := filepath.Join(workDir, HistoryDirName)
+	index := `{"nextId": 100, "entries": [{"id": 99, "tool": "write_file", "path": "` + outside + `", "hasBefore": true, "hasAfter": true}]}`
+	os.WriteFile(filepath.Join(historyDir, "index.json"), []byte(index), 0600)
+	os.WriteFile(filepath.Join(historyDir, "99.before"), []byte("pwned"), 0600)
+	os.WriteFile(filepath.Join(historyDir, "99.after"), []byte("original"), 0600)
+
+	m2, err := NewManager(Config{WorkDir: workDir}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool, _ := m2.Get("undo_edit")
+	if _, err := tool.Execute(map[string]interface{}{"id": float64(99)}); err == nil || !strings.Contains(err.Error(), "outside work directory") {
+		t.Errorf("undo outside work dir error = %v", err)
+	}
+	if data, _ := os.ReadFile(outside); string(data) != "original" {
+		t.Errorf("file outside work dir was overwritten: %q", data)
+	}
+}
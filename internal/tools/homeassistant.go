@@ -0,0 +1,290 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/httpclient"
+)
+
+// maxHAAttributes 查看状态时最多显示的属性数
+const maxHAAttributes = 20
+
+// homeassistantServices 不限实体所属领域的通用服务
+var homeassistantServices = map[string]bool{
+	"turn_on":       true,
+	"turn_off":      true,
+	"toggle":        true,
+	"update_entity": true,
+}
+
+// HomeAssistantConfig Home Assistant REST API配置
+type HomeAssistantConfig struct {
+	Enabled         bool
+	URL             string   // 如 http://homeassistant.local:8123
+	Token           string   // 长期访问令牌
+	AllowedEntities []string // 允许访问的实体，支持通配符如 light.*，为空时不允许任何实体
+}
+
+// HomeAssistantTool 通过 Home Assistant REST API 查看实体状态和调用服务，只能访问白名单中的实体
+type HomeAssistantTool struct {
+	manager *Manager
+	config  HomeAssistantConfig
+}
+
+// haState /api/states 返回的实体状态
+type haState struct {
+	EntityID    string                 `json:"entity_id"`
+	State       string                 `json:"state"`
+	Attributes  map[string]interface{} `json:"attributes"`
+	LastChanged time.Time              `json:"last_changed"`
+}
+
+// NewHomeAssistantTool 创建 Home Assistant 工具
+func NewHomeAssistantTool(manager *Manager, cfg HomeAssistantConfig) *HomeAssistantTool {
+	cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+	return &HomeAssistantTool{manager: manager, config: cfg}
+}
+
+func (t *HomeAssistantTool) Name() string {
+	return "homeassistant"
+}
+
+func (t *HomeAssistantTool) Description() string {
+	return "控制智能家居（Home Assistant）：列出实体、查看状态、调用服务（如 light.turn_on）。只能访问配置允许的实体。"
+}
+
+func (t *HomeAssistantTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"list", "state", "call"},
+				"description": "list 列出允许的实体；state 查看实体状态和属性；call 对实体调用服务",
+			},
+			"entity_id": map[string]interface{}{
+				"type":        "string",
+				"description": "实体ID，如 light.kitchen（state、call 必填）",
+			},
+			"domain": map[string]interface{}{
+				"type":        "string",
+				"description": "list 时只列出该领域的实体，如 light、switch、sensor",
+			},
+			"service": map[string]interface{}{
+				"type":        "string",
+				"description": "call 时调用的服务，如 light.turn_on、climate.set_temperature；只写 turn_on 时使用实体所属领域",
+			},
+			"data": map[string]interface{}{
+				"type":        "object",
+				"description": "服务参数，如 {\"brightness_pct\": 50}，不能指定其他实体",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *HomeAssistantTool) Execute(args map[string]interface{}) (string, error) {
+	action, _ := args["action"].(string)
+	entityID, _ := args["entity_id"].(string)
+	entityID = strings.ToLower(strings.TrimSpace(entityID))
+
+	switch action {
+	case "list":
+		domain, _ := args["domain"].(string)
+		return t.list(strings.ToLower(strings.TrimSpace(domain)))
+
+	case "state":
+		if err := t.checkEntity(entityID); err != nil {
+			return "", err
+		}
+		var s haState
+		if err := t.request(http.MethodGet, "/api/states/"+url.PathEscape(entityID), nil, &s); err != nil {
+			return "", err
+		}
+		return formatHAState(s, true), nil
+
+	case "call":
+		if err := t.checkEntity(entityID); err != nil {
+			return "", err
+		}
+		service, _ := args["service"].(string)
+		data, _ := args["data"].(map[string]interface{})
+		return t.call(entityID, strings.ToLower(strings.TrimSpace(service)), data)
+
+	default:
+		return "", fmt.Errorf("unknown action: %s (use list, state or call)", action)
+	}
+}
+
+// list 列出白名单中的实体
+func (t *HomeAssistantTool) list(domain string) (string, error) {
+	if len(t.config.AllowedEntities) == 0 {
+		return "", fmt.Errorf("no Home Assistant entities are allowed, add them to tools.homeAssistant.allowedEntities")
+	}
+	var states []haState
+	if err := t.request(http.MethodGet, "/api/states", nil, &states); err != nil {
+		return "", err
+	}
+
+	var lines []string
+	for _, s := range states {
+		if !t.allowed(s.EntityID) || (domain != "" && !strings.HasPrefix(s.EntityID, domain+".")) {
+			continue
+		}
+		lines = append(lines, formatHAState(s, false))
+	}
+	if len(lines) == 0 {
+		return "No allowed entities found.", nil
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n"), nil
+}
+
+// call 调用服务，服务只能属于实体所在的领域或 homeassistant 通用服务，避免借白名单实体调用 shell_command 等服务
+func (t *HomeAssistantTool) call(entityID, service string, data map[string]interface{}) (string, error) {
+	entityDomain, _, _ := strings.Cut(entityID, ".")
+	if service == "" {
+		return "", fmt.Errorf("service is required for call")
+	}
+	domain, name, ok := strings.Cut(service, ".")
+	if !ok {
+		domain, name = entityDomain, service
+	}
+	if name == "" || (domain != entityDomain && !(domain == "homeassistant" && homeassistantServices[name])) {
+		return "", fmt.Errorf("service %s.%s cannot be used on %s, use a %s.* service", domain, name, entityID, entityDomain)
+	}
+
+	body := map[string]interface{}{}
+	for k, v := range data {
+		switch k {
+		case "entity_id", "device_id", "area_id", "floor_id", "label_id":
+			return "", fmt.Errorf("data must not contain %s, the target is entity_id", k)
+		}
+		body[k] = v
+	}
+	body["entity_id"] = entityID
+
+	var changed []haState
+	if err := t.request(http.MethodPost, "/api/services/"+url.PathEscape(domain)+"/"+url.PathEscape(name), body, &changed); err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("Called %s.%s on %s", domain, name, entityID)
+	for _, s := range changed {
+		if s.EntityID == entityID {
+			result += "\nNow: " + formatHAState(s, false)
+		}
+	}
+	return result, nil
+}
+
+// checkEntity 检查实体ID格式和白名单
+func (t *HomeAssistantTool) checkEntity(entityID string) error {
+	if entityID == "" {
+		return fmt.Errorf("entity_id is required")
+	}
+	if d, o, ok := strings.Cut(entityID, "."); !ok || d == "" || o == "" || strings.ContainsAny(entityID, "/?#") {
+		return fmt.Errorf("invalid entity_id: %s", entityID)
+	}
+	if !t.allowed(entityID) {
+		return fmt.Errorf("entity %s is not in tools.homeAssistant.allowedEntities", entityID)
+	}
+	return nil
+}
+
+// allowed 实体是否匹配白名单，支持 path.Match 通配符
+func (t *HomeAssistantTool) allowed(entityID string) bool {
+	for _, pattern := range t.config.AllowedEntities {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == entityID {
+			return true
+		}
+		if ok, _ := path.Match(pattern, entityID); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// request 发送带令牌的请求并解析JSON响应
+func (t *HomeAssistantTool) request(method, p string, body interface{}, v interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, t.config.URL+p, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+t.config.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpclient.New(10 * time.Second).Do(req)
+	if err != nil {
+		return fmt.Errorf("home assistant request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4*1024*1024))
+	if err != nil {
+		return fmt.Errorf("failed to read home assistant response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("home assistant rejected the token (status 401)")
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("not found in home assistant: %s", p)
+	case resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated:
+		return fmt.Errorf("home assistant returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(truncateBytes(data, 512))))
+	}
+	return json.Unmarshal(data, v)
+}
+
+// formatHAState 一行显示实体状态，detail 时附上属性和最后变化时间
+func formatHAState(s haState, detail bool) string {
+	line := s.EntityID
+	if name, _ := s.Attributes["friendly_name"].(string); name != "" {
+		line += " (" + name + ")"
+	}
+	line += ": " + s.State
+	if unit, _ := s.Attributes["unit_of_measurement"].(string); unit != "" {
+		line += " " + unit
+	}
+	if !detail {
+		return line
+	}
+
+	if !s.LastChanged.IsZero() {
+		line += "\nLast changed: " + s.LastChanged.Format(time.RFC3339)
+	}
+	keys := make([]string, 0, len(s.Attributes))
+	for k := range s.Attributes {
+		switch k {
+		case "friendly_name", "unit_of_measurement", "icon", "entity_picture", "supported_features", "attribution":
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if len(keys) > maxHAAttributes {
+		keys = keys[:maxHAAttributes]
+	}
+	for _, k := range keys {
+		v, _ := json.Marshal(s.Attributes[k])
+		line += fmt.Sprintf("\n%s: %s", k, truncateBytes(v, 200))
+	}
+	return line
+}
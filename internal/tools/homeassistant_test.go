@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHomeAssistantTool(t *testing.T) {
+	var calls []string
+	var lastBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch {
+		case r.URL.Path == "/api/states":
+			w.Write([]byte(`[
+				{"entity_id":"light.kitchen","state":"off","attributes":{"friendly_name":"Kitchen"}},
+				{"entity_id":"sensor.outdoor_temperature","state":"12.5","attributes":{"friendly_name":"Outdoor","unit_of_measurement":"°C"}},
+				{"entity_id":"lock.front_door","state":"locked","attributes":{}}
+			]`))
+		case r.URL.Path == "/api/states/light.kitchen":
+			w.Write([]byte(`{"entity_id":"light.kitchen","state":"on","last_changed":"2024-05-01T10:00:00Z",
+				"attributes":{"friendly_name":"Kitchen","brightness":128,"color_mode":"brightness","icon":"mdi:lamp"}}`))
+		case r.URL.Path == "/api/services/light/turn_on":
+			json.NewDecoder(r.Body).Decode(&lastBody)
+			w.Write([]byte(`[{"entity_id":"light.kitchen","state":"on","attributes":{"friendly_name":"Kitchen"}}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tool := NewHomeAssistantTool(nil, HomeAssistantConfig{
+		Enabled:         true,
+		URL:             server.URL + "/",
+		Token:           "token",
+		AllowedEntities: []string{"light.*", "sensor.outdoor_temperature"},
+	})
+
+	result, err := tool.Execute(map[string]interface{}{"action": "list"})
+	if err != nil {
+		t.Fatalf("list error = %v", err)
+	}
+	if result != "light.kitchen (Kitchen): off\nsensor.outdoor_temperature (Outdoor): 12.5 °C" {
+		t.Errorf("list = %q", result)
+	}
+	if result, _ := tool.Execute(map[string]interface{}{"action": "list", "domain": "sensor"}); strings.Contains(result, "light.") {
+		t.Errorf("list by domain = %q", result)
+	}
+
+	result, err = tool.Execute(map[string]interface{}{"action": "state", "entity_id": "light.kitchen"})
+	if err != nil {
+		t.Fatalf("state error = %v", err)
+	}
+	want := "light.kitchen (Kitchen): on\nLast changed: 2024-05-01T10:00:00Z\nbrightness: 128\ncolor_mode: \"brightness\""
+	if result != want {
+		t.Errorf("state = %q, want %q", result, want)
+	}
+
+	result, err = tool.Execute(map[string]interface{}{"action": "call", "entity_id": "light.kitchen", "service": "turn_on", "data": map[string]interface{}{"brightness_pct": float64(50)}})
+	if err != nil {
+		t.Fatalf("call error = %v", err)
+	}
+	if result != "Called light.turn_on on light.kitchen\nNow: light.kitchen (Kitchen): on" {
+		t.Errorf("call = %q", result)
+	}
+	if lastBody["entity_id"] != "light.kitchen" || lastBody["brightness_pct"] != float64(50) {
+		t.Errorf("service body = %v", lastBody)
+	}
+
+	before := len(calls)
+	for _, args := range []map[string]interface{}{
+		{"action": "state", "entity_id": "lock.front_door"},
+		{"action": "call", "entity_id": "lock.front_door", "service": "lock.unlock"},
+		{"action": "call", "entity_id": "light.kitchen", "service": "shell_command.reboot"},
+		{"action": "call", "entity_id": "light.kitchen", "service": "homeassistant.restart"},
+		{"action": "call", "entity_id": "light.kitchen", "service": "turn_on", "data": map[string]interface{}{"entity_id": "lock.front_door"}},
+		{"action": "call", "entity_id": "light.kitchen"},
+		{"action": "state", "entity_id": "light.kitchen/../x"},
+		{"action": "bogus"},
+	} {
+		if _, err := tool.Execute(args); err == nil {
+			t.Errorf("Execute(%v) should fail", args)
+		}
+	}
+	if len(calls) != before {
+		t.Errorf("rejected actions reached the server: %v", calls[before:])
+	}
+
+	tool.config.AllowedEntities = nil
+	if _, err := tool.Execute(map[string]interface{}{"action": "list"}); err == nil {
+		t.Error("list without allowed entities should fail")
+	}
+	tool.config.Token = "wrong"
+	tool.config.AllowedEntities = []string{"light.kitchen"}
+	if _, err := tool.Execute(map[string]interface{}{"action": "state", "entity_id": "light.kitchen"}); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("wrong token error = %v", err)
+	}
+}
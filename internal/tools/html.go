@@ -0,0 +1,439 @@
+package tools
+
+import (
+	"html"
+	"strings"
+)
+
+// htmlNode 简化的HTML节点，文本节点 tag 为空
+type htmlNode struct {
+	tag      string
+	attrs    map[string]string
+	text     string
+	parent   *htmlNode
+	children []*htmlNode
+}
+
+// voidElements 无结束标签的元素
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true,
+	"img": true, "input": true, "link": true, "meta": true, "source": true,
+	"track": true, "wbr": true,
+}
+
+// rawTextElements 内容不解析为HTML的元素
+var rawTextElements = map[string]bool{
+	"script": true, "style": true, "textarea": true, "title": true,
+}
+
+// autoClose 打开某元素时隐式关闭的同级元素
+var autoClose = map[string][]string{
+	"li":     {"li"},
+	"dt":     {"dt", "dd"},
+	"dd":     {"dt", "dd"},
+	"tr":     {"tr", "td", "th"},
+	"td":     {"td", "th"},
+	"th":     {"td", "th"},
+	"option": {"option"},
+	"p":      {"p"},
+}
+
+// parseHTML 宽松地解析HTML为节点树，容忍未闭合和错误嵌套的标签
+func parseHTML(src string) *htmlNode {
+	root := &htmlNode{tag: "#root"}
+	cur := root
+
+	appendText := func(text string) {
+		if text == "" {
+			return
+		}
+		cur.children = append(cur.children, &htmlNode{text: html.UnescapeString(text), parent: cur})
+	}
+
+	for len(src) > 0 {
+		lt := strings.IndexByte(src, '<')
+		if lt < 0 {
+			appendText(src)
+			break
+		}
+		appendText(src[:lt])
+		src = src[lt:]
+
+		switch {
+		case strings.HasPrefix(src, "<!--"):
+			end := strings.Index(src, "-->")
+			if end < 0 {
+				return root
+			}
+			src = src[end+3:]
+			continue
+		case strings.HasPrefix(src, "<!") || strings.HasPrefix(src, "<?"):
+			end := strings.IndexByte(src, '>')
+			if end < 0 {
+				return root
+			}
+			src = src[end+1:]
+			continue
+		case strings.HasPrefix(src, "</"):
+			end := strings.IndexByte(src, '>')
+			if end < 0 {
+				return root
+			}
+			name := strings.ToLower(strings.TrimSpace(src[2:end]))
+			src = src[end+1:]
+			// 关闭最近的同名元素，找不到则忽略
+			for n := cur; n != root; n = n.parent {
+				if n.tag == name {
+					cur = n.parent
+					break
+				}
+			}
+			continue
+		}
+
+		tag, attrs, selfClosing, rest, ok := parseTag(src)
+		if !ok {
+			appendText("<")
+			src = src[1:]
+			continue
+		}
+		src = rest
+
+		if closes, ok := autoClose[tag]; ok {
+			for _, c := range closes {
+				if cur.tag == c {
+					cur = cur.parent
+					break
+				}
+			}
+		}
+
+		node := &htmlNode{tag: tag, attrs: attrs, parent: cur}
+		cur.children = append(cur.children, node)
+
+		if rawTextElements[tag] {
+			end := indexFold(src, "</"+tag)
+			if end < 0 {
+				end = len(src)
+			}
+			if text := src[:end]; text != "" {
+				node.children = append(node.children, &htmlNode{text: html.UnescapeString(text), parent: node})
+			}
+			src = src[end:]
+			if gt := strings.IndexByte(src, '>'); gt >= 0 {
+				src = src[gt+1:]
+			}
+			continue
+		}
+
+		if !selfClosing && !voidElements[tag] {
+			cur = node
+		}
+	}
+
+	return root
+}
+
+// parseTag 解析开始标签，返回标签名、属性和剩余内容
+func parseTag(src string) (tag string, attrs map[string]string, selfClosing bool, rest string, ok bool) {
+	i := 1
+	for i < len(src) && isTagNameChar(src[i]) {
+		i++
+	}
+	if i == 1 {
+		return "", nil, false, src, false
+	}
+	tag = strings.ToLower(src[1:i])
+	attrs = make(map[string]string)
+
+	for i < len(src) {
+		for i < len(src) && isSpace(src[i]) {
+			i++
+		}
+		if i >= len(src) {
+			return tag, attrs, false, "", true
+		}
+		if src[i] == '>' {
+			return tag, attrs, selfClosing, src[i+1:], true
+		}
+		if src[i] == '/' {
+			selfClosing = true
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(src) && !isSpace(src[i]) && src[i] != '=' && src[i] != '>' && src[i] != '/' {
+			i++
+		}
+		name := strings.ToLower(src[start:i])
+		for i < len(src) && isSpace(src[i]) {
+			i++
+		}
+
+		value := ""
+		if i < len(src) && src[i] == '=' {
+			i++
+			for i < len(src) && isSpace(src[i]) {
+				i++
+			}
+			if i < len(src) && (src[i] == '"' || src[i] == '\'') {
+				quote := src[i]
+				end := strings.IndexByte(src[i+1:], quote)
+				if end < 0 {
+					return tag, attrs, false, "", true
+				}
+				value = src[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				start := i
+				for i < len(src) && !isSpace(src[i]) && src[i] != '>' {
+					i++
+				}
+				value = src[start:i]
+			}
+		}
+		if name != "" {
+			attrs[name] = html.UnescapeString(value)
+		}
+		selfClosing = false
+	}
+	return tag, attrs, selfClosing, "", true
+}
+
+func isTagNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == ':'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// indexFold 不区分大小写查找子串
+func indexFold(s, substr string) int {
+	return strings.Index(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// find 查找第一个指定标签的后代元素
+func (n *htmlNode) find(tag string) *htmlNode {
+	for _, c := range n.children {
+		if c.tag == tag {
+			return c
+		}
+		if found := c.find(tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// walk 深度优先遍历元素节点，fn 返回false时跳过子节点
+func (n *htmlNode) walk(fn func(*htmlNode) bool) {
+	for _, c := range n.children {
+		if c.tag == "" {
+			continue
+		}
+		if fn(c) {
+			c.walk(fn)
+		}
+	}
+}
+
+// textContent 节点内的纯文本
+func (n *htmlNode) textContent() string {
+	if n.tag == "" {
+		return n.text
+	}
+	var sb strings.Builder
+	var collect func(*htmlNode)
+	collect = func(node *htmlNode) {
+		for _, c := range node.children {
+			if c.tag == "" {
+				sb.WriteString(c.text)
+			} else if c.tag != "script" && c.tag != "style" {
+				collect(c)
+			}
+		}
+	}
+	collect(n)
+	return sb.String()
+}
+
+// hasClass 检查元素是否包含指定class
+func (n *htmlNode) hasClass(class string) bool {
+	for _, c := range strings.Fields(n.attrs["class"]) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// cssSelector 简单CSS选择器，支持 tag、.class、#id、[attr]、[attr=value]、后代与子元素组合及逗号分组
+type cssSelector [][]selectorStep
+
+type selectorStep struct {
+	compound compoundSelector
+	child    bool // 与前一步为子元素关系（>），否则为后代关系
+}
+
+type compoundSelector struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []attrSelector
+}
+
+type attrSelector struct {
+	name     string
+	value    string
+	hasValue bool // false 表示仅要求属性存在
+}
+
+// parseSelector 解析CSS选择器
+func parseSelector(s string) (cssSelector, bool) {
+	var sel cssSelector
+	for _, group := range strings.Split(s, ",") {
+		group = strings.TrimSpace(strings.ReplaceAll(group, ">", " > "))
+		if group == "" {
+			return nil, false
+		}
+
+		var steps []selectorStep
+		child := false
+		for _, part := range strings.Fields(group) {
+			if part == ">" {
+				if len(steps) == 0 {
+					return nil, false
+				}
+				child = true
+				continue
+			}
+			compound, ok := parseCompound(part)
+			if !ok {
+				return nil, false
+			}
+			steps = append(steps, selectorStep{compound: compound, child: child})
+			child = false
+		}
+		if len(steps) == 0 || child {
+			return nil, false
+		}
+		sel = append(sel, steps)
+	}
+	return sel, len(sel) > 0
+}
+
+func parseCompound(s string) (compoundSelector, bool) {
+	var c compoundSelector
+	for len(s) > 0 {
+		switch s[0] {
+		case '.', '#':
+			end := 1
+			for end < len(s) && s[end] != '.' && s[end] != '#' && s[end] != '[' {
+				end++
+			}
+			if end == 1 {
+				return c, false
+			}
+			if s[0] == '.' {
+				c.classes = append(c.classes, s[1:end])
+			} else {
+				c.id = s[1:end]
+			}
+			s = s[end:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return c, false
+			}
+			attr := s[1:end]
+			if eq := strings.IndexByte(attr, '='); eq >= 0 {
+				c.attrs = append(c.attrs, attrSelector{
+					name:     strings.ToLower(attr[:eq]),
+					value:    strings.Trim(attr[eq+1:], `"'`),
+					hasValue: true,
+				})
+			} else {
+				c.attrs = append(c.attrs, attrSelector{name: strings.ToLower(attr)})
+			}
+			s = s[end+1:]
+		default:
+			end := 0
+			for end < len(s) && s[end] != '.' && s[end] != '#' && s[end] != '[' {
+				end++
+			}
+			if c.tag != "" {
+				return c, false
+			}
+			c.tag = strings.ToLower(s[:end])
+			s = s[end:]
+		}
+	}
+	return c, true
+}
+
+func (c compoundSelector) matches(n *htmlNode) bool {
+	if n.tag == "" || n.tag == "#root" {
+		return false
+	}
+	if c.tag != "" && c.tag != "*" && c.tag != n.tag {
+		return false
+	}
+	if c.id != "" && n.attrs["id"] != c.id {
+		return false
+	}
+	for _, class := range c.classes {
+		if !n.hasClass(class) {
+			return false
+		}
+	}
+	for _, attr := range c.attrs {
+		v, ok := n.attrs[attr.name]
+		if !ok || (attr.hasValue && v != attr.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches 检查元素是否匹配选择器
+func (sel cssSelector) matches(n *htmlNode) bool {
+	for _, steps := range sel {
+		if matchSteps(steps, len(steps)-1, n) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSteps(steps []selectorStep, i int, n *htmlNode) bool {
+	if !steps[i].compound.matches(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	if steps[i].child {
+		return n.parent != nil && matchSteps(steps, i-1, n.parent)
+	}
+	for p := n.parent; p != nil; p = p.parent {
+		if matchSteps(steps, i-1, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectAll 返回所有匹配选择器的元素，已匹配元素的后代不再重复返回
+func (n *htmlNode) selectAll(sel cssSelector) []*htmlNode {
+	var result []*htmlNode
+	n.walk(func(c *htmlNode) bool {
+		if sel.matches(c) {
+			result = append(result, c)
+			return false
+		}
+		return true
+	})
+	return result
+}
@@ -0,0 +1,258 @@
+package tools
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	icsUTCLayout   = "20060102T150405Z"
+	icsLocalLayout = "20060102T150405"
+	icsDateLayout  = "20060102"
+)
+
+// icsProperty iCalendar 内容行：名称、参数和值
+type icsProperty struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// parseICS 解析 iCalendar 文本中的 VEVENT，没有时区的时间按 loc 解释，无法解析的事件被跳过
+func parseICS(data string, loc *time.Location) []calendarEvent {
+	var events []calendarEvent
+	var props []icsProperty
+	depth, inEvent := 0, false
+
+	for _, line := range unfoldICS(data) {
+		p, ok := parseICSLine(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case p.Name == "BEGIN" && strings.EqualFold(p.Value, "VEVENT"):
+			inEvent, depth, props = true, 0, nil
+		case p.Name == "BEGIN" && inEvent:
+			// 跳过 VALARM 等嵌套组件
+			depth++
+		case p.Name == "END" && inEvent && depth > 0:
+			depth--
+		case p.Name == "END" && strings.EqualFold(p.Value, "VEVENT"):
+			inEvent = false
+			if ev, ok := icsEvent(props, loc); ok {
+				events = append(events, ev)
+			}
+		case inEvent && depth == 0:
+			props = append(props, p)
+		}
+	}
+	return events
+}
+
+// icsEvent 从 VEVENT 的属性组装事件
+func icsEvent(props []icsProperty, loc *time.Location) (calendarEvent, bool) {
+	var ev calendarEvent
+	var duration time.Duration
+	hasStart := false
+	for _, p := range props {
+		switch p.Name {
+		case "UID":
+			ev.UID = p.Value
+		case "SUMMARY":
+			ev.Summary = unescapeICSText(p.Value)
+		case "LOCATION":
+			ev.Location = unescapeICSText(p.Value)
+		case "DESCRIPTION":
+			ev.Description = unescapeICSText(p.Value)
+		case "DTSTART":
+			t, allDay, err := parseICSTime(p, loc)
+			if err != nil {
+				return ev, false
+			}
+			ev.Start, ev.AllDay, hasStart = t, allDay, true
+		case "DTEND":
+			if t, _, err := parseICSTime(p, loc); err == nil {
+				ev.End = t
+			}
+		case "DURATION":
+			duration = parseICSDuration(p.Value)
+		}
+	}
+	if !hasStart {
+		return ev, false
+	}
+	if ev.End.IsZero() {
+		switch {
+		case duration > 0:
+			ev.End = ev.Start.Add(duration)
+		case ev.AllDay:
+			ev.End = ev.Start.AddDate(0, 0, 1)
+		default:
+			ev.End = ev.Start
+		}
+	}
+	if ev.Summary == "" {
+		ev.Summary = "(no title)"
+	}
+	return ev, true
+}
+
+// unfoldICS 拆分为内容行并合并折行（以空格或制表符开头的行接在上一行后）
+func unfoldICS(data string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// parseICSLine 解析内容行 NAME;PARAM=VALUE:value，参数值可以带引号
+func parseICSLine(line string) (icsProperty, bool) {
+	quoted := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return icsProperty{}, false
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	p := icsProperty{Name: strings.ToUpper(parts[0]), Params: make(map[string]string), Value: line[colon+1:]}
+	for _, param := range parts[1:] {
+		if k, v, ok := strings.Cut(param, "="); ok {
+			p.Params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return p, true
+}
+
+// parseICSTime 解析 DATE 或 DATE-TIME 值，返回是否为全天
+func parseICSTime(p icsProperty, loc *time.Location) (time.Time, bool, error) {
+	v := strings.TrimSpace(p.Value)
+	if strings.EqualFold(p.Params["VALUE"], "DATE") || len(v) == len(icsDateLayout) {
+		t, err := time.ParseInLocation(icsDateLayout, v, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(v, "Z") {
+		t, err := time.Parse(icsUTCLayout, v)
+		return t, false, err
+	}
+	tzLoc := loc
+	if tzid := p.Params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			tzLoc = l
+		}
+	}
+	t, err := time.ParseInLocation(icsLocalLayout, v, tzLoc)
+	return t, false, err
+}
+
+// parseICSDuration 解析 DURATION 值，如 PT1H30M、P1D，无法解析时返回 0
+func parseICSDuration(v string) time.Duration {
+	v = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(v)), "+")
+	if !strings.HasPrefix(v, "P") {
+		return 0
+	}
+	var d time.Duration
+	n := 0
+	for _, r := range v[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			n = n*10 + int(r-'0')
+		case r == 'W':
+			d += time.Duration(n) * 7 * 24 * time.Hour
+			n = 0
+		case r == 'D':
+			d += time.Duration(n) * 24 * time.Hour
+			n = 0
+		case r == 'H':
+			d += time.Duration(n) * time.Hour
+			n = 0
+		case r == 'M':
+			d += time.Duration(n) * time.Minute
+			n = 0
+		case r == 'S':
+			d += time.Duration(n) * time.Second
+			n = 0
+		case r == 'T':
+		default:
+			return 0
+		}
+	}
+	return d
+}
+
+func unescapeICSText(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+func escapeICSText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, ",", `\,`, ";", `\;`).Replace(s)
+}
+
+// ics 生成只包含该事件的 iCalendar 文本，定时事件使用UTC时间
+func (ev *calendarEvent) ics(now time.Time) string {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Mujibot//Calendar//EN",
+		"BEGIN:VEVENT",
+		"UID:" + ev.UID,
+		"DTSTAMP:" + now.UTC().Format(icsUTCLayout),
+	}
+	if ev.AllDay {
+		lines = append(lines,
+			"DTSTART;VALUE=DATE:"+ev.Start.Format(icsDateLayout),
+			"DTEND;VALUE=DATE:"+ev.End.Format(icsDateLayout))
+	} else {
+		lines = append(lines,
+			"DTSTART:"+ev.Start.UTC().Format(icsUTCLayout),
+			"DTEND:"+ev.End.UTC().Format(icsUTCLayout))
+	}
+	lines = append(lines, "SUMMARY:"+escapeICSText(ev.Summary))
+	if ev.Location != "" {
+		lines = append(lines, "LOCATION:"+escapeICSText(ev.Location))
+	}
+	if ev.Description != "" {
+		lines = append(lines, "DESCRIPTION:"+escapeICSText(ev.Description))
+	}
+	lines = append(lines, "END:VEVENT", "END:VCALENDAR")
+
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.WriteString(foldICSLine(line))
+		sb.WriteString("\r\n")
+	}
+	return sb.String()
+}
+
+// foldICSLine 按75字节折行，不拆分多字节字符
+func foldICSLine(line string) string {
+	if len(line) <= 75 {
+		return line
+	}
+	var sb strings.Builder
+	width := 0
+	for _, r := range line {
+		n := len(string(r))
+		if width+n > 75 {
+			sb.WriteString("\r\n ")
+			width = 1
+		}
+		sb.WriteRune(r)
+		width += n
+	}
+	return sb.String()
+}
@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ipAPIURL ipapi.co 免费API
+const ipAPIURL = "https://ipapi.co"
+
+// IPInfoTool IP信息查询工具，默认返回整理后的摘要，raw 时返回接口原始JSON
+type IPInfoTool struct {
+	manager *Manager
+
+	// 测试时可替换的接口地址
+	apiURL string
+}
+
+// ipInfo ipapi.co 响应中用到的字段
+type ipInfo struct {
+	IP          string  `json:"ip"`
+	Version     string  `json:"version"`
+	City        string  `json:"city"`
+	Region      string  `json:"region"`
+	Country     string  `json:"country_name"`
+	CountryCode string  `json:"country_code"`
+	Postal      string  `json:"postal"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	Timezone    string  `json:"timezone"`
+	UTCOffset   string  `json:"utc_offset"`
+	ASN         string  `json:"asn"`
+	Org         string  `json:"org"`
+	Reserved    bool    `json:"reserved"`
+	Error       bool    `json:"error"`
+	Reason      string  `json:"reason"`
+}
+
+func (t *IPInfoTool) Name() string {
+	return "ip_info"
+}
+
+func (t *IPInfoTool) Description() string {
+	return "查询IP地址信息。可查询本机或指定IP的地理位置。"
+}
+
+func (t *IPInfoTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"ip": map[string]interface{}{
+				"type":        "string",
+				"description": "IP地址，留空查询本机",
+			},
+			"raw": map[string]interface{}{
+				"type":        "boolean",
+				"description": "返回接口原始JSON，默认返回整理后的摘要",
+			},
+		},
+		"required": []string{},
+	}
+}
+
+func (t *IPInfoTool) Execute(args map[string]interface{}) (string, error) {
+	ip, _ := args["ip"].(string)
+	ip = strings.TrimSpace(ip)
+	raw, _ := args["raw"].(bool)
+
+	u := t.endpoint() + "/json/"
+	if ip != "" {
+		u = fmt.Sprintf("%s/%s/json/", t.endpoint(), url.PathEscape(ip))
+	}
+	body, err := fetchBody(u)
+	if err != nil {
+		return "", fmt.Errorf("ip info request failed: %w", err)
+	}
+	if raw {
+		return string(body), nil
+	}
+
+	var info ipInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return "", fmt.Errorf("failed to parse ip response: %w", err)
+	}
+	if info.Error {
+		return "", fmt.Errorf("ip lookup failed: %s", info.Reason)
+	}
+	return info.summary(), nil
+}
+
+func (t *IPInfoTool) endpoint() string {
+	if t.apiURL != "" {
+		return t.apiURL
+	}
+	return ipAPIURL
+}
+
+// summary 整理为几行文本，缺失的字段省略
+func (i ipInfo) summary() string {
+	var sb strings.Builder
+	sb.WriteString("IP: " + i.IP)
+	if i.Version != "" {
+		sb.WriteString(" (" + i.Version + ")")
+	}
+	if i.Reserved {
+		sb.WriteString("\nReserved (private or special-use) address, no location available")
+		return sb.String()
+	}
+
+	location := joinNonEmpty(", ", i.City, i.Region, i.Country)
+	if i.CountryCode != "" && i.Country != "" {
+		location += " (" + i.CountryCode + ")"
+	}
+	if location != "" {
+		if i.Postal != "" {
+			location += ", postal " + i.Postal
+		}
+		sb.WriteString("\nLocation: " + location)
+	}
+	if i.Latitude != 0 || i.Longitude != 0 {
+		fmt.Fprintf(&sb, "\nCoordinates: %g, %g", i.Latitude, i.Longitude)
+	}
+	if i.Timezone != "" {
+		sb.WriteString("\nTimezone: " + i.Timezone)
+		if i.UTCOffset != "" {
+			sb.WriteString(" (UTC" + i.UTCOffset + ")")
+		}
+	}
+	if network := joinNonEmpty(" ", i.ASN, i.Org); network != "" {
+		sb.WriteString("\nNetwork: " + network)
+	}
+	return sb.String()
+}
+
+// joinNonEmpty 用 sep 连接非空字符串
+func joinNonEmpty(sep string, parts ...string) string {
+	var kept []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, sep)
+}
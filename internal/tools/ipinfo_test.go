@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIPInfoTool(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/8.8.8.8/json/":
+			w.Write([]byte(`{"ip":"8.8.8.8","version":"IPv4","city":"Mountain View","region":"California","country_name":"United States","country_code":"US","postal":"94043","latitude":37.42301,"longitude":-122.083352,"timezone":"America/Los_Angeles","utc_offset":"-0700","asn":"AS15169","org":"GOOGLE","currency":"USD","languages":"en-US,es-US"}`))
+		case "/10.0.0.1/json/":
+			w.Write([]byte(`{"ip":"10.0.0.1","reserved":true,"version":"IPv4"}`))
+		default:
+			w.Write([]byte(`{"ip":"bogus","error":true,"reason":"Invalid IP Address"}`))
+		}
+	}))
+	defer server.Close()
+
+	tool := &IPInfoTool{apiURL: server.URL}
+
+	result, err := tool.Execute(map[string]interface{}{"ip": "8.8.8.8"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	want := "IP: 8.8.8.8 (IPv4)\n" +
+		"Location: Mountain View, California, United States (US), postal 94043\n" +
+		"Coordinates: 37.42301, -122.083352\n" +
+		"Timezone: America/Los_Angeles (UTC-0700)\n" +
+		"Network: AS15169 GOOGLE"
+	if result != want {
+		t.Errorf("summary = %q, want %q", result, want)
+	}
+
+	result, err = tool.Execute(map[string]interface{}{"ip": "8.8.8.8", "raw": true})
+	if err != nil || !strings.Contains(result, `"languages":"en-US,es-US"`) {
+		t.Errorf("raw result = %q, %v", result, err)
+	}
+
+	result, err = tool.Execute(map[string]interface{}{"ip": "10.0.0.1"})
+	if err != nil || !strings.Contains(result, "Reserved") || strings.Contains(result, "Location") {
+		t.Errorf("reserved result = %q, %v", result, err)
+	}
+
+	if _, err := tool.Execute(map[string]interface{}{"ip": "bogus"}); err == nil || !strings.Contains(err.Error(), "Invalid IP Address") {
+		t.Errorf("invalid ip error = %v", err)
+	}
+}
@@ -1,10 +1,14 @@
 package tools
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"net/http"
 	"net/url"
@@ -13,11 +17,20 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/HaohanHe/mujibot/internal/confirmation"
+	"github.com/HaohanHe/mujibot/internal/httpclient"
+	"github.com/HaohanHe/mujibot/internal/i18n"
 	"github.com/HaohanHe/mujibot/internal/logger"
 	"github.com/HaohanHe/mujibot/internal/memory"
+	"github.com/HaohanHe/mujibot/internal/policy"
+	"github.com/HaohanHe/mujibot/internal/system"
+	"github.com/HaohanHe/mujibot/internal/todo"
 )
 
 type Tool interface {
@@ -29,28 +42,81 @@ type Tool interface {
 
 type Manager struct {
 	tools            map[string]Tool
+	disabled         map[string]Tool // 被配置或 SetEnabled 禁用的内置工具
+	toolsMu          sync.RWMutex
 	workDir          string
+	workspaces       map[string]Workspace
+	perUserWorkDir   bool
+	isAdmin          func(channel, userID string) bool
 	timeout          time.Duration
 	confirmDangerous bool
 	unattendedMode   bool
 	blockedCommands  []string
+	policy           *policy.Policy
 	enabledTools     map[string]bool
 	terminalEnabled  bool
+	terminalRows     int
+	terminalCols     int
 	webSearchEnabled bool
 	memoryMgr        *memory.Manager
+	confirmMgr       *confirmation.ConfirmationManager
+	email            EmailConfig
+	calendar         CalendarConfig
+	homeAssistant    HomeAssistantConfig
+	serviceUnits     []string
+	maxResultChars   int
+	toolTimeouts     map[string]int
+	settings         ToolSettings
+	postProcess      map[string]PostProcessConfig
+	summarizer       Summarizer
+	quotas           *quotaTracker
+	safeMode         *safeModeState
+	notify           func(channel, target, text string) error
+	translate        func(channel, userID, key string, params i18n.Params) string
+	observer         func(ctx context.Context, e ToolEvent)
+	outputFilter     func(ctx context.Context, name, result string) string
+	callSeq          atomic.Uint64
+	todos            *todo.Store
+	contacts         *memory.ContactBook
+	history          *EditHistory
 	log              *logger.Logger
+	version          atomic.Uint64
+
+	// GetToolDefinitions 的缓存，注册表版本变化时重建
+	defsMu      sync.Mutex
+	defs        []map[string]interface{}
+	defsVersion uint64
 }
 
 type Config struct {
 	WorkDir          string
+	Workspaces       []Workspace
+	PerUserWorkDir   bool                              // 普通用户只能访问 workDir/users 下自己的目录
+	SharedDir        string                            // 按用户隔离时的共享目录（相对workDir），注册为 shared 工作区
+	IsAdmin          func(channel, userID string) bool // 管理员不受按用户隔离限制
 	Timeout          int
 	ConfirmDangerous bool
 	UnattendedMode   bool
 	BlockedCommands  []string
+	Policy           *policy.Policy // 危险操作策略，为空时使用内置规则（含 BlockedCommands）
 	EnabledTools     map[string]bool
 	TerminalEnabled  bool
+	TerminalRows     int
+	TerminalCols     int
 	WebSearchEnabled bool
 	MemoryMgr        *memory.Manager
+	ConfirmMgr       *confirmation.ConfirmationManager
+	Email            EmailConfig
+	Calendar         CalendarConfig
+	HomeAssistant    HomeAssistantConfig
+	ServiceUnits     []string
+	MaxResultChars   int
+	ToolTimeouts     map[string]int
+	Settings         ToolSettings                 // 按工具调整参数默认值和上限
+	PostProcess      map[string]PostProcessConfig // 按工具对结果做后处理，键为工具名
+	Quotas           QuotaConfig
+	Todos            *todo.Store
+	Contacts         *memory.ContactBook
 }
 
 func NewManager(cfg Config, log *logger.Logger) (*Manager, error) {
@@ -60,17 +126,58 @@ func NewManager(cfg Config, log *logger.Logger) (*Manager, error) {
 
 	m := &Manager{
 		tools:            make(map[string]Tool),
+		disabled:         make(map[string]Tool),
 		workDir:          cfg.WorkDir,
+		perUserWorkDir:   cfg.PerUserWorkDir,
+		isAdmin:          cfg.IsAdmin,
 		timeout:          time.Duration(cfg.Timeout) * time.Second,
 		confirmDangerous: cfg.ConfirmDangerous,
 		unattendedMode:   cfg.UnattendedMode,
 		blockedCommands:  cfg.BlockedCommands,
+		policy:           cfg.Policy,
 		enabledTools:     cfg.EnabledTools,
 		terminalEnabled:  cfg.TerminalEnabled,
+		terminalRows:     cfg.TerminalRows,
+		terminalCols:     cfg.TerminalCols,
 		webSearchEnabled: cfg.WebSearchEnabled,
 		memoryMgr:        cfg.MemoryMgr,
+		confirmMgr:       cfg.ConfirmMgr,
+		email:            cfg.Email,
+		calendar:         cfg.Calendar,
+		homeAssistant:    cfg.HomeAssistant,
+		serviceUnits:     cfg.ServiceUnits,
+		maxResultChars:   cfg.MaxResultChars,
+		toolTimeouts:     cfg.ToolTimeouts,
+		settings:         cfg.Settings,
+		postProcess:      make(map[string]PostProcessConfig, len(cfg.PostProcess)),
+		quotas:           newQuotaTracker(cfg.Quotas),
+		safeMode:         newSafeModeState(),
+		todos:            cfg.Todos,
+		contacts:         cfg.Contacts,
+		history:          newEditHistory(cfg.WorkDir),
 		log:              log,
 	}
+	workspaces := cfg.Workspaces
+	if cfg.PerUserWorkDir && cfg.SharedDir != "" {
+		shared := cfg.SharedDir
+		if !filepath.IsAbs(shared) {
+			shared = filepath.Join(cfg.WorkDir, shared)
+		}
+		workspaces = append([]Workspace{{Name: SharedWorkspace, Path: shared}}, workspaces...)
+	}
+	m.initWorkspaces(workspaces)
+	for name, pp := range cfg.PostProcess {
+		m.postProcess[name] = pp
+	}
+	m.checkPostProcess()
+
+	if m.policy == nil {
+		p, err := policy.New(policy.Defaults(cfg.BlockedCommands))
+		if err != nil {
+			return nil, fmt.Errorf("invalid default policy: %w", err)
+		}
+		m.policy = p
+	}
 
 	// 注册内置工具
 	m.registerBuiltinTools()
@@ -80,47 +187,251 @@ func NewManager(cfg Config, log *logger.Logger) (*Manager, error) {
 
 // Register 注册工具
 func (m *Manager) Register(tool Tool) {
+	m.toolsMu.Lock()
 	m.tools[tool.Name()] = tool
+	delete(m.disabled, tool.Name())
+	m.toolsMu.Unlock()
+	m.version.Add(1)
 	m.log.Info("tool registered", "name", tool.Name())
 }
 
+// Unregister 运行时移除工具（包括被禁用的），返回工具是否存在
+func (m *Manager) Unregister(name string) bool {
+	m.toolsMu.Lock()
+	_, registered := m.tools[name]
+	_, disabled := m.disabled[name]
+	delete(m.tools, name)
+	delete(m.disabled, name)
+	m.toolsMu.Unlock()
+	if !registered && !disabled {
+		return false
+	}
+	m.version.Add(1)
+	m.log.Info("tool unregistered", "name", name)
+	return true
+}
+
+// Version 返回工具注册表版本号，注册表变化时递增
+func (m *Manager) Version() uint64 {
+	return m.version.Load()
+}
+
 // Get 获取工具
 func (m *Manager) Get(name string) (Tool, bool) {
+	m.toolsMu.RLock()
+	defer m.toolsMu.RUnlock()
 	tool, ok := m.tools[name]
 	return tool, ok
 }
 
-// GetAll 获取所有工具
+// GetAll 获取所有工具（按名称排序）
 func (m *Manager) GetAll() []Tool {
+	m.toolsMu.RLock()
 	result := make([]Tool, 0, len(m.tools))
 	for _, tool := range m.tools {
 		result = append(result, tool)
 	}
+	m.toolsMu.RUnlock()
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
 	return result
 }
 
-// Execute 执行工具
-func (m *Manager) Execute(name string, args map[string]interface{}) (string, error) {
-	tool, ok := m.tools[name]
+// SetEnabled 运行时启用或禁用工具，只能重新启用被禁用的内置工具
+func (m *Manager) SetEnabled(name string, enabled bool) error {
+	m.toolsMu.Lock()
+	defer m.toolsMu.Unlock()
+
+	if enabled {
+		tool, ok := m.disabled[name]
+		if !ok {
+			if _, registered := m.tools[name]; registered {
+				return nil
+			}
+			return fmt.Errorf("tool not found: %s", name)
+		}
+		m.tools[name] = tool
+		delete(m.disabled, name)
+	} else {
+		tool, ok := m.tools[name]
+		if !ok {
+			if _, disabled := m.disabled[name]; disabled {
+				return nil
+			}
+			return fmt.Errorf("tool not found: %s", name)
+		}
+		m.disabled[name] = tool
+		delete(m.tools, name)
+	}
+	m.version.Add(1)
+	m.log.Info("tool toggled", "name", name, "enabled", enabled)
+	return nil
+}
+
+// ApplyEnabled 按 tools.enabledTools 同步各工具的启用状态，配置热更新时调用，未列出的工具启用
+func (m *Manager) ApplyEnabled(enabledTools map[string]bool) {
+	m.toolsMu.Lock()
+	snapshot := make(map[string]bool, len(enabledTools))
+	for name, enabled := range enabledTools {
+		snapshot[name] = enabled
+	}
+	m.enabledTools = snapshot
+
+	var changed []string
+	for name, tool := range m.tools {
+		if enabled, ok := enabledTools[name]; ok && !enabled {
+			m.disabled[name] = tool
+			delete(m.tools, name)
+			changed = append(changed, name)
+		}
+	}
+	for name, tool := range m.disabled {
+		if enabled, ok := enabledTools[name]; !ok || enabled {
+			m.tools[name] = tool
+			delete(m.disabled, name)
+			changed = append(changed, name)
+		}
+	}
+	m.toolsMu.Unlock()
+
+	if len(changed) > 0 {
+		sort.Strings(changed)
+		m.version.Add(1)
+		m.log.Info("tools updated from config", "toggled", strings.Join(changed, ","))
+	}
+}
+
+// Disabled 返回被禁用的内置工具（按名称排序）
+func (m *Manager) Disabled() []Tool {
+	m.toolsMu.RLock()
+	result := make([]Tool, 0, len(m.disabled))
+	for _, tool := range m.disabled {
+		result = append(result, tool)
+	}
+	m.toolsMu.RUnlock()
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
+	return result
+}
+
+// ErrToolTimeout 工具执行超时
+var ErrToolTimeout = errors.New("tool execution timed out")
+
+// defaultToolTimeout tools.timeout 未配置时的默认超时
+const defaultToolTimeout = 30 * time.Second
+
+// defaultToolTimeouts 耗时较长的工具的默认超时，可被 tools.toolTimeouts 覆盖
+var defaultToolTimeouts = map[string]time.Duration{
+	"download_file": 2 * time.Minute,
+	"archive":       5 * time.Minute,
+	"terminal":      6 * time.Minute, // 危险命令需等待人工确认，最长5分钟
+}
+
+// timeoutFor 返回工具的执行超时：toolTimeouts 配置 > 内置默认 > tools.timeout
+func (m *Manager) timeoutFor(name string) time.Duration {
+	if secs, ok := m.toolTimeouts[name]; ok && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if d, ok := defaultToolTimeouts[name]; ok && d > m.timeout {
+		return d
+	}
+	if m.timeout > 0 {
+		return m.timeout
+	}
+	return defaultToolTimeout
+}
+
+// execute 执行工具，审计日志带上ctx中的请求ID，超出配额时拒绝执行，安全模式下先返回计划，结果按配置后处理，过长的结果会被截断
+// 每次调用都有超时，超时后立即返回 ErrToolTimeout，不支持ctx的工具在后台自行结束
+func (m *Manager) execute(ctx context.Context, name string, args map[string]interface{}) (string, error) {
+	tool, ok := m.Get(name)
 	if !ok {
 		return "", fmt.Errorf("tool not found: %s", name)
 	}
 
-	m.log.Info("executing tool", "name", name, "args", args)
+	log := m.log.Ctx(ctx)
+	log.Info("executing tool", "name", name, "args", args)
+	if m.perUserWorkDir {
+		args = withCallerArg(ctx, args)
+	}
+
+	if err := m.quotas.allow(ctx, name); err != nil {
+		log.Warn("tool quota exceeded", "name", name, "error", err)
+		return "", err
+	}
+
+	if preview, handled, err := m.safeModePlan(ctx, tool, args); handled {
+		if err != nil {
+			log.Error("tool plan failed", "name", name, "error", err)
+			return "", err
+		}
+		log.Info("tool plan returned for confirmation", "name", name)
+		return m.limitResult(name, args, preview), nil
+	}
+
+	timeout := m.timeoutFor(name)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	result, err := tool.Execute(args)
+	type outcome struct {
+		result string
+		err    error
+	}
+	args = withContextArg(ctx, args)
+	done := make(chan outcome, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{err: fmt.Errorf("tool %s panicked: %v", name, r)}
+			}
+		}()
+		var o outcome
+		if ct, ok := tool.(ContextTool); ok {
+			o.result, o.err = ct.ExecuteContext(ctx, args)
+		} else {
+			o.result, o.err = tool.Execute(args)
+		}
+		done <- o
+	}()
+
+	var result string
+	var err error
+	select {
+	case o := <-done:
+		result, err = o.result, o.err
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("%w: %s did not finish within %v, try a narrower request or a different approach", ErrToolTimeout, name, timeout)
+	}
 	if err != nil {
-		m.log.Error("tool execution failed", "name", name, "error", err)
+		log.Error("tool execution failed", "name", name, "error", err)
 		return "", err
 	}
 
-	m.log.Info("tool executed successfully", "name", name)
+	log.Info("tool executed successfully", "name", name)
+	result = m.postProcessResult(ctx, name, args, result)
+	result = m.limitResult(name, args, result)
+	if m.outputFilter != nil {
+		result = m.outputFilter(ctx, name, result)
+	}
 	return result, nil
 }
 
 func (m *Manager) GetToolDefinitions() []map[string]interface{} {
-	defs := make([]map[string]interface{}, 0, len(m.tools))
-	for _, tool := range m.tools {
+	m.defsMu.Lock()
+	defer m.defsMu.Unlock()
+	version := m.Version()
+	if m.defs != nil && m.defsVersion == version {
+		return m.defs
+	}
+
+	all := m.GetAll()
+	defs := make([]map[string]interface{}, 0, len(all))
+	for _, tool := range all {
 		defs = append(defs, map[string]interface{}{
 			"type": "function",
 			"function": map[string]interface{}{
@@ -130,21 +441,73 @@ func (m *Manager) GetToolDefinitions() []map[string]interface{} {
 			},
 		})
 	}
+	m.defs, m.defsVersion = defs, version
 	return defs
 }
 
+func (m *Manager) enabledToolsSnapshot() map[string]bool {
+	m.toolsMu.RLock()
+	defer m.toolsMu.RUnlock()
+	return m.enabledTools
+}
+
 func (m *Manager) GetConfig() Config {
 	return Config{
 		WorkDir:          m.workDir,
+		Workspaces:       m.Workspaces()[1:],
+		PerUserWorkDir:   m.perUserWorkDir,
+		IsAdmin:          m.isAdmin,
 		Timeout:          int(m.timeout.Seconds()),
 		ConfirmDangerous: m.confirmDangerous,
 		UnattendedMode:   m.unattendedMode,
 		BlockedCommands:  m.blockedCommands,
-		EnabledTools:     m.enabledTools,
+		EnabledTools:     m.enabledToolsSnapshot(),
 		TerminalEnabled:  m.terminalEnabled,
+		TerminalRows:     m.terminalRows,
+		TerminalCols:     m.terminalCols,
 		WebSearchEnabled: m.webSearchEnabled,
 		MemoryMgr:        m.memoryMgr,
+		ConfirmMgr:       m.confirmMgr,
+		Email:            m.email,
+		Calendar:         m.calendar,
+		HomeAssistant:    m.homeAssistant,
+		ServiceUnits:     m.serviceUnits,
+		MaxResultChars:   m.maxResultChars,
+		ToolTimeouts:     m.toolTimeouts,
+		Settings:         m.settings,
+		PostProcess:      m.postProcess,
+		Quotas:           m.quotas.cfg,
+		Todos:            m.todos,
+		Contacts:         m.contacts,
+	}
+}
+
+// SetNotifier 设置主动消息发送函数，用于后台任务结束时通知发起的聊天
+func (m *Manager) SetNotifier(send func(channel, target, text string) error) {
+	m.notify = send
+}
+
+// SetTranslator 设置通知文本的翻译函数，按发起用户的语言返回 key 对应的文本。未设置时使用英文
+func (m *Manager) SetTranslator(tr func(channel, userID, key string, params i18n.Params) string) {
+	m.translate = tr
+}
+
+// tr 按调用者的语言翻译通知文本
+func (m *Manager) tr(c Caller, key string, params i18n.Params) string {
+	if m.translate == nil {
+		return i18n.New("en-US").Tf(key, params)
 	}
+	return m.translate(c.Channel, c.UserID, key, params)
+}
+
+// SetOutputFilter 设置工具结果过滤函数，结果进入提示词之前调用（如提示注入防护）
+func (m *Manager) SetOutputFilter(fn func(ctx context.Context, name, result string) string) {
+	m.outputFilter = fn
+}
+
+// History 返回文件编辑历史
+func (m *Manager) History() *EditHistory {
+	return m.history
 }
 
 func (m *Manager) IsWebSearchEnabled() bool {
@@ -164,88 +527,82 @@ func (m *Manager) registerBuiltinTools() {
 		&ReadFileTool{manager: m},
 		&WriteFileTool{manager: m},
 		&ListDirectoryTool{manager: m},
+		&MoveFileTool{manager: m},
+		&CopyFileTool{manager: m},
+		&DeleteFileTool{manager: m},
+		&MakeDirectoryTool{manager: m},
+		&StatTool{manager: m},
+		&ArchiveTool{manager: m},
 		&ExecuteCommandTool{manager: m},
 		&GetSystemInfoTool{manager: m},
+		NewProcessesTool(m),
 		&ApplyPatchTool{manager: m},
-		&GrepTool{manager: m},
+		&UndoEditTool{manager: m},
+		&GrepTool{manager: m, settings: m.settings.Grep},
 		&MemoryReadTool{manager: m},
 		&MemoryWriteTool{manager: m},
+		&MemorySearchTool{manager: m},
 	}
 
-	if m.webSearchEnabled {
-		allTools = append(allTools, &WebSearchTool{manager: m})
-		allTools = append(allTools, &HTTPRequestTool{manager: m})
+	if m.todos != nil {
+		allTools = append(allTools,
+			&TodoAddTool{manager: m, store: m.todos},
+			&TodoListTool{manager: m, store: m.todos},
+			&TodoDoneTool{manager: m, store: m.todos},
+		)
 	}
 
-	allTools = append(allTools, &WeatherTool{manager: m})
-	allTools = append(allTools, &IPInfoTool{manager: m})
-	allTools = append(allTools, &ExchangeRateTool{manager: m})
-
-	for _, tool := range allTools {
-		name := tool.Name()
-		// 如果配置中有指定，按配置；否则默认启用
-		if enabled, ok := m.enabledTools[name]; ok && !enabled {
-			m.log.Info("tool disabled by config", "name", name)
-			continue
-		}
-		m.Register(tool)
+	if m.contacts != nil {
+		allTools = append(allTools,
+			&ContactsAddTool{manager: m, book: m.contacts},
+			&ContactsSearchTool{manager: m, book: m.contacts},
+			&ContactsUpdateTool{manager: m, book: m.contacts},
+		)
 	}
-}
 
-func (m *Manager) sanitizePath(path string) (string, error) {
-	if !filepath.IsAbs(path) {
-		path = filepath.Join(m.workDir, path)
+	if m.webSearchEnabled {
+		allTools = append(allTools, &WebSearchTool{manager: m, settings: m.settings.WebSearch})
+		allTools = append(allTools, &HTTPRequestTool{manager: m, settings: m.settings.HTTPRequest})
+		allTools = append(allTools, &ReadFeedTool{manager: m})
+		allTools = append(allTools, NewDownloadTool(m))
 	}
 
-	path = filepath.Clean(path)
+	allTools = append(allTools, &WeatherTool{manager: m, settings: m.settings.Weather})
+	allTools = append(allTools, &IPInfoTool{manager: m})
+	allTools = append(allTools, &ExchangeRateTool{manager: m})
+	allTools = append(allTools, NewQuotesTool(m, m.settings.Quotes))
+	allTools = append(allTools, &DateTimeTool{manager: m})
 
-	realPath, err := filepath.EvalSymlinks(path)
-	if err != nil {
-		if !os.IsNotExist(err) {
-			return "", fmt.Errorf("failed to resolve path: %w", err)
-		}
-		realPath = path
+	if m.email.Enabled && m.email.Host != "" {
+		allTools = append(allTools, NewEmailTool(m, m.email))
 	}
 
-	realWorkDir, err := filepath.EvalSymlinks(m.workDir)
-	if err != nil {
-		return "", fmt.Errorf("failed to resolve work directory: %w", err)
+	if m.calendar.Enabled && m.calendar.URL != "" {
+		allTools = append(allTools, NewCalendarTools(m, m.calendar)...)
 	}
 
-	rel, err := filepath.Rel(realWorkDir, realPath)
-	if err != nil || strings.HasPrefix(rel, "..") || filepath.IsAbs(rel) {
-		return "", fmt.Errorf("path is outside work directory: %s", path)
+	if m.homeAssistant.Enabled && m.homeAssistant.URL != "" {
+		allTools = append(allTools, NewHomeAssistantTool(m, m.homeAssistant))
 	}
 
-	return path, nil
-}
+	if len(m.serviceUnits) > 0 {
+		allTools = append(allTools, NewSystemctlTool(m, m.serviceUnits))
+	}
 
-func isDangerousCommand(cmd string) bool {
-	dangerousPatterns := []string{
-		"rm -rf",
-		"rm -r",
-		"rm -f",
-		"del /",
-		"format",
-		"fdisk",
-		"mkfs",
-		"dd if=",
-		"chmod 777",
-		"chown -R",
-		"> /dev/",
-		":(){ :|:& };:",
-		"wget | sh",
-		"curl | sh",
-		"curl | bash",
+	if m.terminalEnabled {
+		allTools = append(allTools, NewTerminalTool(m, m.confirmMgr))
 	}
 
-	lowerCmd := strings.ToLower(cmd)
-	for _, pattern := range dangerousPatterns {
-		if strings.Contains(lowerCmd, strings.ToLower(pattern)) {
-			return true
+	for _, tool := range allTools {
+		name := tool.Name()
+		// 如果配置中有指定，按配置；否则默认启用
+		if enabled, ok := m.enabledTools[name]; ok && !enabled {
+			m.log.Info("tool disabled by config", "name", name)
+			m.disabled[name] = tool
+			continue
 		}
+		m.Register(tool)
 	}
-	return false
 }
 
 func hasCommandInjection(cmd string) bool {
@@ -270,6 +627,29 @@ func hasCommandInjection(cmd string) bool {
 	return false
 }
 
+// CheckURL 校验外部请求地址，仅允许 http/https 且禁止访问本机和内网
+func CheckURL(rawURL string) (*url.URL, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, fmt.Errorf("only http/https protocols are allowed")
+	}
+
+	host := parsedURL.Hostname()
+	if host == "localhost" || host == "127.0.0.1" || host == "::1" {
+		return nil, fmt.Errorf("access to localhost is not allowed")
+	}
+
+	if isPrivateIP(host) {
+		return nil, fmt.Errorf("access to private IP addresses is not allowed")
+	}
+
+	return parsedURL, nil
+}
+
 func isPrivateIP(host string) bool {
 	if host == "" {
 		return false
@@ -313,17 +693,26 @@ func (t *ReadFileTool) Name() string {
 }
 
 func (t *ReadFileTool) Description() string {
-	return "读取文件内容。支持文本文件，限制1MB以内。"
+	return "读取文件内容。支持文本文件，整体读取限制1MB以内；指定 start_line/end_line 时按行分页读取并带行号，适合查看大文件的局部。"
 }
 
 func (t *ReadFileTool) Parameters() map[string]interface{} {
 	return map[string]interface{}{
 		"type": "object",
 		"properties": map[string]interface{}{
+			"workspace": t.manager.workspaceParam(),
 			"path": map[string]interface{}{
 				"type":        "string",
 				"description": "文件路径（相对workDir或绝对路径）",
 			},
+			"start_line": map[string]interface{}{
+				"type":        "integer",
+				"description": "起始行号（从1开始）",
+			},
+			"end_line": map[string]interface{}{
+				"type":        "integer",
+				"description": "结束行号（包含），默认读取起始行之后的200行",
+			},
 		},
 		"required": []string{"path"},
 	}
@@ -335,10 +724,19 @@ func (t *ReadFileTool) Execute(args map[string]interface{}) (string, error) {
 		return "", fmt.Errorf("path is required")
 	}
 
-	safePath, err := t.manager.sanitizePath(path)
+	safePath, err := t.manager.resolvePath(args, path, false)
 	if err != nil {
 		return "", err
 	}
+	if err := t.manager.enforcePolicy(t.Name(), args, "", safePath); err != nil {
+		return "", err
+	}
+
+	startLine, hasStart := args["start_line"].(float64)
+	endLine, hasEnd := args["end_line"].(float64)
+	if hasStart || hasEnd {
+		return readLines(safePath, int(startLine), int(endLine))
+	}
 
 	// 检查文件大小
 	info, err := os.Stat(safePath)
@@ -347,7 +745,7 @@ func (t *ReadFileTool) Execute(args map[string]interface{}) (string, error) {
 	}
 
 	if info.Size() > 1024*1024 {
-		return "", fmt.Errorf("file too large (max 1MB)")
+		return "", fmt.Errorf("file too large (max 1MB)，使用 start_line/end_line 分页读取")
 	}
 
 	content, err := os.ReadFile(safePath)
@@ -358,6 +756,72 @@ func (t *ReadFileTool) Execute(args map[string]interface{}) (string, error) {
 	return string(content), nil
 }
 
+const (
+	// readPageLines 未指定结束行时每页读取的行数
+	readPageLines = 200
+	// readMaxLines 单次读取的最大行数
+	readMaxLines = 2000
+)
+
+// readLines 按行读取文件的 [start, end] 区间，输出带行号
+func readLines(path string, start, end int) (string, error) {
+	if start < 1 {
+		start = 1
+	}
+	if end <= 0 {
+		end = start + readPageLines - 1
+	}
+	if end < start {
+		return "", fmt.Errorf("end_line must not be less than start_line")
+	}
+	if end-start+1 > readMaxLines {
+		end = start + readMaxLines - 1
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	defer f.Close()
+
+	var sb strings.Builder
+	reader := bufio.NewReader(f)
+	total := 0
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			total++
+			if total >= start && total <= end {
+				// 单行过长时截断，避免超长行撑爆上下文
+				line = strings.TrimRight(line, "\r\n")
+				if len(line) > 2000 {
+					line = strings.ToValidUTF8(line[:2000], "") + "...[truncated]"
+				}
+				fmt.Fprintf(&sb, "%6d\t%s\n", total, line)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+	}
+
+	if start > total {
+		return "", fmt.Errorf("start_line %d is beyond end of file (%d lines)", start, total)
+	}
+	if end > total {
+		end = total
+	}
+
+	header := fmt.Sprintf("[lines %d-%d of %d]\n", start, end, total)
+	if end < total {
+		sb.WriteString(fmt.Sprintf("[%d more lines, continue with start_line=%d]\n", total-end, end+1))
+	}
+	return header + sb.String(), nil
+}
+
 // WriteFileTool 写入文件工具
 type WriteFileTool struct {
 	manager *Manager
@@ -375,6 +839,7 @@ func (t *WriteFileTool) Parameters() map[string]interface{} {
 	return map[string]interface{}{
 		"type": "object",
 		"properties": map[string]interface{}{
+			"workspace": t.manager.workspaceParam(),
 			"path": map[string]interface{}{
 				"type":        "string",
 				"description": "文件路径（相对workDir或绝对路径）",
@@ -388,6 +853,30 @@ func (t *WriteFileTool) Parameters() map[string]interface{} {
 	}
 }
 
+// Plan 安全模式下展示写入前后的diff
+func (t *WriteFileTool) Plan(args map[string]interface{}) (string, error) {
+	path, _ := args["path"].(string)
+	content, ok := args["content"].(string)
+	if path == "" || !ok {
+		return "", fmt.Errorf("path and content are required")
+	}
+
+	safePath, err := t.manager.resolvePath(args, path, true)
+	if err != nil {
+		return "", err
+	}
+
+	before, err := os.ReadFile(safePath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	rel := t.manager.relPath(safePath)
+	if string(before) == content {
+		return fmt.Sprintf("%s already has this content, nothing would change", rel), nil
+	}
+	return unifiedDiff(rel, string(before), content), nil
+}
+
 func (t *WriteFileTool) Execute(args map[string]interface{}) (string, error) {
 	path, ok := args["path"].(string)
 	if !ok {
@@ -399,10 +888,13 @@ func (t *WriteFileTool) Execute(args map[string]interface{}) (string, error) {
 		return "", fmt.Errorf("content is required")
 	}
 
-	safePath, err := t.manager.sanitizePath(path)
+	safePath, err := t.manager.resolvePath(args, path, true)
 	if err != nil {
 		return "", err
 	}
+	if err := t.manager.enforcePolicy(t.Name(), args, "", safePath); err != nil {
+		return "", err
+	}
 
 	// 确保目录存在
 	dir := filepath.Dir(safePath)
@@ -410,7 +902,10 @@ func (t *WriteFileTool) Execute(args map[string]interface{}) (string, error) {
 		return "", fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	if err := os.WriteFile(safePath, []byte(content), 0644); err != nil {
+	err = t.manager.history.Track(t.Name(), safePath, func() error {
+		return os.WriteFile(safePath, []byte(content), 0644)
+	})
+	if err != nil {
 		return "", fmt.Errorf("failed to write file: %w", err)
 	}
 
@@ -434,6 +929,7 @@ func (t *ListDirectoryTool) Parameters() map[string]interface{} {
 	return map[string]interface{}{
 		"type": "object",
 		"properties": map[string]interface{}{
+			"workspace": t.manager.workspaceParam(),
 			"path": map[string]interface{}{
 				"type":        "string",
 				"description": "目录路径（相对workDir或绝对路径），默认为workDir",
@@ -448,7 +944,7 @@ func (t *ListDirectoryTool) Execute(args map[string]interface{}) (string, error)
 		path = p
 	}
 
-	safePath, err := t.manager.sanitizePath(path)
+	safePath, err := t.manager.resolvePath(args, path, false)
 	if err != nil {
 		return "", err
 	}
@@ -491,56 +987,51 @@ func (t *ExecuteCommandTool) Parameters() map[string]interface{} {
 				"type":        "string",
 				"description": "要执行的命令",
 			},
-			"confirm": map[string]interface{}{
-				"type":        "boolean",
-				"description": "危险命令确认",
-			},
 		},
 		"required": []string{"command"},
 	}
 }
 
-func (t *ExecuteCommandTool) Execute(args map[string]interface{}) (string, error) {
-	command, ok := args["command"].(string)
-	if !ok {
+// Plan 安全模式下展示将要执行的命令
+func (t *ExecuteCommandTool) Plan(args map[string]interface{}) (string, error) {
+	command, _ := args["command"].(string)
+	if command == "" {
 		return "", fmt.Errorf("command is required")
 	}
-
 	if hasCommandInjection(command) {
 		return "", fmt.Errorf("potential command injection detected")
 	}
 
-	blockedCommand := ""
-	lowerCmd := strings.ToLower(command)
-	for _, blocked := range t.manager.blockedCommands {
-		if strings.Contains(lowerCmd, strings.ToLower(blocked)) {
-			blockedCommand = blocked
-			break
-		}
+	plan := fmt.Sprintf("Run in %s:\n$ %s", t.manager.homeDir(callerArg(args)), command)
+	switch d := t.manager.evaluatePolicy(t.Name(), command); d.Action {
+	case policy.Deny:
+		plan += fmt.Sprintf("\nWarning: this command is denied by policy rule %s", d.Rule)
+	case policy.Confirm:
+		plan += fmt.Sprintf("\nWarning: this command is considered dangerous (%s risk, policy rule %s)", d.Risk, d.Rule)
 	}
+	return plan, nil
+}
 
-	isDangerous := isDangerousCommand(command)
-	needsConfirmation := false
-	confirmationMsg := ""
+func (t *ExecuteCommandTool) Execute(args map[string]interface{}) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
 
-	if blockedCommand != "" {
-		needsConfirmation = true
-		confirmationMsg = fmt.Sprintf("命令包含黑名单命令: %s，需要确认", blockedCommand)
-	} else if isDangerous {
-		needsConfirmation = true
-		confirmationMsg = "危险命令需要确认"
+func (t *ExecuteCommandTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (string, error) {
+	command, ok := args["command"].(string)
+	if !ok {
+		return "", fmt.Errorf("command is required")
 	}
 
-	if needsConfirmation {
-		if t.manager.confirmDangerous && !t.manager.unattendedMode {
-			confirmed, _ := args["confirm"].(bool)
-			if !confirmed {
-				return "", fmt.Errorf("%s。设置 confirm=true 来执行", confirmationMsg)
-			}
-		}
+	if hasCommandInjection(command) {
+		return "", fmt.Errorf("potential command injection detected")
+	}
+
+	if err := t.manager.enforcePolicy(t.Name(), args, command); err != nil {
+		return "", err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), t.manager.timeout)
+	timeout := t.manager.timeoutFor(t.Name())
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	var cmd *exec.Cmd
@@ -549,11 +1040,11 @@ func (t *ExecuteCommandTool) Execute(args map[string]interface{}) (string, error
 	} else {
 		cmd = exec.CommandContext(ctx, "sh", "-c", command)
 	}
-	cmd.Dir = t.manager.workDir
+	cmd.Dir = t.manager.homeDir(callerArg(args))
 
 	output, err := cmd.CombinedOutput()
 	if ctx.Err() == context.DeadlineExceeded {
-		return "", fmt.Errorf("command timed out after %v", t.manager.timeout)
+		return "", fmt.Errorf("command timed out after %v", timeout)
 	}
 
 	result := string(output)
@@ -574,7 +1065,7 @@ func (t *GetSystemInfoTool) Name() string {
 }
 
 func (t *GetSystemInfoTool) Description() string {
-	return "获取系统信息，包括内存使用、磁盘空间等。"
+	return "获取系统信息：操作系统、CPU核数、内存、磁盘空间、平均负载和运行时间。"
 }
 
 func (t *GetSystemInfoTool) Parameters() map[string]interface{} {
@@ -585,115 +1076,63 @@ func (t *GetSystemInfoTool) Parameters() map[string]interface{} {
 }
 
 func (t *GetSystemInfoTool) Execute(args map[string]interface{}) (string, error) {
-	info := make(map[string]interface{})
-
-	// 内存信息
-	memInfo, err := exec.Command("free", "-h").Output()
-	if err == nil {
-		info["memory"] = string(memInfo)
-	}
-
-	// 磁盘信息
-	diskInfo, err := exec.Command("df", "-h").Output()
-	if err == nil {
-		info["disk"] = string(diskInfo)
-	}
-
-	// 负载信息
-	loadInfo, err := exec.Command("uptime").Output()
-	if err == nil {
-		info["uptime"] = string(loadInfo)
-	}
-
-	// 工作目录
-	info["work_dir"] = t.manager.workDir
-
-	result, _ := json.MarshalIndent(info, "", "  ")
-	return string(result), nil
-}
-
-// ApplyPatchTool 应用代码补丁工具
-type ApplyPatchTool struct {
-	manager *Manager
-}
-
-func (t *ApplyPatchTool) Name() string {
-	return "apply_patch"
-}
-
-func (t *ApplyPatchTool) Description() string {
-	return "应用代码补丁到文件。支持统一diff格式，可以精确修改文件内容。"
-}
-
-func (t *ApplyPatchTool) Parameters() map[string]interface{} {
-	return map[string]interface{}{
-		"type": "object",
-		"properties": map[string]interface{}{
-			"path": map[string]interface{}{
-				"type":        "string",
-				"description": "要修改的文件路径",
-			},
-			"old_string": map[string]interface{}{
-				"type":        "string",
-				"description": "要被替换的旧字符串（必须精确匹配）",
-			},
-			"new_string": map[string]interface{}{
-				"type":        "string",
-				"description": "用于替换的新字符串",
-			},
-		},
-		"required": []string{"path", "old_string", "new_string"},
-	}
-}
-
-func (t *ApplyPatchTool) Execute(args map[string]interface{}) (string, error) {
-	path, ok := args["path"].(string)
-	if !ok {
-		return "", fmt.Errorf("path is required")
+	info := map[string]interface{}{
+		"os":        runtime.GOOS,
+		"arch":      runtime.GOARCH,
+		"cpu_cores": runtime.NumCPU(),
+		"work_dir":  t.manager.workDir,
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		info["hostname"] = hostname
+	}
+
+	// 直接读取系统接口，不依赖 free/df/uptime 等命令
+	if mem, err := system.ReadMemInfo(); err == nil {
+		info["memory"] = map[string]interface{}{
+			"total_bytes":      mem.TotalBytes,
+			"available_bytes":  mem.AvailableBytes,
+			"used_percent":     math.Round(mem.UsedPercent()*10) / 10,
+			"swap_total_bytes": mem.SwapTotalBytes,
+			"swap_free_bytes":  mem.SwapFreeBytes,
+		}
 	}
 
-	oldStr, ok := args["old_string"].(string)
-	if !ok {
-		return "", fmt.Errorf("old_string is required")
+	var disks []system.DiskStats
+	if disk, err := system.DiskUsage(t.manager.workDir); err == nil {
+		disk.Name = "work_dir"
+		disks = append(disks, disk)
 	}
-
-	newStr, ok := args["new_string"].(string)
-	if !ok {
-		return "", fmt.Errorf("new_string is required")
+	if runtime.GOOS != "windows" {
+		// 根分区与工作目录不在同一文件系统时一并报告
+		if root, err := system.DiskUsage("/"); err == nil && (len(disks) == 0 || root.TotalBytes != disks[0].TotalBytes || root.FreeBytes != disks[0].FreeBytes) {
+			root.Name = "root"
+			disks = append(disks, root)
+		}
 	}
-
-	safePath, err := t.manager.sanitizePath(path)
-	if err != nil {
-		return "", err
+	for i := range disks {
+		disks[i].UsedPercent = math.Round(disks[i].UsedPercent*10) / 10
 	}
-
-	// 读取文件内容
-	content, err := os.ReadFile(safePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to read file: %w", err)
+	if len(disks) > 0 {
+		info["disks"] = disks
 	}
 
-	oldContent := string(content)
-
-	// 检查old_string是否存在
-	if !strings.Contains(oldContent, oldStr) {
-		return "", fmt.Errorf("old_string not found in file")
+	if load, err := system.LoadAverage(); err == nil {
+		info["load_average"] = load
 	}
 
-	// 替换内容
-	newContent := strings.Replace(oldContent, oldStr, newStr, 1)
-
-	// 写回文件
-	if err := os.WriteFile(safePath, []byte(newContent), 0644); err != nil {
-		return "", fmt.Errorf("failed to write file: %w", err)
+	if uptime, err := system.Uptime(); err == nil {
+		info["uptime_seconds"] = int64(uptime.Seconds())
+		info["uptime"] = uptime.Truncate(time.Second).String()
 	}
 
-	return fmt.Sprintf("Patch applied successfully to %s", safePath), nil
+	result, _ := json.MarshalIndent(info, "", "  ")
+	return string(result), nil
 }
 
 // WebSearchTool 网页搜索工具
 type WebSearchTool struct {
-	manager *Manager
+	manager  *Manager
+	settings WebSearchSettings
 }
 
 func (t *WebSearchTool) Name() string {
@@ -714,7 +1153,7 @@ func (t *WebSearchTool) Parameters() map[string]interface{} {
 			},
 			"num_results": map[string]interface{}{
 				"type":        "integer",
-				"description": "返回结果数量（默认5，最大10）",
+				"description": fmt.Sprintf("返回结果数量（默认%d，最大%d）", t.settings.defaultResults(), t.settings.maxResults()),
 			},
 		},
 		"required": []string{"query"},
@@ -722,27 +1161,32 @@ func (t *WebSearchTool) Parameters() map[string]interface{} {
 }
 
 func (t *WebSearchTool) Execute(args map[string]interface{}) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+func (t *WebSearchTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (string, error) {
 	query, ok := args["query"].(string)
 	if !ok || query == "" {
 		return "", fmt.Errorf("query is required")
 	}
 
-	numResults := 5
-	if n, ok := args["num_results"].(float64); ok {
+	numResults := t.settings.defaultResults()
+	if n, ok := args["num_results"].(float64); ok && n >= 1 {
 		numResults = int(n)
-		if numResults > 10 {
-			numResults = 10
-		}
-		if numResults < 1 {
-			numResults = 5
+		if max := t.settings.maxResults(); numResults > max {
+			numResults = max
 		}
 	}
 
 	// 使用DuckDuckGo HTML版本搜索
 	searchURL := fmt.Sprintf("https://html.duckduckgo.com/html/?q=%s", strings.ReplaceAll(query, " ", "+"))
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(searchURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, searchURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	client := httpclient.New(10 * time.Second)
+	resp, err := client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("search request failed: %w", err)
 	}
@@ -794,7 +1238,8 @@ func (t *WebSearchTool) Execute(args map[string]interface{}) (string, error) {
 }
 
 type HTTPRequestTool struct {
-	manager *Manager
+	manager  *Manager
+	settings HTTPRequestSettings
 }
 
 func (t *HTTPRequestTool) Name() string {
@@ -802,7 +1247,7 @@ func (t *HTTPRequestTool) Name() string {
 }
 
 func (t *HTTPRequestTool) Description() string {
-	return "发送HTTP请求获取网页内容。用于获取搜索结果的详细内容。"
+	return "发送HTTP请求。用于获取网页内容（默认自动提取正文）或调用REST API（支持请求头、请求体，JSON响应会格式化）。"
 }
 
 func (t *HTTPRequestTool) Parameters() map[string]interface{} {
@@ -815,7 +1260,41 @@ func (t *HTTPRequestTool) Parameters() map[string]interface{} {
 			},
 			"method": map[string]interface{}{
 				"type":        "string",
-				"description": "HTTP方法（GET/POST，默认GET）",
+				"description": "HTTP方法（默认GET）",
+				"enum":        []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD"},
+			},
+			"headers": map[string]interface{}{
+				"type":        "object",
+				"description": "请求头，如 {\"Authorization\": \"Bearer xxx\"}",
+			},
+			"body": map[string]interface{}{
+				"type":        "string",
+				"description": "请求体，JSON字符串会以application/json发送",
+			},
+			"content_type": map[string]interface{}{
+				"type":        "string",
+				"description": "请求体的Content-Type，默认根据body自动判断",
+			},
+			"include_headers": map[string]interface{}{
+				"type":        "boolean",
+				"description": "是否返回全部响应头（默认只返回状态码和Content-Type）",
+			},
+			"selector": map[string]interface{}{
+				"type":        "string",
+				"description": "CSS选择器，只提取匹配的元素，如 'article'、'#content'、'div.post > p'",
+			},
+			"format": map[string]interface{}{
+				"type":        "string",
+				"description": "输出格式: text（默认）或 markdown（保留标题、列表和链接）",
+				"enum":        []string{"text", "markdown"},
+			},
+			"readability": map[string]interface{}{
+				"type":        "boolean",
+				"description": "是否只提取正文，去除导航、广告等（默认true）",
+			},
+			"max_length": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("最大返回字符数（默认%d，最大%d）", t.settings.defaultLength(), t.settings.maxLength()),
 			},
 		},
 		"required": []string{"url"},
@@ -823,47 +1302,58 @@ func (t *HTTPRequestTool) Parameters() map[string]interface{} {
 }
 
 func (t *HTTPRequestTool) Execute(args map[string]interface{}) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+func (t *HTTPRequestTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (string, error) {
 	urlStr, ok := args["url"].(string)
 	if !ok || urlStr == "" {
 		return "", fmt.Errorf("url is required")
 	}
 
-	parsedURL, err := url.Parse(urlStr)
-	if err != nil {
-		return "", fmt.Errorf("invalid url: %w", err)
+	if _, err := CheckURL(urlStr); err != nil {
+		return "", err
 	}
 
-	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return "", fmt.Errorf("only http/https protocols are allowed")
+	method := "GET"
+	if m, ok := args["method"].(string); ok && m != "" {
+		method = strings.ToUpper(m)
 	}
-
-	host := parsedURL.Hostname()
-	if host == "localhost" || host == "127.0.0.1" || host == "::1" {
-		return "", fmt.Errorf("access to localhost is not allowed")
+	if !httpMethods[method] {
+		return "", fmt.Errorf("unsupported method: %s", method)
 	}
 
-	if isPrivateIP(host) {
-		return "", fmt.Errorf("access to private IP addresses is not allowed")
+	body, contentType, err := requestBody(args["body"])
+	if err != nil {
+		return "", err
 	}
-
-	method := "GET"
-	if m, ok := args["method"].(string); ok {
-		method = strings.ToUpper(m)
+	if ct, ok := args["content_type"].(string); ok && ct != "" {
+		contentType = ct
 	}
 
-	client := &http.Client{Timeout: 15 * time.Second}
-	var req *http.Request
-
-	if method == "POST" {
-		req, err = http.NewRequest("POST", urlStr, nil)
-	} else {
-		req, err = http.NewRequest("GET", urlStr, nil)
+	client := httpclient.New(15 * time.Second)
+	// 每次重定向都重新校验目标，防止跳转到本机或内网地址
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("too many redirects")
+		}
+		_, err := CheckURL(req.URL.String())
+		return err
 	}
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, body)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Mujibot/1.0)")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if headers, ok := args["headers"].(map[string]interface{}); ok {
+		for k, v := range headers {
+			req.Header.Set(k, fmt.Sprint(v))
+		}
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -871,325 +1361,155 @@ func (t *HTTPRequestTool) Execute(args map[string]interface{}) (string, error) {
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	data, err := io.ReadAll(io.LimitReader(resp.Body, t.settings.maxBytes()))
 	if err != nil {
 		return "", fmt.Errorf("failed to read response: %w", err)
 	}
 
-	content := string(body)
-
-	content = stripHTMLTags(content)
-
-	if len(content) > 5000 {
-		content = content[:5000] + "\n... (truncated)"
-	}
-
-	content = strings.TrimSpace(content)
-	if len(content) == 0 {
-		return "Empty response", nil
-	}
-
-	return content, nil
-}
-
-// WeatherTool 天气查询工具
-type WeatherTool struct {
-	manager *Manager
-}
-
-func (t *WeatherTool) Name() string {
-	return "weather"
-}
-
-func (t *WeatherTool) Description() string {
-	return "查询城市天气。使用wttr.in免费API，无需API密钥。"
-}
-
-func (t *WeatherTool) Parameters() map[string]interface{} {
-	return map[string]interface{}{
-		"type": "object",
-		"properties": map[string]interface{}{
-			"city": map[string]interface{}{
-				"type":        "string",
-				"description": "城市名称，如 Beijing, Shanghai, Tokyo",
-			},
-			"format": map[string]interface{}{
-				"type":        "string",
-				"description": "格式: 1(简洁), 2(详细), 3(完整), 默认1",
-			},
-		},
-		"required": []string{"city"},
+	maxLength := t.settings.defaultLength()
+	if v, ok := args["max_length"].(float64); ok && v > 0 {
+		maxLength = int(v)
 	}
-}
-
-func (t *WeatherTool) Execute(args map[string]interface{}) (string, error) {
-	city, ok := args["city"].(string)
-	if !ok || city == "" {
-		return "", fmt.Errorf("city is required")
+	if max := t.settings.maxLength(); maxLength > max {
+		maxLength = max
 	}
 
-	format := "1"
-	if f, ok := args["format"].(string); ok && f != "" {
-		format = f
-	}
-
-	// wttr.in 免费天气API
-	url := fmt.Sprintf("https://wttr.in/%s?format=%s&lang=zh", city, format)
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return "", fmt.Errorf("weather request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("weather API returned status %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read weather response: %w", err)
+	respType := resp.Header.Get("Content-Type")
+	if isBinaryResponse(respType, data) {
+		if respType == "" {
+			respType = http.DetectContentType(data)
+		}
+		return "", fmt.Errorf("binary response not supported (%s, %d bytes, HTTP %d)", respType, len(data), resp.StatusCode)
 	}
 
-	return string(body), nil
-}
-
-// IPInfoTool IP信息查询工具
-type IPInfoTool struct {
-	manager *Manager
-}
-
-func (t *IPInfoTool) Name() string {
-	return "ip_info"
-}
-
-func (t *IPInfoTool) Description() string {
-	return "查询IP地址信息。可查询本机或指定IP的地理位置。"
-}
-
-func (t *IPInfoTool) Parameters() map[string]interface{} {
-	return map[string]interface{}{
-		"type": "object",
-		"properties": map[string]interface{}{
-			"ip": map[string]interface{}{
-				"type":        "string",
-				"description": "IP地址，留空查询本机",
-			},
-		},
-		"required": []string{},
-	}
-}
+	content := string(data)
+	title := ""
+	switch {
+	case isJSONResponse(respType, data):
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, data, "", "  "); err == nil {
+			content = pretty.String()
+		}
+	case isHTMLResponse(respType, content):
+		opts := extractOptions{
+			Readability: true,
+			Markdown:    args["format"] == "markdown",
+			BaseURL:     resp.Request.URL,
+		}
+		if selector, ok := args["selector"].(string); ok {
+			opts.Selector = strings.TrimSpace(selector)
+		}
+		if v, ok := args["readability"].(bool); ok {
+			opts.Readability = v
+		}
 
-func (t *IPInfoTool) Execute(args map[string]interface{}) (string, error) {
-	ip := ""
-	if i, ok := args["ip"].(string); ok {
-		ip = i
+		page, err := extractHTML(content, opts)
+		if err != nil {
+			return "", err
+		}
+		title = page.Title
+		content = page.Content
 	}
 
-	// ipapi.co 免费API
-	url := "https://ipapi.co/json/"
-	if ip != "" {
-		url = fmt.Sprintf("https://ipapi.co/%s/json/", ip)
+	content = strings.TrimSpace(content)
+	if runes := []rune(content); len(runes) > maxLength {
+		content = string(runes[:maxLength]) + "\n... (truncated)"
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return "", fmt.Errorf("ip info request failed: %w", err)
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("HTTP %s\n", resp.Status))
+	if includeHeaders, _ := args["include_headers"].(bool); includeHeaders {
+		names := make([]string, 0, len(resp.Header))
+		for name := range resp.Header {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			output.WriteString(fmt.Sprintf("%s: %s\n", name, strings.Join(resp.Header[name], ", ")))
+		}
+	} else if respType != "" {
+		output.WriteString("Content-Type: " + respType + "\n")
 	}
-	defer resp.Body.Close()
+	output.WriteString("\n")
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("ip API returned status %d", resp.StatusCode)
+	if title != "" {
+		output.WriteString("Title: " + title + "\n\n")
 	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read ip response: %w", err)
+	if content == "" {
+		content = "Empty response"
 	}
-
-	return string(body), nil
-}
-
-// ExchangeRateTool 汇率查询工具
-type ExchangeRateTool struct {
-	manager *Manager
-}
-
-func (t *ExchangeRateTool) Name() string {
-	return "exchange_rate"
+	output.WriteString(content)
+	return output.String(), nil
 }
 
-func (t *ExchangeRateTool) Description() string {
-	return "查询货币汇率。使用 exchangerate-api.com 免费API。"
+// httpMethods http_request 支持的请求方法
+var httpMethods = map[string]bool{
+	"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true, "HEAD": true,
 }
 
-func (t *ExchangeRateTool) Parameters() map[string]interface{} {
-	return map[string]interface{}{
-		"type": "object",
-		"properties": map[string]interface{}{
-			"from": map[string]interface{}{
-				"type":        "string",
-				"description": "源货币代码，如 USD, CNY, EUR",
-			},
-			"to": map[string]interface{}{
-				"type":        "string",
-				"description": "目标货币代码，如 CNY, USD, EUR",
-			},
-		},
-		"required": []string{"from", "to"},
+// requestBody 构造请求体，对象和数组编码为JSON
+func requestBody(v interface{}) (io.Reader, string, error) {
+	switch b := v.(type) {
+	case nil:
+		return nil, "", nil
+	case string:
+		if b == "" {
+			return nil, "", nil
+		}
+		if json.Valid([]byte(b)) {
+			return strings.NewReader(b), "application/json", nil
+		}
+		return strings.NewReader(b), "text/plain; charset=utf-8", nil
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid body: %w", err)
+		}
+		return bytes.NewReader(data), "application/json", nil
 	}
 }
 
-func (t *ExchangeRateTool) Execute(args map[string]interface{}) (string, error) {
-	from, ok := args["from"].(string)
-	if !ok || from == "" {
-		return "", fmt.Errorf("from currency is required")
+// isJSONResponse 根据Content-Type或内容判断是否为JSON
+func isJSONResponse(contentType string, data []byte) bool {
+	ct := strings.ToLower(contentType)
+	if strings.Contains(ct, "json") {
+		return true
 	}
-	from = strings.ToUpper(from)
-
-	to, ok := args["to"].(string)
-	if !ok || to == "" {
-		return "", fmt.Errorf("to currency is required")
-	}
-	to = strings.ToUpper(to)
-
-	// exchangerate-api.com 免费API
-	url := fmt.Sprintf("https://api.exchangerate-api.com/v4/latest/%s", from)
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return "", fmt.Errorf("exchange rate request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("exchange API returned status %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read exchange response: %w", err)
-	}
-
-	return string(body), nil
-}
-
-type GrepTool struct {
-	manager *Manager
-}
-
-func (t *GrepTool) Name() string {
-	return "grep"
-}
-
-func (t *GrepTool) Description() string {
-	return "在工作目录中搜索文件内容。支持正则表达式。"
-}
-
-func (t *GrepTool) Parameters() map[string]interface{} {
-	return map[string]interface{}{
-		"type": "object",
-		"properties": map[string]interface{}{
-			"pattern": map[string]interface{}{
-				"type":        "string",
-				"description": "搜索模式（正则表达式）",
-			},
-			"path": map[string]interface{}{
-				"type":        "string",
-				"description": "搜索路径（默认为workDir）",
-			},
-			"include": map[string]interface{}{
-				"type":        "string",
-				"description": "文件匹配模式（如 *.go）",
-			},
-		},
-		"required": []string{"pattern"},
+	if ct != "" && !strings.HasPrefix(ct, "text/plain") {
+		return false
 	}
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed)
 }
 
-func (t *GrepTool) Execute(args map[string]interface{}) (string, error) {
-	pattern, ok := args["pattern"].(string)
-	if !ok || pattern == "" {
-		return "", fmt.Errorf("pattern is required")
-	}
-
-	searchPath := "."
-	if p, ok := args["path"].(string); ok && p != "" {
-		searchPath = p
-	}
-
-	include := "*"
-	if i, ok := args["include"].(string); ok && i != "" {
-		include = i
-	}
-
-	safePath, err := t.manager.sanitizePath(searchPath)
-	if err != nil {
-		return "", err
+// isBinaryResponse 根据Content-Type和内容判断是否为二进制数据
+func isBinaryResponse(contentType string, data []byte) bool {
+	if len(data) == 0 {
+		return false
 	}
-
-	// 编译正则表达式
-	re, err := regexp.Compile(pattern)
-	if err != nil {
-		return "", fmt.Errorf("invalid pattern: %w", err)
+	ct := strings.ToLower(contentType)
+	if strings.HasPrefix(ct, "text/") || strings.Contains(ct, "json") || strings.Contains(ct, "xml") || strings.Contains(ct, "javascript") {
+		return false
 	}
+	return !strings.HasPrefix(http.DetectContentType(data), "text/")
+}
 
-	var matches []string
-	var matchCount int
-
-	// 遍历目录
-	err = filepath.Walk(safePath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // 跳过错误
-		}
-
-		if info.IsDir() {
-			return nil
-		}
-
-		// 检查文件匹配模式
-		matched, _ := filepath.Match(include, filepath.Base(path))
-		if !matched {
-			return nil
-		}
-
-		// 跳过二进制文件和大文件
-		if info.Size() > 1024*1024 {
-			return nil
-		}
-
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return nil
-		}
-
-		lines := strings.Split(string(content), "\n")
-		for i, line := range lines {
-			if re.MatchString(line) {
-				relPath, _ := filepath.Rel(t.manager.workDir, path)
-				matches = append(matches, fmt.Sprintf("%s:%d: %s", relPath, i+1, strings.TrimSpace(line)))
-				matchCount++
-				if matchCount >= 50 { // 限制结果数量
-					return filepath.SkipAll
-				}
-			}
-		}
-
-		return nil
-	})
+const (
+	defaultHTTPMaxLength = 5000
+	maxHTTPMaxLength     = 20000
+	maxHTTPBodySize      = 5 * 1024 * 1024
+)
 
-	if err != nil && err != filepath.SkipAll {
-		return "", err
+// isHTMLResponse 根据Content-Type或内容判断是否为HTML
+func isHTMLResponse(contentType, body string) bool {
+	if contentType != "" {
+		return strings.Contains(strings.ToLower(contentType), "html")
 	}
-
-	if len(matches) == 0 {
-		return "No matches found", nil
+	head := strings.ToLower(strings.TrimSpace(body))
+	if len(head) > 512 {
+		head = head[:512]
 	}
-
-	return strings.Join(matches, "\n"), nil
+	return strings.HasPrefix(head, "<!doctype html") || strings.Contains(head, "<html")
 }
 
 // stripHTMLTags 去除HTML标签
@@ -1208,7 +1528,7 @@ func (t *MemoryReadTool) Name() string {
 }
 
 func (t *MemoryReadTool) Description() string {
-	return "读取长期记忆或每日笔记。用于回顾之前保存的信息。"
+	return "读取长期记忆、每日笔记或归档文件。用于回顾之前保存的信息。"
 }
 
 func (t *MemoryReadTool) Parameters() map[string]interface{} {
@@ -1217,13 +1537,17 @@ func (t *MemoryReadTool) Parameters() map[string]interface{} {
 		"properties": map[string]interface{}{
 			"type": map[string]interface{}{
 				"type":        "string",
-				"description": "记忆类型: 'longterm' 或 'daily'",
-				"enum":        []string{"longterm", "daily"},
+				"description": "记忆类型: 'longterm'、'daily' 或 'archive'（读取 memory_search 找到的归档文件）",
+				"enum":        []string{"longterm", "daily", "archive"},
 			},
 			"date": map[string]interface{}{
 				"type":        "string",
 				"description": "日期 (YYYY-MM-DD格式)，仅用于daily类型，默认为今天",
 			},
+			"file": map[string]interface{}{
+				"type":        "string",
+				"description": "归档文件（memory_search 结果中的路径），仅用于archive类型",
+			},
 		},
 		"required": []string{"type"},
 	}
@@ -1264,6 +1588,17 @@ func (t *MemoryReadTool) Execute(args map[string]interface{}) (string, error) {
 		}
 		return content, nil
 
+	case "archive":
+		file, _ := args["file"].(string)
+		if file == "" {
+			return "", fmt.Errorf("file is required")
+		}
+		content, err := t.manager.memoryMgr.ReadArchive(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read archive: %w", err)
+		}
+		return content, nil
+
 	default:
 		return "", fmt.Errorf("invalid memory type: %s", memType)
 	}
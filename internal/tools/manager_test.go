@@ -1,7 +1,18 @@
 package tools
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/policy"
 )
 
 func TestManager_Execute(t *testing.T) {
@@ -24,11 +35,17 @@ func TestIsDangerousCommand(t *testing.T) {
 		{"echo hello", false},
 	}
 
+	log, _ := logger.New(logger.Config{Level: "error"})
+	m, err := NewManager(Config{WorkDir: t.TempDir()}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	for _, tt := range tests {
 		t.Run(tt.cmd, func(t *testing.T) {
-			result := isDangerousCommand(tt.cmd)
+			result := m.evaluatePolicy("execute_command", tt.cmd).Action == policy.Confirm
 			if result != tt.expected {
-				t.Errorf("isDangerousCommand(%q) = %v, want %v", tt.cmd, result, tt.expected)
+				t.Errorf("dangerous(%q) = %v, want %v", tt.cmd, result, tt.expected)
 			}
 		})
 	}
@@ -85,3 +102,225 @@ func TestIsPrivateIP(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        interface{}
+		wantType    string
+		wantContent string
+	}{
+		{"nil", nil, "", ""},
+		{"json string", `{"a":1}`, "application/json", `{"a":1}`},
+		{"plain string", "hello", "text/plain; charset=utf-8", "hello"},
+		{"object", map[string]interface{}{"a": 1}, "application/json", `{"a":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ct, err := requestBody(tt.body)
+			if err != nil {
+				t.Fatalf("requestBody failed: %v", err)
+			}
+			if ct != tt.wantType {
+				t.Errorf("content type = %q, want %q", ct, tt.wantType)
+			}
+			var content string
+			if r != nil {
+				data, _ := io.ReadAll(r)
+				content = string(data)
+			}
+			if content != tt.wantContent {
+				t.Errorf("body = %q, want %q", content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestResponseTypeDetection(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	tests := []struct {
+		name        string
+		contentType string
+		data        []byte
+		binary      bool
+		json        bool
+	}{
+		{"json", "application/json", []byte(`{"a":1}`), false, true},
+		{"sniffed json", "", []byte(` [1, 2]`), false, true},
+		{"html", "text/html", []byte("<html></html>"), false, false},
+		{"png", "image/png", png, true, false},
+		{"unlabeled png", "", png, true, false},
+		{"octet-stream text", "application/octet-stream", []byte("plain text"), false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBinaryResponse(tt.contentType, tt.data); got != tt.binary {
+				t.Errorf("isBinaryResponse = %v, want %v", got, tt.binary)
+			}
+			if got := isJSONResponse(tt.contentType, tt.data); got != tt.json {
+				t.Errorf("isJSONResponse = %v, want %v", got, tt.json)
+			}
+		})
+	}
+}
+
+func TestReadLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.txt")
+	var content strings.Builder
+	for i := 1; i <= 450; i++ {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		start, end int
+		header     string
+		first      string
+		more       string
+		wantErr    bool
+	}{
+		{"range", 10, 12, "[lines 10-12 of 450]", "    10\tline 10", "[438 more lines, continue with start_line=13]", false},
+		{"default page", 0, 0, "[lines 1-200 of 450]", "     1\tline 1", "continue with start_line=201", false},
+		{"clamped end", 440, 1000, "[lines 440-450 of 450]", "   440\tline 440", "", false},
+		{"beyond end", 451, 0, "", "", "", true},
+		{"inverted", 20, 10, "", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := readLines(path, tt.start, tt.end)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readLines() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			lines := strings.Split(result, "\n")
+			if lines[0] != tt.header || lines[1] != tt.first {
+				t.Errorf("readLines() = %q...", strings.Join(lines[:2], "\n"))
+			}
+			if tt.more != "" && !strings.Contains(result, tt.more) {
+				t.Errorf("readLines() missing %q", tt.more)
+			}
+			if tt.more == "" && strings.Contains(result, "more lines") {
+				t.Errorf("readLines() should not report more lines")
+			}
+		})
+	}
+}
+
+func TestGetSystemInfo(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	m, err := NewManager(Config{WorkDir: t.TempDir()}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := (&GetSystemInfoTool{manager: m}).Execute(map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var info map[string]interface{}
+	if err := json.Unmarshal([]byte(result), &info); err != nil {
+		t.Fatalf("result is not JSON: %v\n%s", err, result)
+	}
+	if info["os"] != runtime.GOOS || info["work_dir"] != m.workDir {
+		t.Errorf("unexpected info: %s", result)
+	}
+	if runtime.GOOS == "linux" {
+		for _, key := range []string{"memory", "disks", "load_average", "uptime_seconds"} {
+			if _, ok := info[key]; !ok {
+				t.Errorf("missing %q in %s", key, result)
+			}
+		}
+	}
+}
+
+func TestSetEnabled(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	m, err := NewManager(Config{WorkDir: t.TempDir(), EnabledTools: map[string]bool{"delete_file": false}}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := m.Get("delete_file"); ok {
+		t.Fatal("delete_file should be disabled by config")
+	}
+	if err := m.SetEnabled("delete_file", true); err != nil {
+		t.Fatalf("enable: %v", err)
+	}
+	if _, ok := m.Get("delete_file"); !ok {
+		t.Error("delete_file should be enabled")
+	}
+
+	version := m.Version()
+	if err := m.SetEnabled("read_file", false); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+	if m.Version() == version {
+		t.Error("version should change when a tool is disabled")
+	}
+	if _, err := m.Execute(context.Background(), "read_file", map[string]interface{}{"path": "x"}); err == nil || !strings.Contains(err.Error(), "tool not found") {
+		t.Errorf("disabled tool executed: %v", err)
+	}
+	if disabled := m.Disabled(); len(disabled) != 1 || disabled[0].Name() != "read_file" {
+		t.Errorf("Disabled() = %v", disabled)
+	}
+	if err := m.SetEnabled("no_such_tool", true); err == nil {
+		t.Error("enabling an unknown tool should fail")
+	}
+}
+
+func TestApplyEnabled(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	m, err := NewManager(Config{WorkDir: t.TempDir(), EnabledTools: map[string]bool{"delete_file": false}}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defs := m.GetToolDefinitions()
+
+	// 配置热更新：重新启用 delete_file，禁用 read_file
+	m.ApplyEnabled(map[string]bool{"read_file": false})
+	if _, ok := m.Get("delete_file"); !ok {
+		t.Error("delete_file should be enabled after it was removed from enabledTools")
+	}
+	if _, ok := m.Get("read_file"); ok {
+		t.Error("read_file should be disabled")
+	}
+	if got := m.GetConfig().EnabledTools; len(got) != 1 || got["read_file"] {
+		t.Errorf("EnabledTools = %v", got)
+	}
+	updated := m.GetToolDefinitions()
+	if len(updated) != len(defs) {
+		t.Errorf("definitions = %d, want %d", len(updated), len(defs))
+	}
+	for _, d := range updated {
+		if d["function"].(map[string]interface{})["name"] == "read_file" {
+			t.Error("definitions still include read_file")
+		}
+	}
+
+	version := m.Version()
+	m.ApplyEnabled(map[string]bool{"read_file": false})
+	if m.Version() != version {
+		t.Error("version should not change when nothing was toggled")
+	}
+
+	if !m.Unregister("read_file") || m.Unregister("read_file") {
+		t.Error("Unregister should remove a disabled tool once")
+	}
+	if m.Version() == version {
+		t.Error("version should change after Unregister")
+	}
+	m.ApplyEnabled(nil)
+	if _, ok := m.Get("read_file"); ok {
+		t.Error("unregistered tool came back")
+	}
+}
@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/memory"
+)
+
+// MemorySearchTool 全文搜索记忆
+type MemorySearchTool struct {
+	manager *Manager
+}
+
+func (t *MemorySearchTool) Name() string {
+	return "memory_search"
+}
+
+func (t *MemorySearchTool) Description() string {
+	return "全文搜索每日笔记、长期记忆和归档，返回带上下文和行号的片段。支持多个关键词、用双引号括起的短语和日期范围。"
+}
+
+func (t *MemorySearchTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "关键词，空格分隔，任一出现即匹配；用双引号括起短语，如 \"backup script\" nginx",
+			},
+			"from": map[string]interface{}{
+				"type":        "string",
+				"description": "起始日期 (YYYY-MM-DD)，设置日期范围时只搜索每日笔记和归档",
+			},
+			"to": map[string]interface{}{
+				"type":        "string",
+				"description": "结束日期 (YYYY-MM-DD)",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "最多返回的片段数，默认20",
+			},
+		},
+		"required": []string{"query"},
+	}
+}
+
+func (t *MemorySearchTool) Execute(args map[string]interface{}) (string, error) {
+	if t.manager.memoryMgr == nil || !t.manager.memoryMgr.IsEnabled() {
+		return "", fmt.Errorf("memory feature is not enabled")
+	}
+
+	query, _ := args["query"].(string)
+	q := memory.SearchQuery{Terms: memory.ParseTerms(query)}
+	if len(q.Terms) == 0 {
+		return "", fmt.Errorf("query is required")
+	}
+	for _, key := range []string{"from", "to"} {
+		date, _ := args[key].(string)
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return "", fmt.Errorf("invalid %s date %q, use YYYY-MM-DD", key, date)
+		}
+		if key == "from" {
+			q.From = date
+		} else {
+			q.To = date
+		}
+	}
+	if limit, ok := args["limit"].(float64); ok {
+		q.Limit = int(limit)
+	}
+
+	results, err := t.manager.memoryMgr.SearchMemory(q)
+	if err != nil {
+		return "", fmt.Errorf("failed to search memory: %w", err)
+	}
+	if len(results) == 0 {
+		return fmt.Sprintf("No memory matches %s", query), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d result(s):\n", len(results))
+	for _, r := range results {
+		fmt.Fprintf(&sb, "\n[%s:%d] %s", r.File, r.Line, r.Kind)
+		if r.Date != "" {
+			fmt.Fprintf(&sb, " %s", r.Date)
+		}
+		fmt.Fprintf(&sb, " (matched: %s)\n%s\n", strings.Join(r.Matched, ", "), r.Snippet)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,592 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultPatchFuzz 默认允许忽略的首尾上下文行数
+const defaultPatchFuzz = 2
+
+// diffHunk 统一diff中的一个块
+type diffHunk struct {
+	oldStart int // 0 表示未知位置
+	lines    []string
+	noEOLOld bool
+	noEOLNew bool
+}
+
+// sides 返回块的旧内容和新内容，以及首尾上下文行数
+func (h *diffHunk) sides() (before, after []string, lead, trail int) {
+	for _, line := range h.lines {
+		switch line[0] {
+		case ' ':
+			before = append(before, line[1:])
+			after = append(after, line[1:])
+		case '-':
+			before = append(before, line[1:])
+		case '+':
+			after = append(after, line[1:])
+		}
+	}
+	for lead < len(h.lines) && h.lines[lead][0] == ' ' {
+		lead++
+	}
+	for trail < len(h.lines)-lead && h.lines[len(h.lines)-1-trail][0] == ' ' {
+		trail++
+	}
+	return before, after, lead, trail
+}
+
+// filePatch 单个文件的补丁
+type filePatch struct {
+	oldPath string
+	newPath string
+	hunks   []diffHunk
+}
+
+// target 返回补丁作用的文件路径
+func (p *filePatch) target() string {
+	if p.newPath != "" && p.newPath != "/dev/null" {
+		return p.newPath
+	}
+	return p.oldPath
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+// parseUnifiedDiff 解析统一diff，不依赖块头中的行数，兼容手写的补丁
+func parseUnifiedDiff(diff string) ([]*filePatch, error) {
+	lines := strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n")
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var patches []*filePatch
+	var current *filePatch
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isFileHeader(lines, i):
+			current = &filePatch{oldPath: diffPath(line[4:]), newPath: diffPath(lines[i+1][4:])}
+			patches = append(patches, current)
+			i += 2
+		case strings.HasPrefix(line, "@@"):
+			if current == nil {
+				current = &filePatch{}
+				patches = append(patches, current)
+			}
+			hunk := diffHunk{}
+			if m := hunkHeaderRe.FindStringSubmatch(line); m != nil {
+				hunk.oldStart, _ = strconv.Atoi(m[1])
+			}
+			i++
+			for ; i < len(lines); i++ {
+				l := lines[i]
+				if strings.HasPrefix(l, "@@") || strings.HasPrefix(l, "diff ") || isFileHeader(lines, i) {
+					break
+				}
+				if l == "" {
+					// 空行视为空白上下文行，但块末尾与下一个文件之间的空行除外
+					if !moreHunkLines(lines, i+1) {
+						break
+					}
+					l = " "
+				}
+				switch l[0] {
+				case ' ', '-', '+':
+					hunk.lines = append(hunk.lines, l)
+					continue
+				case '\\':
+					if len(hunk.lines) > 0 {
+						switch hunk.lines[len(hunk.lines)-1][0] {
+						case '-':
+							hunk.noEOLOld = true
+						case '+':
+							hunk.noEOLNew = true
+						default:
+							hunk.noEOLOld, hunk.noEOLNew = true, true
+						}
+					}
+					continue
+				}
+				break
+			}
+			if !hunkChanges(hunk) {
+				return nil, fmt.Errorf("hunk %d has no changes", len(current.hunks)+1)
+			}
+			current.hunks = append(current.hunks, hunk)
+		default:
+			// diff --git、index 等元信息行
+			i++
+		}
+	}
+
+	var result []*filePatch
+	for _, p := range patches {
+		if len(p.hunks) > 0 {
+			result = append(result, p)
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no hunks found in patch")
+	}
+	return result, nil
+}
+
+// moreHunkLines 判断从 i 开始跳过空行后是否仍是块内容
+func moreHunkLines(lines []string, i int) bool {
+	for ; i < len(lines); i++ {
+		if lines[i] == "" {
+			continue
+		}
+		if isFileHeader(lines, i) {
+			return false
+		}
+		switch lines[i][0] {
+		case ' ', '-', '+', '\\':
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+func isFileHeader(lines []string, i int) bool {
+	return strings.HasPrefix(lines[i], "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")
+}
+
+func hunkChanges(h diffHunk) bool {
+	for _, line := range h.lines {
+		if line[0] != ' ' {
+			return true
+		}
+	}
+	return false
+}
+
+// diffPath 去掉时间戳和 a/ b/ 前缀
+func diffPath(s string) string {
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSpace(s)
+	if s == "/dev/null" {
+		return s
+	}
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		s = s[2:]
+	}
+	return s
+}
+
+// hunkResult 块的应用结果
+type hunkResult struct {
+	line   int
+	offset int
+	fuzz   int
+	loose  bool
+}
+
+func (r hunkResult) String() string {
+	s := fmt.Sprintf("at line %d", r.line)
+	var notes []string
+	if r.offset != 0 {
+		notes = append(notes, fmt.Sprintf("offset %+d", r.offset))
+	}
+	if r.fuzz > 0 {
+		notes = append(notes, fmt.Sprintf("fuzz %d", r.fuzz))
+	}
+	if r.loose {
+		notes = append(notes, "ignoring whitespace")
+	}
+	if len(notes) > 0 {
+		s += " (" + strings.Join(notes, ", ") + ")"
+	}
+	return s
+}
+
+// applyHunks 按顺序应用各块，先精确匹配，再逐级放宽上下文和空白
+func applyHunks(content string, hunks []diffHunk, fuzz int) (string, []hunkResult, error) {
+	eol := "\n"
+	if strings.Contains(content, "\r\n") {
+		eol = "\r\n"
+	}
+	trailingEOL := content == "" || strings.HasSuffix(content, "\n")
+
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(strings.ReplaceAll(content, "\r\n", "\n"), "\n"), "\n")
+	}
+
+	var out []string
+	var results []hunkResult
+	pos, drift := 0, 0
+	for i := range hunks {
+		h := &hunks[i]
+		before, after, lead, trail := h.sides()
+
+		// base 为块在原文件中的预期起始位置，未知时从上一块之后开始找
+		base := pos - drift
+		if h.oldStart > 0 {
+			base = h.oldStart - 1
+			if len(before) == 0 {
+				// 纯新增块的起始行号指向插入点之前的一行
+				base = h.oldStart
+			}
+		}
+		expected := base + drift
+
+		var res hunkResult
+		ok := false
+		for _, loose := range []bool{false, true} {
+			for f := 0; f <= fuzz && !ok; f++ {
+				if f > lead && f > trail {
+					break
+				}
+				l, t := min(f, lead), min(f, trail)
+				o := before[l : len(before)-t]
+				if len(o) == 0 && len(before) > 0 {
+					break
+				}
+				at := findLines(lines, o, expected+l, pos, loose)
+				if at < 0 {
+					continue
+				}
+				out = append(out, lines[pos:at]...)
+				out = append(out, after[l:len(after)-t]...)
+				pos = at + len(o)
+				drift = at - l - base
+				res = hunkResult{line: at - l + 1, offset: drift, fuzz: f, loose: loose}
+				ok = true
+			}
+			if ok {
+				break
+			}
+		}
+		if !ok {
+			return "", nil, fmt.Errorf("hunk %d does not apply (expected near line %d)", i+1, expected+1)
+		}
+		results = append(results, res)
+
+		if h.noEOLNew {
+			trailingEOL = false
+		} else if h.noEOLOld {
+			trailingEOL = true
+		}
+	}
+	out = append(out, lines[pos:]...)
+
+	if len(out) == 0 {
+		return "", results, nil
+	}
+	result := strings.Join(out, eol)
+	if trailingEOL {
+		result += eol
+	}
+	return result, results, nil
+}
+
+// findLines 在 [from, len) 范围内查找离 target 最近的匹配位置
+func findLines(lines, block []string, target, from int, loose bool) int {
+	last := len(lines) - len(block)
+	if last < from {
+		return -1
+	}
+	if target < from {
+		target = from
+	}
+	if target > last {
+		target = last
+	}
+	if len(block) == 0 {
+		return target
+	}
+
+	match := func(at int) bool {
+		for i, want := range block {
+			got := lines[at+i]
+			if loose {
+				got, want = strings.Join(strings.Fields(got), " "), strings.Join(strings.Fields(want), " ")
+			}
+			if got != want {
+				return false
+			}
+		}
+		return true
+	}
+	for d := 0; target-d >= from || target+d <= last; d++ {
+		if target+d <= last && match(target+d) {
+			return target + d
+		}
+		if d > 0 && target-d >= from && match(target-d) {
+			return target - d
+		}
+	}
+	return -1
+}
+
+// ApplyPatchTool 应用代码补丁工具
+type ApplyPatchTool struct {
+	manager *Manager
+}
+
+func (t *ApplyPatchTool) Name() string {
+	return "apply_patch"
+}
+
+func (t *ApplyPatchTool) Description() string {
+	return "应用代码补丁到文件。支持统一diff格式（可多个块、多个文件，容忍行号偏移和少量上下文差异），也支持 old_string/new_string 精确替换。修改前自动备份为 .bak。"
+}
+
+func (t *ApplyPatchTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"workspace": t.manager.workspaceParam(),
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "要修改的文件路径，diff 中只有一个文件时覆盖 diff 头中的路径",
+			},
+			"patch": map[string]interface{}{
+				"type":        "string",
+				"description": "统一diff格式的补丁（--- / +++ / @@ 块）",
+			},
+			"old_string": map[string]interface{}{
+				"type":        "string",
+				"description": "不使用 patch 时，要被替换的旧字符串（必须精确匹配）",
+			},
+			"new_string": map[string]interface{}{
+				"type":        "string",
+				"description": "不使用 patch 时，用于替换的新字符串",
+			},
+			"fuzz": map[string]interface{}{
+				"type":        "integer",
+				"description": "允许忽略的首尾上下文行数，默认2",
+			},
+			"dry_run": map[string]interface{}{
+				"type":        "boolean",
+				"description": "只检查补丁能否应用，不修改文件",
+			},
+			"backup": map[string]interface{}{
+				"type":        "boolean",
+				"description": "是否备份原文件为 .bak，默认true",
+			},
+		},
+	}
+}
+
+// patchChange 待写入的文件修改
+type patchChange struct {
+	path    string
+	content string
+	existed bool
+	remove  bool
+	mode    os.FileMode
+	summary string
+}
+
+// prepare 按参数选择diff或字符串替换，在内存中计算出所有修改
+func (t *ApplyPatchTool) prepare(args map[string]interface{}) ([]patchChange, error) {
+	path, _ := args["path"].(string)
+	if patch, _ := args["patch"].(string); strings.TrimSpace(patch) != "" {
+		fuzz := defaultPatchFuzz
+		if f, ok := args["fuzz"].(float64); ok && f >= 0 {
+			fuzz = int(f)
+		}
+		return t.prepareDiff(args, patch, path, fuzz)
+	}
+	return t.prepareReplace(args, path)
+}
+
+// Plan 安全模式下展示每个文件修改前后的diff
+func (t *ApplyPatchTool) Plan(args map[string]interface{}) (string, error) {
+	changes, err := t.prepare(args)
+	if err != nil {
+		return "", err
+	}
+
+	var parts []string
+	for _, c := range changes {
+		rel := t.manager.relPath(c.path)
+		if c.remove {
+			parts = append(parts, "Delete "+rel)
+			continue
+		}
+		var before []byte
+		if c.existed {
+			if before, err = os.ReadFile(c.path); err != nil {
+				return "", fmt.Errorf("failed to read file: %w", err)
+			}
+		}
+		parts = append(parts, unifiedDiff(rel, string(before), c.content))
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+func (t *ApplyPatchTool) Execute(args map[string]interface{}) (string, error) {
+	dryRun, _ := args["dry_run"].(bool)
+	backup := true
+	if b, ok := args["backup"].(bool); ok {
+		backup = b
+	}
+
+	changes, err := t.prepare(args)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if dryRun {
+		sb.WriteString("Dry run, no files changed:\n")
+	}
+	for _, c := range changes {
+		sb.WriteString(c.summary + "\n")
+	}
+	if dryRun {
+		return strings.TrimRight(sb.String(), "\n"), nil
+	}
+
+	paths := make([]string, len(changes))
+	for i, c := range changes {
+		paths[i] = c.path
+	}
+	if err := t.manager.enforcePolicy(t.Name(), args, "", paths...); err != nil {
+		return "", err
+	}
+
+	for _, c := range changes {
+		if c.existed && backup {
+			original, err := os.ReadFile(c.path)
+			if err != nil {
+				return "", fmt.Errorf("failed to read file: %w", err)
+			}
+			if err := os.WriteFile(c.path+".bak", original, c.mode); err != nil {
+				return "", fmt.Errorf("failed to write backup: %w", err)
+			}
+		}
+		err := t.manager.history.Track(t.Name(), c.path, func() error {
+			if c.remove {
+				return os.Remove(c.path)
+			}
+			if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+				return err
+			}
+			return os.WriteFile(c.path, []byte(c.content), c.mode)
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to update %s: %w", t.manager.relPath(c.path), err)
+		}
+	}
+	if backup {
+		sb.WriteString("Originals backed up with .bak suffix\n")
+	}
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
+
+// prepareDiff 解析并在内存中应用diff，全部成功后才写入
+func (t *ApplyPatchTool) prepareDiff(args map[string]interface{}, patch, path string, fuzz int) ([]patchChange, error) {
+	files, err := parseUnifiedDiff(patch)
+	if err != nil {
+		return nil, err
+	}
+	if path != "" && len(files) > 1 {
+		return nil, fmt.Errorf("patch touches %d files, omit path to use the paths in the diff", len(files))
+	}
+
+	var changes []patchChange
+	for _, fp := range files {
+		target := fp.target()
+		if path != "" {
+			target = path
+		}
+		if target == "" || target == "/dev/null" {
+			return nil, fmt.Errorf("patch has no file path, set path")
+		}
+		safePath, err := t.manager.resolvePath(args, target, true)
+		if err != nil {
+			return nil, err
+		}
+
+		c := patchChange{path: safePath, mode: 0644}
+		var content string
+		if info, err := os.Stat(safePath); err == nil {
+			if fp.oldPath == "/dev/null" {
+				return nil, fmt.Errorf("%s already exists", target)
+			}
+			data, err := os.ReadFile(safePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read file: %w", err)
+			}
+			content, c.existed, c.mode = string(data), true, info.Mode().Perm()
+		} else if fp.oldPath != "/dev/null" {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+
+		var results []hunkResult
+		c.content, results, err = applyHunks(content, fp.hunks, fuzz)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", target, err)
+		}
+		c.remove = fp.newPath == "/dev/null"
+
+		switch {
+		case c.remove:
+			c.summary = fmt.Sprintf("%s: deleted", t.manager.relPath(safePath))
+		case !c.existed:
+			c.summary = fmt.Sprintf("%s: created", t.manager.relPath(safePath))
+		default:
+			c.summary = fmt.Sprintf("%s: %d hunk(s) applied", t.manager.relPath(safePath), len(results))
+		}
+		for i, r := range results {
+			c.summary += fmt.Sprintf("\n  hunk %d %s", i+1, r)
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// prepareReplace 兼容 old_string/new_string 的单次替换
+func (t *ApplyPatchTool) prepareReplace(args map[string]interface{}, path string) ([]patchChange, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	oldStr, ok := args["old_string"].(string)
+	if !ok {
+		return nil, fmt.Errorf("patch or old_string is required")
+	}
+	newStr, ok := args["new_string"].(string)
+	if !ok {
+		return nil, fmt.Errorf("new_string is required")
+	}
+
+	safePath, err := t.manager.resolvePath(args, path, true)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(safePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	content, err := os.ReadFile(safePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	oldContent := string(content)
+	if !strings.Contains(oldContent, oldStr) {
+		return nil, fmt.Errorf("old_string not found in file")
+	}
+
+	return []patchChange{{
+		path:    safePath,
+		content: strings.Replace(oldContent, oldStr, newStr, 1),
+		existed: true,
+		mode:    info.Mode().Perm(),
+		summary: fmt.Sprintf("Patch applied successfully to %s", safePath),
+	}}, nil
+}
@@ -0,0 +1,180 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestApplyHunks(t *testing.T) {
+	original := "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n\nfunc helper() {\n\treturn\n}\n"
+
+	tests := []struct {
+		name    string
+		content string
+		patch   string
+		fuzz    int
+		want    string
+		note    string
+		wantErr bool
+	}{
+		{
+			name:    "multi hunk",
+			content: original,
+			patch: `--- a/main.go
++++ b/main.go
+@@ -3,1 +3,1 @@
+-import "fmt"
++import "log"
+@@ -5,3 +5,3 @@
+ func main() {
+-	fmt.Println("hello")
++	log.Println("hello")
+ }
+`,
+			want: strings.NewReplacer(`"fmt"`, `"log"`, "fmt.Println", "log.Println").Replace(original),
+		},
+		{
+			name:    "offset",
+			content: "// header\n// header\n" + original,
+			patch:   "@@ -9,3 +9,3 @@\n func helper() {\n-\treturn\n+\treturn // done\n }\n",
+			want:    "// header\n// header\n" + strings.Replace(original, "\treturn\n", "\treturn // done\n", 1),
+			note:    "offset +2",
+		},
+		{
+			name:    "fuzz",
+			content: original,
+			patch:   "@@ -5,3 +5,3 @@\n func main() { // changed upstream\n-\tfmt.Println(\"hello\")\n+\tfmt.Println(\"bye\")\n }\n",
+			fuzz:    1,
+			want:    strings.Replace(original, "hello", "bye", 1),
+			note:    "fuzz 1",
+		},
+		{
+			name:    "fuzz disabled",
+			content: original,
+			patch:   "@@ -5,3 +5,3 @@\n func main() { // changed upstream\n-\tfmt.Println(\"hello\")\n+\tfmt.Println(\"bye\")\n }\n",
+			wantErr: true,
+		},
+		{
+			name:    "whitespace",
+			content: original,
+			patch:   "@@ -6 +6 @@\n-    fmt.Println(\"hello\")\n+\tfmt.Println(\"hi\")\n",
+			want:    strings.Replace(original, "hello", "hi", 1),
+			note:    "ignoring whitespace",
+		},
+		{
+			name:    "new file",
+			content: "",
+			patch:   "--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1,2 @@\n+one\n+two\n\\ No newline at end of file\n",
+			want:    "one\ntwo",
+		},
+		{
+			name:    "crlf",
+			content: "a\r\nb\r\nc\r\n",
+			patch:   "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+			want:    "a\r\nB\r\nc\r\n",
+		},
+		{
+			name:    "no match",
+			content: original,
+			patch:   "@@ -1 +1 @@\n-package other\n+package main\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := parseUnifiedDiff(tt.patch)
+			if err != nil {
+				t.Fatalf("parseUnifiedDiff() error = %v", err)
+			}
+			got, results, err := applyHunks(tt.content, files[0].hunks, tt.fuzz)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyHunks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("applyHunks() = %q, want %q", got, tt.want)
+			}
+			if tt.note != "" && !strings.Contains(results[len(results)-1].String(), tt.note) {
+				t.Errorf("result = %q, want note %q", results[len(results)-1], tt.note)
+			}
+		})
+	}
+}
+
+func TestApplyPatchTool(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	workDir := t.TempDir()
+	m, err := NewManager(Config{WorkDir: workDir}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := &ApplyPatchTool{manager: m}
+
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(workDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(name string) string {
+		data, _ := os.ReadFile(filepath.Join(workDir, name))
+		return string(data)
+	}
+	write("a.txt", "one\ntwo\nthree\n")
+	write("b.txt", "alpha\nbeta\n")
+
+	patch := `diff --git a/a.txt b/a.txt
+--- a/a.txt
++++ b/a.txt
+@@ -1,3 +1,3 @@
+ one
+-two
++2
+ three
+diff --git a/b.txt b/b.txt
+--- a/b.txt
++++ b/b.txt
+@@ -1,2 +1,2 @@
+-alpha
++ALPHA
+ beta
+`
+	result, err := tool.Execute(map[string]interface{}{"patch": patch, "dry_run": true})
+	if err != nil || !strings.Contains(result, "Dry run") || read("a.txt") != "one\ntwo\nthree\n" {
+		t.Fatalf("dry run = %q, %v", result, err)
+	}
+
+	if _, err := tool.Execute(map[string]interface{}{"patch": patch}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if read("a.txt") != "one\n2\nthree\n" || read("b.txt") != "ALPHA\nbeta\n" {
+		t.Errorf("patched = %q, %q", read("a.txt"), read("b.txt"))
+	}
+	if read("a.txt.bak") != "one\ntwo\nthree\n" {
+		t.Errorf("backup = %q", read("a.txt.bak"))
+	}
+
+	// 任一文件失败时不修改任何文件
+	bad := strings.Replace(patch, "-alpha", "-gamma", 1)
+	write("a.txt", "one\ntwo\nthree\n")
+	if _, err := tool.Execute(map[string]interface{}{"patch": bad}); err == nil {
+		t.Error("failing hunk should be rejected")
+	}
+	if read("a.txt") != "one\ntwo\nthree\n" {
+		t.Error("files should be untouched when any hunk fails")
+	}
+
+	// 兼容 old_string/new_string
+	if _, err := tool.Execute(map[string]interface{}{"path": "b.txt", "old_string": "beta", "new_string": "BETA", "backup": false}); err != nil {
+		t.Fatalf("replace error = %v", err)
+	}
+	if read("b.txt") != "ALPHA\nBETA\n" {
+		t.Errorf("replaced = %q", read("b.txt"))
+	}
+}
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/HaohanHe/mujibot/internal/confirmation"
+	"github.com/HaohanHe/mujibot/internal/policy"
+)
+
+// evaluatePolicy 按策略检查工具对命令或路径的操作
+func (m *Manager) evaluatePolicy(tool, command string, paths ...string) policy.Decision {
+	return m.policy.Evaluate(policy.Request{
+		Tool:    tool,
+		Command: command,
+		Paths:   paths,
+		Root:    m.workDir,
+	})
+}
+
+// enforcePolicy 执行策略决定：deny 直接拒绝；confirm 在开启危险操作确认时与终端工具一样通过确认管理器请求用户确认。
+// 模型传入的 confirm 参数不算确认，只有安全模式下用户同意的计划和网页上的人工确认可以跳过
+func (m *Manager) enforcePolicy(tool string, args map[string]interface{}, command string, paths ...string) error {
+	d := m.evaluatePolicy(tool, command, paths...)
+	switch d.Action {
+	case policy.Deny:
+		m.log.Warn("operation denied by policy", "tool", tool, "rule", d.Rule, "command", command, "paths", paths)
+		return fmt.Errorf("operation denied by policy rule %s", d.Rule)
+	case policy.Confirm:
+		if !m.confirmDangerous || m.unattendedMode || approvedArg(args) {
+			return nil
+		}
+		return m.requestConfirmation(contextArg(args), tool, command, paths, d)
+	}
+	return nil
+}
+
+// requestConfirmation 通过确认管理器请求用户确认策略要求确认的操作，确认请求发回调用者所在会话。
+// 命令附上影响摘要，风险取规则和影响中较高的一个
+func (m *Manager) requestConfirmation(ctx context.Context, tool, command string, paths []string, d policy.Decision) error {
+	if m.confirmMgr == nil {
+		return fmt.Errorf("操作匹配策略规则 %s，需要用户确认，但未配置确认渠道，操作未执行", d.Rule)
+	}
+	if c, ok := CallerFrom(ctx); ok {
+		ctx = confirmation.WithOrigin(ctx, confirmation.Origin{Channel: c.Channel, UserID: c.UserID, Target: c.Target})
+	}
+	operation, risk := strings.Join(paths, ", "), d.Risk
+	if command != "" {
+		summary := policy.Explain(command)
+		ctx = confirmation.WithEffects(ctx, summary.Effects)
+		operation, risk = command, policy.MaxRisk(d.Risk, summary.Risk)
+	}
+	approved, err := m.confirmMgr.RequestConfirmation(ctx, tool, operation, "policy: "+d.Rule, risk)
+	if err != nil {
+		return fmt.Errorf("confirmation failed: %w", err)
+	}
+	if !approved {
+		return fmt.Errorf("operation rejected by user")
+	}
+	return nil
+}
+
+// approvalArgKey 工具参数中“用户已确认”标记的保留键，值为 approval 类型，模型生成的JSON参数无法伪造
+const approvalArgKey = "\x00approved"
+
+// approval 用户已确认的标记
+type approval struct{}
+
+// WithApproval 复制参数并标记为已由用户确认，策略要求确认的操作不再发送确认请求。
+// 供网页等已经过人工确认的调用使用
+func WithApproval(args map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(args)+1)
+	for k, v := range args {
+		out[k] = v
+	}
+	out[approvalArgKey] = approval{}
+	return out
+}
+
+// approvedArg 参数是否带有用户已确认的标记
+func approvedArg(args map[string]interface{}) bool {
+	_, ok := args[approvalArgKey].(approval)
+	return ok
+}
+
+// Policy 返回生效的策略
+func (m *Manager) Policy() *policy.Policy {
+	return m.policy
+}
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/confirmation"
+	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/policy"
+)
+
+// decidingNotifier 记录确认请求并按 approve 立即批准或拒绝
+type decidingNotifier struct {
+	mgr     *confirmation.ConfirmationManager
+	approve bool
+	reqs    []confirmation.ConfirmationRequest
+}
+
+func (n *decidingNotifier) Name() string { return "test" }
+
+func (n *decidingNotifier) SendConfirmation(req *confirmation.ConfirmationRequest) error {
+	n.reqs = append(n.reqs, *req)
+	if n.approve {
+		return n.mgr.Approve(req.ID, "test")
+	}
+	return n.mgr.Reject(req.ID, "test")
+}
+
+func (n *decidingNotifier) NotifyResult(req *confirmation.ConfirmationRequest, approved bool) {}
+
+func TestPolicyEnforcedByTools(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	p, err := policy.New(append([]policy.Rule{
+		{Name: "secrets", Paths: []string{".env"}, Action: policy.Deny},
+		{Name: "no-curl", Tools: policy.CommandTools, Command: `\bcurl\b`, Action: policy.Deny},
+	}, policy.Defaults(nil)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(t.TempDir(), "config.json5")
+	if err := os.WriteFile(configPath, []byte(`{"llm": {"provider": "ollama"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.NewManager(configPath, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cfg.Close()
+	cm := confirmation.NewConfirmationManager(cfg, log)
+	notifier := &decidingNotifier{mgr: cm}
+	cm.RegisterNotifier(notifier)
+
+	workDir := t.TempDir()
+	m, err := NewManager(Config{WorkDir: workDir, ConfirmDangerous: true, Policy: p, ConfirmMgr: cm}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write, _ := m.Get("write_file")
+	if _, err := write.Execute(map[string]interface{}{"path": "app/.env", "content": "KEY=1"}); err == nil || !strings.Contains(err.Error(), "secrets") {
+		t.Errorf("write .env error = %v", err)
+	}
+	if _, err := write.Execute(map[string]interface{}{"path": "notes.txt", "content": "hi"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 需要确认的操作都发送确认请求给调用者，模型传入的 confirm=true 不算确认
+	ctx := WithCaller(context.Background(), Caller{Channel: "telegram", UserID: "42", Target: "42"})
+	if _, err := m.Execute(ctx, "delete_file", map[string]interface{}{"path": "notes.txt", "confirm": true}); err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Errorf("rejected delete error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "notes.txt")); err != nil {
+		t.Fatalf("file deleted without approval: %v", err)
+	}
+	if len(notifier.reqs) != 1 || notifier.reqs[0].UserID != "42" || notifier.reqs[0].Target != "42" || notifier.reqs[0].Type != "delete_file" {
+		t.Fatalf("confirmation requests = %+v", notifier.reqs)
+	}
+	notifier.approve = true
+	if _, err := m.Execute(ctx, "delete_file", map[string]interface{}{"path": "notes.txt"}); err != nil {
+		t.Errorf("approved delete: %v", err)
+	}
+	if _, err := m.Execute(ctx, "execute_command", map[string]interface{}{"command": "rm -rf build"}); err != nil {
+		t.Errorf("approved command: %v", err)
+	}
+	if len(notifier.reqs) != 3 || notifier.reqs[2].Operation != "rm -rf build" {
+		t.Errorf("confirmation requests = %+v", notifier.reqs)
+	}
+
+	// 未配置确认渠道时拒绝执行
+	noConfirm, err := NewManager(Config{WorkDir: workDir, ConfirmDangerous: true, Policy: p}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := noConfirm.Execute(ctx, "execute_command", map[string]interface{}{"command": "rm -rf build", "confirm": true}); err == nil || !strings.Contains(err.Error(), "未配置确认渠道") {
+		t.Errorf("dangerous command without confirmation channel error = %v", err)
+	}
+
+	cmd, _ := m.Get("execute_command")
+	if _, err := cmd.Execute(map[string]interface{}{"command": "curl example.com", "confirm": true}); err == nil || !strings.Contains(err.Error(), "no-curl") {
+		t.Errorf("denied command error = %v", err)
+	}
+}
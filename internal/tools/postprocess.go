@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 工具结果后处理步骤
+const (
+	PostTruncate   = "truncate"    // 只保留前 maxChars 个字符
+	PostJSONPretty = "json-pretty" // JSON 结果格式化
+	PostStripANSI  = "strip-ansi"  // 去掉终端转义序列和进度条覆盖
+	PostSummarize  = "summarize"   // 超过 maxChars 时用LLM压缩，完整输出保存到 ArtifactsDirName
+)
+
+const (
+	// defaultPostProcessMaxChars truncate 保留的字符数和 summarize 的触发长度
+	defaultPostProcessMaxChars = 2000
+	// maxSummaryInput 发送给摘要模型的最大字符数，超出时保留首尾
+	maxSummaryInput = 30000
+)
+
+// PostProcessConfig 单个工具的结果后处理配置
+type PostProcessConfig struct {
+	Steps    []string // 按顺序执行的步骤：truncate、json-pretty、strip-ansi、summarize
+	MaxChars int      // truncate 保留的字符数，summarize 只处理超过该长度的结果，默认2000
+}
+
+// Summarizer 压缩工具输出，如用便宜的模型总结命令输出
+type Summarizer func(ctx context.Context, tool, text string, maxChars int) (string, error)
+
+// SetSummarizer 设置 summarize 步骤使用的摘要函数，未设置时跳过该步骤
+func (m *Manager) SetSummarizer(fn Summarizer) {
+	m.summarizer = fn
+}
+
+// checkPostProcess 检查配置中的步骤名称，无效的步骤记录警告后忽略
+func (m *Manager) checkPostProcess() {
+	for name, cfg := range m.postProcess {
+		steps := cfg.Steps[:0:0]
+		for _, step := range cfg.Steps {
+			switch step {
+			case PostTruncate, PostJSONPretty, PostStripANSI, PostSummarize:
+				steps = append(steps, step)
+			default:
+				m.log.Warn("unknown tool post-processing step ignored", "tool", name, "step", step)
+			}
+		}
+		cfg.Steps = steps
+		m.postProcess[name] = cfg
+	}
+}
+
+// postProcessResult 按 tools.postProcess 配置依次处理工具结果，在结果长度限制之前执行
+func (m *Manager) postProcessResult(ctx context.Context, name string, args map[string]interface{}, result string) string {
+	cfg, ok := m.postProcess[name]
+	if !ok {
+		return result
+	}
+	maxChars := cfg.MaxChars
+	if maxChars <= 0 {
+		maxChars = defaultPostProcessMaxChars
+	}
+
+	for _, step := range cfg.Steps {
+		switch step {
+		case PostStripANSI:
+			result = cleanTerminalOutput(result)
+		case PostJSONPretty:
+			result = prettyJSON(result)
+		case PostTruncate:
+			result = truncateResult(result, maxChars)
+		case PostSummarize:
+			result = m.summarizeResult(ctx, name, args, result, maxChars)
+		}
+	}
+	return result
+}
+
+// prettyJSON 结果是 JSON 时缩进格式化，否则原样返回
+func prettyJSON(s string) string {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return s
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(trimmed), "", "  "); err != nil {
+		return s
+	}
+	return buf.String()
+}
+
+// truncateResult 只保留前 maxChars 个字符，尽量在换行处截断
+func truncateResult(s string, maxChars int) string {
+	runes := []rune(s)
+	if len(runes) <= maxChars {
+		return s
+	}
+	return cutAtLine(string(runes[:maxChars]), true) + fmt.Sprintf("\n[truncated: %d characters total]", len(runes))
+}
+
+// summarizeResult 结果超过 maxChars 时用摘要函数压缩，完整输出保存到文件；失败时返回原结果
+func (m *Manager) summarizeResult(ctx context.Context, name string, args map[string]interface{}, result string, maxChars int) string {
+	runes := []rune(result)
+	if m.summarizer == nil || len(runes) <= maxChars {
+		return result
+	}
+
+	input := result
+	if len(runes) > maxSummaryInput {
+		input = string(runes[:maxSummaryInput*2/3]) + "\n...\n" + string(runes[len(runes)-maxSummaryInput/3:])
+	}
+	summary, err := m.summarizer(ctx, name, input, maxChars)
+	summary = strings.TrimSpace(summary)
+	if err != nil || summary == "" {
+		m.log.Ctx(ctx).Warn("failed to summarize tool output", "tool", name, "chars", len(runes), "error", err)
+		return result
+	}
+
+	note := fmt.Sprintf("[summarized from %d characters", len(runes))
+	if path, err := m.saveArtifact(m.homeDir(callerArg(args)), name, result); err != nil {
+		m.log.Warn("failed to save tool output", "tool", name, "error", err)
+		note += "]"
+	} else {
+		note += fmt.Sprintf("; full output saved to %s, use read_file with start_line/end_line to read it]", m.relPath(path))
+	}
+	return summary + "\n" + note
+}
@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestPostProcess(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	workDir := t.TempDir()
+	m, err := NewManager(Config{
+		WorkDir: workDir,
+		PostProcess: map[string]PostProcessConfig{
+			"echo": {Steps: []string{"strip-ansi", "json-pretty", "bogus", "truncate"}, MaxChars: 20},
+		},
+	}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Register(echoTool{})
+	ctx := context.Background()
+
+	if steps := m.postProcess["echo"].Steps; len(steps) != 3 {
+		t.Errorf("steps = %v, want unknown step dropped", steps)
+	}
+
+	result, _ := m.Execute(ctx, "echo", map[string]interface{}{"text": "\x1b[31mred\x1b[0m"})
+	if result != "red" {
+		t.Errorf("strip-ansi result = %q", result)
+	}
+
+	result, _ = m.Execute(ctx, "echo", map[string]interface{}{"text": `{"a":1}`})
+	if result != "{\n  \"a\": 1\n}" {
+		t.Errorf("json-pretty result = %q", result)
+	}
+
+	result, _ = m.Execute(ctx, "echo", map[string]interface{}{"text": "line one\nline two\nline three\nline four"})
+	if !strings.HasPrefix(result, "line one\nline two\n[truncated: 38 characters total]") {
+		t.Errorf("truncate result = %q", result)
+	}
+}
+
+func TestPostProcessSummarize(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	workDir := t.TempDir()
+	m, err := NewManager(Config{
+		WorkDir:     workDir,
+		PostProcess: map[string]PostProcessConfig{"echo": {Steps: []string{"summarize"}, MaxChars: 50}},
+	}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Register(echoTool{})
+	ctx := context.Background()
+
+	// 未设置摘要函数时原样返回
+	long := strings.Repeat("building...\n", 20) + "error: missing file"
+	if result, _ := m.Execute(ctx, "echo", map[string]interface{}{"text": long}); result != long {
+		t.Errorf("result without summarizer = %q", result)
+	}
+
+	var calls int
+	m.SetSummarizer(func(ctx context.Context, tool, text string, maxChars int) (string, error) {
+		calls++
+		if tool != "echo" || maxChars != 50 {
+			t.Errorf("summarizer called with tool=%q maxChars=%d", tool, maxChars)
+		}
+		return "build failed: missing file", nil
+	})
+
+	if result, _ := m.Execute(ctx, "echo", map[string]interface{}{"text": "short"}); result != "short" || calls != 0 {
+		t.Errorf("short result = %q, calls = %d", result, calls)
+	}
+
+	result, _ := m.Execute(ctx, "echo", map[string]interface{}{"text": long})
+	if calls != 1 || !strings.HasPrefix(result, "build failed: missing file\n[summarized from 259 characters") {
+		t.Fatalf("summarized result = %q", result)
+	}
+	match := regexp.MustCompile(`saved to (\S+),`).FindStringSubmatch(result)
+	if match == nil {
+		t.Fatalf("no artifact path in %q", result)
+	}
+	data, err := os.ReadFile(filepath.Join(workDir, match[1]))
+	if err != nil || string(data) != long {
+		t.Errorf("artifact = %q, %v", data, err)
+	}
+
+	// 摘要失败时保留原结果
+	m.SetSummarizer(func(ctx context.Context, tool, text string, maxChars int) (string, error) {
+		return "", errors.New("provider down")
+	})
+	if result, _ := m.Execute(ctx, "echo", map[string]interface{}{"text": long}); result != long {
+		t.Errorf("result after failed summary = %q", result)
+	}
+}
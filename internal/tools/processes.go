@@ -0,0 +1,260 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/system"
+)
+
+const (
+	processSampleInterval = 500 * time.Millisecond
+	processCmdlineWidth   = 60
+)
+
+// ProcessesTool 查看和管理进程，进程信息由 system 包按平台读取
+type ProcessesTool struct {
+	manager *Manager
+
+	// 可在测试中替换
+	sample func() ([]system.Process, error)
+	read   func(pid int) (system.Process, error)
+	signal func(pid int, sig syscall.Signal) error
+}
+
+func NewProcessesTool(manager *Manager) *ProcessesTool {
+	return &ProcessesTool{
+		manager: manager,
+		sample: func() ([]system.Process, error) {
+			return system.SampleProcesses(processSampleInterval)
+		},
+		read: system.ReadProcess,
+		signal: func(pid int, sig syscall.Signal) error {
+			p, err := os.FindProcess(pid)
+			if err != nil {
+				return err
+			}
+			// Windows 不支持 SIGTERM，只能直接结束进程
+			if runtime.GOOS == "windows" {
+				return p.Kill()
+			}
+			return p.Signal(sig)
+		},
+	}
+}
+
+func (t *ProcessesTool) Name() string {
+	return "processes"
+}
+
+func (t *ProcessesTool) Description() string {
+	return "查看进程：按CPU或内存排序列出进程、查看某个PID的详情、结束进程（需要确认）。"
+}
+
+func (t *ProcessesTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "list(列出进程，默认), info(进程详情), kill(结束进程)",
+				"enum":        []string{"list", "info", "kill"},
+			},
+			"sort": map[string]interface{}{
+				"type":        "string",
+				"description": "list 的排序方式: cpu(默认) 或 memory",
+				"enum":        []string{"cpu", "memory"},
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "list 返回数量，默认15，最大100",
+			},
+			"filter": map[string]interface{}{
+				"type":        "string",
+				"description": "list 按进程名或命令行过滤（不区分大小写）",
+			},
+			"pid": map[string]interface{}{
+				"type":        "integer",
+				"description": "info/kill 的进程ID",
+			},
+			"signal": map[string]interface{}{
+				"type":        "string",
+				"description": "kill 使用的信号: TERM(默认) 或 KILL",
+				"enum":        []string{"TERM", "KILL"},
+			},
+			"confirm": map[string]interface{}{
+				"type":        "boolean",
+				"description": "确认结束进程",
+			},
+		},
+	}
+}
+
+func (t *ProcessesTool) Execute(args map[string]interface{}) (string, error) {
+	action, _ := args["action"].(string)
+	switch action {
+	case "", "list":
+		return t.list(args)
+	case "info":
+		pid, err := processPID(args)
+		if err != nil {
+			return "", err
+		}
+		p, err := t.read(pid)
+		if err != nil {
+			return "", err
+		}
+		return formatProcess(p), nil
+	case "kill":
+		return t.kill(args)
+	}
+	return "", fmt.Errorf("unknown action: %s", action)
+}
+
+func processPID(args map[string]interface{}) (int, error) {
+	pid, ok := args["pid"].(float64)
+	if !ok || pid <= 0 {
+		return 0, fmt.Errorf("pid is required")
+	}
+	return int(pid), nil
+}
+
+func (t *ProcessesTool) list(args map[string]interface{}) (string, error) {
+	procs, err := t.sample()
+	if err != nil {
+		return "", fmt.Errorf("failed to read processes: %w", err)
+	}
+
+	if filter, _ := args["filter"].(string); filter != "" {
+		filter = strings.ToLower(filter)
+		filtered := procs[:0]
+		for _, p := range procs {
+			if strings.Contains(strings.ToLower(p.Name), filter) || strings.Contains(strings.ToLower(p.Cmdline), filter) {
+				filtered = append(filtered, p)
+			}
+		}
+		procs = filtered
+	}
+	if len(procs) == 0 {
+		return "No processes found", nil
+	}
+
+	sortBy, _ := args["sort"].(string)
+	sort.SliceStable(procs, func(i, j int) bool {
+		if sortBy == "memory" {
+			return procs[i].RSSBytes > procs[j].RSSBytes
+		}
+		if procs[i].CPUPercent != procs[j].CPUPercent {
+			return procs[i].CPUPercent > procs[j].CPUPercent
+		}
+		return procs[i].RSSBytes > procs[j].RSSBytes
+	})
+
+	limit := 15
+	if n, ok := args["limit"].(float64); ok && n > 0 {
+		limit = int(n)
+		if limit > 100 {
+			limit = 100
+		}
+	}
+	total := len(procs)
+	if len(procs) > limit {
+		procs = procs[:limit]
+	}
+
+	var memTotal uint64
+	if mem, err := system.ReadMemInfo(); err == nil {
+		memTotal = mem.TotalBytes
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%7s %-10s %6s %9s %5s  %s\n", "PID", "USER", "CPU%", "RSS", "MEM%", "COMMAND")
+	for _, p := range procs {
+		memPercent := 0.0
+		if memTotal > 0 {
+			memPercent = float64(p.RSSBytes) / float64(memTotal) * 100
+		}
+		fmt.Fprintf(&sb, "%7d %-10s %6.1f %8.1fM %5.1f  %s\n",
+			p.PID, truncateString(p.User, 10), p.CPUPercent, float64(p.RSSBytes)/1024/1024, memPercent, processCommand(p))
+	}
+	fmt.Fprintf(&sb, "[%d of %d processes]", len(procs), total)
+	return sb.String(), nil
+}
+
+func (t *ProcessesTool) kill(args map[string]interface{}) (string, error) {
+	pid, err := processPID(args)
+	if err != nil {
+		return "", err
+	}
+	if pid == 1 || pid == os.Getpid() {
+		return "", fmt.Errorf("refusing to kill process %d", pid)
+	}
+
+	sig := syscall.SIGTERM
+	s, _ := args["signal"].(string)
+	switch strings.TrimPrefix(strings.ToUpper(s), "SIG") {
+	case "", "TERM":
+	case "KILL":
+		sig = syscall.SIGKILL
+	default:
+		return "", fmt.Errorf("unsupported signal: %s", s)
+	}
+
+	p, err := t.read(pid)
+	if err != nil {
+		return "", err
+	}
+
+	if t.manager.confirmDangerous && !t.manager.unattendedMode {
+		if confirmed, _ := args["confirm"].(bool); !confirmed {
+			return "", fmt.Errorf("结束进程 %d (%s) 需要确认。设置 confirm=true 来执行", pid, p.Name)
+		}
+	}
+
+	t.manager.log.Info("killing process", "pid", pid, "name", p.Name, "signal", sig.String())
+	if err := t.signal(pid, sig); err != nil {
+		return "", fmt.Errorf("failed to signal process %d: %w", pid, err)
+	}
+	return fmt.Sprintf("Sent %s to process %d (%s)", sig, pid, p.Name), nil
+}
+
+// processCommand 返回用于展示的命令行，内核线程没有命令行时显示 [name]
+func processCommand(p system.Process) string {
+	if p.Cmdline == "" {
+		return "[" + p.Name + "]"
+	}
+	return truncateString(p.Cmdline, processCmdlineWidth)
+}
+
+func truncateString(s string, max int) string {
+	if r := []rune(s); len(r) > max {
+		return string(r[:max-1]) + "…"
+	}
+	return s
+}
+
+func formatProcess(p system.Process) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "PID: %d\n", p.PID)
+	fmt.Fprintf(&sb, "Name: %s\n", p.Name)
+	fmt.Fprintf(&sb, "State: %s\n", p.State)
+	fmt.Fprintf(&sb, "Parent PID: %d\n", p.PPID)
+	fmt.Fprintf(&sb, "User: %s\n", p.User)
+	fmt.Fprintf(&sb, "Threads: %d\n", p.Threads)
+	fmt.Fprintf(&sb, "Memory: %.1f MB RSS, %.1f MB virtual\n", float64(p.RSSBytes)/1024/1024, float64(p.VSizeBytes)/1024/1024)
+	fmt.Fprintf(&sb, "CPU time: %s\n", p.CPUTime.Round(10*time.Millisecond))
+	if p.Uptime > 0 {
+		fmt.Fprintf(&sb, "Running for: %s\n", p.Uptime.Round(time.Second))
+	}
+	if p.Cmdline != "" {
+		fmt.Fprintf(&sb, "Command: %s", p.Cmdline)
+	} else {
+		fmt.Fprintf(&sb, "Command: [%s]", p.Name)
+	}
+	return sb.String()
+}
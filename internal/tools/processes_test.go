@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/system"
+)
+
+func TestProcessesTool(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	m, err := NewManager(Config{WorkDir: t.TempDir(), ConfirmDangerous: true}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	procs := []system.Process{
+		{PID: 10, Name: "nginx", User: "www", RSSBytes: 50 << 20, CPUPercent: 1.5, Cmdline: "nginx: worker process"},
+		{PID: 20, Name: "postgres", User: "postgres", RSSBytes: 300 << 20, CPUPercent: 0.2, Cmdline: "/usr/bin/postgres -D /data"},
+		{PID: 30, Name: "kworker/0:1", User: "root", CPUPercent: 12},
+	}
+	tool := NewProcessesTool(m)
+	tool.sample = func() ([]system.Process, error) {
+		return append([]system.Process(nil), procs...), nil
+	}
+	tool.read = func(pid int) (system.Process, error) {
+		for _, p := range procs {
+			if p.PID == pid {
+				return p, nil
+			}
+		}
+		return system.Process{}, fmt.Errorf("process %d not found", pid)
+	}
+	var signaled []string
+	tool.signal = func(pid int, sig syscall.Signal) error {
+		signaled = append(signaled, fmt.Sprintf("%d:%d", pid, sig))
+		return nil
+	}
+
+	pidOrder := func(result string) []string {
+		var pids []string
+		for _, line := range strings.Split(result, "\n")[1:] {
+			if fields := strings.Fields(line); len(fields) > 0 && !strings.HasPrefix(line, "[") {
+				pids = append(pids, fields[0])
+			}
+		}
+		return pids
+	}
+
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{}, "30,10,20"},
+		{map[string]interface{}{"sort": "memory"}, "20,10,30"},
+		{map[string]interface{}{"sort": "memory", "limit": float64(1)}, "20"},
+		{map[string]interface{}{"filter": "WORKER"}, "30,10"},
+	}
+	for _, tt := range tests {
+		result, err := tool.Execute(tt.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(pidOrder(result), ","); got != tt.want {
+			t.Errorf("list %v order = %s, want %s\n%s", tt.args, got, tt.want, result)
+		}
+	}
+
+	result, _ := tool.Execute(map[string]interface{}{})
+	if !strings.Contains(result, "[kworker/0:1]") || !strings.Contains(result, "[3 of 3 processes]") {
+		t.Errorf("list = %q", result)
+	}
+
+	result, err = tool.Execute(map[string]interface{}{"action": "info", "pid": float64(20)})
+	if err != nil || !strings.Contains(result, "Command: /usr/bin/postgres -D /data") || !strings.Contains(result, "300.0 MB RSS") {
+		t.Errorf("info = %q, %v", result, err)
+	}
+
+	errTests := []struct {
+		args map[string]interface{}
+		err  string
+	}{
+		{map[string]interface{}{"action": "kill", "pid": float64(10)}, "confirm=true"},
+		{map[string]interface{}{"action": "kill", "pid": float64(1), "confirm": true}, "refusing"},
+		{map[string]interface{}{"action": "kill", "pid": float64(10), "signal": "HUP", "confirm": true}, "unsupported signal"},
+		{map[string]interface{}{"action": "kill", "pid": float64(99), "confirm": true}, "not found"},
+		{map[string]interface{}{"action": "info"}, "pid is required"},
+	}
+	for _, tt := range errTests {
+		if _, err := tool.Execute(tt.args); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Execute(%v) error = %v, want %q", tt.args, err, tt.err)
+		}
+	}
+	if len(signaled) != 0 {
+		t.Fatalf("unexpected signals: %v", signaled)
+	}
+
+	result, err = tool.Execute(map[string]interface{}{"action": "kill", "pid": float64(10), "signal": "SIGKILL", "confirm": true})
+	if err != nil || !strings.Contains(result, "process 10 (nginx)") {
+		t.Errorf("kill = %q, %v", result, err)
+	}
+	if len(signaled) != 1 || signaled[0] != fmt.Sprintf("10:%d", syscall.SIGKILL) {
+		t.Errorf("signals = %v", signaled)
+	}
+}
@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+// ErrQuotaExceeded 工具调用超出配额
+var ErrQuotaExceeded = errors.New("tool quota exceeded")
+
+// QuotaConfig 工具调用配额，0 表示不限制
+type QuotaConfig struct {
+	MaxCallsPerMessage    int
+	MaxCommandsPerHour    int
+	MaxHTTPRequestsPerDay int
+}
+
+// outboundTools 会向外部发起HTTP请求的工具
+var outboundTools = map[string]bool{
+	"web_search":    true,
+	"http_request":  true,
+	"read_feed":     true,
+	"download_file": true,
+	"weather":       true,
+	"ip_info":       true,
+	"exchange_rate": true,
+	"quotes":        true,
+	"calendar_list": true,
+	"calendar_add":  true,
+	"homeassistant": true,
+}
+
+// quotaTracker 按消息和用户统计工具调用次数
+type quotaTracker struct {
+	cfg QuotaConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	messages map[string]*messageCalls // 请求ID -> 本条消息的调用次数
+	commands map[string][]time.Time   // 用户 -> execute_command 调用时间
+	requests map[string][]time.Time   // 用户 -> 外部HTTP请求时间
+}
+
+type messageCalls struct {
+	count int
+	start time.Time
+}
+
+func newQuotaTracker(cfg QuotaConfig) *quotaTracker {
+	return &quotaTracker{
+		cfg:      cfg,
+		now:      time.Now,
+		messages: make(map[string]*messageCalls),
+		commands: make(map[string][]time.Time),
+		requests: make(map[string][]time.Time),
+	}
+}
+
+// quotaUser 配额统计的用户键，没有调用者（Web、定时任务）时共用一个键
+func quotaUser(ctx context.Context) string {
+	if c, ok := CallerFrom(ctx); ok {
+		return c.Channel + ":" + c.UserID
+	}
+	return "local"
+}
+
+// allow 检查并记录一次工具调用，超出配额时返回 ErrQuotaExceeded
+func (q *quotaTracker) allow(ctx context.Context, name string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	user := quotaUser(ctx)
+
+	var msg *messageCalls
+	if id := logger.RequestID(ctx); id != "" && q.cfg.MaxCallsPerMessage > 0 {
+		q.pruneMessages(now)
+		msg = q.messages[id]
+		if msg == nil {
+			msg = &messageCalls{start: now}
+			q.messages[id] = msg
+		}
+		if msg.count >= q.cfg.MaxCallsPerMessage {
+			return fmt.Errorf("%w: at most %d tool calls per message, answer with the information gathered so far",
+				ErrQuotaExceeded, q.cfg.MaxCallsPerMessage)
+		}
+	}
+
+	var window map[string][]time.Time
+	var limit int
+	var period time.Duration
+	var what string
+	switch {
+	case name == "execute_command" && q.cfg.MaxCommandsPerHour > 0:
+		window, limit, period, what = q.commands, q.cfg.MaxCommandsPerHour, time.Hour, "execute_command calls per hour"
+	case outboundTools[name] && q.cfg.MaxHTTPRequestsPerDay > 0:
+		window, limit, period, what = q.requests, q.cfg.MaxHTTPRequestsPerDay, 24*time.Hour, "outbound HTTP requests per day"
+	}
+
+	if window != nil {
+		times := window[user]
+		cutoff := now.Add(-period)
+		for len(times) > 0 && !times[0].After(cutoff) {
+			times = times[1:]
+		}
+		if len(times) >= limit {
+			window[user] = times
+			retry := times[0].Add(period).Sub(now).Round(time.Minute)
+			return fmt.Errorf("%w: at most %d %s, try again in %v", ErrQuotaExceeded, limit, what, retry)
+		}
+		window[user] = append(times, now)
+	}
+
+	if msg != nil {
+		msg.count++
+	}
+	return nil
+}
+
+// pruneMessages 清理一小时前开始的消息计数
+func (q *quotaTracker) pruneMessages(now time.Time) {
+	for id, msg := range q.messages {
+		if now.Sub(msg.start) > time.Hour {
+			delete(q.messages, id)
+		}
+	}
+}
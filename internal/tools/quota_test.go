@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestQuotaTracker(t *testing.T) {
+	q := newQuotaTracker(QuotaConfig{MaxCallsPerMessage: 3, MaxCommandsPerHour: 2, MaxHTTPRequestsPerDay: 1})
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+
+	alice := WithCaller(context.Background(), Caller{Channel: "telegram", UserID: "alice"})
+	bob := WithCaller(context.Background(), Caller{Channel: "telegram", UserID: "bob"})
+	msg1 := logger.WithRequestID(alice, "msg1")
+	msg2 := logger.WithRequestID(alice, "msg2")
+
+	steps := []struct {
+		ctx  context.Context
+		tool string
+		err  string
+	}{
+		{msg1, "read_file", ""},
+		{msg1, "execute_command", ""},
+		{msg1, "execute_command", ""},
+		{msg1, "read_file", "3 tool calls per message"},
+		{msg2, "execute_command", "2 execute_command calls per hour, try again in 1h0m0s"},
+		{msg2, "web_search", ""},
+		{msg2, "weather", "1 outbound HTTP requests per day"},
+		{bob, "execute_command", ""},
+		{bob, "http_request", ""},
+	}
+	for i, s := range steps {
+		err := q.allow(s.ctx, s.tool)
+		if s.err == "" {
+			if err != nil {
+				t.Errorf("step %d: %s error = %v", i, s.tool, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrQuotaExceeded) || !strings.Contains(err.Error(), s.err) {
+			t.Errorf("step %d: %s error = %v, want %q", i, s.tool, err, s.err)
+		}
+	}
+
+	// 超出配额的调用不计数，窗口滑过后恢复
+	now = now.Add(time.Hour)
+	if err := q.allow(msg2, "execute_command"); err != nil {
+		t.Errorf("execute_command after an hour: %v", err)
+	}
+	if err := q.allow(msg2, "http_request"); err == nil {
+		t.Error("http_request should still be limited within the day")
+	}
+	now = now.Add(23 * time.Hour)
+	if err := q.allow(logger.WithRequestID(alice, "msg3"), "http_request"); err != nil {
+		t.Errorf("http_request after a day: %v", err)
+	}
+	if len(q.messages) != 1 {
+		t.Errorf("old message counters not pruned: %d", len(q.messages))
+	}
+}
+
+func TestExecuteQuota(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	m, err := NewManager(Config{WorkDir: t.TempDir(), Quotas: QuotaConfig{MaxCallsPerMessage: 1}}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := logger.WithRequestID(context.Background(), "req")
+	if _, err := m.Execute(ctx, "get_system_info", map[string]interface{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Execute(ctx, "get_system_info", map[string]interface{}{}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("second call error = %v", err)
+	}
+	// 没有请求ID时不限制每条消息的调用次数
+	if _, err := m.Execute(context.Background(), "get_system_info", map[string]interface{}{}); err != nil {
+		t.Errorf("call without request ID: %v", err)
+	}
+}
@@ -0,0 +1,464 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/httpclient"
+)
+
+// 行情数据源
+const (
+	QuoteProviderYahoo     = "yahoo"     // Yahoo Finance，股票、指数、外汇和加密货币（如 BTC-USD）
+	QuoteProviderCoinGecko = "coingecko" // CoinGecko，加密货币
+)
+
+const (
+	yahooChartURL   = "https://query1.finance.yahoo.com/v8/finance/chart"
+	coinGeckoAPIURL = "https://api.coingecko.com/api/v3"
+
+	// maxSparklinePoints 走势图最多显示的点数
+	maxSparklinePoints = 24
+)
+
+var errQuoteNotFound = errors.New("not found")
+
+// quoteRanges 走势图的时间范围及对应的天数
+var quoteRanges = map[string]int{"5d": 5, "1mo": 30, "3mo": 90, "1y": 365}
+
+// coinGeckoIDs 常见加密货币代码对应的 CoinGecko ID，其他代码按ID查询
+var coinGeckoIDs = map[string]string{
+	"btc":  "bitcoin",
+	"eth":  "ethereum",
+	"usdt": "tether",
+	"bnb":  "binancecoin",
+	"sol":  "solana",
+	"xrp":  "ripple",
+	"usdc": "usd-coin",
+	"ada":  "cardano",
+	"doge": "dogecoin",
+	"trx":  "tron",
+	"dot":  "polkadot",
+	"ltc":  "litecoin",
+}
+
+// QuotesTool 股票和加密货币行情工具，返回当前价格、日涨跌和文本走势图。
+// 结果按参数缓存，每个数据源每分钟的请求数有上限
+type QuotesTool struct {
+	manager  *Manager
+	settings QuotesSettings
+
+	// 测试时可替换的接口地址
+	yahooURL     string
+	coinGeckoURL string
+
+	mu       sync.Mutex
+	cache    map[string]quoteCacheEntry
+	requests map[string][]time.Time // 数据源 -> 最近一分钟的请求时间
+}
+
+type quoteCacheEntry struct {
+	result  string
+	expires time.Time
+}
+
+// quote 从数据源整理出的行情
+type quote struct {
+	Symbol        string
+	Name          string
+	Exchange      string
+	Currency      string
+	Price         float64
+	PreviousClose float64 // 一天前的价格，用于计算日涨跌
+	Time          time.Time
+	History       []float64
+}
+
+// NewQuotesTool 创建行情工具
+func NewQuotesTool(m *Manager, settings QuotesSettings) *QuotesTool {
+	return &QuotesTool{
+		manager:  m,
+		settings: settings,
+		cache:    make(map[string]quoteCacheEntry),
+		requests: make(map[string][]time.Time),
+	}
+}
+
+func (t *QuotesTool) Name() string {
+	return "quotes"
+}
+
+func (t *QuotesTool) Description() string {
+	return "查询股票、指数或加密货币的行情：当前价格、日涨跌和一段时间的文本走势图。"
+}
+
+func (t *QuotesTool) Parameters() map[string]interface{} {
+	providers := t.settings.providers()
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"symbol": map[string]interface{}{
+				"type":        "string",
+				"description": "代码。yahoo 如 AAPL、0700.HK、600519.SS、^GSPC、BTC-USD；coingecko 如 BTC、ETH 或 CoinGecko ID（如 bitcoin）",
+			},
+			"provider": map[string]interface{}{
+				"type":        "string",
+				"enum":        providers,
+				"description": "数据源，默认 " + providers[0],
+			},
+			"range": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"5d", "1mo", "3mo", "1y"},
+				"description": "走势图时间范围，默认 1mo",
+			},
+			"currency": map[string]interface{}{
+				"type":        "string",
+				"description": "计价货币，仅 coingecko 使用，默认 USD",
+			},
+		},
+		"required": []string{"symbol"},
+	}
+}
+
+func (t *QuotesTool) Execute(args map[string]interface{}) (string, error) {
+	symbol, _ := args["symbol"].(string)
+	symbol = strings.TrimSpace(symbol)
+	if symbol == "" {
+		return "", fmt.Errorf("symbol is required")
+	}
+
+	providers := t.settings.providers()
+	provider, _ := args["provider"].(string)
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		provider = providers[0]
+	}
+	allowed := false
+	for _, p := range providers {
+		allowed = allowed || p == provider
+	}
+	if !allowed {
+		return "", fmt.Errorf("provider %q is not enabled, use one of: %s", provider, strings.Join(providers, ", "))
+	}
+
+	rng, _ := args["range"].(string)
+	if rng == "" {
+		rng = "1mo"
+	}
+	if _, ok := quoteRanges[rng]; !ok {
+		return "", fmt.Errorf("invalid range %q, use 5d, 1mo, 3mo or 1y", rng)
+	}
+
+	currency, _ := args["currency"].(string)
+	currency = strings.ToLower(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = "usd"
+	}
+
+	key := strings.ToLower(strings.Join([]string{provider, symbol, rng, currency}, "|"))
+	if result, ok := t.cached(key); ok {
+		return result, nil
+	}
+	if err := t.allow(provider); err != nil {
+		return "", err
+	}
+
+	var q *quote
+	var err error
+	switch provider {
+	case QuoteProviderYahoo:
+		q, err = t.yahoo(symbol, rng)
+	case QuoteProviderCoinGecko:
+		q, err = t.coinGecko(symbol, rng, currency)
+	default:
+		return "", fmt.Errorf("unknown provider: %s", provider)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	result := q.summary(rng)
+	t.store(key, result)
+	return result, nil
+}
+
+// allow 检查数据源最近一分钟的请求数，未超过上限时记录本次请求
+func (t *QuotesTool) allow(provider string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	recent := t.requests[provider][:0]
+	for _, at := range t.requests[provider] {
+		if now.Sub(at) < time.Minute {
+			recent = append(recent, at)
+		}
+	}
+	if len(recent) >= t.settings.maxRequestsPerMinute() {
+		t.requests[provider] = recent
+		wait := time.Minute - now.Sub(recent[0])
+		return fmt.Errorf("%s rate limit reached (%d requests per minute), try again in %ds", provider, t.settings.maxRequestsPerMinute(), int(math.Ceil(wait.Seconds())))
+	}
+	t.requests[provider] = append(recent, now)
+	return nil
+}
+
+// cached 读取未过期的缓存
+func (t *QuotesTool) cached(key string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.cache[key]
+	if !ok || time.Now().After(entry.expires) {
+		return "", false
+	}
+	return entry.result, true
+}
+
+// store 写入缓存并清理过期项
+func (t *QuotesTool) store(key, result string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for k, e := range t.cache {
+		if now.After(e.expires) {
+			delete(t.cache, k)
+		}
+	}
+	t.cache[key] = quoteCacheEntry{result: result, expires: now.Add(t.settings.cacheTTL())}
+}
+
+// yahoo 查询 Yahoo Finance 日K数据，日涨跌相对前一个交易日的收盘价
+func (t *QuotesTool) yahoo(symbol, rng string) (*quote, error) {
+	u := fmt.Sprintf("%s/%s?range=%s&interval=1d", t.endpoint(t.yahooURL, yahooChartURL), url.PathEscape(strings.ToUpper(symbol)), rng)
+
+	var data struct {
+		Chart struct {
+			Result []struct {
+				Meta struct {
+					Symbol             string  `json:"symbol"`
+					Currency           string  `json:"currency"`
+					ExchangeName       string  `json:"exchangeName"`
+					LongName           string  `json:"longName"`
+					ShortName          string  `json:"shortName"`
+					RegularMarketPrice float64 `json:"regularMarketPrice"`
+					RegularMarketTime  int64   `json:"regularMarketTime"`
+					ChartPreviousClose float64 `json:"chartPreviousClose"`
+				} `json:"meta"`
+				Indicators struct {
+					Quote []struct {
+						Close []*float64 `json:"close"`
+					} `json:"quote"`
+				} `json:"indicators"`
+			} `json:"result"`
+			Error *struct {
+				Code        string `json:"code"`
+				Description string `json:"description"`
+			} `json:"error"`
+		} `json:"chart"`
+	}
+	if err := t.getJSON(u, &data); err == errQuoteNotFound {
+		return nil, fmt.Errorf("symbol not found: %s", symbol)
+	} else if err != nil {
+		return nil, fmt.Errorf("quote request failed: %w", err)
+	}
+	if e := data.Chart.Error; e != nil {
+		return nil, fmt.Errorf("quote request failed: %s", e.Description)
+	}
+	if len(data.Chart.Result) == 0 {
+		return nil, fmt.Errorf("symbol not found: %s", symbol)
+	}
+
+	r := data.Chart.Result[0]
+	q := &quote{
+		Symbol:   r.Meta.Symbol,
+		Name:     r.Meta.LongName,
+		Exchange: r.Meta.ExchangeName,
+		Currency: r.Meta.Currency,
+		Price:    r.Meta.RegularMarketPrice,
+		Time:     time.Unix(r.Meta.RegularMarketTime, 0),
+	}
+	if q.Name == "" {
+		q.Name = r.Meta.ShortName
+	}
+	if len(r.Indicators.Quote) > 0 {
+		// 停牌等没有数据的交易日为 null
+		for _, c := range r.Indicators.Quote[0].Close {
+			if c != nil {
+				q.History = append(q.History, *c)
+			}
+		}
+	}
+	switch n := len(q.History); {
+	case n >= 2:
+		q.PreviousClose = q.History[n-2]
+	default:
+		q.PreviousClose = r.Meta.ChartPreviousClose
+	}
+	if q.Price == 0 && len(q.History) > 0 {
+		q.Price = q.History[len(q.History)-1]
+	}
+	return q, nil
+}
+
+// coinGecko 查询 CoinGecko 价格历史，日涨跌相对24小时前的价格
+func (t *QuotesTool) coinGecko(symbol, rng, currency string) (*quote, error) {
+	id := strings.ToLower(symbol)
+	if mapped, ok := coinGeckoIDs[id]; ok {
+		id = mapped
+	}
+	params := url.Values{}
+	params.Set("vs_currency", currency)
+	params.Set("days", fmt.Sprint(quoteRanges[rng]))
+	u := fmt.Sprintf("%s/coins/%s/market_chart?%s", t.endpoint(t.coinGeckoURL, coinGeckoAPIURL), url.PathEscape(id), params.Encode())
+
+	var data struct {
+		Prices [][2]float64 `json:"prices"`
+	}
+	if err := t.getJSON(u, &data); err == errQuoteNotFound {
+		return nil, fmt.Errorf("coin not found: %s (use a CoinGecko ID such as bitcoin)", symbol)
+	} else if err != nil {
+		return nil, fmt.Errorf("quote request failed: %w", err)
+	}
+	if len(data.Prices) == 0 {
+		return nil, fmt.Errorf("no price data for %s (%s)", symbol, currency)
+	}
+
+	last := data.Prices[len(data.Prices)-1]
+	q := &quote{
+		Symbol:        strings.ToUpper(symbol),
+		Name:          id,
+		Exchange:      "CoinGecko",
+		Currency:      strings.ToUpper(currency),
+		Price:         last[1],
+		PreviousClose: data.Prices[0][1],
+		Time:          time.UnixMilli(int64(last[0])),
+	}
+	dayAgo := last[0] - float64(24*time.Hour/time.Millisecond)
+	for _, p := range data.Prices {
+		if p[0] >= dayAgo {
+			q.PreviousClose = p[1]
+			break
+		}
+	}
+	for _, p := range data.Prices {
+		q.History = append(q.History, p[1])
+	}
+	return q, nil
+}
+
+// getJSON 发送带浏览器 User-Agent 的GET请求并解析JSON，Yahoo 会拒绝默认的 User-Agent
+func (t *QuotesTool) getJSON(u string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Mujibot/1.0)")
+	req.Header.Set("Accept", "application/json")
+
+	client := httpclient.New(10 * time.Second)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 2*1024*1024))
+	if err != nil {
+		return err
+	}
+	// 两个数据源查询不存在的代码时都返回 404
+	if resp.StatusCode == http.StatusNotFound {
+		return errQuoteNotFound
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("upstream rate limit reached, try again later")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(truncateBytes(body, 512))))
+	}
+	return json.Unmarshal(body, v)
+}
+
+func (t *QuotesTool) endpoint(override, fallback string) string {
+	if override != "" {
+		return override
+	}
+	return fallback
+}
+
+// summary 整理为几行文本：价格、日涨跌、走势图和时间
+func (q *quote) summary(rng string) string {
+	var sb strings.Builder
+	sb.WriteString(q.Symbol)
+	if desc := joinNonEmpty(", ", q.Name, q.Exchange); desc != "" {
+		sb.WriteString(" (" + desc + ")")
+	}
+	fmt.Fprintf(&sb, ": %s %s", formatDecimal(q.Price), q.Currency)
+
+	if q.PreviousClose > 0 {
+		change := q.Price - q.PreviousClose
+		sign := "+"
+		if change < 0 {
+			sign = "-"
+		}
+		fmt.Fprintf(&sb, "\nDay change: %s%s (%s%.2f%%)", sign, formatDecimal(math.Abs(change)), sign, math.Abs(change/q.PreviousClose*100))
+	}
+
+	if len(q.History) >= 2 {
+		low, high := q.History[0], q.History[0]
+		for _, v := range q.History {
+			low = math.Min(low, v)
+			high = math.Max(high, v)
+		}
+		fmt.Fprintf(&sb, "\n%s: %s (low %s, high %s)", rng, sparkline(q.History, maxSparklinePoints), formatDecimal(low), formatDecimal(high))
+	}
+	if !q.Time.IsZero() {
+		sb.WriteString("\nAs of " + q.Time.UTC().Format("2006-01-02 15:04 UTC"))
+	}
+	return sb.String()
+}
+
+// sparkline 把数值序列降采样到最多 maxPoints 个点，用方块字符画出走势
+func sparkline(values []float64, maxPoints int) string {
+	if len(values) > maxPoints {
+		sampled := make([]float64, maxPoints)
+		for i := range sampled {
+			sampled[i] = values[i*(len(values)-1)/(maxPoints-1)]
+		}
+		values = sampled
+	}
+
+	ticks := []rune("▁▂▃▄▅▆▇█")
+	low, high := values[0], values[0]
+	for _, v := range values {
+		low = math.Min(low, v)
+		high = math.Max(high, v)
+	}
+	out := make([]rune, len(values))
+	for i, v := range values {
+		idx := len(ticks) / 2
+		if high > low {
+			idx = int(math.Round((v - low) / (high - low) * float64(len(ticks)-1)))
+		}
+		out[i] = ticks[idx]
+	}
+	return string(out)
+}
+
+// truncateBytes 截取前 n 个字节用于错误信息
+func truncateBytes(b []byte, n int) []byte {
+	if len(b) > n {
+		return b[:n]
+	}
+	return b
+}
@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestQuotesTool(t *testing.T) {
+	var yahooCalls, geckoCalls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/chart/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&yahooCalls, 1)
+		if r.URL.Path != "/chart/AAPL" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"chart":{"result":null,"error":{"code":"Not Found","description":"No data found, symbol may be delisted"}}}`))
+			return
+		}
+		if r.URL.Query().Get("range") != "5d" || r.Header.Get("User-Agent") == "" {
+			t.Errorf("unexpected request %s, User-Agent %q", r.URL, r.Header.Get("User-Agent"))
+		}
+		w.Write([]byte(`{"chart":{"result":[{"meta":{"symbol":"AAPL","currency":"USD","exchangeName":"NMS","longName":"Apple Inc.","regularMarketPrice":189.5,"regularMarketTime":1714579200},
+			"indicators":{"quote":[{"close":[180,null,185,182,185.5,189.5]}]}}],"error":null}}`))
+	})
+	mux.HandleFunc("/gecko/coins/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&geckoCalls, 1)
+		if r.URL.Path != "/gecko/coins/bitcoin/market_chart" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("vs_currency") != "eur" || r.URL.Query().Get("days") != "30" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		// 每12小时一个点，最后一个点24小时前的价格为 60000
+		w.Write([]byte(`{"prices":[[1714320000000,58000],[1714363200000,59000],[1714406400000,60000],[1714449600000,61000],[1714492800000,63000]]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tool := NewQuotesTool(nil, QuotesSettings{MaxRequestsPerMinute: 2})
+	tool.yahooURL = server.URL + "/chart"
+	tool.coinGeckoURL = server.URL + "/gecko"
+
+	result, err := tool.Execute(map[string]interface{}{"symbol": "aapl", "range": "5d"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	want := "AAPL (Apple Inc., NMS): 189.5 USD\n" +
+		"Day change: +4 (+2.16%)\n" +
+		"5d: ▁▅▂▅█ (low 180, high 189.5)\n" +
+		"As of 2024-05-01 16:00 UTC"
+	if result != want {
+		t.Errorf("result = %q, want %q", result, want)
+	}
+
+	// 相同查询命中缓存
+	if _, err := tool.Execute(map[string]interface{}{"symbol": "AAPL", "range": "5d"}); err != nil || yahooCalls != 1 {
+		t.Errorf("cached Execute() error = %v, yahoo calls = %d", err, yahooCalls)
+	}
+
+	result, err = tool.Execute(map[string]interface{}{"symbol": "BTC", "provider": "coingecko", "currency": "EUR"})
+	if err != nil {
+		t.Fatalf("coingecko Execute() error = %v", err)
+	}
+	if !strings.HasPrefix(result, "BTC (bitcoin, CoinGecko): 63000 EUR\nDay change: +3000 (+5.00%)\n1mo: ▁▂▄▅█") {
+		t.Errorf("coingecko result = %q", result)
+	}
+
+	if _, err := tool.Execute(map[string]interface{}{"symbol": "NOPE"}); err == nil || !strings.Contains(err.Error(), "symbol not found: NOPE") {
+		t.Errorf("unknown symbol error = %v", err)
+	}
+
+	// 每个数据源每分钟最多2次请求
+	_, err = tool.Execute(map[string]interface{}{"symbol": "MSFT"})
+	if err == nil || !strings.Contains(err.Error(), "rate limit") {
+		t.Errorf("rate limit error = %v", err)
+	}
+	if yahooCalls != 2 {
+		t.Errorf("yahoo calls = %d, want 2", yahooCalls)
+	}
+
+	if _, err := tool.Execute(map[string]interface{}{"symbol": "AAPL", "range": "10y"}); err == nil {
+		t.Error("invalid range should fail")
+	}
+	tool.settings.Providers = []string{"coingecko"}
+	if _, err := tool.Execute(map[string]interface{}{"symbol": "AAPL", "provider": "yahoo"}); err == nil || !strings.Contains(err.Error(), "not enabled") {
+		t.Errorf("disabled provider error = %v", err)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := sparkline([]float64{1, 2, 3, 4, 5, 6, 7, 8}, 24); got != "▁▂▃▄▅▆▇█" {
+		t.Errorf("sparkline = %q", got)
+	}
+	if got := sparkline([]float64{5, 5, 5}, 24); got != "▅▅▅" {
+		t.Errorf("flat sparkline = %q", got)
+	}
+	values := make([]float64, 100)
+	for i := range values {
+		values[i] = float64(i)
+	}
+	if got := []rune(sparkline(values, 10)); len(got) != 10 || got[0] != '▁' || got[9] != '█' {
+		t.Errorf("downsampled sparkline = %q", string(got))
+	}
+}
@@ -0,0 +1,480 @@
+package tools
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// unlikelyCandidates 通常为导航、广告、评论等非正文区域
+	unlikelyCandidates = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|extra|foot|header|legends|menu|modal|nav|popup|promo|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|tags|toolbar|widget|advert|^ad-|-ad$`)
+	// maybeCandidate 可能是正文的区域
+	maybeCandidate = regexp.MustCompile(`(?i)and|article|body|column|content|main|post|shadow|story|text|entry`)
+	positiveWeight = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|pagination|post|text|blog|story`)
+	negativeWeight = regexp.MustCompile(`(?i)-ad-|hidden|^hid$|banner|combx|comment|com-|contact|foot|footer|footnote|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget|nav|menu`)
+	whitespaceRun  = regexp.MustCompile(`[ \t\r\n\f]+`)
+	blankLines     = regexp.MustCompile(`\n{3,}`)
+	trailingSpace  = regexp.MustCompile(`[ \t]+\n`)
+)
+
+// junkElements 直接丢弃的元素
+var junkElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "iframe": true, "svg": true,
+	"canvas": true, "form": true, "button": true, "input": true, "select": true,
+	"textarea": true, "nav": true, "aside": true, "footer": true, "template": true,
+	"object": true, "embed": true, "head": true,
+}
+
+// blockElements 块级元素，渲染时前后换行
+var blockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true, "header": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+	"pre": true, "blockquote": true, "table": true, "tr": true, "figure": true,
+	"figcaption": true, "hr": true, "body": true, "html": true,
+}
+
+// paragraphTags 参与正文评分的元素
+var paragraphTags = map[string]bool{"p": true, "pre": true, "td": true, "blockquote": true, "li": true}
+
+// extractedPage 提取结果
+type extractedPage struct {
+	Title   string
+	Content string
+}
+
+// extractOptions 提取选项
+type extractOptions struct {
+	Selector    string // CSS选择器，指定时只提取匹配的元素
+	Readability bool   // 是否进行正文识别
+	Markdown    bool   // 输出Markdown，否则为纯文本
+	BaseURL     *url.URL
+}
+
+// extractHTML 从HTML中提取可读内容
+func extractHTML(src string, opts extractOptions) (*extractedPage, error) {
+	doc := parseHTML(src)
+
+	page := &extractedPage{}
+	if title := doc.find("title"); title != nil {
+		page.Title = collapseSpace(title.textContent())
+	}
+
+	var nodes []*htmlNode
+	switch {
+	case opts.Selector != "":
+		sel, ok := parseSelector(opts.Selector)
+		if !ok {
+			return nil, fmt.Errorf("invalid selector: %s", opts.Selector)
+		}
+		nodes = doc.selectAll(sel)
+		if len(nodes) == 0 {
+			return nil, fmt.Errorf("no elements match selector: %s", opts.Selector)
+		}
+	case opts.Readability:
+		removeJunk(doc)
+		nodes = readableContent(doc)
+	default:
+		removeJunk(doc)
+		nodes = []*htmlNode{doc}
+	}
+
+	r := &htmlRenderer{markdown: opts.Markdown, base: opts.BaseURL}
+	for _, n := range nodes {
+		r.render(n)
+		r.block()
+	}
+	page.Content = r.String()
+	return page, nil
+}
+
+// removeJunk 移除脚本、样式、表单等非内容元素
+func removeJunk(n *htmlNode) {
+	kept := n.children[:0]
+	for _, c := range n.children {
+		if c.tag != "" && (junkElements[c.tag] || isHidden(c)) {
+			continue
+		}
+		removeJunk(c)
+		kept = append(kept, c)
+	}
+	n.children = kept
+}
+
+func isHidden(n *htmlNode) bool {
+	if _, ok := n.attrs["hidden"]; ok {
+		return true
+	}
+	if n.attrs["aria-hidden"] == "true" {
+		return true
+	}
+	style := strings.ReplaceAll(strings.ToLower(n.attrs["style"]), " ", "")
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+// readableContent 按段落评分找出正文区域（参考 Arc90 Readability 算法）
+func readableContent(doc *htmlNode) []*htmlNode {
+	// 移除明显不是正文的区域
+	var prune func(*htmlNode)
+	prune = func(n *htmlNode) {
+		kept := n.children[:0]
+		for _, c := range n.children {
+			if c.tag != "" && c.tag != "body" && c.tag != "html" && c.tag != "article" && c.tag != "main" {
+				match := c.attrs["class"] + " " + c.attrs["id"] + " " + c.attrs["role"]
+				if unlikelyCandidates.MatchString(match) && !maybeCandidate.MatchString(match) {
+					continue
+				}
+			}
+			prune(c)
+			kept = append(kept, c)
+		}
+		n.children = kept
+	}
+	prune(doc)
+
+	scores := make(map[*htmlNode]float64)
+	var candidates []*htmlNode
+	addScore := func(n *htmlNode, score float64) {
+		if n == nil || n.tag == "" || n.tag == "#root" {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+
+	doc.walk(func(n *htmlNode) bool {
+		if !paragraphTags[n.tag] {
+			return true
+		}
+		text := collapseSpace(n.textContent())
+		length := utf8.RuneCountInString(text)
+		if length < 25 {
+			return false
+		}
+
+		score := 1.0
+		score += float64(strings.Count(text, ",") + strings.Count(text, "，") + strings.Count(text, "。"))
+		score += minFloat(float64(length)/100, 3)
+
+		addScore(n.parent, score)
+		if n.parent != nil {
+			addScore(n.parent.parent, score/2)
+		}
+		return false
+	})
+
+	if len(candidates) == 0 {
+		if body := doc.find("body"); body != nil {
+			return []*htmlNode{body}
+		}
+		return []*htmlNode{doc}
+	}
+
+	for _, c := range candidates {
+		scores[c] *= 1 - linkDensity(c)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return scores[candidates[i]] > scores[candidates[j]]
+	})
+	top := candidates[0]
+
+	// 合并得分接近的兄弟节点，避免正文被拆分时遗漏
+	if top.parent == nil {
+		return []*htmlNode{top}
+	}
+	threshold := maxFloat(10, scores[top]*0.2)
+	var result []*htmlNode
+	for _, sibling := range top.parent.children {
+		if sibling.tag == "" {
+			continue
+		}
+		if sibling == top {
+			result = append(result, sibling)
+			continue
+		}
+		if score, ok := scores[sibling]; ok && score >= threshold {
+			result = append(result, sibling)
+			continue
+		}
+		if sibling.tag == "p" {
+			text := collapseSpace(sibling.textContent())
+			if utf8.RuneCountInString(text) > 80 && linkDensity(sibling) < 0.25 {
+				result = append(result, sibling)
+			}
+		}
+	}
+	return result
+}
+
+// initialScore 根据标签和 class/id 给出初始分
+func initialScore(n *htmlNode) float64 {
+	score := 0.0
+	switch n.tag {
+	case "article", "main":
+		score += 10
+	case "div":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+
+	for _, attr := range []string{n.attrs["class"], n.attrs["id"]} {
+		if attr == "" {
+			continue
+		}
+		if negativeWeight.MatchString(attr) {
+			score -= 25
+		}
+		if positiveWeight.MatchString(attr) {
+			score += 25
+		}
+	}
+	return score
+}
+
+// linkDensity 链接文字占比
+func linkDensity(n *htmlNode) float64 {
+	total := utf8.RuneCountInString(collapseSpace(n.textContent()))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	n.walk(func(c *htmlNode) bool {
+		if c.tag == "a" {
+			links += utf8.RuneCountInString(collapseSpace(c.textContent()))
+			return false
+		}
+		return true
+	})
+	return float64(links) / float64(total)
+}
+
+// htmlRenderer 将节点渲染为纯文本或Markdown
+type htmlRenderer struct {
+	sb       strings.Builder
+	markdown bool
+	base     *url.URL
+	pre      int
+	lists    []int // 列表嵌套，值为有序列表的下一个序号，无序列表为0
+}
+
+func (r *htmlRenderer) String() string {
+	s := trailingSpace.ReplaceAllString(r.sb.String(), "\n")
+	s = blankLines.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
+
+// block 确保以空行分隔块级内容
+func (r *htmlRenderer) block() {
+	s := r.sb.String()
+	if s == "" || strings.HasSuffix(s, "\n\n") {
+		return
+	}
+	if strings.HasSuffix(s, "\n") {
+		r.sb.WriteString("\n")
+	} else {
+		r.sb.WriteString("\n\n")
+	}
+}
+
+// newline 确保从新行开始
+func (r *htmlRenderer) newline() {
+	s := r.sb.String()
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		r.sb.WriteString("\n")
+	}
+}
+
+func (r *htmlRenderer) text(s string) {
+	if r.pre > 0 {
+		r.sb.WriteString(s)
+		return
+	}
+	s = whitespaceRun.ReplaceAllString(s, " ")
+	cur := r.sb.String()
+	if cur == "" || strings.HasSuffix(cur, "\n") || strings.HasSuffix(cur, " ") {
+		s = strings.TrimLeft(s, " ")
+	}
+	r.sb.WriteString(s)
+}
+
+func (r *htmlRenderer) children(n *htmlNode) {
+	for _, c := range n.children {
+		r.render(c)
+	}
+}
+
+func (r *htmlRenderer) render(n *htmlNode) {
+	if n.tag == "" {
+		r.text(n.text)
+		return
+	}
+	if junkElements[n.tag] || n.tag == "title" {
+		return
+	}
+
+	switch n.tag {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		r.block()
+		if r.markdown {
+			r.sb.WriteString(strings.Repeat("#", int(n.tag[1]-'0')) + " ")
+		}
+		r.sb.WriteString(collapseSpace(n.textContent()))
+		r.block()
+	case "br":
+		r.sb.WriteString("\n")
+	case "hr":
+		r.block()
+		if r.markdown {
+			r.sb.WriteString("---")
+		}
+		r.block()
+	case "pre":
+		r.block()
+		if r.markdown {
+			r.sb.WriteString("```\n")
+		}
+		r.pre++
+		r.children(n)
+		r.pre--
+		if r.markdown {
+			r.newline()
+			r.sb.WriteString("```")
+		}
+		r.block()
+	case "code":
+		if r.markdown && r.pre == 0 {
+			r.sb.WriteString("`" + collapseSpace(n.textContent()) + "`")
+			return
+		}
+		r.children(n)
+	case "strong", "b":
+		r.inline(n, "**")
+	case "em", "i":
+		r.inline(n, "*")
+	case "a":
+		text := collapseSpace(n.textContent())
+		href := r.resolve(n.attrs["href"])
+		if !r.markdown || text == "" || href == "" || strings.HasPrefix(href, "javascript:") {
+			r.children(n)
+			return
+		}
+		r.text(fmt.Sprintf("[%s](%s)", text, href))
+	case "img":
+		if !r.markdown {
+			return
+		}
+		if src := r.resolve(n.attrs["src"]); src != "" && !strings.HasPrefix(src, "data:") {
+			r.text(fmt.Sprintf("![%s](%s)", n.attrs["alt"], src))
+		}
+	case "ul", "ol":
+		r.block()
+		start := 0
+		if n.tag == "ol" {
+			start = 1
+		}
+		r.lists = append(r.lists, start)
+		r.children(n)
+		r.lists = r.lists[:len(r.lists)-1]
+		r.block()
+	case "li":
+		r.newline()
+		depth := len(r.lists)
+		if depth > 1 {
+			r.sb.WriteString(strings.Repeat("  ", depth-1))
+		}
+		if depth > 0 && r.lists[depth-1] > 0 {
+			r.sb.WriteString(fmt.Sprintf("%d. ", r.lists[depth-1]))
+			r.lists[depth-1]++
+		} else {
+			r.sb.WriteString("- ")
+		}
+		r.children(n)
+		r.newline()
+	case "blockquote":
+		r.block()
+		inner := &htmlRenderer{markdown: r.markdown, base: r.base}
+		inner.children(n)
+		for _, line := range strings.Split(inner.String(), "\n") {
+			if r.markdown {
+				r.sb.WriteString("> ")
+			}
+			r.sb.WriteString(line + "\n")
+		}
+		r.block()
+	case "tr":
+		r.newline()
+		var cells []string
+		for _, c := range n.children {
+			if c.tag == "td" || c.tag == "th" {
+				cells = append(cells, collapseSpace(c.textContent()))
+			}
+		}
+		if r.markdown {
+			r.sb.WriteString("| " + strings.Join(cells, " | ") + " |")
+		} else {
+			r.sb.WriteString(strings.Join(cells, "\t"))
+		}
+		r.newline()
+	default:
+		if blockElements[n.tag] {
+			r.block()
+			r.children(n)
+			r.block()
+			return
+		}
+		r.children(n)
+	}
+}
+
+// inline 渲染带标记的行内元素
+func (r *htmlRenderer) inline(n *htmlNode, mark string) {
+	text := collapseSpace(n.textContent())
+	if !r.markdown || text == "" || r.pre > 0 {
+		r.children(n)
+		return
+	}
+	r.text(mark + text + mark)
+}
+
+// resolve 将相对链接转为绝对链接
+func (r *htmlRenderer) resolve(href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") || r.base == nil {
+		return href
+	}
+	u, err := r.base.Parse(href)
+	if err != nil {
+		return href
+	}
+	return u.String()
+}
+
+func collapseSpace(s string) string {
+	return strings.TrimSpace(whitespaceRun.ReplaceAllString(s, " "))
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const testArticle = `<!DOCTYPE html>
+<html>
+<head><title>Test &amp; Article</title><style>body { color: red; }</style></head>
+<body>
+<nav class="menu"><a href="/">Home</a> <a href="/about">About</a></nav>
+<div id="sidebar" class="sidebar"><p>Subscribe to our newsletter for the latest updates, offers and more.</p></div>
+<div class="article-content">
+<h1>Main heading</h1>
+<p>The first paragraph has enough text, with commas, to be scored as real content by the extractor.
+<p>The second paragraph links to <a href="/docs/guide">the guide</a> and contains <strong>bold</strong> text, too.</p>
+<ul><li>first item<li>second item</ul>
+<pre>code  block
+  indented</pre>
+</div>
+<footer>Copyright footer text that should never appear in output at all.</footer>
+<script>var x = "<p>not content</p>";</script>
+</body>
+</html>`
+
+func TestExtractHTML(t *testing.T) {
+	base, _ := url.Parse("https://example.com/posts/1")
+
+	tests := []struct {
+		name    string
+		opts    extractOptions
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "readability text",
+			opts:    extractOptions{Readability: true},
+			want:    []string{"Main heading", "The first paragraph", "the guide", "- first item", "code  block\n  indented"},
+			notWant: []string{"Home", "Subscribe", "Copyright", "not content", "color: red", "**"},
+		},
+		{
+			name: "markdown",
+			opts: extractOptions{Readability: true, Markdown: true, BaseURL: base},
+			want: []string{"# Main heading", "[the guide](https://example.com/docs/guide)", "**bold**", "```\ncode  block"},
+		},
+		{
+			name:    "selector",
+			opts:    extractOptions{Selector: "div.article-content > ul li"},
+			want:    []string{"first item", "second item"},
+			notWant: []string{"Main heading"},
+		},
+		{
+			name:    "no readability",
+			opts:    extractOptions{},
+			want:    []string{"Subscribe", "Main heading"},
+			notWant: []string{"Copyright", "not content"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := extractHTML(testArticle, tt.opts)
+			if err != nil {
+				t.Fatalf("extractHTML failed: %v", err)
+			}
+			if page.Title != "Test & Article" {
+				t.Errorf("expected title, got %q", page.Title)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(page.Content, w) {
+					t.Errorf("expected %q in:\n%s", w, page.Content)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(page.Content, w) {
+					t.Errorf("unexpected %q in:\n%s", w, page.Content)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractHTMLSelectorErrors(t *testing.T) {
+	if _, err := extractHTML(testArticle, extractOptions{Selector: "> p"}); err == nil {
+		t.Error("expected error for invalid selector")
+	}
+	if _, err := extractHTML(testArticle, extractOptions{Selector: "#missing"}); err == nil {
+		t.Error("expected error when nothing matches")
+	}
+}
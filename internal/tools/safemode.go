@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+// safeModePlanTTL 计划等待用户确认的时长
+const safeModePlanTTL = 10 * time.Minute
+
+// PlanTool 修改类工具，安全模式下先返回执行计划而不实际执行
+type PlanTool interface {
+	Tool
+	Plan(args map[string]interface{}) (string, error)
+}
+
+// pendingPlan 已展示给用户、等待确认的计划
+type pendingPlan struct {
+	requestID string
+	created   time.Time
+}
+
+// safeModeState 按用户记录安全模式开关与待确认的计划
+type safeModeState struct {
+	mu    sync.Mutex
+	users map[string]bool
+	plans map[string]map[string]pendingPlan // 用户 -> 计划键 -> 计划
+}
+
+func newSafeModeState() *safeModeState {
+	return &safeModeState{
+		users: make(map[string]bool),
+		plans: make(map[string]map[string]pendingPlan),
+	}
+}
+
+// SetSafeMode 开关用户的安全模式，关闭时丢弃待确认的计划
+func (m *Manager) SetSafeMode(channel, userID string, on bool) {
+	user := channel + ":" + userID
+	m.safeMode.mu.Lock()
+	defer m.safeMode.mu.Unlock()
+	if on {
+		m.safeMode.users[user] = true
+	} else {
+		delete(m.safeMode.users, user)
+		delete(m.safeMode.plans, user)
+	}
+}
+
+// SafeMode 返回用户是否开启了安全模式
+func (m *Manager) SafeMode(channel, userID string) bool {
+	m.safeMode.mu.Lock()
+	defer m.safeMode.mu.Unlock()
+	return m.safeMode.users[channel+":"+userID]
+}
+
+// planKey 以工具名和参数（忽略 confirm）标识一次计划
+func planKey(name string, args map[string]interface{}) string {
+	rest := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		if k != "confirm" {
+			rest[k] = v
+		}
+	}
+	data, _ := json.Marshal(rest)
+	return name + " " + string(data)
+}
+
+// safeModePlan 安全模式下拦截修改类工具：首次调用返回计划，
+// 用户在之后的消息中同意后，以相同参数再次调用才真正执行。handled 为 true 时直接返回 preview
+func (m *Manager) safeModePlan(ctx context.Context, tool Tool, args map[string]interface{}) (preview string, handled bool, err error) {
+	pt, ok := tool.(PlanTool)
+	if !ok {
+		return "", false, nil
+	}
+	if dryRun, _ := args["dry_run"].(bool); dryRun {
+		return "", false, nil
+	}
+	c, ok := CallerFrom(ctx)
+	if !ok {
+		return "", false, nil
+	}
+	user := c.Channel + ":" + c.UserID
+
+	m.safeMode.mu.Lock()
+	defer m.safeMode.mu.Unlock()
+	if !m.safeMode.users[user] {
+		return "", false, nil
+	}
+
+	now := time.Now()
+	plans := m.safeMode.plans[user]
+	if plans == nil {
+		plans = make(map[string]pendingPlan)
+		m.safeMode.plans[user] = plans
+	}
+	for k, p := range plans {
+		if now.Sub(p.created) > safeModePlanTTL {
+			delete(plans, k)
+		}
+	}
+
+	key := planKey(tool.Name(), args)
+	requestID := logger.RequestID(ctx)
+	// 同一条消息内重复调用不算确认，必须等用户回复
+	if p, ok := plans[key]; ok && requestID != "" && p.requestID != requestID {
+		delete(plans, key)
+		// 用户已确认，策略要求的确认不再重复询问
+		args[approvalArgKey] = approval{}
+		return "", false, nil
+	}
+
+	plan, err := pt.Plan(args)
+	if err != nil {
+		return "", true, err
+	}
+	plans[key] = pendingPlan{requestID: requestID, created: now}
+
+	return fmt.Sprintf("[safe mode] Nothing has been changed yet. Planned %s:\n%s\n\n"+
+		"Show this plan to the user and ask whether to apply it. Only if the user agrees in their next message, "+
+		"call %s again with exactly the same arguments.", tool.Name(), plan, tool.Name()), true, nil
+}
@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestSafeMode(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	workDir := t.TempDir()
+	m, err := NewManager(Config{WorkDir: workDir, ConfirmDangerous: true}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, "a.txt"), []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	caller := WithCaller(context.Background(), Caller{Channel: "telegram", UserID: "alice"})
+	msg := func(id string) context.Context { return logger.WithRequestID(caller, id) }
+	read := func() string {
+		data, _ := os.ReadFile(filepath.Join(workDir, "a.txt"))
+		return string(data)
+	}
+	write := map[string]interface{}{"path": "a.txt", "content": "one\n2\n"}
+
+	m.SetSafeMode("telegram", "alice", true)
+	if !m.SafeMode("telegram", "alice") || m.SafeMode("telegram", "bob") {
+		t.Fatal("safe mode should only be on for alice")
+	}
+
+	result, err := m.Execute(msg("1"), "write_file", write)
+	if err != nil || !strings.Contains(result, "[safe mode]") || !strings.Contains(result, "-two\n+2") {
+		t.Fatalf("plan = %q, %v", result, err)
+	}
+	if read() != "one\ntwo\n" {
+		t.Fatal("file changed before approval")
+	}
+
+	// 同一条消息内重复调用不会执行
+	if result, _ := m.Execute(msg("1"), "write_file", write); !strings.Contains(result, "[safe mode]") {
+		t.Errorf("repeated call in the same message = %q", result)
+	}
+	// 参数不同则重新出计划
+	other := map[string]interface{}{"path": "a.txt", "content": "other\n"}
+	if result, _ := m.Execute(msg("2"), "write_file", other); !strings.Contains(result, "[safe mode]") {
+		t.Errorf("different arguments = %q", result)
+	}
+
+	// 用户回复后相同参数的调用才执行
+	if result, err := m.Execute(msg("2"), "write_file", write); err != nil || strings.Contains(result, "[safe mode]") {
+		t.Fatalf("approved call = %q, %v", result, err)
+	}
+	if read() != "one\n2\n" {
+		t.Errorf("a.txt = %q after approval", read())
+	}
+
+	// 用户同意计划后不再重复请求策略确认
+	del := map[string]interface{}{"path": "a.txt"}
+	result, _ = m.Execute(msg("3"), "delete_file", del)
+	if !strings.Contains(result, "Delete file a.txt") {
+		t.Errorf("delete plan = %q", result)
+	}
+	if _, err := m.Execute(msg("4"), "delete_file", del); err != nil {
+		t.Errorf("approved delete: %v", err)
+	}
+
+	result, _ = m.Execute(msg("5"), "execute_command", map[string]interface{}{"command": "echo hi"})
+	if !strings.Contains(result, "$ echo hi") {
+		t.Errorf("command plan = %q", result)
+	}
+
+	// apply_patch 的 dry_run 与只读工具不受影响
+	os.WriteFile(filepath.Join(workDir, "b.txt"), []byte("x\n"), 0644)
+	patch := map[string]interface{}{"path": "b.txt", "patch": "@@ -1 +1 @@\n-x\n+y\n", "dry_run": true}
+	if result, err := m.Execute(msg("6"), "apply_patch", patch); err != nil || !strings.HasPrefix(result, "Dry run") {
+		t.Errorf("dry run = %q, %v", result, err)
+	}
+	delete(patch, "dry_run")
+	if result, _ := m.Execute(msg("6"), "apply_patch", patch); !strings.Contains(result, "-x\n+y") {
+		t.Errorf("patch plan = %q", result)
+	}
+	if _, err := m.Execute(msg("6"), "read_file", map[string]interface{}{"path": "b.txt"}); err != nil {
+		t.Errorf("read_file in safe mode: %v", err)
+	}
+
+	// 关闭后直接执行，待确认的计划被丢弃
+	m.SetSafeMode("telegram", "alice", false)
+	if result, err := m.Execute(msg("7"), "write_file", map[string]interface{}{"path": "c.txt", "content": "c"}); err != nil || strings.Contains(result, "[safe mode]") {
+		t.Errorf("write with safe mode off = %q, %v", result, err)
+	}
+}
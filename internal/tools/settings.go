@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"strings"
+	"time"
+)
+
+// ToolSettings 按工具调整参数的默认值和上限，构造工具时读取，零值使用内置默认值。
+// 生效的取值会写进参数说明，模型据此决定是否需要显式传参
+type ToolSettings struct {
+	Weather     WeatherSettings
+	WebSearch   WebSearchSettings
+	HTTPRequest HTTPRequestSettings
+	Grep        GrepSettings
+	Quotes      QuotesSettings
+}
+
+// WeatherSettings weather 工具配置
+type WeatherSettings struct {
+	DefaultCity  string // 未指定 city 时查询的城市，为空时 city 必填
+	DefaultDays  int    // 默认预报天数，默认3
+	DefaultUnits string // 用户资料未设置单位制时使用，默认 metric
+}
+
+// WebSearchSettings web_search 工具配置
+type WebSearchSettings struct {
+	DefaultResults int // 默认返回结果数，默认5
+	MaxResults     int // 结果数上限，默认10
+}
+
+// HTTPRequestSettings http_request 工具配置
+type HTTPRequestSettings struct {
+	MaxBytes  int // 读取的响应体字节数上限，默认5MB
+	MaxLength int // 返回字符数上限，默认20000
+}
+
+// GrepSettings grep 工具配置
+type GrepSettings struct {
+	MaxMatches int // 默认总匹配数上限，默认50
+	MaxPerFile int // 每个文件默认显示的匹配数，默认10
+}
+
+// QuotesSettings quotes 工具配置
+type QuotesSettings struct {
+	Providers            []string // 启用的数据源，第一个为默认，默认 yahoo、coingecko
+	CacheSeconds         int      // 相同查询的缓存时间（秒），默认60
+	MaxRequestsPerMinute int      // 每个数据源每分钟的请求数上限，默认20
+}
+
+func (s WeatherSettings) days() int {
+	if s.DefaultDays <= 0 {
+		return 3
+	}
+	if s.DefaultDays > maxForecastDays {
+		return maxForecastDays
+	}
+	return s.DefaultDays
+}
+
+func (s WeatherSettings) units() string {
+	if s.DefaultUnits == "" {
+		return "metric"
+	}
+	return s.DefaultUnits
+}
+
+func (s WebSearchSettings) maxResults() int {
+	if s.MaxResults <= 0 {
+		return 10
+	}
+	return s.MaxResults
+}
+
+func (s WebSearchSettings) defaultResults() int {
+	n := s.DefaultResults
+	if n <= 0 {
+		n = 5
+	}
+	if max := s.maxResults(); n > max {
+		n = max
+	}
+	return n
+}
+
+func (s HTTPRequestSettings) maxBytes() int64 {
+	if s.MaxBytes <= 0 {
+		return maxHTTPBodySize
+	}
+	return int64(s.MaxBytes)
+}
+
+func (s HTTPRequestSettings) maxLength() int {
+	if s.MaxLength <= 0 {
+		return maxHTTPMaxLength
+	}
+	return s.MaxLength
+}
+
+func (s HTTPRequestSettings) defaultLength() int {
+	if max := s.maxLength(); max < defaultHTTPMaxLength {
+		return max
+	}
+	return defaultHTTPMaxLength
+}
+
+func (s GrepSettings) maxMatches() int {
+	if s.MaxMatches <= 0 {
+		return 50
+	}
+	return s.MaxMatches
+}
+
+// matchLimit max_results 参数允许的最大值，配置的默认值更大时以配置为准
+func (s GrepSettings) matchLimit() int {
+	if n := s.maxMatches(); n > grepMaxResults {
+		return n
+	}
+	return grepMaxResults
+}
+
+func (s GrepSettings) maxPerFile() int {
+	if s.MaxPerFile <= 0 {
+		return 10
+	}
+	return s.MaxPerFile
+}
+
+func (s QuotesSettings) providers() []string {
+	var result []string
+	for _, p := range s.Providers {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == QuoteProviderYahoo || p == QuoteProviderCoinGecko {
+			result = append(result, p)
+		}
+	}
+	if len(result) == 0 {
+		return []string{QuoteProviderYahoo, QuoteProviderCoinGecko}
+	}
+	return result
+}
+
+func (s QuotesSettings) cacheTTL() time.Duration {
+	if s.CacheSeconds <= 0 {
+		return time.Minute
+	}
+	return time.Duration(s.CacheSeconds) * time.Second
+}
+
+func (s QuotesSettings) maxRequestsPerMinute() int {
+	if s.MaxRequestsPerMinute <= 0 {
+		return 20
+	}
+	return s.MaxRequestsPerMinute
+}
@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+// paramDescription 返回参数的说明
+func paramDescription(tool Tool, name string) string {
+	props := tool.Parameters()["properties"].(map[string]interface{})
+	return props[name].(map[string]interface{})["description"].(string)
+}
+
+func TestToolSettingsDescriptions(t *testing.T) {
+	weather := &WeatherTool{}
+	if _, ok := weather.Parameters()["required"]; !ok {
+		t.Error("city should be required by default")
+	}
+	weather.settings = WeatherSettings{DefaultCity: "Berlin", DefaultDays: 5, DefaultUnits: "imperial"}
+	if _, ok := weather.Parameters()["required"]; ok {
+		t.Error("city should be optional with a default city")
+	}
+	if d := paramDescription(weather, "city"); !strings.Contains(d, "Berlin") {
+		t.Errorf("city description = %q", d)
+	}
+	if d := paramDescription(weather, "days"); !strings.Contains(d, "默认5") {
+		t.Errorf("days description = %q", d)
+	}
+	if d := paramDescription(weather, "units"); !strings.HasSuffix(d, "imperial") {
+		t.Errorf("units description = %q", d)
+	}
+
+	search := &WebSearchTool{settings: WebSearchSettings{DefaultResults: 20, MaxResults: 8}}
+	if d := paramDescription(search, "num_results"); d != "返回结果数量（默认8，最大8）" {
+		t.Errorf("num_results description = %q", d)
+	}
+	if d := paramDescription(&WebSearchTool{}, "num_results"); d != "返回结果数量（默认5，最大10）" {
+		t.Errorf("default num_results description = %q", d)
+	}
+
+	httpTool := &HTTPRequestTool{settings: HTTPRequestSettings{MaxLength: 3000}}
+	if d := paramDescription(httpTool, "max_length"); d != "最大返回字符数（默认3000，最大3000）" {
+		t.Errorf("max_length description = %q", d)
+	}
+
+	// 管理器构造工具时传入配置
+	log, _ := logger.New(logger.Config{Level: "error"})
+	m, err := NewManager(Config{WorkDir: t.TempDir(), Settings: ToolSettings{Grep: GrepSettings{MaxMatches: 800, MaxPerFile: 3}}}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grep, _ := m.Get("grep")
+	if d := paramDescription(grep, "max_results"); d != "总匹配数上限，默认800，最大800" {
+		t.Errorf("max_results description = %q", d)
+	}
+	if d := paramDescription(grep, "max_per_file"); d != "每个文件最多显示的匹配数，默认3" {
+		t.Errorf("max_per_file description = %q", d)
+	}
+}
@@ -0,0 +1,214 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/HaohanHe/mujibot/internal/i18n"
+)
+
+var (
+	unitNameRe     = regexp.MustCompile(`^[A-Za-z0-9@._:-]+$`)
+	journalSinceRe = regexp.MustCompile(`^[A-Za-z0-9 :-]+$`)
+)
+
+// serviceProperties status 显示的 systemctl show 属性
+var serviceProperties = []string{
+	"Id", "Description", "LoadState", "ActiveState", "SubState",
+	"UnitFileState", "MainPID", "ActiveEnterTimestamp", "NRestarts",
+}
+
+// SystemctlTool 管理白名单内的 systemd 服务
+type SystemctlTool struct {
+	manager *Manager
+	units   map[string]string // 规范化名称 -> 配置中的名称
+
+	// 可在测试中替换
+	run func(ctx context.Context, name string, args ...string) ([]byte, error)
+}
+
+func NewSystemctlTool(manager *Manager, units []string) *SystemctlTool {
+	t := &SystemctlTool{
+		manager: manager,
+		units:   make(map[string]string),
+		run: func(ctx context.Context, name string, args ...string) ([]byte, error) {
+			return exec.CommandContext(ctx, name, args...).CombinedOutput()
+		},
+	}
+	for _, u := range units {
+		t.units[normalizeUnit(u)] = u
+	}
+	return t
+}
+
+// normalizeUnit 补全 .service 后缀
+func normalizeUnit(name string) string {
+	name = strings.TrimSpace(name)
+	if !strings.Contains(name, ".") {
+		name += ".service"
+	}
+	return name
+}
+
+func (t *SystemctlTool) Name() string {
+	return "systemctl"
+}
+
+func (t *SystemctlTool) Description() string {
+	return "查看、重启 systemd 服务或读取其日志。仅限以下服务: " + t.unitList()
+}
+
+// DescriptionParams 翻译后的描述同样列出允许的服务
+func (t *SystemctlTool) DescriptionParams() i18n.Params {
+	return i18n.Params{"units": t.unitList()}
+}
+
+func (t *SystemctlTool) unitList() string {
+	names := make([]string, 0, len(t.units))
+	for _, u := range t.units {
+		names = append(names, u)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func (t *SystemctlTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"action": map[string]interface{}{
+				"type":        "string",
+				"description": "status(状态), restart(重启), logs(journalctl日志)",
+				"enum":        []string{"status", "restart", "logs"},
+			},
+			"unit": map[string]interface{}{
+				"type":        "string",
+				"description": "服务名，如 nginx",
+			},
+			"lines": map[string]interface{}{
+				"type":        "integer",
+				"description": "logs 返回的行数，默认50，最大500",
+			},
+			"since": map[string]interface{}{
+				"type":        "string",
+				"description": "logs 的起始时间，如 \"1 hour ago\"、\"today\"、\"2024-05-01 10:00\"",
+			},
+			"confirm": map[string]interface{}{
+				"type":        "boolean",
+				"description": "确认重启",
+			},
+		},
+		"required": []string{"action", "unit"},
+	}
+}
+
+func (t *SystemctlTool) Execute(args map[string]interface{}) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+func (t *SystemctlTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (string, error) {
+	action, _ := args["action"].(string)
+	unit, _ := args["unit"].(string)
+	if !unitNameRe.MatchString(unit) {
+		return "", fmt.Errorf("invalid unit name: %q", unit)
+	}
+	unit = normalizeUnit(unit)
+	if _, ok := t.units[unit]; !ok {
+		return "", fmt.Errorf("unit %s is not in tools.serviceUnits", unit)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.manager.timeoutFor(t.Name()))
+	defer cancel()
+
+	switch action {
+	case "status":
+		return t.status(ctx, unit)
+	case "restart":
+		if t.manager.confirmDangerous && !t.manager.unattendedMode {
+			if confirmed, _ := args["confirm"].(bool); !confirmed {
+				return "", fmt.Errorf("重启 %s 需要确认。设置 confirm=true 来执行", unit)
+			}
+		}
+		t.manager.log.Info("restarting service", "unit", unit)
+		if out, err := t.run(ctx, "systemctl", "restart", unit); err != nil {
+			return "", fmt.Errorf("restart failed: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+		status, err := t.status(ctx, unit)
+		if err != nil {
+			return "Restarted " + unit, nil
+		}
+		return "Restarted " + unit + "\n" + status, nil
+	case "logs":
+		lines := 50
+		if n, ok := args["lines"].(float64); ok && n > 0 {
+			lines = int(n)
+			if lines > 500 {
+				lines = 500
+			}
+		}
+		cmdArgs := []string{"-u", unit, "-n", fmt.Sprint(lines), "--no-pager", "-o", "short-iso"}
+		if since, _ := args["since"].(string); since != "" {
+			if !journalSinceRe.MatchString(since) {
+				return "", fmt.Errorf("invalid since: %q", since)
+			}
+			cmdArgs = append(cmdArgs, "--since", since)
+		}
+		out, err := t.run(ctx, "journalctl", cmdArgs...)
+		if err != nil {
+			return "", fmt.Errorf("journalctl failed: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+		result := strings.TrimSpace(string(out))
+		if result == "" || result == "-- No entries --" {
+			return "No log entries for " + unit, nil
+		}
+		return result, nil
+	}
+	return "", fmt.Errorf("unknown action: %s", action)
+}
+
+// status 通过 systemctl show 获取服务状态
+func (t *SystemctlTool) status(ctx context.Context, unit string) (string, error) {
+	out, err := t.run(ctx, "systemctl", "show", unit, "--no-pager", "--property="+strings.Join(serviceProperties, ","))
+	if err != nil {
+		return "", fmt.Errorf("systemctl failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	props := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(line), "="); ok {
+			props[k] = v
+		}
+	}
+	if props["LoadState"] == "not-found" {
+		return "", fmt.Errorf("unit %s not found", unit)
+	}
+
+	running := "no"
+	if props["ActiveState"] == "active" {
+		running = "yes"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Unit: %s", unit)
+	if d := props["Description"]; d != "" {
+		fmt.Fprintf(&sb, " (%s)", d)
+	}
+	fmt.Fprintf(&sb, "\nRunning: %s\nState: %s/%s", running, props["ActiveState"], props["SubState"])
+	if s := props["UnitFileState"]; s != "" {
+		fmt.Fprintf(&sb, "\nEnabled: %s", s)
+	}
+	if pid := props["MainPID"]; pid != "" && pid != "0" {
+		fmt.Fprintf(&sb, "\nPID: %s", pid)
+	}
+	if since := props["ActiveEnterTimestamp"]; since != "" {
+		fmt.Fprintf(&sb, "\nSince: %s", since)
+	}
+	if n := props["NRestarts"]; n != "" && n != "0" {
+		fmt.Fprintf(&sb, "\nRestarts: %s", n)
+	}
+	return sb.String(), nil
+}
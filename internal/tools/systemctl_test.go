@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestSystemctlTool(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	m, err := NewManager(Config{WorkDir: t.TempDir(), ConfirmDangerous: true}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := NewSystemctlTool(m, []string{"nginx", "backup.timer"})
+
+	var calls []string
+	tool.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		switch {
+		case name == "systemctl" && args[0] == "show":
+			return []byte("Id=nginx.service\nDescription=web server\nLoadState=loaded\nActiveState=active\nSubState=running\nMainPID=42\nNRestarts=0\n"), nil
+		case name == "journalctl":
+			return []byte("2024-05-01T10:00:00 host nginx[42]: started\n"), nil
+		}
+		return nil, nil
+	}
+
+	result, err := tool.Execute(map[string]interface{}{"action": "status", "unit": "nginx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, "Running: yes") || !strings.Contains(result, "PID: 42") || strings.Contains(result, "Restarts:") {
+		t.Errorf("status = %q", result)
+	}
+
+	tests := []struct {
+		args map[string]interface{}
+		err  string
+	}{
+		{map[string]interface{}{"action": "status", "unit": "sshd"}, "not in tools.serviceUnits"},
+		{map[string]interface{}{"action": "status", "unit": "nginx; reboot"}, "invalid unit name"},
+		{map[string]interface{}{"action": "restart", "unit": "nginx.service"}, "confirm=true"},
+		{map[string]interface{}{"action": "logs", "unit": "nginx", "since": "$(id)"}, "invalid since"},
+		{map[string]interface{}{"action": "stop", "unit": "nginx"}, "unknown action"},
+	}
+	for _, tt := range tests {
+		if _, err := tool.Execute(tt.args); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Execute(%v) error = %v, want %q", tt.args, err, tt.err)
+		}
+	}
+
+	calls = nil
+	result, err = tool.Execute(map[string]interface{}{"action": "restart", "unit": "nginx", "confirm": true})
+	if err != nil || !strings.HasPrefix(result, "Restarted nginx.service") {
+		t.Errorf("restart = %q, %v", result, err)
+	}
+	if len(calls) == 0 || calls[0] != "systemctl restart nginx.service" {
+		t.Errorf("restart calls = %v", calls)
+	}
+
+	calls = nil
+	if _, err := tool.Execute(map[string]interface{}{"action": "logs", "unit": "backup.timer", "lines": float64(1000), "since": "1 hour ago"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "journalctl -u backup.timer -n 500 --no-pager -o short-iso --since 1 hour ago"; calls[0] != want {
+		t.Errorf("logs call = %q, want %q", calls[0], want)
+	}
+}
@@ -1,29 +1,96 @@
 package tools
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/HaohanHe/mujibot/internal/confirmation"
+	"github.com/HaohanHe/mujibot/internal/i18n"
+	"github.com/HaohanHe/mujibot/internal/policy"
+	"github.com/HaohanHe/mujibot/internal/system"
 )
 
 type TerminalSession struct {
 	ID        string
+	Command   string
+	Owner     string // 发起会话的用户（channel:userID），为空时不限制访问
 	Cmd       *exec.Cmd
 	Stdin     io.WriteCloser
-	Stdout    io.Reader
-	Stderr    io.Reader
 	Output    strings.Builder
 	StartTime time.Time
 	Running   bool
 	mu        sync.RWMutex
+
+	pty       *os.File      // 伪终端主设备，使用管道时为nil
+	copied    chan struct{} // 伪终端输出读取结束
+	watchers  map[chan struct{}]struct{}
+	origin    Caller // 发起会话的聊天，后台会话结束时通知
+	cancelled bool
+}
+
+const (
+	defaultTerminalRows = 24
+	defaultTerminalCols = 120
+	// ptyDrainTimeout 进程退出后等待读完伪终端剩余输出的时长，后台子进程仍占用终端时不再等待
+	ptyDrainTimeout = time.Second
+	// notifyTailLines、notifyTailChars 完成通知附带的输出末尾
+	notifyTailLines = 20
+	notifyTailChars = 2000
+)
+
+// Write 追加命令输出。不按行缓冲，没有换行的交互提示（如 [Y/n]）也能立即看到
+func (s *TerminalSession) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, err := s.Output.Write(p)
+	s.notifyLocked()
+	return n, err
+}
+
+// finish 标记会话结束并通知观察者
+func (s *TerminalSession) finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Running = false
+	s.notifyLocked()
+}
+
+// notifyLocked 通知观察者有新输出或状态变化，调用方需持有锁
+func (s *TerminalSession) notifyLocked() {
+	for ch := range s.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// drain 进程退出后读完伪终端中剩余的输出并关闭主设备
+func (s *TerminalSession) drain() {
+	if s.pty == nil {
+		return
+	}
+	select {
+	case <-s.copied:
+	case <-time.After(ptyDrainTimeout):
+	}
+	s.pty.Close()
+}
+
+// outputLen 返回当前输出长度
+func (s *TerminalSession) outputLen() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Output.Len()
 }
 
 type TerminalTool struct {
@@ -31,6 +98,7 @@ type TerminalTool struct {
 	sessions  map[string]*TerminalSession
 	mu        sync.RWMutex
 	confirmMgr *confirmation.ConfirmationManager
+	inputWait  time.Duration // 发送输入后等待新输出的时长
 }
 
 func NewTerminalTool(manager *Manager, confirmMgr *confirmation.ConfirmationManager) *TerminalTool {
@@ -38,6 +106,7 @@ func NewTerminalTool(manager *Manager, confirmMgr *confirmation.ConfirmationMana
 		manager:    manager,
 		sessions:   make(map[string]*TerminalSession),
 		confirmMgr: confirmMgr,
+		inputWait:  time.Second,
 	}
 }
 
@@ -46,7 +115,7 @@ func (t *TerminalTool) Name() string {
 }
 
 func (t *TerminalTool) Description() string {
-	return "执行终端命令并获取实时输出。支持交互式会话、后台运行、命令取消。"
+	return "执行终端命令并获取实时输出。支持交互式会话、后台运行、命令取消。需要回答提示的命令（如 apt upgrade、REPL）请用 background=true 启动，再用 input 操作输入。"
 }
 
 func (t *TerminalTool) Parameters() map[string]interface{} {
@@ -59,12 +128,16 @@ func (t *TerminalTool) Parameters() map[string]interface{} {
 			},
 			"action": map[string]interface{}{
 				"type":        "string",
-				"description": "操作类型: run(执行), cancel(取消), list(列出会话)",
-				"enum":        []string{"run", "cancel", "list", "output"},
+				"description": "操作类型: run(执行), input(向后台会话输入一行，如回答 [Y/n] 提示), output(查看输出), resize(调整终端窗口大小), cancel(取消), list(列出会话)",
+				"enum":        []string{"run", "input", "cancel", "list", "output", "resize"},
 			},
 			"sessionId": map[string]interface{}{
 				"type":        "string",
-				"description": "会话ID（用于cancel/output操作）",
+				"description": "会话ID（用于input/cancel/output操作）",
+			},
+			"input": map[string]interface{}{
+				"type":        "string",
+				"description": "写入会话标准输入的一行内容（input操作），自动追加换行",
 			},
 			"timeout": map[string]interface{}{
 				"type":        "number",
@@ -74,23 +147,56 @@ func (t *TerminalTool) Parameters() map[string]interface{} {
 				"type":        "boolean",
 				"description": "是否后台运行",
 			},
+			"rows": map[string]interface{}{
+				"type":        "number",
+				"description": "终端行数（run/resize操作），默认使用配置",
+			},
+			"cols": map[string]interface{}{
+				"type":        "number",
+				"description": "终端列数（run/resize操作），默认使用配置",
+			},
 		},
 		"required": []string{"action"},
 	}
 }
 
 func (t *TerminalTool) Execute(args map[string]interface{}) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext 执行终端操作，危险命令的确认请求发回调用者所在会话。
+// 聊天中的调用者只能查看和操作自己发起的会话
+func (t *TerminalTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (string, error) {
 	action, _ := args["action"].(string)
+	owner := ""
+	if c, ok := CallerFrom(ctx); ok {
+		owner = c.Channel + ":" + c.UserID
+	}
 
 	switch action {
 	case "list":
-		return t.listSessions()
+		return t.listSessions(owner)
 	case "cancel":
 		sessionID, _ := args["sessionId"].(string)
-		return t.cancelSession(sessionID)
+		return t.cancelSession(owner, sessionID)
 	case "output":
 		sessionID, _ := args["sessionId"].(string)
-		return t.getSessionOutput(sessionID)
+		return t.getSessionOutput(owner, sessionID)
+	case "input":
+		sessionID, _ := args["sessionId"].(string)
+		if sessionID == "" {
+			return "", fmt.Errorf("sessionId is required for input action")
+		}
+		input, _ := args["input"].(string)
+		// 先检查会话归属，其他用户的输入不会发出确认请求
+		if err := t.checkOwner(owner, sessionID); err != nil {
+			return "", err
+		}
+		// 输入可能是交给shell执行的命令，与 run 一样检查危险操作
+		if err := t.confirm(ctx, input); err != nil {
+			return "", err
+		}
+		return t.Input(owner, sessionID, input)
 	case "run":
 		command, _ := args["command"].(string)
 		if command == "" {
@@ -104,58 +210,126 @@ func (t *TerminalTool) Execute(args map[string]interface{}) (string, error) {
 		if b, ok := args["background"].(bool); ok {
 			background = b
 		}
-		return t.runCommand(command, timeout, background)
+		rows, cols := t.windowSize(args)
+		return t.runCommand(ctx, command, timeout, background, rows, cols)
+	case "resize":
+		sessionID, _ := args["sessionId"].(string)
+		if err := t.checkOwner(owner, sessionID); err != nil {
+			return "", err
+		}
+		rows, cols := t.windowSize(args)
+		return t.resizeSession(sessionID, rows, cols)
 	default:
 		return "", fmt.Errorf("unknown action: %s", action)
 	}
 }
 
-func (t *TerminalTool) runCommand(command string, timeout int, background bool) (string, error) {
+// confirm 按策略检查命令：deny 直接拒绝，confirm 时请求用户确认，确认请求发回调用者所在会话
+func (t *TerminalTool) confirm(ctx context.Context, command string) error {
 	cfg := t.manager.GetConfig()
-	if !cfg.TerminalEnabled {
-		return "", fmt.Errorf("terminal is disabled in config")
+
+	d := t.manager.evaluatePolicy(t.Name(), command)
+	switch d.Action {
+	case policy.Deny:
+		t.manager.log.Warn("operation denied by policy", "tool", t.Name(), "rule", d.Rule, "command", command)
+		return fmt.Errorf("operation denied by policy rule %s", d.Rule)
+	case policy.Confirm:
+	default:
+		return nil
+	}
+	if !cfg.ConfirmDangerous || cfg.UnattendedMode {
+		return nil
 	}
 
-	var blockedCommand string
-	for _, blocked := range cfg.BlockedCommands {
-		if strings.Contains(command, blocked) {
-			blockedCommand = blocked
-			break
-		}
+	return t.manager.requestConfirmation(ctx, t.Name(), command, nil, d)
+}
+
+// windowSize 读取参数中的终端窗口大小，未指定时使用配置，再缺省为 24x120
+func (t *TerminalTool) windowSize(args map[string]interface{}) (rows, cols int) {
+	cfg := t.manager.GetConfig()
+	rows, cols = cfg.TerminalRows, cfg.TerminalCols
+	if v, ok := args["rows"].(float64); ok && v > 0 {
+		rows = int(v)
+	}
+	if v, ok := args["cols"].(float64); ok && v > 0 {
+		cols = int(v)
+	}
+	if rows <= 0 {
+		rows = defaultTerminalRows
+	}
+	if cols <= 0 {
+		cols = defaultTerminalCols
 	}
+	return min(rows, 500), min(cols, 500)
+}
 
-	isDangerous := confirmation.IsDangerousOperation(command)
-	needsConfirmation := false
-	confirmationDetails := ""
-
-	if blockedCommand != "" {
-		needsConfirmation = true
-		confirmationDetails = fmt.Sprintf("命令包含黑名单命令: %s，需要用户确认", blockedCommand)
-	} else if isDangerous {
-		needsConfirmation = true
-		confirmationDetails = "危险命令需要用户确认"
-	}
-
-	if needsConfirmation {
-		if cfg.ConfirmDangerous && !cfg.UnattendedMode {
-			riskLevel := "high"
-			if blockedCommand != "" {
-				riskLevel = "critical"
-			}
-			approved, err := t.confirmMgr.RequestConfirmation(
-				context.Background(),
-				"terminal",
-				command,
-				confirmationDetails,
-				riskLevel,
-			)
-			if err != nil {
-				return "", fmt.Errorf("confirmation failed: %w", err)
-			}
-			if !approved {
-				return "", fmt.Errorf("operation rejected by user")
-			}
+// startSession 启动会话进程。系统支持时在伪终端中运行，交互程序会按终端方式工作；否则退回管道
+func (t *TerminalTool) startSession(session *TerminalSession, rows, cols int) error {
+	cmd := session.Cmd
+	master, slave, err := system.OpenPTY(rows, cols)
+	if err != nil {
+		if !errors.Is(err, system.ErrPTYUnsupported) {
+			t.manager.log.Warn("failed to open pty, falling back to pipes", "error", err)
+		}
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return fmt.Errorf("failed to create stdin pipe: %w", err)
 		}
+		session.Stdin = stdin
+		cmd.Stdout = session
+		cmd.Stderr = session
+		return cmd.Start()
+	}
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	system.SetControllingTerminal(cmd)
+	if os.Getenv("TERM") == "" {
+		cmd.Env = append(os.Environ(), "TERM=xterm")
+	}
+	err = cmd.Start()
+	slave.Close()
+	if err != nil {
+		master.Close()
+		return err
+	}
+
+	session.Stdin = master
+	session.pty = master
+	session.copied = make(chan struct{})
+	go func() {
+		// 进程退出且终端无人占用后读取返回EIO
+		io.Copy(session, master)
+		close(session.copied)
+	}()
+	return nil
+}
+
+// resizeSession 调整会话的终端窗口大小
+func (t *TerminalTool) resizeSession(sessionID string, rows, cols int) (string, error) {
+	t.mu.RLock()
+	session, ok := t.sessions[sessionID]
+	t.mu.RUnlock()
+
+	if !ok {
+		return "", fmt.Errorf("session not found: %s", sessionID)
+	}
+	if session.pty == nil {
+		return "", fmt.Errorf("session %s is not running in a pseudo-terminal", sessionID)
+	}
+	if err := system.SetPTYSize(session.pty, rows, cols); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Session %s resized to %dx%d", sessionID, cols, rows), nil
+}
+
+func (t *TerminalTool) runCommand(ctx context.Context, command string, timeout int, background bool, rows, cols int) (string, error) {
+	cfg := t.manager.GetConfig()
+	if !cfg.TerminalEnabled {
+		return "", fmt.Errorf("terminal is disabled in config")
+	}
+
+	if err := t.confirm(ctx, command); err != nil {
+		return "", err
 	}
 
 	sessionID := fmt.Sprintf("term_%d", time.Now().UnixNano())
@@ -167,47 +341,37 @@ func (t *TerminalTool) runCommand(command string, timeout int, background bool)
 		cmd = exec.Command("sh", "-c", command)
 	}
 
-	cmd.Dir = cfg.WorkDir
-
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return "", fmt.Errorf("failed to create stdin pipe: %w", err)
-	}
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return "", fmt.Errorf("failed to create stdout pipe: %w", err)
+	origin, _ := CallerFrom(ctx)
+	cmd.Dir = t.manager.homeDir(origin)
+	owner := ""
+	if origin.Channel != "" {
+		owner = origin.Channel + ":" + origin.UserID
 	}
-
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return "", fmt.Errorf("failed to create stderr pipe: %w", err)
-	}
-
 	session := &TerminalSession{
 		ID:        sessionID,
+		Command:   command,
+		Owner:     owner,
 		Cmd:       cmd,
-		Stdin:     stdin,
-		Stdout:    stdout,
-		Stderr:    stderr,
 		StartTime: time.Now(),
 		Running:   true,
+		origin:    origin,
+	}
+
+	if err := t.startSession(session, rows, cols); err != nil {
+		return "", fmt.Errorf("failed to start command: %w", err)
 	}
 
 	t.mu.Lock()
 	t.sessions[sessionID] = session
 	t.mu.Unlock()
 
-	if err := cmd.Start(); err != nil {
-		t.mu.Lock()
-		delete(t.sessions, sessionID)
-		t.mu.Unlock()
-		return "", fmt.Errorf("failed to start command: %w", err)
-	}
-
 	if background {
 		go t.monitorSession(session)
-		return fmt.Sprintf("Session started: %s\nUse 'output' action with sessionId to get output.", sessionID), nil
+		msg := fmt.Sprintf("Session started: %s\nUse 'output' action with sessionId to get output, 'input' action to answer prompts.", sessionID)
+		if origin.Target != "" && t.manager.notify != nil {
+			msg += "\nThe user will be notified with the exit status when it finishes, no need to poll."
+		}
+		return msg, nil
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
@@ -215,27 +379,24 @@ func (t *TerminalTool) runCommand(command string, timeout int, background bool)
 
 	done := make(chan error, 1)
 	go func() {
-		scanner := bufio.NewScanner(io.MultiReader(stdout, stderr))
-		for scanner.Scan() {
-			session.mu.Lock()
-			session.Output.WriteString(scanner.Text() + "\n")
-			session.mu.Unlock()
-		}
 		done <- cmd.Wait()
 	}()
 
 	select {
 	case <-ctx.Done():
 		cmd.Process.Kill()
-		session.mu.Lock()
-		session.Running = false
-		session.mu.Unlock()
-		return session.Output.String() + "\n[TIMEOUT]", nil
+		session.drain()
+		session.finish()
+		session.mu.RLock()
+		output := cleanTerminalOutput(session.Output.String())
+		session.mu.RUnlock()
+		return output + "\n[TIMEOUT]", nil
 	case err := <-done:
-		session.mu.Lock()
-		session.Running = false
-		session.mu.Unlock()
-		output := session.Output.String()
+		session.drain()
+		session.finish()
+		session.mu.RLock()
+		output := cleanTerminalOutput(session.Output.String())
+		session.mu.RUnlock()
 		if err != nil {
 			output += fmt.Sprintf("\n[EXIT ERROR: %v]", err)
 		}
@@ -247,20 +408,76 @@ func (t *TerminalTool) runCommand(command string, timeout int, background bool)
 }
 
 func (t *TerminalTool) monitorSession(session *TerminalSession) {
-	scanner := bufio.NewScanner(io.MultiReader(session.Stdout, session.Stderr))
-	for scanner.Scan() {
-		session.mu.Lock()
-		session.Output.WriteString(scanner.Text() + "\n")
-		session.mu.Unlock()
+	err := session.Cmd.Wait()
+	session.drain()
+	session.finish()
+	t.notifyFinished(session, err)
+}
+
+// notifyFinished 后台会话结束后通知发起会话的聊天，附带退出状态和输出末尾；被取消的会话不通知
+func (t *TerminalTool) notifyFinished(session *TerminalSession, waitErr error) {
+	send := t.manager.notify
+	session.mu.RLock()
+	origin, cancelled := session.origin, session.cancelled
+	output := cleanTerminalOutput(session.Output.String())
+	session.mu.RUnlock()
+	if send == nil || cancelled || origin.Channel == "" || origin.Target == "" {
+		return
 	}
 
-	session.Cmd.Wait()
-	session.mu.Lock()
-	session.Running = false
-	session.mu.Unlock()
+	status := "exit status 0"
+	if waitErr != nil {
+		status = waitErr.Error()
+	}
+	text := t.manager.tr(origin, "terminalFinished", i18n.Params{
+		"id":       session.ID,
+		"status":   status,
+		"duration": time.Since(session.StartTime).Round(time.Second).String(),
+		"command":  session.Command,
+	})
+	if tail := outputTail(output, notifyTailLines, notifyTailChars); tail != "" {
+		text += "\n\n" + tail
+	}
+	if err := send(origin.Channel, origin.Target, text); err != nil {
+		t.manager.log.Warn("failed to send terminal notification", "session", session.ID, "error", err)
+	}
+}
+
+// outputTail 返回输出的最后几行，且不超过 maxChars 个字符
+func outputTail(output string, lines, maxChars int) string {
+	output = strings.TrimRight(output, "\n")
+	parts := strings.Split(output, "\n")
+	if len(parts) > lines {
+		parts = parts[len(parts)-lines:]
+	}
+	tail := []rune(strings.Join(parts, "\n"))
+	if len(tail) > maxChars {
+		tail = tail[len(tail)-maxChars:]
+	}
+	return string(tail)
+}
+
+// ownedBy 会话能否由 owner 访问：owner 为空（网页和定时任务）或会话没有所有者时不限制
+func (s *TerminalSession) ownedBy(owner string) bool {
+	return owner == "" || s.Owner == "" || s.Owner == owner
+}
+
+// checkOwner 检查会话存在且可由 owner 访问
+func (t *TerminalTool) checkOwner(owner, sessionID string) error {
+	t.mu.RLock()
+	session, ok := t.sessions[sessionID]
+	t.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	if !session.ownedBy(owner) {
+		return fmt.Errorf("session %s belongs to another user", sessionID)
+	}
+	return nil
 }
 
-func (t *TerminalTool) cancelSession(sessionID string) (string, error) {
+func (t *TerminalTool) cancelSession(owner, sessionID string) (string, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
@@ -268,23 +485,32 @@ func (t *TerminalTool) cancelSession(sessionID string) (string, error) {
 	if !ok {
 		return "", fmt.Errorf("session not found: %s", sessionID)
 	}
+	if !session.ownedBy(owner) {
+		return "", fmt.Errorf("session %s belongs to another user", sessionID)
+	}
 
 	if !session.Running {
 		return "Session already completed", nil
 	}
 
+	// 先标记取消，避免后台监视协程把结束当作正常完成通知用户
+	session.mu.Lock()
+	session.cancelled = true
+	session.mu.Unlock()
 	if session.Cmd.Process != nil {
 		session.Cmd.Process.Kill()
 	}
 
-	session.Running = false
-	output := session.Output.String()
 	delete(t.sessions, sessionID)
+	session.finish()
+	session.mu.RLock()
+	output := cleanTerminalOutput(session.Output.String())
+	session.mu.RUnlock()
 
 	return output + "\n[SESSION CANCELLED]", nil
 }
 
-func (t *TerminalTool) getSessionOutput(sessionID string) (string, error) {
+func (t *TerminalTool) getSessionOutput(owner, sessionID string) (string, error) {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
@@ -292,6 +518,9 @@ func (t *TerminalTool) getSessionOutput(sessionID string) (string, error) {
 	if !ok {
 		return "", fmt.Errorf("session not found: %s", sessionID)
 	}
+	if !session.ownedBy(owner) {
+		return "", fmt.Errorf("session %s belongs to another user", sessionID)
+	}
 
 	session.mu.RLock()
 	defer session.mu.RUnlock()
@@ -304,20 +533,19 @@ func (t *TerminalTool) getSessionOutput(sessionID string) (string, error) {
 	return fmt.Sprintf("Status: %s\nDuration: %s\nOutput:\n%s",
 		status,
 		time.Since(session.StartTime).Round(time.Second),
-		session.Output.String()), nil
+		cleanTerminalOutput(session.Output.String())), nil
 }
 
-func (t *TerminalTool) listSessions() (string, error) {
+// listSessions 列出 owner 可以访问的会话
+func (t *TerminalTool) listSessions(owner string) (string, error) {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
-	if len(t.sessions) == 0 {
-		return "No active sessions", nil
-	}
-
 	var sb strings.Builder
-	sb.WriteString("Active sessions:\n")
 	for id, session := range t.sessions {
+		if !session.ownedBy(owner) {
+			continue
+		}
 		session.mu.RLock()
 		status := "running"
 		if !session.Running {
@@ -329,7 +557,10 @@ func (t *TerminalTool) listSessions() (string, error) {
 			time.Since(session.StartTime).Round(time.Second)))
 		session.mu.RUnlock()
 	}
-	return sb.String(), nil
+	if sb.Len() == 0 {
+		return "No active sessions", nil
+	}
+	return "Active sessions:\n" + sb.String(), nil
 }
 
 func (t *TerminalTool) Cleanup() {
@@ -356,10 +587,137 @@ func (t *TerminalTool) SendInput(sessionID, input string) error {
 		return fmt.Errorf("session not found: %s", sessionID)
 	}
 
-	if !session.Running {
+	session.mu.RLock()
+	running := session.Running
+	session.mu.RUnlock()
+	if !running {
 		return fmt.Errorf("session not running")
 	}
 
 	_, err := session.Stdin.Write([]byte(input + "\n"))
 	return err
 }
+
+// Input 向会话写入一行输入，等待片刻后返回新产生的输出。
+// owner 为发起输入的用户，会话有发起者时只允许本人输入；owner 为空（Web、定时任务）时不检查
+func (t *TerminalTool) Input(owner, sessionID, input string) (string, error) {
+	t.mu.RLock()
+	session, ok := t.sessions[sessionID]
+	t.mu.RUnlock()
+
+	if !ok {
+		return "", fmt.Errorf("session not found: %s", sessionID)
+	}
+	if !session.ownedBy(owner) {
+		return "", fmt.Errorf("session %s belongs to another user", sessionID)
+	}
+
+	from := session.outputLen()
+	if err := t.SendInput(sessionID, input); err != nil {
+		return "", err
+	}
+
+	deadline := time.Now().Add(t.inputWait)
+	for time.Now().Before(deadline) {
+		session.mu.RLock()
+		running := session.Running
+		session.mu.RUnlock()
+		if !running {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+	status := "running"
+	if !session.Running {
+		status = "completed"
+	}
+	output := cleanTerminalOutput(session.Output.String()[from:])
+	if output == "" {
+		output = "(no new output)"
+	}
+	return fmt.Sprintf("[%s] %s\n%s", sessionID, status, output), nil
+}
+
+// TerminalSessionInfo 终端会话概要
+type TerminalSessionInfo struct {
+	ID        string    `json:"id"`
+	Command   string    `json:"command"`
+	Owner     string    `json:"owner,omitempty"`
+	StartTime time.Time `json:"startTime"`
+	Running   bool      `json:"running"`
+	PTY       bool      `json:"pty"`
+}
+
+func (s *TerminalSession) info() TerminalSessionInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return TerminalSessionInfo{
+		ID:        s.ID,
+		Command:   s.Command,
+		Owner:     s.Owner,
+		StartTime: s.StartTime,
+		Running:   s.Running,
+		PTY:       s.pty != nil,
+	}
+}
+
+// Sessions 返回所有会话概要，按启动时间排序
+func (t *TerminalTool) Sessions() []TerminalSessionInfo {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	list := make([]TerminalSessionInfo, 0, len(t.sessions))
+	for _, session := range t.sessions {
+		list = append(list, session.info())
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].StartTime.Before(list[j].StartTime) })
+	return list
+}
+
+// Snapshot 返回会话概要和清理后的完整输出
+func (t *TerminalTool) Snapshot(sessionID string) (TerminalSessionInfo, string, error) {
+	t.mu.RLock()
+	session, ok := t.sessions[sessionID]
+	t.mu.RUnlock()
+
+	if !ok {
+		return TerminalSessionInfo{}, "", fmt.Errorf("session not found: %s", sessionID)
+	}
+	info := session.info()
+	session.mu.RLock()
+	defer session.mu.RUnlock()
+	return info, cleanTerminalOutput(session.Output.String()), nil
+}
+
+// Watch 订阅会话的输出和状态变化，返回的通道在有变化时收到通知，调用 stop 取消订阅
+func (t *TerminalTool) Watch(sessionID string) (changes <-chan struct{}, stop func(), err error) {
+	t.mu.RLock()
+	session, ok := t.sessions[sessionID]
+	t.mu.RUnlock()
+
+	if !ok {
+		return nil, nil, fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	ch := make(chan struct{}, 1)
+	session.mu.Lock()
+	if session.watchers == nil {
+		session.watchers = make(map[chan struct{}]struct{})
+	}
+	session.watchers[ch] = struct{}{}
+	session.mu.Unlock()
+
+	return ch, func() {
+		session.mu.Lock()
+		delete(session.watchers, ch)
+		session.mu.Unlock()
+	}, nil
+}
+
+// Cancel 终止会话并返回其输出
+func (t *TerminalTool) Cancel(sessionID string) (string, error) {
+	return t.cancelSession("", sessionID)
+}
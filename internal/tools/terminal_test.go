@@ -0,0 +1,358 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/confirmation"
+	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/system"
+)
+
+// rejectingNotifier 记录确认请求并立即拒绝
+type rejectingNotifier struct {
+	mgr *confirmation.ConfirmationManager
+	req confirmation.ConfirmationRequest
+}
+
+func (n *rejectingNotifier) Name() string { return "test" }
+
+func (n *rejectingNotifier) SendConfirmation(req *confirmation.ConfirmationRequest) error {
+	n.req = *req
+	return n.mgr.Reject(req.ID, "test")
+}
+
+func (n *rejectingNotifier) NotifyResult(req *confirmation.ConfirmationRequest, approved bool) {}
+
+func TestTerminalRegistration(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+
+	for _, enabled := range []bool{false, true} {
+		m, err := NewManager(Config{WorkDir: t.TempDir(), TerminalEnabled: enabled}, log)
+		if err != nil {
+			t.Fatalf("NewManager: %v", err)
+		}
+		if _, ok := m.Get("terminal"); ok != enabled {
+			t.Errorf("terminalEnabled=%v: registered=%v", enabled, ok)
+		}
+	}
+}
+
+func TestTerminalDangerousCommandConfirmation(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	t.Cleanup(func() { log.Close() })
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json5")
+	if err := os.WriteFile(configPath, []byte(`{"llm": {"provider": "ollama"}}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := config.NewManager(configPath, log)
+	if err != nil {
+		t.Fatalf("failed to create config manager: %v", err)
+	}
+	t.Cleanup(func() { cfg.Close() })
+
+	cm := confirmation.NewConfirmationManager(cfg, log)
+	notifier := &rejectingNotifier{mgr: cm}
+	cm.RegisterNotifier(notifier)
+
+	tests := []struct {
+		name       string
+		confirmMgr *confirmation.ConfirmationManager
+		wantErr    string
+	}{
+		{"no confirmation manager", nil, "未配置确认渠道"},
+		{"rejected", cm, "operation rejected by user"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workDir := t.TempDir()
+			target := filepath.Join(workDir, "keep.txt")
+			if err := os.WriteFile(target, []byte("x"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := NewManager(Config{
+				WorkDir:          workDir,
+				ConfirmDangerous: true,
+				TerminalEnabled:  true,
+				ConfirmMgr:       tt.confirmMgr,
+			}, log)
+			if err != nil {
+				t.Fatalf("NewManager: %v", err)
+			}
+
+			ctx := WithCaller(context.Background(), Caller{Channel: "telegram", UserID: "42", Target: "42"})
+			_, err = m.Execute(ctx, "terminal", map[string]interface{}{"action": "run", "command": "rm -rf keep.txt"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if _, err := os.Stat(target); err != nil {
+				t.Errorf("command ran without approval: %v", err)
+			}
+		})
+	}
+
+	if notifier.req.Channel != "telegram" || notifier.req.UserID != "42" || notifier.req.Target != "42" {
+		t.Errorf("request origin = %q/%q/%q, want telegram/42/42", notifier.req.Channel, notifier.req.UserID, notifier.req.Target)
+	}
+}
+
+func TestTerminalInput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	log, _ := logger.New(logger.Config{Level: "error"})
+
+	configPath := filepath.Join(t.TempDir(), "config.json5")
+	if err := os.WriteFile(configPath, []byte(`{"llm": {"provider": "ollama"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.NewManager(configPath, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cfg.Close() })
+	cm := confirmation.NewConfirmationManager(cfg, log)
+	notifier := &rejectingNotifier{mgr: cm}
+	cm.RegisterNotifier(notifier)
+
+	m, err := NewManager(Config{WorkDir: t.TempDir(), TerminalEnabled: true, ConfirmDangerous: true, ConfirmMgr: cm}, log)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	tool, _ := m.Get("terminal")
+	term := tool.(*TerminalTool)
+
+	ctx := WithCaller(context.Background(), Caller{Channel: "telegram", UserID: "42", Target: "42"})
+	out, err := m.Execute(ctx, "terminal", map[string]interface{}{
+		"action":     "run",
+		"command":    `printf 'Continue? [Y/n] '; read a; echo "got $a"`,
+		"background": true,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	id := regexp.MustCompile(`term_\d+`).FindString(out)
+	if id == "" {
+		t.Fatalf("no session id in %q", out)
+	}
+
+	// 没有换行的提示也应立即可见
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		out, _ = term.getSessionOutput("", id)
+		if strings.Contains(out, "[Y/n]") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("prompt not shown: %q", out)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if _, err := term.Input("telegram:7", id, "y"); err == nil {
+		t.Error("input from another user was accepted")
+	}
+
+	// 其他用户看不到也不能读取、取消会话，危险输入在发出确认请求前就被拒绝
+	other := WithCaller(context.Background(), Caller{Channel: "telegram", UserID: "7", Target: "7"})
+	if out, err := m.Execute(other, "terminal", map[string]interface{}{"action": "list"}); err != nil || strings.Contains(out, id) {
+		t.Errorf("list for another user = %q, %v", out, err)
+	}
+	for _, action := range []string{"output", "cancel", "resize"} {
+		if _, err := m.Execute(other, "terminal", map[string]interface{}{"action": action, "sessionId": id}); err == nil || !strings.Contains(err.Error(), "another user") {
+			t.Errorf("%s by another user error = %v", action, err)
+		}
+	}
+	if _, err := m.Execute(other, "terminal", map[string]interface{}{"action": "input", "sessionId": id, "input": "rm -rf /tmp/x"}); err == nil || !strings.Contains(err.Error(), "another user") {
+		t.Errorf("dangerous input by another user error = %v", err)
+	}
+	if notifier.req.ID != "" {
+		t.Errorf("confirmation sent for another user's input: %+v", notifier.req)
+	}
+	if out, err := m.Execute(ctx, "terminal", map[string]interface{}{"action": "list"}); err != nil || !strings.Contains(out, id) {
+		t.Errorf("list for owner = %q, %v", out, err)
+	}
+
+	out, err = m.Execute(ctx, "terminal", map[string]interface{}{"action": "input", "sessionId": id, "input": "y"})
+	if err != nil {
+		t.Fatalf("input: %v", err)
+	}
+	if !strings.Contains(out, "got y") {
+		t.Errorf("output = %q, want answer echoed", out)
+	}
+}
+
+func TestTerminalPTY(t *testing.T) {
+	master, slave, err := system.OpenPTY(24, 80)
+	if err != nil {
+		t.Skipf("pty not available: %v", err)
+	}
+	master.Close()
+	slave.Close()
+
+	log, _ := logger.New(logger.Config{Level: "error"})
+	m, err := NewManager(Config{WorkDir: t.TempDir(), TerminalEnabled: true, TerminalRows: 30, TerminalCols: 100}, log)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want string
+	}{
+		{"config size", map[string]interface{}{}, "30 100"},
+		{"explicit size", map[string]interface{}{"rows": float64(40), "cols": float64(132)}, "40 132"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := map[string]interface{}{
+				"action":  "run",
+				"command": `test -t 1 && echo "tty=yes"; stty size; printf '\033[1;32mgreen\033[0m\n'`,
+			}
+			for k, v := range tt.args {
+				args[k] = v
+			}
+			out, err := m.Execute(context.Background(), "terminal", args)
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			for _, want := range []string{"tty=yes", tt.want, "green"} {
+				if !strings.Contains(out, want) {
+					t.Errorf("output %q missing %q", out, want)
+				}
+			}
+			if strings.ContainsAny(out, "\x1b\r") {
+				t.Errorf("output not cleaned: %q", out)
+			}
+		})
+	}
+}
+
+func TestTerminalWatch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	log, _ := logger.New(logger.Config{Level: "error"})
+
+	m, err := NewManager(Config{WorkDir: t.TempDir(), TerminalEnabled: true}, log)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	tool, _ := m.Get("terminal")
+	term := tool.(*TerminalTool)
+
+	out, err := m.Execute(context.Background(), "terminal", map[string]interface{}{
+		"action":     "run",
+		"command":    "read line; echo \"hello $line\"",
+		"background": true,
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	id := regexp.MustCompile(`term_\d+`).FindString(out)
+
+	sessions := term.Sessions()
+	if len(sessions) != 1 || sessions[0].ID != id || !sessions[0].Running || sessions[0].Command == "" {
+		t.Fatalf("Sessions() = %+v", sessions)
+	}
+
+	changes, stop, err := term.Watch(id)
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	defer stop()
+
+	if err := term.SendInput(id, "web"); err != nil {
+		t.Fatalf("SendInput: %v", err)
+	}
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case <-changes:
+		case <-timeout:
+			t.Fatal("session did not finish")
+		}
+		info, output, err := term.Snapshot(id)
+		if err != nil {
+			t.Fatalf("Snapshot: %v", err)
+		}
+		if !info.Running {
+			if !strings.Contains(output, "hello web") {
+				t.Errorf("output = %q", output)
+			}
+			return
+		}
+	}
+}
+
+func TestTerminalBackgroundNotification(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	log, _ := logger.New(logger.Config{Level: "error"})
+
+	m, err := NewManager(Config{WorkDir: t.TempDir(), TerminalEnabled: true}, log)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	type message struct{ channel, target, text string }
+	sent := make(chan message, 4)
+	m.SetNotifier(func(channel, target, text string) error {
+		sent <- message{channel, target, text}
+		return nil
+	})
+
+	ctx := WithCaller(context.Background(), Caller{Channel: "telegram", UserID: "42", Target: "100"})
+	run := func(command string) string {
+		out, err := m.Execute(ctx, "terminal", map[string]interface{}{"action": "run", "command": command, "background": true})
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		return regexp.MustCompile(`term_\d+`).FindString(out)
+	}
+
+	// 被取消的会话不通知
+	cancelled := run("sleep 30")
+	tool, _ := m.Get("terminal")
+	if _, err := tool.(*TerminalTool).Cancel(cancelled); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	id := run("seq 1 30; exit 3")
+	select {
+	case msg := <-sent:
+		if msg.channel != "telegram" || msg.target != "100" {
+			t.Errorf("sent to %s:%s, want telegram:100", msg.channel, msg.target)
+		}
+		for _, want := range []string{id, "exit status 3", "$ seq 1 30; exit 3", "\n30"} {
+			if !strings.Contains(msg.text, want) {
+				t.Errorf("notification %q missing %q", msg.text, want)
+			}
+		}
+		if strings.Contains(msg.text, "\n10\n") {
+			t.Errorf("notification should only include the tail: %q", msg.text)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification")
+	}
+
+	select {
+	case msg := <-sent:
+		t.Errorf("unexpected notification: %q", msg.text)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+// sleepTool 按 mode 模拟不同行为的工具
+type sleepTool struct {
+	release chan struct{}
+}
+
+func (sleepTool) Name() string                       { return "sleep" }
+func (sleepTool) Description() string                { return "" }
+func (sleepTool) Parameters() map[string]interface{} { return nil }
+
+func (t sleepTool) Execute(args map[string]interface{}) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+func (t sleepTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (string, error) {
+	switch args["mode"] {
+	case "ignore":
+		// 不理会 ctx，直到测试结束才返回
+		<-t.release
+		return "late", nil
+	case "ctx":
+		<-ctx.Done()
+		return "", ctx.Err()
+	case "panic":
+		panic("boom")
+	}
+	return "ok", nil
+}
+
+func TestExecuteTimeout(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	m, err := NewManager(Config{WorkDir: t.TempDir(), Timeout: 30, ToolTimeouts: map[string]int{"archive": 7}}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want time.Duration
+	}{
+		{"execute_command", 30 * time.Second},
+		{"archive", 7 * time.Second},
+		{"download_file", 2 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := m.timeoutFor(tt.name); got != tt.want {
+			t.Errorf("timeoutFor(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	m.Register(sleepTool{release: release})
+	m.timeout = 50 * time.Millisecond
+	ctx := context.Background()
+
+	if result, err := m.Execute(ctx, "sleep", map[string]interface{}{}); err != nil || result != "ok" {
+		t.Errorf("fast tool = %q, %v", result, err)
+	}
+
+	for _, mode := range []string{"ignore", "ctx"} {
+		start := time.Now()
+		_, err := m.Execute(ctx, "sleep", map[string]interface{}{"mode": mode})
+		if !errors.Is(err, ErrToolTimeout) || !strings.Contains(err.Error(), "sleep did not finish within 50ms") {
+			t.Errorf("%s: error = %v", mode, err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("%s: returned after %v", mode, elapsed)
+		}
+	}
+
+	// 调用方取消不算超时
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := m.Execute(cancelled, "sleep", map[string]interface{}{"mode": "ctx"}); !errors.Is(err, context.Canceled) || errors.Is(err, ErrToolTimeout) {
+		t.Errorf("cancelled error = %v", err)
+	}
+
+	if _, err := m.Execute(ctx, "sleep", map[string]interface{}{"mode": "panic"}); err == nil || !strings.Contains(err.Error(), "panicked: boom") {
+		t.Errorf("panic error = %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/todo"
+)
+
+// errNoCaller 没有聊天用户身份时（如定时任务）无法使用待办
+var errNoCaller = errors.New("todo tools are only available in chat conversations")
+
+// todoOwner 从context获取待办所属用户
+func todoOwner(ctx context.Context) (todo.Owner, error) {
+	c, ok := CallerFrom(ctx)
+	if !ok {
+		return todo.Owner{}, errNoCaller
+	}
+	return todo.Owner{Channel: c.Channel, UserID: c.UserID, Target: c.Target}, nil
+}
+
+// TodoAddTool 添加待办
+type TodoAddTool struct {
+	manager *Manager
+	store   *todo.Store
+}
+
+func (t *TodoAddTool) Name() string {
+	return "todo_add"
+}
+
+func (t *TodoAddTool) Description() string {
+	return "添加待办事项。设置 due 后会在到期时主动提醒用户。"
+}
+
+func (t *TodoAddTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"text": map[string]interface{}{
+				"type":        "string",
+				"description": "待办内容",
+			},
+			"due": map[string]interface{}{
+				"type":        "string",
+				"description": "截止/提醒时间，如 2024-05-01 14:30、tomorrow、in 2 hours、next monday",
+			},
+			"timezone": map[string]interface{}{
+				"type":        "string",
+				"description": "due 的时区，默认本机时区",
+			},
+		},
+		"required": []string{"text"},
+	}
+}
+
+func (t *TodoAddTool) Execute(args map[string]interface{}) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+func (t *TodoAddTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (string, error) {
+	owner, err := todoOwner(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	text, _ := args["text"].(string)
+
+	var due time.Time
+	if s, _ := args["due"].(string); strings.TrimSpace(s) != "" {
+		tz, _ := args["timezone"].(string)
+		loc, err := loadLocation(strings.TrimSpace(tz))
+		if err != nil {
+			return "", err
+		}
+		due, err = resolveDate(s, time.Now().In(loc))
+		if err != nil {
+			return "", fmt.Errorf("invalid due time: %w", err)
+		}
+		if due.Before(time.Now()) {
+			return "", fmt.Errorf("due time %s is in the past", due.Format("2006-01-02 15:04"))
+		}
+	}
+
+	item, err := t.store.Add(owner, text, due)
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("Added todo #%d: %s", item.ID, item.Text)
+	if !item.Due.IsZero() {
+		result += fmt.Sprintf(" (due %s, a reminder will be sent)", item.Due.Format("2006-01-02 15:04 Mon MST"))
+	}
+	return result, nil
+}
+
+// TodoListTool 列出待办
+type TodoListTool struct {
+	manager *Manager
+	store   *todo.Store
+}
+
+func (t *TodoListTool) Name() string {
+	return "todo_list"
+}
+
+func (t *TodoListTool) Description() string {
+	return "列出用户的待办事项。"
+}
+
+func (t *TodoListTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"include_done": map[string]interface{}{
+				"type":        "boolean",
+				"description": "是否包含已完成的事项",
+			},
+		},
+	}
+}
+
+func (t *TodoListTool) Execute(args map[string]interface{}) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+func (t *TodoListTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (string, error) {
+	owner, err := todoOwner(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	includeDone, _ := args["include_done"].(bool)
+	items := t.store.List(owner, includeDone)
+	if len(items) == 0 {
+		return "No todos.", nil
+	}
+
+	var sb strings.Builder
+	now := time.Now()
+	for _, item := range items {
+		mark := "[ ]"
+		if item.Done {
+			mark = "[x]"
+		}
+		fmt.Fprintf(&sb, "#%d %s %s", item.ID, mark, item.Text)
+		if !item.Due.IsZero() {
+			fmt.Fprintf(&sb, " (due %s", item.Due.Local().Format("2006-01-02 15:04 Mon"))
+			if !item.Done && item.Due.Before(now) {
+				sb.WriteString(", overdue")
+			}
+			sb.WriteString(")")
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
+
+// TodoDoneTool 完成待办
+type TodoDoneTool struct {
+	manager *Manager
+	store   *todo.Store
+}
+
+func (t *TodoDoneTool) Name() string {
+	return "todo_done"
+}
+
+func (t *TodoDoneTool) Description() string {
+	return "将待办事项标记为已完成。"
+}
+
+func (t *TodoDoneTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{
+				"type":        "integer",
+				"description": "待办编号",
+			},
+		},
+		"required": []string{"id"},
+	}
+}
+
+func (t *TodoDoneTool) Execute(args map[string]interface{}) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+func (t *TodoDoneTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (string, error) {
+	owner, err := todoOwner(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	id, ok := args["id"].(float64)
+	if !ok {
+		return "", fmt.Errorf("id is required")
+	}
+
+	item, err := t.store.Complete(owner, int(id))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Completed todo #%d: %s", item.ID, item.Text), nil
+}
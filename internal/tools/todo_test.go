@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/todo"
+)
+
+func TestTodoTools(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	store, err := todo.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewManager(Config{WorkDir: t.TempDir(), Todos: store}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 没有调用者身份时不可用
+	if _, err := m.Execute(context.Background(), "todo_add", map[string]interface{}{"text": "x"}); err == nil {
+		t.Error("todo_add without caller should fail")
+	}
+
+	ctx := WithCaller(context.Background(), Caller{Channel: "telegram", UserID: "1", Target: "100"})
+	result, err := m.Execute(ctx, "todo_add", map[string]interface{}{"text": "water plants", "due": "in 2 hours"})
+	if err != nil || !strings.Contains(result, "#1") || !strings.Contains(result, "reminder") {
+		t.Fatalf("todo_add = %q, %v", result, err)
+	}
+	if _, err := m.Execute(ctx, "todo_add", map[string]interface{}{"text": "late", "due": "2 days ago"}); err == nil {
+		t.Error("due time in the past should be rejected")
+	}
+
+	other := WithCaller(context.Background(), Caller{Channel: "telegram", UserID: "2"})
+	if result, _ := m.Execute(other, "todo_list", map[string]interface{}{}); result != "No todos." {
+		t.Errorf("other user's todo_list = %q", result)
+	}
+
+	if _, err := m.Execute(ctx, "todo_done", map[string]interface{}{"id": float64(1)}); err != nil {
+		t.Fatalf("todo_done error = %v", err)
+	}
+	result, _ = m.Execute(ctx, "todo_list", map[string]interface{}{"include_done": true})
+	if !strings.HasPrefix(result, "#1 [x] water plants") {
+		t.Errorf("todo_list = %q", result)
+	}
+}
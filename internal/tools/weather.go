@@ -0,0 +1,485 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/httpclient"
+)
+
+const (
+	openMeteoGeocodeURL  = "https://geocoding-api.open-meteo.com/v1/search"
+	openMeteoForecastURL = "https://api.open-meteo.com/v1/forecast"
+	wttrURL              = "https://wttr.in"
+
+	// weatherCacheTTL 同一城市的天气缓存时间
+	weatherCacheTTL = 10 * time.Minute
+	maxForecastDays = 7
+)
+
+// WeatherTool 天气查询工具，使用 Open-Meteo 数据整理为简要摘要，raw 时返回接口原始JSON，失败时回退到 wttr.in
+type WeatherTool struct {
+	manager  *Manager
+	settings WeatherSettings
+
+	// 测试时可替换的接口地址
+	geocodeURL  string
+	forecastURL string
+	fallbackURL string
+
+	mu    sync.Mutex
+	cache map[string]weatherCacheEntry
+}
+
+type weatherCacheEntry struct {
+	report  *weatherReport
+	raw     string
+	expires time.Time
+}
+
+// weatherReport 从 Open-Meteo 响应整理出的天气数据
+type weatherReport struct {
+	Location weatherLocation `json:"location"`
+	Current  weatherCurrent  `json:"current"`
+	Daily    []weatherDay    `json:"daily"`
+	Units    weatherUnits    `json:"units"`
+}
+
+type weatherLocation struct {
+	Name      string  `json:"name"`
+	Region    string  `json:"region,omitempty"`
+	Country   string  `json:"country,omitempty"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Timezone  string  `json:"timezone,omitempty"`
+}
+
+type weatherCurrent struct {
+	Time          string  `json:"time"`
+	Temperature   float64 `json:"temperature"`
+	FeelsLike     float64 `json:"feels_like"`
+	Humidity      float64 `json:"humidity"`
+	Precipitation float64 `json:"precipitation"`
+	WindSpeed     float64 `json:"wind_speed"`
+	WindDirection float64 `json:"wind_direction"`
+	Condition     string  `json:"condition"`
+}
+
+type weatherDay struct {
+	Date                     string  `json:"date"`
+	Condition                string  `json:"condition"`
+	TempMax                  float64 `json:"temp_max"`
+	TempMin                  float64 `json:"temp_min"`
+	PrecipitationSum         float64 `json:"precipitation_sum"`
+	PrecipitationProbability float64 `json:"precipitation_probability"`
+}
+
+type weatherUnits struct {
+	Temperature   string `json:"temperature"`
+	Precipitation string `json:"precipitation"`
+	WindSpeed     string `json:"wind_speed"`
+	Humidity      string `json:"humidity"`
+}
+
+func (t *WeatherTool) Name() string {
+	return "weather"
+}
+
+func (t *WeatherTool) Description() string {
+	return "查询城市天气。返回当前天气和未来几天预报的简要摘要（Open-Meteo，无需API密钥）。"
+}
+
+func (t *WeatherTool) Parameters() map[string]interface{} {
+	city := "城市名称，如 Beijing, Shanghai, Tokyo"
+	required := []string{"city"}
+	if t.settings.DefaultCity != "" {
+		city += "，未指定时为 " + t.settings.DefaultCity
+		required = nil
+	}
+	params := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"city": map[string]interface{}{
+				"type":        "string",
+				"description": city,
+			},
+			"days": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("预报天数（1-%d，默认%d）", maxForecastDays, t.settings.days()),
+			},
+			"units": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"metric", "imperial"},
+				"description": "单位制，默认用户资料中的单位制，未设置时为 " + t.settings.units(),
+			},
+			"raw": map[string]interface{}{
+				"type":        "boolean",
+				"description": "返回 Open-Meteo 原始JSON，默认返回整理后的摘要",
+			},
+		},
+	}
+	if required != nil {
+		params["required"] = required
+	}
+	return params
+}
+
+func (t *WeatherTool) Execute(args map[string]interface{}) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+func (t *WeatherTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (string, error) {
+	city, _ := args["city"].(string)
+	city = strings.TrimSpace(city)
+	if city == "" {
+		city = t.settings.DefaultCity
+	}
+	if city == "" {
+		return "", fmt.Errorf("city is required")
+	}
+
+	days := t.settings.days()
+	if d, ok := args["days"].(float64); ok && d >= 1 {
+		days = int(d)
+	}
+	if days > maxForecastDays {
+		days = maxForecastDays
+	}
+
+	units, _ := args["units"].(string)
+	if units == "" {
+		units = t.manager.callerProfile(ctx).Units
+	}
+	if units == "" {
+		units = t.settings.units()
+	}
+	imperial := strings.EqualFold(units, "imperial")
+
+	raw, _ := args["raw"].(bool)
+
+	key := fmt.Sprintf("%s|%d|%t", strings.ToLower(city), days, imperial)
+	entry, ok := t.cached(key)
+	if !ok {
+		report, body, err := t.openMeteo(city, days, imperial)
+		if err != nil {
+			// Open-Meteo 不可用时回退到 wttr.in 文本
+			text, fallbackErr := t.wttr(city, imperial)
+			if fallbackErr != nil {
+				return "", err
+			}
+			return text, nil
+		}
+		entry = weatherCacheEntry{report: report, raw: body}
+		t.store(key, entry)
+	}
+
+	if raw {
+		return entry.raw, nil
+	}
+	return entry.report.summary(), nil
+}
+
+// cached 读取未过期的缓存
+func (t *WeatherTool) cached(key string) (weatherCacheEntry, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.cache[key]
+	if !ok || time.Now().After(entry.expires) {
+		return weatherCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// store 写入缓存并清理过期项
+func (t *WeatherTool) store(key string, entry weatherCacheEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cache == nil {
+		t.cache = make(map[string]weatherCacheEntry)
+	}
+	now := time.Now()
+	for k, e := range t.cache {
+		if now.After(e.expires) {
+			delete(t.cache, k)
+		}
+	}
+	entry.expires = now.Add(weatherCacheTTL)
+	t.cache[key] = entry
+}
+
+// openMeteo 地理编码后查询天气，返回整理后的数据和原始响应
+func (t *WeatherTool) openMeteo(city string, days int, imperial bool) (*weatherReport, string, error) {
+	loc, err := t.geocode(city)
+	if err != nil {
+		return nil, "", err
+	}
+
+	params := url.Values{}
+	params.Set("latitude", fmt.Sprintf("%.4f", loc.Latitude))
+	params.Set("longitude", fmt.Sprintf("%.4f", loc.Longitude))
+	params.Set("current", "temperature_2m,relative_humidity_2m,apparent_temperature,precipitation,weather_code,wind_speed_10m,wind_direction_10m")
+	params.Set("daily", "weather_code,temperature_2m_max,temperature_2m_min,precipitation_sum,precipitation_probability_max")
+	params.Set("timezone", "auto")
+	params.Set("forecast_days", fmt.Sprint(days))
+	if imperial {
+		params.Set("temperature_unit", "fahrenheit")
+		params.Set("wind_speed_unit", "mph")
+		params.Set("precipitation_unit", "inch")
+	}
+
+	var data struct {
+		Timezone     string `json:"timezone"`
+		CurrentUnits struct {
+			Temperature   string `json:"temperature_2m"`
+			Humidity      string `json:"relative_humidity_2m"`
+			Precipitation string `json:"precipitation"`
+			WindSpeed     string `json:"wind_speed_10m"`
+		} `json:"current_units"`
+		Current struct {
+			Time          string  `json:"time"`
+			Temperature   float64 `json:"temperature_2m"`
+			Humidity      float64 `json:"relative_humidity_2m"`
+			FeelsLike     float64 `json:"apparent_temperature"`
+			Precipitation float64 `json:"precipitation"`
+			WeatherCode   int     `json:"weather_code"`
+			WindSpeed     float64 `json:"wind_speed_10m"`
+			WindDirection float64 `json:"wind_direction_10m"`
+		} `json:"current"`
+		Daily struct {
+			Time                     []string  `json:"time"`
+			WeatherCode              []int     `json:"weather_code"`
+			TempMax                  []float64 `json:"temperature_2m_max"`
+			TempMin                  []float64 `json:"temperature_2m_min"`
+			PrecipitationSum         []float64 `json:"precipitation_sum"`
+			PrecipitationProbability []float64 `json:"precipitation_probability_max"`
+		} `json:"daily"`
+	}
+	body, err := fetchBody(t.endpoint(t.forecastURL, openMeteoForecastURL) + "?" + params.Encode())
+	if err != nil {
+		return nil, "", fmt.Errorf("weather request failed: %w", err)
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, "", fmt.Errorf("failed to parse weather response: %w", err)
+	}
+
+	if data.Timezone != "" {
+		loc.Timezone = data.Timezone
+	}
+	report := weatherReport{
+		Location: *loc,
+		Current: weatherCurrent{
+			Time:          data.Current.Time,
+			Temperature:   data.Current.Temperature,
+			FeelsLike:     data.Current.FeelsLike,
+			Humidity:      data.Current.Humidity,
+			Precipitation: data.Current.Precipitation,
+			WindSpeed:     data.Current.WindSpeed,
+			WindDirection: data.Current.WindDirection,
+			Condition:     weatherCondition(data.Current.WeatherCode),
+		},
+		Units: weatherUnits{
+			Temperature:   data.CurrentUnits.Temperature,
+			Precipitation: data.CurrentUnits.Precipitation,
+			WindSpeed:     data.CurrentUnits.WindSpeed,
+			Humidity:      data.CurrentUnits.Humidity,
+		},
+	}
+
+	d := data.Daily
+	for i, date := range d.Time {
+		day := weatherDay{Date: date}
+		if i < len(d.WeatherCode) {
+			day.Condition = weatherCondition(d.WeatherCode[i])
+		}
+		if i < len(d.TempMax) {
+			day.TempMax = d.TempMax[i]
+		}
+		if i < len(d.TempMin) {
+			day.TempMin = d.TempMin[i]
+		}
+		if i < len(d.PrecipitationSum) {
+			day.PrecipitationSum = d.PrecipitationSum[i]
+		}
+		if i < len(d.PrecipitationProbability) {
+			day.PrecipitationProbability = d.PrecipitationProbability[i]
+		}
+		report.Daily = append(report.Daily, day)
+	}
+	return &report, string(body), nil
+}
+
+// summary 整理为几行文本：地点、当前天气和每日预报
+func (r *weatherReport) summary() string {
+	u := r.Units
+	var sb strings.Builder
+	sb.WriteString(joinNonEmpty(", ", r.Location.Name, r.Location.Region, r.Location.Country))
+	if r.Location.Timezone != "" {
+		sb.WriteString(" (" + r.Location.Timezone + ")")
+	}
+
+	c := r.Current
+	fmt.Fprintf(&sb, "\nNow (%s): %s, %g%s, feels like %g%s, humidity %g%s, wind %g %s %s, precipitation %g %s",
+		c.Time, c.Condition, c.Temperature, u.Temperature, c.FeelsLike, u.Temperature, c.Humidity, u.Humidity,
+		c.WindSpeed, u.WindSpeed, compassDirection(c.WindDirection), c.Precipitation, u.Precipitation)
+
+	for _, d := range r.Daily {
+		fmt.Fprintf(&sb, "\n%s: %s, %g~%g%s, precipitation %g %s (%g%%)",
+			d.Date, d.Condition, d.TempMin, d.TempMax, u.Temperature, d.PrecipitationSum, u.Precipitation, d.PrecipitationProbability)
+	}
+	return sb.String()
+}
+
+// compassDirection 将风向角度转换为八方位
+func compassDirection(deg float64) string {
+	dirs := []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+	i := int(math.Mod(deg+22.5+360, 360) / 45)
+	return dirs[i%len(dirs)]
+}
+
+// geocode 将城市名解析为坐标
+func (t *WeatherTool) geocode(city string) (*weatherLocation, error) {
+	params := url.Values{}
+	params.Set("name", city)
+	params.Set("count", "1")
+	params.Set("format", "json")
+
+	var data struct {
+		Results []struct {
+			Name      string  `json:"name"`
+			Latitude  float64 `json:"latitude"`
+			Longitude float64 `json:"longitude"`
+			Country   string  `json:"country"`
+			Admin1    string  `json:"admin1"`
+			Timezone  string  `json:"timezone"`
+		} `json:"results"`
+	}
+	if err := getJSON(t.endpoint(t.geocodeURL, openMeteoGeocodeURL)+"?"+params.Encode(), &data); err != nil {
+		return nil, fmt.Errorf("geocoding failed: %w", err)
+	}
+	if len(data.Results) == 0 {
+		return nil, fmt.Errorf("city not found: %s", city)
+	}
+
+	r := data.Results[0]
+	return &weatherLocation{
+		Name:      r.Name,
+		Region:    r.Admin1,
+		Country:   r.Country,
+		Latitude:  r.Latitude,
+		Longitude: r.Longitude,
+		Timezone:  r.Timezone,
+	}, nil
+}
+
+// wttr 使用 wttr.in 查询简要天气文本
+func (t *WeatherTool) wttr(city string, imperial bool) (string, error) {
+	u := fmt.Sprintf("%s/%s?format=3&lang=zh", t.endpoint(t.fallbackURL, wttrURL), url.PathEscape(city))
+	if imperial {
+		u += "&u"
+	}
+
+	client := httpclient.New(10 * time.Second)
+	resp, err := client.Get(u)
+	if err != nil {
+		return "", fmt.Errorf("weather request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("weather API returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", fmt.Errorf("failed to read weather response: %w", err)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+func (t *WeatherTool) endpoint(override, fallback string) string {
+	if override != "" {
+		return override
+	}
+	return fallback
+}
+
+// getJSON 发送GET请求并解析JSON响应
+func getJSON(u string, v interface{}) error {
+	body, err := fetchBody(u)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// fetchBody 发送GET请求并读取响应体（最多1MB），非200状态返回带响应开头的错误
+func fetchBody(u string) ([]byte, error) {
+	client := httpclient.New(10 * time.Second)
+	resp, err := client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+}
+
+// weatherCondition 将 WMO 天气代码转换为描述
+func weatherCondition(code int) string {
+	switch code {
+	case 0:
+		return "clear sky"
+	case 1:
+		return "mainly clear"
+	case 2:
+		return "partly cloudy"
+	case 3:
+		return "overcast"
+	case 45, 48:
+		return "fog"
+	case 51, 53, 55:
+		return "drizzle"
+	case 56, 57:
+		return "freezing drizzle"
+	case 61:
+		return "light rain"
+	case 63:
+		return "rain"
+	case 65:
+		return "heavy rain"
+	case 66, 67:
+		return "freezing rain"
+	case 71:
+		return "light snow"
+	case 73:
+		return "snow"
+	case 75:
+		return "heavy snow"
+	case 77:
+		return "snow grains"
+	case 80, 81, 82:
+		return "rain showers"
+	case 85, 86:
+		return "snow showers"
+	case 95:
+		return "thunderstorm"
+	case 96, 99:
+		return "thunderstorm with hail"
+	default:
+		return fmt.Sprintf("unknown (%d)", code)
+	}
+}
@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWeatherTool(t *testing.T) {
+	var geocodes, forecasts int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/geocode", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&geocodes, 1)
+		if r.URL.Query().Get("name") == "Nowhere" {
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.Write([]byte(`{"results":[{"name":"Beijing","latitude":39.9,"longitude":116.4,"country":"China","admin1":"Beijing","timezone":"Asia/Shanghai"}]}`))
+	})
+	mux.HandleFunc("/forecast", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&forecasts, 1)
+		if got := r.URL.Query().Get("forecast_days"); got != "2" {
+			t.Errorf("forecast_days = %q, want 2", got)
+		}
+		w.Write([]byte(`{
+			"timezone": "Asia/Shanghai",
+			"current_units": {"temperature_2m": "°C", "relative_humidity_2m": "%", "precipitation": "mm", "wind_speed_10m": "km/h"},
+			"current": {"time": "2024-05-01T12:00", "temperature_2m": 21.5, "relative_humidity_2m": 40, "apparent_temperature": 20.1, "precipitation": 0, "weather_code": 2, "wind_speed_10m": 12.3, "wind_direction_10m": 180},
+			"daily": {"time": ["2024-05-01", "2024-05-02"], "weather_code": [2, 63], "temperature_2m_max": [25, 19], "temperature_2m_min": [12, 10], "precipitation_sum": [0, 8.5], "precipitation_probability_max": [5, 80]}
+		}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tool := &WeatherTool{geocodeURL: server.URL + "/geocode", forecastURL: server.URL + "/forecast", fallbackURL: server.URL + "/missing"}
+
+	result, err := tool.Execute(map[string]interface{}{"city": "Beijing", "days": float64(2)})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	want := "Beijing, Beijing, China (Asia/Shanghai)\n" +
+		"Now (2024-05-01T12:00): partly cloudy, 21.5°C, feels like 20.1°C, humidity 40%, wind 12.3 km/h S, precipitation 0 mm\n" +
+		"2024-05-01: partly cloudy, 12~25°C, precipitation 0 mm (5%)\n" +
+		"2024-05-02: rain, 10~19°C, precipitation 8.5 mm (80%)"
+	if result != want {
+		t.Errorf("summary = %q, want %q", result, want)
+	}
+
+	// raw 返回原始响应，同样使用缓存
+	result, err = tool.Execute(map[string]interface{}{"city": "Beijing", "days": float64(2), "raw": true})
+	if err != nil {
+		t.Fatalf("raw Execute() error = %v", err)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(result), &data); err != nil || data["current_units"] == nil {
+		t.Errorf("raw result = %s, %v", result, err)
+	}
+
+	// 相同城市命中缓存，不再请求接口
+	if _, err := tool.Execute(map[string]interface{}{"city": " beijing ", "days": float64(2)}); err != nil {
+		t.Fatalf("cached Execute() error = %v", err)
+	}
+	if geocodes != 1 || forecasts != 1 {
+		t.Errorf("requests = %d geocode, %d forecast; want 1 each", geocodes, forecasts)
+	}
+
+	// 配置了默认城市和天数时可以省略参数
+	if _, err := tool.Execute(map[string]interface{}{}); err == nil {
+		t.Error("city should be required without a default city")
+	}
+	tool.settings = WeatherSettings{DefaultCity: "Beijing", DefaultDays: 2}
+	if _, err := tool.Execute(map[string]interface{}{}); err != nil {
+		t.Fatalf("Execute() with default city error = %v", err)
+	}
+	if geocodes != 1 {
+		t.Errorf("default city should hit the cache, geocode requests = %d", geocodes)
+	}
+
+	_, err = tool.Execute(map[string]interface{}{"city": "Nowhere", "days": float64(2)})
+	if err == nil || !strings.Contains(err.Error(), "city not found") {
+		t.Errorf("unknown city error = %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultWorkspace 工作目录 tools.workDir 对应的工作区名
+const DefaultWorkspace = "default"
+
+// Workspace 命名工作区，文件工具通过 workspace 参数选择
+type Workspace struct {
+	Name     string
+	Path     string
+	ReadOnly bool
+}
+
+// initWorkspaces 注册默认工作区和配置的工作区，可写工作区目录不存在时自动创建
+func (m *Manager) initWorkspaces(list []Workspace) {
+	m.workspaces = map[string]Workspace{
+		DefaultWorkspace: {Name: DefaultWorkspace, Path: m.workDir},
+	}
+	for _, ws := range list {
+		if ws.Name == "" || ws.Path == "" {
+			m.log.Warn("workspace ignored: name and path are required", "name", ws.Name, "path", ws.Path)
+			continue
+		}
+		if _, ok := m.workspaces[ws.Name]; ok {
+			m.log.Warn("workspace ignored: duplicate name", "name", ws.Name)
+			continue
+		}
+		path, err := filepath.Abs(ws.Path)
+		if err != nil {
+			m.log.Warn("workspace ignored: invalid path", "name", ws.Name, "error", err)
+			continue
+		}
+		if !ws.ReadOnly {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				m.log.Warn("failed to create workspace", "name", ws.Name, "path", path, "error", err)
+			}
+		}
+		ws.Path = path
+		m.workspaces[ws.Name] = ws
+	}
+}
+
+// Workspaces 返回所有工作区，默认工作区在前，其余按名称排序
+func (m *Manager) Workspaces() []Workspace {
+	list := make([]Workspace, 0, len(m.workspaces))
+	for _, ws := range m.workspaces {
+		if ws.Name != DefaultWorkspace {
+			list = append(list, ws)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return append([]Workspace{m.workspaces[DefaultWorkspace]}, list...)
+}
+
+// workspaceParam 文件工具的 workspace 参数定义
+func (m *Manager) workspaceParam() map[string]interface{} {
+	names := make([]string, 0, len(m.workspaces))
+	descs := make([]string, 0, len(m.workspaces))
+	for _, ws := range m.Workspaces() {
+		mode := "读写"
+		if ws.ReadOnly {
+			mode = "只读"
+		}
+		names = append(names, ws.Name)
+		descs = append(descs, fmt.Sprintf("%s(%s)", ws.Name, mode))
+	}
+	return map[string]interface{}{
+		"type":        "string",
+		"description": "相对路径所在的工作区，默认 default。可用: " + strings.Join(descs, ", "),
+		"enum":        names,
+	}
+}
+
+// resolvePath 按 workspace 参数解析路径：相对路径基于所选工作区，绝对路径须位于某个工作区内。
+// 启用按用户隔离时，默认工作区内只能访问调用者自己的目录。write 为 true 时拒绝只读工作区
+func (m *Manager) resolvePath(args map[string]interface{}, path string, write bool) (string, error) {
+	name, _ := args["workspace"].(string)
+	if name == "" {
+		name = DefaultWorkspace
+	}
+	ws, ok := m.workspaces[name]
+	if !ok {
+		return "", fmt.Errorf("unknown workspace: %s", name)
+	}
+	home := m.homeDir(callerArg(args))
+	if !filepath.IsAbs(path) {
+		base := ws.Path
+		if name == DefaultWorkspace {
+			base = home
+		}
+		path = filepath.Join(base, path)
+	}
+
+	safePath, realPath, owner, err := m.checkPath(path)
+	if err != nil {
+		return "", err
+	}
+	if owner.Name == DefaultWorkspace && home != m.workDir && !within(home, realPath) {
+		return "", fmt.Errorf("path is outside your directory: %s", path)
+	}
+	if write && owner.ReadOnly {
+		return "", fmt.Errorf("workspace %s is read-only: %s", owner.Name, path)
+	}
+	return safePath, nil
+}
+
+// checkPath 检查路径（解析符号链接后）位于哪个工作区，嵌套时取最深的一个
+func (m *Manager) checkPath(path string) (string, string, Workspace, error) {
+	path = filepath.Clean(path)
+
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return "", "", Workspace{}, fmt.Errorf("failed to resolve path: %w", err)
+		}
+		realPath = path
+	}
+
+	var owner Workspace
+	depth := -1
+	for _, ws := range m.workspaces {
+		if root, err := filepath.EvalSymlinks(ws.Path); err == nil && within(root, realPath) && len(root) > depth {
+			owner, depth = ws, len(root)
+		}
+	}
+	if depth < 0 {
+		return "", "", Workspace{}, fmt.Errorf("path is outside work directory: %s", path)
+	}
+	// 编辑历史中的快照和索引只能由 EditHistory 读写，否则可伪造记录让撤销写到任意位置
+	if within(filepath.Join(m.workDir, HistoryDirName), realPath) {
+		return "", "", Workspace{}, fmt.Errorf("path is reserved for edit history: %s", path)
+	}
+	return path, realPath, owner, nil
+}
+
+// within 判断 path 是否为 root 或位于其下，root 会先解析符号链接
+func within(root, path string) bool {
+	if real, err := filepath.EvalSymlinks(root); err == nil {
+		root = real
+	}
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// UsersDirName 按用户隔离时个人目录的父目录，位于工作目录下
+const UsersDirName = "users"
+
+// SharedWorkspace 按用户隔离时所有用户共享的工作区名
+const SharedWorkspace = "shared"
+
+// homeDir 返回调用者在默认工作区中的根目录。启用按用户隔离时普通用户为 users/<渠道>_<用户ID>，
+// 管理员和没有调用者的内部调用为工作目录本身
+func (m *Manager) homeDir(c Caller) string {
+	if !m.perUserWorkDir || c.UserID == "" || (m.isAdmin != nil && m.isAdmin(c.Channel, c.UserID)) {
+		return m.workDir
+	}
+	dir := m.userDir(c.Channel, c.UserID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		m.log.Warn("failed to create user directory", "path", dir, "error", err)
+	}
+	return dir
+}
+
+// userDir 用户在 users 下的个人目录
+func (m *Manager) userDir(channel, userID string) string {
+	return filepath.Join(m.workDir, UsersDirName, artifactNameRe.ReplaceAllString(channel+"_"+userID, "_"))
+}
+
+// DeleteUserEdits 删除用户个人目录中文件的编辑历史及快照，返回删除的记录数。
+// 未启用按用户隔离时不删除任何记录
+func (m *Manager) DeleteUserEdits(channel, userID string) (int, error) {
+	if !m.perUserWorkDir || userID == "" {
+		return 0, nil
+	}
+	return m.history.DeleteUnder(m.userDir(channel, userID))
+}
+
+// DeleteUserFiles 删除用户的个人目录（上传和生成的文件），返回删除的文件数。
+// 未启用按用户隔离时没有个人目录，不删除任何文件
+func (m *Manager) DeleteUserFiles(channel, userID string) (int, error) {
+	if !m.perUserWorkDir || userID == "" {
+		return 0, nil
+	}
+	dir := m.userDir(channel, userID)
+	n := 0
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return 0, fmt.Errorf("failed to remove user directory: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,221 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestWorkspaces(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+
+	workDir := t.TempDir()
+	projects := filepath.Join(t.TempDir(), "projects")
+	configs := t.TempDir()
+	if err := os.WriteFile(filepath.Join(configs, "app.conf"), []byte("port=80"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewManager(Config{
+		WorkDir: workDir,
+		Workspaces: []Workspace{
+			{Name: "projects", Path: projects},
+			{Name: "configs", Path: configs, ReadOnly: true},
+			{Name: "default", Path: t.TempDir()},
+		},
+	}, log)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	if _, err := os.Stat(projects); err != nil {
+		t.Errorf("writable workspace not created: %v", err)
+	}
+	var names []string
+	for _, ws := range m.Workspaces() {
+		names = append(names, ws.Name)
+	}
+	if got := strings.Join(names, ","); got != "default,configs,projects" {
+		t.Errorf("Workspaces() = %s", got)
+	}
+
+	tests := []struct {
+		name    string
+		tool    string
+		args    map[string]interface{}
+		want    string
+		wantErr string
+	}{
+		{"read in read-only workspace", "read_file", map[string]interface{}{"workspace": "configs", "path": "app.conf"}, "port=80", ""},
+		{"write to read-only workspace", "write_file", map[string]interface{}{"workspace": "configs", "path": "app.conf", "content": "x"}, "", "read-only"},
+		{"absolute path in read-only workspace", "write_file", map[string]interface{}{"path": filepath.Join(configs, "new.conf"), "content": "x"}, "", "read-only"},
+		{"delete in read-only workspace", "delete_file", map[string]interface{}{"workspace": "configs", "path": "app.conf"}, "", "read-only"},
+		{"write to workspace", "write_file", map[string]interface{}{"workspace": "projects", "path": "a/readme.md", "content": "hello"}, "File written", ""},
+		{"copy out of read-only workspace", "copy_file", map[string]interface{}{"workspace": "configs", "source": "app.conf", "destination_workspace": "projects", "destination": "app.conf"}, "Copied", ""},
+		{"move out of read-only workspace", "move_file", map[string]interface{}{"workspace": "configs", "source": "app.conf", "destination_workspace": "projects", "destination": "moved.conf"}, "", "read-only"},
+		{"delete workspace root", "delete_file", map[string]interface{}{"workspace": "projects", "path": "."}, "", "cannot delete"},
+		{"escape workspace", "read_file", map[string]interface{}{"workspace": "projects", "path": "../../etc/passwd"}, "", "outside work directory"},
+		{"unknown workspace", "list_directory", map[string]interface{}{"workspace": "nope"}, "", "unknown workspace"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool, ok := m.Get(tt.tool)
+			if !ok {
+				t.Fatalf("tool %s not registered", tt.tool)
+			}
+			out, err := tool.Execute(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+
+	if data, err := os.ReadFile(filepath.Join(projects, "app.conf")); err != nil || string(data) != "port=80" {
+		t.Errorf("copied file = %q, %v", data, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(configs, "app.conf")); string(data) != "port=80" {
+		t.Errorf("read-only file changed: %q", data)
+	}
+}
+
+func TestPerUserWorkDir(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+
+	workDir := t.TempDir()
+	m, err := NewManager(Config{
+		WorkDir:        workDir,
+		PerUserWorkDir: true,
+		SharedDir:      "shared",
+		IsAdmin:        func(channel, userID string) bool { return channel+":"+userID == "telegram:99" },
+	}, log)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	alice := WithCaller(context.Background(), Caller{Channel: "telegram", UserID: "1"})
+	bob := WithCaller(context.Background(), Caller{Channel: "telegram", UserID: "2"})
+	admin := WithCaller(context.Background(), Caller{Channel: "telegram", UserID: "99"})
+	aliceFile := filepath.Join(workDir, UsersDirName, "telegram_1", "notes.txt")
+
+	steps := []struct {
+		name    string
+		ctx     context.Context
+		tool    string
+		args    map[string]interface{}
+		want    string
+		wantErr string
+	}{
+		{"write own file", alice, "write_file", map[string]interface{}{"path": "notes.txt", "content": "secret"}, aliceFile, ""},
+		{"relative path is per user", bob, "read_file", map[string]interface{}{"path": "notes.txt"}, "", "failed to stat"},
+		{"absolute path of another user", bob, "read_file", map[string]interface{}{"path": aliceFile}, "", "outside your directory"},
+		{"escape own directory", bob, "list_directory", map[string]interface{}{"path": "../telegram_1"}, "", "outside your directory"},
+		{"work dir root", bob, "list_directory", map[string]interface{}{"path": workDir}, "", "outside your directory"},
+		{"other user's edit history", bob, "undo_edit", map[string]interface{}{}, "", "nothing to undo"},
+		{"write shared file", alice, "write_file", map[string]interface{}{"workspace": "shared", "path": "list.txt", "content": "milk"}, "File written", ""},
+		{"read shared file", bob, "read_file", map[string]interface{}{"workspace": "shared", "path": "list.txt"}, "milk", ""},
+		{"write own scratch file", bob, "write_file", map[string]interface{}{"path": "evil.txt", "content": "evil"}, "File written", ""},
+		{"copy into another user's directory", bob, "copy_file", map[string]interface{}{"source": "evil.txt", "destination": aliceFile, "destination_workspace": "default", "overwrite": true}, "", "outside your directory"},
+		{"move into another user's directory", bob, "move_file", map[string]interface{}{"source": "evil.txt", "destination": aliceFile, "destination_workspace": "default", "overwrite": true}, "", "outside your directory"},
+		{"admin reads any file", admin, "read_file", map[string]interface{}{"path": aliceFile}, "secret", ""},
+		{"internal call reads any file", context.Background(), "read_file", map[string]interface{}{"path": "users/telegram_1/notes.txt"}, "secret", ""},
+		{"undo own edit", alice, "undo_edit", map[string]interface{}{"action": "list"}, "notes.txt", ""},
+	}
+
+	for _, tt := range steps {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := m.Execute(tt.ctx, tt.tool, tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteUserFiles(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+
+	workDir := t.TempDir()
+	m, err := NewManager(Config{WorkDir: workDir, PerUserWorkDir: true}, log)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+
+	alice := WithCaller(context.Background(), Caller{Channel: "telegram", UserID: "1"})
+	bob := WithCaller(context.Background(), Caller{Channel: "telegram", UserID: "2"})
+	for _, path := range []string{"a.txt", "sub/b.txt"} {
+		if _, err := m.Execute(alice, "write_file", map[string]interface{}{"path": path, "content": "x"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := m.Execute(bob, "write_file", map[string]interface{}{"path": "c.txt", "content": "x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 编辑历史中该用户文件的记录和快照一并删除，其他用户的保留
+	var aliceIDs []int
+	for _, e := range m.History().List() {
+		if strings.HasPrefix(filepath.ToSlash(e.Path), UsersDirName+"/telegram_1/") {
+			aliceIDs = append(aliceIDs, e.ID)
+		}
+	}
+	if n, err := m.DeleteUserEdits("telegram", "1"); err != nil || n != len(aliceIDs) || n < 2 {
+		t.Fatalf("DeleteUserEdits = %d, %v, want %d", n, err, len(aliceIDs))
+	}
+	for _, id := range aliceIDs {
+		if _, _, _, err := m.History().Get(id); err == nil {
+			t.Errorf("edit %d should be removed", id)
+		}
+		if _, err := os.Stat(m.History().snapshotPath(id, "after")); !os.IsNotExist(err) {
+			t.Errorf("snapshot of edit %d should be removed, stat err = %v", id, err)
+		}
+	}
+	if entries := m.History().List(); len(entries) != 1 || !strings.Contains(entries[0].Path, "telegram_2") {
+		t.Errorf("other user's edits should be kept: %+v", entries)
+	}
+
+	n, err := m.DeleteUserFiles("telegram", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n < 2 {
+		t.Errorf("deleted = %d, want at least 2", n)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, UsersDirName, "telegram_1")); !os.IsNotExist(err) {
+		t.Errorf("user directory should be removed, stat err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, UsersDirName, "telegram_2", "c.txt")); err != nil {
+		t.Errorf("other user's files should be kept: %v", err)
+	}
+
+	// 未启用按用户隔离时不删除工作目录
+	shared, _ := NewManager(Config{WorkDir: workDir}, log)
+	if n, err := shared.DeleteUserFiles("telegram", "2"); err != nil || n != 0 {
+		t.Errorf("DeleteUserFiles without per-user dirs = %d, %v", n, err)
+	}
+	if _, err := os.Stat(workDir); err != nil {
+		t.Errorf("work dir should be kept: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/analytics"
+	"github.com/HaohanHe/mujibot/internal/httpapi"
+)
+
+// defaultAnalyticsDays /api/analytics 默认返回的天数
+const defaultAnalyticsDays = 30
+
+// SetAnalytics 设置对话分析统计，用于 /api/analytics
+func (s *Server) SetAnalytics(store *analytics.Store) {
+	s.analytics = store
+}
+
+// handleAnalytics 对话分析API: GET /api/analytics?days=30，返回各意图的消息数及按天、智能体、渠道、用户的分组
+func (s *Server) handleAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.analytics == nil {
+		httpapi.RespondError(w, r, http.StatusServiceUnavailable, httpapi.CodeUnavailable, "Analytics not enabled")
+		return
+	}
+
+	days := defaultAnalyticsDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "Invalid days")
+			return
+		}
+		days = n
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.analytics.Report(days, time.Now()))
+}
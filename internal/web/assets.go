@@ -0,0 +1,57 @@
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// staticFiles 内置的控制台页面、样式和脚本
+//
+//go:embed static
+var staticFiles embed.FS
+
+// overlayFS 优先读取磁盘目录中的文件，不存在时回退到内置文件
+type overlayFS struct {
+	disk fs.FS
+	base fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if f, err := o.disk.Open(name); err == nil {
+		return f, nil
+	}
+	return o.base.Open(name)
+}
+
+// assets 返回控制台静态文件。配置 server.webRoot 后同名文件以该目录为准，
+// 可以只覆盖部分文件（如 style.css）或添加新文件，修改后刷新页面即生效
+func (s *Server) assets() fs.FS {
+	embedded, _ := fs.Sub(staticFiles, "static")
+	root := s.config.Get().Server.WebRoot
+	if root == "" {
+		return embedded
+	}
+	return overlayFS{disk: os.DirFS(root), base: embedded}
+}
+
+// serveAsset 发送静态文件，不存在或为目录时返回404
+func (s *Server) serveAsset(w http.ResponseWriter, r *http.Request, name string) {
+	if !fs.ValidPath(name) || strings.HasSuffix(name, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := fs.ReadFile(s.assets(), name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+		w.Header().Set("Content-Type", ctype)
+	}
+	w.Write(data)
+}
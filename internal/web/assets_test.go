@@ -0,0 +1,82 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestAssets(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	t.Cleanup(func() { log.Close() })
+
+	webRoot := t.TempDir()
+	if err := os.WriteFile(filepath.Join(webRoot, "style.css"), []byte("body { color: red; }"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(webRoot, "theme.js"), []byte("var theme = 1;"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, root := range []string{"", webRoot} {
+		cfgData, _ := json.Marshal(map[string]interface{}{
+			"llm":    map[string]string{"provider": "ollama"},
+			"server": map[string]string{"webRoot": root},
+		})
+		configPath := filepath.Join(t.TempDir(), "config.json5")
+		if err := os.WriteFile(configPath, cfgData, 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := config.NewManager(configPath, log)
+		if err != nil {
+			t.Fatalf("config.NewManager: %v", err)
+		}
+		t.Cleanup(func() { cfg.Close() })
+		s := &Server{config: cfg}
+
+		// 覆盖目录中的 style.css 替换内置文件，新增的 theme.js 可以直接访问
+		mode, style, themeStatus := "embedded", ".message-log", http.StatusNotFound
+		if root != "" {
+			mode, style, themeStatus = "override", "color: red", http.StatusOK
+		}
+		tests := []struct {
+			path     string
+			handler  http.HandlerFunc
+			status   int
+			contains string
+			ctype    string
+		}{
+			{"/", s.handleIndex, http.StatusOK, "<!DOCTYPE html>", "text/html"},
+			{"/static/app.js", s.handleStatic, http.StatusOK, "function init()", "javascript"},
+			{"/static/style.css", s.handleStatic, http.StatusOK, style, "text/css"},
+			{"/static/theme.js", s.handleStatic, themeStatus, "", ""},
+			{"/static/", s.handleStatic, http.StatusNotFound, "", ""},
+			{"/static/../server.go", s.handleStatic, http.StatusNotFound, "", ""},
+		}
+
+		for _, tt := range tests {
+			t.Run(mode+tt.path, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.URL.Path = tt.path
+				rec := httptest.NewRecorder()
+				tt.handler(rec, req)
+				if rec.Code != tt.status {
+					t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+				}
+				if !strings.Contains(rec.Body.String(), tt.contains) {
+					t.Errorf("body does not contain %q", tt.contains)
+				}
+				if !strings.Contains(rec.Header().Get("Content-Type"), tt.ctype) {
+					t.Errorf("Content-Type = %q, want %q", rec.Header().Get("Content-Type"), tt.ctype)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/HaohanHe/mujibot/internal/confirmation"
+	"github.com/HaohanHe/mujibot/internal/httpapi"
+)
+
+// webNotifier 把确认请求和结果推送到调试消息流
+type webNotifier struct {
+	server *Server
+}
+
+func (n *webNotifier) Name() string {
+	return "web"
+}
+
+func (n *webNotifier) SendConfirmation(req *confirmation.ConfirmationRequest) error {
+	content := fmt.Sprintf("[%s] %s: %s (%s) - POST /api/confirmations/%s/approve or /reject",
+		req.RiskLevel, req.Type, req.Operation, req.Details, req.ID)
+	for _, e := range req.Effects {
+		content += fmt.Sprintf("\n- %s [%s] %s", e.Kind, e.Risk, strings.Join(e.Targets, ", "))
+	}
+	n.server.LogMessage("confirmation", "confirmation", content, req.UserID, req.Channel, "")
+	return nil
+}
+
+func (n *webNotifier) NotifyResult(req *confirmation.ConfirmationRequest, approved bool) {
+	result := "rejected"
+	if approved {
+		result = "approved"
+	}
+	content := fmt.Sprintf("%s %s by %s: %s", req.ID, result, req.ApprovedBy, req.Operation)
+	n.server.LogMessage("confirmation", "confirmation", content, req.UserID, req.Channel, "")
+}
+
+// handleConfirmations 处理确认API: GET /api/confirmations 待确认列表，GET /api/confirmations/{id} 详情，
+// POST /api/confirmations/{id}/approve|reject 批准或拒绝（需要管理员令牌）
+func (s *Server) handleConfirmations(w http.ResponseWriter, r *http.Request) {
+	if s.confirmations == nil {
+		httpapi.RespondError(w, r, http.StatusServiceUnavailable, httpapi.CodeUnavailable, "Confirmations not enabled")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/confirmations"), "/")
+	if path == "" {
+		if r.Method != http.MethodGet {
+			httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+			return
+		}
+		pending := s.confirmations.GetPending()
+		sort.Slice(pending, func(i, j int) bool { return pending[i].CreatedAt.Before(pending[j].CreatedAt) })
+		if pending == nil {
+			pending = []*confirmation.ConfirmationRequest{}
+		}
+		json.NewEncoder(w).Encode(pending)
+		return
+	}
+
+	id, action, _ := strings.Cut(path, "/")
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		req, err := s.confirmations.GetRequest(id)
+		if err != nil {
+			httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, "Confirmation not found")
+			return
+		}
+		json.NewEncoder(w).Encode(req)
+	case (action == "approve" || action == "reject") && r.Method == http.MethodPost:
+		// 批准会执行危险操作，与终端控制一样需要管理员令牌
+		if !adminRequest(s.config, r) {
+			httpapi.RespondError(w, r, http.StatusForbidden, httpapi.CodeForbidden, "admin token required")
+			return
+		}
+		by := webActor(r)
+		var err error
+		if action == "approve" {
+			err = s.confirmations.Approve(id, by)
+		} else {
+			err = s.confirmations.Reject(id, by)
+		}
+		if err != nil {
+			httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, "Confirmation not found")
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id": id, "status": action + "d"})
+	case action == "" || action == "approve" || action == "reject":
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+	default:
+		httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, "API not found")
+	}
+}
+
+// webActor 网页操作者的标识，记录为 web:<客户端地址>
+func webActor(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "web:" + host
+}
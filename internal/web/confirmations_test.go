@@ -0,0 +1,111 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/confirmation"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+// capturingNotifier 把确认请求和结果转发到通道
+type capturingNotifier struct {
+	requests chan confirmation.ConfirmationRequest
+	results  chan confirmation.ConfirmationRequest
+}
+
+func (n *capturingNotifier) Name() string { return "test" }
+
+func (n *capturingNotifier) SendConfirmation(req *confirmation.ConfirmationRequest) error {
+	n.requests <- *req
+	return nil
+}
+
+func (n *capturingNotifier) NotifyResult(req *confirmation.ConfirmationRequest, approved bool) {
+	n.results <- *req
+}
+
+func TestConfirmationRoutes(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	t.Cleanup(func() { log.Close() })
+
+	cfgData, _ := json.Marshal(map[string]interface{}{
+		"llm":    map[string]string{"provider": "ollama"},
+		"server": map[string]interface{}{"adminToken": "s3cret"},
+	})
+	configPath := filepath.Join(t.TempDir(), "config.json5")
+	if err := os.WriteFile(configPath, cfgData, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.NewManager(configPath, log)
+	if err != nil {
+		t.Fatalf("config.NewManager: %v", err)
+	}
+	t.Cleanup(func() { cfg.Close() })
+
+	cm := confirmation.NewConfirmationManager(cfg, log)
+	notifier := &capturingNotifier{requests: make(chan confirmation.ConfirmationRequest, 1), results: make(chan confirmation.ConfirmationRequest, 1)}
+	cm.RegisterNotifier(notifier)
+	s := &Server{config: cfg, log: log}
+	s.confirmations = cm
+	mux := s.routes()
+	do := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		if token != "" {
+			req.Header.Set("X-Admin-Token", token)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	approved := make(chan bool, 1)
+	go func() {
+		ok, _ := cm.RequestConfirmation(context.Background(), "terminal", "rm -rf build", "policy: dangerous-commands", "high")
+		approved <- ok
+	}()
+	req := <-notifier.requests
+
+	// 没有或令牌错误时不能批准
+	for _, token := range []string{"", "wrong"} {
+		if rec := do("/api/confirmations/"+req.ID+"/approve", token); rec.Code != http.StatusForbidden {
+			t.Errorf("approve with token %q = %d, want 403", token, rec.Code)
+		}
+	}
+	if rec := do("/api/confirmations/"+req.ID+"/reject", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("reject without token = %d, want 403", rec.Code)
+	}
+
+	if rec := do("/api/confirmations/"+req.ID+"/approve", "s3cret"); rec.Code != http.StatusOK {
+		t.Fatalf("approve = %d: %s", rec.Code, rec.Body.String())
+	}
+	select {
+	case ok := <-approved:
+		if !ok {
+			t.Error("request was not approved")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("confirmation did not finish")
+	}
+	if result := <-notifier.results; result.ApprovedBy != "web:192.0.2.1" {
+		t.Errorf("approved by %q, want web:192.0.2.1", result.ApprovedBy)
+	}
+
+	// 未知操作返回 JSON 格式的 404
+	rec := do("/api/confirmations/"+req.ID+"/snooze", "s3cret")
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); rec.Code != http.StatusNotFound || err != nil || body.Error.Code != "not_found" {
+		t.Errorf("unknown action = %d %s", rec.Code, rec.Body.String())
+	}
+}
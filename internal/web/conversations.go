@@ -0,0 +1,130 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/HaohanHe/mujibot/internal/httpapi"
+	"github.com/HaohanHe/mujibot/internal/session"
+)
+
+// conversationRequest 保存或载入对话的请求体
+type conversationRequest struct {
+	Name    string `json:"name"`
+	AgentID string `json:"agent_id"`
+}
+
+// SetSavedConversations 设置已保存对话的存储，用于 /api/conversations
+func (s *Server) SetSavedConversations(store *session.SavedStore) {
+	s.saved = store
+}
+
+// handleConversations 处理已保存对话API:
+// GET|POST /api/conversations/{channel:userID} 列出或保存当前会话，
+// GET|DELETE /api/conversations/{channel:userID}/{name} 查看或删除，
+// POST /api/conversations/{channel:userID}/{name}/load 载入到用户的会话
+func (s *Server) handleConversations(w http.ResponseWriter, r *http.Request) {
+	if s.saved == nil {
+		httpapi.RespondError(w, r, http.StatusServiceUnavailable, httpapi.CodeUnavailable, "Memory not enabled")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.EscapedPath(), "/api/conversations"), "/"), "/")
+	for i, part := range parts {
+		p, err := url.PathUnescape(part)
+		if err != nil {
+			httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "Invalid path")
+			return
+		}
+		parts[i] = p
+	}
+	owner := parts[0]
+	channel, userID, ok := strings.Cut(owner, ":")
+	if !ok || channel == "" || userID == "" {
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "User must be channel:userID")
+		return
+	}
+
+	var req conversationRequest
+	if r.Method == http.MethodPost && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		list, err := s.saved.List(owner)
+		if err != nil {
+			httpapi.RespondError(w, r, http.StatusInternalServerError, httpapi.CodeInternal, err.Error())
+			return
+		}
+		json.NewEncoder(w).Encode(list)
+
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		agent, err := s.agentRouter.Route(userID, channel, req.AgentID)
+		if err != nil {
+			httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, err.Error())
+			return
+		}
+		sess := s.sessionMgr.Get(userID, channel, agent.ID)
+		if sess == nil {
+			httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, "Session not found")
+			return
+		}
+		name, err := session.NormalizeSavedName(req.Name)
+		if err != nil {
+			httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, err.Error())
+			return
+		}
+		messages := s.sessionMgr.GetMessages(sess)
+		replaced, err := s.saved.Save(owner, name, agent.ID, messages)
+		if err != nil {
+			httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, err.Error())
+			return
+		}
+		s.log.Info("conversation saved", "user", owner, "name", name, "by", "web")
+		json.NewEncoder(w).Encode(map[string]interface{}{"name": name, "messages": len(messages), "replaced": replaced})
+
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		c, err := s.saved.Load(owner, parts[1])
+		if err != nil {
+			httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, err.Error())
+			return
+		}
+		json.NewEncoder(w).Encode(c)
+
+	case len(parts) == 2 && r.Method == http.MethodDelete:
+		if err := s.saved.Delete(owner, parts[1]); err != nil {
+			httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, err.Error())
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"name": parts[1], "status": "deleted"})
+
+	case len(parts) == 3 && parts[2] == "load" && r.Method == http.MethodPost:
+		c, err := s.saved.Load(owner, parts[1])
+		if err != nil {
+			httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, err.Error())
+			return
+		}
+		agent, err := s.agentRouter.Route(userID, channel, req.AgentID)
+		if err != nil {
+			httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, err.Error())
+			return
+		}
+		sess := s.sessionMgr.GetOrCreate(userID, channel, agent.ID)
+		s.sessionMgr.Restore(sess, c.Messages)
+		s.log.Info("saved conversation loaded", "user", owner, "name", c.Name, "agent", agent.ID, "by", "web")
+		json.NewEncoder(w).Encode(map[string]interface{}{"name": c.Name, "session": sess.ID, "messages": len(c.Messages)})
+
+	case len(parts) <= 3:
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+	default:
+		httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, "API not found")
+	}
+}
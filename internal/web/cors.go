@@ -0,0 +1,100 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/httpapi"
+)
+
+const (
+	// defaultCORSMaxAge 预检结果默认缓存秒数
+	defaultCORSMaxAge = 600
+	corsAllowMethods  = "GET, POST, PUT, PATCH, DELETE"
+	corsAllowHeaders  = "Content-Type, Authorization, X-Admin-Token, X-Request-ID"
+)
+
+// withOriginPolicy 处理 /api/ 的跨域请求，并拒绝来自其他站点的写请求。
+// 配置每次请求读取，修改 server.cors 和 server.csrf 后立即生效
+func (s *Server) withOriginPolicy(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := s.config.Get().Server
+		origin := r.Header.Get("Origin")
+
+		if origin != "" && strings.HasPrefix(r.URL.Path, "/api/") {
+			w.Header().Add("Vary", "Origin")
+			allowed := corsAllowed(cfg.CORS, origin)
+			if allowed {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Expose-Headers", httpapi.RequestIDHeader)
+			}
+			// 预检请求不进入处理器
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				if !allowed {
+					httpapi.RespondError(w, r, http.StatusForbidden, httpapi.CodeForbidden, "origin not allowed: "+origin)
+					return
+				}
+				maxAge := cfg.CORS.MaxAge
+				if maxAge <= 0 {
+					maxAge = defaultCORSMaxAge
+				}
+				w.Header().Set("Access-Control-Allow-Methods", corsAllowMethods)
+				w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(maxAge))
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+
+		if cfg.CSRF != "off" && isMutating(r.Method) && !sameSiteRequest(r, cfg.CORS) {
+			httpapi.RespondError(w, r, http.StatusForbidden, httpapi.CodeCSRFRejected, "cross-site request rejected")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// corsAllowed 来源是否在 allowedOrigins 中，"*" 匹配任意来源
+func corsAllowed(cfg config.CORSConfig, origin string) bool {
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" || strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameSiteRequest 判断写请求是否可信：同源、明确列出的来源，或不带来源信息的非浏览器请求。
+// "*" 只放开跨域读取，写请求仍需明确列出来源
+func sameSiteRequest(r *http.Request, cors config.CORSConfig) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		// 旧浏览器部分写请求不带 Origin，退回 Referer；都没有时按 Sec-Fetch-Site 判断
+		if ref, err := url.Parse(r.Referer()); err == nil && ref.Host != "" {
+			origin = ref.Scheme + "://" + ref.Host
+		} else {
+			site := r.Header.Get("Sec-Fetch-Site")
+			return site == "" || site == "same-origin" || site == "none"
+		}
+	}
+	if u, err := url.Parse(origin); err == nil && u.Host != "" && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, o := range cors.AllowedOrigins {
+		if o != "*" && strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestOriginPolicy(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	t.Cleanup(func() { log.Close() })
+
+	// 缩进输出，URL 不在最后一行，不会被当作 // 注释去掉
+	cfgData, _ := json.MarshalIndent(map[string]interface{}{
+		"llm": map[string]string{"provider": "ollama"},
+		"server": map[string]interface{}{
+			"cors": map[string]interface{}{"allowedOrigins": []string{"https://dash.example.com", "*"}},
+		},
+	}, "", "  ")
+	configPath := filepath.Join(t.TempDir(), "config.json5")
+	if err := os.WriteFile(configPath, cfgData, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.NewManager(configPath, log)
+	if err != nil {
+		t.Fatalf("config.NewManager: %v", err)
+	}
+	t.Cleanup(func() { cfg.Close() })
+
+	s := &Server{config: cfg}
+	handler := s.withOriginPolicy(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name, method, path string
+		headers            map[string]string
+		status             int
+		allowOrigin        string
+	}{
+		{"curl write", http.MethodPost, "/api/send", nil, http.StatusOK, ""},
+		{"same origin write", http.MethodPost, "/api/send", map[string]string{"Origin": "http://example.com"}, http.StatusOK, "http://example.com"},
+		{"listed origin write", http.MethodPost, "/api/send", map[string]string{"Origin": "https://dash.example.com"}, http.StatusOK, "https://dash.example.com"},
+		{"wildcard origin read", http.MethodGet, "/api/status", map[string]string{"Origin": "https://other.example"}, http.StatusOK, "https://other.example"},
+		{"wildcard origin write", http.MethodPost, "/api/send", map[string]string{"Origin": "https://other.example"}, http.StatusForbidden, "https://other.example"},
+		{"null origin write", http.MethodDelete, "/api/custom-apis", map[string]string{"Origin": "null"}, http.StatusForbidden, "null"},
+		{"cross-site referer", http.MethodPost, "/api/send", map[string]string{"Referer": "https://evil.example/page"}, http.StatusForbidden, ""},
+		{"same-origin referer", http.MethodPost, "/api/send", map[string]string{"Referer": "http://example.com/"}, http.StatusOK, ""},
+		{"cross-site fetch metadata", http.MethodPut, "/api/language", map[string]string{"Sec-Fetch-Site": "cross-site"}, http.StatusForbidden, ""},
+		{"preflight", http.MethodOptions, "/api/send", map[string]string{"Origin": "https://dash.example.com", "Access-Control-Request-Method": "POST"}, http.StatusNoContent, "https://dash.example.com"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, rec.Code, tt.status, rec.Body.String())
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+			t.Errorf("%s: Access-Control-Allow-Origin = %q, want %q", tt.name, got, tt.allowOrigin)
+		}
+	}
+
+	// 关闭校验并去掉通配后，跨站写请求放行，未列出的来源不再带CORS头
+	next := *cfg.Get()
+	next.Server.CSRF = "off"
+	next.Server.CORS.AllowedOrigins = []string{"https://dash.example.com"}
+	cfg.Update(&next)
+	req := httptest.NewRequest(http.MethodPost, "/api/send", nil)
+	req.Header.Set("Origin", "https://other.example")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("csrf off: status = %d, allow origin = %q", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
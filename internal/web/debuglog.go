@@ -0,0 +1,299 @@
+package web
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultDebugLogFile 调试消息持久化文件
+	defaultDebugLogFile = "./debug_messages.jsonl"
+	// defaultDebugLogMaxEntries 持久化保留的消息条数
+	defaultDebugLogMaxEntries = 10000
+	// defaultDebugQueryLimit 分页查询默认条数
+	defaultDebugQueryLimit = 50
+	// maxDebugQueryLimit 分页查询最大条数
+	maxDebugQueryLimit = 500
+)
+
+// debugStore 调试消息的JSONL存储。只追加写入，超出上限10%后压缩为最近的 max 条
+type debugStore struct {
+	path  string
+	max   int
+	mu    sync.Mutex
+	file  *os.File
+	count int
+}
+
+// openDebugStore 打开存储并返回已保存的消息（按时间正序）
+func openDebugStore(path string, max int) (*debugStore, []DebugMessage, error) {
+	if path == "" {
+		path = defaultDebugLogFile
+	}
+	if max <= 0 {
+		max = defaultDebugLogMaxEntries
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create debug log directory: %w", err)
+	}
+
+	s := &debugStore{path: path, max: max}
+	messages, err := s.readAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	s.count = len(messages)
+	if s.count > s.max {
+		messages = messages[s.count-s.max:]
+		if err := s.rewrite(messages); err != nil {
+			return nil, nil, err
+		}
+		s.count = len(messages)
+	}
+
+	s.file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open debug log: %w", err)
+	}
+	return s, messages, nil
+}
+
+// Append 追加一条消息
+func (s *debugStore) Append(msg DebugMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return fmt.Errorf("debug log closed")
+	}
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	s.count++
+	if s.count > s.max+s.max/10 {
+		return s.compact()
+	}
+	return nil
+}
+
+// compact 只保留最近的 max 条，调用方需持有锁
+func (s *debugStore) compact() error {
+	messages, err := s.readAll()
+	if err != nil {
+		return err
+	}
+	if len(messages) > s.max {
+		messages = messages[len(messages)-s.max:]
+	}
+	s.file.Close()
+	s.file = nil
+	if err := s.rewrite(messages); err != nil {
+		return err
+	}
+	s.count = len(messages)
+	s.file, err = os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	return err
+}
+
+// rewrite 原子地用给定消息替换文件内容
+func (s *debugStore) rewrite(messages []DebugMessage) error {
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, msg := range messages {
+		if err := enc.Encode(msg); err != nil {
+			f.Close()
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// readAll 读取所有消息，跳过损坏的行
+func (s *debugStore) readAll() ([]DebugMessage, error) {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read debug log: %w", err)
+	}
+	defer f.Close()
+
+	var messages []DebugMessage
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var msg DebugMessage
+		if json.Unmarshal(scanner.Bytes(), &msg) == nil {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, scanner.Err()
+}
+
+// Query 查询已保存的消息
+func (s *debugStore) Query(q debugQuery) (debugQueryResult, error) {
+	s.mu.Lock()
+	messages, err := s.readAll()
+	s.mu.Unlock()
+	if err != nil {
+		return debugQueryResult{}, err
+	}
+	return q.apply(messages), nil
+}
+
+// Remove 删除满足条件的消息并重写文件，返回删除的消息
+func (s *debugStore) Remove(match func(msg DebugMessage) bool) ([]DebugMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil, fmt.Errorf("debug log closed")
+	}
+
+	messages, err := s.readAll()
+	if err != nil {
+		return nil, err
+	}
+	var kept, removed []DebugMessage
+	for _, msg := range messages {
+		if match(msg) {
+			removed = append(removed, msg)
+		} else {
+			kept = append(kept, msg)
+		}
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	s.file.Close()
+	s.file = nil
+	if err := s.rewrite(kept); err != nil {
+		return nil, err
+	}
+	s.count = len(kept)
+	s.file, err = os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	return removed, err
+}
+
+// Close 关闭存储
+func (s *debugStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// debugQuery 调试消息查询条件
+type debugQuery struct {
+	Since     time.Time // 起始时间（含）
+	Until     time.Time // 结束时间（不含）
+	Type      string
+	RequestID string
+	Contains  string
+	Before    uint64 // 只返回ID小于它的消息，用于向前翻页
+	Offset    int    // 跳过的条数（从最新开始）
+	Limit     int
+}
+
+// debugQueryResult 查询结果，按时间倒序
+type debugQueryResult struct {
+	Total   int            `json:"total"`
+	Offset  int            `json:"offset"`
+	Entries []DebugMessage `json:"entries"`
+}
+
+// parseDebugQuery 解析 /api/logs 的查询参数
+func parseDebugQuery(params url.Values) (debugQuery, error) {
+	since, err := parseTime(params.Get("since"))
+	if err != nil {
+		return debugQuery{}, fmt.Errorf("invalid since: %w", err)
+	}
+	until, err := parseTime(params.Get("until"))
+	if err != nil {
+		return debugQuery{}, fmt.Errorf("invalid until: %w", err)
+	}
+	return debugQuery{
+		Since:     since,
+		Until:     until,
+		Type:      params.Get("type"),
+		RequestID: params.Get("request_id"),
+		Contains:  params.Get("q"),
+		Before:    uint64(parseInt(params.Get("before"), 0)),
+		Offset:    parseInt(params.Get("offset"), 0),
+		Limit:     parseInt(params.Get("limit"), defaultDebugQueryLimit),
+	}, nil
+}
+
+func (q debugQuery) match(msg DebugMessage) bool {
+	ts := time.UnixMilli(msg.Timestamp)
+	switch {
+	case !q.Since.IsZero() && ts.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && !ts.Before(q.Until):
+		return false
+	case q.Type != "" && msg.Type != q.Type:
+		return false
+	case q.RequestID != "" && msg.RequestID != q.RequestID:
+		return false
+	case q.Before > 0 && msg.ID >= q.Before:
+		return false
+	case q.Contains != "" && !strings.Contains(msg.Content, q.Contains):
+		return false
+	}
+	return true
+}
+
+// apply 过滤按时间正序排列的消息并分页
+func (q debugQuery) apply(messages []DebugMessage) debugQueryResult {
+	limit := q.Limit
+	if limit <= 0 || limit > maxDebugQueryLimit {
+		limit = maxDebugQueryLimit
+	}
+	offset := q.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	result := debugQueryResult{Offset: offset, Entries: []DebugMessage{}}
+	for i := len(messages) - 1; i >= 0; i-- {
+		if !q.match(messages[i]) {
+			continue
+		}
+		if result.Total >= offset && len(result.Entries) < limit {
+			result.Entries = append(result.Entries, messages[i])
+		}
+		result.Total++
+	}
+	return result
+}
@@ -0,0 +1,125 @@
+package web
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDebugStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "debug.jsonl")
+	store, saved, err := openDebugStore(path, 10)
+	if err != nil {
+		t.Fatalf("openDebugStore: %v", err)
+	}
+	if len(saved) != 0 {
+		t.Fatalf("new store has %d messages", len(saved))
+	}
+
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 1; i <= 25; i++ {
+		msgType := "user"
+		if i%2 == 0 {
+			msgType = "assistant"
+		}
+		err := store.Append(DebugMessage{
+			ID:        uint64(i),
+			Timestamp: base.Add(time.Duration(i) * time.Minute).UnixMilli(),
+			Type:      msgType,
+			Content:   fmt.Sprintf("message %d", i),
+			RequestID: fmt.Sprintf("req%d", (i+1)/2),
+		})
+		if err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	store.Close()
+
+	// 重新打开时压缩到上限
+	store, saved, err = openDebugStore(path, 10)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer store.Close()
+	if len(saved) != 10 || saved[0].ID != 16 || saved[9].ID != 25 {
+		t.Fatalf("saved = %d messages, first %d", len(saved), saved[0].ID)
+	}
+
+	tests := []struct {
+		name      string
+		params    string
+		wantTotal int
+		wantIDs   []uint64
+	}{
+		{"newest first", "limit=3", 10, []uint64{25, 24, 23}},
+		{"offset", "limit=3&offset=3", 10, []uint64{22, 21, 20}},
+		{"before", "limit=2&before=18", 2, []uint64{17, 16}},
+		{"type", "type=user&limit=2", 5, []uint64{25, 23}},
+		{"request", "request_id=req10", 2, []uint64{20, 19}},
+		{"text", "q=message+2", 6, []uint64{25, 24, 23, 22, 21, 20}},
+		{"time range", fmt.Sprintf("since=%d&until=%d", base.Add(20*time.Minute).Unix(), base.Add(22*time.Minute).Unix()), 2, []uint64{21, 20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, _ := url.ParseQuery(tt.params)
+			q, err := parseDebugQuery(params)
+			if err != nil {
+				t.Fatalf("parseDebugQuery: %v", err)
+			}
+			result, err := store.Query(q)
+			if err != nil {
+				t.Fatalf("Query: %v", err)
+			}
+			if result.Total != tt.wantTotal {
+				t.Errorf("total = %d, want %d", result.Total, tt.wantTotal)
+			}
+			var ids []uint64
+			for _, e := range result.Entries {
+				ids = append(ids, e.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("ids = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestDebugStoreRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "debug.jsonl")
+	store, _, err := openDebugStore(path, 10)
+	if err != nil {
+		t.Fatalf("openDebugStore: %v", err)
+	}
+	for i := 1; i <= 6; i++ {
+		store.Append(DebugMessage{ID: uint64(i), Type: "user", UserID: fmt.Sprintf("u%d", i%2), Channel: "telegram"})
+	}
+
+	removed, err := store.Remove(func(msg DebugMessage) bool { return msg.UserID == "u1" })
+	if err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if len(removed) != 3 {
+		t.Errorf("removed %d messages, want 3", len(removed))
+	}
+	// 删除后仍可继续追加
+	if err := store.Append(DebugMessage{ID: 7, Type: "user", UserID: "u0"}); err != nil {
+		t.Fatalf("Append after Remove: %v", err)
+	}
+	store.Close()
+
+	_, saved, err := openDebugStore(path, 10)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if len(saved) != 4 {
+		t.Fatalf("saved = %d messages, want 4", len(saved))
+	}
+	for _, msg := range saved {
+		if msg.UserID == "u1" {
+			t.Errorf("message %d of removed user is still saved", msg.ID)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/httpapi"
+	"github.com/HaohanHe/mujibot/internal/llm"
+	"github.com/HaohanHe/mujibot/internal/session"
+)
+
+// llmTestTimeout 连接测试的最长等待时间（秒）
+const llmTestTimeout = 30
+
+// llmOverride 连接测试可覆盖的LLM配置，为空的字段使用当前配置
+type llmOverride struct {
+	Provider string `json:"provider"`
+	APIKey   string `json:"apiKey"`
+	BaseURL  string `json:"baseURL"`
+	Model    string `json:"model"`
+}
+
+// llmTestResult 连接测试结果
+type llmTestResult struct {
+	OK        bool   `json:"ok"`
+	Provider  string `json:"provider"`
+	Model     string `json:"model"`
+	LatencyMs int64  `json:"latencyMs"`
+	Reply     string `json:"reply,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// newTestProvider 用当前配置（可被覆盖）创建不重试、超时较短的提供商
+func (s *Server) newTestProvider(o llmOverride) (llm.Provider, config.LLMConfig, error) {
+	cfg := s.config.Get().LLM
+	if o.Provider != "" {
+		cfg.Provider = o.Provider
+	}
+	if o.APIKey != "" {
+		cfg.APIKey = o.APIKey
+	}
+	if o.BaseURL != "" {
+		cfg.BaseURL = o.BaseURL
+	}
+	if o.Model != "" {
+		cfg.Model = o.Model
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 || timeout > llmTestTimeout {
+		timeout = llmTestTimeout
+	}
+	provider, err := llm.NewProvider(cfg.Provider, cfg.APIKey, cfg.BaseURL, cfg.Model, timeout, 0, s.log)
+	if err == nil {
+		err = llm.UseProxy(provider, cfg.Proxy)
+	}
+	return provider, cfg, err
+}
+
+// handleLLMTest 发送一条极短的补全请求，报告延迟和错误，用于在用户消息失败前发现配置问题
+func (s *Server) handleLLMTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var o llmOverride
+	if err := json.NewDecoder(r.Body).Decode(&o); err != nil && err != io.EOF {
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, err.Error())
+		return
+	}
+
+	provider, cfg, err := s.newTestProvider(o)
+	result := llmTestResult{Provider: cfg.Provider, Model: cfg.Model}
+	if err == nil {
+		result.Model = provider.GetModel()
+		start := time.Now()
+		var resp *llm.Response
+		resp, err = provider.Chat([]session.Message{{Role: "user", Content: "Reply with OK."}}, nil)
+		result.LatencyMs = time.Since(start).Milliseconds()
+		if err == nil {
+			result.OK = true
+			result.Reply = truncate(strings.TrimSpace(resp.Content), 100)
+		}
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	s.log.Info("llm connection test", "provider", result.Provider, "model", result.Model,
+		"ok", result.OK, "latency_ms", result.LatencyMs, "error", result.Error)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleLLMModels 列出可用模型。优先从提供商获取，不支持或失败时回退到匹配的预设；
+// 指定 preset 参数时直接返回该预设的模型
+func (s *Server) handleLLMModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	cfg := s.config.Get()
+	result := map[string]interface{}{}
+	if name := r.URL.Query().Get("preset"); name != "" {
+		preset, ok := cfg.LLMPresets[name]
+		if !ok {
+			httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, "Preset not found")
+			return
+		}
+		result["source"] = "preset"
+		result["preset"] = name
+		result["models"] = nonNil(preset.Models)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+
+	provider, _, err := s.newTestProvider(llmOverride{})
+	if err == nil {
+		if lister, ok := provider.(llm.ModelLister); ok {
+			var models []string
+			if models, err = lister.ListModels(); err == nil {
+				result["source"] = "provider"
+				result["models"] = nonNil(models)
+			}
+		} else {
+			err = fmt.Errorf("provider %s cannot list models", cfg.LLM.Provider)
+		}
+	}
+	if err != nil {
+		result["source"] = "preset"
+		result["error"] = err.Error()
+		result["models"] = []string{}
+		if name, preset, ok := matchPreset(cfg); ok {
+			result["preset"] = name
+			result["models"] = nonNil(preset.Models)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// matchPreset 按 baseURL 或提供商名查找当前LLM配置对应的预设
+func matchPreset(cfg *config.Config) (string, config.LLMPreset, bool) {
+	baseURL := strings.TrimRight(cfg.LLM.BaseURL, "/")
+	for name, preset := range cfg.LLMPresets {
+		if baseURL != "" && strings.TrimRight(preset.BaseURL, "/") == baseURL {
+			return name, preset, true
+		}
+	}
+	preset, ok := cfg.LLMPresets[cfg.LLM.Provider]
+	return cfg.LLM.Provider, preset, ok
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
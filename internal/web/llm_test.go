@@ -0,0 +1,92 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+func TestLLMTestAndModels(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	t.Cleanup(func() { log.Close() })
+
+	// 模拟兼容OpenAI的API，只接受 good-key
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer good-key" {
+			http.Error(w, `{"error":"invalid api key"}`, http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/chat/completions":
+			w.Write([]byte(`{"choices":[{"message":{"content":"OK"}}]}`))
+		case "/models":
+			w.Write([]byte(`{"data":[{"id":"model-b"},{"id":"model-a"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(api.Close)
+
+	newServer := func(apiKey string) *Server {
+		cfgData, _ := json.MarshalIndent(map[string]interface{}{
+			"llm": map[string]string{"provider": "openai", "apiKey": apiKey, "baseURL": api.URL, "model": "model-a"},
+			"llmPresets": map[string]interface{}{
+				"local": map[string]interface{}{"name": "Local", "baseURL": api.URL + "/", "models": []string{"preset-model"}},
+			},
+		}, "", "  ")
+		configPath := filepath.Join(t.TempDir(), "config.json5")
+		if err := os.WriteFile(configPath, cfgData, 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := config.NewManager(configPath, log)
+		if err != nil {
+			t.Fatalf("config.NewManager: %v", err)
+		}
+		t.Cleanup(func() { cfg.Close() })
+		return &Server{config: cfg, log: log}
+	}
+	good, bad := newServer("good-key"), newServer("bad-key")
+
+	tests := []struct {
+		name    string
+		server  *Server
+		handler func(*Server) http.HandlerFunc
+		method  string
+		url     string
+		body    string
+		status  int
+		want    []string
+	}{
+		{"test ok", good, func(s *Server) http.HandlerFunc { return s.handleLLMTest }, "POST", "/api/llm/test", "", 200, []string{`"ok":true`, `"model":"model-a"`, `"reply":"OK"`}},
+		{"test bad key", bad, func(s *Server) http.HandlerFunc { return s.handleLLMTest }, "POST", "/api/llm/test", "", 200, []string{`"ok":false`, "401", "invalid api key"}},
+		{"test with override", bad, func(s *Server) http.HandlerFunc { return s.handleLLMTest }, "POST", "/api/llm/test", `{"apiKey":"good-key","model":"model-b"}`, 200, []string{`"ok":true`, `"model":"model-b"`}},
+		{"test requires POST", good, func(s *Server) http.HandlerFunc { return s.handleLLMTest }, "GET", "/api/llm/test", "", 405, nil},
+		{"models from provider", good, func(s *Server) http.HandlerFunc { return s.handleLLMModels }, "GET", "/api/llm/models", "", 200, []string{`"source":"provider"`, `["model-a","model-b"]`}},
+		{"models fall back to preset", bad, func(s *Server) http.HandlerFunc { return s.handleLLMModels }, "GET", "/api/llm/models", "", 200, []string{`"source":"preset"`, `"preset":"local"`, `["preset-model"]`, "401"}},
+		{"models of named preset", good, func(s *Server) http.HandlerFunc { return s.handleLLMModels }, "GET", "/api/llm/models?preset=local", "", 200, []string{`["preset-model"]`}},
+		{"unknown preset", good, func(s *Server) http.HandlerFunc { return s.handleLLMModels }, "GET", "/api/llm/models?preset=nope", "", 404, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			tt.handler(tt.server)(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("body = %s, want %q", rec.Body.String(), want)
+				}
+			}
+		})
+	}
+}
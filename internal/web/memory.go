@@ -0,0 +1,61 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/httpapi"
+	"github.com/HaohanHe/mujibot/internal/memory"
+)
+
+// handleMemorySearch 全文搜索记忆: GET /api/memory/search?q=&from=&to=&context=&limit=
+func (s *Server) handleMemorySearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.memory == nil || !s.memory.IsEnabled() {
+		httpapi.RespondError(w, r, http.StatusServiceUnavailable, httpapi.CodeUnavailable, "Memory not enabled")
+		return
+	}
+
+	params := r.URL.Query()
+	q := memory.SearchQuery{
+		Terms: memory.ParseTerms(params.Get("q")),
+		From:  params.Get("from"),
+		To:    params.Get("to"),
+	}
+	if len(q.Terms) == 0 {
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "q is required")
+		return
+	}
+	for _, date := range []string{q.From, q.To} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "Dates must be YYYY-MM-DD")
+			return
+		}
+	}
+	if v := params.Get("context"); v != "" {
+		q.Context, _ = strconv.Atoi(v)
+	}
+	if v := params.Get("limit"); v != "" {
+		q.Limit, _ = strconv.Atoi(v)
+	}
+
+	results, err := s.memory.SearchMemory(q)
+	if err != nil {
+		httpapi.RespondError(w, r, http.StatusInternalServerError, httpapi.CodeInternal, err.Error())
+		return
+	}
+	if results == nil {
+		results = []memory.SearchResult{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,387 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/HaohanHe/mujibot/internal/httpapi"
+)
+
+// openAPIVersion 接口文档的版本，接口有不兼容的变化时递增
+const openAPIVersion = "1.0.0"
+
+// apiParam 路径或查询参数
+type apiParam struct {
+	name, in, desc string
+	typ            string // 默认 string
+	required       bool
+}
+
+// apiOperation 一个接口，新增路由时同时在 apiOperations 中登记
+type apiOperation struct {
+	method, path, tag, summary string
+	params                     []apiParam
+	body                       string // 请求体在 components/schemas 中的名称
+	resp                       string // 响应体在 components/schemas 中的名称，空时为任意JSON对象
+	respType                   string // 默认 application/json
+	admin                      bool   // 需要管理员令牌
+}
+
+var (
+	ownerParam = apiParam{name: "owner", in: "path", desc: "用户，格式 channel:userID", required: true}
+	idParam    = apiParam{name: "id", in: "path", required: true}
+	nameParam  = apiParam{name: "name", in: "query", desc: "自定义API名称", required: true}
+)
+
+var apiOperations = []apiOperation{
+	{method: "GET", path: "/api/status", tag: "status", summary: "运行状态：内存、协程、会话、CPU、温度等"},
+	{method: "GET", path: "/api/memory-guard", tag: "status", summary: "内存保护器状态"},
+	{method: "GET", path: "/api/crashes", tag: "status", summary: "崩溃报告列表"},
+	{method: "GET", path: "/api/crashes/{id}", tag: "status", summary: "崩溃报告详情", params: []apiParam{idParam}},
+
+	{method: "GET", path: "/api/logs", tag: "logs", summary: "调试消息；带过滤参数时分页查询，返回 {total, entries}", params: []apiParam{
+		{name: "since", in: "query", desc: "起始时间（RFC3339 或 Unix 秒）"},
+		{name: "until", in: "query", desc: "结束时间（RFC3339 或 Unix 秒）"},
+		{name: "type", in: "query", desc: "消息类型，如 user、assistant、tool_call"},
+		{name: "request_id", in: "query"},
+		{name: "q", in: "query", desc: "内容搜索"},
+		{name: "before", in: "query", typ: "integer", desc: "只返回ID小于该值的消息"},
+		{name: "offset", in: "query", typ: "integer"},
+		{name: "limit", in: "query", typ: "integer"},
+	}},
+	{method: "GET", path: "/api/logs/query", tag: "logs", summary: "查询服务日志文件（需配置 logging.file）", params: []apiParam{
+		{name: "level", in: "query", desc: "最低级别：debug、info、warn、error"},
+		{name: "module", in: "query"},
+		{name: "since", in: "query"},
+		{name: "until", in: "query"},
+		{name: "q", in: "query", desc: "文本或 request_id"},
+		{name: "offset", in: "query", typ: "integer"},
+		{name: "limit", in: "query", typ: "integer"},
+	}},
+	{method: "GET", path: "/api/logging/level", tag: "logs", summary: "当前日志级别", resp: "LogLevel"},
+	{method: "PUT", path: "/api/logging/level", tag: "logs", summary: "运行时调整日志级别", body: "LogLevelUpdate", resp: "LogLevel"},
+
+	{method: "GET", path: "/api/sessions", tag: "sessions", summary: "会话统计"},
+	{method: "GET", path: "/api/sessions/stats", tag: "sessions", summary: "会话使用统计", params: []apiParam{
+		{name: "top", in: "query", typ: "integer", desc: "返回的最活跃用户数，默认 10，0 表示全部"},
+	}},
+	{method: "GET", path: "/api/sessions/{id}/export", tag: "sessions", summary: "导出会话记录", respType: "text/markdown", params: []apiParam{
+		{name: "id", in: "path", desc: "会话ID，格式 channel:userID:agentID", required: true},
+		{name: "format", in: "query", desc: "md（默认）或 json"},
+	}},
+	{method: "GET", path: "/api/analytics", tag: "sessions", summary: "按意图统计的对话分析（需开启 analytics.enabled）", params: []apiParam{
+		{name: "days", in: "query", typ: "integer", desc: "按天统计返回的天数，默认 30"},
+	}},
+	{method: "GET", path: "/api/conversations/{owner}", tag: "sessions", summary: "列出已保存的对话", params: []apiParam{ownerParam}},
+	{method: "POST", path: "/api/conversations/{owner}", tag: "sessions", summary: "保存用户当前会话", body: "SaveConversation", params: []apiParam{ownerParam}},
+	{method: "GET", path: "/api/conversations/{owner}/{name}", tag: "sessions", summary: "查看已保存的对话", params: []apiParam{ownerParam, {name: "name", in: "path", required: true}}},
+	{method: "DELETE", path: "/api/conversations/{owner}/{name}", tag: "sessions", summary: "删除已保存的对话", params: []apiParam{ownerParam, {name: "name", in: "path", required: true}}},
+	{method: "POST", path: "/api/conversations/{owner}/{name}/load", tag: "sessions", summary: "载入已保存的对话，替换当前会话", body: "LoadConversation", params: []apiParam{ownerParam, {name: "name", in: "path", required: true}}},
+
+	{method: "GET", path: "/api/agents", tag: "agents", summary: "智能体列表"},
+	{method: "GET", path: "/api/config", tag: "agents", summary: "配置摘要（不含敏感信息）"},
+
+	{method: "POST", path: "/api/llm/test", tag: "llm", summary: "发送极短的补全请求，测试LLM连接"},
+	{method: "GET", path: "/api/llm/models", tag: "llm", summary: "可用模型列表", params: []apiParam{
+		{name: "preset", in: "query", desc: "直接返回该预设的模型"},
+	}},
+	{method: "GET", path: "/api/llm/presets", tag: "llm", summary: "LLM预设"},
+
+	{method: "POST", path: "/api/send", tag: "messages", summary: "发送测试消息，stream 为 true 时返回SSE", body: "SendRequest", resp: "SendResponse"},
+	{method: "GET", path: "/api/messages/stream", tag: "messages", summary: "调试消息实时推送（SSE），每个事件为一条 DebugMessage", respType: "text/event-stream"},
+
+	{method: "GET", path: "/api/tools", tag: "tools", summary: "工具列表（含已禁用的）"},
+	{method: "POST", path: "/api/tools/toggle", tag: "tools", summary: "启用或禁用工具", body: "ToolToggle"},
+	{method: "GET", path: "/api/custom-apis", tag: "tools", summary: "列出自定义API，apiKey 已掩码", resp: "CustomAPIList"},
+	{method: "POST", path: "/api/custom-apis", tag: "tools", summary: "新增自定义API", body: "CustomAPI", resp: "CustomAPI"},
+	{method: "PUT", path: "/api/custom-apis", tag: "tools", summary: "替换自定义API，apiKey 为 ****** 时保留原值", body: "CustomAPI", resp: "CustomAPI", params: []apiParam{nameParam}},
+	{method: "DELETE", path: "/api/custom-apis", tag: "tools", summary: "删除自定义API", params: []apiParam{nameParam}},
+	{method: "GET", path: "/api/edits", tag: "tools", summary: "文件编辑历史"},
+	{method: "GET", path: "/api/edits/{id}", tag: "tools", summary: "编辑记录及修改前后的内容", params: []apiParam{idParam}},
+	{method: "POST", path: "/api/edits/{id}/undo", tag: "tools", summary: "撤销修改", params: []apiParam{idParam, {name: "force", in: "query", typ: "boolean", desc: "文件此后又被修改过时强制撤销"}, {name: "confirm", in: "query", typ: "boolean", desc: "确认撤销策略要求确认的路径"}}},
+	{method: "GET", path: "/api/terminal/sessions", tag: "tools", summary: "终端会话列表"},
+	{method: "GET", path: "/api/terminal/sessions/{id}/stream", tag: "tools", summary: "终端会话实时输出（SSE）", respType: "text/event-stream", params: []apiParam{idParam}},
+	{method: "POST", path: "/api/terminal/sessions/{id}/input", tag: "tools", summary: "向终端会话发送一行输入", admin: true, body: "TerminalInput", params: []apiParam{idParam}},
+	{method: "POST", path: "/api/terminal/sessions/{id}/cancel", tag: "tools", summary: "取消终端会话", admin: true, params: []apiParam{idParam}},
+	{method: "GET", path: "/api/confirmations", tag: "tools", summary: "等待确认的危险操作"},
+	{method: "GET", path: "/api/confirmations/{id}", tag: "tools", summary: "确认详情", params: []apiParam{idParam}},
+	{method: "POST", path: "/api/confirmations/{id}/approve", tag: "tools", summary: "批准", admin: true, params: []apiParam{idParam}},
+	{method: "POST", path: "/api/confirmations/{id}/reject", tag: "tools", summary: "拒绝", admin: true, params: []apiParam{idParam}},
+
+	{method: "GET", path: "/api/language", tag: "users", summary: "语言配置"},
+	{method: "POST", path: "/api/language", tag: "users", summary: "切换当前语言", body: "LanguageUpdate"},
+	{method: "GET", path: "/api/profiles", tag: "users", summary: "已设置资料的用户", resp: "ProfileList"},
+	{method: "GET", path: "/api/profiles/{owner}", tag: "users", summary: "用户资料", resp: "Profile", params: []apiParam{ownerParam}},
+	{method: "PUT", path: "/api/profiles/{owner}", tag: "users", summary: "保存用户资料", body: "Profile", resp: "Profile", params: []apiParam{ownerParam}},
+	{method: "DELETE", path: "/api/profiles/{owner}", tag: "users", summary: "删除用户资料", params: []apiParam{ownerParam}},
+	{method: "GET", path: "/api/memory/search", tag: "users", summary: "全文搜索记忆", params: []apiParam{
+		{name: "q", in: "query", required: true},
+		{name: "from", in: "query", desc: "YYYY-MM-DD"},
+		{name: "to", in: "query", desc: "YYYY-MM-DD"},
+		{name: "context", in: "query", typ: "integer", desc: "匹配行前后的行数"},
+		{name: "limit", in: "query", typ: "integer"},
+	}},
+	{method: "DELETE", path: "/api/users/{owner}/data", tag: "users", summary: "清除用户的全部数据", admin: true, params: []apiParam{
+		ownerParam,
+		{name: "confirm", in: "query", typ: "boolean", desc: "必须为 true，否则返回 428", required: true},
+	}},
+
+	{method: "POST", path: "/webhook/feishu", tag: "webhook", summary: "飞书事件推送"},
+	{method: "GET", path: "/api/openapi.json", tag: "status", summary: "本文档"},
+	{method: "GET", path: "/api/docs", tag: "status", summary: "Swagger UI", respType: "text/html"},
+}
+
+// openAPISchemas components/schemas
+var openAPISchemas = map[string]interface{}{
+	"Error": object(map[string]interface{}{
+		"error": object(map[string]interface{}{
+			"code":      prop("string", "错误码，如 not_found、method_not_allowed、csrf_rejected"),
+			"message":   prop("string", ""),
+			"requestId": prop("string", "与响应头 X-Request-ID 相同"),
+		}, "code", "message"),
+	}, "error"),
+	"DebugMessage": object(map[string]interface{}{
+		"id":          prop("integer", ""),
+		"timestamp":   prop("integer", "Unix毫秒"),
+		"time":        prop("string", ""),
+		"type":        prop("string", "user、assistant、error、tool_call、tool_result 等"),
+		"source":      prop("string", ""),
+		"content":     prop("string", ""),
+		"user_id":     prop("string", ""),
+		"channel":     prop("string", ""),
+		"request_id":  prop("string", ""),
+		"tool":        prop("string", ""),
+		"call_id":     prop("integer", ""),
+		"duration_ms": prop("integer", ""),
+		"error":       prop("boolean", ""),
+	}),
+	"LogLevel": object(map[string]interface{}{
+		"level":   prop("string", ""),
+		"modules": map[string]interface{}{"type": "object", "additionalProperties": prop("string", "")},
+	}),
+	"LogLevelUpdate": object(map[string]interface{}{
+		"level":   prop("string", "为空时保持全局级别不变"),
+		"modules": map[string]interface{}{"type": "object", "additionalProperties": prop("string", "default 移除该模块的覆盖")},
+		"persist": prop("boolean", "写回配置文件"),
+	}),
+	"SendRequest": object(map[string]interface{}{
+		"message":  prop("string", ""),
+		"agent_id": prop("string", ""),
+		"stream":   prop("boolean", ""),
+	}, "message"),
+	"SendResponse": object(map[string]interface{}{
+		"response": prop("string", ""),
+	}),
+	"ToolToggle": object(map[string]interface{}{
+		"name":    prop("string", ""),
+		"enabled": prop("boolean", ""),
+	}, "name", "enabled"),
+	"CustomAPI": object(map[string]interface{}{
+		"name":        prop("string", ""),
+		"description": prop("string", ""),
+		"url":         prop("string", ""),
+		"method":      prop("string", ""),
+		"headers":     map[string]interface{}{"type": "object", "additionalProperties": prop("string", "")},
+		"apiKey":      prop("string", ""),
+		"timeout":     prop("integer", "秒"),
+		"enabled":     prop("boolean", ""),
+	}, "name", "url"),
+	"CustomAPIList":  map[string]interface{}{"type": "array", "items": ref("CustomAPI")},
+	"LanguageUpdate": object(map[string]interface{}{"language": prop("string", "")}, "language"),
+	"TerminalInput":  object(map[string]interface{}{"input": prop("string", "")}, "input"),
+	"Profile": object(map[string]interface{}{
+		"owner":       prop("string", ""),
+		"displayName": prop("string", ""),
+		"locale":      prop("string", ""),
+		"timezone":    prop("string", ""),
+		"units":       prop("string", "metric 或 imperial"),
+		"verbosity":   prop("string", "brief、normal 或 detailed"),
+		"updated":     prop("string", ""),
+	}),
+	"ProfileList": map[string]interface{}{"type": "array", "items": ref("Profile")},
+	"SaveConversation": object(map[string]interface{}{
+		"name":     prop("string", ""),
+		"agent_id": prop("string", ""),
+	}, "name"),
+	"LoadConversation": object(map[string]interface{}{"agent_id": prop("string", "")}),
+}
+
+func object(props map[string]interface{}, required ...string) map[string]interface{} {
+	o := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		o["required"] = required
+	}
+	return o
+}
+
+func prop(typ, desc string) map[string]interface{} {
+	p := map[string]interface{}{"type": typ}
+	if desc != "" {
+		p["description"] = desc
+	}
+	return p
+}
+
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+// buildOpenAPI 由 apiOperations 生成 OpenAPI 3 文档
+func buildOpenAPI() map[string]interface{} {
+	errorResponse := map[string]interface{}{
+		"description": "错误",
+		"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": ref("Error")}},
+	}
+
+	paths := map[string]interface{}{}
+	tags := map[string]bool{}
+	for _, op := range apiOperations {
+		item, _ := paths[op.path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[op.path] = item
+		}
+		tags[op.tag] = true
+
+		schema := map[string]interface{}{"type": "object"}
+		if op.resp != "" {
+			schema = ref(op.resp)
+		}
+		respType := op.respType
+		if respType == "" {
+			respType = "application/json"
+		} else if respType != "application/json" {
+			schema = prop("string", "")
+		}
+		operation := map[string]interface{}{
+			"tags":        []string{op.tag},
+			"summary":     op.summary,
+			"operationId": operationID(op),
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "成功",
+					"content":     map[string]interface{}{respType: map[string]interface{}{"schema": schema}},
+				},
+				"default": errorResponse,
+			},
+		}
+		if len(op.params) > 0 {
+			params := make([]interface{}, 0, len(op.params))
+			for _, p := range op.params {
+				typ := p.typ
+				if typ == "" {
+					typ = "string"
+				}
+				param := map[string]interface{}{"name": p.name, "in": p.in, "required": p.required, "schema": prop(typ, "")}
+				if p.desc != "" {
+					param["description"] = p.desc
+				}
+				params = append(params, param)
+			}
+			operation["parameters"] = params
+		}
+		if op.admin {
+			operation["security"] = []interface{}{map[string]interface{}{"adminToken": []string{}}}
+		}
+		if op.body != "" {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": ref(op.body)}},
+			}
+		}
+		item[strings.ToLower(op.method)] = operation
+	}
+
+	tagList := make([]interface{}, 0, len(tags))
+	for _, name := range sortedKeys(tags) {
+		tagList = append(tagList, map[string]interface{}{"name": name})
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Mujibot API",
+			"version":     openAPIVersion,
+			"description": "Mujibot 调试控制台和管理接口。错误统一返回 Error，每个响应带 X-Request-ID 头。",
+		},
+		"tags":  tagList,
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": openAPISchemas,
+			"securitySchemes": map[string]interface{}{
+				"adminToken": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-Admin-Token", "description": "server.adminToken，也可用 Authorization: Bearer"},
+			},
+		},
+	}
+}
+
+// operationID 由方法和路径生成，如 GET /api/sessions/{id}/export -> getSessionsIdExport
+func operationID(op apiOperation) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(op.method))
+	path := strings.TrimPrefix(op.path, "/api")
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '.'
+	}) {
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return sb.String()
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var (
+	openAPIOnce sync.Once
+	openAPIJSON []byte
+)
+
+// handleOpenAPI GET /api/openapi.json
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	openAPIOnce.Do(func() {
+		openAPIJSON, _ = json.MarshalIndent(buildOpenAPI(), "", "  ")
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIJSON)
+}
+
+// swaggerUIPage 从CDN加载 Swagger UI，浏览器需要能访问 unpkg.com
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+    <meta charset="UTF-8">
+    <title>Mujibot API</title>
+    <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+    <div id="swagger-ui"></div>
+    <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+    <script>
+        window.ui = SwaggerUIBundle({ url: '/api/openapi.json', dom_id: '#swagger-ui' });
+    </script>
+</body>
+</html>
+`
+
+// handleAPIDocs GET /api/docs 显示 Swagger UI
+func (s *Server) handleAPIDocs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
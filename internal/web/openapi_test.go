@@ -0,0 +1,81 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+	s := &Server{toolsHandler: &ToolsHandler{}}
+	mux := s.routes()
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var doc struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" || len(doc.Paths) == 0 {
+		t.Fatalf("openapi = %q, paths = %d", doc.OpenAPI, len(doc.Paths))
+	}
+
+	// 引用的 schema 都已定义，operationId 不重复
+	for _, m := range regexp.MustCompile(`"#/components/schemas/(\w+)"`).FindAllStringSubmatch(rec.Body.String(), -1) {
+		if doc.Components.Schemas[m[1]] == nil {
+			t.Errorf("undefined schema %s", m[1])
+		}
+	}
+	ids := map[string]bool{}
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			id, _ := op["operationId"].(string)
+			if ids[id] {
+				t.Errorf("duplicate operationId %s (%s %s)", id, method, path)
+			}
+			ids[id] = true
+		}
+	}
+
+	// 在 routes 中注册的每个接口都要出现在文档中
+	src, err := os.ReadFile("server.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := map[string]bool{"/api/tools/custom": true}
+	for _, m := range regexp.MustCompile(`mux\.HandleFunc\("(/(?:api|webhook)/[^"]*)"`).FindAllStringSubmatch(string(src), -1) {
+		route := m[1]
+		if legacy[route] {
+			continue
+		}
+		found := false
+		for path := range doc.Paths {
+			if path == route || (strings.HasSuffix(route, "/") && strings.HasPrefix(path, route)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("route %s is missing from the OpenAPI document", route)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/docs", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "/api/openapi.json") {
+		t.Errorf("docs status = %d", rec.Code)
+	}
+}
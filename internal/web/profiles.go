@@ -0,0 +1,89 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/HaohanHe/mujibot/internal/httpapi"
+	"github.com/HaohanHe/mujibot/internal/memory"
+)
+
+// profileUpdate 网页编辑的资料字段
+type profileUpdate struct {
+	DisplayName string `json:"displayName"`
+	Locale      string `json:"locale"`
+	Timezone    string `json:"timezone"`
+	Units       string `json:"units"`
+	Verbosity   string `json:"verbosity"`
+}
+
+// handleProfiles 处理用户资料API: GET /api/profiles 列表，GET|PUT|DELETE /api/profiles/{channel:userID}
+func (s *Server) handleProfiles(w http.ResponseWriter, r *http.Request) {
+	if s.memory == nil || !s.memory.IsEnabled() {
+		httpapi.RespondError(w, r, http.StatusServiceUnavailable, httpapi.CodeUnavailable, "Memory not enabled")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	owner, err := url.PathUnescape(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/profiles"), "/"))
+	if err != nil {
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "Invalid user")
+		return
+	}
+	if owner == "" {
+		if r.Method != http.MethodGet {
+			httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+			return
+		}
+		profiles, err := s.memory.ListProfiles()
+		if err != nil {
+			httpapi.RespondError(w, r, http.StatusInternalServerError, httpapi.CodeInternal, err.Error())
+			return
+		}
+		if profiles == nil {
+			profiles = []memory.Profile{}
+		}
+		json.NewEncoder(w).Encode(profiles)
+		return
+	}
+	if !strings.Contains(owner, ":") {
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "User must be channel:userID")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(s.memory.Profile(owner))
+	case http.MethodPut:
+		var req profileUpdate
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "Invalid request body")
+			return
+		}
+		p, err := s.memory.UpdateProfile(owner, func(p *memory.Profile) error {
+			p.DisplayName = strings.TrimSpace(req.DisplayName)
+			p.Locale = strings.TrimSpace(req.Locale)
+			p.Timezone = strings.TrimSpace(req.Timezone)
+			p.Units = strings.ToLower(strings.TrimSpace(req.Units))
+			p.Verbosity = strings.ToLower(strings.TrimSpace(req.Verbosity))
+			return nil
+		})
+		if err != nil {
+			httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, err.Error())
+			return
+		}
+		s.log.Info("profile updated", "user", owner, "by", "web")
+		json.NewEncoder(w).Encode(p)
+	case http.MethodDelete:
+		if err := s.memory.DeleteProfile(owner); err != nil {
+			httpapi.RespondError(w, r, http.StatusInternalServerError, httpapi.CodeInternal, err.Error())
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"user": owner, "status": "deleted"})
+	default:
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+	}
+}
@@ -1,9 +1,10 @@
 package web
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"html/template"
+	"net"
 	"net/http"
 	"runtime"
 	"strconv"
@@ -12,12 +13,20 @@ import (
 	"time"
 
 	"github.com/HaohanHe/mujibot/internal/agent"
+	"github.com/HaohanHe/mujibot/internal/analytics"
 	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/confirmation"
+	"github.com/HaohanHe/mujibot/internal/crash"
 	"github.com/HaohanHe/mujibot/internal/health"
+	"github.com/HaohanHe/mujibot/internal/httpapi"
 	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/memory"
 	"github.com/HaohanHe/mujibot/internal/session"
 )
 
+// defaultLogBuffer 未配置 resources.logBuffer 时内存中保留的调试消息条数
+const defaultLogBuffer = 200
+
 // Server Web服务器
 type Server struct {
 	port         int
@@ -27,36 +36,81 @@ type Server struct {
 	healthCheck  *health.Checker
 	log          *logger.Logger
 	mu           sync.RWMutex
-	clients      map[chan string]bool
+	clients      sseHub // 控制台 /api/messages/stream 连接
 	messages     []DebugMessage
 	maxMsgs      int
+	streamChunk  int // resources.streamChunkBytes
 	feishuHandler http.HandlerFunc
 	toolsHandler  *ToolsHandler
+	memoryGuard   *health.MemoryGuard
+	crash         *crash.Reporter
+	confirmations *confirmation.ConfirmationManager
+	memory        *memory.Manager
+	saved         *session.SavedStore
+	debugStore    *debugStore
+	purger        PurgeFunc
+	analytics     *analytics.Store
+	watchers      map[string]func(DebugMessage) // 流式 /api/send 按 request_id 订阅工具事件
+	nextMsgID     uint64
+	httpServer    *http.Server
+	listener      net.Listener
 }
 
 // DebugMessage 调试消息
 type DebugMessage struct {
+	ID        uint64 `json:"id"`
+	Timestamp int64  `json:"timestamp"` // Unix毫秒
 	Time      string `json:"time"`
 	Type      string `json:"type"`
 	Source    string `json:"source"`
 	Content   string `json:"content"`
 	UserID    string `json:"user_id,omitempty"`
 	Channel   string `json:"channel,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	// 以下仅用于 tool_call/tool_result 事件
+	Tool       string `json:"tool,omitempty"`
+	CallID     uint64 `json:"call_id,omitempty"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
+	Error      bool   `json:"error,omitempty"`
 }
 
 // NewServer 创建Web服务器
 func NewServer(port int, cfg *config.Manager, sessionMgr *session.Manager, agentRouter *agent.Router, healthCheck *health.Checker, log *logger.Logger) *Server {
-	return &Server{
+	s := &Server{
 		port:        port,
 		config:      cfg,
 		sessionMgr:  sessionMgr,
 		agentRouter: agentRouter,
 		healthCheck: healthCheck,
 		log:         log,
-		clients:     make(map[chan string]bool),
-		messages:    make([]DebugMessage, 0, 100),
-		maxMsgs:     100,
+		maxMsgs:     defaultLogBuffer,
+	}
+	if n := cfg.Get().Resources.LogBuffer; n > 0 {
+		s.maxMsgs = n
+	}
+	s.streamChunk = cfg.Get().Resources.StreamChunkBytes
+	s.clients.onSlow = func() {
+		log.Warn("debug console client too slow, disconnected")
 	}
+	s.messages = make([]DebugMessage, 0, s.maxMsgs)
+
+	// 启用持久化时从存储恢复最近的消息，重启后控制台仍能看到历史
+	if debugCfg := cfg.Get().Server.DebugLog; debugCfg.Enabled {
+		store, saved, err := openDebugStore(debugCfg.File, debugCfg.MaxEntries)
+		if err != nil {
+			log.Warn("debug message persistence disabled", "error", err)
+		} else {
+			s.debugStore = store
+			if n := len(saved); n > 0 {
+				s.nextMsgID = saved[n-1].ID
+				if n > s.maxMsgs {
+					saved = saved[n-s.maxMsgs:]
+				}
+				s.messages = append(s.messages, saved...)
+			}
+		}
+	}
+	return s
 }
 
 // SetFeishuHandler 设置飞书Webhook处理器
@@ -69,8 +123,29 @@ func (s *Server) SetToolsHandler(handler *ToolsHandler) {
 	s.toolsHandler = handler
 }
 
-// Start 启动Web服务器
-func (s *Server) Start() error {
+// SetMemoryGuard 设置内存保护器
+func (s *Server) SetMemoryGuard(guard *health.MemoryGuard) {
+	s.memoryGuard = guard
+}
+
+// SetCrashReporter 设置崩溃报告器
+func (s *Server) SetCrashReporter(c *crash.Reporter) {
+	s.crash = c
+}
+
+// SetConfirmations 设置危险操作确认管理器，确认请求推送到调试消息流
+func (s *Server) SetConfirmations(m *confirmation.ConfirmationManager) {
+	s.confirmations = m
+	m.RegisterNotifier(&webNotifier{server: s})
+}
+
+// SetMemory 设置记忆管理器，用于编辑用户资料和搜索记忆
+func (s *Server) SetMemory(m *memory.Manager) {
+	s.memory = m
+}
+
+// routes 注册所有路由
+func (s *Server) routes() *http.ServeMux {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/", s.handleIndex)
@@ -78,26 +153,63 @@ func (s *Server) Start() error {
 
 	mux.HandleFunc("/api/status", s.handleStatus)
 	mux.HandleFunc("/api/logs", s.handleLogs)
+	mux.HandleFunc("/api/logs/query", s.handleLogQuery)
+	mux.HandleFunc("/api/logging/level", s.handleLogLevel)
 	mux.HandleFunc("/api/sessions", s.handleSessions)
+	mux.HandleFunc("/api/sessions/", s.handleSessionExport)
+	mux.HandleFunc("/api/sessions/stats", s.handleSessionStats)
 	mux.HandleFunc("/api/agents", s.handleAgents)
 	mux.HandleFunc("/api/config", s.handleConfig)
+	mux.HandleFunc("/api/llm/test", s.handleLLMTest)
+	mux.HandleFunc("/api/llm/models", s.handleLLMModels)
 	mux.HandleFunc("/api/send", s.handleSendMessage)
 	mux.HandleFunc("/api/messages/stream", s.handleMessageStream)
+	mux.HandleFunc("/api/memory-guard", s.handleMemoryGuard)
+	mux.HandleFunc("/api/crashes", s.handleCrashes)
+	mux.HandleFunc("/api/crashes/", s.handleCrashes)
+	mux.HandleFunc("/api/confirmations", s.handleConfirmations)
+	mux.HandleFunc("/api/confirmations/", s.handleConfirmations)
+	mux.HandleFunc("/api/profiles", s.handleProfiles)
+	mux.HandleFunc("/api/profiles/", s.handleProfiles)
+	mux.HandleFunc("/api/memory/search", s.handleMemorySearch)
+	mux.HandleFunc("/api/users/", s.handleUsers)
+	mux.HandleFunc("/api/conversations/", s.handleConversations)
+	mux.HandleFunc("/api/analytics", s.handleAnalytics)
+	mux.HandleFunc("/api/openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("/api/docs", s.handleAPIDocs)
 
 	mux.HandleFunc("/webhook/feishu", s.handleFeishuWebhook)
 
 	if s.toolsHandler != nil {
 		mux.HandleFunc("/api/tools", s.toolsHandler.ListTools)
 		mux.HandleFunc("/api/tools/toggle", s.toolsHandler.ToggleTool)
-		mux.HandleFunc("/api/tools/custom", s.handleCustomAPIs)
+		mux.HandleFunc("/api/custom-apis", s.handleCustomAPIs)
+		mux.HandleFunc("/api/tools/custom", s.handleCustomAPIs) // 旧路径
 		mux.HandleFunc("/api/llm/presets", s.toolsHandler.ListLLMPresets)
+		mux.HandleFunc("/api/edits", s.toolsHandler.Edits)
+		mux.HandleFunc("/api/edits/", s.toolsHandler.Edits)
+		mux.HandleFunc("/api/terminal/sessions", s.toolsHandler.Terminal)
+		mux.HandleFunc("/api/terminal/sessions/", s.toolsHandler.Terminal)
 		mux.HandleFunc("/api/language", s.handleLanguage)
 	}
+	return mux
+}
 
+// Start 启动Web服务器
+func (s *Server) Start() error {
 	s.log.Info("web server starting", "port", s.port)
 
+	s.httpServer = &http.Server{Addr: fmt.Sprintf(":%d", s.port), Handler: httpapi.WithRequestID(s.withOriginPolicy(s.routes()))}
+
+	// 同步监听，端口被占用等错误直接返回
+	ln, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+	s.listener = ln
+
 	go func() {
-		if err := http.ListenAndServe(fmt.Sprintf(":%d", s.port), mux); err != nil {
+		if err := s.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
 			s.log.Error("web server error", "error", err)
 		}
 	}()
@@ -105,30 +217,59 @@ func (s *Server) Start() error {
 	return nil
 }
 
-// LogMessage 记录调试消息
-func (s *Server) LogMessage(msgType, source, content, userID, channel string) {
-	msg := DebugMessage{
-		Time:    time.Now().Format("15:04:05"),
-		Type:    msgType,
-		Source:  source,
-		Content: content,
-		UserID:  userID,
-		Channel: channel,
+// Addr 返回实际监听的地址，端口配置为 0 时为系统分配的端口；未启动时为空
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return ""
 	}
+	return s.listener.Addr().String()
+}
 
+// Stop 停止Web服务器，等待进行中的请求完成
+func (s *Server) Stop(ctx context.Context) error {
+	if s.debugStore != nil {
+		defer s.debugStore.Close()
+	}
+	if s.httpServer == nil {
+		return nil
+	}
+	// 控制台的长连接不会自己结束
+	s.clients.closeAll()
+	return s.httpServer.Shutdown(ctx)
+}
+
+// LogMessage 记录调试消息，requestID 用于关联同一请求的日志
+func (s *Server) LogMessage(msgType, source, content, userID, channel, requestID string) {
+	s.addMessage(DebugMessage{
+		Time:      time.Now().Format("15:04:05"),
+		Type:      msgType,
+		Source:    source,
+		Content:   content,
+		UserID:    userID,
+		Channel:   channel,
+		RequestID: requestID,
+	})
+}
+
+// addMessage 保存调试消息并广播给所有SSE客户端，启用持久化时同时写入存储
+func (s *Server) addMessage(msg DebugMessage) {
 	s.mu.Lock()
+	s.nextMsgID++
+	msg.ID = s.nextMsgID
+	msg.Timestamp = time.Now().UnixMilli()
 	s.messages = append(s.messages, msg)
 	if len(s.messages) > s.maxMsgs {
 		s.messages = s.messages[len(s.messages)-s.maxMsgs:]
 	}
 
-	// 广播到所有连接的客户端
+	// 广播到所有连接的客户端，只入队不写出，不会被慢客户端阻塞
 	data, _ := json.Marshal(msg)
-	for client := range s.clients {
-		select {
-		case client <- string(data):
-		default:
-			// 客户端缓冲区满，跳过
+	s.clients.broadcast(sseEvent{id: msg.ID, data: data})
+
+	// 在锁内写入，保证文件中的顺序与ID一致
+	if s.debugStore != nil {
+		if err := s.debugStore.Append(msg); err != nil {
+			s.log.Warn("failed to persist debug message", "error", err)
 		}
 	}
 	s.mu.Unlock()
@@ -137,34 +278,21 @@ func (s *Server) LogMessage(msgType, source, content, userID, channel string) {
 // handleIndex 处理首页
 func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
-		http.NotFound(w, r)
+		httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, "Page not found")
 		return
 	}
-
-	tmpl := template.Must(template.New("index").Parse(indexHTML))
-	tmpl.Execute(w, nil)
+	s.serveAsset(w, r, "index.html")
 }
 
 // handleStatic 处理静态文件
 func (s *Server) handleStatic(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/static/")
-
-	switch path {
-	case "style.css":
-		w.Header().Set("Content-Type", "text/css")
-		w.Write([]byte(styleCSS))
-	case "app.js":
-		w.Header().Set("Content-Type", "application/javascript")
-		w.Write([]byte(appJS))
-	default:
-		http.NotFound(w, r)
-	}
+	s.serveAsset(w, r, strings.TrimPrefix(r.URL.Path, "/static/"))
 }
 
 // handleStatus 处理状态API
 func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -181,18 +309,73 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 			"heap_alloc":  m.HeapAlloc,
 			"heap_sys":    m.HeapSys,
 		},
-		"goroutines": runtime.NumGoroutine(),
-		"sessions":   s.sessionMgr.GetStats(),
+		"goroutines":      runtime.NumGoroutine(),
+		"sessions":        s.sessionMgr.GetStats(),
+		"console_clients": s.clients.count(),
+	}
+	if s.healthCheck != nil {
+		status["cpu"] = s.healthCheck.CPU()
+		status["disk"] = s.healthCheck.DiskUsage()
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(status)
 }
 
-// handleLogs 处理日志API
+// handleMemoryGuard 处理内存保护器状态API
+func (s *Server) handleMemoryGuard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if s.memoryGuard == nil {
+		httpapi.RespondError(w, r, http.StatusServiceUnavailable, httpapi.CodeUnavailable, "Memory guard not enabled")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.memoryGuard.GetStats())
+}
+
+// handleCrashes 处理崩溃报告API: GET /api/crashes 列表，GET /api/crashes/{id} 详情
+func (s *Server) handleCrashes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if s.crash == nil {
+		httpapi.RespondError(w, r, http.StatusServiceUnavailable, httpapi.CodeUnavailable, "Crash reporter not enabled")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/crashes"), "/")
+	if id == "" {
+		list, err := s.crash.List()
+		if err != nil {
+			httpapi.RespondError(w, r, http.StatusInternalServerError, httpapi.CodeInternal, err.Error())
+			return
+		}
+		json.NewEncoder(w).Encode(list)
+		return
+	}
+
+	report, err := s.crash.Get(id)
+	if err != nil {
+		httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, "Crash report not found")
+		return
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// handleLogs 调试消息API。不带参数时返回内存中最近的消息；
+// 带 since/until/type/request_id/q/before/offset/limit 时分页查询，启用持久化时查询存储
 func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -202,13 +385,108 @@ func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
 	s.mu.RUnlock()
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(logs)
+	if len(r.URL.Query()) == 0 {
+		json.NewEncoder(w).Encode(logs)
+		return
+	}
+
+	q, err := parseDebugQuery(r.URL.Query())
+	if err != nil {
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, err.Error())
+		return
+	}
+	result := q.apply(logs)
+	if s.debugStore != nil {
+		if result, err = s.debugStore.Query(q); err != nil {
+			httpapi.RespondError(w, r, http.StatusInternalServerError, httpapi.CodeInternal, err.Error())
+			return
+		}
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleLogQuery 查询日志文件: GET /api/logs/query?level=&module=&since=&until=&q=&offset=&limit=
+func (s *Server) handleLogQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	params := r.URL.Query()
+	since, err := parseTime(params.Get("since"))
+	if err != nil {
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "Invalid since: "+err.Error())
+		return
+	}
+	until, err := parseTime(params.Get("until"))
+	if err != nil {
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "Invalid until: "+err.Error())
+		return
+	}
+
+	result, err := s.log.Query(logger.Query{
+		Level:    params.Get("level"),
+		Module:   params.Get("module"),
+		Since:    since,
+		Until:    until,
+		Contains: params.Get("q"),
+		Offset:   parseInt(params.Get("offset"), 0),
+		Limit:    parseInt(params.Get("limit"), 0),
+	})
+	if err == logger.ErrNoLogFile {
+		httpapi.RespondError(w, r, http.StatusServiceUnavailable, httpapi.CodeUnavailable, "Log file not configured")
+		return
+	}
+	if err != nil {
+		httpapi.RespondError(w, r, http.StatusInternalServerError, httpapi.CodeInternal, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// handleLogLevel 查看或调整日志级别: GET/PUT /api/logging/level
+func (s *Server) handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req struct {
+			Level   string            `json:"level"`
+			Modules map[string]string `json:"modules"`
+			Persist bool              `json:"persist"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, err.Error())
+			return
+		}
+		if err := s.log.ApplyLevels(req.Level, req.Modules); err != nil {
+			httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, err.Error())
+			return
+		}
+
+		level, modules := s.log.Levels()
+		s.log.Info("log level changed", "level", level, "modules", modules, "persist", req.Persist)
+		if req.Persist {
+			s.config.UpdateLogging(level, modules)
+		}
+	default:
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	level, modules := s.log.Levels()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"level":   level,
+		"modules": modules,
+	})
 }
 
 // handleSessions 处理会话API
 func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -218,10 +496,76 @@ func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(stats)
 }
 
+// handleSessionStats 会话使用统计: GET /api/sessions/stats?top=10
+func (s *Server) handleSessionStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	top := 10
+	if v := r.URL.Query().Get("top"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "Invalid top")
+			return
+		}
+		top = n
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.sessionMgr.Stats(top))
+}
+
+// handleSessionExport 导出会话: GET /api/sessions/{id}/export?format=md|json
+func (s *Server) handleSessionExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/sessions/")
+	if !strings.HasSuffix(path, "/export") {
+		httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, "API not found")
+		return
+	}
+
+	id := strings.TrimSuffix(path, "/export")
+	if id == "" {
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "Invalid session id")
+		return
+	}
+
+	sess := s.sessionMgr.GetByID(id)
+	if sess == nil {
+		httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, "Session not found")
+		return
+	}
+
+	filename := "session-" + strings.ReplaceAll(id, ":", "-")
+	switch r.URL.Query().Get("format") {
+	case "json":
+		data, err := s.sessionMgr.ExportJSON(sess)
+		if err != nil {
+			httpapi.RespondError(w, r, http.StatusInternalServerError, httpapi.CodeInternal, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+		w.Write(data)
+	case "", "md", "markdown":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.md"`)
+		w.Write([]byte(s.sessionMgr.ExportMarkdown(sess)))
+	default:
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "Unsupported format")
+	}
+}
+
 // handleAgents 处理智能体API
 func (s *Server) handleAgents(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -242,7 +586,7 @@ func (s *Server) handleAgents(w http.ResponseWriter, r *http.Request) {
 // handleConfig 处理配置API
 func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -280,7 +624,7 @@ func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
 // handleSendMessage 处理发送消息API
 func (s *Server) handleSendMessage(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -291,53 +635,35 @@ func (s *Server) handleSendMessage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, err.Error())
 		return
 	}
 
 	agent, err := s.agentRouter.Route("web_user", "web", req.AgentID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, err.Error())
 		return
 	}
 
-	s.LogMessage("user", "web", req.Message, "web_user", "web")
+	// 中间件已分配请求ID，错误响应和调试消息使用同一个
+	ctx := r.Context()
+	requestID := logger.RequestID(ctx)
+	if requestID == "" {
+		requestID = logger.NewRequestID()
+		ctx = logger.WithRequestID(ctx, requestID)
+	}
+	s.LogMessage("user", "web", req.Message, "web_user", "web", requestID)
 
 	if req.Stream {
-		w.Header().Set("Content-Type", "text/event-stream")
-		w.Header().Set("Cache-Control", "no-cache")
-		w.Header().Set("Connection", "keep-alive")
-
-		flusher, ok := w.(http.Flusher)
-		if !ok {
-			http.Error(w, "Streaming not supported", http.StatusInternalServerError)
-			return
-		}
-
-		var fullResponse string
-		response, err := s.agentRouter.ProcessMessageStream(agent, "web_user", "web", req.Message, func(chunk string) {
-			fullResponse += chunk
-			fmt.Fprintf(w, "data: %s\n\n", chunk)
-			flusher.Flush()
-		})
-
-		if err != nil {
-			fmt.Fprintf(w, "data: [ERROR: %s]\n\n", err.Error())
-			flusher.Flush()
-			return
-		}
-
-		s.LogMessage("assistant", "web", response, "web_user", "web")
-		fmt.Fprintf(w, "data: [DONE]\n\n")
-		flusher.Flush()
+		s.streamMessage(ctx, w, agent, req.Message, requestID)
 	} else {
-		response, err := s.agentRouter.ProcessMessage(agent, "web_user", "web", req.Message)
+		response, err := s.agentRouter.ProcessMessage(ctx, agent, "web_user", "web_user", "web", req.Message)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			httpapi.RespondError(w, r, http.StatusInternalServerError, httpapi.CodeInternal, err.Error())
 			return
 		}
 
-		s.LogMessage("assistant", "web", response, "web_user", "web")
+		s.LogMessage("assistant", "web", response, "web_user", "web", requestID)
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]string{"response": response})
@@ -347,7 +673,7 @@ func (s *Server) handleSendMessage(w http.ResponseWriter, r *http.Request) {
 // handleMessageStream 处理消息流（SSE）
 func (s *Server) handleMessageStream(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -355,37 +681,53 @@ func (s *Server) handleMessageStream(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 
-	// 创建客户端通道
-	client := make(chan string, 10)
-	s.mu.Lock()
-	s.clients[client] = true
-	s.mu.Unlock()
+	// 先登记再取历史消息，两者重叠的部分按ID去重
+	client := s.clients.add()
+	defer s.clients.remove(client)
 
-	defer func() {
-		s.mu.Lock()
-		delete(s.clients, client)
-		s.mu.Unlock()
-		close(client)
-	}()
+	s.mu.RLock()
+	backlog := make([]DebugMessage, len(s.messages))
+	copy(backlog, s.messages)
+	s.mu.RUnlock()
+
+	// 写出时不持有锁，慢客户端只会阻塞自己
+	rc := http.NewResponseController(w)
+	write := func(data []byte) error {
+		rc.SetWriteDeadline(time.Now().Add(sseWriteTimeout))
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
 
 	// 发送现有消息
-	s.mu.RLock()
-	for _, msg := range s.messages {
+	var lastID uint64
+	for _, msg := range backlog {
 		data, _ := json.Marshal(msg)
-		fmt.Fprintf(w, "data: %s\n\n", data)
+		if err := write(data); err != nil {
+			return
+		}
+		lastID = msg.ID
+	}
+	if len(backlog) == 0 {
+		if err := rc.Flush(); err != nil {
+			return
+		}
 	}
-	s.mu.RUnlock()
-	w.(http.Flusher).Flush()
 
 	// 等待新消息
 	for {
 		select {
-		case msg, ok := <-client:
-			if !ok {
+		case e := <-client.queue:
+			if e.id <= lastID {
+				continue
+			}
+			if err := write(e.data); err != nil {
 				return
 			}
-			fmt.Fprintf(w, "data: %s\n\n", msg)
-			w.(http.Flusher).Flush()
+			lastID = e.id
+		case <-client.done:
+			return
 		case <-r.Context().Done():
 			return
 		}
@@ -395,7 +737,7 @@ func (s *Server) handleMessageStream(w http.ResponseWriter, r *http.Request) {
 // handleFeishuWebhook 处理飞书Webhook
 func (s *Server) handleFeishuWebhook(w http.ResponseWriter, r *http.Request) {
 	if s.feishuHandler == nil {
-		http.Error(w, "Feishu not enabled", http.StatusServiceUnavailable)
+		httpapi.RespondError(w, r, http.StatusServiceUnavailable, httpapi.CodeUnavailable, "Feishu not enabled")
 		return
 	}
 	s.feishuHandler(w, r)
@@ -404,7 +746,7 @@ func (s *Server) handleFeishuWebhook(w http.ResponseWriter, r *http.Request) {
 // handleCustomAPIs 处理自定义API
 func (s *Server) handleCustomAPIs(w http.ResponseWriter, r *http.Request) {
 	if s.toolsHandler == nil {
-		http.Error(w, "Tools handler not initialized", http.StatusServiceUnavailable)
+		httpapi.RespondError(w, r, http.StatusServiceUnavailable, httpapi.CodeUnavailable, "Tools handler not initialized")
 		return
 	}
 
@@ -418,14 +760,14 @@ func (s *Server) handleCustomAPIs(w http.ResponseWriter, r *http.Request) {
 	case http.MethodDelete:
 		s.toolsHandler.DeleteCustomAPI(w, r)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
 	}
 }
 
 // handleLanguage 处理语言设置
 func (s *Server) handleLanguage(w http.ResponseWriter, r *http.Request) {
 	if s.toolsHandler == nil {
-		http.Error(w, "Tools handler not initialized", http.StatusServiceUnavailable)
+		httpapi.RespondError(w, r, http.StatusServiceUnavailable, httpapi.CodeUnavailable, "Tools handler not initialized")
 		return
 	}
 
@@ -435,513 +777,10 @@ func (s *Server) handleLanguage(w http.ResponseWriter, r *http.Request) {
 	case http.MethodPost:
 		s.toolsHandler.SetLanguage(w, r)
 	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-	}
-}
-
-// indexHTML 首页HTML
-const indexHTML = `<!DOCTYPE html>
-<html lang="zh-CN">
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>Mujibot 调试控制台</title>
-    <link rel="stylesheet" href="/static/style.css">
-</head>
-<body>
-    <div class="container">
-        <header>
-            <h1>🤖 Mujibot 调试控制台</h1>
-            <div class="status-indicator" id="status">● 连接中</div>
-        </header>
-
-        <div class="main-content">
-            <div class="left-panel">
-                <div class="panel">
-                    <h2>系统状态</h2>
-                    <div id="system-status" class="status-grid">
-                        <div class="status-item">
-                            <span class="label">内存使用:</span>
-                            <span class="value" id="memory">-</span>
-                        </div>
-                        <div class="status-item">
-                            <span class="label">Goroutines:</span>
-                            <span class="value" id="goroutines">-</span>
-                        </div>
-                        <div class="status-item">
-                            <span class="label">会话数:</span>
-                            <span class="value" id="sessions">-</span>
-                        </div>
-                    </div>
-                </div>
-
-                <div class="panel">
-                    <h2>配置信息</h2>
-                    <div id="config-info" class="config-info">加载中...</div>
-                </div>
-
-                <div class="panel">
-                    <h2>智能体列表</h2>
-                    <div id="agent-list" class="agent-list">加载中...</div>
-                </div>
-            </div>
-
-            <div class="right-panel">
-                <div class="panel chat-panel">
-                    <h2>消息调试</h2>
-                    <div id="message-log" class="message-log"></div>
-                    <div class="input-area">
-                        <select id="agent-select">
-                            <option value="">默认智能体</option>
-                        </select>
-                        <input type="text" id="message-input" placeholder="输入消息测试..." maxlength="500">
-                        <button id="send-btn">发送</button>
-                    </div>
-                </div>
-            </div>
-        </div>
-
-        <footer>
-            <p>Mujibot Lightweight AI Assistant | <a href="/api/status" target="_blank">API状态</a></p>
-        </footer>
-    </div>
-
-    <script src="/static/app.js"></script>
-</body>
-</html>`
-
-// styleCSS 样式CSS
-const styleCSS = `
-* {
-    margin: 0;
-    padding: 0;
-    box-sizing: border-box;
-}
-
-body {
-    font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif;
-    background: #1a1a2e;
-    color: #eee;
-    min-height: 100vh;
-}
-
-.container {
-    max-width: 1400px;
-    margin: 0 auto;
-    padding: 20px;
-}
-
-header {
-    display: flex;
-    justify-content: space-between;
-    align-items: center;
-    padding: 20px 0;
-    border-bottom: 2px solid #16213e;
-    margin-bottom: 20px;
-}
-
-header h1 {
-    font-size: 24px;
-    color: #00d9ff;
-}
-
-.status-indicator {
-    display: flex;
-    align-items: center;
-    gap: 8px;
-    font-size: 14px;
-}
-
-.status-indicator.connected {
-    color: #00ff88;
-}
-
-.status-indicator.disconnected {
-    color: #ff4757;
-}
-
-.main-content {
-    display: grid;
-    grid-template-columns: 350px 1fr;
-    gap: 20px;
-}
-
-.panel {
-    background: #16213e;
-    border-radius: 12px;
-    padding: 20px;
-    margin-bottom: 20px;
-}
-
-.panel h2 {
-    font-size: 16px;
-    color: #00d9ff;
-    margin-bottom: 15px;
-    padding-bottom: 10px;
-    border-bottom: 1px solid #0f3460;
-}
-
-.status-grid {
-    display: grid;
-    gap: 10px;
-}
-
-.status-item {
-    display: flex;
-    justify-content: space-between;
-    padding: 8px 0;
-    border-bottom: 1px solid #0f3460;
-}
-
-.status-item:last-child {
-    border-bottom: none;
-}
-
-.status-item .label {
-    color: #888;
-}
-
-.status-item .value {
-    color: #00ff88;
-    font-family: monospace;
-}
-
-.config-info, .agent-list {
-    font-size: 13px;
-    line-height: 1.6;
-}
-
-.config-item {
-    padding: 5px 0;
-    border-bottom: 1px solid #0f3460;
-}
-
-.config-item:last-child {
-    border-bottom: none;
-}
-
-.config-key {
-    color: #888;
-}
-
-.config-value {
-    color: #00d9ff;
-}
-
-.agent-item {
-    padding: 10px;
-    background: #0f3460;
-    border-radius: 6px;
-    margin-bottom: 8px;
-}
-
-.agent-item:last-child {
-    margin-bottom: 0;
-}
-
-.agent-name {
-    font-weight: bold;
-    color: #00d9ff;
-}
-
-.agent-model {
-    font-size: 12px;
-    color: #888;
-    margin-top: 4px;
-}
-
-.chat-panel {
-    height: calc(100vh - 200px);
-    display: flex;
-    flex-direction: column;
-}
-
-.message-log {
-    flex: 1;
-    overflow-y: auto;
-    background: #0a0e27;
-    border-radius: 8px;
-    padding: 15px;
-    margin-bottom: 15px;
-    font-family: monospace;
-    font-size: 13px;
-}
-
-.message-item {
-    margin-bottom: 10px;
-    padding: 10px;
-    border-radius: 6px;
-    animation: fadeIn 0.3s ease;
-}
-
-@keyframes fadeIn {
-    from { opacity: 0; transform: translateY(-10px); }
-    to { opacity: 1; transform: translateY(0); }
-}
-
-.message-item.user {
-    background: #0f3460;
-    border-left: 3px solid #00d9ff;
-}
-
-.message-item.assistant {
-    background: #1a472a;
-    border-left: 3px solid #00ff88;
-}
-
-.message-item.system {
-    background: #3d2817;
-    border-left: 3px solid #ffa502;
-}
-
-.message-item.error {
-    background: #3d1717;
-    border-left: 3px solid #ff4757;
-}
-
-.message-header {
-    display: flex;
-    justify-content: space-between;
-    margin-bottom: 5px;
-    font-size: 11px;
-    color: #888;
-}
-
-.message-content {
-    white-space: pre-wrap;
-    word-break: break-word;
-    line-height: 1.5;
-}
-
-.input-area {
-    display: flex;
-    gap: 10px;
-}
-
-#agent-select {
-    width: 120px;
-    padding: 10px;
-    background: #0f3460;
-    border: 1px solid #16213e;
-    border-radius: 6px;
-    color: #eee;
-}
-
-#message-input {
-    flex: 1;
-    padding: 10px;
-    background: #0f3460;
-    border: 1px solid #16213e;
-    border-radius: 6px;
-    color: #eee;
-    font-size: 14px;
-}
-
-#message-input:focus {
-    outline: none;
-    border-color: #00d9ff;
-}
-
-#send-btn {
-    padding: 10px 20px;
-    background: #00d9ff;
-    color: #1a1a2e;
-    border: none;
-    border-radius: 6px;
-    cursor: pointer;
-    font-weight: bold;
-    transition: background 0.2s;
-}
-
-#send-btn:hover {
-    background: #00b8d9;
-}
-
-#send-btn:disabled {
-    background: #555;
-    cursor: not-allowed;
-}
-
-footer {
-    text-align: center;
-    padding: 20px;
-    color: #666;
-    font-size: 12px;
-}
-
-footer a {
-    color: #00d9ff;
-    text-decoration: none;
-}
-
-footer a:hover {
-    text-decoration: underline;
-}
-
-/* 滚动条样式 */
-::-webkit-scrollbar {
-    width: 8px;
-}
-
-::-webkit-scrollbar-track {
-    background: #0a0e27;
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+	}
 }
 
-::-webkit-scrollbar-thumb {
-    background: #16213e;
-    border-radius: 4px;
-}
-
-::-webkit-scrollbar-thumb:hover {
-    background: #0f3460;
-}
-
-/* 响应式 */
-@media (max-width: 900px) {
-    .main-content {
-        grid-template-columns: 1fr;
-    }
-    
-    .left-panel {
-        order: 2;
-    }
-    
-    .right-panel {
-        order: 1;
-    }
-}
-`
-
-// appJS JavaScript
-const appJS = `
-let eventSource = null;
-let agents = [];
-
-function init() {
-    connectEventStream();
-    loadStatus();
-    loadConfig();
-    loadAgents();
-    setInterval(loadStatus, 5000);
-    document.getElementById('send-btn').addEventListener('click', sendMessage);
-    document.getElementById('message-input').addEventListener('keypress', function(e) {
-        if (e.key === 'Enter') sendMessage();
-    });
-}
-
-function connectEventStream() {
-    eventSource = new EventSource('/api/messages/stream');
-    eventSource.onopen = function() { updateStatus('connected'); };
-    eventSource.onmessage = function(event) {
-        var msg = JSON.parse(event.data);
-        addMessageToLog(msg);
-    };
-    eventSource.onerror = function() {
-        updateStatus('disconnected');
-        setTimeout(connectEventStream, 3000);
-    };
-}
-
-function updateStatus(status) {
-    var indicator = document.getElementById('status');
-    if (status === 'connected') {
-        indicator.textContent = '● 已连接';
-        indicator.className = 'status-indicator connected';
-    } else {
-        indicator.textContent = '● 已断开';
-        indicator.className = 'status-indicator disconnected';
-    }
-}
-
-function loadStatus() {
-    fetch('/api/status').then(function(resp) { return resp.json(); }).then(function(data) {
-        document.getElementById('memory').textContent = formatBytes(data.memory.heap_alloc);
-        document.getElementById('goroutines').textContent = data.goroutines;
-        document.getElementById('sessions').textContent = data.sessions.total_sessions;
-    }).catch(function(err) { console.error('Failed to load status:', err); });
-}
-
-function loadConfig() {
-    fetch('/api/config').then(function(resp) { return resp.json(); }).then(function(data) {
-        var configHtml = '<div class="config-item"><span class="config-key">服务器端口:</span>' +
-            '<span class="config-value">' + data.server.port + '</span></div>' +
-            '<div class="config-item"><span class="config-key">LLM提供商:</span>' +
-            '<span class="config-value">' + data.llm.provider + '</span></div>' +
-            '<div class="config-item"><span class="config-key">模型:</span>' +
-            '<span class="config-value">' + data.llm.model + '</span></div>' +
-            '<div class="config-item"><span class="config-key">智能体数量:</span>' +
-            '<span class="config-value">' + data.agents + '</span></div>';
-        document.getElementById('config-info').innerHTML = configHtml;
-    }).catch(function(err) { console.error('Failed to load config:', err); });
-}
-
-function loadAgents() {
-    fetch('/api/agents').then(function(resp) { return resp.json(); }).then(function(data) {
-        agents = data;
-        var agentListHtml = agents.map(function(a) {
-            return '<div class="agent-item"><div class="agent-name">' + a.name + '</div>' +
-                '<div class="agent-model">' + a.model + '</div></div>';
-        }).join('');
-        document.getElementById('agent-list').innerHTML = agentListHtml || '<div class="agent-item">暂无智能体</div>';
-        var select = document.getElementById('agent-select');
-        select.innerHTML = '<option value="">默认智能体</option>';
-        agents.forEach(function(a) {
-            var option = document.createElement('option');
-            option.value = a.id;
-            option.textContent = a.name;
-            select.appendChild(option);
-        });
-    }).catch(function(err) { console.error('Failed to load agents:', err); });
-}
-
-function sendMessage() {
-    var input = document.getElementById('message-input');
-    var btn = document.getElementById('send-btn');
-    var agentSelect = document.getElementById('agent-select');
-    var message = input.value.trim();
-    if (!message) return;
-    btn.disabled = true;
-    input.value = '';
-    fetch('/api/send', {
-        method: 'POST',
-        headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify({ message: message, agent_id: agentSelect.value })
-    }).then(function(resp) {
-        if (!resp.ok) throw new Error('Failed to send message');
-    }).catch(function(err) {
-        console.error('Failed to send message:', err);
-        addMessageToLog({ type: 'error', time: new Date().toLocaleTimeString(), content: '发送失败: ' + err.message });
-    }).finally(function() { btn.disabled = false; });
-}
-
-function addMessageToLog(msg) {
-    var log = document.getElementById('message-log');
-    var item = document.createElement('div');
-    item.className = 'message-item ' + msg.type;
-    var header = document.createElement('div');
-    header.className = 'message-header';
-    var userIdText = msg.user_id ? '(' + msg.user_id + ')' : '';
-    header.innerHTML = '<span>' + (msg.source || msg.type) + ' ' + userIdText + '</span><span>' + msg.time + '</span>';
-    var content = document.createElement('div');
-    content.className = 'message-content';
-    content.textContent = msg.content;
-    item.appendChild(header);
-    item.appendChild(content);
-    log.appendChild(item);
-    log.scrollTop = log.scrollHeight;
-}
-
-function formatBytes(bytes) {
-    if (bytes === 0) return '0 B';
-    var k = 1024;
-    var sizes = ['B', 'KB', 'MB', 'GB'];
-    var i = Math.floor(Math.log(bytes) / Math.log(k));
-    return parseFloat((bytes / Math.pow(k, i)).toFixed(2)) + ' ' + sizes[i];
-}
-
-init();
-`
-
 // formatBytes 格式化字节
 func formatBytes(bytes uint64) string {
 	if bytes == 0 {
@@ -957,6 +796,17 @@ func formatBytes(bytes uint64) string {
 	return fmt.Sprintf("%d %s", bytes, sizes[i])
 }
 
+// parseTime 解析RFC3339时间或Unix秒，空字符串返回零值
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
 // parseInt 解析整数
 func parseInt(s string, defaultVal int) int {
 	v, err := strconv.Atoi(s)
@@ -0,0 +1,118 @@
+package web
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// sseQueueSize 每个控制台连接等待发送的消息数，排满说明客户端跟不上，断开后由浏览器重连补发
+	sseQueueSize = 256
+	// sseWriteTimeout 单次写入的超时，浏览器标签页挂起时 TCP 窗口占满，写入会一直阻塞
+	sseWriteTimeout = 10 * time.Second
+)
+
+// sseEvent 编码好的调试消息
+type sseEvent struct {
+	id   uint64
+	data []byte
+}
+
+// sseClient 一个 /api/messages/stream 连接，由自己的协程从队列取消息写出
+type sseClient struct {
+	queue chan sseEvent
+	// done 被断开时关闭；queue 不关闭，避免与并发的广播竞争
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// close 关闭 done，返回是否是这次调用关闭的
+func (c *sseClient) close() bool {
+	closed := false
+	c.closeOnce.Do(func() {
+		close(c.done)
+		closed = true
+	})
+	return closed
+}
+
+// sseHub 控制台连接的集合。连接列表写时复制，广播不加锁、不阻塞，
+// 一个卡住的标签页不会拖慢 LogMessage
+type sseHub struct {
+	mu      sync.Mutex // 只保护增删
+	clients atomic.Pointer[[]*sseClient]
+	// onSlow 客户端因队列排满被断开时调用
+	onSlow func()
+}
+
+// add 登记新连接
+func (h *sseHub) add() *sseClient {
+	c := &sseClient{queue: make(chan sseEvent, sseQueueSize), done: make(chan struct{})}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var list []*sseClient
+	if old := h.clients.Load(); old != nil {
+		list = append(list, *old...)
+	}
+	list = append(list, c)
+	h.clients.Store(&list)
+	return c
+}
+
+// remove 移除连接并通知其协程退出，可重复调用
+func (h *sseHub) remove(c *sseClient) {
+	c.close()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	old := h.clients.Load()
+	if old == nil {
+		return
+	}
+	list := make([]*sseClient, 0, len(*old))
+	for _, other := range *old {
+		if other != c {
+			list = append(list, other)
+		}
+	}
+	h.clients.Store(&list)
+}
+
+// broadcast 把消息放入每个连接的队列，队列已满的连接被断开
+func (h *sseHub) broadcast(e sseEvent) {
+	list := h.clients.Load()
+	if list == nil {
+		return
+	}
+	for _, c := range *list {
+		select {
+		case c.queue <- e:
+		case <-c.done:
+		default:
+			// 先关闭 done，之后的广播直接跳过；从列表移除需要加锁，放到协程里
+			if c.close() {
+				go h.remove(c)
+				if h.onSlow != nil {
+					h.onSlow()
+				}
+			}
+		}
+	}
+}
+
+// count 当前连接数
+func (h *sseHub) count() int {
+	if list := h.clients.Load(); list != nil {
+		return len(*list)
+	}
+	return 0
+}
+
+// closeAll 断开所有连接，用于关闭服务器，否则 Shutdown 会一直等待长连接
+func (h *sseHub) closeAll() {
+	if list := h.clients.Load(); list != nil {
+		for _, c := range *list {
+			h.remove(c)
+		}
+	}
+}
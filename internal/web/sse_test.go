@@ -0,0 +1,55 @@
+package web
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSSEHubSlowClient(t *testing.T) {
+	var slow int
+	h := &sseHub{onSlow: func() { slow++ }}
+	stalled := h.add()
+	fast := h.add()
+
+	// 卡住的客户端不读队列，广播仍然不阻塞
+	received := 0
+	start := time.Now()
+	for i := 1; i <= sseQueueSize+10; i++ {
+		h.broadcast(sseEvent{id: uint64(i), data: []byte("{}")})
+		if e := <-fast.queue; e.id == uint64(i) {
+			received++
+		}
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("broadcast blocked for %v", d)
+	}
+	if received != sseQueueSize+10 {
+		t.Errorf("fast client received %d events", received)
+	}
+
+	select {
+	case <-stalled.done:
+	default:
+		t.Fatal("stalled client not disconnected")
+	}
+	if slow != 1 {
+		t.Errorf("onSlow called %d times, want 1", slow)
+	}
+	deadline := time.Now().Add(time.Second)
+	for h.count() != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if h.count() != 1 {
+		t.Errorf("count() = %d, want 1", h.count())
+	}
+
+	h.closeAll()
+	select {
+	case <-fast.done:
+	default:
+		t.Error("closeAll did not disconnect client")
+	}
+	if h.count() != 0 {
+		t.Errorf("count() after closeAll = %d", h.count())
+	}
+}
@@ -0,0 +1,758 @@
+let eventSource = null;
+let agents = [];
+
+function init() {
+    connectEventStream();
+    loadStatus();
+    loadConfig();
+    loadAgents();
+    setInterval(loadStatus, 5000);
+    document.getElementById('send-btn').addEventListener('click', sendMessage);
+    document.getElementById('message-input').addEventListener('keypress', function(e) {
+        if (e.key === 'Enter') sendMessage();
+    });
+    initTabs();
+    document.getElementById('log-query-btn').addEventListener('click', function() { queryLogs(0); });
+    document.getElementById('log-prev').addEventListener('click', function() { queryLogs(logOffset - logLimit); });
+    document.getElementById('log-next').addEventListener('click', function() { queryLogs(logOffset + logLimit); });
+    document.getElementById('load-earlier').addEventListener('click', loadEarlierMessages);
+    initTerminal();
+    initProfiles();
+    initTools();
+}
+
+function initTabs() {
+    document.querySelectorAll('.tab').forEach(function(tab) {
+        tab.addEventListener('click', function() {
+            document.querySelectorAll('.tab').forEach(function(t) { t.classList.remove('active'); });
+            document.querySelectorAll('.tab-content').forEach(function(c) { c.classList.remove('active'); });
+            tab.classList.add('active');
+            document.getElementById(tab.dataset.tab).classList.add('active');
+            if (tab.dataset.tab === 'logs-tab') queryLogs(logOffset);
+            if (tab.dataset.tab === 'terminal-tab') loadTerminalSessions();
+            if (tab.dataset.tab === 'profiles-tab') loadProfiles();
+            if (tab.dataset.tab === 'tools-tab') loadTools();
+        });
+    });
+}
+
+var logOffset = 0;
+var logLimit = 100;
+
+function queryLogs(offset) {
+    logOffset = Math.max(0, offset);
+    var params = new URLSearchParams({ offset: logOffset, limit: logLimit });
+    var level = document.getElementById('log-level').value;
+    var module = document.getElementById('log-module').value.trim();
+    var search = document.getElementById('log-search').value.trim();
+    var since = document.getElementById('log-since').value;
+    if (level) params.set('level', level);
+    if (module) params.set('module', module);
+    if (search) params.set('q', search);
+    if (since) params.set('since', new Date(since).toISOString());
+
+    var viewer = document.getElementById('log-viewer');
+    fetch('/api/logs/query?' + params.toString()).then(function(resp) {
+        if (!resp.ok) return responseError(resp);
+        return resp.json();
+    }).then(function(data) {
+        viewer.innerHTML = '';
+        data.entries.forEach(function(entry) { viewer.appendChild(renderLogLine(entry)); });
+        if (data.entries.length === 0) viewer.textContent = '没有匹配的日志';
+        var end = Math.min(data.offset + data.entries.length, data.total);
+        document.getElementById('log-page-info').textContent = (data.total ? data.offset + 1 : 0) + '-' + end + ' / ' + data.total;
+        document.getElementById('log-prev').disabled = data.offset === 0;
+        document.getElementById('log-next').disabled = end >= data.total;
+    }).catch(function(err) {
+        viewer.textContent = '查询失败: ' + err.message;
+    });
+}
+
+function renderLogLine(entry) {
+    var line = document.createElement('div');
+    line.className = 'log-line ' + entry.level;
+    var parts = [
+        ['log-time', new Date(entry.time).toLocaleString() + ' '],
+        ['log-level', entry.level],
+        ['log-module', entry.module ? '[' + entry.module + '] ' : ''],
+        ['log-message', entry.message],
+        ['log-fields', entry.fields ? ' ' + JSON.stringify(entry.fields) : '']
+    ];
+    parts.forEach(function(p) {
+        var span = document.createElement('span');
+        span.className = p[0];
+        span.textContent = p[1];
+        line.appendChild(span);
+    });
+    return line;
+}
+
+var terminalStream = null;
+
+function initTerminal() {
+    var token = document.getElementById('admin-token');
+    token.value = localStorage.getItem('mujibot-admin-token') || '';
+    token.addEventListener('change', function() { localStorage.setItem('mujibot-admin-token', token.value); });
+    document.getElementById('terminal-refresh').addEventListener('click', loadTerminalSessions);
+    document.getElementById('terminal-session').addEventListener('change', function(e) { streamTerminal(e.target.value); });
+    document.getElementById('terminal-cancel').addEventListener('click', function() {
+        if (confirm('确定取消该会话？')) terminalAction('cancel', {});
+    });
+    document.getElementById('terminal-send').addEventListener('click', sendTerminalInput);
+    document.getElementById('terminal-input').addEventListener('keypress', function(e) {
+        if (e.key === 'Enter') sendTerminalInput();
+    });
+}
+
+function loadTerminalSessions() {
+    var select = document.getElementById('terminal-session');
+    var status = document.getElementById('terminal-status');
+    fetch('/api/terminal/sessions').then(function(resp) {
+        if (!resp.ok) return responseError(resp);
+        return resp.json();
+    }).then(function(data) {
+        var current = select.value;
+        select.innerHTML = '<option value="">选择会话</option>';
+        data.sessions.forEach(function(s) {
+            var option = document.createElement('option');
+            option.value = s.id;
+            option.textContent = s.id + (s.running ? ' [运行中] ' : ' [已结束] ') + s.command;
+            select.appendChild(option);
+        });
+        select.value = current;
+        var readOnly = !data.canControl;
+        ['terminal-input', 'terminal-send', 'terminal-cancel', 'admin-token'].forEach(function(id) {
+            document.getElementById(id).disabled = readOnly;
+        });
+        if (!select.value) status.textContent = data.sessions.length + ' 个会话' + (readOnly ? '（只读，未配置 server.adminToken）' : '');
+    }).catch(function(err) {
+        status.textContent = '加载失败: ' + err.message;
+    });
+}
+
+function streamTerminal(id) {
+    if (terminalStream) {
+        terminalStream.close();
+        terminalStream = null;
+    }
+    var output = document.getElementById('terminal-output');
+    var status = document.getElementById('terminal-status');
+    output.textContent = '';
+    if (!id) return;
+    terminalStream = new EventSource('/api/terminal/sessions/' + encodeURIComponent(id) + '/stream');
+    terminalStream.onmessage = function(event) {
+        var data = JSON.parse(event.data);
+        output.textContent = data.output;
+        output.scrollTop = output.scrollHeight;
+        status.textContent = data.session.command + ' · ' + (data.session.running ? '运行中' : '已结束') +
+            (data.session.pty ? ' · PTY' : '') + (data.session.owner ? ' · ' + data.session.owner : '');
+    };
+    terminalStream.addEventListener('end', function() {
+        terminalStream.close();
+        terminalStream = null;
+        status.textContent += ' · 已结束';
+        loadTerminalSessions();
+    });
+    terminalStream.onerror = function() {
+        if (terminalStream) terminalStream.close();
+        terminalStream = null;
+    };
+}
+
+function terminalAction(action, body) {
+    var id = document.getElementById('terminal-session').value;
+    if (!id) return Promise.resolve();
+    return fetch('/api/terminal/sessions/' + encodeURIComponent(id) + '/' + action, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json', 'X-Admin-Token': document.getElementById('admin-token').value },
+        body: JSON.stringify(body)
+    }).then(function(resp) {
+        if (!resp.ok) return responseError(resp);
+        if (action === 'cancel') loadTerminalSessions();
+    }).catch(function(err) {
+        document.getElementById('terminal-status').textContent = '操作失败: ' + err.message;
+    });
+}
+
+function sendTerminalInput() {
+    var input = document.getElementById('terminal-input');
+    var value = input.value;
+    input.value = '';
+    terminalAction('input', { input: value });
+}
+
+var profileFields = { displayName: 'profile-name', locale: 'profile-locale', timezone: 'profile-timezone', units: 'profile-units', verbosity: 'profile-verbosity' };
+
+function initProfiles() {
+    document.getElementById('profile-select').addEventListener('change', function(e) {
+        document.getElementById('profile-owner').value = e.target.value;
+        loadProfile();
+    });
+    document.getElementById('profile-load').addEventListener('click', loadProfile);
+    document.getElementById('profile-save').addEventListener('click', function() { profileRequest('PUT'); });
+    document.getElementById('profile-delete').addEventListener('click', function() { profileRequest('DELETE'); });
+}
+
+function loadProfiles() {
+    var select = document.getElementById('profile-select');
+    fetch('/api/profiles').then(function(resp) {
+        if (!resp.ok) return responseError(resp);
+        return resp.json();
+    }).then(function(profiles) {
+        var current = select.value;
+        select.innerHTML = '<option value="">选择用户</option>';
+        profiles.forEach(function(p) {
+            var option = document.createElement('option');
+            option.value = p.owner;
+            option.textContent = p.owner + (p.displayName ? ' (' + p.displayName + ')' : '');
+            select.appendChild(option);
+        });
+        select.value = current;
+    }).catch(function(err) {
+        document.getElementById('profile-status').textContent = '加载失败: ' + err.message;
+    });
+}
+
+function fillProfile(p) {
+    Object.keys(profileFields).forEach(function(key) {
+        document.getElementById(profileFields[key]).value = p[key] || '';
+    });
+}
+
+function loadProfile() {
+    var owner = document.getElementById('profile-owner').value.trim();
+    if (!owner) return;
+    fetch('/api/profiles/' + encodeURIComponent(owner)).then(function(resp) {
+        if (!resp.ok) return responseError(resp);
+        return resp.json();
+    }).then(function(p) {
+        fillProfile(p);
+        document.getElementById('profile-status').textContent = owner + (p.updated && p.updated.indexOf('0001') !== 0 ? ' · 更新于 ' + new Date(p.updated).toLocaleString() : ' · 未设置');
+    }).catch(function(err) {
+        document.getElementById('profile-status').textContent = '加载失败: ' + err.message;
+    });
+}
+
+function profileRequest(method) {
+    var owner = document.getElementById('profile-owner').value.trim();
+    var status = document.getElementById('profile-status');
+    if (!owner) {
+        status.textContent = '请输入 channel:userID';
+        return;
+    }
+    var body = {};
+    Object.keys(profileFields).forEach(function(key) {
+        body[key] = document.getElementById(profileFields[key]).value;
+    });
+    fetch('/api/profiles/' + encodeURIComponent(owner), {
+        method: method,
+        headers: { 'Content-Type': 'application/json' },
+        body: method === 'PUT' ? JSON.stringify(body) : undefined
+    }).then(function(resp) {
+        if (!resp.ok) return responseError(resp);
+        if (method === 'DELETE') fillProfile({});
+        status.textContent = method === 'PUT' ? '已保存' : '已删除';
+        loadProfiles();
+    }).catch(function(err) {
+        status.textContent = '操作失败: ' + err.message;
+    });
+}
+
+function initTools() {
+    document.getElementById('tools-refresh').addEventListener('click', loadTools);
+    document.getElementById('language-save').addEventListener('click', saveLanguage);
+    document.getElementById('custom-api-add').addEventListener('click', addCustomAPI);
+}
+
+// responseError 把 {"error": {code, message, requestId}} 转为带 code 和 requestId 的 Error
+function responseError(resp) {
+    return resp.text().then(function(text) {
+        var body = null;
+        try { body = JSON.parse(text).error; } catch (e) { /* 非JSON响应 */ }
+        var err = new Error(body && body.message ? body.message : (text || resp.statusText));
+        if (body) {
+            err.code = body.code;
+            err.requestId = body.requestId;
+            if (body.requestId) err.message += ' #' + body.requestId;
+        }
+        throw err;
+    });
+}
+
+// apiRequest 发送JSON请求，非2xx时以响应文本作为错误
+function apiRequest(url, method, body) {
+    return fetch(url, {
+        method: method || 'GET',
+        headers: body ? { 'Content-Type': 'application/json' } : undefined,
+        body: body ? JSON.stringify(body) : undefined
+    }).then(function(resp) {
+        if (!resp.ok) return responseError(resp);
+        return resp.json();
+    });
+}
+
+function setToolsStatus(text) {
+    document.getElementById('tools-status').textContent = text;
+}
+
+function toolRow(name, desc, button) {
+    var row = document.createElement('div');
+    row.className = 'tool-row';
+    var n = document.createElement('span');
+    n.className = 'config-value';
+    n.textContent = name;
+    var d = document.createElement('span');
+    d.className = 'tool-desc';
+    d.textContent = desc || '';
+    row.appendChild(n);
+    row.appendChild(d);
+    if (button) row.appendChild(button);
+    return row;
+}
+
+function loadTools() {
+    apiRequest('/api/tools').then(function(tools) {
+        var list = document.getElementById('tool-list');
+        list.innerHTML = '';
+        tools.sort(function(a, b) { return a.name < b.name ? -1 : 1; });
+        tools.forEach(function(t) {
+            var btn = document.createElement('button');
+            btn.textContent = t.enabled ? '禁用' : '启用';
+            btn.addEventListener('click', function() { toggleTool(t.name, !t.enabled); });
+            var row = toolRow(t.name + (t.enabled ? '' : ' (已禁用)'), t.description, btn);
+            list.appendChild(row);
+        });
+        if (tools.length === 0) list.textContent = '暂无工具';
+    }).catch(function(err) { setToolsStatus('加载工具失败: ' + err.message); });
+    loadCustomAPIs();
+    apiRequest('/api/llm/presets').then(function(presets) {
+        var list = document.getElementById('llm-preset-list');
+        list.innerHTML = '';
+        Object.keys(presets || {}).sort().forEach(function(id) {
+            var p = presets[id];
+            list.appendChild(toolRow(p.name || id, p.baseURL + (p.models && p.models.length ? ' · ' + p.models.join(', ') : '')));
+        });
+        if (!list.children.length) list.textContent = '暂无预设';
+    }).catch(function(err) { setToolsStatus('加载预设失败: ' + err.message); });
+    apiRequest('/api/language').then(function(lang) {
+        var select = document.getElementById('language-select');
+        select.innerHTML = '';
+        var supported = lang.supported && lang.supported.length ? lang.supported : [lang.current || lang.default];
+        supported.forEach(function(code) {
+            var option = document.createElement('option');
+            option.value = code;
+            option.textContent = code;
+            select.appendChild(option);
+        });
+        select.value = lang.current || lang.default;
+    }).catch(function(err) { setToolsStatus('加载语言失败: ' + err.message); });
+}
+
+function toggleTool(name, enabled) {
+    apiRequest('/api/tools/toggle', 'POST', { name: name, enabled: enabled }).then(function() {
+        setToolsStatus(name + (enabled ? ' 已启用' : ' 已禁用'));
+        loadTools();
+    }).catch(function(err) { setToolsStatus('操作失败: ' + err.message); });
+}
+
+function loadCustomAPIs() {
+    apiRequest('/api/custom-apis').then(function(apis) {
+        var list = document.getElementById('custom-api-list');
+        list.innerHTML = '';
+        (apis || []).forEach(function(a) {
+            var btn = document.createElement('button');
+            btn.textContent = '删除';
+            btn.addEventListener('click', function() { deleteCustomAPI(a.name); });
+            list.appendChild(toolRow(a.name, (a.method || 'GET') + ' ' + a.url + (a.description ? ' · ' + a.description : ''), btn));
+        });
+        if (!list.children.length) list.textContent = '暂无自定义API';
+    }).catch(function(err) { setToolsStatus('加载自定义API失败: ' + err.message); });
+}
+
+function addCustomAPI() {
+    var api = {
+        name: document.getElementById('custom-api-name').value.trim(),
+        url: document.getElementById('custom-api-url').value.trim(),
+        method: document.getElementById('custom-api-method').value,
+        description: document.getElementById('custom-api-description').value.trim(),
+        apiKey: document.getElementById('custom-api-key').value,
+        enabled: true
+    };
+    if (!api.name || !api.url) {
+        setToolsStatus('请填写名称和URL');
+        return;
+    }
+    apiRequest('/api/custom-apis', 'POST', api).then(function() {
+        ['custom-api-name', 'custom-api-url', 'custom-api-description', 'custom-api-key'].forEach(function(id) {
+            document.getElementById(id).value = '';
+        });
+        setToolsStatus(api.name + ' 已添加');
+        loadCustomAPIs();
+    }).catch(function(err) { setToolsStatus('添加失败: ' + err.message); });
+}
+
+function deleteCustomAPI(name) {
+    if (!confirm('删除自定义API ' + name + '？')) return;
+    apiRequest('/api/custom-apis?name=' + encodeURIComponent(name), 'DELETE').then(function() {
+        setToolsStatus(name + ' 已删除');
+        loadCustomAPIs();
+    }).catch(function(err) { setToolsStatus('删除失败: ' + err.message); });
+}
+
+function saveLanguage() {
+    var language = document.getElementById('language-select').value;
+    apiRequest('/api/language', 'POST', { language: language }).then(function() {
+        setToolsStatus('语言已切换为 ' + language);
+    }).catch(function(err) { setToolsStatus('保存失败: ' + err.message); });
+}
+
+function connectEventStream() {
+    eventSource = new EventSource('/api/messages/stream');
+    eventSource.onopen = function() { updateStatus('connected'); };
+    eventSource.onmessage = function(event) {
+        var msg = JSON.parse(event.data);
+        if (msg.id) {
+            // 重连时服务端会重发最近的消息
+            if (msg.id <= lastMessageId) return;
+            lastMessageId = msg.id;
+            if (!oldestMessageId || msg.id < oldestMessageId) oldestMessageId = msg.id;
+        }
+        addMessageToLog(msg);
+    };
+    eventSource.onerror = function() {
+        updateStatus('disconnected');
+        setTimeout(connectEventStream, 3000);
+    };
+}
+
+function updateStatus(status) {
+    var indicator = document.getElementById('status');
+    if (status === 'connected') {
+        indicator.textContent = '● 已连接';
+        indicator.className = 'status-indicator connected';
+    } else {
+        indicator.textContent = '● 已断开';
+        indicator.className = 'status-indicator disconnected';
+    }
+}
+
+function loadStatus() {
+    fetch('/api/status').then(function(resp) { return resp.json(); }).then(function(data) {
+        document.getElementById('memory').textContent = formatBytes(data.memory.heap_alloc);
+        document.getElementById('goroutines').textContent = data.goroutines;
+        document.getElementById('sessions').textContent = data.sessions.total_sessions;
+        if (data.cpu) {
+            document.getElementById('cpu').textContent = data.cpu.usage_percent.toFixed(1) + '% (' + data.cpu.cores + ' cores)';
+            document.getElementById('load').textContent = data.cpu.load ? data.cpu.load.map(function(v) { return v.toFixed(2); }).join(' / ') : '-';
+            var temp = document.getElementById('temperature');
+            temp.textContent = data.cpu.temperature_c ? data.cpu.temperature_c.toFixed(1) + '°C' : '-';
+            temp.style.color = data.cpu.temperature_c >= 80 ? '#e74c3c' : '';
+        }
+    }).catch(function(err) { console.error('Failed to load status:', err); });
+}
+
+function loadConfig() {
+    fetch('/api/config').then(function(resp) { return resp.json(); }).then(function(data) {
+        var configHtml = '<div class="config-item"><span class="config-key">服务器端口:</span>' +
+            '<span class="config-value">' + data.server.port + '</span></div>' +
+            '<div class="config-item"><span class="config-key">LLM提供商:</span>' +
+            '<span class="config-value">' + data.llm.provider + '</span></div>' +
+            '<div class="config-item"><span class="config-key">模型:</span>' +
+            '<span class="config-value">' + data.llm.model + '</span></div>' +
+            '<div class="config-item"><span class="config-key">智能体数量:</span>' +
+            '<span class="config-value">' + data.agents + '</span></div>';
+        document.getElementById('config-info').innerHTML = configHtml;
+    }).catch(function(err) { console.error('Failed to load config:', err); });
+}
+
+function testLLMConnection() {
+    var btn = document.getElementById('llm-test-btn');
+    var result = document.getElementById('llm-test-result');
+    btn.disabled = true;
+    result.className = 'llm-test-result';
+    result.textContent = '测试中...';
+    fetch('/api/llm/test', { method: 'POST' }).then(function(resp) { return resp.json(); }).then(function(data) {
+        if (data.ok) {
+            result.className = 'llm-test-result ok';
+            result.textContent = '连接正常 (' + data.model + ', ' + data.latencyMs + 'ms)';
+        } else {
+            result.className = 'llm-test-result error';
+            result.textContent = '连接失败: ' + data.error;
+        }
+    }).catch(function(err) {
+        result.className = 'llm-test-result error';
+        result.textContent = '请求失败: ' + err;
+    }).then(function() { btn.disabled = false; });
+}
+
+function loadAgents() {
+    fetch('/api/agents').then(function(resp) { return resp.json(); }).then(function(data) {
+        agents = data;
+        var agentListHtml = agents.map(function(a) {
+            return '<div class="agent-item"><div class="agent-name">' + a.name + '</div>' +
+                '<div class="agent-model">' + a.model + '</div></div>';
+        }).join('');
+        document.getElementById('agent-list').innerHTML = agentListHtml || '<div class="agent-item">暂无智能体</div>';
+        var select = document.getElementById('agent-select');
+        select.innerHTML = '<option value="">默认智能体</option>';
+        agents.forEach(function(a) {
+            var option = document.createElement('option');
+            option.value = a.id;
+            option.textContent = a.name;
+            select.appendChild(option);
+        });
+    }).catch(function(err) { console.error('Failed to load agents:', err); });
+}
+
+function sendMessage() {
+    var input = document.getElementById('message-input');
+    var btn = document.getElementById('send-btn');
+    var agentSelect = document.getElementById('agent-select');
+    var message = input.value.trim();
+    if (!message) return;
+    btn.disabled = true;
+    input.value = '';
+    fetch('/api/send', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ message: message, agent_id: agentSelect.value, stream: true })
+    }).then(function(resp) {
+        if (!resp.ok) return responseError(resp);
+        return readSendStream(resp);
+    }).catch(function(err) {
+        console.error('Failed to send message:', err);
+        addMessageToLog({ type: 'error', time: new Date().toLocaleTimeString(), content: '发送失败: ' + err.message });
+    }).finally(function() { btn.disabled = false; });
+}
+
+// 流式输出的回复，按 request_id 索引；调试消息流中的最终回复到达时替换内容而不重复显示
+var streamingReplies = {};
+
+// readSendStream 解析 /api/send 的SSE响应，逐段渲染回复
+function readSendStream(resp) {
+    var reader = resp.body.getReader();
+    var decoder = new TextDecoder();
+    var buffer = '';
+    var requestId = '';
+    var reply = null;
+
+    function handle(event, data) {
+        if (event === 'start') {
+            requestId = data.request_id;
+        } else if (event === 'chunk') {
+            if (!reply) reply = startStreamingReply(requestId);
+            if (reply.done) return;
+            reply.content.textContent += data.content;
+            var log = document.getElementById('message-log');
+            log.scrollTop = log.scrollHeight;
+        } else if (event === 'done') {
+            if (!reply) reply = startStreamingReply(requestId);
+            finishStreamingReply(reply, data.response);
+        } else if (event === 'error') {
+            if (reply) finishStreamingReply(reply, reply.content.textContent);
+            throw new Error(data.error);
+        }
+    }
+
+    function pump() {
+        return reader.read().then(function(result) {
+            if (result.done) return;
+            buffer += decoder.decode(result.value, { stream: true });
+            var frames = buffer.split('\n\n');
+            buffer = frames.pop();
+            frames.forEach(function(frame) {
+                var event = 'message', data = '';
+                frame.split('\n').forEach(function(line) {
+                    if (line.indexOf('event: ') === 0) event = line.slice(7);
+                    else if (line.indexOf('data: ') === 0) data += line.slice(6);
+                });
+                if (data) handle(event, JSON.parse(data));
+            });
+            return pump();
+        });
+    }
+    return pump();
+}
+
+function startStreamingReply(requestId) {
+    addMessageToLog({ type: 'assistant', source: 'web', time: new Date().toLocaleTimeString(), content: '', request_id: requestId });
+    var log = document.getElementById('message-log');
+    var item = log.lastChild;
+    item.classList.add('streaming');
+    var reply = { requestId: requestId, item: item, content: item.querySelector('.message-content'), done: false };
+    if (requestId) streamingReplies[requestId] = reply;
+    return reply;
+}
+
+function finishStreamingReply(reply, content) {
+    reply.done = true;
+    reply.content.textContent = content;
+    reply.item.classList.remove('streaming');
+}
+
+// addMessageToLog 添加一条调试消息，container 为空时追加到消息区末尾并滚动到底部
+function addMessageToLog(msg, container) {
+    if (msg.type === 'tool_call' || msg.type === 'tool_result') {
+        addToolEvent(msg, container);
+        return;
+    }
+    if (msg.type === 'assistant' && !container && streamingReplies[msg.request_id]) {
+        finishStreamingReply(streamingReplies[msg.request_id], msg.content);
+        delete streamingReplies[msg.request_id];
+        return;
+    }
+    var log = container || document.getElementById('message-log');
+    var item = document.createElement('div');
+    item.className = 'message-item ' + msg.type;
+    var header = document.createElement('div');
+    header.className = 'message-header';
+    var userIdText = msg.user_id ? '(' + msg.user_id + ')' : '';
+    var requestIdText = msg.request_id ? '<span class="message-request-id" title="' + msg.request_id + '">#' + msg.request_id + '</span>' : '';
+    header.innerHTML = '<span>' + (msg.source || msg.type) + ' ' + userIdText + requestIdText + '</span><span>' + msg.time + '</span>';
+    var content = document.createElement('div');
+    content.className = 'message-content';
+    content.textContent = msg.content;
+    item.appendChild(header);
+    item.appendChild(content);
+    log.appendChild(item);
+    if (!container) log.scrollTop = log.scrollHeight;
+}
+
+// 已显示消息的ID范围，用于重连去重和加载更早的消息
+var lastMessageId = 0;
+var oldestMessageId = 0;
+
+function loadEarlierMessages() {
+    var btn = document.getElementById('load-earlier');
+    if (!oldestMessageId) return;
+    btn.disabled = true;
+    fetch('/api/logs?limit=50&before=' + oldestMessageId).then(function(resp) {
+        if (!resp.ok) return responseError(resp);
+        return resp.json();
+    }).then(function(result) {
+        var older = document.createElement('div');
+        // 结果按时间倒序
+        for (var i = result.entries.length - 1; i >= 0; i--) {
+            addMessageToLog(result.entries[i], older);
+        }
+        btn.parentNode.insertBefore(older, btn.nextSibling);
+        if (result.entries.length > 0) {
+            oldestMessageId = result.entries[result.entries.length - 1].id;
+        }
+        if (result.total <= result.entries.length) {
+            btn.textContent = '没有更早的消息';
+            return;
+        }
+        btn.disabled = false;
+    }).catch(function() { btn.disabled = false; });
+}
+
+// 每个请求的工具时间线，按 request_id 索引
+var toolTimelines = {};
+
+function formatDuration(ms) {
+    if (ms < 1000) return ms + 'ms';
+    return (ms / 1000).toFixed(1) + 's';
+}
+
+function toolTimeline(requestId, container) {
+    var tl = toolTimelines[requestId];
+    if (tl) return tl;
+    var el = document.createElement('details');
+    el.className = 'tool-timeline';
+    var summary = document.createElement('summary');
+    var steps = document.createElement('div');
+    el.appendChild(summary);
+    el.appendChild(steps);
+    (container || document.getElementById('message-log')).appendChild(el);
+    tl = { requestId: requestId, summary: summary, steps: steps, calls: {} };
+    toolTimelines[requestId] = tl;
+    return tl;
+}
+
+function toolStep(tl, msg) {
+    var call = tl.calls[msg.call_id];
+    if (call) return call;
+    var step = document.createElement('details');
+    step.className = 'tool-step running';
+    var head = document.createElement('summary');
+    var name = document.createElement('span');
+    name.className = 'tool-step-name';
+    name.textContent = msg.tool;
+    var bar = document.createElement('span');
+    bar.className = 'tool-step-bar';
+    var fill = document.createElement('span');
+    bar.appendChild(fill);
+    var duration = document.createElement('span');
+    duration.className = 'tool-step-duration';
+    duration.textContent = '...';
+    var time = document.createElement('span');
+    time.className = 'tool-step-time';
+    time.textContent = msg.time;
+    head.appendChild(name);
+    head.appendChild(bar);
+    head.appendChild(duration);
+    head.appendChild(time);
+    var args = document.createElement('pre');
+    var result = document.createElement('pre');
+    step.appendChild(head);
+    step.appendChild(args);
+    step.appendChild(result);
+    tl.steps.appendChild(step);
+    call = { name: msg.tool, step: step, fill: fill, duration: duration, args: args, result: result, ms: null };
+    tl.calls[msg.call_id] = call;
+    return call;
+}
+
+function addToolEvent(msg, container) {
+    var tl = toolTimeline(msg.request_id || '', container);
+    var call = toolStep(tl, msg);
+    if (msg.type === 'tool_call') {
+        call.args.textContent = '参数: ' + msg.content;
+    } else {
+        call.ms = msg.duration_ms || 0;
+        call.step.className = 'tool-step ' + (msg.error ? 'failed' : 'done');
+        call.duration.textContent = formatDuration(call.ms);
+        call.result.textContent = (msg.error ? '错误: ' : '结果: ') + msg.content;
+    }
+    updateToolTimeline(tl);
+    if (!container) {
+        var log = document.getElementById('message-log');
+        log.scrollTop = log.scrollHeight;
+    }
+}
+
+// updateToolTimeline 按最慢的调用缩放耗时条，并在摘要中标出最慢的工具
+function updateToolTimeline(tl) {
+    var count = 0, running = 0, total = 0, slowest = null;
+    var id;
+    for (id in tl.calls) {
+        var call = tl.calls[id];
+        count++;
+        if (call.ms === null) {
+            running++;
+        } else {
+            total += call.ms;
+            if (!slowest || call.ms > slowest.ms) slowest = call;
+        }
+    }
+    for (id in tl.calls) {
+        var c = tl.calls[id];
+        c.fill.style.width = (c.ms === null || !slowest || slowest.ms === 0) ? '0' : Math.max(2, c.ms / slowest.ms * 100) + '%';
+    }
+    var text = '工具调用 ' + count + ' 次，共 ' + formatDuration(total);
+    if (slowest && count > 1) text += '，最慢 ' + slowest.name + ' ' + formatDuration(slowest.ms);
+    if (running) text += '，' + running + ' 个执行中';
+    if (tl.requestId) text += ' #' + tl.requestId;
+    tl.summary.textContent = text;
+}
+
+function formatBytes(bytes) {
+    if (bytes === 0) return '0 B';
+    var k = 1024;
+    var sizes = ['B', 'KB', 'MB', 'GB'];
+    var i = Math.floor(Math.log(bytes) / Math.log(k));
+    return parseFloat((bytes / Math.pow(k, i)).toFixed(2)) + ' ' + sizes[i];
+}
+
+init();
@@ -0,0 +1,116 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/HaohanHe/mujibot/internal/agent"
+	"github.com/HaohanHe/mujibot/internal/httpapi"
+)
+
+// sseWriter 按事件名推送JSON数据，工具事件与内容片段可能来自不同协程
+type sseWriter struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+	// chunkBytes 内容片段攒够这么多字节再推送，0 时逐个推送
+	chunkBytes int
+	pending    strings.Builder
+}
+
+func (sw *sseWriter) send(event string, v interface{}) {
+	data, _ := json.Marshal(v)
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.flushChunk()
+	sw.write(event, data)
+}
+
+// chunk 推送内容片段，其他事件发出前先推送攒下的片段，保证顺序
+func (sw *sseWriter) chunk(content string) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.pending.WriteString(content)
+	if sw.pending.Len() >= sw.chunkBytes {
+		sw.flushChunk()
+	}
+}
+
+// flushChunk 调用方需持有锁
+func (sw *sseWriter) flushChunk() {
+	if sw.pending.Len() == 0 {
+		return
+	}
+	data, _ := json.Marshal(map[string]string{"content": sw.pending.String()})
+	sw.pending.Reset()
+	sw.write("chunk", data)
+}
+
+func (sw *sseWriter) write(event string, data []byte) {
+	fmt.Fprintf(sw.w, "event: %s\ndata: %s\n\n", event, data)
+	sw.flusher.Flush()
+}
+
+// streamMessage 以SSE返回智能体的流式回复：start、chunk（内容片段）、tool_call/tool_result（工具事件）、
+// 最后是 done（完整回复，护栏拦截时为替换后的内容）或 error
+func (s *Server) streamMessage(ctx context.Context, w http.ResponseWriter, a *agent.Agent, message, requestID string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		httpapi.RespondError(w, nil, http.StatusInternalServerError, httpapi.CodeInternal, "Streaming not supported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	sw := &sseWriter{w: w, flusher: flusher, chunkBytes: s.streamChunk}
+
+	sw.send("start", map[string]string{"request_id": requestID})
+	unwatch := s.watchRequest(requestID, func(msg DebugMessage) {
+		sw.send(msg.Type, msg)
+	})
+	defer unwatch()
+
+	response, err := s.agentRouter.ProcessMessageStream(ctx, a, "web_user", "web_user", "web", message, func(chunk string) {
+		sw.chunk(chunk)
+	})
+	if err != nil {
+		s.LogMessage("error", "web", err.Error(), "web_user", "web", requestID)
+		sw.send("error", map[string]string{"error": err.Error()})
+		return
+	}
+
+	s.LogMessage("assistant", "web", response, "web_user", "web", requestID)
+	sw.send("done", map[string]string{"response": response, "request_id": requestID})
+}
+
+// watchRequest 订阅某个请求的工具事件，返回取消订阅的函数
+func (s *Server) watchRequest(requestID string, fn func(DebugMessage)) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watchers == nil {
+		s.watchers = make(map[string]func(DebugMessage))
+	}
+	s.watchers[requestID] = fn
+	return func() {
+		s.mu.Lock()
+		delete(s.watchers, requestID)
+		s.mu.Unlock()
+	}
+}
+
+// notifyWatcher 把工具事件转发给订阅了该请求的流
+func (s *Server) notifyWatcher(msg DebugMessage) {
+	if msg.RequestID == "" {
+		return
+	}
+	s.mu.RLock()
+	fn := s.watchers[msg.RequestID]
+	s.mu.RUnlock()
+	if fn != nil {
+		fn(msg)
+	}
+}
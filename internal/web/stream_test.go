@@ -0,0 +1,112 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/agent"
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/llm"
+	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/session"
+	"github.com/HaohanHe/mujibot/internal/tools"
+)
+
+// streamProvider 第一轮流式输出并调用工具，第二轮给出最终回复
+type streamProvider struct {
+	calls int
+}
+
+func (p *streamProvider) Chat(messages []session.Message, tools []llm.Tool) (*llm.Response, error) {
+	return p.ChatStream(messages, tools, nil)
+}
+
+func (p *streamProvider) ChatStream(messages []session.Message, tools []llm.Tool, callback func(chunk string)) (*llm.Response, error) {
+	p.calls++
+	if p.calls == 1 {
+		callback("let me\ncheck")
+		tc := session.ToolCall{ID: "1", Type: "function"}
+		tc.Function.Name = "no_such_tool"
+		tc.Function.Arguments = "{}"
+		return &llm.Response{ToolCalls: []session.ToolCall{tc}}, nil
+	}
+	callback("done")
+	return &llm.Response{Content: "done"}, nil
+}
+
+func (p *streamProvider) GetModel() string {
+	return "test"
+}
+
+func TestSendMessageStream(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	t.Cleanup(func() { log.Close() })
+
+	toolMgr, err := tools.NewManager(tools.Config{WorkDir: t.TempDir()}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := session.NewManager(20, 3600, 10, log)
+	t.Cleanup(sessions.Close)
+	router := agent.NewRouter(log)
+	router.RegisterAgent("default", agent.CreateAgent("default", config.AgentConfig{Name: "default"}, &streamProvider{}, toolMgr, sessions, nil, nil, log))
+
+	s := &Server{agentRouter: router, log: log, maxMsgs: 100}
+	toolMgr.SetObserver(s.LogToolEvent)
+
+	req := httptest.NewRequest("POST", "/api/send", strings.NewReader(`{"message":"hi","agent_id":"default","stream":true}`))
+	rec := httptest.NewRecorder()
+	s.handleSendMessage(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status = %d, content type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	body := rec.Body.String()
+	want := []string{
+		"event: start\ndata: {\"request_id\":",
+		"event: chunk\ndata: {\"content\":\"let me\\ncheck\"}",
+		"event: tool_call\ndata: {",
+		"event: tool_result\ndata: {",
+		"event: chunk\ndata: {\"content\":\"done\"}",
+		"event: done\ndata: {\"request_id\":",
+	}
+	last := -1
+	for _, w := range want {
+		i := strings.Index(body, w)
+		if i < 0 {
+			t.Fatalf("missing %q in:\n%s", w, body)
+		}
+		if i < last {
+			t.Errorf("%q out of order in:\n%s", w, body)
+		}
+		last = i
+	}
+	if !strings.Contains(body, `"tool":"no_such_tool"`) || !strings.Contains(body, `"response":"done"`) {
+		t.Errorf("unexpected stream:\n%s", body)
+	}
+	if len(s.watchers) != 0 {
+		t.Errorf("watcher not removed")
+	}
+}
+
+func TestSSEWriterChunkBytes(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sw := &sseWriter{w: rec, flusher: rec, chunkBytes: 8}
+	sw.chunk("abc")
+	sw.chunk("def")
+	if rec.Body.Len() != 0 {
+		t.Fatalf("chunk sent before reaching chunkBytes: %q", rec.Body.String())
+	}
+	sw.chunk("gh")
+	sw.chunk("ij")
+	sw.send("done", map[string]string{"response": "abcdefghij"})
+
+	want := "event: chunk\ndata: {\"content\":\"abcdefgh\"}\n\n" +
+		"event: chunk\ndata: {\"content\":\"ij\"}\n\n" +
+		"event: done\ndata: {\"response\":\"abcdefghij\"}\n\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("stream = %q, want %q", got, want)
+	}
+}
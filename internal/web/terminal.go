@@ -0,0 +1,161 @@
+package web
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/httpapi"
+	"github.com/HaohanHe/mujibot/internal/tools"
+)
+
+const (
+	// terminalStreamInterval 合并输出推送的间隔，避免刷屏程序每个字节推送一次
+	terminalStreamInterval = 200 * time.Millisecond
+	// terminalStreamMaxChars 每次推送的输出上限，只保留末尾
+	terminalStreamMaxChars = 64 * 1024
+)
+
+// Terminal 终端会话API:
+// GET /api/terminal/sessions 会话列表，GET /api/terminal/sessions/{id}/stream 实时输出（SSE），
+// POST /api/terminal/sessions/{id}/input|cancel 输入和取消（需管理员令牌）
+func (h *ToolsHandler) Terminal(w http.ResponseWriter, r *http.Request) {
+	term := h.terminal()
+	if term == nil {
+		httpapi.RespondError(w, r, http.StatusServiceUnavailable, httpapi.CodeUnavailable, "Terminal not enabled")
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/terminal/sessions"), "/")
+	if path == "" {
+		if r.Method != http.MethodGet {
+			httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"sessions":   term.Sessions(),
+			"canControl": h.config.Get().Server.AdminToken != "",
+		})
+		return
+	}
+
+	id, action, _ := strings.Cut(path, "/")
+	switch {
+	case action == "stream" && r.Method == http.MethodGet:
+		h.streamTerminal(w, r, term, id)
+	case (action == "input" || action == "cancel") && r.Method == http.MethodPost:
+		if !h.isAdmin(r) {
+			httpapi.RespondError(w, r, http.StatusForbidden, httpapi.CodeForbidden, "admin token required")
+			return
+		}
+		var output string
+		var err error
+		if action == "input" {
+			var req struct {
+				Input string `json:"input"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "Invalid request")
+				return
+			}
+			output, err = term.Input("", id, req.Input)
+		} else {
+			output, err = term.Cancel(id)
+		}
+		if err != nil {
+			httpapi.RespondError(w, r, http.StatusConflict, httpapi.CodeConflict, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"output": output})
+	default:
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// terminal 返回已注册的终端工具，未启用时为nil
+func (h *ToolsHandler) terminal() *tools.TerminalTool {
+	tool, ok := h.tools.Get("terminal")
+	if !ok {
+		return nil
+	}
+	term, _ := tool.(*tools.TerminalTool)
+	return term
+}
+
+// isAdmin 校验 X-Admin-Token 或 Bearer 令牌，未配置 server.adminToken 时控制台只读
+func (h *ToolsHandler) isAdmin(r *http.Request) bool {
+	return adminRequest(h.config, r)
+}
+
+// adminRequest 请求是否带有正确的 server.adminToken，未配置令牌时总是 false
+func adminRequest(cfg *config.Manager, r *http.Request) bool {
+	want := cfg.Get().Server.AdminToken
+	if want == "" {
+		return false
+	}
+	got := r.Header.Get("X-Admin-Token")
+	if got == "" {
+		got = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+// streamTerminal 以SSE推送会话的完整输出，会话结束后发送 end 事件
+func (h *ToolsHandler) streamTerminal(w http.ResponseWriter, r *http.Request, term *tools.TerminalTool, id string) {
+	changes, stop, err := term.Watch(id)
+	if err != nil {
+		httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, err.Error())
+		return
+	}
+	defer stop()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher, _ := w.(http.Flusher)
+
+	// send 推送当前输出，会话已结束或已移除时返回 false
+	send := func() bool {
+		info, output, err := term.Snapshot(id)
+		if err == nil {
+			if runes := []rune(output); len(runes) > terminalStreamMaxChars {
+				output = string(runes[len(runes)-terminalStreamMaxChars:])
+			}
+			data, _ := json.Marshal(map[string]interface{}{"session": info, "output": output})
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+		running := err == nil && info.Running
+		if !running {
+			fmt.Fprint(w, "event: end\ndata: {}\n\n")
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return running
+	}
+
+	if !send() {
+		return
+	}
+	for {
+		select {
+		case <-changes:
+			select {
+			case <-time.After(terminalStreamInterval):
+			case <-r.Context().Done():
+				return
+			}
+			if !send() {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
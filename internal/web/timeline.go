@@ -0,0 +1,58 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/tools"
+)
+
+const (
+	// timelineArgsMaxChars 时间线中工具参数的字符上限
+	timelineArgsMaxChars = 1000
+	// timelineResultMaxChars 时间线中工具结果的字符上限
+	timelineResultMaxChars = 2000
+)
+
+// LogToolEvent 把工具调用事件记录为调试消息，控制台按 request_id 汇总成时间线
+func (s *Server) LogToolEvent(ctx context.Context, e tools.ToolEvent) {
+	msg := DebugMessage{
+		Time:      time.Now().Format("15:04:05"),
+		Type:      e.Type,
+		Source:    e.Tool,
+		RequestID: logger.RequestID(ctx),
+		Tool:      e.Tool,
+		CallID:    e.ID,
+	}
+	if c, ok := tools.CallerFrom(ctx); ok {
+		msg.UserID = c.UserID
+		msg.Channel = c.Channel
+	}
+
+	switch e.Type {
+	case tools.ToolCallEvent:
+		data, _ := json.Marshal(e.Args)
+		msg.Content = truncate(string(data), timelineArgsMaxChars)
+	case tools.ToolResultEvent:
+		msg.DurationMs = e.Duration.Milliseconds()
+		if e.Err != nil {
+			msg.Error = true
+			msg.Content = e.Err.Error()
+		} else {
+			msg.Content = truncate(e.Result, timelineResultMaxChars)
+		}
+	}
+	s.addMessage(msg)
+	s.notifyWatcher(msg)
+}
+
+// truncate 按字符截断，超出时加省略号
+func truncate(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen]) + "..."
+}
@@ -3,8 +3,12 @@ package web
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/httpapi"
+	"github.com/HaohanHe/mujibot/internal/i18n"
 	"github.com/HaohanHe/mujibot/internal/tools"
 )
 
@@ -20,9 +24,15 @@ func NewToolsHandler(cfg *config.Manager, toolMgr *tools.Manager) *ToolsHandler
 	}
 }
 
+// ListTools GET /api/tools 列出已注册和已禁用的工具
 func (h *ToolsHandler) ListTools(w http.ResponseWriter, r *http.Request) {
-	tools := h.tools.GetAll()
+	if r.Method != http.MethodGet {
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	all := h.tools.GetAll()
 	cfg := h.config.Get()
+	tr := i18n.New(cfg.Language.Current)
 
 	type ToolInfo struct {
 		Name        string                 `json:"name"`
@@ -32,10 +42,10 @@ func (h *ToolsHandler) ListTools(w http.ResponseWriter, r *http.Request) {
 	}
 
 	result := make([]ToolInfo, 0)
-	for _, tool := range tools {
+	for _, tool := range all {
 		info := ToolInfo{
 			Name:        tool.Name(),
-			Description: tool.Description(),
+			Description: tools.Describe(tr, cfg.Language.Current, tool),
 			Parameters:  tool.Parameters(),
 			Enabled:     true,
 		}
@@ -46,29 +56,40 @@ func (h *ToolsHandler) ListTools(w http.ResponseWriter, r *http.Request) {
 
 		result = append(result, info)
 	}
+	for _, tool := range h.tools.Disabled() {
+		result = append(result, ToolInfo{
+			Name:        tool.Name(),
+			Description: tools.Describe(tr, cfg.Language.Current, tool),
+			Parameters:  tool.Parameters(),
+			Enabled:     false,
+		})
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(result)
 }
 
+// ToggleTool POST /api/tools/toggle 启用或禁用工具，并写回配置
 func (h *ToolsHandler) ToggleTool(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
 	var req struct {
 		Name    string `json:"name"`
 		Enabled bool   `json:"enabled"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, err.Error())
 		return
 	}
 
-	cfg := h.config.Get()
-	if cfg.Tools.EnabledTools == nil {
-		cfg.Tools.EnabledTools = make(map[string]bool)
+	if err := h.tools.SetEnabled(req.Name, req.Enabled); err != nil {
+		httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, err.Error())
+		return
 	}
-	cfg.Tools.EnabledTools[req.Name] = req.Enabled
-
-	h.config.Update(cfg)
+	h.config.SetToolEnabled(req.Name, req.Enabled)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -78,23 +99,48 @@ func (h *ToolsHandler) ToggleTool(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ListCustomAPIs 列出自定义API，apiKey 只返回是否已设置
 func (h *ToolsHandler) ListCustomAPIs(w http.ResponseWriter, r *http.Request) {
 	cfg := h.config.Get()
+	apis := make([]config.CustomAPIConfig, len(cfg.Tools.CustomAPIs))
+	for i, api := range cfg.Tools.CustomAPIs {
+		if api.APIKey != "" {
+			api.APIKey = maskedAPIKey
+		}
+		apis[i] = api
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(cfg.Tools.CustomAPIs)
+	json.NewEncoder(w).Encode(apis)
 }
 
+// maskedAPIKey 列表中代替已设置的 apiKey，更新时原样提交表示保留原值
+const maskedAPIKey = "******"
+
 func (h *ToolsHandler) AddCustomAPI(w http.ResponseWriter, r *http.Request) {
 	var api config.CustomAPIConfig
 	if err := json.NewDecoder(r.Body).Decode(&api); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, err.Error())
 		return
 	}
 
-	cfg := h.config.Get()
-	cfg.Tools.CustomAPIs = append(cfg.Tools.CustomAPIs, api)
-	h.config.Update(cfg)
+	if api.Name == "" || api.URL == "" {
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "name and url required")
+		return
+	}
+
+	next := *h.config.Get()
+	for _, a := range next.Tools.CustomAPIs {
+		if a.Name == api.Name {
+			httpapi.RespondError(w, r, http.StatusConflict, httpapi.CodeConflict, "API already exists")
+			return
+		}
+	}
+	next.Tools.CustomAPIs = append(append([]config.CustomAPIConfig(nil), next.Tools.CustomAPIs...), api)
+	h.config.Update(&next)
 
+	if api.APIKey != "" {
+		api.APIKey = maskedAPIKey
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(api)
 }
@@ -102,52 +148,65 @@ func (h *ToolsHandler) AddCustomAPI(w http.ResponseWriter, r *http.Request) {
 func (h *ToolsHandler) UpdateCustomAPI(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("name")
 	if name == "" {
-		http.Error(w, "name required", http.StatusBadRequest)
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "name required")
 		return
 	}
 
 	var api config.CustomAPIConfig
 	if err := json.NewDecoder(r.Body).Decode(&api); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, err.Error())
 		return
 	}
 
-	cfg := h.config.Get()
-	for i, a := range cfg.Tools.CustomAPIs {
+	next := *h.config.Get()
+	for i, a := range next.Tools.CustomAPIs {
 		if a.Name == name {
-			cfg.Tools.CustomAPIs[i] = api
-			h.config.Update(cfg)
+			if api.APIKey == maskedAPIKey {
+				api.APIKey = a.APIKey
+			}
+			next.Tools.CustomAPIs = append([]config.CustomAPIConfig(nil), next.Tools.CustomAPIs...)
+			next.Tools.CustomAPIs[i] = api
+			h.config.Update(&next)
+			if api.APIKey != "" {
+				api.APIKey = maskedAPIKey
+			}
 			w.Header().Set("Content-Type", "application/json")
 			json.NewEncoder(w).Encode(api)
 			return
 		}
 	}
 
-	http.Error(w, "API not found", http.StatusNotFound)
+	httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, "API not found")
 }
 
 func (h *ToolsHandler) DeleteCustomAPI(w http.ResponseWriter, r *http.Request) {
 	name := r.URL.Query().Get("name")
 	if name == "" {
-		http.Error(w, "name required", http.StatusBadRequest)
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "name required")
 		return
 	}
 
-	cfg := h.config.Get()
-	for i, a := range cfg.Tools.CustomAPIs {
+	next := *h.config.Get()
+	for i, a := range next.Tools.CustomAPIs {
 		if a.Name == name {
-			cfg.Tools.CustomAPIs = append(cfg.Tools.CustomAPIs[:i], cfg.Tools.CustomAPIs[i+1:]...)
-			h.config.Update(cfg)
+			apis := append([]config.CustomAPIConfig(nil), next.Tools.CustomAPIs[:i]...)
+			next.Tools.CustomAPIs = append(apis, next.Tools.CustomAPIs[i+1:]...)
+			h.config.Update(&next)
 			w.Header().Set("Content-Type", "application/json")
 			json.NewEncoder(w).Encode(map[string]bool{"success": true})
 			return
 		}
 	}
 
-	http.Error(w, "API not found", http.StatusNotFound)
+	httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, "API not found")
 }
 
+// ListLLMPresets GET /api/llm/presets 列出LLM预设
 func (h *ToolsHandler) ListLLMPresets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
 	cfg := h.config.Get()
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(cfg.LLMPresets)
@@ -165,14 +224,76 @@ func (h *ToolsHandler) SetLanguage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, err.Error())
 		return
 	}
 
-	cfg := h.config.Get()
-	cfg.Language.Current = req.Language
-	h.config.Update(cfg)
+	next := *h.config.Get()
+	supported := len(next.Language.Supported) == 0
+	for _, lang := range next.Language.Supported {
+		if lang == req.Language {
+			supported = true
+		}
+	}
+	if req.Language == "" || !supported {
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "unsupported language: "+req.Language)
+		return
+	}
+	next.Language.Current = req.Language
+	h.config.Update(&next)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]bool{"success": true})
 }
+
+// Edits 文件编辑历史API: GET /api/edits 列表，GET /api/edits/{id} 详情，POST /api/edits/{id}/undo 撤销
+func (h *ToolsHandler) Edits(w http.ResponseWriter, r *http.Request) {
+	history := h.tools.History()
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/edits"), "/")
+
+	if path == "" {
+		if r.Method != http.MethodGet {
+			httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(history.List())
+		return
+	}
+
+	idStr, action, _ := strings.Cut(path, "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "invalid edit id")
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		entry, before, after, err := history.Get(id)
+		if err != nil {
+			httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"edit":   entry,
+			"before": string(before),
+			"after":  string(after),
+		})
+	case action == "undo" && r.Method == http.MethodPost:
+		args := map[string]interface{}{}
+		if r.URL.Query().Get("confirm") == "true" {
+			args = tools.WithApproval(args)
+		}
+		entry, err := history.Undo(id, r.URL.Query().Get("force") == "true", h.tools.UndoCheck(args))
+		if err != nil {
+			httpapi.RespondError(w, r, http.StatusConflict, httpapi.CodeConflict, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entry)
+	default:
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+	}
+}
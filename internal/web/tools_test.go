@@ -0,0 +1,90 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/logger"
+	"github.com/HaohanHe/mujibot/internal/tools"
+)
+
+func TestToolsRoutes(t *testing.T) {
+	log, _ := logger.New(logger.Config{Level: "error"})
+	t.Cleanup(func() { log.Close() })
+
+	dir := t.TempDir()
+	cfgData, _ := json.Marshal(map[string]interface{}{
+		"llm":      map[string]string{"provider": "ollama"},
+		"language": map[string]interface{}{"current": "zh", "supported": []string{"zh", "en"}},
+	})
+	configPath := filepath.Join(dir, "config.json5")
+	if err := os.WriteFile(configPath, cfgData, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.NewManager(configPath, log)
+	if err != nil {
+		t.Fatalf("config.NewManager: %v", err)
+	}
+	t.Cleanup(func() { cfg.Close() })
+	toolMgr, err := tools.NewManager(tools.Config{WorkDir: filepath.Join(dir, "work"), Timeout: 5}, log)
+	if err != nil {
+		t.Fatalf("tools.NewManager: %v", err)
+	}
+
+	s := &Server{config: cfg, log: log, toolsHandler: NewToolsHandler(cfg, toolMgr)}
+	mux := s.routes()
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodGet, "/api/tools", "", http.StatusOK},
+		{http.MethodPost, "/api/tools", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/tools/toggle", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/api/tools/toggle", `{"name":"no_such_tool","enabled":false}`, http.StatusNotFound},
+		{http.MethodGet, "/api/llm/presets", "", http.StatusOK},
+		{http.MethodDelete, "/api/llm/presets", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/api/custom-apis", `{"name":"weather"}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/custom-apis", `{"name":"weather","url":"https://example.com","apiKey":"secret"}`, http.StatusOK},
+		{http.MethodPost, "/api/custom-apis", `{"name":"weather","url":"https://example.com"}`, http.StatusConflict},
+		{http.MethodPut, "/api/custom-apis?name=weather", `{"name":"weather","url":"https://example.org","apiKey":"******"}`, http.StatusOK},
+		{http.MethodPatch, "/api/custom-apis", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/api/language", `{"language":"fr"}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/language", `{"language":"en"}`, http.StatusOK},
+		{http.MethodPut, "/api/language", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		if rec := do(tt.method, tt.path, tt.body); rec.Code != tt.status {
+			t.Errorf("%s %s = %d, want %d: %s", tt.method, tt.path, rec.Code, tt.status, rec.Body.String())
+		}
+	}
+
+	// 列表不返回 apiKey，更新时提交掩码保留原值
+	rec := do(http.MethodGet, "/api/custom-apis", "")
+	if strings.Contains(rec.Body.String(), "secret") || !strings.Contains(rec.Body.String(), maskedAPIKey) {
+		t.Errorf("custom apis = %s", rec.Body.String())
+	}
+	apis := cfg.Get().Tools.CustomAPIs
+	if len(apis) != 1 || apis[0].APIKey != "secret" || apis[0].URL != "https://example.org" {
+		t.Errorf("config custom apis = %+v", apis)
+	}
+	if cfg.Get().Language.Current != "en" {
+		t.Errorf("language = %q", cfg.Get().Language.Current)
+	}
+
+	if rec := do(http.MethodDelete, "/api/custom-apis?name=weather", ""); rec.Code != http.StatusOK || len(cfg.Get().Tools.CustomAPIs) != 0 {
+		t.Errorf("delete = %d, custom apis = %+v", rec.Code, cfg.Get().Tools.CustomAPIs)
+	}
+}
@@ -0,0 +1,118 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/HaohanHe/mujibot/internal/httpapi"
+	"github.com/HaohanHe/mujibot/internal/tools"
+)
+
+// PurgeReport 清除用户数据的结果，各项为删除的条数
+type PurgeReport struct {
+	User          string `json:"user"`
+	Sessions      int    `json:"sessions"`
+	Memory        int    `json:"memory"`        // 置顶、资料和上次对话摘要
+	Journal       int    `json:"journal"`       // 每日笔记中的对话摘要
+	Conversations int    `json:"conversations"` // 已保存的对话
+	Todos         int    `json:"todos"`
+	Contacts      int    `json:"contacts"`
+	Feeds         int    `json:"feeds"` // 订阅
+	Files         int    `json:"files"`
+	Edits         int    `json:"edits"` // 个人目录中文件的编辑历史快照
+	Audit         int    `json:"audit"` // 工具调用记录
+	DebugMessages int    `json:"debugMessages"`
+	Analytics     int    `json:"analytics"` // 对话分析中该用户的消息数
+	Outbox        int    `json:"outbox"`    // 发往该用户私聊的待重发消息
+}
+
+// PurgeFunc 清除用户的全部数据，by 记录操作者
+type PurgeFunc func(channel, userID, by string) (PurgeReport, error)
+
+// SetPurger 设置清除用户数据的函数，用于 DELETE /api/users/{channel:userID}/data
+func (s *Server) SetPurger(fn PurgeFunc) {
+	s.purger = fn
+}
+
+// DeleteUserMessages 删除用户的调试消息，返回删除的工具调用记录数和其他消息数。
+// 启用持久化时同时从存储中删除
+func (s *Server) DeleteUserMessages(channel, userID string) (audit, messages int, err error) {
+	match := func(msg DebugMessage) bool {
+		return msg.Channel == channel && msg.UserID == userID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var removed []DebugMessage
+	kept := s.messages[:0]
+	for _, msg := range s.messages {
+		if match(msg) {
+			removed = append(removed, msg)
+		} else {
+			kept = append(kept, msg)
+		}
+	}
+	s.messages = kept
+	if s.debugStore != nil {
+		// 存储中的消息包含内存中的全部消息
+		if removed, err = s.debugStore.Remove(match); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	for _, msg := range removed {
+		if msg.Type == tools.ToolCallEvent || msg.Type == tools.ToolResultEvent {
+			audit++
+		} else {
+			messages++
+		}
+	}
+	return audit, messages, nil
+}
+
+// handleUsers 处理用户数据API: DELETE /api/users/{channel:userID}/data?confirm=true 清除用户的全部数据，需要管理员令牌
+func (s *Server) handleUsers(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/users"), "/")
+	if !strings.HasSuffix(path, "/data") {
+		httpapi.RespondError(w, r, http.StatusNotFound, httpapi.CodeNotFound, "API not found")
+		return
+	}
+	owner, err := url.PathUnescape(strings.TrimSuffix(path, "/data"))
+	if err != nil {
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "Invalid user")
+		return
+	}
+	channel, userID, ok := strings.Cut(owner, ":")
+	if !ok || channel == "" || userID == "" {
+		httpapi.RespondError(w, r, http.StatusBadRequest, httpapi.CodeBadRequest, "User must be channel:userID")
+		return
+	}
+	if r.Method != http.MethodDelete {
+		httpapi.RespondError(w, r, http.StatusMethodNotAllowed, httpapi.CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !adminRequest(s.config, r) {
+		httpapi.RespondError(w, r, http.StatusForbidden, httpapi.CodeForbidden, "admin token required")
+		return
+	}
+	if s.purger == nil {
+		httpapi.RespondError(w, r, http.StatusServiceUnavailable, httpapi.CodeUnavailable, "Purge not available")
+		return
+	}
+	// 确认步骤：删除不可恢复，必须显式带上 confirm=true
+	if r.URL.Query().Get("confirm") != "true" {
+		httpapi.RespondError(w, r, http.StatusPreconditionRequired, httpapi.CodeConfirmRequired, "This permanently deletes all data of "+owner+"; repeat with ?confirm=true")
+		return
+	}
+
+	report, err := s.purger(channel, userID, webActor(r))
+	if err != nil {
+		httpapi.RespondError(w, r, http.StatusInternalServerError, httpapi.CodeInternal, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,271 @@
+// Package webhook 出站Webhook：把机器人活动以签名的JSON事件推送给外部自动化工具
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/httpclient"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+// 事件类型，与 config.WebhookEvents 一致
+const (
+	EventMessageReceived     = "message_received"
+	EventReplySent           = "reply_sent"
+	EventToolExecuted        = "tool_executed"
+	EventConfirmationPending = "confirmation_pending"
+	EventAlert               = "alert"
+)
+
+// 请求头
+const (
+	HeaderEvent     = "X-Mujibot-Event"
+	HeaderDelivery  = "X-Mujibot-Delivery"
+	HeaderTimestamp = "X-Mujibot-Timestamp"
+	HeaderSignature = "X-Mujibot-Signature"
+)
+
+const (
+	defaultTimeout   = 10 * time.Second
+	defaultQueueSize = 100
+	// maxAttempts 每个地址最多尝试次数，连接失败、5xx 和 429 时重试
+	maxAttempts = 3
+	// defaultRetryDelay 第一次重试前的等待时间，之后每次翻倍
+	defaultRetryDelay = 2 * time.Second
+)
+
+// Event 推送的事件，同一事件发往每个地址时 ID 相同，接收方可据此去重
+type Event struct {
+	ID    string      `json:"id"`
+	Event string      `json:"event"`
+	Time  time.Time   `json:"time"`
+	Data  interface{} `json:"data"`
+}
+
+// Dispatcher 事件排队后由后台协程逐个发送，Emit 不阻塞调用方。
+// 地址和密钥在发送时读取配置，修改 webhooks.endpoints 后立即生效
+type Dispatcher struct {
+	config     *config.Manager
+	log        *logger.Logger
+	retryDelay time.Duration
+
+	queue  chan Event
+	stopCh chan struct{}
+	once   sync.Once
+	wg     sync.WaitGroup
+}
+
+// New 创建分发器，队列大小在创建时确定
+func New(cfg *config.Manager, log *logger.Logger) *Dispatcher {
+	size := cfg.Get().Webhooks.QueueSize
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	return &Dispatcher{
+		config:     cfg,
+		log:        log,
+		retryDelay: defaultRetryDelay,
+		queue:      make(chan Event, size),
+		stopCh:     make(chan struct{}),
+	}
+}
+
+// Start 启动发送协程
+func (d *Dispatcher) Start() {
+	d.wg.Add(1)
+	go d.run()
+}
+
+// Stop 停止发送协程，队列中剩余的事件在一个超时时间内尝试发送一次
+func (d *Dispatcher) Stop() {
+	d.once.Do(func() { close(d.stopCh) })
+	d.wg.Wait()
+}
+
+// Emit 登记事件，没有地址订阅该事件时忽略，队列已满时丢弃
+func (d *Dispatcher) Emit(event string, data interface{}) {
+	if !d.subscribed(event) {
+		return
+	}
+	e := Event{ID: newID(), Event: event, Time: time.Now(), Data: data}
+	select {
+	case d.queue <- e:
+	default:
+		d.log.Warn("webhook queue full, event dropped", "event", event)
+	}
+}
+
+func (d *Dispatcher) subscribed(event string) bool {
+	for _, ep := range d.config.Get().Webhooks.Endpoints {
+		if wants(ep, event) {
+			return true
+		}
+	}
+	return false
+}
+
+func wants(ep config.WebhookEndpoint, event string) bool {
+	if len(ep.Events) == 0 {
+		return true
+	}
+	for _, e := range ep.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Dispatcher) run() {
+	defer d.wg.Done()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-d.stopCh
+		cancel()
+	}()
+
+	for {
+		select {
+		case e := <-d.queue:
+			d.deliver(ctx, e, maxAttempts)
+		case <-d.stopCh:
+			d.flush()
+			return
+		}
+	}
+}
+
+// flush 退出前把队列中剩余的事件各发送一次
+func (d *Dispatcher) flush() {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout())
+	defer cancel()
+	for {
+		select {
+		case e := <-d.queue:
+			d.deliver(ctx, e, 1)
+		default:
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) timeout() time.Duration {
+	if t := d.config.Get().Webhooks.Timeout; t > 0 {
+		return time.Duration(t) * time.Second
+	}
+	return defaultTimeout
+}
+
+// deliver 把事件发往每个订阅的地址
+func (d *Dispatcher) deliver(ctx context.Context, e Event, attempts int) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		d.log.Error("failed to encode webhook event", "event", e.Event, "error", err)
+		return
+	}
+	client := httpclient.New(d.timeout())
+	for _, ep := range d.config.Get().Webhooks.Endpoints {
+		if !wants(ep, e.Event) {
+			continue
+		}
+		err := d.post(ctx, client, ep, e, body, attempts)
+		if err != nil {
+			d.log.Warn("webhook delivery failed", "event", e.Event, "id", e.ID, "url", ep.URL, "error", err)
+		}
+	}
+}
+
+// post 发送一次事件，可重试的错误按退避重试
+func (d *Dispatcher) post(ctx context.Context, client *http.Client, ep config.WebhookEndpoint, e Event, body []byte, attempts int) error {
+	delay := d.retryDelay
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var retry bool
+		retry, err = d.send(ctx, client, ep, e, body)
+		if err == nil || !retry || attempt == attempts {
+			return err
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+		delay *= 2
+	}
+	return err
+}
+
+// send 返回是否值得重试
+func (d *Dispatcher) send(ctx context.Context, client *http.Client, ep config.WebhookEndpoint, e Event, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	// 每次尝试重新签名，时间戳不会因重试而过期
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Mujibot-Webhook")
+	req.Header.Set(HeaderEvent, e.Event)
+	req.Header.Set(HeaderDelivery, e.ID)
+	req.Header.Set(HeaderTimestamp, ts)
+	if ep.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(ep.Secret, ts, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
+
+// Sign 计算签名：sha256= 加上以 secret 为密钥对 "时间戳.请求体" 的 HMAC-SHA256 十六进制值
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验签名，接收方可用 maxAge 拒绝过旧的请求防止重放，0 表示不检查时间
+func Verify(secret, timestamp, signature string, body []byte, maxAge time.Duration) bool {
+	if maxAge > 0 {
+		sec, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return false
+		}
+		if age := time.Since(time.Unix(sec, 0)); age > maxAge || age < -maxAge {
+			return false
+		}
+	}
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
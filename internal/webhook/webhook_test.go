@@ -0,0 +1,154 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/HaohanHe/mujibot/internal/config"
+	"github.com/HaohanHe/mujibot/internal/logger"
+)
+
+type received struct {
+	path    string
+	event   Event
+	headers http.Header
+	body    []byte
+}
+
+func newTestDispatcher(t *testing.T, endpoints []config.WebhookEndpoint) *Dispatcher {
+	t.Helper()
+	log, _ := logger.New(logger.Config{Level: "error"})
+	t.Cleanup(func() { log.Close() })
+
+	// 缩进输出，URL 中的 // 不在最后一行，不会被当作注释去掉
+	data, _ := json.MarshalIndent(map[string]interface{}{
+		"llm":      map[string]string{"provider": "ollama"},
+		"webhooks": map[string]interface{}{"endpoints": endpoints},
+	}, "", "  ")
+	path := filepath.Join(t.TempDir(), "config.json5")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.NewManager(path, log)
+	if err != nil {
+		t.Fatalf("config.NewManager: %v", err)
+	}
+	t.Cleanup(func() { cfg.Close() })
+
+	d := New(cfg, log)
+	d.retryDelay = 10 * time.Millisecond
+	return d
+}
+
+func TestDispatcher(t *testing.T) {
+	var mu sync.Mutex
+	var got []received
+	failures := 1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		// /flaky 第一次返回 503，/reject 总是返回 400
+		switch {
+		case r.URL.Path == "/flaky" && failures > 0:
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		case r.URL.Path == "/reject":
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		var e Event
+		json.Unmarshal(body, &e)
+		got = append(got, received{path: r.URL.Path, event: e, headers: r.Header, body: body})
+	}))
+	defer srv.Close()
+
+	d := newTestDispatcher(t, []config.WebhookEndpoint{
+		{URL: srv.URL + "/all", Secret: "s3cret"},
+		{URL: srv.URL + "/flaky", Events: []string{EventAlert}},
+		{URL: srv.URL + "/reject", Events: []string{EventReplySent}},
+	})
+	d.Emit(EventToolExecuted, nil) // 只有 /all 订阅
+	d.Emit(EventMessageReceived, map[string]interface{}{"content": "hi"})
+	d.Emit(EventAlert, map[string]interface{}{"text": "disk full"})
+	d.Emit(EventReplySent, map[string]interface{}{"content": "hello"})
+	d.Start()
+	// 等到所有投递完成再停止，Stop 时队列中剩余的事件只尝试一次
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := len(got)
+		mu.Unlock()
+		if n >= 6 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	d.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	counts := map[string]int{}
+	for _, r := range got {
+		counts[r.path+" "+r.event.Event]++
+	}
+	want := map[string]int{
+		"/all tool_executed":    1,
+		"/all message_received": 1,
+		"/all alert":            1,
+		"/all reply_sent":       1,
+		"/flaky alert":          1, // 503 后重试成功
+		"/reject reply_sent":    1, // 400 不重试
+	}
+	if len(counts) != len(want) {
+		t.Errorf("deliveries = %v, want %v", counts, want)
+	}
+	for k, n := range want {
+		if counts[k] != n {
+			t.Errorf("%s delivered %d times, want %d", k, counts[k], n)
+		}
+	}
+
+	for _, r := range got {
+		if r.headers.Get(HeaderEvent) != r.event.Event || r.headers.Get(HeaderDelivery) != r.event.ID || r.event.ID == "" {
+			t.Errorf("%s: headers %v do not match event %+v", r.path, r.headers, r.event)
+		}
+		sig := r.headers.Get(HeaderSignature)
+		if r.path != "/all" {
+			if sig != "" {
+				t.Errorf("%s: unexpected signature without secret", r.path)
+			}
+			continue
+		}
+		if !Verify("s3cret", r.headers.Get(HeaderTimestamp), sig, r.body, time.Minute) {
+			t.Errorf("%s: invalid signature %q", r.path, sig)
+		}
+		if Verify("wrong", r.headers.Get(HeaderTimestamp), sig, r.body, time.Minute) {
+			t.Errorf("%s: signature verified with wrong secret", r.path)
+		}
+	}
+}
+
+func TestDispatcherIgnoresUnsubscribed(t *testing.T) {
+	d := newTestDispatcher(t, []config.WebhookEndpoint{{URL: "http://127.0.0.1:1/hook", Events: []string{EventAlert}}})
+	d.Emit(EventMessageReceived, nil)
+	if len(d.queue) != 0 {
+		t.Errorf("queued %d events without subscribers", len(d.queue))
+	}
+
+	body := []byte(`{}`)
+	stale := "1000000000"
+	if Verify("k", stale, Sign("k", stale, body), body, time.Minute) {
+		t.Error("stale timestamp accepted")
+	}
+	if !Verify("k", stale, Sign("k", stale, body), body, 0) {
+		t.Error("signature rejected without max age")
+	}
+}